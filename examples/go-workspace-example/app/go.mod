@@ -0,0 +1,5 @@
+module github.com/example/container-go-workspace/app
+
+go 1.23
+
+require github.com/example/container-go-workspace/lib v0.0.0
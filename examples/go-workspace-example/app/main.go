@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/example/container-go-workspace/lib"
+)
+
+func main() {
+	// Go to definition on Greet jumps into the lib module
+	fmt.Println(lib.Greet("container.nvim"))
+}
@@ -0,0 +1,3 @@
+module github.com/example/container-go-workspace/lib
+
+go 1.23
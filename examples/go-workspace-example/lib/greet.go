@@ -0,0 +1,9 @@
+// Package lib is used by the app module through go.work.
+package lib
+
+import "fmt"
+
+// Greet returns a greeting for name.
+func Greet(name string) string {
+	return fmt.Sprintf("Hello, %s!", name)
+}
@@ -0,0 +1,9 @@
+package lib
+
+import "testing"
+
+func TestGreet(t *testing.T) {
+	if got := Greet("gopls"); got != "Hello, gopls!" {
+		t.Errorf("Greet() = %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+-- lua/container/adhoc.lua
+-- Ad hoc containers started from an image without devcontainer.json (:ContainerStartImage)
+
+local M = {}
+
+-- Name of the configuration, used for the container name
+-- @param image string
+-- @return string
+function M.name(image)
+  return 'adhoc-' .. image:gsub('[^%w_.-]', '-')
+end
+
+-- Normalized configuration of an ad hoc container
+-- The workspace is bind mounted at opts.workspace_folder and, unless opts.keep is set, the container is
+-- created with --rm so that stopping it also removes it.
+-- @param image string: image to run, e.g. 'golang:1.22'
+-- @param workspace_root string: host folder mounted into the container
+-- @param opts table|nil: { workspace_folder, user, env, name, keep }
+-- @return table: normalized configuration
+function M.config(image, workspace_root, opts)
+  opts = opts or {}
+  local parser = require('container.parser')
+  local workspace_folder = opts.workspace_folder or parser.DEFAULT_WORKSPACE_FOLDER
+
+  local config = parser.normalize_for_plugin({
+    name = opts.name or M.name(image),
+    image = image,
+    workspaceFolder = workspace_folder,
+    remoteUser = opts.user,
+    containerEnv = opts.env,
+    -- Exec sessions keep the PATH of the image (e.g. /usr/local/go/bin) instead of the default preset
+    remoteEnv = { PATH = '${containerEnv:PATH}' },
+    runArgs = not opts.keep and { '--rm' } or nil,
+  })
+  config.workspace_mount = { type = 'bind', source = workspace_root, target = workspace_folder }
+  config.base_path = workspace_root
+  config.workspace_root = workspace_root
+  config.ephemeral = not opts.keep
+  return config
+end
+
+return M
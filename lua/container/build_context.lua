@@ -0,0 +1,263 @@
+-- lua/container/build_context.lua
+-- Build contexts as docker sends them: .dockerignore rules and the size of what is left
+-- The rules come from <Dockerfile>.dockerignore next to the Dockerfile, else from .dockerignore in the context. A rule
+-- excludes a path when it matches the path or one of its parent directories; "**" matches any number of
+-- directories, "!" re-includes and the last matching rule wins. Ignored files are left out of the image cache key,
+-- and builds report the size of the context, warning when it exceeds docker.context_warning_size.
+
+local M = {}
+
+local fs = require('container.utils.fs')
+local log = require('container.utils.log')
+
+-- Files measured at most; larger contexts are reported as "at least"
+M.MAX_FILES = 100000
+
+-- Split a path into its segments, dropping empty and "." segments
+local function segments(path)
+  local result = {}
+  for segment in path:gmatch('[^/]+') do
+    if segment ~= '.' then
+      table.insert(result, segment)
+    end
+  end
+  return result
+end
+
+-- Lua pattern of one glob segment: * and ? stay within the segment, [!a-z] is a negated class
+local function segment_pattern(glob)
+  local out = { '^' }
+  local i = 1
+  while i <= #glob do
+    local char = glob:sub(i, i)
+    if char == '*' then
+      table.insert(out, '[^/]*')
+    elseif char == '?' then
+      table.insert(out, '[^/]')
+    elseif char == '[' and glob:find(']', i + 1, true) then
+      local close = glob:find(']', i + 1, true)
+      local class = glob:sub(i + 1, close - 1):gsub('^!', '^')
+      table.insert(out, '[' .. class .. ']')
+      i = close
+    elseif char == '\\' and i < #glob then
+      i = i + 1
+      table.insert(out, (glob:sub(i, i):gsub('%p', '%%%0')))
+    else
+      table.insert(out, (char:gsub('%p', '%%%0')))
+    end
+    i = i + 1
+  end
+  table.insert(out, '$')
+  return table.concat(out)
+end
+
+-- Parse the content of a .dockerignore file
+-- @param content string
+-- @return table: rules { segments = { lua patterns or '**' }, negate = boolean }
+function M.parse(content)
+  local rules = {}
+  for line in (content or ''):gmatch('[^\r\n]+') do
+    line = vim.trim(line)
+    if line ~= '' and not line:match('^#') then
+      local negate = line:sub(1, 1) == '!'
+      if negate then
+        line = vim.trim(line:sub(2))
+      end
+      local parts = {}
+      for _, segment in ipairs(segments(line)) do
+        table.insert(parts, segment == '**' and '**' or segment_pattern(segment))
+      end
+      if #parts > 0 then
+        table.insert(rules, { segments = parts, negate = negate })
+      end
+    end
+  end
+  return rules
+end
+
+-- Match rule segments against the first `last` path segments from the given positions
+local function match_segments(rule, path, last, ri, pi)
+  if ri > #rule then
+    return pi > last
+  end
+  if rule[ri] == '**' then
+    for next_pi = pi, last + 1 do
+      if match_segments(rule, path, last, ri + 1, next_pi) then
+        return true
+      end
+    end
+    return false
+  end
+  if pi > last or not path[pi]:match(rule[ri]) then
+    return false
+  end
+  return match_segments(rule, path, last, ri + 1, pi + 1)
+end
+
+-- Check whether a rule matches a path or one of its parent directories
+local function rule_matches(rule, path)
+  for last = 1, #path do
+    if match_segments(rule.segments, path, last, 1, 1) then
+      return true
+    end
+  end
+  return false
+end
+
+-- Check whether a path is left out of the build context
+-- @param relative string: path relative to the context directory
+-- @param rules table: from parse()
+-- @return boolean
+function M.is_ignored(relative, rules)
+  local path = segments(relative)
+  local ignored = false
+  for _, rule in ipairs(rules or {}) do
+    if rule.negate == ignored and rule_matches(rule, path) then
+      ignored = not rule.negate
+    end
+  end
+  return ignored
+end
+
+-- Find the ignore file of a build
+-- @param context_dir string
+-- @param dockerfile string|nil: absolute path of the Dockerfile
+-- @return string|nil: path of the ignore file
+function M.find_ignore_file(context_dir, dockerfile)
+  if dockerfile and fs.is_file(dockerfile .. '.dockerignore') then
+    return dockerfile .. '.dockerignore'
+  end
+  local path = fs.join_path(context_dir, '.dockerignore')
+  return fs.is_file(path) and path or nil
+end
+
+-- Rules applying to a build context
+-- @param context_dir string
+-- @param dockerfile string|nil
+-- @return table: rules, empty without an ignore file
+-- @return string|nil: path of the ignore file
+function M.rules(context_dir, dockerfile)
+  local path = M.find_ignore_file(context_dir, dockerfile)
+  if not path then
+    return {}, nil
+  end
+  return M.parse(fs.read_file(path)), path
+end
+
+-- Size of a build context after the ignore rules
+-- Ignored directories are not descended into unless a "!" rule could re-include something below them.
+-- @param context_dir string
+-- @param rules table
+-- @return table: { size, files, truncated, largest = { { name, size } } } with the three largest top-level entries
+function M.measure(context_dir, rules)
+  local uv = vim.uv or vim.loop
+  local has_negations = false
+  for _, rule in ipairs(rules) do
+    has_negations = has_negations or rule.negate
+  end
+
+  local result = { size = 0, files = 0, truncated = false }
+  local top_level = {}
+
+  local function walk(dir, relative_dir, top)
+    local handle = uv.fs_scandir(dir)
+    while handle and not result.truncated do
+      local name, kind = uv.fs_scandir_next(handle)
+      if not name then
+        break
+      end
+      local relative = relative_dir and (relative_dir .. '/' .. name) or name
+      local path = dir .. '/' .. name
+      local ignored = M.is_ignored(relative, rules)
+      if kind == 'directory' then
+        if not ignored or has_negations then
+          walk(path, relative, top or name)
+        end
+      elseif not ignored then
+        local stat = kind == 'file' and uv.fs_stat(path)
+        local size = stat and stat.size or 0
+        result.size = result.size + size
+        result.files = result.files + 1
+        top_level[top or name] = (top_level[top or name] or 0) + size
+        result.truncated = result.files >= M.MAX_FILES
+      end
+    end
+  end
+  walk(context_dir, nil, nil)
+
+  result.largest = {}
+  for name, size in pairs(top_level) do
+    table.insert(result.largest, { name = name, size = size })
+  end
+  table.sort(result.largest, function(a, b)
+    return a.size > b.size
+  end)
+  for i = #result.largest, 4, -1 do
+    result.largest[i] = nil
+  end
+  return result
+end
+
+-- Human-readable size: "512 B", "3.4 MB", "1.2 GB"
+function M.format_size(bytes)
+  local units = { 'B', 'KB', 'MB', 'GB', 'TB' }
+  local unit = 1
+  while bytes >= 1024 and unit < #units do
+    bytes = bytes / 1024
+    unit = unit + 1
+  end
+  return unit == 1 and string.format('%d B', bytes) or string.format('%.1f %s', bytes, units[unit])
+end
+
+-- Size above which a build context is warned about (docker.context_warning_size, in MB; 0 disables)
+local function warning_size()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local megabytes = ok and plugin_config.get_value('docker.context_warning_size')
+  return (megabytes or 500) * 1024 * 1024
+end
+
+-- Report the size of a build context in the build output, warning when it is too large
+-- @param context_dir string
+-- @param dockerfile string|nil
+-- @param on_progress function|nil: receives the report lines
+-- @param label string|nil: e.g. the compose service
+-- @return table: measurement from measure()
+function M.report(context_dir, dockerfile, on_progress, label)
+  local rules, ignore_file = M.rules(context_dir, dockerfile)
+  local measured = M.measure(context_dir, rules)
+  local size = (measured.truncated and 'at least ' or '') .. M.format_size(measured.size)
+  local line = string.format(
+    'Build context%s: %s in %d files (%s)',
+    label and (' of ' .. label) or '',
+    size,
+    measured.files,
+    ignore_file and (fs.basename(ignore_file) .. ' applied') or 'no .dockerignore'
+  )
+  log.info(line)
+  if on_progress then
+    on_progress(line)
+  end
+
+  local threshold = warning_size()
+  if threshold > 0 and measured.size > threshold then
+    local largest = {}
+    for _, entry in ipairs(measured.largest) do
+      table.insert(largest, string.format('%s (%s)', entry.name, M.format_size(entry.size)))
+    end
+    local warning = string.format(
+      'Build context%s is %s; %s to leave out what the image does not need. Largest: %s',
+      label and (' of ' .. label) or '',
+      size,
+      ignore_file and ('extend ' .. fs.basename(ignore_file)) or 'add a .dockerignore',
+      table.concat(largest, ', ')
+    )
+    log.warn(warning)
+    require('container.utils.notify').warn(warning)
+    if on_progress then
+      on_progress('WARNING: ' .. warning)
+    end
+  end
+  return measured
+end
+
+return M
@@ -0,0 +1,136 @@
+-- lua/container/completion.lua
+-- Command-line completion of container names, compose services, images and ports
+-- The candidates come from docker and the configuration when <Tab> is pressed; failures complete nothing.
+
+local M = {}
+
+-- Keep the candidates starting with the typed text, without duplicates
+-- @param candidates table: list of strings
+-- @param arg_lead string
+-- @return table
+function M.filter(candidates, arg_lead)
+  local result, seen = {}, {}
+  for _, candidate in ipairs(candidates) do
+    if not seen[candidate] and candidate:find(arg_lead or '', 1, true) == 1 then
+      seen[candidate] = true
+      table.insert(result, candidate)
+    end
+  end
+  return result
+end
+
+-- Services of the compose devcontainer
+function M.services()
+  local ok, services = pcall(function()
+    return require('container.logs').list_services()
+  end)
+  return ok and services or {}
+end
+
+-- Local images (repository:tag)
+function M.images()
+  return vim.tbl_map(function(image)
+    return image.name
+  end, require('container.docker').list_images())
+end
+
+-- Names of the running containers
+function M.containers()
+  local names = {}
+  for _, container in ipairs(require('container.docker').list_containers()) do
+    if container.status:match('^Up') then
+      table.insert(names, container.name)
+    end
+  end
+  table.sort(names)
+  return names
+end
+
+-- Container ports that can be forwarded: forwardPorts of the configuration and ports the image exposes
+-- Ports already forwarded are left out.
+-- @return table: port numbers as strings, sorted numerically
+function M.ports()
+  local container = require('container')
+  local state = container.get_state()
+  if not state.current_container then
+    return {}
+  end
+  local forwarded = {}
+  for _, forward in ipairs(state.port_forwards or {}) do
+    if not forward.service then
+      forwarded[forward.container_port] = true
+    end
+  end
+
+  local ports = {}
+  local function add(port)
+    port = tonumber(port)
+    if port and not forwarded[port] and not vim.tbl_contains(ports, port) then
+      table.insert(ports, port)
+    end
+  end
+  for _, entry in ipairs(state.current_config and state.current_config.ports or {}) do
+    if not entry.service then
+      add(entry.container_port)
+    end
+  end
+  local info = require('container.docker').get_container_info(state.current_container)
+  for spec in pairs(info and info.Config and info.Config.ExposedPorts or {}) do
+    add(spec:match('^(%d+)/tcp$'))
+  end
+  table.sort(ports)
+  return vim.tbl_map(tostring, ports)
+end
+
+-- :ContainerLogs [service] [--since=] [--tail=] [--no-follow]
+function M.logs(arg_lead)
+  if arg_lead:match('^%-') then
+    return M.filter({ '--since=', '--tail=', '--no-follow' }, arg_lead)
+  end
+  return M.filter(M.services(), arg_lead)
+end
+
+-- :ContainerExec [--service=<name>] {command}
+-- Only the option and its service are completed; the command is free text.
+function M.exec(arg_lead, cmd_line)
+  local before = cmd_line:match('^%s*%S+%s+(.*)$') or ''
+  before = before:sub(1, #before - #arg_lead)
+  if before ~= '' and before ~= '--service ' then
+    return {}
+  end
+  local service = arg_lead:match('^%-%-service=(.*)$')
+  if service then
+    return vim.tbl_map(function(name)
+      return '--service=' .. name
+    end, M.filter(M.services(), service))
+  end
+  if before == '--service ' then
+    return M.filter(M.services(), arg_lead)
+  end
+  if arg_lead:match('^%-') and #M.services() > 0 then
+    return M.filter({ '--service=' }, arg_lead)
+  end
+  return {}
+end
+
+-- :ContainerForward {container_port} [host_port]
+function M.forward(arg_lead, cmd_line)
+  local args = vim.split(vim.trim(cmd_line), '%s+')
+  -- Only the container port is completed (the first argument)
+  if #args > 2 or (#args == 2 and arg_lead == '') then
+    return {}
+  end
+  return M.filter(M.ports(), arg_lead)
+end
+
+-- :ContainerAttach [name]
+function M.attach(arg_lead)
+  return M.filter(M.containers(), arg_lead)
+end
+
+-- :ContainerStartImage {image}
+function M.start_image(arg_lead)
+  return M.filter(M.images(), arg_lead)
+end
+
+return M
@@ -27,8 +27,51 @@ M.defaults = {
   -- Basic settings
   auto_open = 'immediate', -- 'immediate', 'off' - behavior when devcontainer.json is detected
   auto_open_delay = 2000, -- milliseconds to wait before auto-open
-  log_level = 'info',
-  container_runtime = 'docker', -- 'docker' or 'podman'
+  log_level = 'info', -- 'trace', 'debug', 'info', 'warn' or 'error'
+  log_buffer_size = 2000, -- Log entries kept for :ContainerLog (the oldest are dropped)
+  container_runtime = 'docker', -- 'docker', 'podman' or 'auto'
+  container_name_template = nil, -- e.g. '{project}-{branch}'; tokens: {name}, {project}, {branch}, {hash}
+  labels = {}, -- Extra labels added to created containers
+  default_build_args = {}, -- docker build flags of every image build, before build.* (e.g. { '--pull' })
+  default_run_args = {}, -- docker run flags of every container, before runArgs (e.g. { '--network=host' })
+  additional_mounts = {}, -- Host folders bind mounted besides devcontainer.json mounts: { source, target, readonly }
+  -- More workspace folders of a monorepo, mounted and path-mapped: { host, container, name, readonly }
+  workspace_folders = {},
+  -- Clone a repository into a named volume mounted at workspaceFolder instead of bind mounting the project folder
+  workspace_clone = {
+    repository = nil, -- Clone URL, e.g. 'https://github.com/me/app.git'; the project folder is mounted when unset
+    branch = nil, -- Branch to check out (the default branch when unset)
+    volume = nil, -- Volume name (default: <repository>-<hash>-workspace)
+  },
+  env_files = {}, -- Environment files (.env) loaded into the container and exec sessions
+  cache_go_modules = false, -- Mount shared volumes at the Go module and build caches (per workspace and Go version)
+  sync_check = {
+    timeout = 5000, -- Milliseconds :ContainerSyncCheck waits for a host change to appear in the container
+    warn_latency = 500, -- Milliseconds above which the workspace mount is reported as slow
+  },
+  detach = {
+    stop_container = false, -- :ContainerDetach stops the container instead of leaving it running
+  },
+  prune = {
+    max_age = 30, -- Days after which :ContainerPrune --all removes cached images
+  },
+  shutdown_action = 'none', -- Exit action without shutdownAction: 'none', 'stopContainer' or 'stopCompose'
+  host_requirements = {
+    mode = 'soft', -- Host short of hostRequirements cpus/memory/storage: 'soft' warns, 'hard' fails the start
+    limits = true, -- Limit the container to the required cpus and memory (--cpus, --memory)
+  },
+  -- Secrets for exec sessions and builds, kept out of files and logs: NAME = 'HOST_VAR', function() or
+  -- { env = 'HOST_VAR', value = function, build = true } (build = true also passes --secret id=NAME to builds)
+  secrets = {},
+  -- devcontainer-lock.json next to devcontainer.json: 'read' pins features to it (and offers to update it when it is
+  -- out of date), 'write' also creates and updates it whenever features are resolved, 'off' ignores it
+  features_lock = 'read',
+  -- Saving devcontainer.json, the Dockerfile, compose files or local features of the open configuration
+  watch_config = {
+    enabled = false,
+    action = 'notify', -- 'notify' that a rebuild is needed, 'prompt' to run :ContainerRebuild or 'rebuild' right away
+    debounce = 500, -- Milliseconds to collect saves before acting on them
+  },
 
   -- devcontainer settings
   devcontainer_path = '.devcontainer',
@@ -49,12 +92,32 @@ M.defaults = {
     port_range = { 8000, 9000 },
     servers = {}, -- Server-specific configurations
     on_attach = nil, -- Custom on_attach function
+    -- Additional host -> container path mappings for LSP URIs
+    -- The devcontainer workspaceFolder and bind mounts are mapped automatically
+    -- e.g. { ['~/go/pkg/mod'] = '/go/pkg/mod' }
+    path_mappings = {},
+    -- VS Code extension IDs (customizations.vscode.extensions) mapped to the LSP server to set up,
+    -- added to the built-in mappings; map an ID to false to ignore it
+    -- e.g. { ['denoland.vscode-deno'] = 'denols' }
+    vscode_extensions = {},
+    -- Root patterns per filetype, looked up in the container from the buffer's directory upward
+    -- (default: the patterns of the language, e.g. go.mod for Go); e.g. { typescript = { 'package.json' } }
+    root_patterns = {},
+    -- On changes of go.mod, go.sum or go.work: 'restart' gopls, 'notify' it (workspace/didChangeWatchedFiles) or 'off'
+    go_mod_change = 'restart',
+    go_mod_debounce = 1000, -- Milliseconds to collect changes before acting on them
+    request_timeout = 30000, -- Milliseconds before an unanswered request is cancelled (0: never)
+    watchdog_interval = 10000, -- Milliseconds between checks that the server processes and containers run (0: off)
+    max_timeouts = 3, -- Requests in a row that time out before a server is considered dead
+    auto_restart = false, -- Start a server again after it stopped unexpectedly
   },
 
   -- Terminal settings
   terminal = {
     -- Default shell and behavior
     default_shell = '/bin/sh', -- Use POSIX sh as fallback
+    shell = nil, -- Shell of :ContainerShell (default: login shell of remoteUser from /etc/passwd)
+    cwd = nil, -- Working directory (default: workspaceFolder); e.g. '${containerEnv:PROJECT_DIR}'
     auto_insert = true, -- Automatically enter insert mode
     close_on_exit = true, -- Close buffer when process exits
     close_on_container_stop = true, -- Close all terminals when container stops
@@ -65,7 +128,7 @@ M.defaults = {
     history_dir = (vim.fn and vim.fn.stdpath and vim.fn.stdpath('data') or '/tmp') .. '/container/terminal_history',
 
     -- Default positioning
-    default_position = 'split', -- 'split', 'tab', 'float'
+    default_position = 'split', -- 'split', 'vsplit', 'tab', 'float'
 
     -- Custom split command for positioning and sizing
     -- Examples: 'botright', 'topleft', 'rightbelow', 'leftabove'
@@ -107,6 +170,10 @@ M.defaults = {
     show_notifications = true,
     notification_level = 'normal', -- 'verbose', 'normal', 'minimal', 'silent'
     status_line = true,
+    build_window = true, -- Follow image builds in a floating window (q closes it, the build continues)
+    -- Progress of builds, pulls and test runs: 'notify', 'fidget', 'none' (results only) or function(event)
+    progress = 'notify',
+    stats_interval = 2000, -- Milliseconds between :ContainerStats refreshes
     icons = {
       container = '🐳',
       running = '🚀',
@@ -148,6 +215,7 @@ M.defaults = {
       node = 9229, -- Default port for Node.js debugger
       java = 5005, -- Default port for Java debugger
     },
+    attach_image = nil, -- Image with dlv for a sidecar attaching to processes (default: dlv in the container)
     path_mappings = {
       -- Default container workspace path (fallback if auto-detection fails)
       container_workspace = '/workspace',
@@ -168,6 +236,9 @@ M.defaults = {
     port_range_start = 10000,
     port_range_end = 20000,
     conflict_resolution = 'auto', -- 'auto', 'prompt', 'error'
+    forwarder_image = 'alpine/socat', -- Sidecar image used by :ContainerForward on running containers
+    forward_ports = 'create', -- 'create' (publish forwardPorts with docker run -p) or 'start' (forward once running)
+    open_browser = nil, -- function(url, port) opening ports with onAutoForward "openBrowser" (default: vim.ui.open)
   },
 
   -- Docker settings
@@ -177,6 +248,22 @@ M.defaults = {
     privileged = false,
     init = true,
     remove_orphans = true,
+    build_progress = 'buildkit', -- 'buildkit' (stage progress with BuildKit) or 'plain' (classic builder output)
+    health_timeout = 120, -- Seconds to wait for a HEALTHCHECK to pass before giving up (0 disables waiting)
+    stop_timeout = 10, -- Seconds between SIGTERM and SIGKILL when stopping a container
+    context_warning_size = 500, -- MB of build context (after .dockerignore) above which builds warn (0 disables)
+    sync_on_save = true, -- Copy saved files into the container when DOCKER_HOST is a remote daemon
+    pull_cache_from = true, -- Pull build.cacheFrom images and use them instead of building when they match
+    retries = 3, -- Retries of pulls, network/volume creation and starts failing transiently (0 disables)
+    retry_delay = 1000, -- Milliseconds before the first retry, doubled for each next one
+  },
+
+  -- Registry login before pulling images (password from password_env or password_command)
+  registry = {
+    server = nil, -- e.g. 'ghcr.io' (Docker Hub: 'docker.io')
+    username = nil,
+    password_env = nil, -- Environment variable holding the password or token
+    password_command = nil, -- Shell command printing the password or token
   },
 
   -- Test integration settings
@@ -184,6 +271,63 @@ M.defaults = {
     enabled = true, -- Enable automatic test plugin integration
     auto_setup = true, -- Automatically setup when container starts
     output_mode = 'buffer', -- 'buffer' (default), 'terminal', 'quickfix'
+    coverage = false, -- Collect go test coverage with :ContainerTest and show it as signs
+    default_runner = 'go', -- Runner of :ContainerTest in buffers whose filetype has none
+    runners = {}, -- Test runners by filetype, overriding the built-ins (go, python, javascript, typescript)
+  },
+
+  -- Formatting with formatters installed in the container
+  run_file = {
+    -- Shell command per filetype for :ContainerRunFile; {file}, {dir} and {name} are the container path, folder
+    -- and name of the file, and the command runs in that folder
+    commands = {
+      go = 'go run .',
+      python = 'python3 {file}',
+      sh = 'sh {file}',
+      bash = 'bash {file}',
+      javascript = 'node {file}',
+      typescript = 'npx --yes tsx {file}',
+      ruby = 'ruby {file}',
+      lua = 'lua {file}',
+    },
+  },
+
+  -- Named tasks of :ContainerTask: a shell command, or { cmd, cwd, env, parser } (parser: 'go', 'gcc', 'tsc',
+  -- 'python' or { locations = { ... } } with Lua patterns capturing file, line, column and message)
+  -- e.g. { test = 'go test ./...', lint = { cmd = 'golangci-lint run', parser = 'go' } }
+  tasks = {},
+
+  -- Keep-alive: ping the container and reconnect terminals and LSP clients whose connections were lost
+  -- (e.g. while the machine slept)
+  heartbeat = {
+    enabled = true,
+    interval = 30000, -- Milliseconds between pings (docker exec <container> true)
+    timeout = 5000, -- Milliseconds before a ping counts as failed
+  },
+
+  -- Cross-builds of :ContainerGoBuild with the Go toolchain of the container
+  go_build = {
+    targets = { 'linux/amd64' }, -- GOOS/GOARCH tuples, e.g. { 'linux/amd64', 'darwin/arm64', 'windows/amd64' }
+    packages = { '.' }, -- Packages to build, relative to the Go module of the current file
+    args = {}, -- Extra go build arguments, e.g. { '-trimpath', '-ldflags=-s -w' }
+    output_dir = 'dist', -- Host directory receiving <goos>_<goarch>/ artifact folders (relative to the workspace)
+    cgo = false, -- CGO_ENABLED=1 instead of 0
+  },
+
+  -- golangci-lint of :ContainerLint
+  lint = {
+    cmd = 'golangci-lint', -- Linter executable in the container
+    args = { 'run', '--out-format=json' }, -- Must print the JSON report on stdout (v2: '--output.json.path=stdout')
+    diagnostics = false, -- Also show the issues as vim.diagnostic entries
+    install_package = 'github.com/golangci/golangci-lint/cmd/golangci-lint@latest', -- Offered when missing ('' never)
+  },
+
+  format = {
+    on_save = false, -- Format buffers on BufWritePre
+    timeout = 3000, -- Milliseconds to wait for the formatter
+    formatters = {
+      go = { 'goimports', 'gofmt' }, -- Per filetype; the first one installed in the container is used
+    },
   },
 
   -- Development settings
@@ -227,9 +371,24 @@ local function validate_config(config)
   return errors
 end
 
+-- Options of setup() and the project configuration that the plugin does not know
+local function find_unknown_options(...)
+  local v = get_validator()
+  local unknown = {}
+  for _, options in ipairs({ ... }) do
+    for _, path in ipairs(v.unknown_options and v.unknown_options(options, M.defaults) or {}) do
+      table.insert(unknown, path)
+    end
+  end
+  return unknown
+end
+
 -- Configuration setup
+-- @return boolean, table, table: true with the configuration and the unknown options (reported, not fatal), or
+--   false with the list of invalid options
 function M.setup(user_config)
   user_config = user_config or {}
+  local project_options = nil
 
   -- Copy default configuration
   current_config = deep_copy(M.defaults)
@@ -265,6 +424,7 @@ function M.setup(user_config)
         log.info('Loading project configuration from %s', project_config_path)
       end
       merge_config(current_config, project_config)
+      project_options = project_config
     elseif not ok then
       if log then
         log.warn('Failed to load project configuration: %s', tostring(project_config))
@@ -283,13 +443,23 @@ function M.setup(user_config)
     return false, errors
   end
 
+  local unknown = find_unknown_options(user_config, project_options)
+  for _, path in ipairs(unknown) do
+    if log then
+      log.warn('Unknown configuration option: %s', path)
+    end
+  end
+
   -- Set log level if log is available
   if log and log.set_level then
     log.set_level(current_config.log_level)
+    if log.set_max_entries then
+      log.set_max_entries(current_config.log_buffer_size)
+    end
     log.debug('Configuration loaded successfully')
   end
 
-  return true, current_config
+  return true, current_config, unknown
 end
 
 -- Get current configuration
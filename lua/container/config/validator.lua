@@ -164,8 +164,95 @@ M.schema = {
   -- Basic settings
   auto_open = validators.enum({ 'immediate', 'off' }),
   auto_open_delay = validators.all(validators.type('number'), validators.range(0, 60000)),
-  log_level = validators.enum({ 'debug', 'info', 'warn', 'error' }),
-  container_runtime = validators.enum({ 'docker', 'podman' }),
+  log_level = validators.enum({ 'trace', 'debug', 'info', 'warn', 'error' }),
+  log_buffer_size = validators.all(validators.type('number'), validators.range(100, 100000)),
+  container_runtime = validators.enum({ 'docker', 'podman', 'auto' }),
+  container_name_template = validators.optional(validators.type('string')),
+  labels = validators.type('table'),
+  default_build_args = validators.array_of(validators.type('string')),
+  default_run_args = validators.array_of(validators.type('string')),
+  additional_mounts = validators.array_of(function(mount)
+    if type(mount) ~= 'table' then
+      return false, 'Expected table'
+    end
+    if type(mount.source) ~= 'string' or mount.source == '' then
+      return false, 'source must be a non-empty string'
+    end
+    if type(mount.target) ~= 'string' or not mount.target:match('^/') then
+      return false, 'target must be an absolute path'
+    end
+    if mount.readonly ~= nil and type(mount.readonly) ~= 'boolean' then
+      return false, 'readonly must be a boolean'
+    end
+    return true
+  end),
+  workspace_folders = validators.array_of(function(folder)
+    if type(folder) ~= 'table' then
+      return false, 'Expected table'
+    end
+    if type(folder.host) ~= 'string' or folder.host == '' then
+      return false, 'host must be a non-empty string'
+    end
+    if type(folder.container) ~= 'string' or not folder.container:match('^/') then
+      return false, 'container must be an absolute path'
+    end
+    if folder.name ~= nil and (type(folder.name) ~= 'string' or folder.name == '') then
+      return false, 'name must be a non-empty string'
+    end
+    if folder.readonly ~= nil and type(folder.readonly) ~= 'boolean' then
+      return false, 'readonly must be a boolean'
+    end
+    return true
+  end),
+  workspace_clone = {
+    repository = validators.optional(validators.type('string')),
+    branch = validators.optional(validators.type('string')),
+    volume = validators.optional(validators.pattern('^[%w][%w_.-]*$', 'Must be a valid volume name')),
+  },
+  env_files = validators.array_of(validators.type('string')),
+  cache_go_modules = validators.type('boolean'),
+  sync_check = {
+    timeout = validators.all(validators.type('number'), validators.range(100, 600000)),
+    warn_latency = validators.all(validators.type('number'), validators.range(0, 600000)),
+  },
+  detach = {
+    stop_container = validators.type('boolean'),
+  },
+  prune = {
+    max_age = validators.all(validators.type('number'), validators.range(0, 3650)),
+  },
+  shutdown_action = validators.enum({ 'none', 'stopContainer', 'stopCompose' }),
+  host_requirements = {
+    mode = validators.enum({ 'soft', 'hard' }),
+    limits = validators.type('boolean'),
+  },
+  secrets = validators.all(validators.type('table'), function(value)
+    for name, spec in pairs(value) do
+      if type(name) ~= 'string' or not name:match('^[%a_][%w_]*$') then
+        return false, 'Secret names must be environment variable names: ' .. tostring(name)
+      end
+      if type(spec) == 'table' then
+        if spec.env ~= nil and type(spec.env) ~= 'string' then
+          return false, name .. '.env must be a string'
+        end
+        if spec.value ~= nil and type(spec.value) ~= 'function' then
+          return false, name .. '.value must be a function'
+        end
+        if spec.build ~= nil and type(spec.build) ~= 'boolean' then
+          return false, name .. '.build must be a boolean'
+        end
+      elseif type(spec) ~= 'string' and type(spec) ~= 'function' then
+        return false, name .. ' must be a host variable name, a function or a table'
+      end
+    end
+    return true
+  end),
+  features_lock = validators.enum({ 'read', 'write', 'off' }),
+  watch_config = {
+    enabled = validators.type('boolean'),
+    action = validators.enum({ 'notify', 'prompt', 'rebuild' }),
+    debounce = validators.all(validators.type('number'), validators.range(0, 60000)),
+  },
 
   -- Paths
   devcontainer_path = validators.type('string'),
@@ -197,6 +284,44 @@ M.schema = {
     end),
     servers = validators.type('table'),
     on_attach = validators.optional(validators.func()),
+    path_mappings = validators.all(validators.type('table'), function(value)
+      for host_path, container_path in pairs(value) do
+        if type(host_path) ~= 'string' or type(container_path) ~= 'string' then
+          return false, 'Must map host path strings to container path strings'
+        end
+        if not container_path:match('^/') then
+          return false, 'Container path must be absolute: ' .. container_path
+        end
+      end
+      return true
+    end),
+    vscode_extensions = validators.all(validators.type('table'), function(value)
+      for id, server in pairs(value) do
+        if type(id) ~= 'string' or (type(server) ~= 'string' and server ~= false) then
+          return false, 'Must map extension IDs to server names or false'
+        end
+      end
+      return true
+    end),
+    root_patterns = validators.all(validators.type('table'), function(value)
+      for filetype, patterns in pairs(value) do
+        if type(filetype) ~= 'string' or type(patterns) ~= 'table' then
+          return false, 'Must map filetypes to lists of root patterns'
+        end
+        for _, pattern in ipairs(patterns) do
+          if type(pattern) ~= 'string' then
+            return false, 'Root patterns must be strings'
+          end
+        end
+      end
+      return true
+    end),
+    go_mod_change = validators.enum({ 'restart', 'notify', 'off' }),
+    go_mod_debounce = validators.all(validators.type('number'), validators.range(0, 60000)),
+    request_timeout = validators.all(validators.type('number'), validators.range(0, 3600000)),
+    watchdog_interval = validators.all(validators.type('number'), validators.range(0, 3600000)),
+    max_timeouts = validators.all(validators.type('number'), validators.range(1, 100)),
+    auto_restart = validators.type('boolean'),
   },
 
   -- DAP settings
@@ -209,6 +334,7 @@ M.schema = {
       node = validators.all(validators.type('number'), validators.range(1024, 65535)),
       java = validators.all(validators.type('number'), validators.range(1024, 65535)),
     },
+    attach_image = validators.optional(validators.type('string')),
     path_mappings = {
       container_workspace = validators.all(
         validators.type('string'),
@@ -221,13 +347,15 @@ M.schema = {
   -- Terminal settings
   terminal = {
     default_shell = validators.type('string'),
+    shell = validators.optional(validators.type('string')),
+    cwd = validators.optional(validators.type('string')),
     auto_insert = validators.type('boolean'),
     close_on_exit = validators.type('boolean'),
     close_on_container_stop = validators.type('boolean'),
     persistent_history = validators.type('boolean'),
     max_history_lines = validators.all(validators.type('number'), validators.range(0, 100000)),
     history_dir = validators.type('string'),
-    default_position = validators.enum({ 'split', 'tab', 'float' }),
+    default_position = validators.enum({ 'split', 'vsplit', 'tab', 'float' }),
     split_command = validators.type('string'),
     float = {
       width = validators.all(validators.type('number'), validators.range(0.1, 1.0)),
@@ -246,6 +374,9 @@ M.schema = {
     show_notifications = validators.type('boolean'),
     notification_level = validators.enum({ 'verbose', 'normal', 'minimal', 'silent' }),
     status_line = validators.type('boolean'),
+    build_window = validators.type('boolean'),
+    progress = validators.any(validators.enum({ 'notify', 'fidget', 'none' }), validators.func()),
+    stats_interval = validators.all(validators.type('number'), validators.range(500, 60000)),
     icons = validators.type('table'),
     statusline = {
       format = validators.type('table'),
@@ -265,6 +396,9 @@ M.schema = {
     port_range_start = validators.all(validators.type('number'), validators.range(1024, 65534)),
     port_range_end = validators.all(validators.type('number'), validators.range(1025, 65535)),
     conflict_resolution = validators.enum({ 'auto', 'prompt', 'error' }),
+    forwarder_image = validators.type('string'),
+    forward_ports = validators.enum({ 'create', 'start' }),
+    open_browser = validators.optional(validators.func()),
   },
 
   -- Docker settings
@@ -274,6 +408,22 @@ M.schema = {
     privileged = validators.type('boolean'),
     init = validators.type('boolean'),
     remove_orphans = validators.type('boolean'),
+    build_progress = validators.enum({ 'buildkit', 'plain' }),
+    health_timeout = validators.all(validators.type('number'), validators.range(0, 3600)),
+    stop_timeout = validators.all(validators.type('number'), validators.range(1, 3600)),
+    context_warning_size = validators.all(validators.type('number'), validators.range(0, 1048576)),
+    sync_on_save = validators.type('boolean'),
+    pull_cache_from = validators.type('boolean'),
+    retries = validators.all(validators.type('number'), validators.range(0, 10)),
+    retry_delay = validators.all(validators.type('number'), validators.range(0, 60000)),
+  },
+
+  -- Registry login
+  registry = {
+    server = validators.optional(validators.type('string')),
+    username = validators.optional(validators.type('string')),
+    password_env = validators.optional(validators.type('string')),
+    password_command = validators.optional(validators.type('string')),
   },
 
   -- Test integration
@@ -281,6 +431,63 @@ M.schema = {
     enabled = validators.type('boolean'),
     auto_setup = validators.type('boolean'),
     output_mode = validators.enum({ 'buffer', 'terminal', 'quickfix' }),
+    coverage = validators.type('boolean'),
+    default_runner = validators.type('string'),
+    runners = validators.type('table'),
+  },
+
+  -- Formatting
+  run_file = {
+    commands = validators.type('table'),
+  },
+
+  tasks = validators.all(validators.type('table'), function(tasks)
+    for name, spec in pairs(tasks) do
+      if type(spec) == 'table' then
+        if type(spec.cmd) ~= 'string' then
+          return false, name .. '.cmd must be a string'
+        end
+        if spec.cwd ~= nil and type(spec.cwd) ~= 'string' then
+          return false, name .. '.cwd must be a string'
+        end
+        if spec.env ~= nil and type(spec.env) ~= 'table' then
+          return false, name .. '.env must be a table'
+        end
+        if spec.parser ~= nil and type(spec.parser) ~= 'string' and type(spec.parser) ~= 'table' then
+          return false, name .. '.parser must be a parser name or a table of patterns'
+        end
+      elseif type(spec) ~= 'string' then
+        return false, name .. ' must be a shell command or a table'
+      end
+    end
+    return true
+  end),
+
+  heartbeat = {
+    enabled = validators.type('boolean'),
+    interval = validators.all(validators.type('number'), validators.range(1000, 3600000)),
+    timeout = validators.all(validators.type('number'), validators.range(100, 600000)),
+  },
+
+  go_build = {
+    targets = validators.array_of(validators.pattern('^%w+/%w+$', 'Must be GOOS/GOARCH, e.g. linux/amd64')),
+    packages = validators.array_of(validators.type('string')),
+    args = validators.array_of(validators.type('string')),
+    output_dir = validators.type('string'),
+    cgo = validators.type('boolean'),
+  },
+
+  lint = {
+    cmd = validators.type('string'),
+    args = validators.array_of(validators.type('string')),
+    diagnostics = validators.type('boolean'),
+    install_package = validators.type('string'),
+  },
+
+  format = {
+    on_save = validators.type('boolean'),
+    timeout = validators.all(validators.type('number'), validators.range(100, 60000)),
+    formatters = validators.type('table'),
   },
 
   -- Development settings
@@ -290,45 +497,70 @@ M.schema = {
   },
 }
 
--- Validate a value against a schema
-local function validate_value(value, schema, path)
-  path = path or ''
+-- Keys of a table in a stable order, so errors are reported the same way every time
+local function sorted_keys(tbl)
+  local keys = {}
+  for key in pairs(tbl) do
+    table.insert(keys, key)
+  end
+  table.sort(keys, function(a, b)
+    return tostring(a) < tostring(b)
+  end)
+  return keys
+end
 
+-- Validate a value against a schema, adding every invalid option to errors
+local function validate_value(value, schema, path, errors)
   if type(schema) == 'function' then
     -- Direct validator function
     local valid, err = schema(value)
     if not valid then
-      return false, string.format('%s: %s', path, err)
+      table.insert(errors, string.format('%s: %s', path, err))
     end
-    return true
   elseif type(schema) == 'table' and type(value) == 'table' then
     -- Nested schema
-    for key, sub_schema in pairs(schema) do
+    for _, key in ipairs(sorted_keys(schema)) do
       local sub_path = path == '' and key or path .. '.' .. key
-      local sub_value = value[key]
-
-      if sub_value ~= nil then
-        local valid, err = validate_value(sub_value, sub_schema, sub_path)
-        if not valid then
-          return false, err
-        end
+      if value[key] ~= nil then
+        validate_value(value[key], schema[key], sub_path, errors)
       end
     end
-    return true
+  elseif type(schema) == 'table' then
+    table.insert(errors, string.format('%s: Expected table, got %s', path, type(value)))
   end
+end
 
-  return true
+-- Options the plugin does not know (typos, options of other plugins), as dotted paths
+-- A key is known when the schema or the defaults have it; only sections with a nested schema are looked into, so
+-- free-form tables (lsp.servers, labels, ...) may hold any key.
+-- @param options table: options given to setup()
+-- @param defaults table: default configuration
+-- @return table: list of paths such as 'terminal.shel'
+function M.unknown_options(options, defaults)
+  local unknown = {}
+  local function walk(value, schema, default, path)
+    for _, key in ipairs(sorted_keys(value)) do
+      local sub_path = path == '' and tostring(key) or path .. '.' .. tostring(key)
+      if schema[key] == nil and default[key] == nil then
+        table.insert(unknown, sub_path)
+      elseif type(schema[key]) == 'table' and type(value[key]) == 'table' then
+        walk(value[key], schema[key], type(default[key]) == 'table' and default[key] or {}, sub_path)
+      end
+    end
+  end
+  if type(options) == 'table' then
+    walk(options, M.schema, defaults or {}, '')
+  end
+  return unknown
 end
 
 -- Validate entire configuration
+-- @return boolean, table: whether the configuration is valid and the list of errors ('path: message')
 function M.validate(config)
   local errors = {}
 
   -- Validate against schema
-  local valid, err = validate_value(config, M.schema)
-  if not valid then
-    table.insert(errors, err)
-  end
+  validate_value(config, M.schema, '', errors)
 
   -- Additional cross-field validations
   if config.port_forwarding then
@@ -339,7 +571,7 @@ function M.validate(config)
   end
 
   -- Validate that required executables exist (skip in test environments)
-  if config.container_runtime and vim.fn and vim.fn.executable then
+  if config.container_runtime and config.container_runtime ~= 'auto' and vim.fn and vim.fn.executable then
     if vim.fn.executable(config.container_runtime) == 0 then
       table.insert(errors, string.format('container_runtime: %s executable not found', config.container_runtime))
     end
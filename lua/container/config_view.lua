@@ -0,0 +1,204 @@
+-- lua/container/config_view.lua
+-- The configuration in effect as pretty-printed JSON (:ContainerConfig)
+-- Shows devcontainer.json after extends and variable expansion, the file each top-level property comes from,
+-- and the values computed from it: container name, image, mounts, environment and ports.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Name of the output buffer
+M.OUTPUT_NAME = 'config'
+
+local INDENT = '  '
+
+local function is_array(value)
+  if next(value) == nil then
+    return false
+  end
+  local count = 0
+  for _ in pairs(value) do
+    count = count + 1
+  end
+  return count == #value
+end
+
+-- JSON string literal (vim.json.encode would also escape "/", which is noisy in paths)
+local escapes = { ['"'] = '\\"', ['\\'] = '\\\\', ['\n'] = '\\n', ['\r'] = '\\r', ['\t'] = '\\t' }
+local function quote(text)
+  return '"'
+    .. tostring(text):gsub('[%c"\\]', function(char)
+      return escapes[char] or string.format('\\u%04x', char:byte())
+    end)
+    .. '"'
+end
+
+local function sorted_keys(value)
+  local keys = {}
+  for key in pairs(value) do
+    table.insert(keys, key)
+  end
+  table.sort(keys, function(a, b)
+    return tostring(a) < tostring(b)
+  end)
+  return keys
+end
+
+-- Encode a value as indented JSON with sorted object keys
+-- Empty tables are written as {}; functions and other Lua values as strings.
+-- @param value any
+-- @param indent string|nil: indentation of the line the value starts on
+-- @return string
+function M.encode(value, indent)
+  indent = indent or ''
+  local value_type = type(value)
+  if value == nil or value == vim.NIL then
+    return 'null'
+  elseif value_type == 'boolean' then
+    return tostring(value)
+  elseif value_type == 'number' then
+    return value == math.floor(value) and string.format('%d', value) or tostring(value)
+  elseif value_type ~= 'table' then
+    return quote(value)
+  end
+
+  if next(value) == nil then
+    return '{}'
+  end
+  local inner = indent .. INDENT
+  local items = {}
+  if is_array(value) then
+    for _, item in ipairs(value) do
+      table.insert(items, inner .. M.encode(item, inner))
+    end
+    return '[\n' .. table.concat(items, ',\n') .. '\n' .. indent .. ']'
+  end
+  for _, key in ipairs(sorted_keys(value)) do
+    table.insert(items, inner .. quote(key) .. ': ' .. M.encode(value[key], inner))
+  end
+  return '{\n' .. table.concat(items, ',\n') .. '\n' .. indent .. '}'
+end
+
+-- Values computed from the configuration
+-- @param config table: normalized configuration
+-- @param container_id string|nil
+-- @return table
+function M.computed(config, container_id)
+  local docker = require('container.docker')
+  local computed = {
+    containerName = docker.resolve_container_name(config),
+    containerId = container_id,
+    workspaceFolder = config.workspace_folder,
+    remoteUser = config.remote_user,
+    containerUser = config.container_user,
+  }
+
+  computed.image = config.uid_image or config.features_image or config.built_image or config.prepared_image
+  if not computed.image and config.dockerfile then
+    local ok, tag = pcall(function()
+      return docker.get_image_cache_tag(config, docker.compute_image_cache_key(config))
+    end)
+    computed.image = ok and tag or nil
+  end
+  computed.image = computed.image or config.image
+
+  if config.workspace_mount then
+    computed.workspaceMount = docker.format_mount(config.workspace_mount)
+  end
+  if config.mounts and #config.mounts > 0 then
+    computed.mounts = {}
+    for _, mount in ipairs(config.mounts) do
+      table.insert(computed.mounts, docker.format_mount(mount))
+    end
+  end
+
+  computed.containerEnv = config.environment
+  computed.remoteEnv = require('container.environment').get_remote_environment(config)
+  -- Values of env files are secrets: only the names are listed
+  if config.env_files and #config.env_files > 0 then
+    computed.envFiles = config.env_files
+    computed.envFileVariables = config.env_file_keys
+  end
+  local secrets = require('container.secrets').describe()
+  if #secrets > 0 then
+    computed.secrets = secrets
+  end
+
+  if config.ports and #config.ports > 0 then
+    computed.ports = {}
+    for _, port in ipairs(config.ports) do
+      if port.container_port then
+        local text = port.host_port and string.format('%d -> %d', port.host_port, port.container_port)
+          or tostring(port.container_port)
+        table.insert(computed.ports, port.label and string.format('%s (%s)', text, port.label) or text)
+      end
+    end
+  end
+
+  if config.compose_files then
+    computed.composeFiles = config.compose_files
+    computed.service = config.service
+    computed.composeProject = require('container.docker.compose').get_project_name(config)
+  end
+  return computed
+end
+
+-- Lines of the configuration view
+-- @param config table: normalized configuration
+-- @param container_id string|nil
+-- @return table: lines
+function M.render(config, container_id)
+  local sections = {
+    { 'configFile', config.config_file },
+  }
+
+  -- Where each property comes from, when extends brought in other files
+  local sources = {}
+  local extended = false
+  for key, files in pairs(config.key_sources or {}) do
+    sources[key] = #files == 1 and files[1] or files
+    if files[1] ~= config.config_file or #files > 1 then
+      extended = true
+    end
+  end
+  if extended then
+    table.insert(sections, { 'sources', sources })
+  end
+
+  table.insert(sections, { 'computed', M.computed(config, container_id) })
+  table.insert(sections, { 'devcontainer', config.devcontainer_json or {} })
+
+  local items = {}
+  for _, section in ipairs(sections) do
+    if section[2] ~= nil then
+      table.insert(items, INDENT .. quote(section[1]) .. ': ' .. M.encode(section[2], INDENT))
+    end
+  end
+  return vim.split('{\n' .. table.concat(items, ',\n') .. '\n}', '\n')
+end
+
+-- Show the configuration of the current workspace in a read-only JSON buffer
+-- @param config table|nil: normalized configuration
+-- @param container_id string|nil
+-- @return boolean
+function M.show(config, container_id)
+  if not config then
+    require('container.utils.notify').warn('No devcontainer configuration loaded. Run :ContainerOpen first')
+    return false
+  end
+
+  local ok, lines = pcall(M.render, config, container_id)
+  if not ok then
+    log.error('Failed to render the configuration: %s', lines)
+    require('container.utils.notify').error('Failed to render the configuration: ' .. tostring(lines))
+    return false
+  end
+
+  local output = require('container.ui.output')
+  output.set_lines(M.OUTPUT_NAME, lines)
+  vim.bo[output.get_buffer(M.OUTPUT_NAME)].filetype = 'json'
+  output.open(M.OUTPUT_NAME, { split = 'botright vsplit', focus = true })
+  return true
+end
+
+return M
@@ -0,0 +1,210 @@
+-- lua/container/coverage.lua
+-- Go test coverage collected inside the container, shown as signs in the covered files
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Profile written by go test -coverprofile inside the container
+M.PROFILE_PATH = '/tmp/container-nvim-coverage.out'
+
+-- Sign text and highlight per line state
+M.SIGNS = {
+  covered = { text = '▎', hl = 'ContainerCoverageCovered' },
+  uncovered = { text = '▎', hl = 'ContainerCoverageUncovered' },
+}
+
+-- Coverage of the last run: { files = { [host_file] = { [lnum] = count } }, covered, statements }
+local report = nil
+local visible = false
+local namespace = nil
+
+local function get_namespace()
+  if not namespace then
+    namespace = vim.api.nvim_create_namespace('container_coverage')
+    vim.api.nvim_set_hl(0, M.SIGNS.covered.hl, { link = 'DiffAdd', default = true })
+    vim.api.nvim_set_hl(0, M.SIGNS.uncovered.hl, { link = 'DiffDelete', default = true })
+  end
+  return namespace
+end
+
+-- Parse a coverage profile ("mode: set" followed by "file:l1.c1,l2.c2 statements count" blocks)
+-- Blocks repeated across packages are counted once, keeping the highest count.
+-- @return table: { files = { [profile_file] = { [lnum] = count } }, covered, statements }
+function M.parse_profile(text)
+  local blocks = {}
+  local order = {}
+  for line in (text or ''):gmatch('[^\n]+') do
+    local file, start_line, end_line, statements, count = line:match('^(.+):(%d+)%.%d+,(%d+)%.%d+ (%d+) (%d+)$')
+    if file then
+      local key = line:match('^(.+) %d+$')
+      local block = blocks[key]
+      if not block then
+        block = {
+          file = file,
+          start_line = tonumber(start_line),
+          end_line = tonumber(end_line),
+          statements = tonumber(statements),
+          count = 0,
+        }
+        blocks[key] = block
+        table.insert(order, key)
+      end
+      block.count = math.max(block.count, tonumber(count))
+    end
+  end
+
+  local result = { files = {}, covered = 0, statements = 0 }
+  for _, key in ipairs(order) do
+    local block = blocks[key]
+    local lines = result.files[block.file] or {}
+    result.files[block.file] = lines
+    for lnum = block.start_line, block.end_line do
+      lines[lnum] = math.max(lines[lnum] or 0, block.count)
+    end
+    result.statements = result.statements + block.statements
+    if block.count > 0 then
+      result.covered = result.covered + block.statements
+    end
+  end
+  return result
+end
+
+-- Resolve a file of the profile to a host path
+-- Files inside the module are reported by import path; absolute container paths are mapped back like LSP paths.
+-- @param ctx table: { host_root, container_root, module_root, module_path }
+function M.resolve_file(file, ctx)
+  local go_test = require('container.test')
+  if file:match('^/') then
+    local lsp_path = require('container.lsp.path')
+    if lsp_path.get_mappings().container_workspace then
+      local mapped = lsp_path.to_local_path(file)
+      if mapped ~= file then
+        return mapped
+      end
+    end
+    return go_test.map_path(file, ctx.container_root, ctx.host_root)
+  end
+
+  if ctx.module_root and ctx.module_path then
+    if file:sub(1, #ctx.module_path + 1) == ctx.module_path .. '/' then
+      return require('container.utils.fs').join_path(ctx.module_root, file:sub(#ctx.module_path + 2))
+    end
+  end
+  return nil
+end
+
+-- Coverage percentage of the last run
+function M.percentage(coverage)
+  coverage = coverage or report
+  if not coverage or coverage.statements == 0 then
+    return 0
+  end
+  return coverage.covered * 100 / coverage.statements
+end
+
+-- Place the signs of a buffer
+function M.render_buffer(bufnr)
+  if not report then
+    return
+  end
+  local ns = get_namespace()
+  vim.api.nvim_buf_clear_namespace(bufnr, ns, 0, -1)
+  local lines = report.files[vim.api.nvim_buf_get_name(bufnr)]
+  if not lines then
+    return
+  end
+
+  local line_count = vim.api.nvim_buf_line_count(bufnr)
+  for lnum, count in pairs(lines) do
+    if lnum <= line_count then
+      local sign = count > 0 and M.SIGNS.covered or M.SIGNS.uncovered
+      vim.api.nvim_buf_set_extmark(bufnr, ns, lnum - 1, 0, {
+        sign_text = sign.text,
+        sign_hl_group = sign.hl,
+        priority = 5,
+      })
+    end
+  end
+end
+
+-- Show coverage signs in loaded and newly opened Go files
+function M.show()
+  if not report then
+    notify.error('No coverage yet. Run :ContainerTest with test_integration.coverage enabled')
+    return false
+  end
+  visible = true
+  for _, bufnr in ipairs(vim.api.nvim_list_bufs()) do
+    if vim.api.nvim_buf_is_loaded(bufnr) then
+      M.render_buffer(bufnr)
+    end
+  end
+  local group = vim.api.nvim_create_augroup('ContainerCoverage', { clear = true })
+  vim.api.nvim_create_autocmd('BufReadPost', {
+    group = group,
+    pattern = '*.go',
+    callback = function(args)
+      M.render_buffer(args.buf)
+    end,
+  })
+  return true
+end
+
+-- Remove the coverage signs
+function M.hide()
+  visible = false
+  pcall(vim.api.nvim_del_augroup_by_name, 'ContainerCoverage')
+  local ns = get_namespace()
+  for _, bufnr in ipairs(vim.api.nvim_list_bufs()) do
+    if vim.api.nvim_buf_is_loaded(bufnr) then
+      vim.api.nvim_buf_clear_namespace(bufnr, ns, 0, -1)
+    end
+  end
+end
+
+-- Toggle the coverage signs (:ContainerCoverage)
+function M.toggle()
+  if visible then
+    M.hide()
+    return true
+  end
+  return M.show()
+end
+
+-- Read the profile of the finished run from the container and show it
+-- @param container_id string: container the tests ran in
+-- @param ctx table: path context of the run (see resolve_file)
+function M.load(container_id, ctx)
+  require('container.docker').run_docker_command_async(
+    { 'exec', container_id, 'cat', M.PROFILE_PATH },
+    {},
+    function(result)
+      vim.schedule(function()
+        if not result.success then
+          log.warn('Could not read coverage profile: %s', result.stderr or 'unknown')
+          return
+        end
+
+        local parsed = M.parse_profile(result.stdout)
+        local files = {}
+        for file, lines in pairs(parsed.files) do
+          local host_file = M.resolve_file(file, ctx)
+          if host_file then
+            files[host_file] = lines
+          else
+            log.debug('Coverage file outside the workspace: %s', file)
+          end
+        end
+        parsed.files = files
+        report = parsed
+
+        notify.status(string.format('Coverage: %.1f%% of statements', M.percentage()))
+        M.show()
+      end)
+    end
+  )
+end
+
+return M
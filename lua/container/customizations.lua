@@ -0,0 +1,117 @@
+-- lua/container/customizations.lua
+-- Access to the customizations of devcontainer.json
+-- Besides the raw tree, LSP servers are derived from the "container.nvim" block and from the VS Code
+-- extensions listed under customizations.vscode.extensions (mapped through lsp.vscode_extensions).
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Tool name of this plugin's block in customizations
+M.TOOL = 'container.nvim'
+
+-- Well-known VS Code extensions and the LSP server that covers them
+M.DEFAULT_VSCODE_EXTENSIONS = {
+  ['golang.go'] = 'gopls',
+  ['ms-python.python'] = 'pylsp',
+  ['ms-python.vscode-pylance'] = 'pyright',
+  ['rust-lang.rust-analyzer'] = 'rust_analyzer',
+  ['llvm-vs-code-extensions.vscode-clangd'] = 'clangd',
+  ['ms-vscode.cpptools'] = 'clangd',
+  ['sumneko.lua'] = 'lua_ls',
+  ['dbaeumer.vscode-eslint'] = 'eslint',
+  ['redhat.java'] = 'jdtls',
+  ['vscjava.vscode-java-pack'] = 'jdtls',
+  ['rebornix.ruby'] = 'solargraph',
+  ['castwide.solargraph'] = 'solargraph',
+  ['bmewburn.vscode-intelephense-client'] = 'intelephense',
+}
+
+-- Customizations of a devcontainer configuration
+-- @param config table|nil: parsed or normalized devcontainer configuration
+-- @param tool string|nil: tool name (e.g. 'vscode'); the whole tree is returned when omitted
+-- @return table
+function M.get(config, tool)
+  local customizations = config and config.customizations
+  if type(customizations) ~= 'table' then
+    return {}
+  end
+  if tool == nil then
+    return customizations
+  end
+  return type(customizations[tool]) == 'table' and customizations[tool] or {}
+end
+
+-- VS Code extension IDs listed in customizations.vscode.extensions
+function M.vscode_extensions(config)
+  local extensions = M.get(config, 'vscode').extensions
+  if type(extensions) ~= 'table' then
+    return {}
+  end
+  local ids = {}
+  for _, id in ipairs(extensions) do
+    -- "-publisher.name" excludes an extension in VS Code
+    if type(id) == 'string' and not id:match('^%-') then
+      table.insert(ids, id:lower())
+    end
+  end
+  return ids
+end
+
+-- Mapping from extension IDs to servers: the defaults extended by lsp.vscode_extensions
+-- (an ID mapped to false removes a default)
+function M.extension_mappings(lsp_config)
+  local mappings = vim.deepcopy(M.DEFAULT_VSCODE_EXTENSIONS)
+  local user_mappings = lsp_config and lsp_config.vscode_extensions or {}
+  for id, server in pairs(user_mappings) do
+    mappings[id:lower()] = server or nil
+  end
+  return mappings
+end
+
+-- LSP servers requested by the devcontainer configuration
+-- customizations["container.nvim"].lsp.servers maps server names to options merged into the server's
+-- client config, or to false to keep the server from being started. Servers mapped from VS Code
+-- extensions are requested with no options.
+-- @param config table|nil: devcontainer configuration
+-- @param lsp_config table|nil: lsp section of the plugin configuration
+-- @return table: { [server_name] = { source = 'container.nvim'|<extension id>, options = table|nil } | false }
+function M.lsp_servers(config, lsp_config)
+  local servers = {}
+
+  local mappings = M.extension_mappings(lsp_config)
+  for _, id in ipairs(M.vscode_extensions(config)) do
+    local server = mappings[id]
+    if server and servers[server] == nil then
+      servers[server] = { source = id }
+    end
+  end
+
+  local lsp = M.get(config, M.TOOL).lsp
+  local declared = type(lsp) == 'table' and lsp.servers or {}
+  for name, options in pairs(declared) do
+    if options == false then
+      servers[name] = false
+    elseif type(options) == 'table' then
+      servers[name] = { source = M.TOOL, options = next(options) and options or nil }
+    elseif options == true then
+      servers[name] = { source = M.TOOL }
+    else
+      log.warn('Ignoring customizations.%s.lsp.servers.%s: expected an object or boolean', M.TOOL, name)
+    end
+  end
+
+  return servers
+end
+
+-- Check whether the configuration asks for any LSP server
+function M.requests_lsp(config, lsp_config)
+  for _, server in pairs(M.lsp_servers(config, lsp_config)) do
+    if server then
+      return true
+    end
+  end
+  return false
+end
+
+return M
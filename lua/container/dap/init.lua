@@ -223,7 +223,7 @@ function M._get_adapter_config(language, container_id)
   local adapters = {
     python = {
       type = 'executable',
-      command = 'docker',
+      command = require('container.docker.runtime').get(),
       args = {
         'exec',
         '-i',
@@ -246,7 +246,7 @@ function M._get_adapter_config(language, container_id)
     typescript = nil,
     rust = {
       type = 'executable',
-      command = 'docker',
+      command = require('container.docker.runtime').get(),
       args = {
         'exec',
         '-i',
@@ -370,7 +370,10 @@ function M._register_configuration(language, container_id)
         program = function()
           -- Find the compiled binary
           local cargo_target = vim.fn.system(
-            'docker exec ' .. container_id .. ' find target/debug -maxdepth 1 -type f -executable | head -1'
+            require('container.docker.runtime').get()
+              .. ' exec '
+              .. container_id
+              .. ' find target/debug -maxdepth 1 -type f -executable | head -1'
           )
           return vim.trim(cargo_target)
         end,
@@ -524,6 +527,391 @@ function M.start_debugging(opts)
   return true
 end
 
+-- Package installed when dlv is missing from the container
+M.DLV_PACKAGE = 'github.com/go-delve/delve/cmd/dlv@latest'
+
+-- Build dlv substitutePath entries from host <-> container path mappings
+-- @param mappings table: list of { host, container } (see lsp.interceptor.build_mappings)
+function M.build_substitute_path(mappings)
+  local substitute_path = {}
+  for _, mapping in ipairs(mappings or {}) do
+    table.insert(substitute_path, { from = mapping.host, to = mapping.container })
+  end
+  return substitute_path
+end
+
+-- Build the launch configuration that debugs a single Go test
+-- @param opts table: { test_name, container_dir, substitute_path }
+function M.build_go_test_configuration(opts)
+  return {
+    type = 'container_go',
+    request = 'launch',
+    name = 'Container: Debug ' .. opts.test_name,
+    mode = 'test',
+    program = opts.container_dir,
+    args = { '-test.run', '^' .. opts.test_name .. '$' },
+    substitutePath = opts.substitute_path,
+  }
+end
+
+-- Build docker arguments that start a detached dlv dap server in the container
+-- dlv listens on all interfaces so published ports and forwarding sidecars can reach it
+function M.build_dlv_dap_args(container_id, port, container_dir, env_args)
+  local args = { 'exec', '-d' }
+  vim.list_extend(args, env_args or {})
+  vim.list_extend(args, { '-w', container_dir, container_id, 'dlv', 'dap', '--listen=0.0.0.0:' .. port })
+  return args
+end
+
+-- Find the host port reaching a container port, either published or dynamically forwarded
+function M._find_host_port(container_id, container_port)
+  for _, forward in ipairs(require('container').get_port_forwards()) do
+    if forward.container_port == container_port then
+      return forward.host_port
+    end
+  end
+
+  local info = docker.get_container_info(container_id)
+  local ports = info and info.NetworkSettings and info.NetworkSettings.Ports or {}
+  local bindings = ports[container_port .. '/tcp']
+  if type(bindings) == 'table' and bindings[1] and bindings[1].HostPort then
+    return tonumber(bindings[1].HostPort)
+  end
+  return nil
+end
+
+-- Make the Delve port reachable from the host, forwarding it when it was not published
+function M._ensure_dlv_port(container_id, container_port, callback)
+  local host_port = M._find_host_port(container_id, container_port)
+  if host_port then
+    callback(host_port)
+    return
+  end
+
+  local ok, err = require('container').forward_port(container_port, nil, function(forward, forward_err)
+    callback(forward and forward.host_port, forward_err)
+  end)
+  if not ok then
+    callback(nil, err)
+  end
+end
+
+-- Make sure dlv is installed in the container, offering to go install it
+function M._ensure_dlv(callback)
+  local container_main = require('container')
+  local result = container_main.exec({ 'sh', '-c', 'command -v dlv' })
+  if result and result.code == 0 then
+    callback(true)
+    return
+  end
+
+  local choice = vim.fn.confirm(
+    'Delve (dlv) is not installed in the container. Install it with go install?',
+    '&Yes\n&No',
+    1
+  )
+  if choice ~= 1 then
+    callback(false, 'dlv is not installed in the container')
+    return
+  end
+
+  notify.container('Installing Delve: go install ' .. M.DLV_PACKAGE)
+  container_main.exec({ 'go', 'install', M.DLV_PACKAGE }, {
+    callback = function(install_result)
+      if install_result.code ~= 0 then
+        local output = install_result.stderr ~= '' and install_result.stderr or install_result.stdout
+        callback(false, 'go install failed: ' .. vim.trim(output))
+        return
+      end
+      notify.success('Delve installed in container')
+      callback(true)
+    end,
+  })
+end
+
+-- Debug the Go test function under the cursor with dlv dap inside the container
+function M.debug_nearest()
+  local container_main = require('container')
+  local container_id = container_main.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local ok, dap = pcall(require, 'dap')
+  if not ok then
+    notify.error('nvim-dap is not installed')
+    return false
+  end
+
+  local go_test = require('container.test')
+  local lines = vim.api.nvim_buf_get_lines(0, 0, -1, false)
+  local test_name = go_test.find_enclosing_test(lines, vim.fn.line('.'))
+  if not test_name then
+    notify.error('No enclosing func TestXxx found at cursor')
+    return false
+  end
+
+  local container_config = container_main.get_state().current_config or {}
+  local host_root, container_root = require('container.parser').workspace_roots(container_config)
+  local container_dir = go_test.map_path(vim.fn.expand('%:p:h'), host_root, container_root)
+  if not container_dir then
+    notify.error('Current file is outside the container workspace')
+    return false
+  end
+
+  local interceptor = require('container.lsp.interceptor')
+  local mappings = interceptor.build_mappings(host_root, container_root, container_config.mounts)
+  local configuration = M.build_go_test_configuration({
+    test_name = test_name,
+    container_dir = container_dir,
+    substitute_path = M.build_substitute_path(mappings),
+  })
+  local port = config.get().dap.ports.go
+
+  M._ensure_dlv(function(installed, install_err)
+    if not installed then
+      notify.error(install_err)
+      return
+    end
+
+    -- A dlv dap server serves a single session, so replace any server left on the port
+    docker.run_docker_command({ 'exec', container_id, 'pkill', '-f', 'dlv.*--listen=.*:' .. port })
+
+    local environment = require('container.environment')
+    local args = M.build_dlv_dap_args(container_id, port, container_dir, environment.build_exec_args(container_config))
+    local result = docker.run_docker_command(args)
+    if not result.success then
+      notify.error('Failed to start dlv dap: ' .. (result.stderr or ''))
+      return
+    end
+
+    M._ensure_dlv_port(container_id, port, function(host_port, port_err)
+      if not host_port then
+        notify.error('Delve port is not reachable from the host: ' .. (port_err or 'unknown'))
+        return
+      end
+
+      dap.adapters.container_go = { type = 'server', host = '127.0.0.1', port = host_port }
+      M._state.adapters.go = 'container_go'
+      log.info('Debugging %s via dlv dap on host port %d', test_name, host_port)
+
+      -- Give dlv a moment to start listening before connecting
+      vim.defer_fn(function()
+        dap.run(configuration)
+      end, 500)
+    end)
+  end)
+
+  return true
+end
+
+-- Capabilities that let a process ptrace others in the container
+local PTRACE_CAPABILITIES = { SYS_PTRACE = true, CAP_SYS_PTRACE = true, ALL = true, CAP_ALL = true }
+
+-- Check whether processes of a container may ptrace each other (privileged, or capAdd SYS_PTRACE)
+-- @param info table|nil: docker inspect output of the container
+-- @return boolean
+function M.has_ptrace(info)
+  local host_config = info and info.HostConfig or {}
+  if host_config.Privileged then
+    return true
+  end
+  for _, capability in ipairs(host_config.CapAdd or {}) do
+    if PTRACE_CAPABILITIES[capability:upper()] then
+      return true
+    end
+  end
+  return false
+end
+
+-- Name of the tools sidecar debugging a container
+function M.attach_sidecar_name(container_id)
+  return 'container-nvim-dlv-' .. container_id:sub(1, 12)
+end
+
+-- Build docker arguments that start a dlv dap server able to attach to processes of the container
+-- With opts.image, a tools sidecar shares the PID and network namespaces of the container and gets SYS_PTRACE, so
+-- neither dlv nor the capability are needed in the container itself. Otherwise dlv runs in the container, through
+-- exec --privileged when the container cannot ptrace (opts.ptrace false).
+-- @param container_id string
+-- @param port number: port dlv listens on (in the network namespace of the container)
+-- @param opts table: { image, ptrace, container_dir, env_args }
+-- @return table
+function M.build_attach_args(container_id, port, opts)
+  local listen = '--listen=0.0.0.0:' .. port
+  if opts.image then
+    return {
+      'run',
+      '-d',
+      '--rm',
+      '--name',
+      M.attach_sidecar_name(container_id),
+      '--pid=container:' .. container_id,
+      '--network=container:' .. container_id,
+      '--cap-add=SYS_PTRACE',
+      '--security-opt',
+      'seccomp=unconfined',
+      opts.image,
+      'dlv',
+      'dap',
+      listen,
+    }
+  end
+  local args = M.build_dlv_dap_args(container_id, port, opts.container_dir, opts.env_args)
+  if not opts.ptrace then
+    table.insert(args, 3, '--privileged')
+  end
+  return args
+end
+
+-- Build the configuration that attaches dlv to a running process
+-- @param pid number: process ID in the container
+-- @param substitute_path table: see build_substitute_path
+function M.build_attach_configuration(pid, substitute_path)
+  return {
+    type = 'container_go',
+    request = 'attach',
+    name = 'Container: Attach to process ' .. pid,
+    mode = 'local',
+    processId = pid,
+    substitutePath = substitute_path,
+  }
+end
+
+-- Parse `ps -eo pid=,args=` output into the processes that can be attached to
+-- @param stdout string
+-- @return table: list of { pid, command }
+function M.parse_processes(stdout)
+  local processes = {}
+  for line in (stdout or ''):gmatch('[^\n]+') do
+    local pid, command = line:match('^%s*(%d+)%s+(.-)%s*$')
+    if pid and not command:match('^ps %-eo') and not command:match('^dlv ') then
+      table.insert(processes, { pid = tonumber(pid), command = command })
+    end
+  end
+  return processes
+end
+
+-- Let the user pick a process of the container
+local function select_process(container_id, callback)
+  local result = docker.run_docker_command({ 'exec', container_id, 'ps', '-eo', 'pid=,args=' })
+  if not result.success then
+    notify.error('Cannot list the processes of the container (is ps installed?); pass the PID instead')
+    return
+  end
+  local processes = M.parse_processes(result.stdout)
+  if #processes == 0 then
+    notify.error('No process to attach to in the container')
+    return
+  end
+  vim.ui.select(processes, {
+    prompt = 'Attach debugger to:',
+    format_item = function(process)
+      return string.format('%6d  %s', process.pid, process.command)
+    end,
+  }, function(process)
+    if process then
+      callback(process.pid)
+    end
+  end)
+end
+
+-- Attach Delve to a process already running in the container (a long-running server, for instance)
+-- Without a PID the processes of the container are offered for selection. Attaching needs ptrace: see
+-- build_attach_args for how the capability is obtained.
+-- @param pid number|nil: process ID in the container
+-- @return boolean: false when debugging cannot start
+function M.attach_process(pid)
+  local container_main = require('container')
+  local container_id = container_main.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local ok, dap = pcall(require, 'dap')
+  if not ok then
+    notify.error('nvim-dap is not installed')
+    return false
+  end
+
+  if not pid then
+    select_process(container_id, M.attach_process)
+    return true
+  end
+  pid = tonumber(pid)
+  if not pid then
+    notify.error('PID must be a number')
+    return false
+  end
+
+  local dap_config = config.get().dap
+  local port = dap_config.ports.go
+  local image = dap_config.attach_image
+  local container_config = container_main.get_state().current_config or {}
+  local host_root, container_root = require('container.parser').workspace_roots(container_config)
+  local interceptor = require('container.lsp.interceptor')
+  local mappings = interceptor.build_mappings(host_root, container_root, container_config.mounts)
+  local ptrace = M.has_ptrace(docker.get_container_info(container_id))
+
+  local function start()
+    -- A dlv dap server serves a single session, so replace any server left on the port
+    if image then
+      docker.run_docker_command({ 'rm', '-f', M.attach_sidecar_name(container_id) })
+    else
+      docker.run_docker_command({ 'exec', container_id, 'pkill', '-f', 'dlv.*--listen=.*:' .. port })
+      if not ptrace then
+        log.info('Container lacks SYS_PTRACE, starting dlv with exec --privileged')
+      end
+    end
+
+    local args = M.build_attach_args(container_id, port, {
+      image = image,
+      ptrace = ptrace,
+      container_dir = container_root,
+      env_args = require('container.environment').build_exec_args(container_config),
+    })
+    local result = docker.run_docker_command(args)
+    if not result.success then
+      notify.error('Failed to start dlv dap: ' .. (result.stderr or ''))
+      return
+    end
+    if image then
+      M._state.attach_sidecar = M.attach_sidecar_name(container_id)
+    end
+
+    M._ensure_dlv_port(container_id, port, function(host_port, port_err)
+      if not host_port then
+        notify.error('Delve port is not reachable from the host: ' .. (port_err or 'unknown'))
+        return
+      end
+
+      dap.adapters.container_go = { type = 'server', host = '127.0.0.1', port = host_port }
+      M._state.adapters.go = 'container_go'
+      log.info('Attaching dlv to process %d via host port %d', pid, host_port)
+
+      -- Give dlv a moment to start listening before connecting
+      vim.defer_fn(function()
+        dap.run(M.build_attach_configuration(pid, M.build_substitute_path(mappings)))
+      end, 500)
+    end)
+  end
+
+  if image then
+    start()
+  else
+    M._ensure_dlv(function(installed, install_err)
+      if not installed then
+        notify.error(install_err)
+        return
+      end
+      start()
+    end)
+  end
+  return true
+end
+
 function M.stop_debugging()
   local ok, dap = pcall(require, 'dap')
   if not ok then
@@ -532,6 +920,12 @@ function M.stop_debugging()
 
   dap.terminate()
   dap.close()
+
+  -- The tools sidecar of an attach session is not needed anymore
+  if M._state.attach_sidecar then
+    docker.run_docker_command({ 'rm', '-f', M._state.attach_sidecar })
+    M._state.attach_sidecar = nil
+  end
 end
 
 function M.list_debug_sessions()
@@ -0,0 +1,390 @@
+-- lua/container/docker/compose.lua
+-- Docker Compose support for multi-service devcontainers
+
+local M = {}
+
+local fs = require('container.utils.fs')
+local log = require('container.utils.log')
+
+-- Check if the normalized configuration describes a compose-based devcontainer
+function M.is_compose_config(config)
+  return config ~= nil and config.compose_files ~= nil and #config.compose_files > 0 and config.service ~= nil
+end
+
+-- Generate a compose project name unique per project path
+function M.get_project_name(config)
+  local project_path = config.base_path or vim.fn.getcwd()
+  local path_hash = vim.fn.sha256(project_path):sub(1, 8)
+  local clean_name = (config.name or 'devcontainer'):lower():gsub('[^a-z0-9_-]', '-')
+  return string.format('%s-%s-devcontainer', clean_name, path_hash)
+end
+
+-- Path of the override file generated for the attached service
+function M.get_override_path(config)
+  return fs.join_path(vim.fn.stdpath('cache'), 'container.nvim', 'compose', M.get_project_name(config) .. '.json')
+end
+
+-- Build the base "docker compose" arguments (project name and compose files)
+function M.build_base_args(config, include_override)
+  local args = { 'compose', '-p', M.get_project_name(config) }
+  for _, file in ipairs(config.compose_files or {}) do
+    table.insert(args, '-f')
+    table.insert(args, file)
+  end
+  if include_override then
+    table.insert(args, '-f')
+    table.insert(args, M.get_override_path(config))
+  end
+  return args
+end
+
+-- Build the list of services to start (runServices plus the attached service)
+function M.get_services_to_start(config)
+  if not config.run_services or #config.run_services == 0 then
+    -- Compose starts every service when none are listed
+    return {}
+  end
+
+  local services = vim.deepcopy(config.run_services)
+  if not vim.tbl_contains(services, config.service) then
+    table.insert(services, config.service)
+  end
+  return services
+end
+
+-- Check whether the compose file already defines networking for the service
+-- Publishing ports conflicts with network_mode, and services with explicit
+-- networks keep them untouched
+function M.service_defines_network(compose_config, service)
+  local service_config = compose_config and compose_config.services and compose_config.services[service]
+  if not service_config then
+    return false
+  end
+  return service_config.network_mode ~= nil or service_config.networks ~= nil
+end
+
+-- Build the override applied on top of the user's compose files
+function M.build_override(config, compose_config)
+  local docker = require('container.docker')
+  local service = {
+    labels = {
+      ['devcontainer.local_folder'] = config.base_path or vim.fn.getcwd(),
+      ['devcontainer.config_file'] = config.config_file,
+      [docker.WORKSPACE_LABEL] = docker.get_workspace_path(config),
+    },
+  }
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  for key, value in pairs(ok and plugin_config and plugin_config.labels or {}) do
+    service.labels[key] = tostring(value)
+  end
+
+  -- Keep the attached service alive only with overrideCommand true; by default the service runs its own command
+  if docker.overrides_command(config) then
+    service.entrypoint = { '/bin/sh', '-c', 'while sleep 1000; do :; done' }
+  end
+
+  -- privileged, capAdd and securityOpt of devcontainer.json
+  if config.privileged then
+    service.privileged = true
+  end
+  if config.cap_add and #config.cap_add > 0 then
+    service.cap_add = config.cap_add
+  end
+  if config.security_opt and #config.security_opt > 0 then
+    service.security_opt = config.security_opt
+  end
+
+  -- Environment from containerEnv and the env files
+  if config.environment and not vim.tbl_isempty(config.environment) then
+    service.environment = config.environment
+  end
+  if config.env_file then
+    service.env_file = { config.env_file }
+  end
+
+  -- Publish forwarded ports only when the service does not manage its own network
+  if not M.service_defines_network(compose_config, config.service) then
+    local ports = {}
+    for _, port in ipairs(config.ports or {}) do
+      -- Ports of other services ("db:5432") are forwarded from their container once the project is up
+      if port.host_port and port.container_port and not port.service and docker.is_published_at_create(port) then
+        table.insert(ports, string.format('%d:%d', port.host_port, port.container_port))
+      end
+    end
+    if #ports > 0 then
+      service.ports = ports
+    end
+  else
+    log.info('Service %s defines its own network, skipping port publishing', config.service)
+  end
+
+  return { services = { [config.service] = service } }
+end
+
+-- Resolve the merged compose configuration (used to inspect the service definition)
+function M.get_compose_config(config)
+  local docker = require('container.docker')
+  local args = M.build_base_args(config, false)
+  vim.list_extend(args, { 'config', '--format', 'json' })
+
+  local result = docker.run_docker_command(args, { cwd = config.compose_project_dir })
+  if not result.success then
+    return nil, result.stderr
+  end
+
+  local ok, decoded = pcall(vim.json.decode, result.stdout)
+  if not ok or type(decoded) ~= 'table' then
+    return nil, 'Failed to decode compose configuration'
+  end
+  return decoded
+end
+
+-- Write the override file to disk
+-- @return boolean, string|nil, table|nil: success, error and the merged compose configuration when it was readable
+function M.write_override(config)
+  local compose_config, err = M.get_compose_config(config)
+  if not compose_config then
+    log.warn('Could not inspect compose configuration: %s', err or 'unknown')
+  elseif not (compose_config.services and compose_config.services[config.service]) then
+    return false, string.format('Service "%s" not found in compose file', config.service)
+  end
+
+  require('container.docker').resolve_port_conflicts(config.ports)
+  local override = M.build_override(config, compose_config)
+  local ok, err = fs.write_file(M.get_override_path(config), vim.json.encode(override))
+  return ok, err, compose_config
+end
+
+-- Report the build context size of every service built by a build or up
+-- @param config table: normalized configuration
+-- @param compose_config table|nil: merged compose configuration
+function M.report_build_contexts(config, compose_config, on_progress)
+  local services = M.get_services_to_start(config)
+  if #services == 0 then
+    services = vim.tbl_keys(compose_config and compose_config.services or {})
+    table.sort(services)
+  end
+  for _, name in ipairs(services) do
+    local service = compose_config.services[name]
+    local build = service and service.build
+    -- The merged configuration has absolute contexts; a string is the short form of build.context
+    local context = type(build) == 'string' and build or type(build) == 'table' and build.context
+    if context and not context:match('^%a+://') then
+      local dockerfile = type(build) == 'table' and build.dockerfile
+      if dockerfile then
+        dockerfile = fs.resolve_path(dockerfile, context)
+      end
+      require('container.build_context').report(context, dockerfile, on_progress, name)
+    end
+  end
+end
+
+-- Run a docker compose command streaming output lines to on_progress
+-- opts.pipeline: workspace root whose start pipeline the job belongs to
+local function run_streaming(args, opts, on_progress, callback)
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, args)
+  local logged = require('container.docker').log_command(cmd)
+
+  local output = {}
+  local function on_data(_, data)
+    for _, line in ipairs(data or {}) do
+      if line ~= '' then
+        table.insert(output, line)
+        if on_progress then
+          on_progress(line)
+        end
+      end
+    end
+  end
+
+  local job_id = vim.fn.jobstart(cmd, {
+    cwd = opts.cwd,
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      logged(exit_code)
+      vim.schedule(function()
+        callback({
+          success = exit_code == 0,
+          code = exit_code,
+          stdout = table.concat(output, '\n'),
+          stderr = exit_code ~= 0 and table.concat(output, '\n') or '',
+        })
+      end)
+    end,
+  })
+
+  if job_id <= 0 then
+    callback({ success = false, code = -1, stdout = '', stderr = 'Failed to start docker compose' })
+  elseif opts.pipeline then
+    require('container.pipeline').track(opts.pipeline, job_id)
+  end
+  return job_id
+end
+
+-- Build images of the compose services
+function M.build(config, on_progress, callback)
+  local ok, err, compose_config = M.write_override(config)
+  if not ok then
+    callback(false, { success = false, stderr = err or 'Failed to write compose override file' })
+    return
+  end
+  if compose_config then
+    M.report_build_contexts(config, compose_config, on_progress)
+  end
+
+  local args = M.build_base_args(config, true)
+  table.insert(args, 'build')
+  if config.force_rebuild then
+    table.insert(args, '--no-cache')
+  end
+  vim.list_extend(args, M.get_services_to_start(config))
+
+  local opts = { cwd = config.compose_project_dir, pipeline = config.workspace_root }
+  run_streaming(args, opts, on_progress, function(result)
+    callback(result.success, result)
+  end)
+end
+
+-- Start the compose services and return the attached service container ID
+function M.up(config, on_progress, callback)
+  require('container.docker').warn_privileged(config)
+  local ok, err, compose_config = M.write_override(config)
+  if not ok then
+    callback(nil, err or 'Failed to write compose override file')
+    return
+  end
+  -- up --build builds the services whose images are missing
+  if compose_config then
+    M.report_build_contexts(config, compose_config, on_progress)
+  end
+
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'up', '-d', '--build' })
+  if config.force_rebuild then
+    table.insert(args, '--force-recreate')
+  end
+  vim.list_extend(args, M.get_services_to_start(config))
+
+  local opts = { cwd = config.compose_project_dir, pipeline = config.workspace_root }
+  run_streaming(args, opts, on_progress, function(result)
+    if not result.success then
+      callback(nil, 'docker compose up failed: ' .. result.stderr)
+      return
+    end
+    M.get_service_container(config, callback)
+  end)
+end
+
+-- Find the container ID of the attached service
+function M.get_service_container(config, callback)
+  local docker = require('container.docker')
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'ps', '-q', config.service })
+
+  docker.run_docker_command_async(args, { cwd = config.compose_project_dir }, function(result)
+    local container_id = result.success and vim.trim(result.stdout):match('^(%S+)') or nil
+    if not container_id then
+      callback(nil, string.format('No running container for service "%s"', config.service))
+      return
+    end
+    callback(container_id, nil)
+  end)
+end
+
+-- Find the container of a service synchronously (first replica), for commands run in another service
+-- @return string|nil: container ID, nil when the service is not running
+function M.find_service_container(config, service)
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'ps', '-q', service })
+  local result = require('container.docker').run_docker_command(args, { cwd = config.compose_project_dir })
+  return result.success and vim.trim(result.stdout):match('^(%S+)') or nil
+end
+
+-- Find the running containers of a service, in replica order
+-- @param callback function(ids): container IDs, empty when the service is not running
+function M.get_service_containers(config, service, callback)
+  local docker = require('container.docker')
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'ps', '--format', '{{.ID}}\t{{.Name}}', service })
+
+  docker.run_docker_command_async(args, { cwd = config.compose_project_dir }, function(result)
+    local containers = {}
+    for line in (result.success and result.stdout or ''):gmatch('[^\n]+') do
+      local id, name = line:match('^(%S+)\t(.*)$')
+      if id then
+        -- Replicas are named <project>-<service>-<number>
+        table.insert(containers, { id = id, replica = tonumber(name:match('(%d+)$')) or 0 })
+      end
+    end
+    table.sort(containers, function(a, b)
+      return a.replica < b.replica
+    end)
+    callback(vim.tbl_map(function(container)
+      return container.id
+    end, containers))
+  end)
+end
+
+-- Resolve the workspace folder inside the attached service
+-- workspaceFolder from devcontainer.json wins, otherwise the service working directory is used
+function M.resolve_workspace_folder(config, container_id)
+  if config.workspace_folder_specified then
+    return config.workspace_folder
+  end
+
+  local docker = require('container.docker')
+  local result = docker.run_docker_command({ 'inspect', '--format', '{{.Config.WorkingDir}}', container_id })
+  local working_dir = result.success and vim.trim(result.stdout) or ''
+  if working_dir ~= '' then
+    return working_dir
+  end
+  return config.workspace_folder or '/'
+end
+
+-- Stop the project's services, giving them `timeout` seconds to exit after SIGTERM
+-- The callback receives { force_killed = true } when the attached service had to be killed.
+-- @param container_id string|nil: container of the attached service
+function M.stop(config, container_id, timeout, on_progress, callback)
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'stop', '-t', tostring(timeout) })
+
+  run_streaming(args, { cwd = config.compose_project_dir }, on_progress, function(result)
+    if not result.success then
+      callback(false, result.stderr)
+      return
+    end
+    if not container_id then
+      callback(true, nil, { force_killed = false })
+      return
+    end
+    require('container.docker').was_force_killed_async(container_id, function(force_killed)
+      callback(true, nil, { force_killed = force_killed })
+    end)
+  end)
+end
+
+-- Restart the attached service in place
+function M.restart(config, on_progress, callback)
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'restart', '-t', tostring(require('container.docker').get_stop_timeout()), config.service })
+
+  run_streaming(args, { cwd = config.compose_project_dir }, on_progress, function(result)
+    callback(result.success, result.success and nil or result.stderr)
+  end)
+end
+
+-- Stop and remove all services of the compose project
+function M.down(config, on_progress, callback)
+  local args = M.build_base_args(config, true)
+  vim.list_extend(args, { 'down', '-t', tostring(require('container.docker').get_stop_timeout()) })
+
+  run_streaming(args, { cwd = config.compose_project_dir }, on_progress, function(result)
+    callback(result.success, result.success and nil or result.stderr)
+  end)
+end
+
+return M
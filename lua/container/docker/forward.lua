@@ -0,0 +1,224 @@
+-- lua/container/docker/forward.lua
+-- Dynamic port forwarding for running containers
+-- Docker cannot publish new ports on a running container, so each forward runs a small
+-- socat sidecar on the container's network that publishes the host port and relays to it.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Default image used for forwarding sidecars
+M.DEFAULT_IMAGE = 'alpine/socat'
+
+-- Label attached to sidecars, value is the forwarded container ID
+M.LABEL = 'container.nvim.forward'
+
+-- Label of sidecars forwarding a port of another compose service, value is the service name
+-- Their M.LABEL is the attached container, so they are listed and stopped with its own forwards.
+M.SERVICE_LABEL = 'container.nvim.forward.service'
+
+-- Name of the sidecar container for a forward
+function M.get_sidecar_name(container_name, container_port)
+  local clean_name = (container_name or 'container'):gsub('^/', ''):gsub('[^%w_.-]', '-')
+  return string.format('%s-forward-%d', clean_name, container_port)
+end
+
+-- Extract the network to relay through from docker inspect output
+-- @param info table: docker inspect result for the target container
+-- @return table|nil: { mode, network, ip } or nil with an error message
+function M.get_network_target(info)
+  local host_config = info and info.HostConfig or {}
+  local mode = host_config.NetworkMode or 'default'
+
+  if mode == 'host' then
+    return { mode = 'host' }
+  end
+
+  if mode == 'none' or mode:match('^container:') or mode:match('^service:') then
+    return nil,
+      string.format(
+        'Container network mode "%s" cannot publish ports. Add the port to forwardPorts and rebuild the container',
+        mode
+      )
+  end
+
+  local networks = info and info.NetworkSettings and info.NetworkSettings.Networks or {}
+  local names = vim.tbl_keys(networks)
+  table.sort(names)
+
+  -- Prefer the configured network mode, then any network with an address
+  if networks[mode] and networks[mode].IPAddress ~= '' then
+    return { mode = mode, network = mode, ip = networks[mode].IPAddress }
+  end
+  for _, name in ipairs(names) do
+    local ip = networks[name].IPAddress
+    if ip and ip ~= '' then
+      return { mode = mode, network = name, ip = ip }
+    end
+  end
+
+  return nil, 'Container has no reachable network address. Add the port to forwardPorts and rebuild the container'
+end
+
+-- Build docker run arguments for a forwarding sidecar
+-- @param opts table: { name, container_id, network, ip, container_port, host_port, bind_address, image, service }
+function M.build_run_args(opts)
+  local publish = string.format('%d:%d', opts.host_port, opts.container_port)
+  if opts.bind_address and opts.bind_address ~= '' then
+    publish = opts.bind_address .. ':' .. publish
+  end
+
+  local args = {
+    'run',
+    '-d',
+    '--rm',
+    '--name',
+    opts.name,
+    '--label',
+    M.LABEL .. '=' .. opts.container_id,
+  }
+  if opts.service then
+    vim.list_extend(args, { '--label', M.SERVICE_LABEL .. '=' .. opts.service })
+  end
+  vim.list_extend(args, {
+    '--network',
+    opts.network,
+    '-p',
+    publish,
+    opts.image or M.DEFAULT_IMAGE,
+    string.format('TCP-LISTEN:%d,fork,reuseaddr', opts.container_port),
+    string.format('TCP-CONNECT:%s:%d', opts.ip, opts.container_port),
+  })
+  return args
+end
+
+-- Start forwarding host_port to container_port of a running container
+-- @param container_id string: target container
+-- @param container_port number: port inside the container
+-- @param host_port number: port on the host
+-- @param opts table: { bind_address, image, owner, service }; a port of another compose service is forwarded with
+--   owner (the attached container the sidecar is listed and stopped with) and the name of the service
+-- @param callback function(forward, err): forward = { container_port, host_port, sidecar, network, service }
+function M.start(container_id, container_port, host_port, opts, callback)
+  local docker = require('container.docker')
+  opts = opts or {}
+
+  local info = docker.get_container_info(container_id)
+  if not info then
+    callback(nil, 'Failed to inspect container ' .. container_id)
+    return
+  end
+
+  local target, err = M.get_network_target(info)
+  if not target then
+    callback(nil, err)
+    return
+  end
+
+  if target.mode == 'host' then
+    -- Ports of host network containers are already reachable on the host
+    callback({ container_port = container_port, host_port = container_port, network = 'host', service = opts.service })
+    return
+  end
+
+  local name = M.get_sidecar_name(info.Name, container_port)
+  local args = M.build_run_args({
+    name = name,
+    container_id = opts.owner or container_id,
+    service = opts.service,
+    network = target.network,
+    ip = target.ip,
+    container_port = container_port,
+    host_port = host_port,
+    bind_address = opts.bind_address,
+    image = opts.image,
+  })
+
+  log.info('Starting port forward %d -> %s:%d via %s', host_port, target.ip, container_port, name)
+  docker.run_docker_command_async(args, {}, function(result)
+    if not result.success then
+      local err = result.stderr ~= '' and result.stderr or 'unknown'
+      callback(nil, string.format('Failed to start port forward: %s', err))
+      return
+    end
+    callback({
+      container_port = container_port,
+      host_port = host_port,
+      sidecar = name,
+      network = target.network,
+      service = opts.service,
+    })
+  end)
+end
+
+-- Parse a `docker ps` line of a sidecar (name, ports, networks and service separated by tabs)
+-- @return table|nil: { container_port, host_port, sidecar, network, service }
+function M.parse_sidecar_line(line)
+  local parts = vim.split(line or '', '\t')
+  local host_port, container_port = (parts[2] or ''):match(':(%d+)%->(%d+)/tcp')
+  if not host_port then
+    return nil
+  end
+  return {
+    container_port = tonumber(container_port),
+    host_port = tonumber(host_port),
+    sidecar = parts[1],
+    network = parts[3] ~= '' and parts[3] or nil,
+    service = parts[4] ~= '' and parts[4] or nil,
+  }
+end
+
+-- List forwards whose sidecars are still running for a container
+-- @param callback function(forwards): same shape as the forwards passed to start() callbacks
+function M.list(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async({
+    'ps',
+    '--filter',
+    'label=' .. M.LABEL .. '=' .. container_id,
+    '--format',
+    '{{.Names}}\t{{.Ports}}\t{{.Networks}}\t{{.Label "' .. M.SERVICE_LABEL .. '"}}',
+  }, {}, function(result)
+    local forwards = {}
+    for line in (result.stdout or ''):gmatch('[^\n]+') do
+      local forward = M.parse_sidecar_line(line)
+      if forward then
+        table.insert(forwards, forward)
+      end
+    end
+    callback(forwards)
+  end)
+end
+
+-- Stop every forwarding sidecar attached to a container
+-- @param callback function|nil: called when all sidecars are removed
+function M.stop_all(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async(
+    { 'ps', '-aq', '--filter', 'label=' .. M.LABEL .. '=' .. container_id },
+    {},
+    function(result)
+      local ids = {}
+      for id in (result.stdout or ''):gmatch('%S+') do
+        table.insert(ids, id)
+      end
+      if #ids == 0 then
+        if callback then
+          callback()
+        end
+        return
+      end
+
+      local args = { 'rm', '-f' }
+      vim.list_extend(args, ids)
+      log.info('Removing %d port forward sidecar(s)', #ids)
+      docker.run_docker_command_async(args, {}, function()
+        if callback then
+          callback()
+        end
+      end)
+    end
+  )
+end
+
+return M
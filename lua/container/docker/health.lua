@@ -0,0 +1,110 @@
+-- lua/container/docker/health.lua
+-- Waiting for the HEALTHCHECK of a container (from the image or the compose service)
+-- Docker reports a container as running as soon as its process starts; when a health check is defined
+-- the container is only treated as ready once the check passes.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Milliseconds between health polls
+M.POLL_INTERVAL = 1000
+
+-- Seconds to wait when docker.health_timeout is not configured
+M.DEFAULT_TIMEOUT = 120
+
+local function configured_timeout()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  local docker_config = ok and plugin_config and plugin_config.docker or {}
+  return docker_config.health_timeout or M.DEFAULT_TIMEOUT
+end
+
+-- Parse the output of `inspect --format '{{json .State.Health}}'`
+-- @return table|nil: { status, output, exit_code } of the latest probe and the failing streak, nil when the container
+-- has no health check
+function M.parse_health(stdout)
+  local text = vim.trim(stdout or '')
+  if text == '' or text == 'null' or text == '<no value>' then
+    return nil
+  end
+
+  local ok, health = pcall(vim.json.decode, text)
+  if not ok or type(health) ~= 'table' or not health.Status then
+    return nil
+  end
+
+  local probes = type(health.Log) == 'table' and health.Log or {}
+  local last = probes[#probes]
+  return {
+    status = health.Status,
+    output = last and vim.trim(last.Output or '') or '',
+    exit_code = last and last.ExitCode or nil,
+    failing_streak = tonumber(health.FailingStreak) or 0,
+  }
+end
+
+-- Read the health state of a container
+-- @param callback function(health|nil)
+function M.get(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async(
+    { 'inspect', '--format', '{{json .State.Health}}', container_id },
+    {},
+    function(result)
+      callback(result.success and M.parse_health(result.stdout) or nil)
+    end
+  )
+end
+
+-- Wait until the container is healthy
+-- Containers without a health check are ready immediately. An unhealthy status keeps being polled,
+-- since Docker retries the check, until the timeout.
+-- @param opts table|nil: { timeout = seconds (default: docker.health_timeout), on_status = function(health) }
+-- @param callback function(ready, health): health holds the last probe when the wait timed out
+function M.wait(container_id, opts, callback)
+  opts = opts or {}
+  local timeout = opts.timeout or configured_timeout()
+  if timeout <= 0 then
+    callback(true)
+    return
+  end
+
+  local uv = vim.uv or vim.loop
+  local deadline = uv.now() + timeout * 1000
+  local last_status = nil
+
+  local function poll()
+    M.get(container_id, function(health)
+      if not health then
+        callback(true)
+        return
+      end
+      if health.status == 'healthy' then
+        log.info('Container %s is healthy', container_id)
+        callback(true, health)
+        return
+      end
+
+      if health.status ~= last_status then
+        last_status = health.status
+        log.info('Waiting for container health check: %s', health.status)
+        if opts.on_status then
+          opts.on_status(health)
+        end
+      end
+
+      if uv.now() >= deadline then
+        log.warn('Container %s not healthy after %ds: %s', container_id, timeout, health.output)
+        callback(false, health)
+        return
+      end
+      vim.defer_fn(poll, M.POLL_INTERVAL)
+    end)
+  end
+
+  poll()
+end
+
+return M
@@ -3,10 +3,29 @@
 
 local M = {}
 local log = require('container.utils.log')
+local runtime = require('container.docker.runtime')
 
 -- Shell detection cache to avoid repeated checks
 local shell_cache = {}
 
+-- Log a docker invocation at debug level (frequent status checks at trace level)
+-- @param command string|table
+-- @param lightweight boolean|nil: a status check (inspect, images, ps)
+-- @return function(exit_code): logs the exit code and duration of the invocation
+function M.log_command(command, lightweight)
+  local write = lightweight and log.trace or log.debug
+  if type(command) == 'table' then
+    command = table.concat(command, ' ')
+  end
+  write('Executing: %s', command)
+  local uv = vim.uv or vim.loop
+  local started = uv and uv.hrtime and uv.hrtime()
+  return function(exit_code)
+    local elapsed = started and string.format(' in %d ms', (uv.hrtime() - started) / 1e6) or ''
+    write('Exited with code %s%s: %s', tostring(exit_code), elapsed, command)
+  end
+end
+
 -- Helper function to detect E2E test environment
 local function is_e2e_test_environment()
   return vim.v.argv
@@ -23,6 +42,34 @@ local function safe_system_call(cmd)
   return vim.fn.system(cmd)
 end
 
+-- Run a docker command synchronously with extra environment variables, which vim.fn.system cannot set
+-- @return string, number: output (stdout then stderr) and exit code
+local function system_with_env(args, env, cwd)
+  local cmd_args = { runtime.get() }
+  vim.list_extend(cmd_args, args)
+  local stdout, stderr = {}, {}
+  local job_id = vim.fn.jobstart(cmd_args, {
+    env = env,
+    cwd = cwd,
+    stdout_buffered = true,
+    stderr_buffered = true,
+    on_stdout = function(_, data)
+      stdout = data or {}
+    end,
+    on_stderr = function(_, data)
+      stderr = data or {}
+    end,
+  })
+  if job_id <= 0 then
+    return '', -1
+  end
+  local exit_code = vim.fn.jobwait({ job_id }, is_e2e_test_environment() and 15000 or -1)[1]
+  if exit_code == -1 then
+    vim.fn.jobstop(job_id)
+  end
+  return table.concat(stdout, '\n') .. table.concat(stderr, '\n'), exit_code
+end
+
 -- Detect available shell in container
 local function detect_shell(container_id)
   if shell_cache[container_id] then
@@ -30,7 +77,8 @@ local function detect_shell(container_id)
   end
 
   -- Check if container is running first
-  local status_cmd = string.format('docker inspect -f "{{.State.Status}}" %s 2>/dev/null', container_id)
+  local status_cmd =
+    string.format('%s inspect -f "{{.State.Status}}" %s 2>/dev/null', runtime.get(), container_id)
   local status_result = safe_system_call(status_cmd)
   if vim.v.shell_error ~= 0 or not status_result:match('running') then
     log.debug('Container %s not running, using default shell: sh', container_id)
@@ -40,7 +88,7 @@ local function detect_shell(container_id)
   local shells = { 'bash', 'zsh', 'sh' }
 
   for _, shell in ipairs(shells) do
-    local cmd = string.format('docker exec %s which %s 2>/dev/null', container_id, shell)
+    local cmd = string.format('%s exec %s which %s 2>/dev/null', runtime.get(), container_id, shell)
     local result = safe_system_call(cmd)
     if vim.v.shell_error == 0 and result:match(shell) then
       log.debug('Detected shell in container %s: %s', container_id, shell)
@@ -75,7 +123,7 @@ end
 function M.check_docker_availability()
   log.debug('Checking Docker availability (sync)')
 
-  local _ = safe_system_call('docker --version 2>/dev/null')
+  local _ = safe_system_call(runtime.get() .. ' --version 2>/dev/null')
   local exit_code = vim.v.shell_error
 
   if exit_code ~= 0 then
@@ -85,13 +133,13 @@ function M.check_docker_availability()
   end
 
   -- Check Docker daemon operation with timeout
-  _ = safe_system_call('docker ps 2>/dev/null')
+  local output = safe_system_call(table.concat(M.ping_args(), ' ') .. ' 2>&1')
   exit_code = vim.v.shell_error
 
   if exit_code ~= 0 then
-    log.error('Docker daemon is not running or timed out')
+    log.error('Docker daemon is not running or timed out: %s', vim.trim(output or ''))
     local error_msg = M._build_docker_daemon_error()
-    return false, error_msg
+    return false, error_msg, vim.trim(output or '')
   end
 
   log.info('Docker is available and running')
@@ -178,6 +226,14 @@ local function run_job_with_wait(cmd_args, job_opts, timeout_ms)
   return job_id
 end
 
+-- Lightweight daemon check: ask the daemon for its version
+function M.ping_args()
+  local format = runtime.is_podman() and '{{.Version.Version}}' or '{{.ServerVersion}}'
+  return { runtime.get(), 'info', '--format', format }
+end
+
+-- Check that the runtime is installed and its daemon reachable
+-- @param callback function(available, error_msg, detail): detail is the runtime's own error output
 function M.check_docker_availability_async(callback)
   log.debug('Checking Docker availability (async)')
 
@@ -192,12 +248,17 @@ function M.check_docker_availability_async(callback)
       end
 
       -- Docker daemon check
+      local stderr = {}
       local daemon_job_opts = {
+        on_stderr = function(_, data)
+          vim.list_extend(stderr, data or {})
+        end,
         on_exit = function(_, daemon_exit_code, _)
           if daemon_exit_code ~= 0 then
-            log.error('Docker daemon is not running')
+            local detail = vim.trim(table.concat(stderr, '\n'))
+            log.error('Docker daemon is not reachable: %s', detail)
             local error_msg = M._build_docker_daemon_error()
-            callback(false, error_msg)
+            callback(false, error_msg, detail)
           else
             log.info('Docker is available and running')
             callback(true)
@@ -208,9 +269,9 @@ function M.check_docker_availability_async(callback)
       }
 
       if is_headless_mode() then
-        run_job_with_wait({ 'docker', 'ps' }, daemon_job_opts, 5000)
+        run_job_with_wait(M.ping_args(), daemon_job_opts, 5000)
       else
-        vim.fn.jobstart({ 'docker', 'ps' }, daemon_job_opts)
+        vim.fn.jobstart(M.ping_args(), daemon_job_opts)
       end
     end,
     stdout_buffered = true,
@@ -218,12 +279,98 @@ function M.check_docker_availability_async(callback)
   }
 
   if is_headless_mode() then
-    run_job_with_wait({ 'docker', '--version' }, version_job_opts, 3000)
+    run_job_with_wait({ runtime.get(), '--version' }, version_job_opts, 3000)
   else
-    vim.fn.jobstart({ 'docker', '--version' }, version_job_opts)
+    vim.fn.jobstart({ runtime.get(), '--version' }, version_job_opts)
   end
 end
 
+-- Commands run again after a transient failure (docker.retries)
+-- Only commands that leave the same state when run twice: a pull, creating a network or volume (an existing one
+-- fails the retry instead of being duplicated) and starting a created container (a running one is left alone).
+-- Other state-changing commands (run, create, exec, rm, ...) are never retried unless the caller passes
+-- opts.retry = true.
+M.IDEMPOTENT_COMMANDS = {
+  { 'pull' },
+  { 'image', 'pull' },
+  { 'network', 'create' },
+  { 'volume', 'create' },
+  { 'start' },
+}
+
+-- Error output of failures that may pass when tried again (matched lowercased)
+M.TRANSIENT_ERROR_PATTERNS = {
+  'port is already allocated',
+  'address already in use',
+  'timeout',
+  'timed out',
+  'connection reset',
+  'connection refused',
+  'temporary failure',
+  'network is unreachable',
+  'no route to host',
+  'tls handshake',
+  'unexpected eof',
+  'service unavailable',
+  'too many requests',
+  'toomanyrequests',
+}
+
+-- Defaults of docker.retries and docker.retry_delay (milliseconds before the first retry, doubled for each next
+-- one up to RETRY_MAX_DELAY)
+M.DEFAULT_RETRIES = 3
+M.DEFAULT_RETRY_DELAY = 1000
+M.RETRY_MAX_DELAY = 30000
+
+-- Check whether a docker command may be run again after a failure
+-- @param args table: docker arguments without the runtime
+-- @return boolean
+function M.is_idempotent(args)
+  for _, command in ipairs(M.IDEMPOTENT_COMMANDS) do
+    local matches = true
+    for i, word in ipairs(command) do
+      if args[i] ~= word then
+        matches = false
+        break
+      end
+    end
+    if matches then
+      return true
+    end
+  end
+  return false
+end
+
+-- Check whether a failure looks transient (port still held, network hiccup, registry rate limit)
+-- @param stderr string|nil
+-- @return boolean
+function M.is_transient_error(stderr)
+  local text = (stderr or ''):lower()
+  for _, pattern in ipairs(M.TRANSIENT_ERROR_PATTERNS) do
+    if text:find(pattern, 1, true) then
+      return true
+    end
+  end
+  return false
+end
+
+-- Retry settings: { retries, delay }
+function M.retry_settings()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local get = ok and plugin_config.get_value
+  return {
+    retries = get and get('docker.retries') or M.DEFAULT_RETRIES,
+    delay = get and get('docker.retry_delay') or M.DEFAULT_RETRY_DELAY,
+  }
+end
+
+-- Milliseconds to wait before a retry (exponential backoff)
+-- @param retry number: 1 for the first retry
+-- @return number
+function M.retry_delay(retry)
+  return math.min(M.retry_settings().delay * 2 ^ (retry - 1), M.RETRY_MAX_DELAY)
+end
+
 -- Execute command synchronously
 -- Synchronous Docker command execution (kept for compatibility)
 function M.run_docker_command(args, opts)
@@ -234,7 +381,7 @@ function M.run_docker_command(args, opts)
   for _, arg in ipairs(args) do
     table.insert(escaped_args, vim.fn.shellescape(arg))
   end
-  local cmd = 'docker ' .. table.concat(escaped_args, ' ')
+  local cmd = runtime.get() .. ' ' .. table.concat(escaped_args, ' ')
 
   if opts.cwd then
     cmd = 'cd ' .. vim.fn.shellescape(opts.cwd) .. ' && ' .. cmd
@@ -250,13 +397,18 @@ function M.run_docker_command(args, opts)
     end
   end
 
-  -- Only log important commands, suppress frequent status checks
-  if not is_lightweight_command or (opts and opts.verbose) then
-    log.debug('Executing (sync): %s', cmd)
-  end
+  -- Frequent status checks are only logged at trace level
+  local logged = M.log_command(cmd, is_lightweight_command and not opts.verbose)
 
-  local stdout = safe_system_call(cmd)
-  local exit_code = vim.v.shell_error
+  local stdout, exit_code
+  local job_env = require('container.secrets').job_env(args)
+  if job_env then
+    stdout, exit_code = system_with_env(args, job_env, opts.cwd)
+  else
+    stdout = safe_system_call(cmd)
+    exit_code = vim.v.shell_error
+  end
+  logged(exit_code)
 
   return {
     success = exit_code == 0,
@@ -266,11 +418,38 @@ function M.run_docker_command(args, opts)
   }
 end
 
--- Asynchronous Docker command execution
-function M.run_docker_command_async(args, opts, callback)
-  opts = opts or {}
+-- Run docker commands side by side and wait for them at most timeout_ms in total
+-- The commands are detached, so ones still running after the wait finish on their own even when Neovim exits.
+-- @param commands table: list of { args = table, cwd = string|nil }
+-- @param timeout_ms number
+-- @return number: commands that had not finished by the end of the wait
+function M.run_docker_commands_detached(commands, timeout_ms)
+  local job_ids = {}
+  for _, command in ipairs(commands) do
+    local cmd_args = { runtime.get() }
+    vim.list_extend(cmd_args, command.args)
+    M.log_command(cmd_args)
+    local job_id = vim.fn.jobstart(cmd_args, { cwd = command.cwd, detach = true })
+    if job_id > 0 then
+      table.insert(job_ids, job_id)
+    end
+  end
+  if #job_ids == 0 then
+    return 0
+  end
+
+  local pending = 0
+  for _, code in ipairs(vim.fn.jobwait(job_ids, timeout_ms)) do
+    if code == -1 then
+      pending = pending + 1
+    end
+  end
+  return pending
+end
 
-  local cmd_args = { 'docker' }
+-- Run a docker command once
+local function run_async_once(args, opts, callback)
+  local cmd_args = { runtime.get() }
   for _, arg in ipairs(args) do
     table.insert(cmd_args, arg)
   end
@@ -285,34 +464,29 @@ function M.run_docker_command_async(args, opts, callback)
     end
   end
 
-  -- Only log important commands, suppress frequent status checks
-  if not is_lightweight_command or (opts and opts.verbose) then
-    log.debug('Executing (async): %s', table.concat(cmd_args, ' '))
-  end
+  -- Frequent status checks are only logged at trace level
+  local logged = M.log_command(cmd_args, is_lightweight_command and not opts.verbose)
 
   local stdout_lines = {}
   local stderr_lines = {}
+  -- Blank lines are dropped unless opts.keep_empty asks for the output as printed
+  local function collect(lines, data)
+    for _, line in ipairs(data or {}) do
+      if opts.keep_empty or line ~= '' then
+        table.insert(lines, line)
+      end
+    end
+  end
 
   local job_opts = {
     on_stdout = function(_, data, _)
-      if data then
-        for _, line in ipairs(data) do
-          if line ~= '' then
-            table.insert(stdout_lines, line)
-          end
-        end
-      end
+      collect(stdout_lines, data)
     end,
     on_stderr = function(_, data, _)
-      if data then
-        for _, line in ipairs(data) do
-          if line ~= '' then
-            table.insert(stderr_lines, line)
-          end
-        end
-      end
+      collect(stderr_lines, data)
     end,
     on_exit = function(_, exit_code, _)
+      logged(exit_code)
       local result = {
         success = exit_code == 0,
         code = exit_code,
@@ -333,14 +507,73 @@ function M.run_docker_command_async(args, opts, callback)
   if opts.cwd then
     job_opts.cwd = opts.cwd
   end
+  -- Secrets named by -e or --secret reach docker through the environment of this job only
+  job_opts.env = require('container.secrets').job_env(args)
 
   -- Use the new helper function that handles headless mode properly
   local timeout_ms = (opts.timeout or 30) * 1000 -- Convert seconds to milliseconds
+  local job_id
   if is_headless_mode() then
-    return run_job_with_wait(cmd_args, job_opts, timeout_ms)
+    job_id = run_job_with_wait(cmd_args, job_opts, timeout_ms)
   else
-    return vim.fn.jobstart(cmd_args, job_opts)
+    job_id = vim.fn.jobstart(cmd_args, job_opts)
+  end
+  if opts.pipeline then
+    require('container.pipeline').track(opts.pipeline, job_id)
+  end
+  return job_id
+end
+
+-- Asynchronous Docker command execution
+-- Idempotent commands (IDEMPOTENT_COMMANDS, or opts.retry = true) that fail with a transient error are run again
+-- up to docker.retries times with exponential backoff; each retry is logged. opts.retry = false never retries.
+-- opts.pipeline: workspace root whose start pipeline the job belongs to (stopped when the start is cancelled)
+-- opts.keep_empty: keep blank lines, so stdout and stderr are returned exactly as printed
+-- @return number: job id of the first attempt
+function M.run_docker_command_async(args, opts, callback)
+  opts = opts or {}
+
+  local retry = opts.retry
+  if retry == nil then
+    retry = M.is_idempotent(args)
+  end
+  if not retry then
+    return run_async_once(args, opts, callback)
+  end
+
+  local pipeline = opts.pipeline and require('container.pipeline')
+  local run = pipeline and pipeline.active(opts.pipeline)
+  local retries = M.retry_settings().retries
+  local attempt = 0
+
+  local function try()
+    attempt = attempt + 1
+    return run_async_once(args, opts, function(result)
+      local retryable = not result.success
+        and result.code ~= M.JOB_STOPPED_EXIT_CODE
+        and attempt <= retries
+        and not (pipeline and pipeline.is_cancelled(run))
+        and M.is_transient_error(result.stderr)
+      if retryable then
+        local delay = M.retry_delay(attempt)
+        log.warn(
+          'docker %s failed (%s), retrying in %d ms (retry %d/%d)',
+          table.concat(args, ' '),
+          result.stderr:match('[^\n]+') or 'exit code ' .. tostring(result.code),
+          delay,
+          attempt,
+          retries
+        )
+        vim.defer_fn(try, delay)
+        return
+      end
+      if callback then
+        callback(result)
+      end
+    end)
   end
+
+  return try()
 end
 
 -- Check Docker image existence
@@ -373,10 +606,14 @@ function M.check_image_exists_async(image_name, callback)
   end)
 end
 
+-- Exit code of a job stopped with jobstop() (SIGTERM)
+M.JOB_STOPPED_EXIT_CODE = 143
+
 -- Docker image pull with retry mechanism
+-- Transient failures are retried like other idempotent commands (docker.retries, docker.retry_delay).
 function M.pull_image_async(image_name, on_progress, on_complete, retry_count)
   retry_count = retry_count or 0
-  local max_retries = 2
+  local max_retries = M.retry_settings().retries
 
   log.info('Pulling Docker image (async): %s (attempt %d/%d)', image_name, retry_count + 1, max_retries + 1)
 
@@ -395,7 +632,7 @@ function M.pull_image_async(image_name, on_progress, on_complete, retry_count)
 
   local job_id
   if is_headless_mode() then
-    job_id = run_job_with_wait({ 'docker', 'pull', image_name }, {
+    job_id = run_job_with_wait({ runtime.get(), 'pull', image_name }, {
       on_stdout = function(job_id, data, event)
         log.debug('Docker pull stdout callback triggered (job: %d, event: %s)', job_id, event)
         data_received = true
@@ -491,23 +728,27 @@ function M.pull_image_async(image_name, on_progress, on_complete, retry_count)
 
         if on_complete then
           vim.schedule(function()
-            -- Retry logic for network failures
-            if not result.success and retry_count < max_retries then
+            -- Retry logic for network failures; pulls stopped on purpose (timeout, cancelled start) are not retried
+            if not result.success and exit_code ~= M.JOB_STOPPED_EXIT_CODE and retry_count < max_retries then
               -- Check if error is potentially retryable (network-related)
-              local stderr_output = table.concat(stderr_lines, '\n'):lower()
-              local is_retryable = stderr_output:match('timeout')
-                or stderr_output:match('network')
-                or stderr_output:match('connection')
-                or stderr_output:match('temporary failure')
+              local stderr_output = table.concat(stderr_lines, '\n')
+              local is_retryable = M.is_transient_error(stderr_output)
+                or stderr_output:lower():match('network')
                 or exit_code == 124 -- timeout exit code
 
               if is_retryable then
-                local wait_time = (retry_count + 1) * 2000 -- 2s, 4s, 6s
-                log.info('Retrying image pull in %d seconds...', wait_time / 1000)
+                local wait_time = M.retry_delay(retry_count + 1)
+                log.warn(
+                  'Image pull of %s failed, retrying in %d ms (retry %d/%d)',
+                  image_name,
+                  wait_time,
+                  retry_count + 1,
+                  max_retries
+                )
                 if on_progress then
                   on_progress(
                     string.format(
-                      'Retrying in %d seconds... (attempt %d/%d)',
+                      'Retrying in %.1fs... (attempt %d/%d)',
                       wait_time / 1000,
                       retry_count + 2,
                       max_retries + 1
@@ -533,7 +774,7 @@ function M.pull_image_async(image_name, on_progress, on_complete, retry_count)
     }, 300000) -- 5 minute timeout for image pulls
   else
     -- Use normal jobstart for non-headless mode
-    job_id = vim.fn.jobstart({ 'docker', 'pull', image_name }, {
+    job_id = vim.fn.jobstart({ runtime.get(), 'pull', image_name }, {
       on_stdout = function(job_id, data, event)
         log.debug('Docker pull stdout callback triggered (job: %d, event: %s)', job_id, event)
         data_received = true
@@ -609,13 +850,31 @@ function M.pull_image_async(image_name, on_progress, on_complete, retry_count)
               error = exit_code ~= 0 and table.concat(stderr_lines, '\n') or nil,
             }
 
-            -- Handle retry logic here
-            if not result.success and retry_count < max_retries then
-              local wait_time = (2 ^ retry_count) * 1000
-              log.info('Retrying image pull in %dms (attempt %d/%d)', wait_time, retry_count + 1, max_retries)
+            -- Handle retry logic here; denied pulls fail the same way until the user logs in, and unknown images
+            -- or tags are not retried either
+            if
+              not result.success
+              and exit_code ~= M.JOB_STOPPED_EXIT_CODE
+              and retry_count < max_retries
+              and not require('container.registry').is_auth_error(result.stderr)
+              and M.is_transient_error(result.stderr)
+            then
+              local wait_time = M.retry_delay(retry_count + 1)
+              log.warn(
+                'Image pull of %s failed, retrying in %d ms (retry %d/%d)',
+                image_name,
+                wait_time,
+                retry_count + 1,
+                max_retries
+              )
               if on_progress then
                 on_progress(
-                  string.format('Retrying in %.1fs (attempt %d/%d)...', wait_time / 1000, retry_count + 1, max_retries)
+                  string.format(
+                    'Retrying in %.1fs (attempt %d/%d)...',
+                    wait_time / 1000,
+                    retry_count + 2,
+                    max_retries + 1
+                  )
                 )
               end
 
@@ -755,54 +1014,435 @@ function M.pull_image(image_name, on_progress, on_complete)
   end, 100)
 end
 
--- Docker image build
-function M.build_image(config, on_progress, on_complete)
-  log.info('Building Docker image: %s', config.name)
-
-  vim.defer_fn(function()
-    local args = { 'build' }
+-- Serialize a value with sorted keys so equal tables always produce the same string
+local function canonical_string(value)
+  if type(value) ~= 'table' then
+    return tostring(value)
+  end
+  local keys = vim.tbl_keys(value)
+  table.sort(keys, function(a, b)
+    return tostring(a) < tostring(b)
+  end)
+  local parts = {}
+  for _, key in ipairs(keys) do
+    table.insert(parts, tostring(key) .. '=' .. canonical_string(value[key]))
+  end
+  return '{' .. table.concat(parts, ',') .. '}'
+end
 
-    -- Set tag
-    local tag = config.name:lower():gsub('[^a-z0-9_.-]', '-')
-    table.insert(args, '-t')
-    table.insert(args, tag)
+-- List build context files referenced by COPY/ADD instructions in a Dockerfile
+-- Files excluded by the .dockerignore rules are not sent to docker and are left out.
+-- @param dockerfile_content string: Dockerfile content
+-- @param context_dir string: absolute build context directory
+-- @param ignore_rules table|nil: rules from container.build_context
+-- @return table: sorted list of absolute file paths
+function M.get_build_context_files(dockerfile_content, context_dir, ignore_rules)
+  local fs = require('container.utils.fs')
+  local build_context = require('container.build_context')
+  local files = {}
+  local seen = {}
+
+  local function add_file(path)
+    if seen[path] then
+      return
+    end
+    seen[path] = true
+    local relative = fs.relative_path(path, context_dir)
+    if relative and build_context.is_ignored(relative, ignore_rules) then
+      return
+    end
+    if fs.is_file(path) then
+      table.insert(files, path)
+    end
+  end
 
-    -- Build arguments
-    if config.build_args then
-      for key, value in pairs(config.build_args) do
-        table.insert(args, '--build-arg')
-        table.insert(args, string.format('%s=%s', key, value))
+  -- Join continuation lines before looking at instructions
+  local content = (dockerfile_content or ''):gsub('\\%s*\n', ' ')
+  for line in content:gmatch('[^\n]+') do
+    local instruction, rest = line:match('^%s*(%a+)%s+(.*)$')
+    instruction = instruction and instruction:upper()
+    if (instruction == 'COPY' or instruction == 'ADD') and not rest:match('%-%-from=') then
+      local tokens = {}
+      for token in rest:gmatch('%S+') do
+        if not token:match('^%-%-') then
+          table.insert(tokens, token)
+        end
+      end
+      -- The last token is the destination
+      for i = 1, #tokens - 1 do
+        local source = tokens[i]:gsub('^%[?"', ''):gsub('",?%]?$', '')
+        if source ~= '' and not source:match('^%a+://') then
+          local path = fs.resolve_path(source, context_dir)
+          if fs.is_directory(path) then
+            for _, file in ipairs(vim.fn.globpath(path, '**', false, true)) do
+              add_file(file)
+            end
+          elseif source:find('[*?]') then
+            for _, file in ipairs(vim.fn.glob(path, false, true)) do
+              add_file(file)
+            end
+          else
+            add_file(path)
+          end
+        end
       end
     end
+  end
+
+  table.sort(files)
+  return files
+end
+
+-- Compute the image cache key from everything that affects the built image:
+-- base image, Dockerfile, files copied from the build context, build args and features
+function M.compute_image_cache_key(config)
+  local fs = require('container.utils.fs')
+  local parts = {
+    'image=' .. (config.image or ''),
+    'build_args=' .. canonical_string(config.build_args or {}),
+    'features=' .. canonical_string(config.features or {}),
+  }
+  -- Only added when set so existing keys stay valid
+  if config.build_target then
+    table.insert(parts, 'target=' .. config.build_target)
+  end
+  if config.build_options and #config.build_options > 0 then
+    table.insert(parts, 'options=' .. table.concat(config.build_options, ' '))
+  end
+  if config.default_build_args and #config.default_build_args > 0 then
+    table.insert(parts, 'default_options=' .. table.concat(config.default_build_args, ' '))
+  end
 
-    -- Specify Dockerfile
-    if config.dockerfile then
-      table.insert(args, '-f')
-      table.insert(args, config.dockerfile)
+  if config.dockerfile then
+    local dockerfile_content = fs.read_file(config.dockerfile) or ''
+    local context_dir = fs.resolve_path(config.context or '.', config.base_path or vim.fn.getcwd())
+    table.insert(parts, 'dockerfile=' .. vim.fn.sha256(dockerfile_content))
+    table.insert(parts, 'context=' .. (config.context or '.'))
+    local ignore_rules = require('container.build_context').rules(context_dir, config.dockerfile)
+    for _, file in ipairs(M.get_build_context_files(dockerfile_content, context_dir, ignore_rules)) do
+      local relative = fs.relative_path(file, context_dir) or file
+      table.insert(parts, relative .. '=' .. vim.fn.sha256(fs.read_file(file) or ''))
     end
+  end
 
-    -- Build context
-    local context = config.context or '.'
-    table.insert(args, context)
+  return vim.fn.sha256(table.concat(parts, '\n')):sub(1, 12)
+end
 
-    local result = M.run_docker_command(args, { cwd = config.base_path })
+-- Tag of the cached image for a devcontainer and cache key
+function M.get_image_cache_tag(config, cache_key)
+  local clean_name = (config.name or 'devcontainer'):lower():gsub('[^a-z0-9_.-]', '-')
+  return string.format('container-nvim-%s:%s', clean_name, cache_key)
+end
 
-    if result.success then
-      log.info('Successfully built Docker image: %s', tag)
+-- Label carrying the image cache key of built images, compared with the local key before a prebuilt image is used
+M.CACHE_KEY_LABEL = 'dev.container-nvim.cache-key'
+
+-- Label attached to every image the plugin builds, with the kind of image ('build', 'features' or 'uid') as value
+-- Images keep it once a rebuild leaves them untagged, so :ContainerPrune can find them.
+M.IMAGE_LABEL = 'dev.container-nvim.image'
+
+-- `--label` arguments marking an image built by the plugin
+-- @param kind string: 'build', 'features' or 'uid'
+function M.image_label_args(kind)
+  return { '--label', M.IMAGE_LABEL .. '=' .. kind }
+end
+
+-- Registry images that may hold a prebuilt image of the configuration, in the order they are tried:
+-- customizations.container.nvim.prebuiltImage, then build.cacheFrom images (docker.pull_cache_from)
+-- cacheFrom entries in BuildKit form are used when they are registry caches ("type=registry,ref=...").
+-- @param config table: normalized configuration
+-- @return table: image references
+function M.prebuilt_candidates(config)
+  local candidates = {}
+  local seen = {}
+  local function add(image)
+    if type(image) == 'string' and image ~= '' and not seen[image] then
+      seen[image] = true
+      table.insert(candidates, image)
+    end
+  end
+
+  add(config.prebuilt_image)
+  local plugin_config = require('container.config').get() or {}
+  if (plugin_config.docker or {}).pull_cache_from == false then
+    return candidates
+  end
+  for _, entry in ipairs(config.cache_from or {}) do
+    if not entry:find('=', 1, true) then
+      add(entry)
+    elseif entry:match('^type=registry,') or entry:match(',type=registry') then
+      add(entry:match('ref=([^,]+)'))
+    end
+  end
+  return candidates
+end
+
+-- Pull a prebuilt image and use it when it was built from the same inputs
+-- Candidates are pulled in turn; one whose cache key label matches cache_key is tagged as the cache tag.
+-- Pull failures and images built from other inputs fall through to the next candidate.
+-- @param config table
+-- @param cache_key string: from compute_image_cache_key()
+-- @param on_progress function|nil
+-- @param callback function(image, reasons): image is the candidate used (nil when none matched); reasons say why
+--   candidates were passed over
+function M.use_prebuilt_image(config, cache_key, on_progress, callback)
+  local candidates = M.prebuilt_candidates(config)
+  local tag = M.get_image_cache_tag(config, cache_key)
+  local reasons = {}
+  local pipeline = require('container.pipeline')
+  local run = pipeline.active(config.workspace_root)
+
+  local function try(index)
+    local image = candidates[index]
+    if not image or pipeline.is_cancelled(run) then
+      callback(nil, reasons)
+      return
+    end
+    if on_progress then
+      on_progress('Pulling prebuilt image: ' .. image)
+    end
+    local job_id = M.pull_image_async(image, on_progress, function(success)
+      if not success then
+        table.insert(reasons, 'pull of ' .. image .. ' failed')
+        try(index + 1)
+        return
+      end
+      local format = string.format('{{index .Config.Labels %q}}', M.CACHE_KEY_LABEL)
+      M.run_docker_command_async({ 'image', 'inspect', '--format', format, image }, {}, function(result)
+        local label = result.success and vim.trim(result.stdout or '') or ''
+        if label ~= cache_key then
+          log.info('Prebuilt image %s has cache key %s, local key is %s', image, label, cache_key)
+          table.insert(reasons, image .. ' was built from a different Dockerfile or context')
+          try(index + 1)
+          return
+        end
+        M.run_docker_command_async({ 'tag', image, tag }, {}, function(tag_result)
+          if not tag_result.success then
+            table.insert(reasons, 'tagging ' .. image .. ' failed')
+            try(index + 1)
+            return
+          end
+          callback(image, reasons)
+        end)
+      end)
+    end)
+    pipeline.track(config.workspace_root, job_id)
+  end
+  try(1)
+end
+
+-- `docker build` flags for default_build_args, build.args (sorted by name), build.target, build.cacheFrom and
+-- build.options
+function M.build_option_args(config)
+  -- default_build_args of the plugin come first so the flags of devcontainer.json override them
+  local args = vim.list_extend({}, config.default_build_args or {})
+
+  local names = vim.tbl_keys(config.build_args or {})
+  table.sort(names)
+  for _, name in ipairs(names) do
+    table.insert(args, '--build-arg')
+    table.insert(args, string.format('%s=%s', name, tostring(config.build_args[name])))
+  end
+
+  if config.build_target then
+    table.insert(args, '--target')
+    table.insert(args, config.build_target)
+  end
+
+  for _, image in ipairs(config.cache_from or {}) do
+    table.insert(args, '--cache-from')
+    table.insert(args, image)
+  end
+
+  -- Extra options are passed through as given
+  vim.list_extend(args, config.build_options or {})
+  return args
+end
+
+-- Result passed to build callbacks when the start was cancelled before the build began
+M.CANCELLED_RESULT = { success = false, cancelled = true, stdout = '', stderr = 'Cancelled' }
+
+-- `docker build` arguments (without the runtime) of the devcontainer image, run from config.base_path
+-- @return table: arguments
+-- @return boolean: whether BuildKit is used (DOCKER_BUILDKIT)
+function M.build_image_args(config, tag)
+  local args = { 'build', '-t', tag }
+  vim.list_extend(args, M.image_label_args('build'))
+  if config.force_rebuild then
+    table.insert(args, '--no-cache')
+  end
+  -- Images pushed from CI carry the key so other machines can tell whether they match their checkout
+  if config.image_cache_key then
+    table.insert(args, '--label')
+    table.insert(args, M.CACHE_KEY_LABEL .. '=' .. config.image_cache_key)
+  end
+
+  local plugin_config = require('container.config').get() or {}
+  local buildkit = (plugin_config.docker or {}).build_progress ~= 'plain'
+  -- Podman prints its own STEP lines and has no --progress option
+  if buildkit and not runtime.is_podman() then
+    table.insert(args, '--progress=plain')
+  elseif not runtime.is_podman() then
+    -- The classic builder keeps intermediate containers of interrupted builds unless told otherwise
+    table.insert(args, '--force-rm')
+  end
+
+  -- build.args, build.target, build.cacheFrom and build.options
+  vim.list_extend(args, M.build_option_args(config))
+  -- Secrets marked with build = true (--secret id=NAME,env=NAME)
+  vim.list_extend(args, require('container.secrets').build_args())
+
+  -- Specify Dockerfile
+  if config.dockerfile then
+    table.insert(args, '-f')
+    table.insert(args, config.dockerfile)
+  end
+
+  -- Build context
+  table.insert(args, config.context or '.')
+  return args, buildkit
+end
+
+-- Docker image build
+-- Images are tagged with a cache key and reused until an input changes or force_rebuild is set
+-- Output is streamed line by line to on_progress. With docker.build_progress = 'buildkit' (default)
+-- BuildKit's plain progress format is used so stages and their durations can be followed.
+function M.build_image(config, on_progress, on_complete)
+  log.info('Building Docker image: %s', config.name)
+
+  -- Tag with the cache key so unchanged configurations reuse the image
+  local cache_key = M.compute_image_cache_key(config)
+  local tag = M.get_image_cache_tag(config, cache_key)
+  config.image_cache_key = cache_key
+  config.prebuilt_from = nil
+  config.prebuilt_skipped = nil
+  -- A start cancelled while the cache is checked must not go on to build
+  local run = require('container.pipeline').active(config.workspace_root)
+
+  M.check_image_exists_async(tag, function(exists)
+    if require('container.pipeline').is_cancelled(run) then
+      if on_complete then
+        on_complete(false, M.CANCELLED_RESULT)
+      end
+      return
+    end
+    if exists and not config.force_rebuild then
+      log.info('Using cached image: %s', tag)
       config.built_image = tag
-    else
-      log.error('Failed to build Docker image: %s', result.stderr)
+      config.image_source = 'cache'
+      if on_complete then
+        on_complete(true, { success = true, stdout = '', stderr = '' })
+      end
+      return
+    end
+    if config.force_rebuild or #M.prebuilt_candidates(config) == 0 then
+      M._run_build(config, tag, on_progress, on_complete)
+      return
     end
 
-    if on_complete then
+    M.use_prebuilt_image(config, cache_key, on_progress, function(image, reasons)
+      if require('container.pipeline').is_cancelled(run) then
+        if on_complete then
+          on_complete(false, M.CANCELLED_RESULT)
+        end
+        return
+      end
+      if image then
+        log.info('Using prebuilt image: %s (tagged %s)', image, tag)
+        if on_progress then
+          on_progress('Using prebuilt image ' .. image .. ' (matches the local Dockerfile and context)')
+        end
+        config.built_image = tag
+        config.image_source = 'prebuilt'
+        config.prebuilt_from = image
+        if on_complete then
+          on_complete(true, { success = true, stdout = '', stderr = '' })
+        end
+        return
+      end
+      local reason = table.concat(reasons, '; ')
+      log.info('Building locally: %s', reason)
+      if on_progress then
+        on_progress('No usable prebuilt image (' .. reason .. '), building locally')
+      end
+      config.prebuilt_skipped = reason
+      M._run_build(config, tag, on_progress, on_complete)
+    end)
+  end)
+end
+
+-- Run `docker build` for build_image()
+function M._run_build(config, tag, on_progress, on_complete)
+  local args, buildkit = M.build_image_args(config, tag)
+  local cmd = { runtime.get() }
+  vim.list_extend(cmd, args)
+  local logged = M.log_command(cmd)
+
+  -- Show how much is sent to the builder (and warn when it is a lot)
+  local fs = require('container.utils.fs')
+  local context_dir = fs.resolve_path(config.context or '.', config.base_path or vim.fn.getcwd())
+  require('container.build_context').report(context_dir, config.dockerfile, on_progress)
+
+  local stdout_lines = {}
+  local stderr_lines = {}
+  local function collect(lines, data)
+    for _, line in ipairs(data or {}) do
+      if line ~= '' then
+        table.insert(lines, line)
+        if on_progress then
+          on_progress(line)
+        end
+      end
+    end
+  end
+
+  -- Build secrets reach docker through the environment of this job only
+  local build_env = require('container.secrets').job_env(cmd) or {}
+  build_env.DOCKER_BUILDKIT = buildkit and '1' or '0'
+  local job_id = vim.fn.jobstart(cmd, {
+    cwd = config.base_path,
+    env = build_env,
+    on_stdout = function(_, data)
+      collect(stdout_lines, data)
+    end,
+    -- BuildKit writes its progress to stderr
+    on_stderr = function(_, data)
+      collect(stderr_lines, data)
+    end,
+    on_exit = function(_, exit_code)
+      logged(exit_code)
       vim.schedule(function()
-        on_complete(result.success, result)
+        local result = {
+          success = exit_code == 0,
+          code = exit_code,
+          stdout = table.concat(stdout_lines, '\n'),
+          stderr = table.concat(stderr_lines, '\n'),
+        }
+        if result.success then
+          log.info('Successfully built Docker image: %s', tag)
+          config.built_image = tag
+          config.image_source = 'build'
+        else
+          log.error('Failed to build Docker image: %s', result.stderr)
+        end
+        if on_complete then
+          on_complete(result.success, result)
+        end
       end)
+    end,
+  })
+
+  require('container.pipeline').track(config.workspace_root, job_id)
+  if job_id <= 0 then
+    log.error('Failed to start image build')
+    if on_complete then
+      on_complete(false, { success = false, stdout = '', stderr = 'Failed to start ' .. runtime.get() .. ' build' })
     end
-  end, 100)
+  end
 end
 
 -- Prepare image (build or pull)
+-- Devcontainer features are layered on top when the container is created (see build_features_image).
 function M.prepare_image(config, on_progress, on_complete)
   -- Build if Dockerfile is specified
   if config.dockerfile then
@@ -811,49 +1451,162 @@ function M.prepare_image(config, on_progress, on_complete)
 
   -- If image is specified
   if config.image then
-    -- Check if image exists locally
-    local exists = M.check_image_exists(config.image)
+    config.image_cache_key = M.compute_image_cache_key(config)
 
-    if exists then
-      log.info('Image already exists locally: %s', config.image)
-      config.prepared_image = config.image
-      if on_complete then
-        vim.schedule(function()
+    -- Pull if the image doesn't exist locally
+    M.check_image_exists_async(config.image, function(exists)
+      if exists then
+        log.info('Image already exists locally: %s', config.image)
+        config.prepared_image = config.image
+        if on_complete then
           on_complete(true, { success = true, stdout = '', stderr = '' })
-        end)
+        end
+        return
       end
-      return
-    else
-      -- Pull if image doesn't exist
-      return M.pull_image(config.image, on_progress, function(success, result)
+      local job_id = M.pull_image_async(config.image, on_progress, function(success, result)
         if success then
           config.prepared_image = config.image
         end
         if on_complete then
           on_complete(success, result)
         end
-      end)
-    end
-  end
+      end)
+      require('container.pipeline').track(config.workspace_root, job_id)
+    end)
+    return
+  end
+
+  -- If neither Dockerfile nor Image is specified
+  local error_msg = 'No dockerfile or image specified'
+  log.error(error_msg)
+  if on_complete then
+    vim.schedule(function()
+      on_complete(false, { success = false, stderr = error_msg })
+    end)
+  end
+end
+
+-- Build an image with devcontainer features layered on top of the base image
+-- The resulting image is tagged with a hash of the base image and the feature
+-- configuration so subsequent starts reuse it instead of rebuilding
+function M.build_features_image(config, on_progress, on_complete)
+  local features = require('container.features')
+
+  local base_image = config.built_image or config.prepared_image or config.image
+  local feature_list = features.normalize(config.features, config.devcontainer_folder)
+
+  if #feature_list == 0 or not base_image then
+    vim.schedule(function()
+      on_complete(true, { success = true, stdout = '', stderr = '' })
+    end)
+    return
+  end
+
+  -- Pin the features to devcontainer-lock.json before the tag is computed from them
+  local features_lock = require('container.features_lock')
+  local lock_plan = features_lock.prepare(config, feature_list)
+  local tag = features.image_tag(config, base_image, feature_list)
+  local run = require('container.pipeline').active(config.workspace_root)
+
+  M.check_image_exists_async(tag, function(exists)
+    if require('container.pipeline').is_cancelled(run) then
+      on_complete(false, M.CANCELLED_RESULT)
+      return
+    end
+    if exists and not config.force_rebuild then
+      log.info('Using cached features image: %s', tag)
+      config.features_image = tag
+      on_complete(true, { success = true, stdout = '', stderr = '' })
+      return
+    end
+
+    if on_progress then
+      on_progress(string.format('Installing %d devcontainer feature(s)...', #feature_list))
+    end
+
+    -- Preserve the base image user so it can be restored after installation
+    local inspect_args = { 'image', 'inspect', '--format', '{{.Config.User}}', base_image }
+    M.run_docker_command_async(inspect_args, {}, function(user_result)
+      local image_user = user_result.success and vim.trim(user_result.stdout) or nil
+
+      local context_dir, ordered_or_err = features.prepare_build_context(base_image, feature_list, {
+        remote_user = config.remote_user,
+        container_user = image_user ~= '' and image_user or nil,
+        image_user = image_user,
+      })
+      if not context_dir then
+        log.error('Failed to prepare features: %s', ordered_or_err)
+        on_complete(false, { success = false, stdout = '', stderr = ordered_or_err })
+        return
+      end
+
+      for _, feature in ipairs(ordered_or_err) do
+        log.info('Feature %s resolved to version %s', feature.ref, feature.version or feature.resolved or 'unknown')
+      end
+      if lock_plan and lock_plan.write then
+        local written, write_err = features_lock.write(lock_plan.path, ordered_or_err)
+        if not written then
+          log.warn('Failed to write %s: %s', lock_plan.path, tostring(write_err))
+        end
+      end
+
+      local stdout_lines = {}
+      local stderr_lines = {}
+      local function collect(lines, data)
+        for _, line in ipairs(data or {}) do
+          if line ~= '' then
+            table.insert(lines, line)
+            if on_progress then
+              on_progress(line)
+            end
+          end
+        end
+      end
 
-  -- If neither Dockerfile nor Image is specified
-  local error_msg = 'No dockerfile or image specified'
-  log.error(error_msg)
-  if on_complete then
-    vim.schedule(function()
-      on_complete(false, { success = false, stderr = error_msg })
+      local cmd = { runtime.get(), 'build', '-t', tag }
+      vim.list_extend(cmd, M.image_label_args('features'))
+      vim.list_extend(cmd, { '-f', context_dir .. '/Dockerfile', context_dir })
+      local logged = M.log_command(cmd)
+      local job_id = vim.fn.jobstart(cmd, {
+        on_stdout = function(_, data)
+          collect(stdout_lines, data)
+        end,
+        on_stderr = function(_, data)
+          collect(stderr_lines, data)
+        end,
+        on_exit = function(_, exit_code)
+          logged(exit_code)
+          vim.schedule(function()
+            local result = {
+              success = exit_code == 0,
+              code = exit_code,
+              stdout = table.concat(stdout_lines, '\n'),
+              stderr = table.concat(stderr_lines, '\n'),
+            }
+            if result.success then
+              log.info('Successfully built features image: %s', tag)
+              config.features_image = tag
+            else
+              log.error('Failed to build features image: %s', result.stderr)
+            end
+            on_complete(result.success, result)
+          end)
+        end,
+      })
+      require('container.pipeline').track(config.workspace_root, job_id)
     end)
-  end
+  end)
 end
 
 -- Container creation
 -- Container creation (async version)
 function M.create_container_async(config, callback)
   log.info('Creating Docker container (async): %s', config.name)
+  M.warn_privileged(config)
 
   local args = M._build_create_args(config)
 
-  M.run_docker_command_async(args, {}, function(result)
+  M.run_docker_command_async(args, { pipeline = config.workspace_root }, function(result)
     if result.success then
       local container_id = result.stdout:gsub('%s+', '')
       log.info('Successfully created container: %s', container_id)
@@ -878,6 +1631,53 @@ function M.create_container_async(config, callback)
   end)
 end
 
+-- Container name used unless container_name_template is configured
+-- {name}: devcontainer name, {project}: workspace folder name, {branch}: git branch, {hash}: workspace path hash
+M.DEFAULT_CONTAINER_NAME_TEMPLATE = '{name}-{hash}-devcontainer'
+
+local function get_plugin_config()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  return ok and plugin_config or {}
+end
+
+-- Current git branch of a folder ('' outside a repository or on a detached HEAD)
+local function get_git_branch(path)
+  local output = vim.fn.systemlist({ 'git', '-C', path, 'rev-parse', '--abbrev-ref', 'HEAD' })
+  if vim.v.shell_error ~= 0 or not output[1] or output[1] == 'HEAD' then
+    return ''
+  end
+  return output[1]
+end
+
+-- Make a string a valid container name ([a-z0-9][a-z0-9_.-]*)
+function M.sanitize_container_name(name)
+  local sanitized = name:lower():gsub('[^a-z0-9_.-]', '-'):gsub('^[^a-z0-9]+', '')
+  if sanitized == '' then
+    return 'devcontainer'
+  end
+  return sanitized
+end
+
+-- Expand the tokens of a container name template
+-- The workspace hash is appended when the template has no {hash}, so names stay unique per workspace.
+-- @param template string
+-- @param values table: { name, project, branch, hash }
+function M.expand_container_name_template(template, values)
+  local name = template:gsub('{(%w+)}', function(token)
+    if values[token] == nil then
+      log.warn('Unknown token in container_name_template: {%s}', token)
+      return ''
+    end
+    return values[token]
+  end)
+  if not template:find('{hash}', 1, true) then
+    name = name .. '-' .. values.hash
+  end
+  return M.sanitize_container_name(name)
+end
+
 -- Generate unique container name with project path hash
 function M.generate_container_name(config)
   -- Get project root path for uniqueness
@@ -886,25 +1686,427 @@ function M.generate_container_name(config)
   -- Create hash of project path for uniqueness
   local path_hash = vim.fn.sha256(project_path):sub(1, 8)
 
-  -- Clean the config name
-  local clean_name = config.name:lower():gsub('[^a-z0-9_.-]', '-')
-
-  -- Combine name, hash, and suffix for uniqueness
-  local container_name = string.format('%s-%s-devcontainer', clean_name, path_hash)
+  local template = get_plugin_config().container_name_template or M.DEFAULT_CONTAINER_NAME_TEMPLATE
+  local values = {
+    name = config.name or 'devcontainer',
+    project = project_path:match('([^/]+)/*$') or '',
+    hash = path_hash,
+  }
+  if template:find('{branch}', 1, true) then
+    values.branch = get_git_branch(project_path)
+  end
+  local container_name = M.expand_container_name_template(template, values)
 
   log.debug('Generated container name: %s (from project: %s)', container_name, project_path)
   return container_name
 end
 
+-- Container name of a loaded configuration, generated once and kept in config.container_name
+-- The name of an existing container found for the configuration is kept instead, so a template with {branch} does not
+-- rename the container of a workspace after a branch switch.
+function M.resolve_container_name(config)
+  if not config.container_name then
+    config.container_name = M.generate_container_name(config)
+  end
+  return config.container_name
+end
+
+-- Stable identifier of a configuration: the default container name, whatever container_name_template says
+-- Recorded in the CONFIG_LABEL of its containers and used for the names of their volumes.
+function M.get_config_id(config)
+  local project_path = config.base_path or vim.fn.getcwd()
+  return M.expand_container_name_template(M.DEFAULT_CONTAINER_NAME_TEMPLATE, {
+    name = config.name or 'devcontainer',
+    hash = vim.fn.sha256(project_path):sub(1, 8),
+  })
+end
+
+-- `--label` arguments of the user's `labels` table (sorted so the arguments are stable)
+function M.user_label_args()
+  local labels = get_plugin_config().labels or {}
+  local keys = vim.tbl_keys(labels)
+  table.sort(keys)
+  local args = {}
+  for _, key in ipairs(keys) do
+    table.insert(args, '--label')
+    table.insert(args, string.format('%s=%s', key, tostring(labels[key])))
+  end
+  return args
+end
+
+-- Format a normalized mount as a `--mount` value
+function M.format_mount(mount)
+  local parts = { 'type=' .. mount.type }
+  if mount.source then
+    table.insert(parts, 'source=' .. mount.source)
+  end
+  table.insert(parts, 'target=' .. mount.target)
+  if mount.readonly then
+    table.insert(parts, 'readonly')
+  end
+  if mount.consistency then
+    table.insert(parts, 'consistency=' .. mount.consistency)
+  end
+  -- Podman: relabel bind mounts for SELinux (same as the :Z volume option)
+  if mount.type == 'bind' and runtime.is_podman() then
+    table.insert(parts, 'relabel=private')
+  end
+  return table.concat(parts, ',')
+end
+
+-- Create a named volume unless it already exists
+function M.ensure_volume(name)
+  if M.run_docker_command({ 'volume', 'inspect', name }).success then
+    return true
+  end
+  log.info('Creating volume: %s', name)
+  local result = M.run_docker_command({ 'volume', 'create', name })
+  if not result.success then
+    log.warn('Failed to create volume %s: %s', name, result.stderr)
+  end
+  return result.success
+end
+
+-- Check mounts before creating a container
+-- Named volumes are created; bind mounts whose host path is missing are dropped so a stale
+-- mount does not fail the whole start. On a remote daemon every bind mount is dropped, as the
+-- host paths do not exist there.
+-- @return table, table: mounts to pass to docker, skipped bind mounts
+function M.prepare_mounts(mounts)
+  local prepared = {}
+  local skipped = {}
+  local remote = runtime.is_remote()
+  for _, mount in ipairs(mounts or {}) do
+    if mount.type == 'bind' and remote then
+      log.warn('Skipping mount %s: bind mounts are not available on a remote Docker host', mount.source)
+      table.insert(skipped, mount)
+    elseif
+      mount.type == 'bind'
+      and vim.fn.isdirectory(mount.source) == 0
+      and vim.fn.filereadable(mount.source) == 0
+    then
+      log.warn('Skipping mount %s: host path does not exist', mount.source)
+      table.insert(skipped, mount)
+    else
+      if mount.type == 'volume' and mount.source then
+        M.ensure_volume(mount.source)
+      end
+      table.insert(prepared, mount)
+    end
+  end
+  return prepared, skipped
+end
+
+-- Volume holding the workspace of a container on a remote daemon
+function M.remote_workspace_volume(config)
+  return M.get_config_id(config) .. '-workspace'
+end
+
+-- Copy the workspace into the container (remote daemons, where it cannot be bind mounted)
+-- `docker cp -a` keeps the host UID/GID, which the remote user has after the UID update.
+-- @param container_id string
+-- @param config table: normalized devcontainer config
+-- @param callback function(success, error_msg)
+function M.sync_workspace_async(container_id, config, callback)
+  local parser = require('container.parser')
+  local host_root, container_root = parser.workspace_roots(config)
+  log.info('Copying workspace %s to %s:%s', host_root, container_id, container_root)
+  M.run_docker_command_async({ 'exec', container_id, 'mkdir', '-p', container_root }, {}, function()
+    M.run_docker_command_async(
+      { 'cp', '-a', host_root .. '/.', container_id .. ':' .. container_root },
+      { pipeline = config.workspace_root },
+      function(result)
+        callback(result.success, result.success and nil or result.stderr)
+      end
+    )
+  end)
+end
+
+-- Script cloning the workspace repository into the volume: $1 target, $2 repository, $3 branch, $4 owner
+-- A clone found in the volume is reused; "reused" or "cloned" on stdout tells which happened.
+local CLONE_SCRIPT = [[
+target=$1 repository=$2 branch=$3 owner=$4
+if [ -e "$target/.git" ]; then echo reused; exit 0; fi
+if [ -n "$(ls -A "$target" 2>/dev/null)" ]; then echo "$target is not empty and is not a git repository" >&2; exit 1; fi
+command -v git >/dev/null 2>&1 || { echo "git is not installed in the container" >&2; exit 1; }
+git clone ${branch:+--branch "$branch"} -- "$repository" "$target" || exit 1
+if [ -n "$owner" ]; then chown -R "$owner" "$target"; fi
+echo cloned
+]]
+
+-- Clone the workspace repository into its volume (workspace_clone), unless an earlier start already did
+-- git runs as root without prompting for credentials, then the clone is handed to the remote user.
+-- @param container_id string
+-- @param config table: normalized devcontainer config with workspace_clone
+-- @param callback function(success, error_msg, reused)
+function M.clone_workspace_async(container_id, config, callback)
+  local clone = config.workspace_clone
+  local target = config.workspace_mount and config.workspace_mount.target or config.workspace_folder
+  local owner = config.remote_user or config.container_user or ''
+  log.info('Cloning %s into volume %s at %s', clone.repository, clone.volume, target)
+  M.run_docker_command_async({
+    'exec',
+    '-u',
+    'root',
+    '-e',
+    'GIT_TERMINAL_PROMPT=0',
+    container_id,
+    'sh',
+    '-c',
+    CLONE_SCRIPT,
+    'clone',
+    target,
+    clone.repository,
+    clone.branch or '',
+    owner,
+  }, { pipeline = config.workspace_root, retry = false, timeout = 600 }, function(result)
+    if not result.success then
+      local message = vim.trim(result.stderr ~= '' and result.stderr or result.stdout)
+      callback(false, message ~= '' and message or ('exit code ' .. tostring(result.code)))
+      return
+    end
+    callback(true, nil, result.stdout:match('reused') ~= nil)
+  end)
+end
+
+-- Copy between the host and a container with `docker cp`
+-- Directories are copied recursively; -a keeps file modes and the UID/GID of the source.
+-- @param source string: host path or "<container>:<path>"
+-- @param target string: host path or "<container>:<path>"
+-- @param callback function(success, error_msg)
+function M.copy_async(source, target, callback)
+  M.run_docker_command_async({ 'cp', '-a', source, target }, {}, function(result)
+    callback(result.success, result.success and nil or vim.trim(result.stderr or ''))
+  end)
+end
+
+-- Copy a single host file of the workspace into the container
+function M.sync_file_async(container_id, config, file, callback)
+  local parser = require('container.parser')
+  local host_root, container_root = parser.workspace_roots(config)
+  local target = require('container.test').map_path(file, host_root, container_root)
+  if not target then
+    callback(false, 'File is outside the workspace: ' .. file)
+    return
+  end
+  M.run_docker_command_async({ 'cp', '-a', file, container_id .. ':' .. target }, {}, function(result)
+    callback(result.success, result.success and nil or result.stderr)
+  end)
+end
+
+-- Label attached to every container so it can be found again for its workspace
+M.WORKSPACE_LABEL = 'container.nvim.workspace'
+-- Label telling the configurations of a workspace apart (see get_config_id)
+M.CONFIG_LABEL = 'container.nvim.config'
+
+-- Workspace path recorded in the label (the project root the container belongs to)
+function M.get_workspace_path(config)
+  return config.workspace_root or config.base_path or vim.fn.getcwd()
+end
+
+-- Build a `docker ps --filter` value matching containers of a workspace
+function M.workspace_label_filter(workspace_path)
+  return string.format('label=%s=%s', M.WORKSPACE_LABEL, workspace_path)
+end
+
+-- Build a `docker ps --filter` value matching containers of a configuration
+function M.config_label_filter(config)
+  return string.format('label=%s=%s', M.CONFIG_LABEL, M.get_config_id(config))
+end
+
+-- `--label` arguments recording the workspace and configuration of a container
+function M.workspace_label_args(config)
+  return {
+    '--label',
+    string.format('%s=%s', M.WORKSPACE_LABEL, M.get_workspace_path(config)),
+    '--label',
+    string.format('%s=%s', M.CONFIG_LABEL, M.get_config_id(config)),
+  }
+end
+
+-- Check whether a configured port is published with `-p` when the container is created
+-- appPort entries always are. forwardPorts entries are too, unless port_forwarding.forward_ports is 'start': then
+-- they are forwarded with sidecars once the container runs.
+function M.is_published_at_create(port)
+  if port.source == 'appPort' then
+    return true
+  end
+  local plugin_config = require('container.config').get() or {}
+  return (plugin_config.port_forwarding or {}).forward_ports ~= 'start'
+end
+
+-- Resolve host port conflicts for fixed port forwards
+-- When a host port is already bound, the next free port is used and the chosen mapping is reported.
+-- With port_forwarding.conflict_resolution = 'error' the requested port is kept so the start fails.
+function M.resolve_port_conflicts(ports)
+  local port_utils = require('container.utils.port')
+  local plugin_config = require('container.config').get() or {}
+  local conflict_resolution = (plugin_config.port_forwarding or {}).conflict_resolution or 'auto'
+  local used = {}
+
+  for _, port in ipairs(ports or {}) do
+    if port.host_port and port.container_port then
+      local requested = port.requested_host_port or port.host_port
+      local conflict = vim.tbl_contains(used, requested) or not port_utils.is_port_available(requested)
+
+      if not conflict then
+        port.host_port = requested
+      elseif conflict_resolution == 'error' then
+        log.error('Host port %d is already in use (container port %d)', requested, port.container_port)
+      else
+        local chosen = port_utils.find_next_available_port(requested + 1, used)
+        if chosen then
+          port.requested_host_port = requested
+          port.host_port = chosen
+          local message = string.format(
+            'Host port %d is in use, forwarding container port %d to host port %d',
+            requested,
+            port.container_port,
+            chosen
+          )
+          log.warn(message)
+          require('container.utils.notify').container(message)
+        else
+          log.error('No free host port found for container port %d', port.container_port)
+        end
+      end
+
+      table.insert(used, port.host_port)
+    end
+  end
+
+  return ports
+end
+
+-- Value of a flag in runArgs ("--flag value" or "--flag=value")
+-- A repeated flag has its last value, as for docker (default_run_args come before runArgs).
+-- @param names table: spellings of the flag, e.g. { '--network', '--net' }
+-- @return string|boolean|nil: the value, true for a flag without value, nil when absent
+function M.find_run_arg(run_args, names)
+  local found = nil
+  for i, arg in ipairs(run_args or {}) do
+    for _, name in ipairs(names) do
+      if arg == name then
+        local value = run_args[i + 1]
+        found = (value and not value:match('^%-')) and value or true
+      elseif arg:sub(1, #name + 1) == name .. '=' then
+        found = arg:sub(#name + 2)
+      end
+    end
+  end
+  return found
+end
+
+-- Check whether hostRequirements.gpu asks for GPUs that runArgs do not already request
+-- hostRequirements.gpu is true, "optional" or { cores, memory }
+function M.wants_gpu(config)
+  local gpu = config.host_requirements and config.host_requirements.gpu
+  if not gpu then
+    return false
+  end
+  if M.find_run_arg(config.run_args, { '--gpus' }) then
+    return false
+  end
+  local device = M.find_run_arg(config.run_args, { '--device' })
+  return not (type(device) == 'string' and device:find('nvidia.com/gpu', 1, true))
+end
+
+-- Flags giving the container every GPU for hostRequirements.gpu
+-- Omitted when the GPU check (config.gpu_available) found no GPU support on the host
+function M.gpu_args(config)
+  if not M.wants_gpu(config) or config.gpu_available == false then
+    return {}
+  end
+  -- Podman uses the CDI device of the NVIDIA Container Toolkit
+  if runtime.is_podman() then
+    return { '--device', 'nvidia.com/gpu=all' }
+  end
+  return { '--gpus', 'all' }
+end
+
+-- Check whether containers can use NVIDIA GPUs: the nvidia runtime is registered with Docker,
+-- or a CDI specification is installed for Podman
+-- @param callback function(available)
+function M.check_gpu_support_async(callback)
+  if runtime.is_podman() then
+    local fs = require('container.utils.fs')
+    callback(fs.is_file('/etc/cdi/nvidia.yaml') or fs.is_file('/var/run/cdi/nvidia.yaml'))
+    return
+  end
+  M.run_docker_command_async({ 'info', '--format', '{{json .Runtimes}}' }, {}, function(result)
+    callback(result.success and result.stdout:find('nvidia', 1, true) ~= nil)
+  end)
+end
+
+-- Keep-alive command replacing the image's ENTRYPOINT and CMD
+M.KEEP_ALIVE_COMMAND = 'while true; do sleep 3600; done'
+
+-- Whether the keep-alive command replaces the image's command (devcontainer.json overrideCommand)
+-- Defaults to true for image and Dockerfile configurations and to false for Docker Compose, where the service
+-- usually runs the application.
+-- @param config table
+-- @return boolean
+function M.overrides_command(config)
+  if config.override_command ~= nil then
+    return config.override_command
+  end
+  return not require('container.docker.compose').is_compose_config(config)
+end
+
+-- docker create flags of privileged, capAdd and securityOpt
+-- @param config table: normalized configuration
+-- @return table
+function M.security_args(config)
+  local args = {}
+  if config.privileged then
+    table.insert(args, '--privileged')
+  end
+  for _, capability in ipairs(config.cap_add or {}) do
+    vim.list_extend(args, { '--cap-add', capability })
+  end
+  for _, option in ipairs(config.security_opt or {}) do
+    vim.list_extend(args, { '--security-opt', option })
+  end
+  return args
+end
+
+-- Warn before a privileged container is created
+function M.warn_privileged(config)
+  if not config.privileged then
+    return
+  end
+  log.warn('Creating privileged container %s', tostring(config.name))
+  require('container.utils.notify').warn(
+    'devcontainer.json sets "privileged": the container gets all capabilities and access to the host devices'
+  )
+end
+
+-- Arguments around the image that keep the container running
+-- With overrideCommand false the image's ENTRYPOINT and CMD run and nothing is added.
+-- @return table, table: arguments before the image (after the flags) and after it
+local function command_args(config)
+  if not M.overrides_command(config) then
+    return {}, {}
+  end
+  -- Replace any bash-dependent entrypoint of the base image with POSIX sh
+  return { '--entrypoint', '/bin/sh' }, { '-c', M.KEEP_ALIVE_COMMAND }
+end
+
 -- Build container creation arguments
+-- Generated flags come first and runArgs are appended in their order right before the image,
+-- so options given in runArgs override the generated ones.
 function M._build_create_args(config)
   local args = { 'create' }
 
   -- Container name (unique per project)
-  local container_name = M.generate_container_name(config)
+  local container_name = M.resolve_container_name(config)
   table.insert(args, '--name')
   table.insert(args, container_name)
 
+  -- Workspace and configuration labels for re-discovery after a restart
+  vim.list_extend(args, M.workspace_label_args(config))
+  vim.list_extend(args, M.user_label_args())
+
   -- Interactive mode
   table.insert(args, '-it')
 
@@ -914,7 +2116,8 @@ function M._build_create_args(config)
     table.insert(args, config.workspace_folder)
   end
 
-  -- Environment variables
+  -- Environment variables (containerEnv overrides the env files)
+  vim.list_extend(args, require('container.env_file').args(config))
   if config.environment then
     for key, value in pairs(config.environment) do
       table.insert(args, '-e')
@@ -923,62 +2126,79 @@ function M._build_create_args(config)
   end
 
   -- Volume mount
-  if config.mounts then
-    for _, mount in ipairs(config.mounts) do
-      table.insert(args, '--mount')
-      local mount_str = string.format('type=%s,source=%s,target=%s', mount.type, mount.source, mount.target)
-      if mount.readonly then
-        mount_str = mount_str .. ',readonly'
-      end
-      if mount.consistency then
-        mount_str = mount_str .. ',consistency=' .. mount.consistency
-      end
-      table.insert(args, mount_str)
-    end
+  for _, mount in ipairs(config.mounts or {}) do
+    table.insert(args, '--mount')
+    table.insert(args, M.format_mount(mount))
   end
 
-  -- Port forwarding
-  if config.ports then
+  -- Port forwarding (published ports are discarded on the host network)
+  if config.ports and M.find_run_arg(config.run_args, { '--network', '--net' }) ~= 'host' then
+    M.resolve_port_conflicts(config.ports)
     for _, port in ipairs(config.ports) do
-      if port.host_port and port.container_port then
+      if port.host_port and port.container_port and M.is_published_at_create(port) then
         table.insert(args, '-p')
         table.insert(args, string.format('%d:%d', port.host_port, port.container_port))
       end
     end
   end
 
-  -- Privileged mode
-  if config.privileged then
-    table.insert(args, '--privileged')
-  end
+  -- Privileged mode, added capabilities and security options
+  vim.list_extend(args, M.security_args(config))
 
   -- init process
   if config.init then
     table.insert(args, '--init')
   end
 
-  -- User specification
-  if config.remote_user then
+  -- hostRequirements.gpu
+  vim.list_extend(args, M.gpu_args(config))
+
+  -- hostRequirements.cpus and memory as limits
+  vim.list_extend(args, require('container.host_requirements').limit_args(config))
+
+  -- User specification (the container runs as containerUser, exec sessions use remoteUser)
+  local container_user = config.container_user or config.remote_user
+  if container_user then
     table.insert(args, '--user')
-    table.insert(args, config.remote_user)
+    table.insert(args, container_user)
   end
 
-  -- Workspace mount (default)
-  local workspace_source = config.workspace_source or vim.fn.getcwd()
-  local workspace_target = config.workspace_mount or '/workspace'
-  table.insert(args, '-v')
-  table.insert(args, workspace_source .. ':' .. workspace_target)
+  -- Runtime specific arguments (user namespace of rootless Podman)
+  vim.list_extend(args, runtime.create_args(config.run_args))
 
-  -- Override any bash-dependent entrypoint from base image
-  table.insert(args, '--entrypoint')
-  table.insert(args, '/bin/sh')
+  -- Workspace mount (workspaceMount, or the project folder at workspaceFolder)
+  -- An entry of `mounts` for the same target replaces it, as docker rejects duplicate mount points
+  local workspace_mount = config.workspace_mount
+  for _, mount in ipairs(config.mounts or {}) do
+    if workspace_mount and mount.target == workspace_mount.target then
+      workspace_mount = nil
+    end
+  end
+  if runtime.is_remote() then
+    -- The host folder does not exist on a remote daemon: use a volume that the workspace is copied into
+    local target = workspace_mount and workspace_mount.target or (not config.workspace_mount and '/workspace')
+    if target and not (workspace_mount and workspace_mount.type ~= 'bind') then
+      workspace_mount = { type = 'volume', source = M.remote_workspace_volume(config), target = target }
+    end
+  end
+  if workspace_mount then
+    table.insert(args, '--mount')
+    table.insert(args, M.format_mount(workspace_mount))
+  elseif not config.workspace_mount then
+    table.insert(args, '-v')
+    table.insert(args, vim.fn.getcwd() .. ':/workspace' .. (runtime.is_podman() and ':Z' or ''))
+  end
+
+  -- Keep-alive command unless overrideCommand is false
+  local before_image, after_image = command_args(config)
+  vim.list_extend(args, before_image)
 
-  -- Image
-  table.insert(args, config.image)
+  -- runArgs from devcontainer.json
+  vim.list_extend(args, config.run_args or {})
 
-  -- Default command (keep container running with POSIX sh)
-  table.insert(args, '-c')
-  table.insert(args, 'while true; do sleep 3600; done')
+  -- Image (prefer the UID-remapped image, then the image with devcontainer features installed, then the built image)
+  table.insert(args, config.uid_image or config.features_image or config.built_image or config.image)
+  vim.list_extend(args, after_image)
 
   return args
 end
@@ -990,10 +2210,14 @@ function M.create_container(config)
   local args = { 'create' }
 
   -- Container name (unique per project)
-  local container_name = M.generate_container_name(config)
+  local container_name = M.resolve_container_name(config)
   table.insert(args, '--name')
   table.insert(args, container_name)
 
+  -- Workspace and configuration labels for re-discovery after a restart
+  vim.list_extend(args, M.workspace_label_args(config))
+  vim.list_extend(args, M.user_label_args())
+
   -- Interactive mode
   table.insert(args, '-it')
 
@@ -1003,7 +2227,8 @@ function M.create_container(config)
     table.insert(args, config.workspace_folder)
   end
 
-  -- Environment variables
+  -- Environment variables (containerEnv overrides the env files)
+  vim.list_extend(args, require('container.env_file').args(config))
   if config.environment then
     for key, value in pairs(config.environment) do
       table.insert(args, '-e')
@@ -1012,65 +2237,64 @@ function M.create_container(config)
   end
 
   -- Volume mount
-  if config.mounts then
-    for _, mount in ipairs(config.mounts) do
-      table.insert(args, '--mount')
-      local mount_str = string.format('type=%s,source=%s,target=%s', mount.type, mount.source, mount.target)
-      if mount.readonly then
-        mount_str = mount_str .. ',readonly'
-      end
-      if mount.consistency then
-        mount_str = mount_str .. ',consistency=' .. mount.consistency
-      end
-      table.insert(args, mount_str)
-    end
+  for _, mount in ipairs(config.mounts or {}) do
+    table.insert(args, '--mount')
+    table.insert(args, M.format_mount(mount))
   end
 
   -- Port forwarding
   if config.ports then
+    M.resolve_port_conflicts(config.ports)
     for _, port in ipairs(config.ports) do
-      if port.host_port and port.container_port then
+      if port.host_port and port.container_port and M.is_published_at_create(port) then
         table.insert(args, '-p')
         table.insert(args, string.format('%d:%d', port.host_port, port.container_port))
       end
     end
   end
 
-  -- Privileged mode
-  if config.privileged then
-    table.insert(args, '--privileged')
-  end
+  -- Privileged mode, added capabilities and security options
+  vim.list_extend(args, M.security_args(config))
 
   -- init process
   if config.init then
     table.insert(args, '--init')
   end
 
-  -- User specification
-  if config.remote_user then
+  -- User specification (the container runs as containerUser, exec sessions use remoteUser)
+  local container_user = config.container_user or config.remote_user
+  if container_user then
     table.insert(args, '--user')
-    table.insert(args, config.remote_user)
+    table.insert(args, container_user)
   end
 
+  -- hostRequirements.gpu
+  vim.list_extend(args, M.gpu_args(config))
+
+  -- hostRequirements.cpus and memory as limits
+  vim.list_extend(args, require('container.host_requirements').limit_args(config))
+
+  -- Runtime specific arguments (user namespace of rootless Podman)
+  vim.list_extend(args, runtime.create_args(config.run_args))
+
   -- Image to use (built image or specified image)
-  local image = config.built_image or config.prepared_image or config.image
+  local image = config.features_image or config.built_image or config.prepared_image or config.image
   if not image then
     local error_msg = 'No image available for container creation'
     log.error(error_msg)
     return nil, error_msg
   end
-  -- Override any bash-dependent entrypoint from base image
-  table.insert(args, '--entrypoint')
-  table.insert(args, '/bin/sh')
+  -- Keep-alive command unless overrideCommand is false
+  local before_image, after_image = command_args(config)
+  vim.list_extend(args, before_image)
 
-  table.insert(args, image)
+  -- runArgs from devcontainer.json
+  vim.list_extend(args, config.run_args or {})
 
-  -- Default command (keep container running with POSIX sh)
-  table.insert(args, '-c')
-  table.insert(args, 'while true; do sleep 3600; done')
+  table.insert(args, image)
+  vim.list_extend(args, after_image)
 
   log.info('Docker create command: docker %s', table.concat(args, ' '))
-  log.debug('Using POSIX sh entrypoint to avoid bash dependency')
 
   local result = M.run_docker_command(args)
 
@@ -1148,17 +2372,6 @@ end
 function M.start_container_async(container_id, callback)
   log.info('Starting container asynchronously: %s', container_id)
 
-  -- Start container
-  local result = M.run_docker_command({ 'start', container_id })
-  if not result.success then
-    local error_msg = result.stderr or 'unknown error'
-    log.error('Failed to start container: %s', error_msg)
-    callback(false, error_msg)
-    return
-  end
-
-  log.info('Container started, checking readiness...')
-
   -- Wait for readiness non-blocking
   local attempts = 0
   local max_attempts = 30
@@ -1186,13 +2399,46 @@ function M.start_container_async(container_id, callback)
     end
   end
 
-  -- Start first check
-  vim.defer_fn(check_ready, 500)
+  -- Start container; a port still held by a container that is going away is retried (docker.retries)
+  M.run_docker_command_async({ 'start', container_id }, {}, function(result)
+    if not result.success then
+      local error_msg = result.stderr ~= '' and result.stderr or 'unknown error'
+      log.error('Failed to start container: %s', error_msg)
+      callback(false, error_msg)
+      return
+    end
+
+    log.info('Container started, checking readiness...')
+    -- Start first check
+    vim.defer_fn(check_ready, 500)
+  end)
+end
+
+-- Seconds `docker stop` waits after SIGTERM before sending SIGKILL (docker.stop_timeout)
+M.DEFAULT_STOP_TIMEOUT = 10
+
+function M.get_stop_timeout()
+  return (get_plugin_config().docker or {}).stop_timeout or M.DEFAULT_STOP_TIMEOUT
+end
+
+-- Exit code of a process terminated by SIGKILL (128 + 9)
+local SIGKILL_EXIT_CODE = 137
+
+-- Check whether a stopped container was killed instead of exiting on SIGTERM
+function M.was_force_killed_async(container_id, callback)
+  M.run_docker_command_async(
+    { 'inspect', '-f', '{{.State.ExitCode}} {{.State.OOMKilled}}', container_id },
+    {},
+    function(result)
+      local exit_code, oom_killed = (result.stdout or ''):match('^(%d+) (%a+)')
+      callback(result.success and tonumber(exit_code) == SIGKILL_EXIT_CODE and oom_killed ~= 'true')
+    end
+  )
 end
 
 -- Container stop (synchronous version - kept for compatibility)
 function M.stop_container(container_id, timeout)
-  timeout = timeout or 30
+  timeout = timeout or M.get_stop_timeout()
   log.info('Stopping container: %s', container_id)
 
   local args = { 'stop' }
@@ -1214,9 +2460,11 @@ function M.stop_container(container_id, timeout)
 end
 
 -- Container stop (async version)
+-- `docker stop` sends SIGTERM and SIGKILL once the timeout has passed; the callback receives
+-- { force_killed = true } as third argument when the container did not exit in time.
 function M.stop_container_async(container_id, callback, timeout)
-  timeout = timeout or 30
-  log.info('Stopping container: %s', container_id)
+  timeout = timeout or M.get_stop_timeout()
+  log.info('Stopping container: %s (timeout: %ds)', container_id, timeout)
 
   local args = { 'stop' }
   if timeout then
@@ -1228,9 +2476,14 @@ function M.stop_container_async(container_id, callback, timeout)
   M.run_docker_command_async(args, {}, function(result)
     if result.success then
       log.info('Successfully stopped container: %s', container_id)
-      if callback then
-        callback(true)
-      end
+      M.was_force_killed_async(container_id, function(force_killed)
+        if force_killed then
+          log.warn('Container %s did not exit within %ds and was killed', container_id, timeout)
+        end
+        if callback then
+          callback(true, nil, { force_killed = force_killed })
+        end
+      end)
     else
       log.error('Failed to stop container: %s', result.stderr)
       if callback then
@@ -1240,6 +2493,23 @@ function M.stop_container_async(container_id, callback, timeout)
   end)
 end
 
+-- Container restart in place (`docker restart`), with the same stop timeout as stop_container_async
+function M.restart_container_async(container_id, callback, timeout)
+  timeout = timeout or M.get_stop_timeout()
+  log.info('Restarting container: %s (timeout: %ds)', container_id, timeout)
+
+  M.run_docker_command_async({ 'restart', '-t', tostring(timeout), container_id }, {}, function(result)
+    if result.success then
+      log.info('Successfully restarted container: %s', container_id)
+    else
+      log.error('Failed to restart container: %s', result.stderr)
+    end
+    if callback then
+      callback(result.success, result.success and nil or result.stderr)
+    end
+  end)
+end
+
 -- Container kill (immediate termination)
 function M.kill_container(container_id, callback)
   log.info('Killing container: %s', container_id)
@@ -1341,7 +2611,7 @@ end
 function M.stop_and_remove_container(container_id, stop_timeout, callback)
   log.info('Stopping and removing container: %s', container_id)
 
-  stop_timeout = stop_timeout or 30
+  stop_timeout = stop_timeout or M.get_stop_timeout()
 
   -- First stop the container
   local args = { 'stop' }
@@ -1634,7 +2904,7 @@ function M.execute_command_stream(container_id, command, opts)
     return 1 -- Return a dummy job ID for compatibility
   end
 
-  local cmd_args = { 'docker', 'exec' }
+  local cmd_args = { runtime.get(), 'exec' }
 
   -- Interactive mode
   if opts.interactive then
@@ -1677,6 +2947,8 @@ function M.execute_command_stream(container_id, command, opts)
     end
   end
 
+  local logged = M.log_command(cmd_args)
+
   -- Setup streaming callbacks
   local job_opts = {
     on_stdout = function(_, data, _)
@@ -1698,6 +2970,7 @@ function M.execute_command_stream(container_id, command, opts)
       end
     end,
     on_exit = function(_, exit_code, _)
+      logged(exit_code)
       if opts.on_exit then
         opts.on_exit(exit_code)
       end
@@ -1767,6 +3040,37 @@ function M.build_command(base_command, opts)
   return table.concat(command_parts, ' && ')
 end
 
+-- Get the ID of the image a container was created from
+-- @param callback function(image_id|nil)
+function M.get_container_image_id(container_id, callback)
+  M.run_docker_command_async({ 'inspect', '--format', '{{.Image}}', container_id }, {}, function(result)
+    local image_id = result.success and vim.trim(result.stdout or '') or ''
+    callback(image_id ~= '' and image_id or nil)
+  end)
+end
+
+-- Remove an image once no tag refers to it anymore (e.g. after it was rebuilt under the same tag)
+-- Tagged images and images still used by a container are kept.
+-- @param callback function(removed, err)
+function M.remove_dangling_image(image_id, callback)
+  M.run_docker_command_async({ 'image', 'inspect', '--format', '{{len .RepoTags}}', image_id }, {}, function(result)
+    if not result.success then
+      callback(false, 'Image not found: ' .. image_id)
+      return
+    end
+    if vim.trim(result.stdout or '') ~= '0' then
+      callback(false, 'Image is still tagged: ' .. image_id)
+      return
+    end
+    M.run_docker_command_async({ 'image', 'rm', image_id }, {}, function(rm_result)
+      if rm_result.success then
+        log.info('Removed dangling image: %s', image_id)
+      end
+      callback(rm_result.success, rm_result.success and nil or rm_result.stderr)
+    end)
+  end)
+end
+
 -- Get container status
 function M.get_container_status(container_id)
   -- Removed verbose debug log that was called every second
@@ -1900,12 +3204,30 @@ end
 function M.list_devcontainers()
   log.debug('Listing devcontainers')
 
-  -- Filter for containers with "-devcontainer" suffix
+  -- Containers labeled with their workspace, plus older ones recognized by the "-devcontainer" suffix
+  local workspaces = {}
+  local labeled = M.run_docker_command({
+    'ps',
+    '-a',
+    '--filter',
+    'label=' .. M.WORKSPACE_LABEL,
+    '--format',
+    '{{.ID}}\t{{.Label "' .. M.WORKSPACE_LABEL .. '"}}',
+  })
+  if labeled.success then
+    for line in labeled.stdout:gmatch('[^\n]+') do
+      local id, workspace = line:match('^([^\t]+)\t(.*)$')
+      if id then
+        workspaces[id] = workspace
+      end
+    end
+  end
+
   local containers = M.list_containers()
   local devcontainers = {}
 
   for _, container in ipairs(containers) do
-    if container.name:match('-devcontainer$') then
+    if workspaces[container.id] or container.name:match('-devcontainer$') then
       -- Parse status to determine if running
       local running = container.status:match('^Up') ~= nil
 
@@ -1915,6 +3237,7 @@ function M.list_devcontainers()
         status = container.status,
         image = container.image,
         running = running,
+        project_path = workspaces[container.id] ~= '' and workspaces[container.id] or nil,
       })
     end
   end
@@ -1922,11 +3245,31 @@ function M.list_devcontainers()
   return devcontainers
 end
 
+-- List local images (untagged images are left out)
+-- @return table: { { name = 'repo:tag', id, size, created } }
+function M.list_images()
+  local result = M.run_docker_command({
+    'images',
+    '--format',
+    '{{.Repository}}:{{.Tag}}\t{{.ID}}\t{{.Size}}\t{{.CreatedSince}}',
+  })
+  if not result.success then
+    return {}
+  end
+
+  local images = {}
+  for line in result.stdout:gmatch('[^\n]+') do
+    local name, id, size, created = line:match('^([^\t]+)\t([^\t]+)\t([^\t]*)\t(.*)$')
+    if name and not name:find('<none>', 1, true) then
+      table.insert(images, { name = name, id = id, size = size, created = created })
+    end
+  end
+  return images
+end
+
 -- Get container name for a project path
 function M.get_container_name(project_path)
-  local path_hash = vim.fn.sha256(project_path):sub(1, 8)
-  local project_name = vim.fn.fnamemodify(project_path, ':t')
-  return string.format('%s-%s-devcontainer', project_name:lower():gsub('[^a-z0-9_.-]', '-'), path_hash)
+  return M.generate_container_name({ name = vim.fn.fnamemodify(project_path, ':t'), base_path = project_path })
 end
 
 -- Get forwarded ports for all containers
@@ -2094,7 +3437,7 @@ end
 -- Build detailed error message when Docker daemon is not running
 function M._build_docker_daemon_error()
   local error_lines = {
-    'Docker is installed but the daemon is not running.',
+    'Docker daemon not reachable.',
     '',
     'To start Docker daemon:',
     '• macOS/Windows: Start Docker Desktop application',
@@ -2103,6 +3446,11 @@ function M._build_docker_daemon_error()
     'If using Docker Desktop, check that it has started completely.',
     'You may need to wait a few moments after starting Docker Desktop.',
   }
+  local host = runtime.docker_host()
+  if host then
+    table.insert(error_lines, '')
+    table.insert(error_lines, 'DOCKER_HOST is set to ' .. host .. ', check that the host is reachable.')
+  end
   return table.concat(error_lines, '\n')
 end
 
@@ -0,0 +1,111 @@
+-- lua/container/docker/runtime.lua
+-- Container runtime selection
+-- Every command of container.docker (and the exec commands built elsewhere) goes through the binary
+-- returned by get(), so Podman can be used as a drop-in replacement for Docker.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+M.RUNTIMES = { 'docker', 'podman' }
+
+-- Runtime found by 'auto' detection (cached for the session)
+local detected = nil
+
+local function configured_runtime()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  return ok and plugin_config and plugin_config.container_runtime or 'docker'
+end
+
+-- Detect the runtime by checking which binary exists (Docker is preferred when both are installed)
+function M.detect()
+  for _, runtime in ipairs(M.RUNTIMES) do
+    if vim.fn.executable(runtime) == 1 then
+      return runtime
+    end
+  end
+  return 'docker'
+end
+
+-- Binary used to run container commands
+function M.get()
+  local runtime = configured_runtime()
+  if runtime ~= 'auto' then
+    return runtime
+  end
+
+  if not detected then
+    detected = M.detect()
+    log.info('Detected container runtime: %s', detected)
+  end
+  return detected
+end
+
+function M.is_podman()
+  return M.get() == 'podman'
+end
+
+-- Rootless Podman maps the invoking user to root in the container's user namespace, so files
+-- written to bind mounts would be owned by a sub UID on the host unless the namespace keeps the host ID.
+function M.is_rootless_podman()
+  if not M.is_podman() then
+    return false
+  end
+  local uv = vim.uv or vim.loop
+  return uv.getuid() ~= 0
+end
+
+-- Daemon the runtime talks to (DOCKER_HOST for Docker, CONTAINER_HOST for Podman)
+function M.docker_host()
+  local host
+  if M.is_podman() then
+    host = vim.env.CONTAINER_HOST
+  else
+    host = vim.env.DOCKER_HOST
+  end
+  if host == nil or host == '' then
+    return nil
+  end
+  return host
+end
+
+-- Whether a daemon address points to another machine
+-- Unix sockets, named pipes and TCP on the loopback interface are local.
+function M.is_remote_host(host)
+  if not host or host == '' then
+    return false
+  end
+  if host:match('^ssh://') then
+    return true
+  end
+  local address = host:match('^tcp://([^/]+)')
+  if not address then
+    return false
+  end
+  local name = address:match('^%[(.-)%]') or address:match('^([^:]+)')
+  return not (name == 'localhost' or name == '::1' or (name and name:match('^127%.')))
+end
+
+-- Whether containers run on a remote daemon, where host paths of this machine do not exist
+-- Bind mounts are replaced by a workspace volume that is filled with `docker cp`.
+function M.is_remote()
+  return M.is_remote_host(M.docker_host())
+end
+
+-- Extra `create` arguments needed by the runtime
+-- @param run_args table|nil: user supplied runArgs, which take precedence
+function M.create_args(run_args)
+  if M.is_remote() or not M.is_rootless_podman() then
+    return {}
+  end
+  for _, arg in ipairs(run_args or {}) do
+    if arg:match('^%-%-userns') then
+      return {}
+    end
+  end
+  return { '--userns=keep-id' }
+end
+
+return M
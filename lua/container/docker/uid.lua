@@ -0,0 +1,227 @@
+-- lua/container/docker/uid.lua
+-- updateRemoteUserUID support
+-- Files created in a bind-mounted workspace are owned by the container user's UID, so on Linux
+-- hosts the user is remapped to the host UID/GID. Image based configs get a derived image;
+-- compose services are remapped in the running service container.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Shell script that rewrites /etc/passwd and /etc/group (expects REMOTE_USER, NEW_UID and NEW_GID)
+-- A missing user or an already taken UID is reported and left alone instead of failing the build.
+M.UPDATE_SCRIPT = {
+  [[eval $(awk -F: -v u="$REMOTE_USER" '$1 == u { print "OLD_UID="$3";OLD_GID="$4";HOME_FOLDER="$6 }' /etc/passwd);]],
+  [[eval $(awk -F: -v id="$NEW_UID" '$3 == id { print "EXISTING_USER=" $1 }' /etc/passwd);]],
+  [[eval $(awk -F: -v id="$NEW_GID" '$3 == id { print "EXISTING_GROUP=" $1 }' /etc/group);]],
+  [[if [ -z "$OLD_UID" ]; then]],
+  [[echo "Remote user not found in /etc/passwd ($REMOTE_USER).";]],
+  [[elif [ "$OLD_UID" = "$NEW_UID" ] && [ "$OLD_GID" = "$NEW_GID" ]; then]],
+  [[echo "UIDs and GIDs are the same ($NEW_UID:$NEW_GID).";]],
+  [[elif [ "$OLD_UID" != "$NEW_UID" ] && [ -n "$EXISTING_USER" ]; then]],
+  [[echo "User with UID exists ($EXISTING_USER=$NEW_UID).";]],
+  [[else]],
+  [[if [ "$OLD_GID" != "$NEW_GID" ] && [ -n "$EXISTING_GROUP" ]; then]],
+  [[echo "Group with GID exists ($EXISTING_GROUP=$NEW_GID)."; NEW_GID="$OLD_GID";]],
+  [[fi;]],
+  [[echo "Updating UID:GID from $OLD_UID:$OLD_GID to $NEW_UID:$NEW_GID.";]],
+  [[sed -i -e "s/^\(${REMOTE_USER}:[^:]*:\)[^:]*:[^:]*/\1${NEW_UID}:${NEW_GID}/" /etc/passwd;]],
+  [[if [ "$OLD_GID" != "$NEW_GID" ]; then sed -i -e "s/^\([^:]*:[^:]*:\)${OLD_GID}:/\1${NEW_GID}:/" /etc/group; fi;]],
+  [[chown -R "$NEW_UID:$NEW_GID" "$HOME_FOLDER";]],
+  [[fi]],
+}
+
+-- User whose UID is remapped: remoteUser, falling back to containerUser
+function M.get_target_user(config)
+  return config.remote_user or config.container_user
+end
+
+-- Host UID and GID
+function M.get_host_ids()
+  local uv = vim.uv or vim.loop
+  local uid = uv.getuid and uv.getuid() or tonumber(vim.trim(vim.fn.system({ 'id', '-u' })))
+  local gid = uv.getgid and uv.getgid() or tonumber(vim.trim(vim.fn.system({ 'id', '-g' })))
+  return uid, gid
+end
+
+-- Check whether the UID remap applies
+-- Only Linux hosts need it (Docker Desktop translates ownership itself), and root or numeric users are kept as is.
+function M.should_update(config)
+  if not config or config.update_remote_user_uid == false then
+    return false
+  end
+
+  local user = M.get_target_user(config)
+  if not user or user == 'root' or user:match('^%d+$') then
+    return false
+  end
+
+  local uv = vim.uv or vim.loop
+  if uv.os_uname().sysname ~= 'Linux' then
+    return false
+  end
+
+  -- Rootless Podman already keeps the host UID with --userns=keep-id
+  if require('container.docker.runtime').is_rootless_podman() then
+    return false
+  end
+
+  local uid = M.get_host_ids()
+  return uid ~= nil and uid ~= 0
+end
+
+-- Generate the Dockerfile of the derived image
+-- @param image_user string|nil: user of the base image, restored after the remap
+function M.generate_dockerfile(base_image, image_user)
+  local lines = {
+    'FROM ' .. base_image,
+    'USER root',
+    'ARG REMOTE_USER',
+    'ARG NEW_UID',
+    'ARG NEW_GID',
+    'RUN ' .. table.concat(M.UPDATE_SCRIPT, ' \\\n  '),
+  }
+  if image_user and image_user ~= '' then
+    table.insert(lines, 'USER ' .. image_user)
+  end
+  return table.concat(lines, '\n') .. '\n'
+end
+
+-- Tag of the derived image, unique per base image, user and host IDs
+function M.image_tag(base_image, user, uid, gid)
+  local key = vim.fn.sha256(string.format('%s|%s|%d|%d', base_image, user, uid, gid)):sub(1, 12)
+  return 'container-nvim-uid:' .. key
+end
+
+-- Build arguments passing the target user and host IDs
+function M.build_args(user, uid, gid)
+  return {
+    '--build-arg',
+    'REMOTE_USER=' .. user,
+    '--build-arg',
+    'NEW_UID=' .. uid,
+    '--build-arg',
+    'NEW_GID=' .. gid,
+  }
+end
+
+-- Build the derived image and set config.uid_image
+-- @param base_image string: image the container would otherwise run
+-- @param on_complete function(success, result)
+function M.build_image(config, base_image, on_progress, on_complete)
+  local docker = require('container.docker')
+  local user = M.get_target_user(config)
+  local uid, gid = M.get_host_ids()
+  local tag = M.image_tag(base_image, user, uid, gid)
+
+  local pipeline = require('container.pipeline')
+  local run = pipeline.active(config.workspace_root)
+
+  docker.check_image_exists_async(tag, function(exists)
+    if pipeline.is_cancelled(run) then
+      on_complete(false, docker.CANCELLED_RESULT)
+      return
+    end
+    if exists and not config.force_rebuild then
+      log.info('Using cached UID image: %s', tag)
+      config.uid_image = tag
+      on_complete(true, { success = true, stdout = '', stderr = '' })
+      return
+    end
+
+    local inspect_args = { 'image', 'inspect', '--format', '{{.Config.User}}', base_image }
+    docker.run_docker_command_async(inspect_args, {}, function(user_result)
+      local image_user = user_result.success and vim.trim(user_result.stdout) or nil
+
+      local context_dir = vim.fn.tempname()
+      vim.fn.mkdir(context_dir, 'p')
+      vim.fn.writefile(vim.split(M.generate_dockerfile(base_image, image_user), '\n'), context_dir .. '/Dockerfile')
+
+      if on_progress then
+        on_progress(string.format('Updating UID of %s to %d:%d...', user, uid, gid))
+      end
+
+      local args = { 'build', '-t', tag }
+      vim.list_extend(args, docker.image_label_args('uid'))
+      vim.list_extend(args, M.build_args(user, uid, gid))
+      vim.list_extend(args, { context_dir })
+
+      docker.run_docker_command_async(args, { timeout = 600, pipeline = config.workspace_root }, function(result)
+        vim.fn.delete(context_dir, 'rf')
+        if result.success then
+          log.info('Built UID image: %s', tag)
+          config.uid_image = tag
+        else
+          log.error('Failed to build UID image: %s', result.stderr)
+        end
+        on_complete(result.success, result)
+      end)
+    end)
+  end)
+end
+
+-- Interpret the result of `id -u <user>`; nil means the check itself could not run
+local function user_lookup_result(result)
+  if result.success then
+    return true
+  end
+  if (result.stderr or ''):match('executable file not found') then
+    return nil
+  end
+  return false
+end
+
+-- Check that a user exists in an image (before creating a container that runs as it)
+-- @return boolean|nil: nil when the image has no `id` binary to check with
+function M.user_exists_in_image(image, user)
+  local docker = require('container.docker')
+  return user_lookup_result(docker.run_docker_command({ 'run', '--rm', '--entrypoint', 'id', image, '-u', user }))
+end
+
+-- Async version of user_exists_in_image
+-- @param callback function(exists): exists is nil when the image has no `id` binary to check with
+function M.user_exists_in_image_async(image, user, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async({ 'run', '--rm', '--entrypoint', 'id', image, '-u', user }, {}, function(result)
+    callback(user_lookup_result(result))
+  end)
+end
+
+-- Check that a user exists in a running container
+-- @return boolean|nil: nil when the container has no `id` binary to check with
+function M.user_exists_in_container(container_id, user)
+  local docker = require('container.docker')
+  return user_lookup_result(docker.run_docker_command({ 'exec', container_id, 'id', '-u', user }))
+end
+
+-- Remap the user inside a running container (compose services build their own images)
+-- @return boolean: true when the script ran successfully
+function M.update_running_container(container_id, config)
+  local docker = require('container.docker')
+  local user = M.get_target_user(config)
+  local uid, gid = M.get_host_ids()
+
+  local result = docker.run_docker_command({
+    'exec',
+    '-u',
+    'root',
+    '-e',
+    'REMOTE_USER=' .. user,
+    '-e',
+    'NEW_UID=' .. uid,
+    '-e',
+    'NEW_GID=' .. gid,
+    container_id,
+    'sh',
+    '-c',
+    table.concat(M.UPDATE_SCRIPT, ' '),
+  })
+  if not result.success then
+    log.warn('Failed to update UID of %s: %s', user, result.stderr)
+    return false
+  end
+  log.info('UID update: %s', vim.trim(result.stdout))
+  return true
+end
+
+return M
@@ -0,0 +1,159 @@
+-- lua/container/doctor.lua
+-- Environment diagnostics (:ContainerDoctor): runtime, daemon, compose and configuration checks
+
+local M = {}
+
+M.OUTPUT_NAME = 'doctor'
+
+local ICONS = { ok = '✓', warn = '⚠', error = '✗' }
+
+-- Run a command and return its trimmed output and whether it succeeded
+local function run(args)
+  local output = vim.fn.system(args)
+  return vim.trim(output or ''), vim.v.shell_error == 0
+end
+
+-- Hints for common daemon errors
+local function daemon_hint(detail)
+  if detail:match('[Pp]ermission denied') then
+    return 'Add your user to the docker group (sudo usermod -aG docker $USER) and log in again'
+  end
+  if detail:match('[Cc]annot connect') or detail:match('[Ii]s the docker daemon running') then
+    return 'Start Docker Desktop or run "sudo systemctl start docker"'
+  end
+  return nil
+end
+
+-- Collect the diagnostics
+-- @return table: list of { level = 'ok'|'warn'|'error', message, hint }
+function M.collect()
+  local runtime = require('container.docker.runtime')
+  local docker = require('container.docker')
+  local results = {}
+  local function add(level, message, hint)
+    table.insert(results, { level = level, message = message, hint = hint })
+  end
+
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  plugin_config = ok and plugin_config or {}
+
+  -- Runtime binary
+  local binary = runtime.get()
+  if vim.fn.executable(binary) == 0 then
+    local hint = nil
+    for _, candidate in ipairs(runtime.RUNTIMES) do
+      if candidate ~= binary and vim.fn.executable(candidate) == 1 then
+        hint = string.format('%s is installed: set container_runtime = "%s" or "auto"', candidate, candidate)
+      end
+    end
+    add('error', string.format('%s not found in PATH', binary), hint)
+    return results
+  end
+  local version = run({ binary, '--version' })
+  add('ok', string.format('%s: %s', binary, version))
+
+  -- Daemon
+  local host = runtime.docker_host()
+  if host then
+    add('ok', string.format('Daemon address: %s%s', host, runtime.is_remote() and ' (remote)' or ''))
+  end
+  local server_version, reachable = run(docker.ping_args())
+  if not reachable then
+    add('error', 'Docker daemon not reachable: ' .. server_version, daemon_hint(server_version))
+    return results
+  end
+  add('ok', 'Daemon reachable (server version ' .. server_version .. ')')
+
+  -- Docker Compose
+  local compose_version, has_compose = run({ binary, 'compose', 'version', '--short' })
+  if has_compose then
+    add('ok', 'Compose: ' .. compose_version)
+  else
+    add('warn', 'docker compose is not available', 'Install the Compose plugin to use dockerComposeFile configurations')
+  end
+
+  -- Plugin configuration
+  local valid, errors = require('container.config.validator').validate(plugin_config)
+  if valid then
+    add('ok', 'Plugin configuration is valid')
+  else
+    for _, err in ipairs(errors) do
+      add('warn', 'Configuration: ' .. err)
+    end
+  end
+
+  -- Workspace mount of the attached container (slow file sharing lets tests see stale files)
+  local container_ok, container = pcall(require, 'container')
+  local container_id = container_ok and container.get_container_id and container.get_container_id()
+  if container_id then
+    local sync_check = require('container.sync_check')
+    local _, container_root = require('container.parser').workspace_roots(container.get_state().current_config)
+    local mounts, has_mounts = run({ binary, 'exec', container_id, 'cat', '/proc/mounts' })
+    local mount = has_mounts and sync_check.parse_mounts(mounts, container_root) or nil
+    if mount then
+      add('ok', string.format('Workspace mount: %s (%s)', container_root, sync_check.describe_mount(mount)))
+    else
+      add('warn', 'Workspace mount type could not be detected', 'Run :ContainerSyncCheck to measure mount latency')
+    end
+  end
+
+  -- devcontainer.json of the current directory
+  local parser = require('container.parser')
+  local config_path = parser.find_devcontainer_json(vim.fn.getcwd())
+  if not config_path then
+    add('warn', 'No devcontainer.json found from ' .. vim.fn.getcwd())
+    return results
+  end
+  local raw_config, parse_err = parser.parse(config_path)
+  if not raw_config then
+    add('error', 'devcontainer.json: ' .. tostring(parse_err))
+    return results
+  end
+  add('ok', 'devcontainer.json: ' .. config_path)
+  for _, err in ipairs(parser.validate(raw_config)) do
+    add('warn', 'devcontainer.json: ' .. err)
+  end
+
+  local devcontainer = parser.normalize_for_plugin(raw_config)
+  if devcontainer.dockerfile and vim.fn.filereadable(devcontainer.dockerfile) == 0 then
+    add('error', 'Dockerfile not found: ' .. devcontainer.dockerfile)
+  end
+  if runtime.is_remote() then
+    for _, mount in ipairs(devcontainer.mounts or {}) do
+      if mount.type == 'bind' then
+        add('warn', 'Bind mount ignored on a remote daemon: ' .. tostring(mount.source))
+      end
+    end
+  end
+  if require('container.docker.compose').is_compose_config(devcontainer) and not has_compose then
+    add('error', 'devcontainer.json uses dockerComposeFile but docker compose is not available')
+  end
+
+  return results
+end
+
+-- Format the diagnostics as report lines
+function M.format(results)
+  local lines = { 'container.nvim doctor', '' }
+  for _, result in ipairs(results) do
+    table.insert(lines, string.format('%s %s', ICONS[result.level], result.message))
+    if result.hint then
+      table.insert(lines, '    ' .. result.hint)
+    end
+  end
+  return lines
+end
+
+-- Collect the diagnostics and show them in the container://doctor buffer
+-- @return table: the collected results
+function M.run()
+  local results = M.collect()
+  local output = require('container.ui.output')
+  output.set_lines(M.OUTPUT_NAME, M.format(results))
+  output.open(M.OUTPUT_NAME)
+  return results
+end
+
+return M
@@ -0,0 +1,310 @@
+-- lua/container/dry_run.lua
+-- Dry run of :ContainerStart (:ContainerStart --dry-run)
+-- Computes the docker commands a start would run, with the resolved mounts, environment and ports,
+-- without executing anything. The plan is written as a shell script that can be pasted into a terminal.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Name of the output buffer used for the plan
+M.OUTPUT_NAME = 'dry-run'
+
+-- Word standing for the container in lifecycle commands, printed as "$CONTAINER"
+local CONTAINER_REF = '\0container'
+
+-- Quote a word for POSIX shells
+-- @param word string
+-- @return string
+function M.shell_quote(word)
+  word = tostring(word)
+  if word == CONTAINER_REF then
+    return '"$CONTAINER"'
+  end
+  if word ~= '' and not word:find('[^%w@%%+=:,./_-]') then
+    return word
+  end
+  return "'" .. word:gsub("'", [['\'']]) .. "'"
+end
+
+-- Join words into a shell command line
+-- @param words table
+-- @return string
+function M.shell_join(words)
+  local quoted = {}
+  for _, word in ipairs(words) do
+    table.insert(quoted, M.shell_quote(word))
+  end
+  return table.concat(quoted, ' ')
+end
+
+-- Sorted "KEY=value" lines of an environment table
+local function env_lines(env)
+  local keys = vim.tbl_keys(env or {})
+  table.sort(keys)
+  local lines = {}
+  for _, key in ipairs(keys) do
+    table.insert(lines, string.format('#   %s=%s', key, tostring(env[key])))
+  end
+  return lines
+end
+
+-- Summary of ports, mounts and environment at the top of the plan
+local function summary(config, docker)
+  local lines = { '# Ports:' }
+  local has_ports = false
+  for _, port in ipairs(config.ports or {}) do
+    if port.host_port and port.container_port then
+      has_ports = true
+      local requested = port.requested_host_port
+      table.insert(
+        lines,
+        string.format(
+          '#   container %d -> host %d%s%s',
+          port.container_port,
+          port.host_port,
+          requested and string.format(' (%d is in use)', requested) or '',
+          docker.is_published_at_create(port) and '' or ' (forwarded after start)'
+        )
+      )
+    end
+  end
+  if not has_ports then
+    table.insert(lines, '#   (none)')
+  end
+
+  -- Plugin defaults put before the flags of devcontainer.json
+  for _, defaults in ipairs({
+    { args = config.default_build_args, title = '# default_build_args (before the build settings):' },
+    { args = config.default_run_args, title = '# default_run_args (before runArgs):' },
+  }) do
+    if defaults.args and #defaults.args > 0 then
+      table.insert(lines, defaults.title)
+      table.insert(lines, '#   ' .. M.shell_join(defaults.args))
+    end
+  end
+
+  table.insert(lines, '# Mounts:')
+  if config.workspace_mount then
+    table.insert(lines, '#   ' .. docker.format_mount(config.workspace_mount) .. ' (workspace)')
+  end
+  if config.workspace_clone then
+    local clone = config.workspace_clone
+    local branch = clone.branch and (' (branch ' .. clone.branch .. ')') or ''
+    table.insert(lines, string.format('#   %s%s is cloned into it on the first start', clone.repository, branch))
+  end
+  for _, mount in ipairs(config.mounts or {}) do
+    table.insert(lines, '#   ' .. docker.format_mount(mount))
+  end
+  if not config.workspace_mount and #(config.mounts or {}) == 0 then
+    table.insert(lines, '#   (none)')
+  end
+
+  local container_env = config.environment or {}
+  table.insert(lines, '# containerEnv:')
+  vim.list_extend(lines, vim.tbl_isempty(container_env) and { '#   (none)' } or env_lines(container_env))
+  local remote_env = config.remote_env or {}
+  table.insert(lines, '# remoteEnv (exec sessions and lifecycle commands):')
+  vim.list_extend(lines, vim.tbl_isempty(remote_env) and { '#   (none)' } or env_lines(remote_env))
+
+  local secrets = require('container.secrets').describe()
+  if #secrets > 0 then
+    table.insert(lines, '# Secrets (values hidden, passed by name):')
+    for _, secret in ipairs(secrets) do
+      table.insert(lines, '#   ' .. secret)
+    end
+  end
+
+  -- Values of env files usually are secrets: only their names are shown
+  if config.env_files and #config.env_files > 0 then
+    table.insert(lines, '# Env files (values hidden):')
+    for _, path in ipairs(config.env_files) do
+      table.insert(lines, '#   ' .. path)
+    end
+    for _, key in ipairs(config.env_file_keys or {}) do
+      table.insert(lines, '#   ' .. key .. '=***')
+    end
+  end
+  return lines
+end
+
+-- initializeCommand, run on the host from the workspace root
+local function initialize_section(config)
+  local lifecycle = require('container.lifecycle')
+  local entries = lifecycle.normalize_command(config.initialize_command)
+  if #entries == 0 then
+    return {}
+  end
+  local lines = { '', '# initializeCommand (on the host)' }
+  table.insert(lines, M.shell_join({ 'cd', config.workspace_root or config.base_path or vim.fn.getcwd() }))
+  for _, entry in ipairs(entries) do
+    table.insert(lines, M.shell_join(entry.args))
+  end
+  return lines
+end
+
+-- Image build or pull, features and UID remap; sets the image names the container would run
+local function image_section(config, docker, runtime)
+  local lines = {}
+
+  if config.dockerfile then
+    config.image_cache_key = docker.compute_image_cache_key(config)
+    local tag = docker.get_image_cache_tag(config, config.image_cache_key)
+    local args, buildkit = docker.build_image_args(config, tag)
+    table.insert(lines, '')
+    local candidates = docker.prebuilt_candidates(config)
+    if #candidates > 0 then
+      table.insert(lines, '# Pull a prebuilt image first, used when labeled ' .. config.image_cache_key)
+      for _, image in ipairs(candidates) do
+        table.insert(lines, M.shell_join({ runtime.get(), 'pull', image }))
+      end
+    end
+    vim.list_extend(lines, {
+      '# Build the image (skipped when ' .. tag .. ' exists, unless :ContainerStart!)',
+      M.shell_join({ 'cd', config.base_path or vim.fn.getcwd() }),
+      'DOCKER_BUILDKIT=' .. (buildkit and '1' or '0') .. ' ' .. M.shell_join(vim.list_extend({ runtime.get() }, args)),
+    })
+    config.built_image = tag
+  elseif config.image then
+    vim.list_extend(lines, {
+      '',
+      '# Pull the image (skipped when it exists locally)',
+      M.shell_join({ runtime.get(), 'pull', config.image }),
+    })
+  end
+
+  local base_image = config.built_image or config.image
+  local features = require('container.features')
+  local feature_list = features.normalize(config.features, config.devcontainer_folder)
+  if #feature_list > 0 and base_image then
+    local features_lock = require('container.features_lock')
+    if config.config_file and require('container.config').get_value('features_lock') ~= 'off' then
+      features_lock.apply(feature_list, features_lock.read(features_lock.path(config.config_file)))
+    end
+    config.features_image = features.image_tag(config, base_image, feature_list)
+    vim.list_extend(lines, { '', '# Install devcontainer features (the build context is generated at start)' })
+    for _, feature in ipairs(feature_list) do
+      local locked = feature.locked and string.format(' (locked: %s)', feature.oci.digest) or ''
+      table.insert(lines, '#   ' .. feature.ref .. locked)
+    end
+    table.insert(lines, '#   -> ' .. config.features_image)
+  end
+
+  local uid = require('container.docker.uid')
+  local run_image = config.features_image or config.built_image or config.image
+  if run_image and uid.should_update(config) then
+    local user = uid.get_target_user(config)
+    local host_uid, host_gid = uid.get_host_ids()
+    config.uid_image = uid.image_tag(run_image, user, host_uid, host_gid)
+    vim.list_extend(lines, {
+      '',
+      string.format('# Remap the UID/GID of %s to %d:%d (when the user exists in the image)', user, host_uid, host_gid),
+      '#   -> ' .. config.uid_image,
+    })
+  end
+
+  return lines
+end
+
+-- Lifecycle commands executed in the container once it runs
+local function lifecycle_section(config, container_line)
+  local lifecycle = require('container.lifecycle')
+  local runtime = require('container.docker.runtime')
+  local lines = {}
+  for _, hook in ipairs(lifecycle.HOOKS) do
+    for _, entry in ipairs(lifecycle.normalize_command(config[hook.key])) do
+      if #lines == 0 then
+        lines = { '', '# Lifecycle commands (the create family only runs on the first start)', container_line }
+      end
+      local args = vim.list_extend({ runtime.get() }, lifecycle.build_exec_args(CONTAINER_REF, config, entry))
+      table.insert(lines, '# ' .. hook.name .. (entry.label and ' (' .. entry.label .. ')' or ''))
+      table.insert(lines, M.shell_join(args))
+    end
+  end
+  return lines
+end
+
+-- Docker compose services
+local function compose_section(config, runtime)
+  local compose = require('container.docker.compose')
+  require('container.docker').resolve_port_conflicts(config.ports)
+  -- The merged compose configuration is not inspected, so ports are always published
+  local override = vim.json.encode(compose.build_override(config, nil))
+  local base = vim.list_extend({ runtime.get() }, compose.build_base_args(config, true))
+
+  local up = vim.list_extend(vim.deepcopy(base), { 'up', '-d', '--build' })
+  if config.force_rebuild then
+    table.insert(up, '--force-recreate')
+  end
+  vim.list_extend(up, compose.get_services_to_start(config))
+
+  local lines = {
+    '',
+    '# Start the compose services',
+    M.shell_join({ 'cd', config.compose_project_dir or config.base_path or vim.fn.getcwd() }),
+    'cat > ' .. M.shell_quote(compose.get_override_path(config)) .. " <<'EOF'",
+    override,
+    'EOF',
+    M.shell_join(up),
+  }
+  local ps = M.shell_join(vim.list_extend(vim.deepcopy(base), { 'ps', '-q', config.service }))
+  vim.list_extend(lines, lifecycle_section(config, 'CONTAINER=$(' .. ps .. ')'))
+  return lines
+end
+
+-- Plan of a start as shell script lines
+-- The configuration is not modified.
+-- @param config table: normalized devcontainer configuration
+-- @return table: lines
+function M.plan(config)
+  local docker = require('container.docker')
+  local runtime = require('container.docker.runtime')
+  config = vim.deepcopy(config)
+
+  local lines = {
+    '#!/bin/sh',
+    string.format('# Dry run of :ContainerStart for %s, nothing has been executed.', config.name or 'devcontainer'),
+    '# Steps for an existing container (start only) and cached images are skipped at start.',
+    '#',
+  }
+  local steps = initialize_section(config)
+
+  if require('container.docker.compose').is_compose_config(config) then
+    vim.list_extend(steps, compose_section(config, runtime))
+  else
+    vim.list_extend(steps, image_section(config, docker, runtime))
+    local create = vim.list_extend({ runtime.get() }, docker._build_create_args(config))
+    local name = docker.resolve_container_name(config)
+    vim.list_extend(steps, {
+      '',
+      '# Create and start the container',
+      M.shell_join(create),
+      M.shell_join({ runtime.get(), 'start', name }),
+    })
+    vim.list_extend(steps, lifecycle_section(config, 'CONTAINER=' .. M.shell_quote(name)))
+  end
+
+  -- Ports are resolved while the commands are built, so the summary comes last
+  vim.list_extend(lines, summary(config, docker))
+  vim.list_extend(lines, steps)
+  return lines
+end
+
+-- Show the plan of a start in the dry-run output buffer
+-- @param config table: normalized devcontainer configuration
+function M.show(config)
+  local ok, lines = pcall(M.plan, config)
+  if not ok then
+    log.error('Failed to compute the dry run: %s', lines)
+    require('container.utils.notify').error('Failed to compute the dry run: ' .. tostring(lines))
+    return false
+  end
+
+  local output = require('container.ui.output')
+  output.set_lines(M.OUTPUT_NAME, lines)
+  vim.bo[output.get_buffer(M.OUTPUT_NAME)].filetype = 'sh'
+  output.open(M.OUTPUT_NAME, { focus = true })
+  return true
+end
+
+return M
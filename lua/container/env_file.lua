@@ -0,0 +1,256 @@
+-- lua/container/env_file.lua
+-- Environment files (--env-file in runArgs and the env_files plugin setting)
+-- The files are parsed like .env files (comments, export, quoted values) and their variables are written to one
+-- generated file in Docker's plain KEY=VALUE format. That file is passed with --env-file to docker create and to
+-- every exec session (terminals, LSP, lifecycle commands), so values never appear on command lines or in logs.
+-- The generated file is readable by the owner only and removed when the container stops or Neovim exits.
+
+local M = {}
+
+local fs = require('container.utils.fs')
+local log = require('container.utils.log')
+
+-- Escapes recognized in double-quoted values
+local escapes = { n = '\n', r = '\r', t = '\t', ['"'] = '"', ['\\'] = '\\' }
+
+-- Value of a KEY=VALUE line after the "="
+-- @return string, string|nil: value and a warning
+local function parse_value(raw)
+  local quote = raw:sub(1, 1)
+  if quote == "'" then
+    local value = raw:match("^'(.-)'")
+    if value then
+      return value
+    end
+    return raw, 'unterminated single quote'
+  elseif quote == '"' then
+    local chars = {}
+    local i = 2
+    while i <= #raw do
+      local char = raw:sub(i, i)
+      if char == '\\' and i < #raw then
+        local next_char = raw:sub(i + 1, i + 1)
+        table.insert(chars, escapes[next_char] or ('\\' .. next_char))
+        i = i + 2
+      elseif char == '"' then
+        return table.concat(chars)
+      else
+        table.insert(chars, char)
+        i = i + 1
+      end
+    end
+    return raw, 'unterminated double quote'
+  end
+  -- Unquoted values end at a comment preceded by whitespace
+  return vim.trim((raw:gsub('%s+#.*$', '')))
+end
+
+-- Parse the content of an environment file
+-- Lines are KEY=VALUE, optionally prefixed with "export". Values may be single-quoted (literal) or double-quoted
+-- (with \n, \t, \" and \\ escapes). A bare KEY takes the value of the host environment, like docker --env-file.
+-- Warnings name the line and never include values.
+-- @param content string
+-- @return table, table, table: variables, names in file order, warnings
+function M.parse(content)
+  local env = {}
+  local keys = {}
+  local warnings = {}
+  local lnum = 0
+  for line in ((content or '') .. '\n'):gmatch('(.-)\r?\n') do
+    lnum = lnum + 1
+    line = vim.trim(line)
+    if line ~= '' and not vim.startswith(line, '#') then
+      line = line:gsub('^export%s+', '')
+      local key, raw = line:match('^([%a_][%w_.-]*)%s*=%s*(.*)$')
+      local value, warning
+      if key then
+        value, warning = parse_value(raw)
+      else
+        key = line:match('^([%a_][%w_.-]*)$')
+        value = key and os.getenv(key)
+        if not key then
+          warning = 'expected KEY=VALUE'
+        end
+      end
+      if warning then
+        table.insert(warnings, string.format('line %d: %s', lnum, warning))
+      end
+      if key and value then
+        if env[key] == nil then
+          table.insert(keys, key)
+        end
+        env[key] = value
+      end
+    end
+  end
+  return env, keys, warnings
+end
+
+-- Read and parse an environment file
+-- @param path string
+-- @return table|nil, table|string: { env, keys, warnings }, or nil and the error
+function M.read(path)
+  local content = fs.read_file(path)
+  if not content then
+    return nil, 'cannot read ' .. path
+  end
+  local env, keys, warnings = M.parse(content)
+  return { env = env, keys = keys, warnings = warnings }
+end
+
+-- Render variables in Docker's --env-file format (values are taken literally up to the end of the line)
+-- @param env table
+-- @param keys table: names in output order
+-- @return string, table: content and names skipped because their value spans several lines
+function M.render(env, keys)
+  local lines = {}
+  local skipped = {}
+  for _, key in ipairs(keys) do
+    if env[key]:find('[\r\n]') then
+      table.insert(skipped, key)
+    else
+      table.insert(lines, key .. '=' .. env[key])
+    end
+  end
+  return table.concat(lines, '\n') .. (#lines > 0 and '\n' or ''), skipped
+end
+
+-- Take --env-file out of runArgs
+-- @param run_args table
+-- @return table, table: remaining runArgs and the env file paths in order
+function M.extract_run_args(run_args)
+  local remaining = {}
+  local paths = {}
+  local i = 1
+  while i <= #(run_args or {}) do
+    local arg = run_args[i]
+    local value = arg:match('^%-%-env%-file=(.+)$')
+    if value then
+      table.insert(paths, value)
+    elseif arg == '--env-file' and run_args[i + 1] then
+      table.insert(paths, run_args[i + 1])
+      i = i + 1
+    else
+      table.insert(remaining, arg)
+    end
+    i = i + 1
+  end
+  return remaining, paths
+end
+
+-- Write a file readable by the owner only
+-- A previous file is removed first so the new one is created with mode 0600 and never readable by others.
+-- @return boolean, string|nil: success and the error
+local function write_private(path, content)
+  local uv = vim.uv or vim.loop
+  local ok, err = fs.ensure_directory(fs.dirname(path))
+  if not ok then
+    return false, err
+  end
+  uv.fs_unlink(path)
+  local fd, open_err = uv.fs_open(path, 'wx', 384)
+  if not fd then
+    return false, open_err
+  end
+  local written, write_err = uv.fs_write(fd, content, 0)
+  uv.fs_close(fd)
+  if not written then
+    uv.fs_unlink(path)
+    return false, write_err
+  end
+  return true
+end
+
+-- Path of the generated env file of a configuration
+function M.get_generated_path(config)
+  local project_path = config.base_path or vim.fn.getcwd()
+  local clean_name = (config.name or 'devcontainer'):lower():gsub('[^a-z0-9_-]', '-')
+  local name = string.format('%s-%s.env', clean_name, vim.fn.sha256(project_path):sub(1, 8))
+  return fs.join_path(vim.fn.stdpath('cache'), 'container.nvim', 'env', name)
+end
+
+-- Load the env files of a normalized configuration and write the generated env file
+-- --env-file entries are taken out of run_args; they and env_files are resolved against the workspace root after
+-- expanding ~ and environment variables. Later files override earlier ones. Missing files are skipped.
+-- Sets config.env_file (generated path), config.env_files (sources) and config.env_file_keys.
+-- @param config table: normalized configuration
+-- @param env_files table|nil: the env_files plugin setting
+-- @return table: warnings
+function M.prepare(config, env_files)
+  local parser = require('container.parser')
+  local warnings = {}
+  local run_args, paths = M.extract_run_args(config.run_args)
+  config.run_args = run_args
+  vim.list_extend(paths, env_files or {})
+
+  local root = config.workspace_root or config.base_path or vim.fn.getcwd()
+  local env = {}
+  local keys = {}
+  local sources = {}
+  for _, path in ipairs(paths) do
+    path = parser.expand_host_path(path)
+    if not fs.is_absolute_path(path) then
+      path = fs.resolve_path(path, root)
+    end
+    local parsed, err = M.read(path)
+    if not parsed then
+      table.insert(warnings, string.format('Skipping env file %s: %s', path, err))
+    else
+      table.insert(sources, path)
+      for _, warning in ipairs(parsed.warnings) do
+        table.insert(warnings, string.format('Env file %s %s', path, warning))
+      end
+      for _, key in ipairs(parsed.keys) do
+        if env[key] == nil then
+          table.insert(keys, key)
+        end
+        env[key] = parsed.env[key]
+      end
+    end
+  end
+
+  config.env_file = nil
+  config.env_files = sources
+  config.env_file_keys = keys
+  if #sources == 0 then
+    return warnings
+  end
+
+  local content, skipped = M.render(env, keys)
+  for _, key in ipairs(skipped) do
+    table.insert(warnings, string.format('Skipping %s from env files: multi-line values are not supported', key))
+  end
+  local path = M.get_generated_path(config)
+  -- The file holds secrets: readable by the owner only
+  local ok, err = write_private(path, content)
+  if not ok then
+    table.insert(warnings, 'Could not write env file: ' .. tostring(err))
+    return warnings
+  end
+  log.debug('Loaded %d variable(s) from %s into %s', #keys, table.concat(sources, ', '), path)
+  config.env_file = path
+  return warnings
+end
+
+-- docker create/exec arguments passing the generated env file
+-- @param config table|nil: normalized configuration
+-- @return table
+function M.args(config)
+  if config and config.env_file and vim.fn.filereadable(config.env_file) == 1 then
+    return { '--env-file', config.env_file }
+  end
+  return {}
+end
+
+-- Remove the generated env file of a configuration (the container stopped or Neovim exits)
+-- @param config table|nil: normalized configuration
+function M.remove(config)
+  if config and config.env_file then
+    local uv = vim.uv or vim.loop
+    uv.fs_unlink(config.env_file)
+    log.debug('Removed env file %s', config.env_file)
+    config.env_file = nil
+  end
+end
+
+return M
@@ -53,8 +53,16 @@ local function get_environment(config, context_type)
     log.debug('Applied standard containerEnv')
   end
 
-  if config.remoteEnv then
-    env = vim.tbl_deep_extend('force', env, config.remoteEnv)
+  -- The environment of the user's shell (userEnvProbe) adds what rc files set, e.g. PATH entries of version managers
+  if config.user_env then
+    env = vim.tbl_deep_extend('force', env, config.user_env)
+    log.debug('Applied probed user environment')
+  end
+
+  -- remoteEnv is applied last so it can override containerEnv for exec sessions
+  local remote_env = M.get_remote_environment(config)
+  if not vim.tbl_isempty(remote_env) then
+    env = vim.tbl_deep_extend('force', env, remote_env)
     log.debug('Applied standard remoteEnv')
   end
 
@@ -130,6 +138,226 @@ local function expand_env_vars(value)
   return value
 end
 
+-- Resolve ${containerEnv:VAR} and ${containerEnv:VAR:default} against the container environment
+-- References that cannot be resolved are kept so the fallback expansion can handle them
+function M.resolve_container_env_refs(value, container_env)
+  if type(value) ~= 'string' or not container_env then
+    return value
+  end
+
+  return (value:gsub('${containerEnv:([^}:]+):?([^}]*)}', function(var_name, default)
+    if container_env[var_name] ~= nil then
+      return container_env[var_name]
+    end
+    if default ~= '' then
+      return default
+    end
+    return nil
+  end))
+end
+
+-- Get remoteEnv (normalized or raw) resolved against the running container environment
+function M.get_remote_environment(config)
+  local remote_env = config and (config.remote_env or config.remoteEnv) or {}
+  local resolved = {}
+  for key, value in pairs(remote_env) do
+    resolved[key] = M.resolve_container_env_refs(value, config.container_runtime_env)
+  end
+  return resolved
+end
+
+-- Expand the variables of a working directory in the container (terminal, exec and LSP cwd)
+-- The variables of devcontainer.json are supported (${containerWorkspaceFolder}, ${localWorkspaceFolder},
+-- ${localEnv:NAME}, ${devcontainerId}), and ${containerEnv:NAME[:default]} sees remoteEnv on top of the container
+-- environment like exec sessions do. A default may refer to another variable, expanded when the default is used:
+-- ${containerEnv:PROJECT_DIR:${containerWorkspaceFolder}/app}.
+-- @param path string|nil
+-- @param config table|nil: normalized configuration
+-- @return string|nil: the path with the variables that could be resolved expanded
+function M.expand_path(path, config)
+  if type(path) ~= 'string' or not path:find('${', 1, true) then
+    return path
+  end
+  local parser = require('container.parser')
+  local host_root, container_root = parser.workspace_roots(config)
+  local context = {
+    workspace_folder = host_root,
+    container_workspace = container_root,
+    devcontainer_id = config and config.devcontainer_id,
+    defer_container_env = true,
+  }
+  -- The container environment is read on the first ${containerEnv:...} unless remoteEnv already needed it
+  local env
+  local function lookup(var_name)
+    if not env then
+      if config and not config.container_runtime_env then
+        local ok, container = pcall(require, 'container')
+        local container_id = ok and container.get_container_id and container.get_container_id()
+        if container_id then
+          config.container_runtime_env = M.load_container_env(container_id)
+        end
+      end
+      env = vim.tbl_extend('force', config and config.container_runtime_env or {}, M.get_remote_environment(config))
+    end
+    return env[var_name]
+  end
+
+  -- Values are not scanned again, so a variable that refers to itself cannot loop
+  local function resolve(name)
+    local var_name, default = name:match('^containerEnv:([^:${}]+):?(.*)$')
+    if not var_name then
+      return parser.expand_variables('${' .. name .. '}', context)
+    end
+    local value = lookup(var_name)
+    if value ~= nil then
+      return tostring(value)
+    end
+    return default ~= '' and parser.substitute(default, resolve) or nil
+  end
+  path = parser.substitute(path, resolve)
+
+  if path:find('${', 1, true) then
+    log.warn('Working directory %s refers to unknown variables', path)
+  end
+  return path
+end
+
+-- Check whether remoteEnv references ${containerEnv:...}
+function M.needs_container_env(config)
+  for _, value in pairs(config and (config.remote_env or config.remoteEnv) or {}) do
+    if type(value) == 'string' and value:find('${containerEnv:', 1, true) then
+      return true
+    end
+  end
+  return false
+end
+
+-- Read the environment of a running container (image ENV plus containerEnv)
+-- @return table: variable name -> value
+function M.load_container_env(container_id)
+  local docker = require('container.docker')
+  local result = docker.run_docker_command({ 'inspect', '--format', '{{json .Config.Env}}', container_id })
+  local env = {}
+  if not result.success then
+    log.warn('Failed to read container environment: %s', result.stderr)
+    return env
+  end
+
+  local ok, entries = pcall(vim.json.decode, vim.trim(result.stdout))
+  if ok and type(entries) == 'table' then
+    for _, entry in ipairs(entries) do
+      local key, value = entry:match('^([^=]+)=(.*)$')
+      if key then
+        env[key] = value
+      end
+    end
+  end
+  return env
+end
+
+-- Shell flags of the userEnvProbe values ('none' probes nothing)
+M.USER_ENV_PROBE_FLAGS = {
+  loginShell = '-lc',
+  interactiveShell = '-ic',
+  loginInteractiveShell = '-lic',
+}
+
+-- Variables of the probe shell itself that exec sessions must not inherit
+local PROBE_IGNORED = {
+  _ = true,
+  PWD = true,
+  OLDPWD = true,
+  SHLVL = true,
+  HOSTNAME = true,
+  TERM = true,
+  PS1 = true,
+  PS2 = true,
+  PROMPT_COMMAND = true,
+}
+
+-- Marker around the probed environment, so output of rc files is ignored
+local PROBE_MARKER = 'CONTAINER_NVIM_ENV_PROBE'
+
+-- Build the docker exec arguments that print the environment of the user's shell
+-- The login shell of the user comes from /etc/passwd; NUL separators are turned into \001 so the output survives
+-- system().
+-- @param container_id string
+-- @param config table: normalized configuration (user_env_probe, remote_user)
+-- @return table|nil: nil when userEnvProbe is none
+function M.build_probe_args(container_id, config)
+  local flags = M.USER_ENV_PROBE_FLAGS[config.user_env_probe]
+  if not flags then
+    return nil
+  end
+  local args = { 'exec' }
+  if config.remote_user then
+    vim.list_extend(args, { '-u', config.remote_user })
+  end
+  local script = table.concat({
+    'shell=$(getent passwd "$(id -un)" 2>/dev/null | cut -d: -f7)',
+    '[ -x "$shell" ] || shell=/bin/sh',
+    'exec "$shell" "$1" "printf %s $2; cat /proc/self/environ | tr \'\\000\' \'\\001\'; printf %s $2"',
+  }, '; ')
+  vim.list_extend(args, { container_id, 'sh', '-c', script, 'sh', flags, PROBE_MARKER })
+  return args
+end
+
+-- Parse the output of the probe into the variables the shell adds or changes
+-- @param stdout string
+-- @param base table|nil: environment of the container (load_container_env), left out of the result
+-- @return table|nil: variable name -> value; nil when the output has no environment
+function M.parse_probe_output(stdout, base)
+  local first = (stdout or ''):find(PROBE_MARKER, 1, true)
+  local last = first and stdout:find(PROBE_MARKER, first + #PROBE_MARKER, true)
+  if not last then
+    return nil
+  end
+  local env = {}
+  for entry in stdout:sub(first + #PROBE_MARKER, last - 1):gmatch('[^\1\n]+') do
+    local key, value = entry:match('^([%a_][%w_]*)=(.*)$')
+    if key and not PROBE_IGNORED[key] and (base or {})[key] ~= value then
+      env[key] = value
+    end
+  end
+  return env
+end
+
+-- Probe the environment of the user's shell (userEnvProbe) and cache it in config.user_env
+-- Exec, LSP and terminal sessions inherit it below remoteEnv, so tools installed through rc files are found.
+-- @param container_id string
+-- @param config table: normalized configuration
+-- @param callback function(env|nil)|nil: called asynchronously; without it the probe runs synchronously
+-- @return table|nil: the probed variables (synchronous probe only)
+function M.probe_user_env(container_id, config, callback)
+  local args = config and M.build_probe_args(container_id, config)
+  if not args then
+    if callback then
+      callback(nil)
+    end
+    return nil
+  end
+  local docker = require('container.docker')
+  local function handle(result)
+    local env = result.success and M.parse_probe_output(result.stdout, M.load_container_env(container_id)) or nil
+    if env then
+      config.user_env = env
+      log.info('Probed %s environment: %d variables', config.user_env_probe, vim.tbl_count(env))
+    else
+      log.warn('userEnvProbe %s failed: %s', config.user_env_probe, vim.trim(result.stderr or ''))
+    end
+    return env
+  end
+  if callback then
+    docker.run_docker_command_async(args, {}, function(result)
+      vim.schedule(function()
+        callback(handle(result))
+      end)
+    end)
+    return nil
+  end
+  return handle(docker.run_docker_command(args))
+end
+
 -- Build environment variable arguments for docker exec
 function M.build_env_args(config, context_type)
   if not config then
@@ -150,6 +378,11 @@ function M.build_env_args(config, context_type)
     -- Don't specify -u flag, let Docker use the container's default user
   end
 
+  -- Variables of env files come from the generated file so their values stay out of the arguments
+  vim.list_extend(args, require('container.env_file').args(config))
+  -- Secrets are passed by name; docker reads their values from the environment of its job (secrets.job_env)
+  vim.list_extend(args, require('container.secrets').exec_args())
+
   -- Add environment variables with expansion
   for key, value in pairs(env) do
     table.insert(args, '-e')
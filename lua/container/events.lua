@@ -0,0 +1,97 @@
+-- lua/container/events.lua
+-- Event dispatch for Lua subscribers and User autocmds
+-- require('container').on('started', callback) subscribes to an event by its short name ('*' receives every event);
+-- the returned function unsubscribes. Each event is passed to the subscribers and then fired as the User autocmd
+-- of the same name, with the same data. A failing subscriber is reported and does not stop the others.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Short names of the events and their User autocmd patterns
+M.EVENTS = {
+  opened = 'ContainerOpened',
+  build_started = 'ContainerBuildStarted',
+  build_progress = 'ContainerBuildProgress',
+  build_failed = 'ContainerBuildFailed',
+  built = 'ContainerBuilt',
+  started = 'ContainerStarted',
+  restarted = 'ContainerRestarted',
+  attached = 'ContainerAttached',
+  detached = 'ContainerDetached',
+  reconnected = 'ContainerReconnected',
+  stopped = 'ContainerStopped',
+  closed = 'ContainerClosed',
+  state_changed = 'ContainerStateChanged',
+}
+
+-- Short name by autocmd pattern
+local names = {}
+for name, pattern in pairs(M.EVENTS) do
+  names[pattern] = name
+end
+
+-- Subscribers by short name (or '*'), in subscription order
+local subscribers = {}
+
+-- Subscribe to an event
+-- @param event string: short name from M.EVENTS, or '*' for every event
+-- @param callback function(data, event): data as in the User autocmd, event the short name
+-- @return function: unsubscribes (calling it again does nothing)
+function M.on(event, callback)
+  if event ~= '*' and not M.EVENTS[event] then
+    error(string.format("Unknown container event '%s'", tostring(event)), 2)
+  end
+  if type(callback) ~= 'function' then
+    error('container event callback must be a function', 2)
+  end
+  local subscriber = { callback = callback }
+  subscribers[event] = subscribers[event] or {}
+  table.insert(subscribers[event], subscriber)
+  return function()
+    for i, existing in ipairs(subscribers[event] or {}) do
+      if existing == subscriber then
+        table.remove(subscribers[event], i)
+        return
+      end
+    end
+  end
+end
+
+-- Call the subscribers of an event; errors are logged and notified once per subscriber
+local function dispatch(list, data, name)
+  if not list or #list == 0 then
+    return
+  end
+  -- A copy, as callbacks may unsubscribe
+  for _, subscriber in ipairs(vim.list_extend({}, list)) do
+    local ok, err = pcall(subscriber.callback, data, name)
+    if not ok then
+      log.error('Handler of container event %s failed: %s', name, tostring(err))
+      if not subscriber.reported then
+        subscriber.reported = true
+        require('container.utils.notify').warn(string.format('Handler of container event %s failed: %s', name, err))
+      end
+    end
+  end
+end
+
+-- Dispatch an event to the subscribers and fire its User autocmd
+-- @param pattern string: autocmd pattern, e.g. 'ContainerStarted'
+-- @param data table|nil
+function M.emit(pattern, data)
+  data = data or {}
+  local name = names[pattern]
+  if name then
+    dispatch(subscribers[name], data, name)
+    dispatch(subscribers['*'], data, name)
+  end
+  vim.api.nvim_exec_autocmds('User', { pattern = pattern, data = data })
+end
+
+-- Remove every subscriber
+function M.clear()
+  subscribers = {}
+end
+
+return M
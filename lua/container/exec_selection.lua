@@ -0,0 +1,118 @@
+-- lua/container/exec_selection.lua
+-- Run selected buffer lines as one shell script in the container (:ContainerExecSelection)
+-- The lines are passed to `sh -c` together, so `cd` and variables carry over from line to line.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for results
+M.OUTPUT_NAME = 'exec'
+
+-- Job streaming into the buffer
+local job_id = nil
+
+-- Lines of a buffer range, by default the last visual selection
+-- @param opts table|nil: { bufnr, line1, line2 }
+-- @return table: lines
+function M.get_lines(opts)
+  opts = opts or {}
+  local bufnr = opts.bufnr or 0
+  local line1 = opts.line1 or vim.fn.line("'<")
+  local line2 = opts.line2 or vim.fn.line("'>")
+  if line1 > line2 then
+    line1, line2 = line2, line1
+  end
+  return vim.api.nvim_buf_get_lines(bufnr, line1 - 1, line2, false)
+end
+
+-- Join lines into a script, nil when there is nothing to run
+-- @param lines table
+-- @return string|nil
+function M.to_script(lines)
+  local script = table.concat(lines or {}, '\n')
+  if vim.trim(script) == '' then
+    return nil
+  end
+  return script
+end
+
+-- Stop the running script
+function M.stop()
+  if job_id then
+    pcall(vim.fn.jobstop, job_id)
+    job_id = nil
+  end
+end
+
+-- Run lines in the container and stream the output into the output buffer
+-- The script runs in workspaceFolder as remoteUser with containerEnv/remoteEnv, like exec().
+-- A run replaces the previous one.
+-- @param lines table: script lines
+-- @return boolean: true when the script was started
+function M.run(lines)
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local script = M.to_script(lines)
+  if not script then
+    notify.warn('Nothing to run: the selection is empty')
+    return false
+  end
+
+  M.stop()
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, container._build_exec_args(container_id, script, {}))
+  log.info('Running selection in container (%d line(s))', #lines)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  local header = {}
+  for _, line in ipairs(lines) do
+    table.insert(header, '$ ' .. line)
+  end
+  table.insert(header, '')
+  output.append(M.OUTPUT_NAME, header)
+  output.open(M.OUTPUT_NAME)
+
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      output.append(M.OUTPUT_NAME, lines)
+    end)
+  end)
+
+  local id
+  id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        -- A replaced run is not reported
+        if job_id ~= id then
+          return
+        end
+        job_id = nil
+        local rest = remaining()
+        if #rest > 0 then
+          output.append(M.OUTPUT_NAME, rest)
+        end
+        output.append(M.OUTPUT_NAME, { '', string.format('<== exited with code %d', exit_code) })
+      end)
+    end,
+  })
+
+  if id <= 0 then
+    notify.error('Failed to start docker exec')
+    return false
+  end
+  job_id = id
+  return true
+end
+
+return M
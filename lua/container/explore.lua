@@ -0,0 +1,226 @@
+-- lua/container/explore.lua
+-- Browse and edit files of the container (:ContainerExplore)
+-- Directories open as buffers listing their entries (`ls -Ap` in the container) and files are read with
+-- `docker exec cat` and written back through `docker exec -i`, so workspaces kept in a named volume (or on a remote
+-- daemon) can be edited although they are not mirrored to the host. Buffers are named container:///<path>; a
+-- trailing slash marks a directory. LSP locations in a volume workspace come back as such names
+-- (see lsp.interceptor), so jumps open them here as well.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Prefix of the buffer names (followed by the absolute container path)
+M.PREFIX = 'container://'
+
+-- Filetype of directory buffers
+M.FILETYPE = 'container_explore'
+
+-- Buffer name of a container path
+-- @param path string: absolute container path (directories end with /)
+-- @return string
+function M.buffer_name(path)
+  return M.PREFIX .. path
+end
+
+-- Container path of a buffer name
+-- @param name string
+-- @return string|nil: nil when the buffer does not belong to the explorer
+function M.path_of(name)
+  if not vim.startswith(name, M.PREFIX .. '/') then
+    return nil
+  end
+  return name:sub(#M.PREFIX + 1)
+end
+
+-- Path of an entry in a directory
+-- @param dir string
+-- @param name string: entry as listed (directories end with /)
+-- @return string
+function M.join(dir, name)
+  return dir:gsub('/+$', '') .. '/' .. name
+end
+
+-- Parent directory of a path, ending with /
+-- @param path string
+-- @return string
+function M.parent(path)
+  local parent = path:gsub('/+$', ''):match('^(.*)/[^/]*$')
+  return (parent or '') .. '/'
+end
+
+-- Entries of a directory listing
+-- Directories (marked with / by ls -p) come first, each group sorted by name.
+-- @param stdout string: output of `ls -Ap`
+-- @return table: list of names, directories ending with /
+function M.parse_listing(stdout)
+  local entries = {}
+  for line in (stdout or ''):gmatch('[^\n]+') do
+    if line ~= './' and line ~= '../' then
+      table.insert(entries, line)
+    end
+  end
+  table.sort(entries, function(a, b)
+    local a_dir, b_dir = vim.endswith(a, '/'), vim.endswith(b, '/')
+    if a_dir ~= b_dir then
+      return a_dir
+    end
+    return a < b
+  end)
+  return entries
+end
+
+-- docker exec arguments running a command as the remoteUser
+-- @param container_id string
+-- @param config table|nil: normalized configuration (remote_user)
+-- @param command table: command and its arguments
+-- @param interactive boolean|nil: keep stdin open (-i)
+-- @return table
+function M.exec_args(container_id, config, command, interactive)
+  local args = { 'exec' }
+  if interactive then
+    table.insert(args, '-i')
+  end
+  if config and config.remote_user then
+    vim.list_extend(args, { '-u', config.remote_user })
+  end
+  table.insert(args, container_id)
+  return vim.list_extend(args, command)
+end
+
+-- Run docker synchronously, without a shell so file contents pass through unchanged
+local function run(args, input)
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, args)
+  local output = vim.fn.system(cmd, input)
+  return vim.v.shell_error == 0, output
+end
+
+-- Running container and its configuration
+local function target()
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    return nil, nil, 'No active container'
+  end
+  return container_id, container.get_state().current_config
+end
+
+-- Fill a directory buffer
+local function read_dir(buf, path)
+  local container_id, config, err = target()
+  local ok, output = false, err
+  if container_id then
+    ok, output = run(M.exec_args(container_id, config, { 'ls', '-Ap', path }))
+  end
+  if not ok then
+    notify.error('Cannot list ' .. path .. ': ' .. vim.trim(output or ''))
+    return
+  end
+  vim.bo[buf].modifiable = true
+  vim.api.nvim_buf_set_lines(buf, 0, -1, false, M.parse_listing(output))
+  vim.bo[buf].buftype = 'nofile'
+  vim.bo[buf].bufhidden = 'hide'
+  vim.bo[buf].modifiable = false
+  vim.bo[buf].filetype = M.FILETYPE
+
+  local function open_entry()
+    local name = vim.api.nvim_get_current_line()
+    if name ~= '' then
+      vim.cmd.edit(vim.fn.fnameescape(M.buffer_name(M.join(path, name))))
+    end
+  end
+  local opts = { buffer = buf, silent = true, nowait = true }
+  vim.keymap.set('n', '<CR>', open_entry, vim.tbl_extend('force', opts, { desc = 'Open entry' }))
+  vim.keymap.set('n', '-', function()
+    vim.cmd.edit(vim.fn.fnameescape(M.buffer_name(M.parent(path))))
+  end, vim.tbl_extend('force', opts, { desc = 'Open parent directory' }))
+  vim.keymap.set('n', 'R', function()
+    read_dir(buf, path)
+  end, vim.tbl_extend('force', opts, { desc = 'Refresh' }))
+end
+
+-- Fill a file buffer with the contents of the container file
+local function read_file(buf, path)
+  local container_id, config, err = target()
+  local ok, output = false, err
+  if container_id then
+    ok, output = run(M.exec_args(container_id, config, { 'cat', path }))
+  end
+  if not ok then
+    notify.error('Cannot read ' .. path .. ': ' .. vim.trim(output or ''))
+    return
+  end
+  local lines = vim.split(output, '\n', { plain = true })
+  local eol = lines[#lines] == ''
+  if eol then
+    table.remove(lines)
+  end
+  vim.api.nvim_buf_set_lines(buf, 0, -1, false, lines)
+  vim.bo[buf].buftype = 'acwrite'
+  vim.bo[buf].eol = eol
+  vim.bo[buf].modified = false
+  local filetype = vim.filetype.match({ filename = path, buf = buf })
+  if filetype then
+    vim.bo[buf].filetype = filetype
+  end
+end
+
+-- Write a file buffer back to the container
+-- @param buf number
+-- @param path string: container path
+-- @return boolean
+function M.write(buf, path)
+  local container_id, config, err = target()
+  if not container_id then
+    notify.error('Cannot write ' .. path .. ': ' .. err)
+    return false
+  end
+  local lines = vim.api.nvim_buf_get_lines(buf, 0, -1, false)
+  local content = table.concat(lines, '\n') .. (vim.bo[buf].eol and '\n' or '')
+  local command = { 'sh', '-c', 'cat > "$1"', 'sh', path }
+  local ok, output = run(M.exec_args(container_id, config, command, true), content)
+  if not ok then
+    log.error('Writing %s failed: %s', path, output)
+    notify.error('Cannot write ' .. path .. ': ' .. vim.trim(output or ''))
+    return false
+  end
+  vim.bo[buf].modified = false
+  notify.info(string.format('"%s" %dL written to the container', path, #lines))
+  return true
+end
+
+-- Fill a container:/// buffer (BufReadCmd, see plugin/container.lua)
+-- @param buf number
+-- @param path string: container path, directories end with /
+function M.read(buf, path)
+  if vim.endswith(path, '/') then
+    read_dir(buf, path)
+  else
+    read_file(buf, path)
+  end
+end
+
+-- Open a directory or file of the container in the current window (:ContainerExplore)
+-- Relative paths resolve against the workspace folder, which is also the default.
+-- @param path string|nil
+function M.open(path)
+  local container_id, config, err = target()
+  if not container_id then
+    notify.error(err)
+    return
+  end
+  local _, container_root = require('container.parser').workspace_roots(config)
+  if not path or path == '' then
+    path = container_root
+  elseif not vim.startswith(path, '/') then
+    path = M.join(container_root, path)
+  end
+  if not vim.endswith(path, '/') and run(M.exec_args(container_id, config, { 'test', '-d', path })) then
+    path = path .. '/'
+  end
+  vim.cmd.edit(vim.fn.fnameescape(M.buffer_name(path)))
+end
+
+return M
@@ -0,0 +1,488 @@
+-- lua/container/features.lua
+-- Dev Container Features support (resolution, ordering and Dockerfile generation)
+
+local M = {}
+
+local fs = require('container.utils.fs')
+local log = require('container.utils.log')
+
+-- Directory inside the image where feature sources are copied during build
+M.CONTAINER_FEATURES_DIR = '/tmp/container-nvim-features'
+
+-- Tag used when a feature reference does not specify a version
+local DEFAULT_TAG = 'latest'
+
+-- Get root directory used to cache downloaded features and build contexts
+function M.get_cache_dir()
+  return fs.join_path(vim.fn.stdpath('cache'), 'container.nvim', 'features')
+end
+
+-- Check whether a feature reference points to a local folder
+local function is_local_reference(ref)
+  return ref:match('^%./') ~= nil or ref:match('^%.%./') ~= nil
+end
+
+-- Check whether a feature reference points to a tarball URL
+local function is_tarball_reference(ref)
+  return ref:match('^https?://') ~= nil
+end
+
+-- Parse an OCI feature reference such as ghcr.io/devcontainers/features/go:1
+function M.parse_oci_reference(ref)
+  local resource, digest = ref:match('^([^@]+)@(sha256:%x+)$')
+  local tag = nil
+  if not resource then
+    resource = ref
+    -- The tag separator is the last ':' that appears after the last '/'
+    local last_slash = resource:match('.*()/') or 0
+    local colon = resource:find(':[^:/]*$')
+    if colon and colon > last_slash then
+      tag = resource:sub(colon + 1)
+      resource = resource:sub(1, colon - 1)
+    end
+  end
+
+  if not tag and not digest then
+    tag = DEFAULT_TAG
+  end
+
+  local registry, repository = resource:match('^([^/]+)/(.+)$')
+  if not registry then
+    return nil, 'Invalid feature reference: ' .. ref
+  end
+
+  return {
+    registry = registry,
+    repository = repository,
+    resource = resource,
+    tag = tag,
+    digest = digest,
+    id = repository:match('([^/]+)$'),
+  }
+end
+
+-- Convert a feature option name into the environment variable name used by install.sh
+function M.option_env_name(name)
+  local env_name = tostring(name):gsub('[^%w_]', '_'):gsub('^[%d_]+', '_')
+  return env_name:upper()
+end
+
+-- Normalize the devcontainer.json "features" object into a list sorted by reference
+-- Decoded JSON objects do not keep the declaration order, so the references are sorted to keep the list (and the
+-- image cache key) stable; installation order comes from installsAfter (see sort_by_installs_after).
+function M.normalize(features, devcontainer_folder)
+  local normalized = {}
+  if type(features) ~= 'table' then
+    return normalized
+  end
+
+  local refs = {}
+  for ref, _ in pairs(features) do
+    table.insert(refs, ref)
+  end
+  table.sort(refs)
+
+  for _, ref in ipairs(refs) do
+    local value = features[ref]
+    local options = {}
+
+    -- "feature": "1.2" is shorthand for { "version": "1.2" }
+    if type(value) == 'string' then
+      options.version = value
+    elseif type(value) == 'table' then
+      options = vim.deepcopy(value)
+    elseif value == false then
+      options = nil
+    end
+
+    if options then
+      local feature = {
+        ref = ref,
+        options = options,
+      }
+
+      if is_local_reference(ref) then
+        feature.kind = 'local'
+        feature.path = fs.resolve_path(ref, devcontainer_folder or vim.fn.getcwd())
+        feature.id = fs.basename(feature.path)
+        feature.resource = feature.path
+      elseif is_tarball_reference(ref) then
+        feature.kind = 'tarball'
+        feature.id = ref:match('devcontainer%-feature%-([%w_%-]+)%.tgz$') or ref:match('([^/]+)$')
+        feature.resource = ref
+      else
+        local oci, err = M.parse_oci_reference(ref)
+        if not oci then
+          log.warn('Skipping feature: %s', err)
+        else
+          feature.kind = 'oci'
+          feature.id = oci.id
+          feature.resource = oci.resource
+          feature.oci = oci
+        end
+      end
+
+      if feature.kind then
+        table.insert(normalized, feature)
+      end
+    end
+  end
+
+  return normalized
+end
+
+-- Strip tag/digest from a reference so installsAfter entries can be matched
+local function strip_version(ref)
+  if is_local_reference(ref) or is_tarball_reference(ref) then
+    return ref
+  end
+  local oci = M.parse_oci_reference(ref)
+  return oci and oci.resource or ref
+end
+
+-- Order features honoring installsAfter (stable topological sort)
+function M.sort_by_installs_after(features)
+  local count = #features
+  local remaining = {}
+  for i = 1, count do
+    remaining[i] = true
+  end
+
+  -- Returns true if feature a must be installed after feature b
+  local function depends_on(a, b)
+    for _, after in ipairs(a.installs_after or {}) do
+      local stripped = strip_version(after)
+      if stripped == b.resource or stripped == b.id or after == b.ref then
+        return true
+      end
+    end
+    return false
+  end
+
+  local ordered = {}
+  while #ordered < count do
+    local picked = nil
+    for i = 1, count do
+      if remaining[i] then
+        local ready = true
+        for j = 1, count do
+          if j ~= i and remaining[j] and depends_on(features[i], features[j]) then
+            ready = false
+            break
+          end
+        end
+        if ready then
+          picked = i
+          break
+        end
+      end
+    end
+
+    if not picked then
+      -- Circular installsAfter: fall back to declaration order for the rest
+      log.warn('Circular installsAfter detected between features, using declaration order')
+      for i = 1, count do
+        if remaining[i] then
+          table.insert(ordered, features[i])
+          remaining[i] = nil
+        end
+      end
+    else
+      table.insert(ordered, features[picked])
+      remaining[picked] = nil
+    end
+  end
+
+  return ordered
+end
+
+-- Build the environment variables passed to a feature's install.sh
+function M.build_option_env(feature, context)
+  context = context or {}
+  local env = {}
+
+  -- Defaults declared by devcontainer-feature.json
+  local metadata_options = feature.metadata and feature.metadata.options or {}
+  for name, option in pairs(metadata_options) do
+    if type(option) == 'table' and option.default ~= nil then
+      env[M.option_env_name(name)] = tostring(option.default)
+    end
+  end
+
+  -- User supplied options take precedence
+  for name, value in pairs(feature.options or {}) do
+    env[M.option_env_name(name)] = tostring(value)
+  end
+
+  env._REMOTE_USER = context.remote_user or 'root'
+  env._CONTAINER_USER = context.container_user or context.remote_user or 'root'
+  env._REMOTE_USER_HOME = env._REMOTE_USER == 'root' and '/root' or ('/home/' .. env._REMOTE_USER)
+  env._CONTAINER_USER_HOME = env._CONTAINER_USER == 'root' and '/root' or ('/home/' .. env._CONTAINER_USER)
+
+  return env
+end
+
+-- Render env variables as a shell-sourceable file (sorted for stable hashes)
+function M.render_env_file(env)
+  local keys = {}
+  for key, _ in pairs(env) do
+    table.insert(keys, key)
+  end
+  table.sort(keys)
+
+  local lines = {}
+  for _, key in ipairs(keys) do
+    local value = env[key]:gsub('[\\"$`]', '\\%0')
+    table.insert(lines, string.format('%s="%s"', key, value))
+  end
+  return table.concat(lines, '\n') .. '\n'
+end
+
+-- Generate a Dockerfile that layers the features on top of the base image
+function M.generate_dockerfile(base_image, features, opts)
+  opts = opts or {}
+  local lines = {
+    '# Generated by container.nvim - do not edit',
+    'FROM ' .. base_image,
+    'USER root',
+  }
+
+  for index, feature in ipairs(features) do
+    local dir_name = string.format('%d-%s', index, feature.id)
+    local target = M.CONTAINER_FEATURES_DIR .. '/' .. dir_name
+
+    table.insert(lines, '')
+    table.insert(lines, string.format('# Feature: %s', feature.ref))
+    table.insert(lines, string.format('COPY %s %s', dir_name, target))
+
+    -- Feature-provided containerEnv is baked into the image
+    local container_env = feature.metadata and feature.metadata.containerEnv or {}
+    local env_keys = vim.tbl_keys(container_env)
+    table.sort(env_keys)
+    for _, key in ipairs(env_keys) do
+      table.insert(lines, string.format('ENV %s="%s"', key, tostring(container_env[key]):gsub('"', '\\"')))
+    end
+
+    table.insert(
+      lines,
+      string.format(
+        'RUN cd %s && chmod +x ./install.sh && set -a && . ./devcontainer-features.env && set +a && ./install.sh',
+        target
+      )
+    )
+  end
+
+  table.insert(lines, '')
+  table.insert(lines, 'RUN rm -rf ' .. M.CONTAINER_FEATURES_DIR)
+
+  -- Restore the original image user so feature installation does not change it
+  if opts.image_user and opts.image_user ~= '' then
+    table.insert(lines, 'USER ' .. opts.image_user)
+  end
+
+  return table.concat(lines, '\n') .. '\n'
+end
+
+-- Compute a cache key for the features image
+-- Local feature sources are hashed as well so edits trigger a rebuild
+function M.compute_cache_key(base_image, features)
+  local parts = { base_image }
+
+  for _, feature in ipairs(features) do
+    local option_keys = vim.tbl_keys(feature.options or {})
+    table.sort(option_keys)
+    local option_parts = {}
+    for _, key in ipairs(option_keys) do
+      table.insert(option_parts, key .. '=' .. tostring(feature.options[key]))
+    end
+
+    table.insert(parts, feature.ref .. '|' .. table.concat(option_parts, ','))
+    -- A feature pinned by the lockfile is rebuilt when the lockfile moves it to another digest
+    if feature.locked then
+      table.insert(parts, feature.oci.digest)
+    end
+
+    if feature.kind == 'local' then
+      for _, file in ipairs({ 'devcontainer-feature.json', 'install.sh' }) do
+        local content = fs.read_file(fs.join_path(feature.path, file))
+        if content then
+          table.insert(parts, vim.fn.sha256(content))
+        end
+      end
+    end
+  end
+
+  return vim.fn.sha256(table.concat(parts, '\n'))
+end
+
+-- Generate the tag of the image containing the features
+function M.image_tag(config, base_image, features)
+  local clean_name = (config.name or 'devcontainer'):lower():gsub('[^a-z0-9_.-]', '-')
+  local key = M.compute_cache_key(base_image, features):sub(1, 12)
+  return string.format('container-nvim-%s-features:%s', clean_name, key)
+end
+
+-- Run a shell command and return output and success
+local function system(cmd)
+  local output = vim.fn.system(cmd)
+  return vim.v.shell_error == 0, output
+end
+
+-- Download and extract an OCI feature artifact into dest
+local function fetch_oci_feature(feature, dest)
+  local oci = feature.oci
+  local base_url = string.format('https://%s', oci.registry)
+
+  -- Anonymous pull token (ghcr.io and most OCI registries support this flow)
+  local ok, token_json = system({
+    'curl',
+    '-sSL',
+    string.format('%s/token?scope=repository:%s:pull&service=%s', base_url, oci.repository, oci.registry),
+  })
+  local token = nil
+  if ok then
+    local decoded_ok, decoded = pcall(vim.json.decode, token_json)
+    token = decoded_ok and type(decoded) == 'table' and decoded.token or nil
+  end
+
+  local auth_args = {}
+  if token then
+    auth_args = { '-H', 'Authorization: Bearer ' .. token }
+  end
+
+  -- Resolve the requested version (tag or digest) to a manifest
+  local manifest_cmd = { 'curl', '-sSL', '-H', 'Accept: application/vnd.oci.image.manifest.v1+json' }
+  vim.list_extend(manifest_cmd, auth_args)
+  table.insert(
+    manifest_cmd,
+    string.format('%s/v2/%s/manifests/%s', base_url, oci.repository, oci.digest or oci.tag)
+  )
+  local manifest_ok, manifest_body = system(manifest_cmd)
+  if not manifest_ok then
+    return false, 'Failed to fetch manifest for ' .. feature.ref
+  end
+
+  local decoded_ok, manifest = pcall(vim.json.decode, manifest_body)
+  if not decoded_ok or type(manifest) ~= 'table' or not manifest.layers or not manifest.layers[1] then
+    return false, 'Invalid manifest for ' .. feature.ref
+  end
+
+  feature.resolved = oci.digest or ('sha256:' .. vim.fn.sha256(manifest_body))
+  feature.integrity = manifest.layers[1].digest
+  -- A feature pinned by devcontainer-lock.json must still have the layer recorded there
+  if feature.locked and feature.locked.integrity and feature.locked.integrity ~= feature.integrity then
+    return false,
+      string.format(
+        'Feature %s does not match the integrity in the lockfile (%s, got %s)',
+        feature.ref,
+        feature.locked.integrity,
+        feature.integrity
+      )
+  end
+
+  local archive = fs.join_path(dest, 'feature.tgz')
+  local blob_cmd = { 'curl', '-sSL', '-o', archive }
+  vim.list_extend(blob_cmd, auth_args)
+  table.insert(blob_cmd, string.format('%s/v2/%s/blobs/%s', base_url, oci.repository, manifest.layers[1].digest))
+  local blob_ok, blob_err = system(blob_cmd)
+  if not blob_ok then
+    return false, 'Failed to download feature ' .. feature.ref .. ': ' .. blob_err
+  end
+
+  local tar_ok, tar_err = system({ 'tar', '-xf', archive, '-C', dest })
+  vim.fn.delete(archive)
+  if not tar_ok then
+    return false, 'Failed to extract feature ' .. feature.ref .. ': ' .. tar_err
+  end
+
+  return true
+end
+
+-- Download and extract a tarball feature into dest
+local function fetch_tarball_feature(feature, dest)
+  local archive = fs.join_path(dest, 'feature.tgz')
+  local ok, err = system({ 'curl', '-sSL', '-o', archive, feature.ref })
+  if not ok then
+    return false, 'Failed to download feature ' .. feature.ref .. ': ' .. err
+  end
+
+  local tar_ok, tar_err = system({ 'tar', '-xzf', archive, '-C', dest })
+  vim.fn.delete(archive)
+  if not tar_ok then
+    return false, 'Failed to extract feature ' .. feature.ref .. ': ' .. tar_err
+  end
+
+  feature.resolved = 'sha256:' .. vim.fn.sha256(feature.ref)
+  return true
+end
+
+-- Copy feature sources into dest and load devcontainer-feature.json metadata
+function M.fetch(feature, dest)
+  vim.fn.mkdir(dest, 'p')
+
+  local ok, err
+  if feature.kind == 'local' then
+    if not fs.is_file(fs.join_path(feature.path, 'install.sh')) then
+      return false, 'Local feature has no install.sh: ' .. feature.path
+    end
+    ok, err = system({ 'cp', '-R', feature.path .. '/.', dest })
+  elseif feature.kind == 'tarball' then
+    ok, err = fetch_tarball_feature(feature, dest)
+  else
+    ok, err = fetch_oci_feature(feature, dest)
+  end
+
+  if not ok then
+    return false, err
+  end
+
+  local metadata_content = fs.read_file(fs.join_path(dest, 'devcontainer-feature.json'))
+  if metadata_content then
+    local decoded_ok, metadata = pcall(vim.json.decode, metadata_content)
+    if decoded_ok and type(metadata) == 'table' then
+      feature.metadata = metadata
+      feature.installs_after = metadata.installsAfter or {}
+      feature.version = metadata.version
+      feature.id = metadata.id or feature.id
+    end
+  end
+
+  log.debug('Fetched feature %s (version: %s)', feature.ref, tostring(feature.version))
+  return true
+end
+
+-- Prepare a docker build context with all features and a generated Dockerfile
+-- Returns the context directory and the ordered feature list
+function M.prepare_build_context(base_image, features, opts)
+  opts = opts or {}
+  local context_dir = fs.join_path(M.get_cache_dir(), M.compute_cache_key(base_image, features):sub(1, 12))
+  vim.fn.delete(context_dir, 'rf')
+  vim.fn.mkdir(context_dir, 'p')
+
+  local staging = {}
+  for _, feature in ipairs(features) do
+    local staging_dir = fs.join_path(context_dir, 'staging', feature.id .. '-' .. vim.fn.sha256(feature.ref):sub(1, 8))
+    local ok, err = M.fetch(feature, staging_dir)
+    if not ok then
+      return nil, err
+    end
+    feature.staging_dir = staging_dir
+    table.insert(staging, feature)
+  end
+
+  local ordered = M.sort_by_installs_after(staging)
+  for index, feature in ipairs(ordered) do
+    local dir_name = string.format('%d-%s', index, feature.id)
+    local target_dir = fs.join_path(context_dir, dir_name)
+    vim.fn.rename(feature.staging_dir, target_dir)
+    local env_file = fs.join_path(target_dir, 'devcontainer-features.env')
+    fs.write_file(env_file, M.render_env_file(M.build_option_env(feature, opts)))
+  end
+  vim.fn.delete(fs.join_path(context_dir, 'staging'), 'rf')
+
+  fs.write_file(fs.join_path(context_dir, 'Dockerfile'), M.generate_dockerfile(base_image, ordered, opts))
+
+  return context_dir, ordered
+end
+
+return M
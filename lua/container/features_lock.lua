@@ -0,0 +1,165 @@
+-- lua/container/features_lock.lua
+-- devcontainer-lock.json: the feature versions a configuration was built with
+-- The lockfile sits next to devcontainer.json (.devcontainer-lock.json next to a .devcontainer.json) in the format
+-- of the Dev Containers CLI: each OCI feature reference maps to the version it installed, the digest it resolved to
+-- ("resolved") and the digest of its layer ("integrity"). Locked features are fetched by that digest, so everyone
+-- building the configuration installs the same feature code. Local and tarball features are not locked.
+
+local M = {}
+
+local fs = require('container.utils.fs')
+local log = require('container.utils.log')
+
+-- Lockfile path for a devcontainer.json
+-- @param config_file string: path of devcontainer.json
+-- @return string
+function M.path(config_file)
+  local name = fs.basename(config_file):match('^%.') and '.devcontainer-lock.json' or 'devcontainer-lock.json'
+  return fs.join_path(fs.dirname(config_file), name)
+end
+
+-- Read a lockfile
+-- @param path string
+-- @return table|nil: { features = { [ref] = { version, resolved, integrity } } }, nil when the file does not exist
+-- @return string|nil: error message when the file is not a valid lockfile
+function M.read(path)
+  local content = fs.read_file(path)
+  if not content then
+    return nil
+  end
+  local ok, lock = pcall(vim.json.decode, content)
+  if not ok or type(lock) ~= 'table' then
+    return nil, 'Invalid lockfile: ' .. path
+  end
+  if type(lock.features) ~= 'table' then
+    lock.features = {}
+  end
+  return lock
+end
+
+-- Pin the OCI features of a list to the digests of a lockfile
+-- A locked feature is fetched by its digest and its layer checked against the integrity (see features.fetch).
+-- @param feature_list table: normalized features
+-- @param lock table|nil
+-- @return table: the features that were pinned
+function M.apply(feature_list, lock)
+  local pinned = {}
+  for _, feature in ipairs(feature_list) do
+    local entry = lock and feature.kind == 'oci' and lock.features[feature.ref]
+    local resolved = type(entry) == 'table' and entry.resolved
+    local digest = type(resolved) == 'string' and resolved:match('@(sha256:%x+)$')
+    if digest and feature.oci.digest and feature.oci.digest ~= digest then
+      log.warn('Feature %s is pinned in devcontainer.json, ignoring the lockfile digest', feature.ref)
+    elseif digest then
+      feature.locked = entry
+      feature.oci.digest = digest
+      table.insert(pinned, feature)
+    end
+  end
+  return pinned
+end
+
+-- Differences between the features of devcontainer.json and a lockfile
+-- @param feature_list table: normalized features
+-- @param lock table
+-- @return table: { added = refs without lock entry, removed = lock entries of features no longer used }
+function M.diff(feature_list, lock)
+  local added, removed, used = {}, {}, {}
+  for _, feature in ipairs(feature_list) do
+    if feature.kind == 'oci' then
+      used[feature.ref] = true
+      if not lock.features[feature.ref] then
+        table.insert(added, feature.ref)
+      end
+    end
+  end
+  for ref in pairs(lock.features) do
+    if not used[ref] then
+      table.insert(removed, ref)
+    end
+  end
+  table.sort(removed)
+  return { added = added, removed = removed }
+end
+
+-- Check whether a diff has any difference
+function M.is_stale(diff)
+  return #diff.added > 0 or #diff.removed > 0
+end
+
+-- Describe a diff for a notification
+function M.describe(diff)
+  local parts = {}
+  if #diff.added > 0 then
+    table.insert(parts, 'not locked: ' .. table.concat(diff.added, ', '))
+  end
+  if #diff.removed > 0 then
+    table.insert(parts, 'no longer used: ' .. table.concat(diff.removed, ', '))
+  end
+  return table.concat(parts, '; ')
+end
+
+-- Lockfile handling of a features build (features_lock setting)
+-- The features are pinned to the lockfile; whether it is written once they are resolved depends on the setting:
+-- 'write' always writes it, 'read' offers to when it is out of date with devcontainer.json.
+-- @param config table: normalized configuration (config_file)
+-- @param feature_list table: normalized features, pinned in place
+-- @return table|nil: { path, write }, nil when lockfiles are off
+function M.prepare(config, feature_list)
+  local mode = require('container.config').get_value('features_lock') or 'read'
+  if mode == 'off' or not config.config_file then
+    return nil
+  end
+  local notify = require('container.utils.notify')
+  local plan = { path = M.path(config.config_file), write = mode == 'write' }
+  local lock, err = M.read(plan.path)
+  if err then
+    notify.status(err .. ', features are not pinned', 'warn')
+  end
+  if not lock then
+    return plan
+  end
+
+  local pinned = M.apply(feature_list, lock)
+  log.info('%d feature(s) pinned by %s', #pinned, plan.path)
+  local diff = M.diff(feature_list, lock)
+  if M.is_stale(diff) and not plan.write then
+    local message =
+      string.format('%s is out of date with devcontainer.json (%s)', fs.basename(plan.path), M.describe(diff))
+    notify.status(message, 'warn')
+    plan.write = vim.fn.confirm(message .. '\nUpdate it once the features are resolved?', '&Yes\n&No', 2) == 1
+  end
+  return plan
+end
+
+-- Lockfile content of fetched features
+-- @param feature_list table: features after features.fetch (resolved, version, integrity)
+-- @return table
+function M.build(feature_list)
+  local locked = {}
+  for _, feature in ipairs(feature_list) do
+    if feature.kind == 'oci' and feature.resolved then
+      locked[feature.ref] = {
+        version = feature.version,
+        resolved = feature.resource .. '@' .. feature.resolved,
+        integrity = feature.integrity,
+      }
+    end
+  end
+  return { features = locked }
+end
+
+-- Write the lockfile of fetched features
+-- @param path string
+-- @param feature_list table
+-- @return boolean, string|nil
+function M.write(path, feature_list)
+  local content = require('container.config_view').encode(M.build(feature_list)) .. '\n'
+  if fs.read_file(path) == content then
+    return true
+  end
+  log.info('Writing feature lockfile %s', path)
+  return fs.write_file(path, content)
+end
+
+return M
@@ -0,0 +1,222 @@
+-- lua/container/format.lua
+-- Format buffers with formatters installed in the container (goimports, gofmt, ...)
+-- The buffer is piped through the formatter with docker exec and only the changed lines are replaced, so the
+-- cursor, marks and undo history are kept. With format.on_save this runs on BufWritePre.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Exit code of the format script when none of the formatters is installed
+M.NOT_FOUND_CODE = 127
+
+-- Container and filetype pairs already warned about a missing formatter
+local warned = {}
+
+-- Formatter commands configured for a filetype, in order of preference
+-- @param filetype string
+-- @return table|nil: list of shell commands
+function M.get_formatters(filetype)
+  local format_config = require('container.config').get_value('format') or {}
+  local formatters = (format_config.formatters or {})[filetype]
+  if type(formatters) == 'string' then
+    formatters = { formatters }
+  end
+  if type(formatters) ~= 'table' or #formatters == 0 then
+    return nil
+  end
+  return formatters
+end
+
+-- Shell script running the first formatter installed in the container on stdin
+-- @param commands table: shell commands, e.g. { 'goimports', 'gofmt -s' }
+-- @return string
+function M.build_script(commands)
+  local branches = {}
+  for i, command in ipairs(commands) do
+    local binary = command:match('^%s*(%S+)')
+    table.insert(
+      branches,
+      string.format('%s command -v %s >/dev/null 2>&1; then exec %s', i == 1 and 'if' or 'elif', binary, command)
+    )
+  end
+  return string.format('%s; else exit %d; fi', table.concat(branches, '; '), M.NOT_FOUND_CODE)
+end
+
+-- Replace the lines of a buffer that differ from the formatted lines
+-- Unchanged lines are left alone so marks and the cursor stay where they were.
+-- @param bufnr number
+-- @param lines table: formatted lines
+-- @return boolean: true when the buffer was changed
+function M.apply(bufnr, lines)
+  local current = vim.api.nvim_buf_get_lines(bufnr, 0, -1, false)
+  local old_text = table.concat(current, '\n') .. '\n'
+  local new_text = table.concat(lines, '\n') .. '\n'
+  if old_text == new_text then
+    return false
+  end
+
+  local diff = vim.text and vim.text.diff or vim.diff
+  if not diff then
+    local cursors = {}
+    for _, win in ipairs(vim.fn.win_findbuf(bufnr)) do
+      cursors[win] = vim.api.nvim_win_get_cursor(win)
+    end
+    vim.api.nvim_buf_set_lines(bufnr, 0, -1, false, lines)
+    for win, cursor in pairs(cursors) do
+      pcall(vim.api.nvim_win_set_cursor, win, { math.min(cursor[1], #lines), cursor[2] })
+    end
+    return true
+  end
+
+  -- Hunks are applied from the bottom so the line numbers of the earlier ones stay valid
+  local hunks = diff(old_text, new_text, { result_type = 'indices' })
+  for i = #hunks, 1, -1 do
+    local start_a, count_a, start_b, count_b = hunks[i][1], hunks[i][2], hunks[i][3], hunks[i][4]
+    local replacement = {}
+    for lnum = start_b, start_b + count_b - 1 do
+      table.insert(replacement, lines[lnum])
+    end
+    -- A pure insertion is reported after line start_a
+    local first = count_a == 0 and start_a or start_a - 1
+    vim.api.nvim_buf_set_lines(bufnr, first, first + count_a, false, replacement)
+  end
+  return true
+end
+
+-- Pipe text through a script in the container and wait for the result
+-- @return table|nil, string|nil: { code, stdout, stderr }, or nil and the error
+local function run(args, input, timeout)
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, args)
+
+  local stdout, stderr, code = {}, {}, nil
+  local job_id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    stdout_buffered = true,
+    stderr_buffered = true,
+    on_stdout = function(_, data)
+      stdout = data or {}
+    end,
+    on_stderr = function(_, data)
+      stderr = data or {}
+    end,
+    on_exit = function(_, exit_code)
+      code = exit_code
+    end,
+  })
+  if not job_id or job_id <= 0 then
+    return nil, 'Failed to start docker exec'
+  end
+  vim.fn.chansend(job_id, input)
+  vim.fn.chanclose(job_id, 'stdin')
+
+  if not vim.wait(timeout, function()
+    return code ~= nil
+  end, 10) then
+    pcall(vim.fn.jobstop, job_id)
+    return nil, string.format('timed out after %dms', timeout)
+  end
+  return { code = code, stdout = stdout, stderr = table.concat(stderr, '\n') }
+end
+
+-- Format a buffer with the formatter of its filetype in the container
+-- @param bufnr number|nil: defaults to the current buffer
+-- @param opts table|nil: { quiet = boolean } quiet skips silently when there is nothing to format with
+-- @return boolean: true when the buffer was formatted (changed or already formatted)
+function M.format(bufnr, opts)
+  opts = opts or {}
+  bufnr = (bufnr == nil or bufnr == 0) and vim.api.nvim_get_current_buf() or bufnr
+
+  local filetype = vim.bo[bufnr].filetype
+  local formatters = M.get_formatters(filetype)
+  if not formatters then
+    if not opts.quiet then
+      notify.warn(string.format('No container formatter configured for filetype "%s"', filetype))
+    end
+    return false
+  end
+  if not vim.bo[bufnr].modifiable then
+    return false
+  end
+
+  local container = require('container')
+  local status = container.status()
+  local container_id = status.container_id
+  if not container_id or status.state ~= 'running' then
+    if not opts.quiet then
+      notify.critical('No running container. Start container first with :ContainerStart')
+    end
+    return false
+  end
+
+  -- Run from the folder of the file so goimports resolves the module and its local packages
+  local parser = require('container.parser')
+  local host_root, container_root = parser.workspace_roots(container.get_config())
+  local file = vim.api.nvim_buf_get_name(bufnr)
+  local dir = nil
+  if file ~= '' then
+    dir = require('container.test').map_path(vim.fn.fnamemodify(file, ':h'), host_root, container_root)
+  end
+
+  local format_config = require('container.config').get_value('format') or {}
+  local lines = vim.api.nvim_buf_get_lines(bufnr, 0, -1, false)
+  local args = container._build_exec_args(container_id, M.build_script(formatters), { cwd = dir })
+  local result, err = run(args, table.concat(lines, '\n') .. '\n', format_config.timeout or 3000)
+  if not result then
+    notify.warn(string.format('Formatting %s in the container failed: %s', vim.fn.fnamemodify(file, ':t'), err))
+    return false
+  end
+
+  if result.code == M.NOT_FOUND_CODE then
+    local key = container_id .. ':' .. filetype
+    if not warned[key] then
+      warned[key] = true
+      local message = 'Not formatting %s files: none of %s is installed in the container'
+      notify.warn(string.format(message, filetype, table.concat(formatters, ', ')))
+    end
+    return false
+  end
+  if result.code ~= 0 then
+    local first_line = vim.split(result.stderr, '\n', { trimempty = true })[1] or ('exit code ' .. result.code)
+    notify.warn(string.format('Formatter failed on %s: %s', vim.fn.fnamemodify(file, ':t'), first_line))
+    log.debug('Formatter output: %s', result.stderr)
+    return false
+  end
+
+  -- The last element of the output is the empty text after the final newline
+  local formatted = result.stdout
+  if formatted[#formatted] == '' then
+    table.remove(formatted)
+  end
+  if #formatted == 0 and #lines > 1 then
+    log.warn('Formatter returned no output for %s, leaving the buffer unchanged', file)
+    return false
+  end
+  if M.apply(bufnr, formatted) then
+    log.debug('Formatted %s with %s', file, formatters[1])
+  end
+  return true
+end
+
+-- Register the BufWritePre autocmd when format.on_save is enabled
+-- @param format_config table: the format section of the plugin config
+function M.setup(format_config)
+  format_config = format_config or {}
+  local group = vim.api.nvim_create_augroup('ContainerFormat', { clear = true })
+  if not format_config.on_save then
+    return
+  end
+  vim.api.nvim_create_autocmd('BufWritePre', {
+    group = group,
+    callback = function(args)
+      if vim.bo[args.buf].buftype ~= '' or not M.get_formatters(vim.bo[args.buf].filetype) then
+        return
+      end
+      M.format(args.buf, { quiet = true })
+    end,
+  })
+end
+
+return M
@@ -0,0 +1,129 @@
+-- lua/container/forward_store.lua
+-- Saved dynamic port forwards of each workspace
+-- Forwards added with :ContainerForward are written to stdpath('state') so that they can be set up
+-- again for the next container of the workspace (after a rebuild, a restart of Neovim or a reattach).
+
+local M = {}
+
+local log = require('container.utils.log')
+local fs = require('container.utils.fs')
+
+-- TCP socket state of listening sockets in /proc/net/tcp
+local TCP_LISTEN = '0A'
+
+-- Directory holding one file per workspace
+function M.get_dir()
+  return fs.join_path(vim.fn.stdpath('state'), 'container.nvim', 'forwards')
+end
+
+-- Path of the file for a workspace
+-- @param workspace_root string
+function M.get_path(workspace_root)
+  return fs.join_path(M.get_dir(), vim.fn.sha256(workspace_root):sub(1, 16) .. '.json')
+end
+
+-- Saved forwards of a workspace
+-- @param workspace_root string|nil
+-- @return table: list of { container_port, host_port }
+function M.load(workspace_root)
+  if not workspace_root then
+    return {}
+  end
+  local path = M.get_path(workspace_root)
+  if not fs.is_file(path) then
+    return {}
+  end
+
+  local content = fs.read_file(path)
+  local ok, data = pcall(vim.json.decode, content or '')
+  if not ok or type(data) ~= 'table' or type(data.forwards) ~= 'table' then
+    log.warn('Ignoring unreadable port forward file %s', path)
+    return {}
+  end
+
+  local forwards = {}
+  for _, forward in ipairs(data.forwards) do
+    local container_port = tonumber(forward.container_port)
+    if container_port then
+      table.insert(forwards, { container_port = container_port, host_port = tonumber(forward.host_port) })
+    end
+  end
+  return forwards
+end
+
+-- Write the forwards of a workspace, removing the file once none are left
+local function write(workspace_root, forwards)
+  local path = M.get_path(workspace_root)
+  if #forwards == 0 then
+    os.remove(path)
+    return true
+  end
+
+  local ok, err = fs.write_file(path, vim.json.encode({ workspace = workspace_root, forwards = forwards }))
+  if not ok then
+    log.warn('Failed to save port forwards: %s', err)
+  end
+  return ok
+end
+
+-- Save a forward, replacing a saved forward of the same container port
+-- @param workspace_root string|nil
+-- @param forward table: { container_port, host_port }
+function M.add(workspace_root, forward)
+  if not workspace_root then
+    return false
+  end
+  local forwards = vim.tbl_filter(function(saved)
+    return saved.container_port ~= forward.container_port
+  end, M.load(workspace_root))
+  table.insert(forwards, { container_port = forward.container_port, host_port = forward.host_port })
+  return write(workspace_root, forwards)
+end
+
+-- Forget the forward of a container port
+-- @param workspace_root string|nil
+-- @param container_port number
+function M.remove(workspace_root, container_port)
+  if not workspace_root then
+    return false
+  end
+  local forwards = vim.tbl_filter(function(saved)
+    return saved.container_port ~= container_port
+  end, M.load(workspace_root))
+  return write(workspace_root, forwards)
+end
+
+-- Parse /proc/net/tcp and /proc/net/tcp6 contents into the set of listening ports
+-- @param content string
+-- @return table: port -> true
+function M.parse_listening_ports(content)
+  local ports = {}
+  for line in (content or ''):gmatch('[^\n]+') do
+    -- sl local_address rem_address st ...; local_address is <hex ip>:<hex port>
+    local port, st = line:match('^%s*%d+:%s+%x+:(%x+)%s+%x+:%x+%s+(%x+)')
+    if port and st == TCP_LISTEN then
+      ports[tonumber(port, 16)] = true
+    end
+  end
+  return ports
+end
+
+-- Ports listened on inside a container
+-- @param callback function(ports): port -> true, nil when the container could not be inspected
+function M.list_listening_ports(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async(
+    { 'exec', container_id, 'cat', '/proc/net/tcp', '/proc/net/tcp6' },
+    {},
+    function(result)
+      -- cat fails on kernels without IPv6 but still prints /proc/net/tcp
+      if not result.stdout or result.stdout == '' then
+        callback(nil)
+        return
+      end
+      callback(M.parse_listening_ports(result.stdout))
+    end
+  )
+end
+
+return M
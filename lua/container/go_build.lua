@@ -0,0 +1,254 @@
+-- lua/container/go_build.lua
+-- Cross-build Go programs in the container (:ContainerGoBuild)
+-- `go build` runs once per GOOS/GOARCH target of go_build.targets with the Go toolchain of the container, so the
+-- binaries match the ones CI builds from the same image. The artifacts of a target are written to a directory in the
+-- container and copied with `docker cp` to <go_build.output_dir>/<goos>_<goarch> on the host, which works for
+-- volume workspaces as well. Targets are built one after another; compiler errors go to the quickfix list.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for build output
+M.OUTPUT_NAME = 'go_build'
+
+-- Container directory receiving the artifacts before they are copied to the host
+M.CONTAINER_OUTPUT_DIR = '/tmp/container.nvim-go-build'
+
+-- Build in progress: { job_id, cancelled }
+local running = nil
+
+-- Parse a target tuple
+-- @param target string: e.g. 'linux/amd64'
+-- @return string|nil, string|nil: GOOS and GOARCH
+function M.parse_target(target)
+  return (target or ''):match('^(%w+)/(%w+)$')
+end
+
+-- Targets to build: the command arguments, or go_build.targets
+-- @param args table|nil
+-- @return table|nil: list of { target, goos, goarch }
+-- @return string|nil: error message
+function M.resolve_targets(args)
+  local list = args and #args > 0 and args or require('container.config').get_value('go_build.targets') or {}
+  if #list == 0 then
+    return nil, 'No targets; set go_build.targets or pass GOOS/GOARCH tuples (e.g. linux/amd64)'
+  end
+  local targets = {}
+  for _, target in ipairs(list) do
+    local goos, goarch = M.parse_target(target)
+    if not goos then
+      return nil, 'Invalid target ' .. tostring(target) .. ' (expected GOOS/GOARCH, e.g. linux/amd64)'
+    end
+    table.insert(targets, { target = target, goos = goos, goarch = goarch })
+  end
+  return targets
+end
+
+-- Command building a target: the artifact directory is emptied first, then `go build -o <dir>/` writes one binary
+-- per main package into it
+-- @param out_dir string: container directory of the artifacts
+-- @param build_config table: go_build settings (packages, args)
+-- @return table: argv
+function M.build_command(out_dir, build_config)
+  local cmd = { 'sh', '-c', 'rm -rf "$0" && mkdir -p "$0" && exec "$@"', out_dir, 'go', 'build', '-o', out_dir .. '/' }
+  vim.list_extend(cmd, build_config.args or {})
+  vim.list_extend(cmd, build_config.packages or { '.' })
+  return cmd
+end
+
+-- Quickfix item of a compiler error line, with the file mapped to the host
+-- Errors are printed relative to the build directory, or as container paths.
+-- @param line string
+-- @param ctx table: { host_root, container_root, host_dir }
+-- @return table|nil
+function M.parse_error(line, ctx)
+  local test = require('container.test')
+  local file, lnum, col, msg = test.parse_location(line)
+  if not file then
+    return nil
+  end
+  local filename
+  if file:match('^/') then
+    filename = test.map_path(file, ctx.container_root, ctx.host_root)
+  else
+    filename = require('container.utils.fs').resolve_path(file, ctx.host_dir)
+  end
+  if not filename then
+    return nil
+  end
+  return { filename = filename, lnum = lnum, col = col or 0, text = msg, type = 'E' }
+end
+
+-- Check whether a build is running
+function M.is_running()
+  return running ~= nil
+end
+
+-- Stop the running build; the remaining targets are skipped
+function M.stop()
+  if running then
+    running.cancelled = true
+    pcall(vim.fn.jobstop, running.job_id)
+    running = nil
+  end
+end
+
+-- Build the targets in the container and copy the artifacts to the host
+-- The build runs in the Go module of the current file, or in the workspace folder.
+-- @param args table|nil: targets overriding go_build.targets
+-- @return boolean: true when the build was started
+function M.run(args)
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+  if running then
+    notify.warn('A Go build is already running')
+    return false
+  end
+  local targets, err = M.resolve_targets(args)
+  if not targets then
+    notify.error(err)
+    return false
+  end
+
+  local build_config = require('container.config').get_value('go_build') or {}
+  local container_config = container.get_state().current_config or {}
+  local fs = require('container.utils.fs')
+  local test = require('container.test')
+  local file = vim.fn.expand('%:p')
+  local folder = require('container.workspace_folders').for_path(container_config, file ~= '' and file or nil)
+  local host_dir = file ~= '' and test.find_go_module(fs.dirname(file)) or folder.host
+  local container_dir = test.map_path(host_dir, folder.host, folder.container)
+  if not container_dir then
+    host_dir, container_dir = folder.host, folder.container
+  end
+  local ctx = { host_root = folder.host, container_root = folder.container, host_dir = host_dir }
+  local output_dir = fs.resolve_path(build_config.output_dir or 'dist', folder.host)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.open(M.OUTPUT_NAME)
+  local progress = require('container.ui.progress')
+  local token = progress.begin('Go build', targets[1].target)
+
+  local items, seen, results = {}, {}, {}
+  local run = { cancelled = false }
+  running = run
+
+  local function finish()
+    running = nil
+    local failed = vim.tbl_filter(function(result)
+      return not result.success
+    end, results)
+    vim.fn.setqflist({}, ' ', { title = 'ContainerGoBuild', items = items })
+    output.append(M.OUTPUT_NAME, { '' })
+    for _, result in ipairs(results) do
+      local mark = result.success and '✓' or '✗'
+      output.append(M.OUTPUT_NAME, { string.format('%s %s: %s', mark, result.target, result.message) })
+    end
+    if #failed == 0 then
+      progress.finish(token, true, string.format('Built %d target(s) into %s', #results, output_dir))
+    else
+      local names = vim.tbl_map(function(result)
+        return result.target
+      end, failed)
+      progress.finish(token, false, string.format('Build failed for %s', table.concat(names, ', ')))
+      if #items > 0 then
+        vim.cmd('copen')
+      end
+    end
+  end
+
+  local function build(index)
+    if run.cancelled then
+      progress.cancel(token)
+      return
+    end
+    local target = targets[index]
+    if not target then
+      finish()
+      return
+    end
+    progress.report(token, target.target, math.floor((index - 1) * 100 / #targets))
+
+    local out_dir = string.format('%s/%s_%s', M.CONTAINER_OUTPUT_DIR, target.goos, target.goarch)
+    local host_out = string.format('%s/%s_%s', output_dir, target.goos, target.goarch)
+    local env = { GOOS = target.goos, GOARCH = target.goarch, CGO_ENABLED = build_config.cgo and '1' or '0' }
+    local build_cmd = M.build_command(out_dir, build_config)
+    local cmd = { require('container.docker.runtime').get() }
+    vim.list_extend(cmd, container._build_exec_args(container_id, build_cmd, { cwd = container_dir, env = env }))
+    output.append(M.OUTPUT_NAME, {
+      string.format('==> %s: %s  (in %s)', target.target, table.concat(build_cmd, ' ', 5), container_dir),
+    })
+    log.info('Go build of %s in container (cwd: %s)', target.target, container_dir)
+
+    -- The same error is reported by every target; the first one keeps it
+    local function add_lines(lines)
+      for _, line in ipairs(lines) do
+        local item = M.parse_error(line, ctx)
+        local key = item and string.format('%s:%d:%d:%s', item.filename, item.lnum, item.col, item.text)
+        if item and not seen[key] then
+          seen[key] = true
+          item.text = string.format('%s: %s', target.target, item.text)
+          table.insert(items, item)
+        end
+      end
+      output.append(M.OUTPUT_NAME, lines)
+    end
+
+    local on_data, remaining = require('container.utils.lines').handler(function(lines)
+      vim.schedule(function()
+        add_lines(lines)
+      end)
+    end)
+
+    local function done(success, message)
+      table.insert(results, { target = target.target, success = success, message = message })
+      build(index + 1)
+    end
+
+    local job_id = vim.fn.jobstart(cmd, {
+      env = require('container.secrets').job_env(cmd),
+      on_stdout = on_data,
+      on_stderr = on_data,
+      on_exit = function(_, exit_code)
+        vim.schedule(function()
+          if run.cancelled then
+            progress.cancel(token)
+            return
+          end
+          local rest = remaining()
+          if #rest > 0 then
+            add_lines(rest)
+          end
+          if exit_code ~= 0 then
+            done(false, string.format('go build exited with code %d', exit_code))
+            return
+          end
+          vim.fn.mkdir(host_out, 'p')
+          local source = container_id .. ':' .. out_dir .. '/.'
+          require('container.docker').copy_async(source, host_out, function(ok, copy_err)
+            vim.schedule(function()
+              done(ok, ok and host_out or ('copying the artifacts failed: ' .. tostring(copy_err)))
+            end)
+          end)
+        end)
+      end,
+    })
+    if job_id <= 0 then
+      done(false, 'failed to start docker exec')
+      return
+    end
+    run.job_id = job_id
+  end
+
+  build(1)
+  return true
+end
+
+return M
@@ -0,0 +1,188 @@
+-- lua/container/go_cache.lua
+-- Named volumes for the Go module and build caches (cache_go_modules)
+-- Before the container is created, `go env` runs in the image as the remote user to find GOMODCACHE and GOCACHE.
+-- One volume per cache is mounted there, named after the workspace and the Go minor version: containers of the same
+-- workspace share the caches, while another Go version gets caches of its own. The volumes carry a label so
+-- :ContainerGoCacheClear can find them.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Label marking the cache volumes, set to the workspace hash
+M.LABEL = 'container.nvim.go-cache'
+
+-- Prints GOMODCACHE, GOCACHE, GOVERSION and the user's UID and GID, one per line
+M.PROBE_SCRIPT = 'go env GOMODCACHE GOCACHE GOVERSION && id -u && id -g'
+
+-- Parse the output of PROBE_SCRIPT
+-- @param output string
+-- @return table|nil: { modcache, gocache, version, uid, gid }
+function M.parse_probe(output)
+  local lines = {}
+  for line in (output or ''):gmatch('[^\r\n]+') do
+    table.insert(lines, vim.trim(line))
+  end
+  local modcache, gocache, version, uid, gid = lines[1], lines[2], lines[3], lines[4], lines[5]
+  if not (modcache and modcache:match('^/') and gocache and gocache:match('^/') and version) then
+    return nil
+  end
+  return { modcache = modcache, gocache = gocache, version = version, uid = uid, gid = gid }
+end
+
+-- Go minor version used in volume names ("go1.22.3" -> "go1.22", "devel go1.23-abc" -> "devel")
+function M.version_key(version)
+  return version:match('^(go%d+%.%d+)') or (version:match('^(%w+)') or 'go')
+end
+
+-- Hash of the workspace the volumes belong to
+function M.workspace_hash(config)
+  return vim.fn.sha256(config.base_path or vim.fn.getcwd()):sub(1, 8)
+end
+
+-- Cache volumes of a workspace and Go version
+-- @return table: { { name, target } } for the module cache and the build cache
+function M.volumes(config, probe)
+  local version = M.version_key(probe.version):gsub('%.', '-')
+  local prefix = string.format('container-nvim-go-%s-%s', M.workspace_hash(config), version)
+  return {
+    { name = prefix .. '-mod', target = probe.modcache },
+    { name = prefix .. '-build', target = probe.gocache },
+  }
+end
+
+-- Check whether the cache volumes are enabled
+function M.enabled()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  return ok and plugin_config and plugin_config.cache_go_modules == true
+end
+
+-- Create a cache volume unless it exists
+-- New volumes are empty directories owned by root: they are handed to the user running go.
+local function ensure_volume(volume, config, image, probe, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async({ 'volume', 'inspect', volume.name }, {}, function(inspect)
+    if inspect.success then
+      callback(true)
+      return
+    end
+    local create = { 'volume', 'create', '--label', M.LABEL .. '=' .. M.workspace_hash(config), volume.name }
+    docker.run_docker_command_async(create, {}, function(created)
+      if not created.success then
+        log.warn('Failed to create Go cache volume %s: %s', volume.name, created.stderr)
+        callback(false)
+        return
+      end
+      log.info('Created Go cache volume: %s', volume.name)
+      if not probe.uid or probe.uid == '0' then
+        callback(true)
+        return
+      end
+      docker.run_docker_command_async({
+        'run',
+        '--rm',
+        '-u',
+        'root',
+        '-v',
+        volume.name .. ':/cache',
+        '--entrypoint',
+        'chown',
+        image,
+        string.format('%s:%s', probe.uid, probe.gid or probe.uid),
+        '/cache',
+      }, {}, function(chown)
+        if not chown.success then
+          log.warn('Failed to hand Go cache volume %s to UID %s: %s', volume.name, probe.uid, chown.stderr)
+        end
+        callback(true)
+      end)
+    end)
+  end)
+end
+
+-- Add the cache volumes to the mounts of a configuration
+-- Targets already mounted by devcontainer.json are left alone. When go is not found in the image nothing is added.
+-- @param config table: normalized configuration (mounts are extended)
+-- @param image string: image the container is created from
+-- @param callback function(added): number of mounts added
+function M.prepare_async(config, image, callback)
+  local docker = require('container.docker')
+  local args = { 'run', '--rm' }
+  local user = config.remote_user or config.container_user
+  if user then
+    vim.list_extend(args, { '-u', user })
+  end
+  vim.list_extend(args, { '--entrypoint', 'sh', image, '-c', M.PROBE_SCRIPT })
+
+  docker.run_docker_command_async(args, {}, function(result)
+    local probe = result.success and M.parse_probe(result.stdout)
+    if not probe then
+      log.info('Go not found in %s, not mounting Go caches: %s', image, result.stderr or '')
+      callback(0)
+      return
+    end
+
+    local mounted = {}
+    for _, mount in ipairs(config.mounts or {}) do
+      mounted[mount.target] = true
+    end
+    local volumes = vim.tbl_filter(function(volume)
+      return not mounted[volume.target]
+    end, M.volumes(config, probe))
+
+    local added = 0
+    local function next_volume(index)
+      local volume = volumes[index]
+      if not volume then
+        log.info('Mounted %d Go cache volume(s) for %s', added, probe.version)
+        callback(added)
+        return
+      end
+      ensure_volume(volume, config, image, probe, function(ok)
+        if ok then
+          config.mounts = config.mounts or {}
+          table.insert(config.mounts, { type = 'volume', source = volume.name, target = volume.target })
+          added = added + 1
+        end
+        next_volume(index + 1)
+      end)
+    end
+    next_volume(1)
+  end)
+end
+
+-- Remove the Go cache volumes of a workspace (all Go versions)
+-- Volumes used by a container, running or stopped, cannot be removed until the container is.
+-- @param config table: normalized configuration
+-- @param callback function(removed, failed): volume names
+function M.clear(config, callback)
+  local docker = require('container.docker')
+  local filter = string.format('label=%s=%s', M.LABEL, M.workspace_hash(config))
+  docker.run_docker_command_async({ 'volume', 'ls', '-q', '--filter', filter }, {}, function(result)
+    local names = vim.split(vim.trim(result.stdout or ''), '\n', { trimempty = true })
+    if not result.success or #names == 0 then
+      callback({}, {})
+      return
+    end
+    local removed, failed = {}, {}
+    local function next_volume(index)
+      local name = names[index]
+      if not name then
+        callback(removed, failed)
+        return
+      end
+      docker.run_docker_command_async({ 'volume', 'rm', name }, {}, function(rm)
+        table.insert(rm.success and removed or failed, name)
+        if not rm.success then
+          log.warn('Failed to remove Go cache volume %s: %s', name, rm.stderr)
+        end
+        next_volume(index + 1)
+      end)
+    end
+    next_volume(1)
+  end)
+end
+
+return M
@@ -0,0 +1,329 @@
+-- lua/container/health.lua
+-- Health checks of the container and its Compose services in a live window (:ContainerHealth)
+-- The state of each check and the output of its last probe are read with `docker inspect`, then `docker events`
+-- filtered to health_status follows the transitions: each one is listed with its time and the probe output is read
+-- again. This shows why a start is held up by a health check (see docker.health) or by a service that never gets
+-- healthy.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+local BUFFER_NAME = 'health'
+
+-- Transitions kept in the window (the oldest are dropped)
+M.MAX_EVENTS = 50
+
+-- Lines of probe output shown per service
+M.MAX_OUTPUT_LINES = 5
+
+-- Open window: { win, job_id, service, services, events, error, closing }
+local view = nil
+
+local inspect_format = table.concat({
+  '{{.Id}}',
+  '{{.Name}}',
+  '{{index .Config.Labels "com.docker.compose.service"}}',
+  '{{.State.Status}}',
+  '{{json .State.Health}}',
+}, '|')
+
+-- Parse `docker inspect` output in inspect_format
+-- @param stdout string
+-- @return table: services { id (short), name, state, health = docker.health.parse_health() result or nil }
+function M.parse_states(stdout)
+  local parse_health = require('container.docker.health').parse_health
+  local services = {}
+  for line in (stdout or ''):gmatch('[^\r\n]+') do
+    -- The health JSON comes last, as the probe output may contain the separator
+    local id, name, service, state, health = line:match('^([^|]*)|([^|]*)|([^|]*)|([^|]*)|(.*)$')
+    if id then
+      -- Containers outside Compose have no service label ("<no value>")
+      if service == '' or service == '<no value>' then
+        service = name:gsub('^/', '')
+      end
+      table.insert(services, { id = id:sub(1, 12), name = service, state = state, health = parse_health(health) })
+    end
+  end
+  return services
+end
+
+-- Parse a line of `docker events --format '{{json .}}'`
+-- @param line string
+-- @return table|nil: { id (short), name, status, time }, nil for other events
+function M.parse_event(line)
+  local ok, event = pcall(vim.json.decode, line)
+  if not ok or type(event) ~= 'table' then
+    return nil
+  end
+  local status = (event.Action or event.status or ''):match('^health_status:%s*(%S+)')
+  if not status then
+    return nil
+  end
+  local actor = type(event.Actor) == 'table' and event.Actor or {}
+  local attributes = type(actor.Attributes) == 'table' and actor.Attributes or {}
+  return {
+    id = (actor.ID or event.id or ''):sub(1, 12),
+    name = attributes['com.docker.compose.service'] or attributes.name,
+    status = status,
+    time = tonumber(event.time),
+  }
+end
+
+local columns = { 'SERVICE', 'STATE', 'HEALTH', 'FAILING', 'LAST CHECK' }
+
+-- Lines of the window: a table of the services, the output of their last probes and the transitions
+-- @param services table: from parse_states()
+-- @param events table: from parse_event(), oldest first
+-- @return table: lines
+function M.render_lines(services, events)
+  local cells = { columns }
+  for _, service in ipairs(services) do
+    local health = service.health
+    table.insert(cells, {
+      service.name or service.id,
+      service.state or '-',
+      health and health.status or 'no health check',
+      health and tostring(health.failing_streak or 0) or '-',
+      health and health.exit_code and ('exit ' .. health.exit_code) or '-',
+    })
+  end
+
+  local widths = {}
+  for _, line in ipairs(cells) do
+    for i, cell in ipairs(line) do
+      widths[i] = math.max(widths[i] or 0, vim.fn.strdisplaywidth(cell))
+    end
+  end
+  local lines = {}
+  for _, line in ipairs(cells) do
+    local padded = {}
+    for i, cell in ipairs(line) do
+      table.insert(padded, cell .. string.rep(' ', widths[i] - vim.fn.strdisplaywidth(cell)))
+    end
+    table.insert(lines, (table.concat(padded, '  '):gsub('%s+$', '')))
+  end
+
+  -- Output of the last probe of each check
+  for _, service in ipairs(services) do
+    local output = service.health and service.health.output or ''
+    if output ~= '' then
+      table.insert(lines, '')
+      table.insert(lines, string.format('%s, last check output:', service.name or service.id))
+      local output_lines = vim.split(output, '\n', { plain = true })
+      for i = 1, math.min(#output_lines, M.MAX_OUTPUT_LINES) do
+        table.insert(lines, '  ' .. output_lines[i])
+      end
+      if #output_lines > M.MAX_OUTPUT_LINES then
+        table.insert(lines, string.format('  (%d more lines)', #output_lines - M.MAX_OUTPUT_LINES))
+      end
+    end
+  end
+
+  table.insert(lines, '')
+  table.insert(lines, 'Health events:')
+  if #events == 0 then
+    table.insert(lines, '  (waiting for health status changes)')
+  end
+  for _, event in ipairs(events) do
+    local time = event.time and os.date('%H:%M:%S', event.time) or '--:--:--'
+    table.insert(lines, string.format('  %s  %s  %s', time, event.name or event.id, event.status))
+  end
+  return lines
+end
+
+local function window_valid()
+  return view ~= nil and view.win ~= nil and vim.api.nvim_win_is_valid(view.win)
+end
+
+local function render()
+  if not view then
+    return
+  end
+  local lines
+  if not view.services then
+    lines = { 'Reading health checks...' }
+  else
+    lines = M.render_lines(view.services, view.events)
+    if view.error then
+      vim.list_extend(lines, { '', view.error })
+    end
+  end
+  require('container.ui.output').set_lines(BUFFER_NAME, lines)
+  if window_valid() then
+    local width = 0
+    for _, line in ipairs(lines) do
+      width = math.max(width, vim.fn.strdisplaywidth(line))
+    end
+    vim.api.nvim_win_set_config(view.win, {
+      relative = 'editor',
+      width = math.min(math.max(width, 40), vim.o.columns - 4),
+      height = math.min(#lines, vim.o.lines - 4),
+      row = 1,
+      col = math.max(vim.o.columns - width - 4, 0),
+    })
+  end
+end
+
+-- Read the state of the given containers again and replace their entries
+-- @param ids table
+-- @param callback function|nil: called once the entries are updated
+local function inspect(ids, callback)
+  local opened = view
+  local args = { 'inspect', '--format', inspect_format }
+  vim.list_extend(args, ids)
+  require('container.docker').run_docker_command_async(args, {}, function(result)
+    if view ~= opened then
+      return
+    end
+    for _, updated in ipairs(M.parse_states(result.success and result.stdout or '')) do
+      local replaced = false
+      for i, service in ipairs(view.services or {}) do
+        if service.id == updated.id then
+          view.services[i] = updated
+          replaced = true
+        end
+      end
+      if not replaced and (not view.service or updated.name == view.service) then
+        view.services = view.services or {}
+        table.insert(view.services, updated)
+      end
+    end
+    if callback then
+      callback()
+    end
+    render()
+  end)
+end
+
+-- Follow health_status events of the watched containers
+local function follow()
+  local opened = view
+  local cmd = {
+    require('container.docker.runtime').get(),
+    'events',
+    '--filter',
+    'type=container',
+    '--filter',
+    'event=health_status',
+    '--format',
+    '{{json .}}',
+  }
+  for _, service in ipairs(view.services) do
+    vim.list_extend(cmd, { '--filter', 'container=' .. service.id })
+  end
+  log.debug('Following health events: %s', table.concat(cmd, ' '))
+
+  local on_stdout = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      if view ~= opened then
+        return
+      end
+      for _, line in ipairs(lines) do
+        local event = M.parse_event(line)
+        if event then
+          table.insert(view.events, event)
+          if #view.events > M.MAX_EVENTS then
+            table.remove(view.events, 1)
+          end
+          inspect({ event.id })
+        end
+      end
+    end)
+  end)
+  view.job_id = vim.fn.jobstart(cmd, {
+    on_stdout = on_stdout,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        if view == opened and not view.closing then
+          view.error = string.format('docker events exited with code %d, transitions are no longer followed', exit_code)
+          render()
+        end
+      end)
+    end,
+  })
+  if view.job_id <= 0 then
+    view.error = 'Failed to start docker events, transitions are not followed'
+    render()
+  end
+end
+
+-- Close the window and stop following events
+function M.close()
+  if not view then
+    return
+  end
+  view.closing = true
+  if view.job_id and view.job_id > 0 then
+    pcall(vim.fn.jobstop, view.job_id)
+  end
+  if window_valid() then
+    pcall(vim.api.nvim_win_close, view.win, true)
+  end
+  view = nil
+end
+
+-- Show the health window, following health status changes until it is closed
+-- @param service string|nil: only show this Compose service (or container name)
+-- @return boolean: true when the window was opened
+function M.open(service)
+  local state = require('container').get_state()
+  if not state.current_container then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+  M.close()
+
+  local buf = require('container.ui.output').get_buffer(BUFFER_NAME)
+  view = { service = service, events = {} }
+  view.win = vim.api.nvim_open_win(buf, true, {
+    relative = 'editor',
+    width = 40,
+    height = 1,
+    row = 1,
+    col = math.max(vim.o.columns - 44, 0),
+    style = 'minimal',
+    border = 'rounded',
+  })
+  vim.wo[view.win].wrap = false
+  if vim.fn.has('nvim-0.9') == 1 then
+    local title = service and string.format(' Health: %s ', service) or ' Container health '
+    vim.api.nvim_win_set_config(view.win, { title = title, title_pos = 'center' })
+  end
+  for _, key in ipairs({ 'q', '<Esc>' }) do
+    vim.keymap.set('n', key, M.close, { buffer = buf, nowait = true, desc = 'Close health window' })
+  end
+  -- Events stop being followed however the window is closed
+  local opened = view
+  vim.api.nvim_create_autocmd('WinClosed', {
+    pattern = tostring(view.win),
+    once = true,
+    callback = function()
+      if view == opened then
+        M.close()
+      end
+    end,
+  })
+  render()
+
+  require('container.stats').get_container_ids(function(ids)
+    if view ~= opened then
+      return
+    end
+    inspect(ids, function()
+      view.services = view.services or {}
+      -- Without a container to filter on, docker events would follow every container
+      if #view.services == 0 then
+        M.close()
+        local message = service and string.format('No running container of service "%s"', service)
+        notify.error(message or 'No running container')
+        return
+      end
+      follow()
+    end)
+  end)
+  return true
+end
+
+return M
@@ -0,0 +1,176 @@
+-- lua/container/heartbeat.lua
+-- Keep-alive for long idle sessions
+-- After the machine slept, the docker exec processes behind terminals and LSP clients may be gone while the plugin
+-- still shows them as connected. Every heartbeat.interval milliseconds `docker exec <container> true` pings the
+-- container of the current workspace. When a ping fails (or takes longer than heartbeat.timeout), or a tick comes
+-- much later than due (the machine was suspended) and a terminal or LSP client of the container is dead, the
+-- terminals and LSP clients are reconnected once the container answers again (ContainerReconnected).
+
+local M = {}
+
+local log = require('container.utils.log')
+
+M.DEFAULTS = {
+  enabled = true,
+  interval = 30000,
+  timeout = 5000,
+}
+
+-- A tick later than this many intervals means the machine was suspended
+M.RESUME_FACTOR = 2
+
+local options = vim.deepcopy(M.DEFAULTS)
+local timer = nil
+-- Wall clock time (seconds) of the last tick; the loop clock does not advance during a suspend on every platform
+local last_tick = nil
+-- True while a ping or reconnection is in progress
+local busy = false
+
+-- Check whether the time since the last tick shows a suspend
+-- @param last number|nil: os.time() of the last tick
+-- @param now number: os.time()
+-- @param interval number: milliseconds
+-- @return boolean
+function M.is_resumed(last, now, interval)
+  if not last then
+    return false
+  end
+  return (now - last) * 1000 > interval * M.RESUME_FACTOR + 1000
+end
+
+-- Ping a container with `docker exec <container> true`
+-- @param container_id string
+-- @param timeout number: milliseconds before the ping counts as failed
+-- @param callback function(alive)
+function M.ping(container_id, timeout, callback)
+  local done = false
+  local function finish(alive)
+    if not done then
+      done = true
+      callback(alive)
+    end
+  end
+  local cmd = { require('container.docker.runtime').get(), 'exec', container_id, 'true' }
+  local job_id = vim.fn.jobstart(cmd, {
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        finish(exit_code == 0)
+      end)
+    end,
+  })
+  if job_id <= 0 then
+    finish(false)
+    return
+  end
+  vim.defer_fn(function()
+    if not done then
+      pcall(vim.fn.jobstop, job_id)
+      finish(false)
+    end
+  end, timeout)
+end
+
+-- Check whether the terminals and LSP clients of a container are still connected
+-- @param container_id string
+-- @return boolean
+function M.session_alive(container_id)
+  for _, session in ipairs(require('container.terminal.session').list_sessions()) do
+    if session.container_id == container_id and not session:is_valid() then
+      return false
+    end
+  end
+  local ok, lsp = pcall(require, 'container.lsp.init')
+  if ok and lsp.get_state().container_id == container_id then
+    local clients = vim.lsp.get_clients and vim.lsp.get_clients() or vim.lsp.get_active_clients()
+    for _, client in ipairs(clients) do
+      if client.config and client.config.container_id == container_id and client.is_stopped() then
+        return false
+      end
+    end
+  end
+  return true
+end
+
+-- Ping the container of the current workspace and reconnect its session when needed
+function M.tick()
+  local now = os.time()
+  local resumed = M.is_resumed(last_tick, now, options.interval)
+  local idle = now - (last_tick or now)
+  last_tick = now
+
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if busy or not container_id or container.get_state().detached then
+    return
+  end
+  if resumed then
+    log.info('Heartbeat: resumed after %d seconds', idle)
+  end
+
+  busy = true
+  M.ping(container_id, options.timeout, function(alive)
+    local healthy = alive and (not resumed or M.session_alive(container_id))
+    if healthy then
+      busy = false
+      return
+    end
+    local reason = alive and 'resumed from suspend' or 'the container did not answer'
+    log.warn('Heartbeat: %s (%s)', reason, container_id)
+    -- Wait until the container answers again before reconnecting
+    local function reconnect()
+      M.ping(container_id, options.timeout, function(answered)
+        if container.get_container_id() ~= container_id then
+          busy = false
+          return
+        end
+        if not answered then
+          require('container.docker').run_docker_command_async(
+            { 'inspect', '--format', '{{.State.Status}}', container_id },
+            { retry = false },
+            function(result)
+              vim.schedule(function()
+                local status = result.success and vim.trim(result.stdout) or nil
+                if status == 'running' then
+                  vim.defer_fn(reconnect, options.interval)
+                else
+                  busy = false
+                  container._lost_container(container_id, status)
+                end
+              end)
+            end
+          )
+          return
+        end
+        busy = false
+        container._reconnect_session(container_id, reason)
+      end)
+    end
+    reconnect()
+  end)
+end
+
+-- Start the heartbeat timer
+-- @param opts table|nil: heartbeat settings (enabled, interval, timeout)
+function M.setup(opts)
+  options = vim.tbl_extend('force', vim.deepcopy(M.DEFAULTS), opts or {})
+  M.stop()
+  if not options.enabled or options.interval <= 0 then
+    return
+  end
+  last_tick = os.time()
+  timer = (vim.uv or vim.loop).new_timer()
+  timer:start(options.interval, options.interval, vim.schedule_wrap(M.tick))
+  log.debug('Heartbeat: pinging the container every %d ms', options.interval)
+end
+
+-- Stop the heartbeat timer
+function M.stop()
+  if timer then
+    timer:stop()
+    timer:close()
+    timer = nil
+  end
+  busy = false
+end
+
+return M
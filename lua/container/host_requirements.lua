@@ -0,0 +1,153 @@
+-- lua/container/host_requirements.lua
+-- devcontainer.json hostRequirements: cpus, memory and storage
+-- Before a start the host is checked for the required resources: the CPUs and memory the container runtime reports
+-- (the VM of Docker Desktop, or the remote host) and the free disk space of the workspace. A shortfall is a warning,
+-- or fails the start with host_requirements.mode = 'hard'. With host_requirements.limits the container is limited to
+-- the required CPUs and memory (--cpus, --memory) unless runArgs set limits themselves.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+local UNITS = { b = 1, kb = 1024, mb = 1024 ^ 2, gb = 1024 ^ 3, tb = 1024 ^ 4 }
+
+-- Parse a size of hostRequirements ("4gb", "512mb", "1.5tb", or a number of bytes)
+-- @param value string|number
+-- @return number|nil: bytes, nil when the value is not a size
+function M.parse_size(value)
+  if type(value) == 'number' then
+    return value > 0 and value or nil
+  end
+  if type(value) ~= 'string' then
+    return nil
+  end
+  local number, unit = value:lower():gsub('%s', ''):match('^([%d%.]+)(%a*)$')
+  number = tonumber(number)
+  if not number or number <= 0 then
+    return nil
+  end
+  if unit == '' then
+    return number
+  end
+  -- "g", "gi" and "gib" are accepted like "gb"
+  unit = unit:gsub('i?b?$', '') .. 'b'
+  local factor = UNITS[unit]
+  return factor and math.floor(number * factor) or nil
+end
+
+-- Required resources of a configuration
+-- @param config table: normalized configuration
+-- @return table: { cpus, memory, storage } (memory and storage in bytes), nil where not required
+function M.requirements(config)
+  local host_requirements = config and config.host_requirements or {}
+  local cpus = tonumber(host_requirements.cpus)
+  return {
+    cpus = cpus and cpus > 0 and cpus or nil,
+    memory = M.parse_size(host_requirements.memory),
+    storage = M.parse_size(host_requirements.storage),
+  }
+end
+
+-- Check whether a configuration requires any resources
+function M.has_requirements(config)
+  local required = M.requirements(config)
+  return required.cpus ~= nil or required.memory ~= nil or required.storage ~= nil
+end
+
+-- Compare the required resources with the available ones
+-- Resources that could not be determined are not reported.
+-- @param required table: from requirements()
+-- @param available table: { cpus, memory, storage }
+-- @return table: descriptions of the shortfalls, e.g. "memory: 8.0 GB required, 4.0 GB available"
+function M.check(required, available)
+  local format_size = require('container.build_context').format_size
+  local shortfalls = {}
+  if required.cpus and available.cpus and available.cpus < required.cpus then
+    table.insert(shortfalls, string.format('cpus: %g required, %g available', required.cpus, available.cpus))
+  end
+  for _, name in ipairs({ 'memory', 'storage' }) do
+    if required[name] and available[name] and available[name] < required[name] then
+      table.insert(
+        shortfalls,
+        string.format('%s: %s required, %s available', name, format_size(required[name]), format_size(available[name]))
+      )
+    end
+  end
+  return shortfalls
+end
+
+-- Resources available to containers
+-- CPUs and memory come from the container runtime; storage is the free space of the workspace on this machine,
+-- unknown for a remote runtime.
+-- @param config table
+-- @param callback function(available)
+function M.get_available_async(config, callback)
+  local runtime = require('container.docker.runtime')
+  local format = runtime.is_podman() and '{{.Host.CPUs}} {{.Host.MemTotal}}' or '{{.NCPU}} {{.MemTotal}}'
+  require('container.docker').run_docker_command_async({ 'info', '--format', format }, {}, function(result)
+    local cpus, memory = (result.success and result.stdout or ''):match('(%d+)%s+(%d+)')
+    local available = { cpus = tonumber(cpus), memory = tonumber(memory) }
+    if not runtime.is_remote() then
+      local uv = vim.uv or vim.loop
+      local stat = uv.fs_statfs and uv.fs_statfs(config.base_path or vim.fn.getcwd())
+      available.storage = stat and stat.bavail * stat.bsize or nil
+    end
+    callback(available)
+  end)
+end
+
+-- Setting of the plugin (host_requirements.mode, host_requirements.limits)
+local function setting(key, default)
+  local ok, plugin_config = pcall(require, 'container.config')
+  local value = ok and plugin_config.get_value('host_requirements.' .. key)
+  if value == nil then
+    return default
+  end
+  return value
+end
+
+-- Check the host before a start
+-- @param config table
+-- @param callback function(ok, message): ok is false when a hard requirement is not met; message describes the
+--   shortfalls (nil when there are none)
+function M.verify_async(config, callback)
+  local required = M.requirements(config)
+  M.get_available_async(config, function(available)
+    local shortfalls = M.check(required, available)
+    if #shortfalls == 0 then
+      log.debug('hostRequirements met')
+      callback(true, nil)
+      return
+    end
+    local message = 'Host does not meet hostRequirements (' .. table.concat(shortfalls, '; ') .. ')'
+    local hard = setting('mode', 'soft') == 'hard'
+    if hard then
+      log.error(message)
+    else
+      log.warn(message)
+    end
+    callback(not hard, message)
+  end)
+end
+
+-- Limits of the container for the required CPUs and memory
+-- Left out with host_requirements.limits = false, for limits given in runArgs and for Docker Compose.
+-- @param config table
+-- @return table: docker create arguments
+function M.limit_args(config)
+  if not setting('limits', true) then
+    return {}
+  end
+  local docker = require('container.docker')
+  local required = M.requirements(config)
+  local args = {}
+  if required.cpus and not docker.find_run_arg(config.run_args, { '--cpus', '--cpu-quota', '--cpu-period' }) then
+    vim.list_extend(args, { '--cpus', string.format('%g', required.cpus) })
+  end
+  if required.memory and not docker.find_run_arg(config.run_args, { '--memory', '-m' }) then
+    vim.list_extend(args, { '--memory', string.format('%d', required.memory) })
+  end
+  return args
+end
+
+return M
@@ -3,10 +3,20 @@
 --
 -- This module triggers the following User autocmd events:
 -- - ContainerOpened: When devcontainer config is loaded
+-- - ContainerBuildStarted: When an image build (Dockerfile, features or compose) begins
+-- - ContainerBuildFailed: When an image build fails (data.error holds the reason)
 -- - ContainerBuilt: When container image is built/prepared
 -- - ContainerStarted: When container starts successfully
+-- - ContainerAttached: When the plugin attaches to an already running container
 -- - ContainerStopped: When container stops or is killed
 -- - ContainerClosed: When devcontainer is closed/reset
+-- - ContainerBuildProgress: When a build step or pulled layer completes (data = { kind, stage, percentage })
+--
+-- The same events can be subscribed to from Lua with M.on() (see container.events).
+--
+-- Every event carries container_id and container_name in its data and fires after the
+-- internal state has been updated, so handlers calling status() see the new state.
+-- - ContainerStateChanged: When status().state changes (data = { state, previous })
 
 local M = {}
 
@@ -17,35 +27,341 @@ local docker = nil
 local log = nil
 local lsp = nil
 local notify = nil
+local pipeline = nil
 
 -- Internal state
-local state = {
-  initialized = false,
-  current_container = nil,
-  current_config = nil,
-  -- Cache for container status to reduce frequent Docker calls
-  status_cache = {
-    container_status = nil,
-    last_update = 0,
-    update_interval = 5000, -- Update container status every 5 seconds
-    updating = false, -- Flag to prevent concurrent updates
-  },
-}
+local initialized = false
+
+-- Per-workspace state; each project tracks its own container, config and forwards
+local function new_workspace_state(workspace_root)
+  return {
+    workspace_root = workspace_root,
+    -- devcontainer.json chosen for the workspace when it has several configurations
+    config_path = nil,
+    current_container = nil,
+    current_config = nil,
+    -- Ports forwarded after start ({ container_port, host_port, sidecar, network })
+    port_forwards = {},
+    -- Container left by :ContainerDetach ({ container_id, config, stopped, host_clients }), for :ContainerReopen
+    detached = nil,
+    -- Container lifecycle as reported by status(): 'none', 'building', 'running' or 'stopped'
+    lifecycle = { state = 'none', started_at = nil },
+    -- Cache for container status to reduce frequent Docker calls
+    status_cache = {
+      container_status = nil,
+      last_update = 0,
+      update_interval = 5000, -- Update container status every 5 seconds
+      updating = false, -- Flag to prevent concurrent updates
+    },
+  }
+end
+
+-- Workspace states keyed by workspace root
+local workspaces = {}
+-- State of the active workspace (follows the current buffer)
+local state = new_workspace_state(nil)
+-- Workspace root lookup cache keyed by directory
+local workspace_root_cache = {}
+-- ContainerStarted autocmd restoring the session after rebuild_container()
+local pending_rebuild_restore = nil
 
 -- Clear status cache when state changes
-local function clear_status_cache()
-  state.status_cache.container_status = nil
-  state.status_cache.last_update = 0
-  state.status_cache.updating = false
+-- @param workspace table|nil: workspace state (default: the active one)
+local function clear_status_cache(workspace)
+  local cache = (workspace or state).status_cache
+  cache.container_status = nil
+  cache.last_update = 0
+  cache.updating = false
 end
 
 -- Clear all state including current container
 local function clear_all_state()
   state.current_container = nil
   state.current_config = nil
+  state.port_forwards = {}
+  state.lifecycle = { state = 'none', started_at = nil }
   clear_status_cache()
 end
 
+-- Parse a docker timestamp (e.g. 2024-01-02T03:04:05.123456789Z) into epoch seconds
+local function parse_docker_time(value)
+  local year, month, day, hour, min, sec = (value or ''):match('^(%d+)-(%d+)-(%d+)T(%d+):(%d+):(%d+)')
+  if not year or year == '0001' then
+    return nil
+  end
+  local timestamp = os.time({
+    year = tonumber(year),
+    month = tonumber(month),
+    day = tonumber(day),
+    hour = tonumber(hour),
+    min = tonumber(min),
+    sec = tonumber(sec),
+  })
+  -- os.time() reads the table as local time while docker reports UTC
+  local now = os.time()
+  return timestamp + os.difftime(now, os.time(os.date('!*t', now)))
+end
+
+-- Update the lifecycle state and announce transitions
+local function set_container_state(new_state, started_at)
+  local previous = state.lifecycle.state
+  if new_state == 'running' then
+    state.lifecycle.started_at = started_at or state.lifecycle.started_at or os.time()
+  else
+    state.lifecycle.started_at = nil
+  end
+  if previous == new_state then
+    return
+  end
+
+  state.lifecycle.state = new_state
+  pcall(require('container.events').emit, 'ContainerStateChanged', { state = new_state, previous = previous })
+  vim.schedule(function()
+    pcall(vim.cmd, 'redrawstatus')
+  end)
+end
+
+-- Start in progress for the active workspace (see container.pipeline)
+local function active_start()
+  pipeline = pipeline or require('container.pipeline')
+  return pipeline.active(state.workspace_root)
+end
+
+-- End the start of the active workspace after it completed or failed
+local function finish_start()
+  pipeline = pipeline or require('container.pipeline')
+  pipeline.finish(active_start())
+  notify = notify or require('container.utils.notify')
+  notify.clear_progress('start')
+end
+
+-- Report a step of the start; the step is also exposed as status().progress
+-- Takes the arguments of notify.progress() without the operation, or a message alone.
+local function start_progress(step, total, message)
+  if message == nil and type(step) == 'string' then
+    step, total, message = nil, nil, step
+  end
+  pipeline = pipeline or require('container.pipeline')
+  pipeline.set_progress(active_start(), step, total, message)
+  notify = notify or require('container.utils.notify')
+  notify.progress('start', step, total, message)
+end
+
+-- Fall back to the state implied by the current container after an aborted operation
+-- An aborted start ends with it.
+local function reset_container_state()
+  set_container_state(state.current_container and 'stopped' or 'none')
+  finish_start()
+end
+
+-- Move to 'running' and read the start time of the container in the background
+-- Until `docker inspect` answers, the time of the transition stands in (an attached container may have been
+-- started long before).
+local function set_running(container_id)
+  set_container_state('running')
+  if not container_id then
+    return
+  end
+  docker = docker or require('container.docker')
+  -- The answer updates this workspace even when another one is active by then
+  local workspace = state
+  docker.run_docker_command_async(
+    { 'inspect', '--format', '{{.State.StartedAt}}', container_id },
+    {},
+    function(result)
+      local started_at = result.success and parse_docker_time(vim.trim(result.stdout or ''))
+      if started_at and workspace.lifecycle.state == 'running' and workspace.current_container == container_id then
+        workspace.lifecycle.started_at = started_at
+        clear_status_cache(workspace)
+      end
+    end
+  )
+end
+
+-- Fire a lifecycle User autocmd
+-- @param pattern string: event name
+-- @param data table|nil: payload; container_id and container_name default to the current container
+-- @param new_state string|nil: lifecycle state to move to before handlers run
+local function emit_event(pattern, data, new_state)
+  data = data or {}
+  if data.container_id == nil then
+    data.container_id = state.current_container
+  end
+  if data.container_name == nil then
+    data.container_name = state.current_config and state.current_config.name
+  end
+
+  if new_state == 'running' then
+    set_running(data.container_id)
+  elseif new_state then
+    set_container_state(new_state)
+  end
+
+  require('container.events').emit(pattern, data)
+end
+
+-- Report an unreachable runtime with a single notification; the runtime's own error goes to :messages
+local function report_docker_unavailable(error_msg, detail)
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  local headline = vim.split(error_msg or 'Docker daemon not reachable', '\n')[1]:gsub('%.$', '')
+  if detail and detail ~= '' then
+    vim.api.nvim_echo({ { detail, 'ErrorMsg' } }, true, {})
+    headline = headline .. ' (see :messages)'
+  end
+  log.error('%s', error_msg or headline)
+  notify.critical(headline)
+end
+
+-- Check whether LSP is set up for the container: lsp.auto_setup, or servers requested by the
+-- devcontainer customizations
+local function should_setup_lsp()
+  config = config or require('container.config')
+  if config.get_value('lsp.auto_setup') then
+    return true
+  end
+  return require('container.customizations').requests_lsp(state.current_config, config.get_value('lsp'))
+end
+
+-- Workspace root of a path, cached per directory
+local function find_workspace_root(path)
+  if not path or path == '' then
+    return nil
+  end
+  if workspace_root_cache[path] == nil then
+    parser = parser or require('container.parser')
+    local ok, root = pcall(parser.find_workspace_root, path)
+    workspace_root_cache[path] = ok and root or false
+  end
+  return workspace_root_cache[path] or nil
+end
+
+-- State of a workspace, created on first use
+local function workspace_state(workspace_root)
+  if not workspaces[workspace_root] then
+    if state.workspace_root == nil then
+      -- The initial state has not been bound to a project yet, adopt it
+      state.workspace_root = workspace_root
+      workspaces[workspace_root] = state
+    else
+      workspaces[workspace_root] = new_workspace_state(workspace_root)
+    end
+  end
+  return workspaces[workspace_root]
+end
+
+-- Make the given workspace active, creating its state on first use
+local function use_workspace(workspace_root)
+  if not workspace_root or workspace_root == state.workspace_root then
+    return state
+  end
+
+  local workspace = workspace_state(workspace_root)
+  if workspace ~= state then
+    state = workspace
+    log = log or require('container.utils.log')
+    log.debug('Switched active workspace to %s', workspace_root)
+    -- LSP operations follow the container of the active workspace
+    if lsp then
+      lsp.switch_container(state.current_container)
+    end
+    vim.schedule(function()
+      pcall(vim.cmd, 'redrawstatus')
+    end)
+  end
+  return state
+end
+
+-- Commands and callbacks running on a workspace; the current buffer does not select another one meanwhile
+local pinned = 0
+
+-- Run fn on the state of a workspace, then give the active workspace back
+local function run_in_workspace(workspace_root, fn, ...)
+  local active = state
+  if workspace_root then
+    state = workspace_state(workspace_root)
+  end
+  if lsp and state ~= active then
+    lsp.switch_container(state.current_container)
+  end
+  pinned = pinned + 1
+  local function restore(ok, ...)
+    pinned = pinned - 1
+    if state ~= active then
+      state = active
+      if lsp then
+        lsp.switch_container(state.current_container)
+      end
+    end
+    if not ok then
+      error((...), 0)
+    end
+    return ...
+  end
+  return restore(pcall(fn, ...))
+end
+
+-- Bind an async callback to the workspace that started the operation
+-- The callback updates that workspace even when another project is active by the time it runs.
+local function in_workspace(workspace_root, fn)
+  return function(...)
+    return run_in_workspace(workspace_root, fn, ...)
+  end
+end
+
+-- Workspace root of the current buffer, falling back to the working directory
+local function current_workspace_root()
+  local bufname = vim.api.nvim_buf_get_name(0)
+  local dir = bufname ~= '' and vim.fn.fnamemodify(bufname, ':p:h') or nil
+  if dir and vim.fn.isdirectory(dir) == 1 then
+    local root = find_workspace_root(dir)
+    if root then
+      return root
+    end
+  end
+  return find_workspace_root(vim.fn.getcwd())
+end
+
+-- Select the workspace that owns the current buffer
+function M._sync_workspace()
+  if pinned > 0 then
+    return state
+  end
+  return use_workspace(current_workspace_root())
+end
+
+-- Wrap a command so that it acts on the workspace of the current buffer
+-- Commands called by another command or by a bound callback keep the workspace they run in.
+local function command(fn)
+  return function(...)
+    if pinned > 0 then
+      return fn(...)
+    end
+    pcall(M._sync_workspace)
+    pinned = pinned + 1
+    local function release(ok, ...)
+      pinned = pinned - 1
+      if not ok then
+        error((...), 0)
+      end
+      return ...
+    end
+    return release(pcall(fn, ...))
+  end
+end
+
+-- Workspace root of the active workspace
+function M.get_workspace_root()
+  return state.workspace_root
+end
+
+-- Roots of all workspaces known to this session
+function M.list_workspaces()
+  local roots = vim.tbl_keys(workspaces)
+  table.sort(roots)
+  return roots
+end
+
 -- Configuration setup
 function M.setup(user_config)
   log = require('container.utils.log')
@@ -53,13 +369,22 @@ function M.setup(user_config)
   notify = require('container.utils.notify')
 
   -- Clear any previous state
-  clear_all_state()
+  workspaces = {}
+  workspace_root_cache = {}
+  state = new_workspace_state(nil)
 
-  local success = config.setup(user_config)
+  -- Invalid options stop the setup, unknown ones are reported so typos do not go unnoticed
+  local success, result, unknown = config.setup(user_config)
   if not success then
     log.error('Failed to setup configuration')
+    if type(result) == 'table' and #result > 0 then
+      notify.critical('Invalid container.nvim configuration:\n  ' .. table.concat(result, '\n  '))
+    end
     return false
   end
+  if type(unknown) == 'table' and #unknown > 0 then
+    notify.warn('Unknown container.nvim options (ignored): ' .. table.concat(unknown, ', '))
+  end
 
   -- Initialize terminal system
   local terminal_ok, terminal_err = pcall(function()
@@ -125,7 +450,52 @@ function M.setup(user_config)
     log.warn('Failed to initialize ftplugin manager: %s', ftplugin_err)
   end
 
-  state.initialized = true
+  -- Format on save with formatters in the container
+  local format_ok, format_err = pcall(function()
+    require('container.format').setup(config.get_value('format') or {})
+  end)
+
+  if not format_ok then
+    log.warn('Failed to initialize container formatting: %s', format_err)
+  end
+
+  -- Ping the container so connections lost while the machine slept are reconnected
+  local heartbeat_ok, heartbeat_err = pcall(function()
+    require('container.heartbeat').setup(config.get_value('heartbeat') or {})
+  end)
+
+  if not heartbeat_ok then
+    log.warn('Failed to initialize the heartbeat: %s', heartbeat_err)
+  end
+
+  -- Commands act on the workspace of the current buffer
+  local workspace_group = vim.api.nvim_create_augroup('ContainerWorkspace', { clear = true })
+  vim.api.nvim_create_autocmd({ 'BufEnter', 'DirChanged' }, {
+    group = workspace_group,
+    callback = function()
+      pcall(M._sync_workspace)
+    end,
+  })
+
+  -- A remote daemon cannot bind mount the workspace: copy saved files into the container
+  vim.api.nvim_create_autocmd('BufWritePost', {
+    group = workspace_group,
+    callback = function(args)
+      M._sync_saved_file(args.buf)
+    end,
+  })
+
+  -- Ad hoc containers (start_image) do not outlive the session; others follow their shutdownAction
+  vim.api.nvim_create_autocmd('VimLeavePre', {
+    group = workspace_group,
+    callback = function()
+      pcall(M._remove_ephemeral_containers)
+      pcall(M._shutdown_containers)
+      pcall(M._remove_env_files)
+    end,
+  })
+
+  initialized = true
   log.debug('container.nvim initialized successfully')
 
   -- Attempt to auto-detect and reconnect to existing containers
@@ -137,10 +507,11 @@ function M.setup(user_config)
 end
 
 -- Open devcontainer
+-- @param path string|nil: workspace directory, or a devcontainer.json to use for its workspace
 function M.open(path)
   log = log or require('container.utils.log')
 
-  if not state.initialized then
+  if not initialized then
     log.error('Plugin not initialized. Call setup() first.')
     return false
   end
@@ -148,24 +519,40 @@ function M.open(path)
   parser = parser or require('container.parser')
   docker = docker or require('container.docker')
 
-  path = path or vim.fn.getcwd()
+  local config_path = nil
+  if path and path:match('%.json$') then
+    config_path = vim.fn.fnamemodify(path, ':p')
+    path = parser.get_workspace_folder(config_path)
+  end
+
+  path = path or current_workspace_root() or vim.fn.getcwd()
   log.info('Opening devcontainer from path: %s', path)
 
+  -- Each project keeps its own state, keyed by workspace root
+  local workspace_root = find_workspace_root(path) or path
+  use_workspace(workspace_root)
+  -- Subsequent commands keep using the configuration chosen for this workspace
+  config_path = config_path or state.config_path
+
   -- Check Docker availability
-  local docker_ok, docker_err = docker.check_docker_availability()
+  local docker_ok, docker_err, docker_detail = docker.check_docker_availability()
   if not docker_ok then
-    log.error('Docker is not available: %s', docker_err)
-    -- Display detailed error message to user
-    notify.error('Docker is not available', docker_err)
+    report_docker_unavailable(docker_err, docker_detail)
     return false
   end
 
   -- Search and parse devcontainer.json
-  local devcontainer_config, parse_err = parser.find_and_parse(path)
+  local devcontainer_config, parse_err
+  if config_path then
+    devcontainer_config, parse_err = parser.parse(config_path)
+  else
+    devcontainer_config, parse_err = parser.find_and_parse(path)
+  end
   if not devcontainer_config then
     log.error('Failed to parse devcontainer.json: %s', parse_err)
     return false
   end
+  state.config_path = config_path
 
   -- Validate configuration
   local validation_errors = parser.validate(devcontainer_config)
@@ -182,6 +569,16 @@ function M.open(path)
     log.error('Failed to resolve dynamic ports: %s', port_err)
     return false
   end
+  -- workspace_folders are mounted like additional_mounts
+  local additional_mounts = require('container.workspace_folders').mounts(
+    config.get().additional_mounts,
+    config.get().workspace_folders or {}
+  )
+  local _, mount_warnings = parser.add_additional_mounts(resolved_config, additional_mounts)
+  notify = notify or require('container.utils.notify')
+  for _, warning in ipairs(mount_warnings) do
+    notify.status(warning, 'warn')
+  end
 
   -- Validate resolved ports
   local resolved_validation_errors = parser.validate_resolved_ports(resolved_config)
@@ -195,12 +592,28 @@ function M.open(path)
   -- Normalize configuration for plugin use
   local normalized_config = parser.normalize_for_plugin(resolved_config)
   normalized_config.base_path = path -- Add base path for container name generation
+  normalized_config.workspace_root = workspace_root
+  parser.add_default_args(normalized_config, config.get())
+  local _, clone_warning = parser.add_workspace_clone(normalized_config, config.get())
+  if clone_warning then
+    notify.status(clone_warning, 'warn')
+  end
+
+  -- Environment files from runArgs and env_files
+  for _, warning in ipairs(require('container.env_file').prepare(normalized_config, config.get().env_files)) do
+    notify.status(warning, 'warn')
+  end
 
   -- Merge with plugin configuration
   parser.merge_with_plugin_config(resolved_config, config.get())
 
   state.current_config = normalized_config
 
+  -- Report saves of the Dockerfile, devcontainer.json and the other files the container is built from
+  if config.get_value('watch_config.enabled') then
+    require('container.watch').watch(normalized_config)
+  end
+
   log.info('Successfully loaded devcontainer configuration: %s', normalized_config.name)
   log.debug('Config has postCreateCommand: %s', tostring(normalized_config.postCreateCommand ~= nil))
   log.debug('Config has post_create_command: %s', tostring(normalized_config.post_create_command ~= nil))
@@ -212,19 +625,17 @@ function M.open(path)
   end
 
   -- Trigger ContainerOpened event
-  vim.api.nvim_exec_autocmds('User', {
-    pattern = 'ContainerOpened',
-    data = {
-      container_name = normalized_config.name,
-      config_path = path,
-    },
+  emit_event('ContainerOpened', {
+    container_name = normalized_config.name,
+    config_path = path,
   })
 
   return true
 end
 
 -- Prepare image (build or pull)
-function M.build()
+-- @param on_complete function|nil: called with success after the image is ready
+function M.build(on_complete)
   log = log or require('container.utils.log')
 
   if not state.current_config then
@@ -235,38 +646,183 @@ function M.build()
   docker = docker or require('container.docker')
 
   log.info('Preparing devcontainer image')
+  local workspace_root = state.workspace_root
+
+  -- Restore the previous state once the build finishes; start() moves on from there
+  local previous_state = state.lifecycle.state ~= 'building' and state.lifecycle.state or 'none'
+  emit_event('ContainerBuildStarted', {
+    image = state.current_config.image,
+    dockerfile = state.current_config.dockerfile,
+    service = state.current_config.service,
+  }, 'building')
+
+  -- Follow the build in the progress window, and its steps in the progress sink (ui.progress);
+  -- without the window every output line is reported
+  local build_title = 'Building ' .. (state.current_config.name or 'devcontainer')
+  local build_window = require('container.ui.build_progress')
+  local use_window = build_window.enabled()
+  if use_window then
+    build_window.start(build_title)
+  end
+  local progress = require('container.ui.progress')
+  local progress_token = progress.begin(build_title)
+  local on_progress = in_workspace(workspace_root, function(data)
+    if use_window then
+      build_window.handle_line(data)
+    end
+    local stage, percentage = progress.build_stage(data)
+    if stage or not use_window then
+      progress.report(progress_token, stage or data, percentage)
+    end
+    if stage then
+      emit_event('ContainerBuildProgress', { kind = 'build', stage = stage, percentage = percentage })
+    end
+  end)
+
+  -- Builds run by start() belong to its pipeline; a build of its own (:ContainerBuild) gets one so
+  -- that it can be cancelled too. A cancelled build ends quietly, cancel() has reset the state.
+  local run = active_start()
+  local standalone = run == nil
+  if standalone then
+    run = pipeline.begin(state.workspace_root)
+    run.build_only = true
+    run.previous_state = previous_state
+  end
+  local function cancelled()
+    if not pipeline.is_cancelled(run) then
+      if standalone then
+        pipeline.finish(run)
+      end
+      return false
+    end
+    log.info('Image build cancelled')
+    progress.cancel(progress_token)
+    if on_complete then
+      on_complete(false)
+    end
+    return true
+  end
+
+  local compose = require('container.docker.compose')
+  if compose.is_compose_config(state.current_config) then
+    return compose.build(state.current_config, on_progress, in_workspace(workspace_root, function(success, result)
+      if cancelled() then
+        return
+      end
+      if use_window then
+        build_window.finish(success)
+      end
+      progress.finish(progress_token, success, not success and (result.stderr or 'unknown error') or nil)
+      if success then
+        log.info('Successfully built compose services')
+        emit_event('ContainerBuilt', {
+          container_name = state.current_config and state.current_config.name or 'unknown',
+          service = state.current_config and state.current_config.service,
+        }, previous_state)
+      else
+        log.error('Failed to build compose services: %s', result.stderr or 'unknown error')
+        emit_event('ContainerBuildFailed', {
+          service = state.current_config and state.current_config.service,
+          error = result.stderr or 'unknown error',
+        }, previous_state)
+      end
+      if on_complete then
+        on_complete(success)
+      end
+    end))
+  end
 
-  return docker.prepare_image(state.current_config, function(data)
-    -- Display build progress via notification system
-    notify.progress('image_build', nil, nil, data)
-  end, function(success, result)
+  return docker.prepare_image(state.current_config, on_progress, in_workspace(workspace_root, function(success, result)
+    if cancelled() then
+      return
+    end
+    if use_window then
+      build_window.finish(success)
+    end
+    progress.finish(progress_token, success, not success and (result.stderr or 'unknown error') or nil)
     if success then
       log.info('Successfully prepared devcontainer image')
+      local config = state.current_config or {}
+      -- Say whether a prebuilt image was used or why the image was built here
+      if config.image_source == 'prebuilt' then
+        notify.status('Using prebuilt image ' .. config.prebuilt_from)
+      elseif config.image_source == 'build' and config.prebuilt_skipped then
+        notify.status('Built image locally: ' .. config.prebuilt_skipped)
+      end
       -- Trigger ContainerBuilt event
-      vim.api.nvim_exec_autocmds('User', {
-        pattern = 'ContainerBuilt',
-        data = {
-          container_name = state.current_config and state.current_config.name or 'unknown',
-          image = state.current_config and state.current_config.image or 'unknown',
-        },
-      })
+      emit_event('ContainerBuilt', {
+        container_name = config.name or 'unknown',
+        image = config.image or 'unknown',
+        image_cache_key = config.image_cache_key,
+        image_source = config.image_source,
+        prebuilt_image = config.prebuilt_from,
+      }, previous_state)
     else
       log.error('Failed to prepare devcontainer image: %s', result.stderr or 'unknown error')
+      emit_event('ContainerBuildFailed', {
+        image = state.current_config and state.current_config.image,
+        error = result.stderr or 'unknown error',
+      }, previous_state)
+    end
+    if on_complete then
+      on_complete(success)
+    end
+  end))
+end
+
+-- Ask which devcontainer.json to use when a workspace has several configurations
+-- The choice is remembered for the workspace.
+-- @return boolean: true when the picker was shown (callback runs once a configuration is chosen)
+function M._select_config(workspace_root, callback)
+  parser = parser or require('container.parser')
+  local configs = parser.find_devcontainer_configs(workspace_root)
+  if #configs < 2 then
+    return false
+  end
+
+  vim.ui.select(configs, {
+    prompt = 'Select devcontainer configuration:',
+    format_item = function(config_path)
+      return config_path:sub(#workspace_root + 2)
+    end,
+  }, function(choice)
+    if not choice then
+      notify.container('No devcontainer configuration selected')
+      return
     end
+    workspace_state(workspace_root).config_path = choice
+    run_in_workspace(workspace_root, callback, choice)
   end)
+  return true
 end
 
 -- Start container (fully async version)
-function M.start()
+-- @param opts table|nil: { force_rebuild = boolean } rebuilds the image and recreates the container
+function M.start(opts)
   log = log or require('container.utils.log')
+  opts = opts or {}
 
-  if not state.initialized then
+  if not initialized then
     log.error('Plugin not initialized. Call setup() first.')
     return false
   end
 
   -- If no configuration is loaded, try to load it automatically
   if not state.current_config then
+    -- Ask which configuration to start when the workspace has several
+    local workspace_root = current_workspace_root()
+    if workspace_root then
+      use_workspace(workspace_root)
+      if
+        not state.config_path
+        and M._select_config(workspace_root, function()
+          M.start(opts)
+        end)
+      then
+        return true
+      end
+    end
+
     log.info('No devcontainer configuration loaded, attempting to load...')
     local success = M.open()
     if not success then
@@ -275,121 +831,487 @@ function M.start()
     end
     -- Since open() is synchronous and includes container building/creation,
     -- we need to restart the async start process
-    return M.start()
+    return M.start(opts)
+  end
+
+  -- Show the commands of the start instead of running them
+  if opts.dry_run then
+    local plan_config = vim.deepcopy(state.current_config)
+    plan_config.force_rebuild = opts.force_rebuild or plan_config.force_rebuild
+    return require('container.dry_run').show(plan_config)
   end
 
   docker = docker or require('container.docker.init')
 
+  -- Bypass the image cache: drop prepared images so they are rebuilt with --no-cache
+  if opts.force_rebuild then
+    log.info('Force rebuild requested, ignoring cached images')
+    state.current_config.force_rebuild = true
+    state.current_config.built_image = nil
+    state.current_config.prepared_image = nil
+    state.current_config.features_image = nil
+    state.current_config.features_checked = nil
+    state.current_config.uid_image = nil
+  end
+
+  -- Make sure the daemon is reachable before running anything long (initializeCommand, builds)
+  if not opts.docker_checked then
+    if active_start() then
+      notify.status('A container start or build is already in progress (:ContainerCancel cancels it)', 'warn')
+      return false
+    end
+    -- The steps from here on run as one pipeline that :ContainerCancel can stop
+    pipeline = pipeline or require('container.pipeline')
+    local run = pipeline.begin(state.workspace_root)
+    set_container_state('building')
+    start_progress(1, 6, 'Step 1: Checking Docker...')
+    local workspace_root = state.workspace_root
+    docker.check_docker_availability_async(function(available, err, detail)
+      vim.schedule(in_workspace(workspace_root, function()
+        if run.cancelled then
+          return
+        end
+        if not available then
+          reset_container_state()
+          report_docker_unavailable(err, detail)
+          return
+        end
+        start_progress(1, 6, 'Step 1: ✓ Docker is available')
+        M.start(vim.tbl_extend('force', opts, { docker_checked = true }))
+      end))
+    end)
+    return true
+  end
+
+  -- hostRequirements: warn about (or, in hard mode, refuse) a host without the required resources
+  local host_requirements = require('container.host_requirements')
+  if not opts.host_checked and host_requirements.has_requirements(state.current_config) then
+    local workspace_root = state.workspace_root
+    local run = active_start()
+    host_requirements.verify_async(state.current_config, function(ok, message)
+      vim.schedule(in_workspace(workspace_root, function()
+        if pipeline.is_cancelled(run) then
+          return
+        end
+        if not ok then
+          reset_container_state()
+          notify.critical(message .. ' (host_requirements.mode is hard)')
+          return
+        end
+        if message then
+          notify.status(message, 'warn')
+        end
+        M.start(vim.tbl_extend('force', opts, { host_checked = true }))
+      end))
+    end)
+    return true
+  end
+
+  -- initializeCommand runs on the host before anything is built or started
+  if not opts.host_initialized and state.current_config.initialize_command then
+    set_container_state('building')
+    local workspace_root = state.workspace_root
+    local run = active_start()
+    M._run_initialize_command(in_workspace(workspace_root, function(success)
+      if pipeline.is_cancelled(run) then
+        return
+      end
+      if success then
+        M.start(vim.tbl_extend('force', opts, { host_initialized = true }))
+      else
+        reset_container_state()
+      end
+    end))
+    return true
+  end
+
   log.info('Starting devcontainer...')
   notify.container('Starting DevContainer...', 'info')
+  set_container_state('building')
+  -- Async steps below must update this workspace even if another buffer is focused meanwhile
+  local workspace_root = state.workspace_root
+  -- ...and stop once the start is cancelled
+  pipeline = pipeline or require('container.pipeline')
+  local run = active_start()
+
+  -- Compose-based devcontainers are started through docker compose
+  local compose = require('container.docker.compose')
+  if compose.is_compose_config(state.current_config) then
+    -- A forced rebuild builds the service images without the cache before the services are recreated
+    if state.current_config.force_rebuild then
+      M.build(in_workspace(workspace_root, function(success)
+        if pipeline.is_cancelled(run) then
+          return
+        end
+        if not success then
+          reset_container_state()
+          notify.critical('Failed to build compose services')
+          return
+        end
+        set_container_state('building')
+        M._start_compose()
+      end))
+      return true
+    end
+    return M._start_compose()
+  end
 
-  -- Check if image is prepared
+  -- Check if image is prepared (Dockerfile images are built or reused from the cache first)
   local has_image = state.current_config.built_image
     or state.current_config.prepared_image
-    or state.current_config.image
+    or (not state.current_config.dockerfile and state.current_config.image)
 
   if not has_image then
     log.info('Image not prepared, building/pulling first...')
     notify.container('Building/pulling image... This may take a while.', 'info')
-    notify.status('Image building is not yet fully async. This may take time.', 'info')
-    M.build()
+    M.build(in_workspace(workspace_root, function(success)
+      if pipeline.is_cancelled(run) then
+        return
+      end
+      if success and (state.current_config.built_image or state.current_config.prepared_image) then
+        M.start({ host_initialized = true, docker_checked = true, host_checked = true })
+      else
+        reset_container_state()
+        notify.critical('Failed to prepare image')
+      end
+    end))
     return true
   end
 
-  -- Check Docker availability (async)
-  notify.progress('start', 1, 6, 'Step 1: Checking Docker...')
-  docker.check_docker_availability_async(function(available, err)
-    vim.schedule(function()
-      if not available then
-        notify.critical('Docker not available: ' .. (err or 'unknown'))
+  -- Check for existing containers (async)
+  start_progress(2, 6, 'Step 2: Checking for existing containers...')
+
+  -- Containers are found by their labels, as a container_name_template with {branch} changes the name
+  local expected_container_name = docker.resolve_container_name(state.current_config)
+  log.info('Looking for the container of this workspace (new name: %s)', expected_container_name)
+
+  M._find_workspace_container(state.current_config, function(found)
+    local containers = found and { found } or {}
+    vim.schedule(in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
         return
       end
-      notify.progress('start', 1, 6, 'Step 1: ✓ Docker is available')
+      local container_id = nil
 
-      -- Check for existing containers (async)
-      notify.progress('start', 2, 6, 'Step 2: Checking for existing containers...')
+      if #containers > 0 then
+        container_id = containers[1].id
+        local container_status = containers[1].status
+        log.info('Found existing container: %s (status: %s)', container_id, container_status)
+        start_progress(
+          2,
+          6,
+          'Step 2: ✓ Found existing container: ' .. container_id:sub(1, 12) .. ' (' .. container_status .. ')'
+        )
+        state.current_container = container_id
+        clear_status_cache()
 
-      -- Generate the expected container name using the same logic as creation
-      local expected_container_name = docker.generate_container_name(state.current_config)
-      log.info('Looking for container with name: %s', expected_container_name)
+        -- Recreate the container so it uses the rebuilt image
+        if state.current_config.force_rebuild then
+          start_progress(3, 6, 'Step 3: Removing existing container for rebuild...')
+          docker.stop_and_remove_container(container_id, nil, function(removed, remove_err)
+            vim.schedule(in_workspace(workspace_root, function()
+              if pipeline.is_cancelled(run) then
+                return
+              end
+              if not removed then
+                reset_container_state()
+                notify.critical('Failed to remove container for rebuild: ' .. (remove_err or 'unknown'))
+                return
+              end
+              state.current_container = nil
+              clear_status_cache()
+              M.start({ host_initialized = true, docker_checked = true, host_checked = true })
+            end))
+          end)
+          return
+        end
 
-      M._list_containers_with_fallback(expected_container_name, function(containers)
-        vim.schedule(function()
-          local container_id = nil
-
-          if #containers > 0 then
-            container_id = containers[1].id
-            local container_status = containers[1].status
-            log.info('Found existing container: %s (status: %s)', container_id, container_status)
-            notify.progress(
-              'start',
-              'Step 2: ✓ Found existing container: ' .. container_id:sub(1, 12) .. ' (' .. container_status .. ')'
-            )
+        -- Check if container is already running
+        if container_status:match('^Up') then
+          -- Container is already running, proceed directly to final setup
+          if run then
+            run.was_running = true
+          end
+          start_progress(3, 6, 'Step 3: Container already running, setting up features...')
+          M._start_final_step(container_id)
+        else
+          -- Container exists but is not running, start it first
+          start_progress(3, 6, 'Step 3: Starting existing container...')
+          M._start_stopped_container(container_id)
+        end
+      else
+        -- Create new container (async); a cancelled start removes it by name
+        if run then
+          run.created_container = expected_container_name
+        end
+        start_progress(3, 6, 'Step 3: Creating new container...')
+        M._create_container_full_async(state.current_config, function(create_result, create_err)
+          vim.schedule(in_workspace(workspace_root, function()
+            if pipeline.is_cancelled(run) then
+              -- Created after the start was cancelled
+              if create_result then
+                docker.remove_container_async(create_result, true)
+              end
+              return
+            end
+            if not create_result then
+              log.error('Failed to create container: %s', create_err)
+              reset_container_state()
+              notify.critical('Failed to create container: ' .. (create_err or 'unknown'))
+              return
+            end
+            container_id = create_result
+            start_progress(3, 6, 'Step 3: ✓ Created container: ' .. container_id:sub(1, 12))
             state.current_container = container_id
+            state.current_config.force_rebuild = false
             clear_status_cache()
 
-            -- Check if container is already running
-            if container_status:match('^Up') then
-              -- Container is already running, proceed directly to final setup
-              notify.progress('start', 3, 6, 'Step 3: Container already running, setting up features...')
-              M._start_final_step(container_id)
-            else
-              -- Container exists but is not running, start it first
-              notify.progress('start', 3, 6, 'Step 3: Starting existing container...')
-              M._start_stopped_container(container_id)
-            end
-          else
-            -- Create new container (async)
-            notify.progress('start', 3, 6, 'Step 3: Creating new container...')
-            M._create_container_full_async(state.current_config, function(create_result, create_err)
-              vim.schedule(function()
-                if not create_result then
-                  log.error('Failed to create container: %s', create_err)
-                  notify.critical('Failed to create container: ' .. (create_err or 'unknown'))
-                  return
-                end
-                container_id = create_result
-                notify.progress('start', 3, 6, 'Step 3: ✓ Created container: ' .. container_id:sub(1, 12))
-                state.current_container = container_id
-                clear_status_cache()
-
-                -- Proceed to container startup
-                M._start_final_step(container_id)
-              end)
-            end)
-          end
+            -- Proceed to container startup
+            M._start_final_step(container_id)
+          end))
         end)
-      end)
-    end)
-  end)
+      end
+    end))
+  end, { match_config = true })
 
   notify.status('DevContainer start initiated (non-blocking)...', 'info')
   return true
 end
 
--- Start a stopped container and proceed to final setup
-function M._start_stopped_container(container_id)
-  docker = docker or require('container.docker.init')
+-- Start an ad hoc container from an image, without devcontainer.json
+-- The workspace is mounted at opts.workspace_folder and the container goes through the same start
+-- pipeline (exec, terminals, LSP) as a configured one. It is removed when it stops and when Neovim exits.
+-- @param image string: image to run, e.g. 'golang:1.22'
+-- @param opts table|nil: { workspace_folder, user, env, name, keep }
+--   keep: keep the container after it stops instead of removing it
+function M.start_image(image, opts)
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  config = config or require('container.config')
+  opts = opts or {}
 
-  docker.start_container_async(container_id, function(success, error_msg)
-    vim.schedule(function()
-      if success then
-        notify.progress('start', 3, 6, 'Step 3: ✓ Container started successfully')
+  if not initialized then
+    log.error('Plugin not initialized. Call setup() first.')
+    return false
+  end
+  if type(image) ~= 'string' or vim.trim(image) == '' then
+    notify.error('An image is required, e.g. :ContainerStartImage golang:1.22')
+    return false
+  end
+  image = vim.trim(image)
+
+  local workspace_root = current_workspace_root() or vim.fn.getcwd()
+  use_workspace(workspace_root)
+  if state.current_container or active_start() then
+    notify.status('A container is already attached to this workspace, stop it first with :ContainerStop', 'warn')
+    return false
+  end
+
+  local normalized_config = require('container.adhoc').config(image, workspace_root, opts)
+  require('container.parser').add_default_args(normalized_config, config.get())
+  log.info('Starting ad hoc container from image %s', image)
+  state.current_config = normalized_config
+  -- A devcontainer.json chosen earlier is loaded again by the next :ContainerStart
+  state.config_path = nil
+  emit_event('ContainerOpened', { container_name = normalized_config.name, image = image })
+  return M.start()
+end
+
+-- Remove the ad hoc containers of every workspace (called when Neovim exits)
+function M._remove_ephemeral_containers()
+  docker = docker or require('container.docker.init')
+  for _, workspace in pairs(workspaces) do
+    if workspace.current_container and workspace.current_config and workspace.current_config.ephemeral then
+      docker.run_docker_command({ 'rm', '-f', workspace.current_container })
+    end
+  end
+end
+
+-- Time on top of docker.stop_timeout that exiting waits for the containers to stop
+local SHUTDOWN_GRACE_SECONDS = 2
+
+-- What happens to the container when Neovim exits: shutdownAction of devcontainer.json, else the
+-- shutdown_action setting ('none' by default, leaving the container running to reconnect later)
+-- @return string: 'none', 'stopContainer' or 'stopCompose'
+function M._shutdown_action(container_config)
+  local action = container_config and container_config.shutdown_action
+  if not action then
+    config = config or require('container.config')
+    action = config.get_value('shutdown_action') or 'none'
+  end
+  -- stopCompose only applies to compose projects
+  if action == 'stopCompose' and not require('container.docker.compose').is_compose_config(container_config) then
+    action = 'stopContainer'
+  end
+  return action
+end
+
+-- Remove the generated env files of every workspace (VimLeavePre)
+function M._remove_env_files()
+  local env_file = require('container.env_file')
+  env_file.remove(state.current_config)
+  for _, workspace in pairs(workspaces) do
+    env_file.remove(workspace.current_config)
+  end
+end
+
+-- Apply the shutdown action of every attached container (VimLeavePre)
+-- Runs synchronously, as Neovim exits right after. The stops run side by side and exiting waits for them up to
+-- docker.stop_timeout seconds (plus a grace period) in total; stops still running then finish in the background.
+function M._shutdown_containers()
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker.init')
+  local compose = require('container.docker.compose')
+  local stop_timeout = docker.get_stop_timeout()
+  local timeout = tostring(stop_timeout)
+  local commands = {}
+  for _, workspace in pairs(workspaces) do
+    local container_config = workspace.current_config
+    if workspace.current_container and container_config and not container_config.ephemeral then
+      local action = M._shutdown_action(container_config)
+      if action == 'stopCompose' then
+        local args = compose.build_base_args(container_config, true)
+        vim.list_extend(args, { 'stop', '-t', timeout })
+        table.insert(commands, { args = args, cwd = container_config.compose_project_dir })
+      elseif action == 'stopContainer' then
+        table.insert(commands, { args = { 'stop', '-t', timeout, workspace.current_container } })
+      end
+    end
+  end
+  if #commands == 0 then
+    return
+  end
+
+  local pending = docker.run_docker_commands_detached(commands, (stop_timeout + SHUTDOWN_GRACE_SECONDS) * 1000)
+  if pending > 0 then
+    log.warn('%d container(s) still stopping when Neovim exits', pending)
+  end
+end
+
+-- Run initializeCommand on the host, following its output like an image build
+-- @param callback function(success)
+function M._run_initialize_command(callback)
+  local current_config = state.current_config
+  local build_window = require('container.ui.build_progress')
+  local use_window = build_window.enabled()
+  if use_window then
+    build_window.start('initializeCommand')
+  end
+
+  local cwd = state.workspace_root or current_config.base_path or vim.fn.getcwd()
+  local lifecycle = require('container.lifecycle')
+  local run = active_start()
+  lifecycle.run_initialize_command(current_config, cwd, function(line)
+    if use_window then
+      build_window.handle_line(line)
+    else
+      notify.progress('image_build', nil, nil, line)
+    end
+  end, function(success, failure)
+    -- The command was stopped by :ContainerCancel
+    if pipeline.is_cancelled(run) then
+      callback(false)
+      return
+    end
+    if use_window then
+      build_window.finish(success)
+    else
+      notify.clear_progress('image_build')
+    end
+    if not success then
+      local message = string.format('%s failed with exit code %d: %s', failure.hook, failure.exit_code, failure.command)
+      log.error(message)
+      notify.critical(message)
+    end
+    callback(success)
+  end)
+end
+
+-- Start docker compose services and attach to the configured service
+function M._start_compose()
+  local compose = require('container.docker.compose')
+  local current_config = state.current_config
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  if run then
+    -- A cancelled start stops the services it started
+    run.compose = true
+  end
+
+  start_progress(1, 6, 'Step 1: Starting compose services...')
+  compose.up(current_config, function(line)
+    -- Build and startup output goes to the same progress channel as image builds
+    notify.progress('image_build', nil, nil, line)
+  end, function(container_id, err)
+    vim.schedule(in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
+        return
+      end
+      notify.clear_progress('image_build')
+      if not container_id then
+        log.error('Failed to start compose services: %s', err or 'unknown')
+        reset_container_state()
+        notify.critical('Failed to start compose services: ' .. (err or 'unknown'))
+        return
+      end
+
+      log.info('Attached to compose service %s: %s', current_config.service, container_id)
+      current_config.force_rebuild = false
+      current_config.workspace_folder = compose.resolve_workspace_folder(current_config, container_id)
+
+      -- Compose builds the service image itself, so the UID remap happens in the running container
+      local uid = require('container.docker.uid')
+      if uid.should_update(current_config) then
+        start_progress(3, 6, 'Step 3: Updating remote user UID...')
+        uid.update_running_container(container_id, current_config)
+      end
+      state.current_container = container_id
+      clear_status_cache()
+      start_progress(3, 6, 'Step 3: ✓ Compose service running: ' .. current_config.service)
+      M._finalize_container_setup(container_id)
+    end))
+  end)
+
+  return true
+end
+
+-- Start a stopped container and proceed to final setup
+function M._start_stopped_container(container_id)
+  docker = docker or require('container.docker.init')
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  if run then
+    -- A cancelled start stops the container again
+    run.started_container = container_id
+  end
+
+  docker.start_container_async(container_id, function(success, error_msg)
+    vim.schedule(in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
+        -- Started after the start was cancelled
+        if success then
+          docker.stop_container_async(container_id)
+        end
+        return
+      end
+      if success then
+        start_progress(3, 6, 'Step 3: ✓ Container started successfully')
         log.info('Stopped container started successfully: %s', container_id)
         -- Proceed to final setup
         M._start_final_step(container_id)
       else
         log.error('Failed to start stopped container: %s', error_msg or 'unknown')
+        reset_container_state()
 
         -- Check if it's a bash compatibility issue
         if error_msg and error_msg:match('bash.*executable file not found') then
           log.info('Detected bash compatibility issue, recreating container with POSIX sh')
-          notify.progress('start', 3, 6, 'Step 3: Fixing shell compatibility issue...')
+          start_progress(3, 6, 'Step 3: Fixing shell compatibility issue...')
 
           -- Force remove the incompatible container
           local removed = docker.force_remove_container(container_id)
           if removed then
-            notify.progress('start', 3, 6, 'Step 3: ✓ Removed incompatible container, creating new one...')
+            start_progress(3, 6, 'Step 3: ✓ Removed incompatible container, creating new one...')
             -- Re-parse configuration and create new container
             local current_path = vim.fn.getcwd()
             local parser = require('container.parser')
@@ -398,67 +1320,222 @@ function M._start_stopped_container(container_id)
               log.info('Recreating container with POSIX sh compatibility')
               -- Use the standard container creation flow
               M._create_container_full_async(config, function(create_result, create_err)
-                vim.schedule(function()
+                vim.schedule(in_workspace(workspace_root, function()
                   if not create_result then
                     log.error('Failed to recreate container: %s', create_err)
                     notify.critical('Failed to recreate container: ' .. (create_err or 'unknown'))
-                    notify.clear_progress('start')
+                    finish_start()
                   else
                     log.info('Successfully recreated container: %s', create_result)
-                    notify.progress('start', 3, 6, 'Step 3: ✓ Recreated container with POSIX sh')
+                    start_progress(3, 6, 'Step 3: ✓ Recreated container with POSIX sh')
                     M._start_final_step(create_result)
                   end
-                end)
+                end))
               end)
             else
               notify.critical('Failed to re-parse configuration: ' .. (parse_error or 'unknown'))
-              notify.clear_progress('start')
+              finish_start()
             end
           else
             notify.critical('Failed to remove incompatible container')
-            notify.clear_progress('start')
+            finish_start()
           end
         else
           notify.critical('Failed to start existing container: ' .. (error_msg or 'unknown'))
-          notify.clear_progress('start')
+          finish_start()
         end
       end
-    end)
+    end))
   end)
 end
 
 -- Final step: Container feature setup (assumes container is already running)
 function M._start_final_step(container_id)
-  notify.progress('start', 4, 6, 'Step 4: Setting up container features...')
+  start_progress(4, 6, 'Step 4: Setting up container features...')
 
   -- Check if container is actually running before proceeding
   docker = docker or require('container.docker.init')
-  local container_status = docker.get_container_status(container_id)
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  local status_args = { 'inspect', '--format', '{{.State.Status}}', container_id }
+  docker.run_docker_command_async(status_args, {}, in_workspace(workspace_root, function(result)
+    if pipeline.is_cancelled(run) then
+      return
+    end
+    if result.success and vim.trim(result.stdout) == 'running' then
+      -- Container is already running, proceed with setup
+      log.info('Container is already running: %s', container_id)
+      M._finalize_container_setup(container_id)
+      return
+    end
 
-  if container_status ~= 'running' then
     -- Container is not running, try to start it first
-    notify.progress('start', 4, 6, 'Step 4: Container not running, starting it...')
+    start_progress(4, 6, 'Step 4: Container not running, starting it...')
     docker.start_container_async(container_id, function(success, error_msg)
-      vim.schedule(function()
+      vim.schedule(in_workspace(workspace_root, function()
+        if pipeline.is_cancelled(run) then
+          return
+        end
         if success then
           log.info('Container started successfully: %s', container_id)
           M._finalize_container_setup(container_id)
         else
           log.error('Failed to start container: %s', error_msg or 'unknown')
+          reset_container_state()
           notify.critical('Failed to start container: ' .. (error_msg or 'unknown'))
-          notify.clear_progress('start')
         end
-      end)
+      end))
     end)
-  else
-    -- Container is already running, proceed with setup
-    log.info('Container is already running: %s', container_id)
-    M._finalize_container_setup(container_id)
-  end
+  end))
 end
 
 -- Finalize container setup after ensuring it's running
+-- A container whose image or compose service defines a HEALTHCHECK is only treated as started
+-- once the check passes.
 function M._finalize_container_setup(container_id)
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  require('container.docker.health').wait(container_id, {
+    on_status = in_workspace(workspace_root, function(health)
+      if not pipeline.is_cancelled(run) then
+        start_progress(4, 6, string.format('Step 4: Waiting for container health check (%s)...', health.status))
+      end
+    end),
+  }, in_workspace(workspace_root, function(ready, health)
+    if pipeline.is_cancelled(run) then
+      return
+    end
+    if not ready then
+      local message = string.format('Container did not become healthy (status: %s)', health.status)
+      if health.output ~= '' then
+        message = message .. '\nLast health check output:\n' .. health.output
+      end
+      log.error(message)
+      reset_container_state()
+      notify.critical(message)
+      return
+    end
+    if state.current_config and state.current_config.workspace_clone then
+      start_progress(4, 6, 'Step 4: Cloning the workspace repository...')
+      M._clone_workspace(container_id, function()
+        if not pipeline.is_cancelled(run) then
+          M._complete_container_start(container_id)
+        end
+      end)
+      return
+    end
+    if not require('container.docker.runtime').is_remote() then
+      M._complete_container_start(container_id)
+      return
+    end
+    start_progress(4, 6, 'Step 4: Copying workspace to the remote container...')
+    M.sync_remote_workspace(function()
+      if not pipeline.is_cancelled(run) then
+        M._complete_container_start(container_id)
+      end
+    end)
+  end))
+end
+
+-- Clone the repository of workspace_clone into the workspace volume before the lifecycle commands run
+-- A failed clone ends the start with the git error; the container is kept, so the next :ContainerStart tries again.
+-- @param callback function: called once the workspace holds the clone
+function M._clone_workspace(container_id, callback)
+  docker = docker or require('container.docker')
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  local clone = state.current_config.workspace_clone
+  local on_cloned = in_workspace(workspace_root, function(success, error_msg, reused)
+    if pipeline.is_cancelled(run) then
+      return
+    end
+    if not success then
+      log.error('Failed to clone %s: %s', clone.repository, error_msg)
+      reset_container_state()
+      notify.critical(
+        string.format(
+          'Failed to clone %s into volume %s:\n%s\nFix the cause and run :ContainerStart again',
+          clone.repository,
+          clone.volume,
+          error_msg
+        )
+      )
+      return
+    end
+    if reused then
+      log.info('Reusing the clone of %s in volume %s', clone.repository, clone.volume)
+    else
+      notify.status(
+        string.format('Cloned %s into volume %s, browse it with :ContainerExplore', clone.repository, clone.volume)
+      )
+    end
+    callback()
+  end)
+  docker.clone_workspace_async(container_id, state.current_config, on_cloned)
+end
+
+-- Copy the workspace into the container of a remote Docker host (:ContainerSyncWorkspace)
+-- Files in the container are overwritten by the host copies; files only in the container are kept.
+-- @param callback function|nil: called once the copy has finished
+function M.sync_remote_workspace(callback)
+  log = log or require('container.utils.log')
+  if not state.current_container then
+    notify.error('No active container')
+    return false
+  end
+  if require('container.parser').is_volume_workspace(state.current_config) then
+    notify.error('The workspace is kept in a volume, there is no host copy to sync')
+    return false
+  end
+  docker = docker or require('container.docker')
+  local workspace_root = state.workspace_root
+  docker.sync_workspace_async(state.current_container, state.current_config, function(success, error_msg)
+    vim.schedule(in_workspace(workspace_root, function()
+      if success then
+        notify.status('Workspace copied to the container')
+      else
+        log.error('Failed to copy workspace: %s', error_msg or 'unknown')
+        notify.error('Failed to copy workspace to the container: ' .. (error_msg or 'unknown'))
+      end
+      if callback then
+        callback(success)
+      end
+    end))
+  end)
+  return true
+end
+
+-- Copy a saved buffer into the container when the daemon is remote
+function M._sync_saved_file(bufnr)
+  if not state.current_container or state.lifecycle.state ~= 'running' then
+    return
+  end
+  if not require('container.docker.runtime').is_remote() then
+    return
+  end
+  if require('container.parser').is_volume_workspace(state.current_config) then
+    return
+  end
+  local docker_config = config.get_value('docker') or {}
+  if docker_config.sync_on_save == false then
+    return
+  end
+  local file = vim.api.nvim_buf_get_name(bufnr)
+  if file == '' then
+    return
+  end
+  docker = docker or require('container.docker')
+  docker.sync_file_async(state.current_container, state.current_config, file, function(success, error_msg)
+    if not success then
+      vim.schedule(function()
+        log.debug('Not copied to the container: %s (%s)', file, error_msg or 'unknown')
+      end)
+    end
+  end)
+end
+
+-- Announce the started container, then run lifecycle commands and set up features
+function M._complete_container_start(container_id)
   notify.container('Container is running!', 'info')
   log.info('Container is ready: %s', container_id)
 
@@ -467,67 +1544,150 @@ function M._finalize_container_setup(container_id)
   log.info('LSP path resolution will be handled by strategy system')
 
   -- Trigger ContainerStarted event
-  vim.api.nvim_exec_autocmds('User', {
-    pattern = 'ContainerStarted',
-    data = {
-      container_id = container_id,
-      container_name = state.current_config and state.current_config.name or 'unknown',
-    },
-  })
-
-  -- Setup core features with graceful degradation
-  M._setup_container_features_gracefully(container_id)
+  emit_event('ContainerStarted', {
+    container_id = container_id,
+    container_name = state.current_config and state.current_config.name or 'unknown',
+  }, 'running')
+
+  -- Exec sessions fall back to the container's default user when remoteUser does not exist
+  local remote_user = state.current_config and state.current_config.remote_user
+  if remote_user and require('container.docker.uid').user_exists_in_container(container_id, remote_user) == false then
+    log.warn('remoteUser %s does not exist in the container, using the default user', remote_user)
+    notify.status(string.format('remoteUser "%s" not found in container, using its default user', remote_user), 'warn')
+    state.current_config.remote_user = nil
+  end
 
-  -- Setup test integration
-  local test_config = config.get()
-  if
-    test_config.test_integration
-    and test_config.test_integration.enabled
-    and test_config.test_integration.auto_setup
-  then
-    log.debug('Setting up test integration...')
-    vim.defer_fn(function()
-      local test_runner = require('container.test_runner')
-      if test_runner.setup() then
-        log.info('Test integration setup complete')
-      end
-    end, 500) -- Small delay to ensure everything is loaded
+  -- Resolve ${containerEnv:...} references in remoteEnv against the running container
+  local environment = require('container.environment')
+  if state.current_config and environment.needs_container_env(state.current_config) then
+    state.current_config.container_runtime_env = environment.load_container_env(container_id)
   end
 
-  -- Execute post-start command (existing)
-  if state.current_config.post_start_command then
-    notify.progress('start', 6, 6, 'Step 6: Running post-start command...')
-    M.exec(state.current_config.post_start_command)
+  -- Run lifecycle commands (create family only on first start); features are set up once the
+  -- waitFor command has finished while later commands keep running
+  local current_config = state.current_config
+  local lifecycle = require('container.lifecycle')
+  local workspace_root = state.workspace_root
+  local run = active_start()
+  local wait_for = lifecycle.wait_for_name(current_config)
+  if run then
+    -- LSP servers are not started before the waitFor command has finished (see waiting_for())
+    run.waiting_for = wait_for
   end
+  start_progress(5, 6, 'Step 5: Running lifecycle commands (waiting for ' .. wait_for .. ')...')
+  local on_hook = in_workspace(workspace_root, function(hook_name, waiting)
+    if waiting then
+      start_progress(5, 6, string.format('Step 5: Running %s (waiting for %s)...', hook_name, wait_for))
+    else
+      start_progress(5, 6, string.format('Step 5: Running %s (container ready)...', hook_name))
+    end
+  end)
+  local on_ready = in_workspace(workspace_root, function()
+    if pipeline.is_cancelled(run) then
+      return
+    end
+    if run then
+      -- From here on cancelling only stops the remaining lifecycle commands
+      run.ready = true
+      run.waiting_for = nil
+    end
+    -- Capture the environment of the user's shell (userEnvProbe) before LSP servers and terminals start
+    environment.probe_user_env(container_id, current_config)
+
+    -- Setup core features with graceful degradation
+    M._setup_container_features_gracefully(container_id)
+
+    -- Forward the ports saved for the workspace again (after a rebuild or a restart of Neovim)
+    M._restore_port_forwards(container_id)
+
+    -- Act on portsAttributes onAutoForward once the published ports are listened on
+    require('container.port_actions').watch(container_id, current_config.ports, in_workspace(workspace_root, function()
+      return state.current_container == container_id
+    end))
 
-  notify.container('DevContainer is ready!', 'info')
-  notify.clear_progress('start') -- Clear progress messages
+    -- Setup test integration
+    local test_config = config.get()
+    if
+      test_config.test_integration
+      and test_config.test_integration.enabled
+      and test_config.test_integration.auto_setup
+    then
+      log.debug('Setting up test integration...')
+      vim.defer_fn(function()
+        local test_runner = require('container.test_runner')
+        if test_runner.setup() then
+          log.info('Test integration setup complete')
+        end
+      end, 500) -- Small delay to ensure everything is loaded
+    end
+
+    notify.container('DevContainer is ready!', 'info')
+    notify.clear_progress('start') -- Clear progress messages
+  end)
+
+  lifecycle.run(container_id, current_config, {
+    wait_for = wait_for,
+    on_ready = on_ready,
+    on_hook = on_hook,
+  }, in_workspace(workspace_root, function(success, failure)
+    if pipeline.is_cancelled(run) then
+      return
+    end
+    if not success then
+      local message = string.format('%s failed with exit code %d: %s', failure.hook, failure.exit_code, failure.command)
+      log.error(message)
+      notify.critical(message)
+    end
+    finish_start()
+  end))
 end
 
 -- Full container creation (fully async version)
 function M._create_container_full_async(config, callback)
   local docker = require('container.docker.init')
 
+  -- Images built from a Dockerfile already exist locally
+  if config.built_image then
+    start_progress(3, 6, 'Step 3a: ✓ Using built image: ' .. config.built_image)
+    M._create_container_direct(config, callback)
+    return
+  end
+
   -- Step 1: Check image existence
-  notify.progress('start', 3, 6, 'Step 3a: Checking if image exists locally...')
+  start_progress(3, 6, 'Step 3a: Checking if image exists locally...')
+  local workspace_root = state.workspace_root
+  local run = active_start()
   docker.check_image_exists_async(config.image, function(exists, image_id)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
+        callback(nil, 'Cancelled')
+        return
+      end
       if exists then
-        notify.progress('start', 3, 6, 'Step 3a: ✓ Image found locally: ' .. config.image)
+        start_progress(3, 6, 'Step 3a: ✓ Image found locally: ' .. config.image)
         -- Image exists, create container directly
         M._create_container_direct(config, callback)
       else
         notify.status('Image not found locally, pulling: ' .. config.image, 'warn')
-        -- Pull image then create container
-        M._pull_and_create_container(config, callback)
+        -- Pull image then create container, logging in first when the registry setting covers it
+        require('container.registry').ensure_login(config.image, in_workspace(workspace_root, function()
+          if pipeline.is_cancelled(run) then
+            callback(nil, 'Cancelled')
+            return
+          end
+          M._pull_and_create_container(config, callback)
+        end))
       end
-    end)
+    end))
   end)
 end
 
 -- Create container after image pull
-function M._pull_and_create_container(config, callback)
+-- A pull rejected for lack of credentials asks for a registry login and then retries the pull and create only.
+-- @param logged_in boolean|nil: a login was done for this start already (the retry fails for good)
+function M._pull_and_create_container(config, callback, logged_in)
   local docker = require('container.docker.init')
+  local registry = require('container.registry')
 
   notify.container('Step 3b: Pulling image (this may take a while)...', 'info')
   notify.status('Image: ' .. config.image, 'info')
@@ -536,24 +1696,46 @@ function M._pull_and_create_container(config, callback)
 
   local start_time = vim.fn.reltime()
   local progress_count = 0
+  local workspace_root = state.workspace_root
+  local run = active_start()
+
+  -- Follow the pull in the build window, and the layers in the progress sink (ui.progress)
+  local build_window = require('container.ui.build_progress')
+  local use_window = build_window.enabled()
+  if use_window then
+    build_window.start('Pulling ' .. config.image)
+  end
+  local pull_progress = require('container.ui.progress')
+  local progress_token = pull_progress.begin('Pulling ' .. config.image)
+  local layers = {}
 
-  local job_id = docker.pull_image_async(config.image, function(progress)
+  local job_id = docker.pull_image_async(config.image, in_workspace(workspace_root, function(progress)
     progress_count = progress_count + 1
-    local elapsed = vim.fn.reltimestr(vim.fn.reltime(start_time))
-    -- Use progress consolidation to reduce message spam
-    notify.progress('pull', string.format('[%ss] %s', elapsed, progress), {
-      consolidate_rapid = true,
-      consolidate_threshold = 2000, -- Only show progress every 2 seconds
-    })
+    if use_window then
+      build_window.handle_line((progress:gsub('^%s*%[std%a+%] ', '')))
+    end
+    local stage, percentage = pull_progress.pull_stage(progress, layers)
+    if stage then
+      pull_progress.report(progress_token, stage, percentage)
+      emit_event('ContainerBuildProgress', { kind = 'pull', stage = stage, percentage = percentage })
+    end
 
     -- Confirm that progress is visible
     if progress_count == 1 then
       notify.status('Docker pull output started - progress tracking is working', 'info')
     end
-  end, function(success, result)
-    vim.schedule(function()
+  end), function(success, result)
+    vim.schedule(in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
+        pull_progress.cancel(progress_token)
+        callback(nil, 'Cancelled')
+        return
+      end
       local elapsed = vim.fn.reltimestr(vim.fn.reltime(start_time))
-      notify.clear_progress('pull') -- Clear pull progress messages
+      if use_window then
+        build_window.finish(success)
+      end
+      pull_progress.finish(progress_token, success, not success and result and (result.stderr or result.error) or nil)
 
       log.info('Pull completed with status: %s in %s', tostring(success), elapsed)
 
@@ -562,16 +1744,26 @@ function M._pull_and_create_container(config, callback)
         notify.status('Now proceeding to create container...', 'info')
 
         -- Trigger ContainerBuilt event after successful pull
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerBuilt',
-          data = {
-            container_name = config.name or 'unknown',
-            image = config.image,
-          },
+        emit_event('ContainerBuilt', {
+          container_name = config.name or 'unknown',
+          image = config.image,
         })
 
         -- Image pull successful, create container
         M._create_container_direct(config, callback)
+      elseif not logged_in and result and registry.is_auth_error(result.stderr or result.error) then
+        log.warn('Image pull of %s was denied: %s', config.image, result.stderr or result.error)
+        registry.handle_auth_failure(config.image, in_workspace(workspace_root, function(ok)
+          if pipeline.is_cancelled(run) then
+            callback(nil, 'Cancelled')
+          elseif ok then
+            notify.status('Logged in, pulling again: ' .. config.image, 'info')
+            build_window.close()
+            M._pull_and_create_container(config, callback, true)
+          else
+            callback(nil, 'Failed to pull image: credentials required for ' .. registry.get_registry(config.image))
+          end
+        end))
       else
         notify.critical('Image pull failed')
         log.error('Image pull failed for %s', config.image)
@@ -598,22 +1790,24 @@ function M._pull_and_create_container(config, callback)
         notify.status('Troubleshooting: Check network, verify image name: ' .. config.image, 'warn')
         callback(nil, 'Failed to pull image: ' .. (result and result.stderr or result and result.error or 'unknown'))
       end
-    end)
+    end))
   end)
 
   if job_id and job_id > 0 then
+    pipeline.track(config.workspace_root, job_id)
     notify.status('Pull job started successfully (ID: ' .. job_id .. ')', 'info')
     log.debug('Docker pull job started with ID: %d', job_id)
 
     -- Check progress after 30 seconds
     vim.defer_fn(function()
-      if progress_count == 0 then
+      if progress_count == 0 and not pipeline.is_cancelled(run) then
         notify.status('Warning: No progress received after 30 seconds', 'warn')
         notify.status('This may indicate a Docker or network issue', 'warn')
         log.warn('No pull progress received after 30 seconds for image: %s', config.image)
       end
     end, 30000)
   else
+    pull_progress.finish(progress_token, false, 'Failed to start Docker pull job')
     notify.critical('Failed to start pull job')
     log.error('Failed to start Docker pull job, job_id: %s', tostring(job_id))
     callback(nil, 'Failed to start Docker pull job')
@@ -623,25 +1817,165 @@ end
 -- Direct container creation with conflict handling
 function M._create_container_direct(config, callback)
   local docker = require('container.docker.init')
+  local workspace_root = state.workspace_root
+  local run = active_start()
+
+  -- Install devcontainer features into a derived image before creating the container
+  if config.features and not vim.tbl_isempty(config.features) and not config.features_checked then
+    start_progress(3, 6, 'Step 3b: Installing devcontainer features...')
+    emit_event('ContainerBuildStarted', { image = config.image, features = config.features })
+    docker.build_features_image(config, function(line)
+      log.debug('Features build: %s', line)
+    end, function(success, result)
+      vim.schedule(in_workspace(workspace_root, function()
+        if pipeline.is_cancelled(run) then
+          callback(nil, 'Cancelled')
+          return
+        end
+        if not success then
+          emit_event('ContainerBuildFailed', {
+            image = config.image,
+            features = config.features,
+            error = result and result.stderr or 'unknown',
+          })
+          notify.critical('Failed to install devcontainer features')
+          callback(nil, 'Failed to install features: ' .. (result and result.stderr or 'unknown'))
+          return
+        end
+        -- Done once, also when no features image was needed (every feature disabled)
+        config.features_checked = true
+        M._create_container_direct(config, callback)
+      end))
+    end)
+    return
+  end
+
+  local uid = require('container.docker.uid')
+  local run_image = config.features_image or config.built_image or config.image
+
+  -- Fall back to the image's default user when a configured user does not exist in the image
+  if not config.users_checked then
+    config.users_checked = true
+    local keys = vim.tbl_filter(function(key)
+      return config[key] ~= nil
+    end, { 'container_user', 'remote_user' })
+    local function check_user(index)
+      local key = keys[index]
+      if not key then
+        M._create_container_direct(config, callback)
+        return
+      end
+      local user = config[key]
+      uid.user_exists_in_image_async(run_image, user, in_workspace(workspace_root, function(exists)
+        if pipeline.is_cancelled(run) then
+          callback(nil, 'Cancelled')
+          return
+        end
+        if exists == false then
+          log.warn('User %s does not exist in image %s, using the image default user', user, run_image)
+          notify.status(string.format('User "%s" not found in image, using its default user', user), 'warn')
+          config[key] = nil
+        end
+        check_user(index + 1)
+      end))
+    end
+    check_user(1)
+    return
+  end
+
+  -- Remap the remote user's UID/GID to the host user so bind-mounted files keep host ownership
+  if not config.uid_image and uid.should_update(config) then
+    start_progress(3, 6, 'Step 3b: Updating remote user UID...')
+    uid.build_image(config, run_image, function(line)
+      log.debug('UID update: %s', line)
+    end, function(success, result)
+      vim.schedule(in_workspace(workspace_root, function()
+        if pipeline.is_cancelled(run) then
+          callback(nil, 'Cancelled')
+          return
+        end
+        if not success then
+          log.warn('Failed to update remote user UID: %s', result and result.stderr or 'unknown')
+          notify.status('Could not update remote user UID, files may be owned by another user', 'warn')
+          config.uid_image = run_image
+        end
+        M._create_container_direct(config, callback)
+      end))
+    end)
+    return
+  end
+
+  -- hostRequirements.gpu: leave out the GPU flag when the host cannot provide GPUs instead of failing the create
+  if docker.wants_gpu(config) and config.gpu_available == nil then
+    docker.check_gpu_support_async(function(available)
+      vim.schedule(in_workspace(workspace_root, function()
+        if pipeline.is_cancelled(run) then
+          callback(nil, 'Cancelled')
+          return
+        end
+        config.gpu_available = available
+        if not available and config.host_requirements.gpu == 'optional' then
+          log.info('No GPU support found, starting without GPUs (hostRequirements.gpu is optional)')
+        elseif not available then
+          log.warn('hostRequirements.gpu is set but no GPU support was found (nvidia runtime or CDI spec)')
+          notify.status('GPU requested but NVIDIA Container Toolkit not found, starting without GPUs', 'warn')
+        end
+        M._create_container_direct(config, callback)
+      end))
+    end)
+    return
+  end
 
-  notify.progress('start', 3, 6, 'Step 3c: Creating container...')
+  -- cache_go_modules: mount shared volumes at the module and build caches of the Go in the image
+  if not config.go_caches_checked and require('container.go_cache').enabled() then
+    config.go_caches_checked = true
+    start_progress(3, 6, 'Step 3b: Preparing Go cache volumes...')
+    local go_cache = require('container.go_cache')
+    go_cache.prepare_async(config, config.uid_image or run_image, in_workspace(workspace_root, function()
+      if pipeline.is_cancelled(run) then
+        callback(nil, 'Cancelled')
+        return
+      end
+      M._create_container_direct(config, callback)
+    end))
+    return
+  end
+
+  -- Create missing named volumes and drop bind mounts whose host path is gone
+  if config.mounts and not config.mounts_checked then
+    config.mounts_checked = true
+    local skipped
+    config.mounts, skipped = docker.prepare_mounts(config.mounts)
+    local reason = require('container.docker.runtime').is_remote() and 'not available on a remote Docker host'
+      or 'host path does not exist'
+    for _, mount in ipairs(skipped) do
+      notify.status(string.format('Skipping mount %s: %s', mount.source, reason), 'warn')
+    end
+  end
+
+  start_progress(3, 6, 'Step 3c: Creating container...')
 
   -- First attempt to create the container
-  docker.create_container_async(config, function(container_id, error_msg)
+  docker.create_container_async(config, in_workspace(workspace_root, function(container_id, error_msg)
+    -- The caller removes a container created by a cancelled start
+    if pipeline.is_cancelled(run) then
+      callback(container_id, error_msg)
+      return
+    end
     if container_id then
-      notify.progress('start', 3, 6, 'Step 3c: ✓ Container created successfully: ' .. container_id:sub(1, 12))
+      start_progress(3, 6, 'Step 3c: ✓ Container created successfully: ' .. container_id:sub(1, 12))
       log.info('Container created successfully: %s', container_id)
       callback(container_id, error_msg)
     else
       -- Check if error is due to name conflict
       if error_msg and error_msg:match('already in use') then
         log.warn('Container name conflict detected, attempting to handle existing container')
-        notify.progress('start', 3, 6, 'Step 3c: Name conflict detected, checking existing container...')
+        start_progress(3, 6, 'Step 3c: Name conflict detected, checking existing container...')
 
         -- Try to find and reuse the existing container
-        local expected_name = docker.generate_container_name(config)
+        local expected_name = docker.resolve_container_name(config)
         M._list_containers_with_fallback(expected_name, function(existing_containers)
-          vim.schedule(function()
+          vim.schedule(in_workspace(workspace_root, function()
             if #existing_containers > 0 then
               local existing_container = existing_containers[1]
               log.info(
@@ -649,12 +1983,7 @@ function M._create_container_direct(config, callback)
                 existing_container.id,
                 existing_container.status
               )
-              notify.progress(
-                'start',
-                3,
-                6,
-                'Step 3c: ✓ Using existing container: ' .. existing_container.id:sub(1, 12)
-              )
+              start_progress(3, 6, 'Step 3c: ✓ Using existing container: ' .. existing_container.id:sub(1, 12))
 
               -- Return the existing container instead of creating a new one
               callback(existing_container.id, nil)
@@ -664,7 +1993,7 @@ function M._create_container_direct(config, callback)
               log.error('Container creation failed: %s', error_msg or 'unknown')
               callback(nil, error_msg)
             end
-          end)
+          end))
         end)
       else
         -- Other creation error, propagate it
@@ -673,13 +2002,86 @@ function M._create_container_direct(config, callback)
         callback(container_id, error_msg)
       end
     end
-  end)
+  end))
+end
+
+-- Cancel the start or image build in progress (:ContainerCancel)
+-- In-flight jobs (initializeCommand, builds, pulls, docker create, lifecycle commands) are stopped and
+-- what the start left behind is cleaned up: a container it created is removed, a stopped container it
+-- started is stopped again and compose services it started are stopped. Once the container is ready
+-- only the remaining lifecycle commands are stopped.
+-- @return boolean: true when something was cancelled
+function M.cancel()
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  pipeline = pipeline or require('container.pipeline')
+
+  local run = pipeline.cancel(state.workspace_root)
+  if not run then
+    notify.status('No container start in progress', 'warn')
+    return false
+  end
+
+  notify.clear_progress('start')
+  notify.clear_progress('image_build')
+  notify.clear_progress('pull')
+  local cancel_message = run.build_only and 'Image build cancelled' or 'Container start cancelled'
+  require('container.ui.build_progress').mark_cancelled(cancel_message)
+
+  if run.ready then
+    notify.container('Remaining lifecycle commands cancelled', 'info')
+    return true
+  end
+
+  docker = docker or require('container.docker.init')
+  local container_id = state.current_container
+  local current_config = state.current_config
+  local announced = state.lifecycle.state == 'running'
+  if run.compose and current_config then
+    require('container.docker.compose').stop(current_config, nil, docker.get_stop_timeout(), function(line)
+      log.debug('compose stop: %s', line)
+    end, function() end)
+    state.current_container = nil
+  elseif run.created_container then
+    -- Removed by name: a killed `docker create` may still have created it
+    docker.remove_container_async(run.created_container, true)
+    state.current_container = nil
+  elseif run.started_container then
+    docker.stop_container_async(run.started_container)
+  end
+  clear_status_cache()
+
+  if run.was_running then
+    set_running(container_id)
+  elseif run.build_only then
+    set_container_state(run.previous_state)
+  elseif announced then
+    -- ContainerStarted was already fired, the container is gone again
+    emit_event('ContainerStopped', {
+      container_id = container_id,
+      container_name = current_config and current_config.name or 'unknown',
+    }, state.current_container and 'stopped' or 'none')
+  else
+    reset_container_state()
+  end
+  notify.container(cancel_message, 'info')
+  return true
 end
 
 -- Stop container
+-- Stopping while the container is being started cancels the start instead (see cancel()).
 function M.stop()
   log = log or require('container.utils.log')
 
+  local run = active_start()
+  if run and not run.build_only then
+    if not run.ready then
+      return M.cancel()
+    end
+    -- Remaining lifecycle commands would fail once the container is gone
+    pipeline.cancel(state.workspace_root)
+  end
+
   if not state.current_container then
     log.error('No active container')
     return false
@@ -708,36 +2110,75 @@ function M.stop()
     statusline.set_stopping_state(true, state.current_config and state.current_config.name or 'Container')
   end
 
+  -- Containers get docker.stop_timeout seconds to exit on SIGTERM before they are killed;
+  -- compose projects stop all their services with the same timeout
+  local stop_timeout = docker.get_stop_timeout()
+  local container_id = state.current_container
+  local current_config = state.current_config
+  local stop_fn = function(callback)
+    docker.stop_container_async(container_id, callback, stop_timeout)
+  end
+  local compose = require('container.docker.compose')
+  -- Compose projects stop as a whole unless shutdownAction asks for the attached service only
+  if compose.is_compose_config(current_config) and M._shutdown_action(current_config) ~= 'stopContainer' then
+    stop_fn = function(callback)
+      compose.stop(current_config, container_id, stop_timeout, function(line)
+        log.debug('compose stop: %s', line)
+      end, callback)
+    end
+  end
+
+  -- Remove dynamic port forwards before stopping the container
+  if #state.port_forwards > 0 then
+    require('container.docker.forward').stop_all(state.current_container)
+    state.port_forwards = {}
+  end
+
   -- Use async version to prevent freezing
-  docker.stop_container_async(state.current_container, function(success, error_msg)
-    vim.schedule(function()
+  local workspace_root = state.workspace_root
+  local function on_stopped(success, error_msg, result)
+    vim.schedule(in_workspace(workspace_root, function()
       -- Clear stopping state
       if statusline_ok then
         statusline.set_stopping_state(false)
       end
 
       if success then
-        notify.container('Container stopped successfully', 'info')
+        if result and result.force_killed then
+          notify.status(
+            string.format('Container did not exit within %ds and was killed (SIGKILL)', stop_timeout),
+            'warn'
+          )
+        else
+          notify.container('Container stopped successfully', 'info')
+        end
         log.info('Container stopped successfully: %s', state.current_container)
-
-        -- Trigger ContainerStopped event
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = state.current_container,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
-
-        -- Clear state after successful stop
+        -- Clear state first so handlers see the stopped container
+        local event_data = {
+          container_id = state.current_container,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }
         state.current_container = nil
         clear_status_cache()
+        -- The env file and secrets are written and loaded again by the next start
+        require('container.env_file').remove(state.current_config)
         state.current_config = nil
+        require('container.secrets').clear()
+        emit_event('ContainerStopped', event_data, 'stopped')
       else
         notify.critical('Failed to stop container: ' .. (error_msg or 'unknown'))
         log.error('Failed to stop container: %s', error_msg or 'unknown')
       end
-    end)
+    end))
+  end
+
+  -- Run the preStopCommand cleanup while the container is still up
+  require('container.lifecycle').run_pre_stop_command(container_id, current_config, function(success, failure)
+    if not success then
+      log.warn('preStopCommand failed (exit code %s): %s', failure.exit_code, failure.command)
+      notify.status('preStopCommand failed, stopping the container anyway', 'warn')
+    end
+    stop_fn(on_stopped)
   end)
 
   return true
@@ -766,28 +2207,26 @@ function M.kill()
   end
 
   log.info('Killing container: %s', state.current_container)
+  local workspace_root = state.workspace_root
   docker.kill_container(state.current_container, function(success, error_msg)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
       if success then
         notify.container('Container killed successfully', 'info')
         log.info('Container killed successfully: %s', state.current_container)
-        -- Trigger ContainerStopped event before clearing state
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = state.current_container,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
+        -- Clear state first so handlers see the stopped container
+        local event_data = {
+          container_id = state.current_container,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }
         state.current_container = nil
         clear_status_cache()
-        clear_status_cache()
         state.current_config = nil
+        emit_event('ContainerStopped', event_data, 'stopped')
       else
         notify.critical('Failed to kill container: ' .. (error_msg or 'unknown'))
         log.error('Failed to kill container: %s', error_msg or 'unknown')
       end
-    end)
+    end))
   end)
 
   return true
@@ -816,28 +2255,26 @@ function M.terminate()
   end
 
   log.info('Terminating container: %s', state.current_container)
+  local workspace_root = state.workspace_root
   docker.terminate_container(state.current_container, function(success, error_msg)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
       if success then
         notify.container('Container terminated successfully', 'info')
         log.info('Container terminated successfully: %s', state.current_container)
-        -- Trigger ContainerStopped event before clearing state
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = state.current_container,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
+        -- Clear state first so handlers see the stopped container
+        local event_data = {
+          container_id = state.current_container,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }
         state.current_container = nil
         clear_status_cache()
-        clear_status_cache()
         state.current_config = nil
+        emit_event('ContainerStopped', event_data, 'stopped')
       else
         notify.critical('Failed to terminate container: ' .. (error_msg or 'unknown'))
         log.error('Failed to terminate container: %s', error_msg or 'unknown')
       end
-    end)
+    end))
   end)
 
   return true
@@ -866,25 +2303,24 @@ function M.remove()
   end
 
   log.info('Removing container: %s', state.current_container)
+  local workspace_root = state.workspace_root
   docker.remove_container_async(state.current_container, false, function(success, error_msg)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
       if success then
         print('✓ Container removed successfully')
-        -- Trigger ContainerStopped event before clearing state
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = state.current_container,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
+        -- Clear state first so handlers see the removed container
+        local event_data = {
+          container_id = state.current_container,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }
         state.current_container = nil
         clear_status_cache()
         state.current_config = nil
+        emit_event('ContainerStopped', event_data, 'none')
       else
         print('✗ Failed to remove container: ' .. (error_msg or 'unknown'))
       end
-    end)
+    end))
   end)
 
   return true
@@ -913,25 +2349,24 @@ function M.stop_and_remove()
   end
 
   log.info('Stopping and removing container: %s', state.current_container)
+  local workspace_root = state.workspace_root
   docker.stop_and_remove_container(state.current_container, 30, function(success, error_msg)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
       if success then
         print('✓ Container stopped and removed successfully')
-        -- Trigger ContainerStopped event before clearing state
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = state.current_container,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
+        -- Clear state first so handlers see the removed container
+        local event_data = {
+          container_id = state.current_container,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }
         state.current_container = nil
         clear_status_cache()
         state.current_config = nil
+        emit_event('ContainerStopped', event_data, 'none')
       else
         print('✗ Failed to stop and remove container: ' .. (error_msg or 'unknown'))
       end
-    end)
+    end))
   end)
 
   return true
@@ -945,6 +2380,18 @@ function M.terminal(opts)
   return terminal.terminal(opts)
 end
 
+-- Open the container user's login shell
+function M.shell(opts)
+  local terminal = require('container.terminal')
+  return terminal.shell(opts)
+end
+
+-- Run a full-screen program in the container in a terminal buffer (see container.terminal.exec_interactive)
+function M.exec_interactive(cmd, opts)
+  local terminal = require('container.terminal')
+  return terminal.exec_interactive(cmd, opts)
+end
+
 -- Create new terminal session
 function M.terminal_new(name)
   local terminal = require('container.terminal')
@@ -1000,11 +2447,18 @@ function M.terminal_cleanup_history(days)
 end
 
 -- Attach to existing container
+-- Without a name, re-attach to the container labeled with the current workspace
 function M.attach(container_name)
   log = log or require('container.utils.log')
   docker = docker or require('container.docker')
 
-  docker.attach_to_container(container_name, function(success, error_msg)
+  if not container_name or container_name == '' then
+    M._try_reconnect_existing_container({ manual = true })
+    return
+  end
+
+  local workspace_root = state.workspace_root
+  docker.attach_to_container(container_name, in_workspace(workspace_root, function(success, error_msg)
     if success then
       state.current_container = container_name
       clear_status_cache()
@@ -1012,18 +2466,19 @@ function M.attach(container_name)
       notify.container('Attached to container: ' .. container_name)
 
       -- Trigger ContainerOpened event for attach
-      vim.api.nvim_exec_autocmds('User', {
-        pattern = 'ContainerOpened',
-        data = {
-          container_name = container_name,
-          attached = true,
-        },
+      emit_event('ContainerOpened', {
+        container_name = container_name,
+        attached = true,
       })
+      emit_event('ContainerAttached', {
+        container_id = container_name,
+        container_name = container_name,
+      }, 'running')
     else
       log.error('Failed to attach to container: %s', error_msg)
       notify.critical('Failed to attach: ' .. error_msg)
     end
-  end)
+  end))
 end
 
 -- Start a specific container by name
@@ -1031,7 +2486,8 @@ function M.start_container(container_name)
   log = log or require('container.utils.log')
   docker = docker or require('container.docker')
 
-  docker.start_existing_container(container_name, function(success, error_msg)
+  local workspace_root = state.workspace_root
+  docker.start_existing_container(container_name, in_workspace(workspace_root, function(success, error_msg)
     if success then
       log.info('Started container: %s', container_name)
       notify.container('Started container: ' .. container_name)
@@ -1040,18 +2496,15 @@ function M.start_container(container_name)
       log.info('LSP path resolution will be handled by strategy system')
 
       -- Trigger ContainerStarted event
-      vim.api.nvim_exec_autocmds('User', {
-        pattern = 'ContainerStarted',
-        data = {
-          container_id = container_name,
-          container_name = container_name,
-        },
-      })
+      emit_event('ContainerStarted', {
+        container_id = container_name,
+        container_name = container_name,
+      }, container_name == state.current_container and 'running' or nil)
     else
       log.error('Failed to start container: %s', error_msg)
       notify.critical('Failed to start: ' .. error_msg)
     end
-  end)
+  end))
 end
 
 -- Stop a specific container by name
@@ -1066,8 +2519,9 @@ function M.stop_container(container_name)
     statusline.set_stopping_state(true, container_name)
   end
 
+  local workspace_root = state.workspace_root
   docker.stop_existing_container(container_name, function(success, error_msg)
-    vim.schedule(function()
+    vim.schedule(in_workspace(workspace_root, function()
       -- Clear stopping state
       if statusline_ok then
         statusline.set_stopping_state(false)
@@ -1078,121 +2532,411 @@ function M.stop_container(container_name)
         notify.container('Stopped container: ' .. container_name)
 
         -- Trigger ContainerStopped event
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStopped',
-          data = {
-            container_id = container_name,
-            container_name = container_name,
-          },
-        })
+        emit_event('ContainerStopped', {
+          container_id = container_name,
+          container_name = container_name,
+        }, container_name == state.current_container and 'stopped' or nil)
       else
         log.error('Failed to stop container: %s', error_msg)
         notify.critical('Failed to stop: ' .. error_msg)
       end
+    end))
+  end)
+end
+
+-- Remove a specific (stopped) container by name
+function M.remove_container(container_name)
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
+  notify = notify or require('container.utils.notify')
+
+  if container_name == state.current_container then
+    return M.remove()
+  end
+
+  docker.remove_container_async(container_name, false, function(success, error_msg)
+    vim.schedule(function()
+      if success then
+        log.info('Removed container: %s', container_name)
+        notify.container('Removed container: ' .. container_name)
+      else
+        log.error('Failed to remove container: %s', error_msg)
+        notify.critical('Failed to remove: ' .. (error_msg or 'unknown'))
+      end
     end)
   end)
+  return true
 end
 
--- Restart the current DevContainer
+-- Restart the current DevContainer in place
+-- `docker restart` (or `docker compose restart` of the service) keeps the container, so no image is built.
+-- Terminals and a running go test are stopped and reopened in the restarted container, postStartCommand
+-- and postAttachCommand run again, LSP clients and port forwards are set up again and ContainerRestarted fires.
 function M.restart()
   log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
 
   if not state.current_container then
     log.error('No active container to restart')
     notify.error('No active container to restart')
     return false
   end
+  if active_start() then
+    notify.warn('A start is in progress, wait for it or cancel it with :ContainerCancel')
+    return false
+  end
 
   local container_id = state.current_container
+  local workspace_root = state.workspace_root
+  local current_config = state.current_config or {}
+  local restore = M._suspend_session(container_id)
+  local restart_lsp = lsp ~= nil and next(lsp.get_state().clients or {}) ~= nil
+  if lsp then
+    lsp.stop_all()
+    lsp.clear_container_init_status(container_id)
+  end
+  -- Forwarding sidecars relay to the container address, which may change with the restart
+  if #state.port_forwards > 0 then
+    require('container.docker.forward').stop_all(container_id)
+    state.port_forwards = {}
+  end
+
+  log.info('Restarting container: %s', container_id)
   notify.container('Restarting DevContainer...', 'info')
-  log.info('Restarting current container: %s', container_id)
 
-  docker = docker or require('container.docker.init')
+  local function restart(callback)
+    local compose = require('container.docker.compose')
+    if compose.is_compose_config(current_config) then
+      compose.restart(current_config, function(line)
+        log.debug('compose restart: %s', line)
+      end, callback)
+    else
+      docker.restart_container_async(container_id, callback)
+    end
+  end
 
-  -- First stop the container
-  docker.stop_container_async(container_id, function(stop_success, stop_error)
-    vim.schedule(function()
-      if stop_success then
-        log.info('Container stopped for restart: %s', container_id)
-        notify.progress('restart', 'Step 1: ✓ Container stopped')
-
-        -- Wait a moment then start again
-        vim.defer_fn(function()
-          notify.progress('restart', 'Step 2: Starting container...')
-          docker.start_container_async(container_id, function(start_success, start_error)
-            vim.schedule(function()
-              if start_success then
-                notify.progress('restart', 'Step 2: ✓ Container started')
-                log.info('Container restarted successfully: %s', container_id)
-
-                -- LSP path resolution is now handled by the LSP strategy system
-                log.info('LSP path resolution will be handled by strategy system')
-
-                -- Trigger ContainerStarted event
-                vim.api.nvim_exec_autocmds('User', {
-                  pattern = 'ContainerStarted',
-                  data = {
-                    container_id = container_id,
-                    container_name = state.current_config and state.current_config.name or 'unknown',
-                    restarted = true,
-                  },
-                })
-
-                notify.container('DevContainer restarted successfully!', 'info')
-                notify.clear_progress('restart')
-              else
-                log.error('Failed to start container after stop: %s', start_error or 'unknown')
-                notify.critical('Failed to start container after restart: ' .. (start_error or 'unknown'))
-                notify.clear_progress('restart')
-              end
-            end)
-          end)
-        end, 1000) -- Wait 1 second between stop and start
+  restart(function(success, err)
+    vim.schedule(in_workspace(workspace_root, function()
+      if state.current_container ~= container_id then
+        return
+      end
+      clear_status_cache()
+      if not success then
+        log.error('Failed to restart container: %s', err or 'unknown')
+        notify.critical('Failed to restart container: ' .. (err or 'unknown'))
+        M._get_container_status_async(container_id, function(status)
+          vim.schedule(in_workspace(workspace_root, function()
+            if state.current_container == container_id and status ~= 'running' then
+              emit_event('ContainerStopped', { container_id = container_id }, 'stopped')
+            end
+          end))
+        end)
+        return
+      end
+
+      emit_event('ContainerRestarted', { container_id = container_id }, 'running')
+
+      local on_ready = in_workspace(workspace_root, function()
+        if state.current_container ~= container_id then
+          return
+        end
+        M._restore_session(restore, container_id)
+        if restart_lsp then
+          M.lsp_setup()
+        end
+        M._restore_port_forwards(container_id)
+        notify.container('DevContainer restarted', 'info')
+      end)
+
+      require('container.lifecycle').run(container_id, current_config, {
+        families = { 'start', 'attach' },
+        wait_for = current_config.wait_for,
+        on_ready = on_ready,
+      }, function(lifecycle_success, failure)
+        if not lifecycle_success then
+          local message =
+            string.format('%s failed with exit code %d: %s', failure.hook, failure.exit_code, failure.command)
+          log.error(message)
+          notify.critical(message)
+        end
+      end)
+    end))
+  end)
+
+  return true
+end
+
+-- Restart a specific container by name
+function M.restart_container(container_name)
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
+
+  docker.restart_container(container_name, function(success, error_msg)
+    if success then
+      log.info('Restarted container: %s', container_name)
+      notify.container('Restarted container: ' .. container_name)
+    else
+      log.error('Failed to restart container: %s', error_msg)
+      notify.critical('Failed to restart: ' .. error_msg)
+    end
+  end)
+end
+
+-- Rebuild container for a project
+function M.rebuild(project_path)
+  log = log or require('container.utils.log')
+
+  -- First stop existing container if any
+  if state.current_container then
+    M.stop()
+  end
+
+  -- Force rebuild on next open
+  notify.status('Container will be rebuilt on next open')
+
+  -- Open with force rebuild
+  M.open(project_path, { force_rebuild = true })
+end
+
+-- Rebuild the image without the cache and recreate the attached container
+-- Terminal sessions and a running go test are stopped first, then reopened (or run again) once the new container
+-- has started. Dynamic port forwards come back from the saved forwards and LSP is set up again by the start.
+-- @param opts table|nil: { prune = boolean } also removes the previous image once the rebuild left it dangling
+function M.rebuild_container(opts)
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
+  opts = opts or {}
+
+  if not state.current_container then
+    -- Nothing to recreate yet, start() removes a stopped container of this configuration itself
+    return M.start({ force_rebuild = true })
+  end
+
+  if pending_rebuild_restore then
+    pcall(vim.api.nvim_del_autocmd, pending_rebuild_restore)
+    pending_rebuild_restore = nil
+  end
+
+  local container_id = state.current_container
+  local workspace_root = state.workspace_root
+  local restore = M._suspend_session(container_id)
+
+  if lsp then
+    lsp.stop_all()
+  end
+  if #state.port_forwards > 0 then
+    require('container.docker.forward').stop_all(container_id)
+    state.port_forwards = {}
+  end
+
+  log.info('Rebuilding container: %s', container_id)
+  notify.container('Rebuilding DevContainer without cache...', 'info')
+  set_container_state('building')
+
+  docker.get_container_image_id(container_id, in_workspace(workspace_root, function(old_image)
+    local function remove(callback)
+      local compose = require('container.docker.compose')
+      if compose.is_compose_config(state.current_config) then
+        compose.down(state.current_config, function(line)
+          log.debug('compose down: %s', line)
+        end, callback)
       else
-        log.error('Failed to stop container for restart: %s', stop_error or 'unknown')
-        notify.critical('Failed to stop container for restart: ' .. (stop_error or 'unknown'))
-        notify.clear_progress('restart')
+        docker.stop_and_remove_container(container_id, nil, callback)
       end
+    end
+
+    remove(function(removed, err)
+      vim.schedule(in_workspace(workspace_root, function()
+        if not removed then
+          reset_container_state()
+          notify.critical('Failed to remove container for rebuild: ' .. (err or 'unknown'))
+          return
+        end
+        state.current_container = nil
+        clear_status_cache()
+
+        pending_rebuild_restore = vim.api.nvim_create_autocmd('User', {
+          pattern = 'ContainerStarted',
+          once = true,
+          callback = function(args)
+            pending_rebuild_restore = nil
+            if state.workspace_root ~= workspace_root then
+              return
+            end
+            M._restore_session(restore, args.data and args.data.container_id)
+            if opts.prune and old_image then
+              M._prune_replaced_image(old_image, args.data and args.data.container_id)
+            end
+          end,
+        })
+        M.start({ force_rebuild = true })
+      end))
     end)
-  end)
+  end))
+
+  return true
+end
+
+-- Stop the terminals and the go test running in a container before it is rebuilt or restarted
+-- Both run docker exec in the container and would be left with a dead process.
+-- @return table: { terminals, test } to pass to _restore_session()
+function M._suspend_session(container_id)
+  local restore = {
+    terminals = {},
+    test = require('container.test').stop(),
+  }
+
+  local session_manager = require('container.terminal.session')
+  for _, session in ipairs(session_manager.list_sessions()) do
+    if session.container_id == container_id then
+      table.insert(restore.terminals, session.name)
+      session_manager.close_session(session.name, true)
+    end
+  end
+  return restore
+end
+
+-- Reopen the terminals and the go test stopped by _suspend_session()
+function M._restore_session(restore, container_id)
+  for _, name in ipairs(restore.terminals) do
+    M.terminal({ name = name })
+  end
+  if restore.test then
+    require('container.test').run(restore.test)
+  end
+  log.info('Restored session of container %s', container_id or 'unknown')
+end
+
+-- Reconnect the terminals and LSP clients of the running container after their connections were lost
+-- Called by the heartbeat after a suspend or an unanswered ping, once the container answers again.
+-- @param container_id string
+-- @param reason string: passed in the ContainerReconnected data
+-- @return boolean: false when the container is no longer the current one
+function M._reconnect_session(container_id, reason)
+  if state.current_container ~= container_id then
+    return false
+  end
+  log.info('Reconnecting to container %s: %s', container_id, reason)
+  local workspace_root = state.workspace_root
+  M._restore_session(M._suspend_session(container_id), container_id)
 
+  local done = in_workspace(workspace_root, function()
+    emit_event('ContainerReconnected', { container_id = container_id, reason = reason })
+    notify.container('Reconnected to the container (' .. reason .. ')', 'info')
+  end)
+  if not (lsp and lsp.get_state().container_id == container_id) then
+    done()
+    return true
+  end
+  -- Clients still running are restarted, servers whose client is gone are set up again
+  lsp.restart(nil, in_workspace(workspace_root, function()
+    if state.current_container == container_id then
+      M.lsp_setup()
+    end
+    done()
+  end))
   return true
 end
 
--- Restart a specific container by name
-function M.restart_container(container_name)
-  log = log or require('container.utils.log')
-  docker = docker or require('container.docker')
+-- The heartbeat found the container no longer running
+-- @param container_id string
+-- @param status string|nil: docker status, nil when the container is gone
+function M._lost_container(container_id, status)
+  if state.current_container ~= container_id then
+    return
+  end
+  log.warn('Container %s is %s', container_id, status or 'gone')
+  notify.warn('The container is ' .. (status or 'gone'))
+  if lsp then
+    lsp.stop_all()
+  end
+  emit_event('ContainerStopped', { container_id = container_id }, 'stopped')
+end
 
-  docker.restart_container(container_name, function(success, error_msg)
-    if success then
-      log.info('Restarted container: %s', container_name)
-      notify.container('Restarted container: ' .. container_name)
-    else
-      log.error('Failed to restart container: %s', error_msg)
-      notify.critical('Failed to restart: ' .. error_msg)
+-- Remove the image of the container replaced by a rebuild when nothing uses it anymore
+function M._prune_replaced_image(old_image, container_id)
+  docker.get_container_image_id(container_id, function(new_image)
+    if not new_image or new_image == old_image then
+      return
     end
+    docker.remove_dangling_image(old_image, function(removed, err)
+      vim.schedule(function()
+        if removed then
+          notify.container('Removed previous image ' .. old_image:gsub('^sha256:', ''):sub(1, 12))
+        else
+          log.info('Kept previous image: %s', err or 'unknown')
+        end
+      end)
+    end)
   end)
 end
 
--- Rebuild container for a project
-function M.rebuild(project_path)
-  log = log or require('container.utils.log')
+-- Get a structured snapshot of the container state
+-- No Docker calls are made, so this is cheap enough for statuslines
+-- @return table: { state, name, image, uptime, started_at, container_id, service, progress, waiting_for }
+function M.status()
+  local current_config = state.current_config or {}
+  local container_state = state.lifecycle.state
+  local started_at = container_state == 'running' and state.lifecycle.started_at or nil
+  local run = active_start()
 
-  -- First stop existing container if any
-  if state.current_container then
-    M.stop()
-  end
+  return {
+    state = container_state,
+    name = current_config.name,
+    image = current_config.uid_image
+      or current_config.features_image
+      or current_config.built_image
+      or current_config.prepared_image
+      or current_config.image,
+    uptime = started_at and math.max(0, os.difftime(os.time(), started_at)) or nil,
+    started_at = started_at,
+    container_id = state.current_container,
+    service = current_config.service,
+    workspace_root = state.workspace_root,
+    -- Current step of a start in progress: { step, total, message }
+    progress = run and run.progress,
+    -- Lifecycle command (waitFor) the start waits for before the container is ready
+    waiting_for = run and not run.ready and run.waiting_for or nil,
+    -- Running or last :ContainerTest run: { state, passed, failed, skipped }
+    tests = require('container.test').summary(),
+  }
+end
 
-  -- Force rebuild on next open
-  notify.status('Container will be rebuilt on next open')
+-- Subscribe to a plugin event from Lua
+-- Events: opened, build_started, build_progress, build_failed, built, started, restarted, attached, detached,
+-- reconnected, stopped, closed, state_changed, or '*' for all of them. The callback receives the data of the User
+-- autocmd and the event name.
+-- @return function: unsubscribes
+function M.on(event, callback)
+  return require('container.events').on(event, callback)
+end
 
-  -- Open with force rebuild
-  M.open(project_path, { force_rebuild = true })
+-- Lifecycle command (waitFor) a start of the current workspace is waiting for
+-- Until it has finished the container is not ready: LSP servers are started afterwards.
+-- @return string|nil: e.g. 'updateContentCommand', nil when no start is waiting
+function M.waiting_for()
+  local run = active_start()
+  return run and not run.ready and run.waiting_for or nil
 end
 
--- Get container status
-function M.status()
+-- Format seconds as a compact duration (e.g. 45s, 12m, 3h05m, 2d04h)
+function M._format_uptime(seconds)
+  if not seconds then
+    return nil
+  end
+  seconds = math.floor(seconds)
+  if seconds < 60 then
+    return seconds .. 's'
+  elseif seconds < 3600 then
+    return math.floor(seconds / 60) .. 'm'
+  elseif seconds < 86400 then
+    return string.format('%dh%02dm', math.floor(seconds / 3600), math.floor(seconds % 3600 / 60))
+  end
+  return string.format('%dd%02dh', math.floor(seconds / 86400), math.floor(seconds % 86400 / 3600))
+end
+
+-- Show container status details
+function M.show_status()
   log = log or require('container.utils.log')
 
   if not state.current_container then
@@ -1205,12 +2949,29 @@ function M.status()
   local status = docker.get_container_status(state.current_container)
   local info = docker.get_container_info(state.current_container)
 
+  local summary = M.status()
+
   print('=== DevContainer Status ===')
   print('Container ID: ' .. state.current_container)
   print('Status: ' .. (status or 'unknown'))
+  if summary.workspace_root then
+    print('Workspace: ' .. summary.workspace_root)
+  end
+  if summary.service then
+    print('Service: ' .. summary.service)
+  end
+  if summary.uptime then
+    print('Uptime: ' .. M._format_uptime(summary.uptime))
+  end
+  if summary.waiting_for then
+    print('Not ready: waiting for ' .. summary.waiting_for)
+  end
 
   if info then
     print('Image: ' .. (info.Config.Image or 'unknown'))
+    if state.current_config and state.current_config.image_cache_key then
+      print('Image cache key: ' .. state.current_config.image_cache_key)
+    end
     print('Created: ' .. (info.Created or 'unknown'))
 
     -- Show configured ports from devcontainer.json
@@ -1275,10 +3036,12 @@ function M.status()
     info = info,
     configured_ports = state.current_config and state.current_config.normalized_ports or {},
     project_id = state.current_config and state.current_config.project_id or nil,
+    image_cache_key = state.current_config and state.current_config.image_cache_key or nil,
   }
 end
 
--- Display logs
+-- Display logs in the logs buffer
+-- @param opts table|nil: { service, since, tail, follow } (see container.logs)
 function M.logs(opts)
   log = log or require('container.utils.log')
 
@@ -1287,10 +3050,7 @@ function M.logs(opts)
     return false
   end
 
-  docker = docker or require('container.docker')
-  opts = opts or { tail = 100 }
-
-  return docker.get_logs(state.current_container, opts)
+  return require('container.logs').show(opts or { follow = true })
 end
 
 -- Get current configuration
@@ -1298,11 +3058,49 @@ function M.get_config()
   return state.current_config
 end
 
+-- Show the resolved devcontainer configuration as JSON in a read-only buffer (:ContainerConfig)
+function M.show_resolved_config()
+  return require('container.config_view').show(state.current_config, state.current_container)
+end
+
+-- Remove the Go cache volumes of the current workspace (cache_go_modules)
+function M.clear_go_cache()
+  if not state.current_config then
+    notify.warn('No devcontainer configuration loaded. Run :ContainerOpen first')
+    return false
+  end
+  require('container.go_cache').clear(state.current_config, function(removed, failed)
+    if #removed == 0 and #failed == 0 then
+      notify.status('No Go cache volumes to remove', 'info')
+      return
+    end
+    if #removed > 0 then
+      notify.success(string.format('Removed Go cache volumes: %s', table.concat(removed, ', ')))
+    end
+    if #failed > 0 then
+      notify.warn(string.format('Could not remove %s (still used by a container?)', table.concat(failed, ', ')))
+    end
+  end)
+  return true
+end
+
+-- Get the customizations of the current devcontainer.json
+-- @param tool string|nil: tool name (e.g. 'vscode' or 'container.nvim'); the whole tree when omitted
+function M.get_customizations(tool)
+  return require('container.customizations').get(state.current_config, tool)
+end
+
 -- Get current container ID
 function M.get_container_id()
   return state.current_container
 end
 
+-- Expand the variables of a working directory given for an exec session (see environment.expand_path)
+local function expand_workdir(path)
+  local environment = require('container.environment')
+  return environment.expand_path and environment.expand_path(path, state.current_config) or path
+end
+
 -- Execute command in container
 function M.execute(command, opts)
   log = log or require('container.utils.log')
@@ -1315,13 +3113,27 @@ function M.execute(command, opts)
 
   opts = opts or {}
 
+  -- Another service of a compose devcontainer runs the command with its own working directory and user
+  local container_id = state.current_container
+  if opts.service then
+    local compose = require('container.docker.compose')
+    if not compose.is_compose_config(state.current_config) then
+      return nil, 'Services can only be selected for Docker Compose devcontainers'
+    end
+    container_id = compose.find_service_container(state.current_config, opts.service)
+    if not container_id then
+      return nil, string.format('No running container for service "%s"', opts.service)
+    end
+  end
+
   -- Set default working directory from config if not specified
-  if not opts.workdir and state.current_config and state.current_config.workspace_folder then
+  if not opts.service and not opts.workdir and state.current_config and state.current_config.workspace_folder then
     opts.workdir = state.current_config.workspace_folder
   end
+  opts.workdir = expand_workdir(opts.workdir)
 
   -- Set default user from config if not specified
-  if not opts.user and state.current_config and state.current_config.remote_user then
+  if not opts.service and not opts.user and state.current_config and state.current_config.remote_user then
     opts.user = state.current_config.remote_user
   end
 
@@ -1330,7 +3142,7 @@ function M.execute(command, opts)
   log.info('Executing command in container: %s', command_str)
 
   -- Execute command
-  local result = docker.execute_command(state.current_container, command, opts)
+  local result = docker.execute_command(container_id, command, opts)
 
   -- Handle result based on mode
   if opts.mode == 'async' or opts.mode == 'fire_and_forget' then
@@ -1364,6 +3176,7 @@ function M.execute_stream(command, opts)
   if not opts.workdir and state.current_config and state.current_config.workspace_folder then
     opts.workdir = state.current_config.workspace_folder
   end
+  opts.workdir = expand_workdir(opts.workdir)
 
   -- Set default user from config if not specified
   if not opts.user and state.current_config and state.current_config.remote_user then
@@ -1378,6 +3191,223 @@ function M.execute_stream(command, opts)
   return docker.execute_command_stream(state.current_container, command, opts)
 end
 
+-- Build docker exec arguments for M.exec
+-- Defaults come from devcontainer.json (remoteUser, workspaceFolder, containerEnv/remoteEnv)
+function M._build_exec_args(container_id, cmd, opts)
+  local environment = require('container.environment')
+  local current_config = state.current_config or {}
+  local args = { 'exec', '-i' }
+
+  local user = opts.user or current_config.remote_user
+  if user then
+    vim.list_extend(args, { '-u', user })
+  end
+
+  vim.list_extend(args, require('container.env_file').args(current_config))
+  vim.list_extend(args, require('container.secrets').exec_args())
+  local env = vim.tbl_extend('force', environment.get_exec_environment(current_config), opts.env or {})
+  local keys = vim.tbl_keys(env)
+  table.sort(keys)
+  for _, key in ipairs(keys) do
+    vim.list_extend(args, { '-e', key .. '=' .. tostring(env[key]) })
+  end
+
+  vim.list_extend(args, { '-w', expand_workdir(opts.cwd or current_config.workspace_folder or '/workspace') })
+  table.insert(args, container_id)
+
+  if type(cmd) == 'string' then
+    vim.list_extend(args, { '/bin/sh', '-c', cmd })
+  else
+    vim.list_extend(args, cmd)
+  end
+
+  return args
+end
+
+-- Execute a command in the running container
+-- @param cmd string|table: shell command string or argv list
+-- @param opts table|nil: { cwd, env, user, timeout (ms, sync only), callback }
+-- @return table|nil: { code, stdout, stderr } in sync mode, job id when callback is given
+-- @return string|nil: error message when the command could not be started
+function M.exec(cmd, opts)
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
+  opts = opts or {}
+
+  local container_id = M.get_container_id()
+  if not container_id then
+    local err = 'No running container. Start one with :ContainerStart or attach with :ContainerAttach'
+    log.error('exec: %s', err)
+    return nil, err
+  end
+
+  if type(cmd) == 'string' then
+    if vim.trim(cmd) == '' then
+      return nil, 'exec: command must not be empty'
+    end
+  elseif type(cmd) ~= 'table' or cmd[1] == nil then
+    return nil, 'exec: command must be a string or a non-empty argv table'
+  end
+
+  local args = M._build_exec_args(container_id, cmd, opts)
+  log.info('Executing command in container: %s', type(cmd) == 'string' and cmd or table.concat(cmd, ' '))
+
+  local function to_result(result)
+    return { code = result.code, stdout = result.stdout or '', stderr = result.stderr or '' }
+  end
+
+  if opts.callback then
+    local job_id = docker.run_docker_command_async(args, { keep_empty = true }, function(result)
+      opts.callback(to_result(result))
+    end)
+    if not job_id or job_id <= 0 then
+      return nil, 'Failed to start docker exec'
+    end
+    return job_id
+  end
+
+  local completed
+  local job_id = docker.run_docker_command_async(args, { keep_empty = true }, function(result)
+    completed = result
+  end)
+
+  local timeout = opts.timeout or 30000
+  if not vim.wait(timeout, function()
+    return completed ~= nil
+  end, 50) then
+    -- Do not leave the command running in the background
+    if job_id and job_id > 0 then
+      vim.fn.jobstop(job_id)
+    end
+    return nil, string.format('Command timed out after %dms', timeout)
+  end
+
+  return to_result(completed)
+end
+
+-- Run buffer lines as one shell script in the container, streaming the output to a buffer
+-- @param opts table|nil: { bufnr, line1, line2 } (default: the last visual selection) or { lines }
+-- @return boolean: true when the script was started
+function M.exec_selection(opts)
+  opts = opts or {}
+  local exec_selection = require('container.exec_selection')
+  return exec_selection.run(opts.lines or exec_selection.get_lines(opts))
+end
+
+-- Run the file of the current buffer with the command of its filetype (run_file.commands), streaming the output
+-- @param opts table|nil: { file, filetype, args } (default: the current buffer, no extra arguments)
+-- @return boolean: true when the command was started
+function M.run_file(opts)
+  return require('container.run_file').run(opts)
+end
+
+-- Run a named task of the tasks setting in the container, streaming the output
+-- @param name string|nil: task name (default: pick one)
+-- @param args table|nil: extra arguments appended to the command
+-- @return boolean: true when the task was started
+function M.task(name, args)
+  return require('container.task').run(name, args)
+end
+
+-- Run golangci-lint in the container and load the issues into the quickfix list
+-- @param args table|nil: arguments appended to lint.args, e.g. packages
+-- @return boolean: true when the linter was started
+function M.lint(args)
+  return require('container.lint').run(args)
+end
+
+-- Prefix marking the container side of copy()
+local CONTAINER_PATH_PREFIX = 'container:'
+
+-- Copy files or directories between the host and the container (:ContainerCopy)
+-- The container side is prefixed with "container:"; relative container paths resolve against the
+-- workspace folder, relative host paths against the current directory. Directories are copied
+-- recursively and file modes and ownership are preserved.
+-- @param src string: e.g. "container:dist/app.tar.gz" or "./config.yaml"
+-- @param dest string
+-- @param callback function|nil: function(success, error_msg), also called when the container is not running
+-- @return boolean, string|nil: false and the error when the arguments or host paths are invalid
+function M.copy(src, dest, callback)
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker')
+  notify = notify or require('container.utils.notify')
+
+  local function fail(message)
+    log.error(message)
+    notify.error(message)
+    if callback then
+      callback(false, message)
+    end
+    return false, message
+  end
+
+  if not src or not dest or src == '' or dest == '' then
+    return fail('Usage: :ContainerCopy {src} {dest}')
+  end
+
+  local from_container = vim.startswith(src, CONTAINER_PATH_PREFIX)
+  local to_container = vim.startswith(dest, CONTAINER_PATH_PREFIX)
+  if from_container == to_container then
+    return fail('Copy needs exactly one side prefixed with "container:", e.g. :ContainerCopy container:/tmp/out.log .')
+  end
+
+  if not state.current_container then
+    return fail('No active container')
+  end
+  local container_id = state.current_container
+  local workspace_root = state.workspace_root
+
+  local _, container_root = require('container.parser').workspace_roots(state.current_config)
+  local function container_path(path)
+    path = path:sub(#CONTAINER_PATH_PREFIX + 1)
+    if path == '' or not vim.startswith(path, '/') then
+      path = container_root .. (path == '' and '' or '/' .. path)
+    end
+    return container_id .. ':' .. path
+  end
+
+  local source, target
+  if from_container then
+    target = vim.fn.fnamemodify(dest, ':p')
+    local parent = vim.fn.isdirectory(target) == 1 and target or vim.fn.fnamemodify(target, ':h')
+    if vim.fn.isdirectory(parent) == 0 then
+      return fail('Host directory does not exist: ' .. parent)
+    end
+    source = container_path(src)
+  else
+    source = vim.fn.fnamemodify(src, ':p'):gsub('/$', '')
+    if vim.fn.isdirectory(source) == 0 and vim.fn.filereadable(source) == 0 then
+      return fail('Host path does not exist: ' .. src)
+    end
+    target = container_path(dest)
+  end
+
+  M._get_container_status_async(container_id, function(status)
+    vim.schedule(in_workspace(workspace_root, function()
+      if status ~= 'running' then
+        fail('Container is not running, start it with :ContainerStart first')
+        return
+      end
+
+      log.info('Copying %s to %s', source, target)
+      docker.copy_async(source, target, function(success, error_msg)
+        vim.schedule(function()
+          if success then
+            notify.status(string.format('Copied %s to %s', src, dest))
+          else
+            log.error('Failed to copy %s to %s: %s', source, target, error_msg or 'unknown')
+            notify.error('Copy failed: ' .. (error_msg or 'unknown'))
+          end
+          if callback then
+            callback(success, error_msg)
+          end
+        end)
+      end)
+    end))
+  end)
+  return true
+end
+
 -- Build complex command with environment setup
 function M.build_command(base_command, opts)
   docker = docker or require('container.docker')
@@ -1437,25 +3467,283 @@ function M.run_test(test_cmd, opts)
   end
 end
 
--- Reset plugin state
-function M.reset()
-  log = log or require('container.utils.log')
-
-  -- Trigger ContainerClosed event before clearing state
-  if state.current_container or state.current_config then
-    vim.api.nvim_exec_autocmds('User', {
-      pattern = 'ContainerClosed',
-      data = {
-        container_id = state.current_container,
-        container_name = state.current_config and state.current_config.name or 'unknown',
-      },
-    })
-  end
-
-  state.current_container = nil
-  clear_status_cache()
-  state.current_config = nil
-  log.info('Plugin state reset')
+-- Reset plugin state
+function M.reset()
+  log = log or require('container.utils.log')
+
+  local event_data = (state.current_container or state.current_config)
+    and {
+      container_id = state.current_container,
+      container_name = state.current_config and state.current_config.name or 'unknown',
+    }
+
+  state.current_container = nil
+  clear_status_cache()
+  state.current_config = nil
+  require('container.watch').unwatch()
+
+  -- Trigger ContainerClosed event after clearing state
+  if event_data then
+    emit_event('ContainerClosed', event_data, 'none')
+  end
+  log.info('Plugin state reset')
+end
+
+-- Forward a container port to the host while the container is running
+-- @param container_port number: port inside the container
+-- @param host_port number|nil: host port (defaults to container_port, auto-incremented when in use)
+-- @param callback function|nil: called with (forward, err)
+-- @return boolean|nil, string|nil: true when the forward is being set up, nil and error otherwise
+function M.forward_port(container_port, host_port, callback)
+  log = log or require('container.utils.log')
+  config = config or require('container.config')
+  local notify = require('container.utils.notify')
+  callback = callback or function() end
+
+  container_port = tonumber(container_port)
+  if not container_port or container_port < 1 or container_port > 65535 then
+    return nil, 'Invalid container port: ' .. tostring(container_port)
+  end
+
+  if not state.current_container then
+    return nil, 'No running container. Start one with :ContainerStart'
+  end
+
+  for _, forward in ipairs(state.port_forwards) do
+    if forward.container_port == container_port and not forward.service then
+      return nil,
+        string.format('Container port %d is already forwarded to host port %d', container_port, forward.host_port)
+    end
+  end
+
+  local port_utils = require('container.utils.port')
+  local requested = tonumber(host_port) or container_port
+  local chosen = port_utils.find_next_available_port(requested)
+  if not chosen then
+    return nil, string.format('No free host port found starting at %d', requested)
+  end
+  if chosen ~= requested then
+    notify.container(string.format('Host port %d is in use, using host port %d instead', requested, chosen))
+  end
+
+  local forward_config = config.get_value('port_forwarding') or {}
+  local workspace_root = state.workspace_root
+  require('container.docker.forward').start(state.current_container, container_port, chosen, {
+    bind_address = forward_config.bind_address,
+    image = forward_config.forwarder_image,
+  }, function(forward, err)
+    vim.schedule(in_workspace(workspace_root, function()
+      if not forward then
+        notify.error(err)
+        callback(nil, err)
+        return
+      end
+      table.insert(state.port_forwards, forward)
+      -- Saved with the requested host port so that it is tried first again (not for ad hoc containers)
+      if not (state.current_config and state.current_config.ephemeral) then
+        local saved = { container_port = container_port, host_port = requested }
+        require('container.forward_store').add(workspace_root, saved)
+      end
+      notify.container(string.format('Forwarding container port %d to host port %d', container_port, forward.host_port))
+      M._watch_forward_action(forward)
+      callback(forward)
+    end))
+  end)
+
+  return true
+end
+
+-- Perform the portsAttributes onAutoForward action of a forward started with forward_port()
+function M._watch_forward_action(forward)
+  local current = state.current_config
+  if not current then
+    return
+  end
+  local attributes = require('container.parser').find_port_attributes({
+    portsAttributes = current.port_attributes,
+    otherPortsAttributes = current.other_port_attributes,
+  }, forward.container_port)
+  if type(attributes) ~= 'table' then
+    return
+  end
+
+  local container_id = state.current_container
+  local workspace_root = state.workspace_root
+  local port = {
+    container_port = forward.container_port,
+    host_port = forward.host_port,
+    label = attributes.label,
+    attributes = attributes,
+  }
+  require('container.port_actions').watch(container_id, { port }, in_workspace(workspace_root, function()
+    return state.current_container == container_id
+  end))
+end
+
+-- Forward the forwardPorts entries naming another compose service ("db:5432") from that service's container
+-- A service scaled to several replicas is forwarded from the first one, with a warning.
+function M._forward_service_ports(container_id)
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  config = config or require('container.config')
+  local current_config = state.current_config
+  local active = {}
+  for _, forward in ipairs(state.port_forwards) do
+    if forward.service then
+      active[forward.service .. ':' .. forward.container_port] = true
+    end
+  end
+  local ports = vim.tbl_filter(function(port)
+    return port.service ~= nil and not active[port.service .. ':' .. port.container_port]
+  end, current_config and current_config.ports or {})
+  if #ports == 0 then
+    return
+  end
+
+  local compose = require('container.docker.compose')
+  local forward_config = config.get_value('port_forwarding') or {}
+  local workspace_root = state.workspace_root
+  for _, port in ipairs(ports) do
+    compose.get_service_containers(current_config, port.service, function(ids)
+      vim.schedule(in_workspace(workspace_root, function()
+        if state.current_container ~= container_id then
+          return
+        end
+        if #ids == 0 then
+          local message = 'Service %s has no running container, port %d is not forwarded'
+          notify.status(string.format(message, port.service, port.container_port), 'warn')
+          return
+        end
+        if #ids > 1 then
+          local message = 'Service %s runs %d replicas, forwarding port %d from the first one'
+          notify.status(string.format(message, port.service, #ids, port.container_port), 'warn')
+        end
+        require('container.docker.forward').start(ids[1], port.container_port, port.host_port, {
+          bind_address = forward_config.bind_address,
+          image = forward_config.forwarder_image,
+          owner = container_id,
+          service = port.service,
+        }, function(forward, err)
+          vim.schedule(in_workspace(workspace_root, function()
+            if not forward then
+              local message = 'Could not forward port %d of service %s: %s'
+              notify.error(string.format(message, port.container_port, port.service, err))
+              return
+            end
+            if state.current_container ~= container_id then
+              return
+            end
+            table.insert(state.port_forwards, forward)
+            local message = 'Forwarding port %d of service %s to host port %d'
+            log.info(message, forward.container_port, port.service, forward.host_port)
+          end))
+        end)
+      end))
+    end)
+  end
+end
+
+-- Forward the forwardPorts entries not published at creation (port_forwarding.forward_ports = 'start')
+-- @return table: container ports being forwarded or already forwarded, as keys
+function M._forward_configured_ports(container_id)
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  config = config or require('container.config')
+  local docker = require('container.docker')
+  local current_config = state.current_config
+  local active = {}
+  for _, forward in ipairs(state.port_forwards) do
+    if not forward.service then
+      active[forward.container_port] = true
+    end
+  end
+  local ports = vim.tbl_filter(function(port)
+    return port.container_port ~= nil
+      and not port.service
+      and not active[port.container_port]
+      and not docker.is_published_at_create(port)
+  end, current_config and current_config.ports or {})
+
+  local forward_config = config.get_value('port_forwarding') or {}
+  local workspace_root = state.workspace_root
+  local port_utils = require('container.utils.port')
+  for _, port in ipairs(ports) do
+    active[port.container_port] = true
+    local requested = port.requested_host_port or port.host_port or port.container_port
+    local host_port = port_utils.find_next_available_port(requested)
+    if not host_port then
+      log.error('No free host port found for container port %d', port.container_port)
+    else
+      port.host_port = host_port
+      port.requested_host_port = host_port ~= requested and requested or nil
+      require('container.docker.forward').start(container_id, port.container_port, host_port, {
+        bind_address = forward_config.bind_address,
+        image = forward_config.forwarder_image,
+      }, function(forward, err)
+        vim.schedule(in_workspace(workspace_root, function()
+          if not forward then
+            notify.error(string.format('Could not forward port %d: %s', port.container_port, err))
+            return
+          end
+          if state.current_container ~= container_id then
+            return
+          end
+          table.insert(state.port_forwards, forward)
+          log.info('Forwarding port %d to host port %d', forward.container_port, forward.host_port)
+        end))
+      end)
+    end
+  end
+  return active
+end
+
+-- Set up the saved forwards of the workspace that are not active in the container
+-- Saved forwards whose container port nothing listens on anymore are dropped with a warning.
+function M._restore_port_forwards(container_id)
+  log = log or require('container.utils.log')
+  notify = notify or require('container.utils.notify')
+  local store = require('container.forward_store')
+  local workspace_root = state.workspace_root
+  M._forward_service_ports(container_id)
+  -- forwardPorts come first, a saved forward of the same port is not set up again
+  local active = M._forward_configured_ports(container_id)
+  if state.current_config and state.current_config.ephemeral then
+    return
+  end
+  local missing = vim.tbl_filter(function(saved)
+    return not active[saved.container_port]
+  end, store.load(workspace_root))
+  if #missing == 0 then
+    return
+  end
+
+  store.list_listening_ports(container_id, function(listening)
+    vim.schedule(in_workspace(workspace_root, function()
+      if state.current_container ~= container_id then
+        return
+      end
+      if not listening then
+        log.debug('Could not read listening ports of %s, restoring saved forwards unchecked', container_id)
+      end
+      for _, saved in ipairs(missing) do
+        if listening and not listening[saved.container_port] then
+          store.remove(workspace_root, saved.container_port)
+          local message = 'Dropped saved forward of port %d: nothing listens on it in the container'
+          notify.status(string.format(message, saved.container_port), 'warn')
+        else
+          local ok, err = M.forward_port(saved.container_port, saved.host_port)
+          if not ok then
+            log.warn('Could not restore forward of port %d: %s', saved.container_port, err)
+          end
+        end
+      end
+    end))
+  end)
+end
+
+-- Get dynamic port forwards started with forward_port()
+function M.get_port_forwards()
+  return vim.deepcopy(state.port_forwards)
 end
 
 -- Show detailed port information
@@ -1485,14 +3773,28 @@ function M.show_ports()
         type_info = ' (fixed)'
       end
 
+      if port.requested_host_port then
+        type_info = string.format('%s (host port %d was in use)', type_info, port.requested_host_port)
+      end
+
+      -- appPort is published by docker run, forwardPorts may be forwarded once the container runs
+      local at_create = not port.service and require('container.docker').is_published_at_create(port)
+      local source = port.source or 'forwardPorts'
+      type_info = string.format('%s (%s, %s)', type_info, source, at_create and 'at create' or 'after start')
+
       local protocol = port.protocol ~= 'tcp' and '/' .. port.protocol or ''
+      local label = port.label and string.format(' [%s]', port.label) or ''
+      -- Ports of other compose services are named after the service
+      local target = port.service or 'Container'
       print(
         string.format(
-          '  %d. Container:%d -> Host:%s%s%s',
+          '  %d. %s:%d -> Host:%s%s%s%s',
           i,
+          target,
           port.container_port,
           tostring(port.host_port),
           protocol,
+          label,
           type_info
         )
       )
@@ -1506,6 +3808,24 @@ function M.show_ports()
     print()
   end
 
+  -- Show ports forwarded after start
+  if #state.port_forwards > 0 then
+    print('Dynamic Forwards:')
+    for i, forward in ipairs(state.port_forwards) do
+      print(
+        string.format(
+          '  %d. %s:%d -> Host:%d (%s)',
+          i,
+          forward.service or 'Container',
+          forward.container_port,
+          forward.host_port,
+          forward.sidecar or forward.network
+        )
+      )
+    end
+    print()
+  end
+
   -- Show port allocation statistics
   local port_utils = require('container.utils.port')
   local allocated_ports = port_utils.get_project_ports(state.current_config.project_id or 'unknown')
@@ -1587,7 +3907,7 @@ function M.lsp_status(detailed)
 
   -- Initialize LSP module (if not already done)
   if not lsp then
-    if not state.initialized then
+    if not initialized then
       log.warn('Plugin not fully initialized')
       return nil
     end
@@ -1679,7 +3999,7 @@ function M.lsp_setup()
   log = log or require('container.utils.log')
   config = config or require('container.config')
 
-  if not state.initialized then
+  if not initialized then
     log.error('Plugin not initialized. Call setup() first.')
     return false
   end
@@ -1704,16 +4024,7 @@ function M.lsp_setup()
   lsp.set_container_id(state.current_container)
 
   -- Configure path mapping
-  local ok, lsp_path = pcall(require, 'devcontainer.lsp.path')
-  if ok then
-    lsp_path.setup(
-      vim.fn.getcwd(),
-      (state.current_config and state.current_config.workspace_mount) or '/workspace',
-      (state.current_config and state.current_config.mounts) or {}
-    )
-  else
-    log.warn('Failed to load LSP path module')
-  end
+  M._setup_lsp_path_mappings()
 
   -- Check current LSP state before setup
   local current_state = lsp.get_state()
@@ -1730,6 +4041,36 @@ function M.lsp_setup()
   return true
 end
 
+-- Configure host <-> container path mappings used by the LSP integration
+-- The workspaceFolder maps to the host workspace, bind mounts and lsp.path_mappings add more
+function M._setup_lsp_path_mappings()
+  local ok, lsp_path = pcall(require, 'container.lsp.path')
+  if not ok then
+    log.warn('Failed to load LSP path module: %s', lsp_path)
+    return
+  end
+
+  config = config or require('container.config')
+  local current_config = state.current_config or {}
+  local mounts = {}
+  for _, mount in ipairs(current_config.mounts or {}) do
+    if mount.type == 'bind' and mount.source and mount.target then
+      mounts[mount.source] = mount.target
+    end
+  end
+  for host_path, container_path in pairs(config.get_value('lsp.path_mappings') or {}) do
+    mounts[vim.fn.expand(host_path)] = container_path
+  end
+  -- Folders of workspace_folders, also when the container was started before they were configured
+  local workspace_folders = require('container.workspace_folders')
+  for _, folder in ipairs(workspace_folders.configured(config.get_value('workspace_folders') or {})) do
+    mounts[folder.host] = folder.container
+  end
+
+  local host_root, container_root = require('container.parser').workspace_roots(current_config)
+  lsp_path.setup(host_root, container_root, mounts)
+end
+
 -- Get current plugin state
 function M.get_state()
   local container_status = nil
@@ -1760,10 +4101,12 @@ function M.get_state()
   end
 
   return {
-    initialized = state.initialized,
+    initialized = initialized,
     current_container = state.current_container,
     current_config = state.current_config,
     container_status = container_status,
+    -- ID of the container left by :ContainerDetach
+    detached = state.detached and state.detached.container_id or nil,
   }
 end
 
@@ -1814,12 +4157,13 @@ function M._list_containers_with_fallback(expected_name, callback)
 end
 
 -- Get container list asynchronously
+-- @param filter string|table|nil: `docker ps --filter` value, or several that must all match
 function M._list_containers_async(filter, callback)
   local args = { 'ps', '-a', '--format', '{{.ID}}\\t{{.Names}}\\t{{.Status}}\\t{{.Image}}' }
 
-  if filter then
+  for _, value in ipairs(type(filter) == 'table' and filter or { filter }) do
     table.insert(args, '--filter')
-    table.insert(args, filter)
+    table.insert(args, value)
   end
 
   local docker = require('container.docker.init')
@@ -1885,31 +4229,19 @@ function M._start_container_step4(container_id)
         log.info('LSP path resolution will be handled by strategy system')
 
         -- Trigger ContainerStarted event
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerStarted',
-          data = {
-            container_id = container_id,
-            container_name = state.current_config and state.current_config.name or 'unknown',
-          },
-        })
+        emit_event('ContainerStarted', {
+          container_id = container_id,
+          container_name = state.current_config and state.current_config.name or 'unknown',
+        }, 'running')
 
         -- Setup LSP integration
-        if config.get_value('lsp.auto_setup') then
+        if should_setup_lsp() then
           print('Setting up LSP...')
           lsp = lsp or require('container.lsp.init')
           lsp.setup(config.get_value('lsp'))
           lsp.set_container_id(container_id)
 
-          local ok, lsp_path = pcall(require, 'devcontainer.lsp.path')
-          if ok then
-            lsp_path.setup(
-              vim.fn.getcwd(),
-              state.current_config.workspace_mount or '/workspace',
-              state.current_config.mounts or {}
-            )
-          else
-            log.warn('Failed to load LSP path module')
-          end
+          M._setup_lsp_path_mappings()
 
           lsp.setup_lsp_in_container()
           print('✓ LSP setup complete!')
@@ -1933,10 +4265,16 @@ function M._create_container_async(config, callback)
   callback(nil, 'Container creation requires full :DevcontainerStart workflow')
 end
 
+-- Diagnose the environment (:ContainerDoctor): runtime, version, daemon, compose and configuration
+-- @return table: list of { level = 'ok'|'warn'|'error', message, hint }
+function M.doctor()
+  return require('container.doctor').run()
+end
+
 -- Display comprehensive debug information
 function M.debug_info()
   print('=== DevContainer Debug Info ===')
-  print('Plugin initialized: ' .. tostring(state.initialized))
+  print('Plugin initialized: ' .. tostring(initialized))
   print('Current container ID: ' .. (state.current_container or 'none'))
   print('Current config name: ' .. (state.current_config and state.current_config.name or 'none'))
 
@@ -2026,100 +4364,230 @@ function M.debug_info()
   print('\n=== Debug Info Complete ===')
 end
 
+-- Find the container of a workspace: by workspace label first, then by generated name
+-- (containers created before the label was introduced only match by name)
+-- The name of the container found is kept in normalized_config.container_name.
+-- @param opts table|nil: { match_config = boolean } only accepts a container labeled with this configuration
+--   (used when the workspace has several configurations)
+function M._find_workspace_container(normalized_config, callback, opts)
+  opts = opts or {}
+  docker = docker or require('container.docker.init')
+  local filters = { docker.workspace_label_filter(docker.get_workspace_path(normalized_config)) }
+  if opts.match_config then
+    table.insert(filters, docker.config_label_filter(normalized_config))
+  end
+
+  local function found(container)
+    if container then
+      normalized_config.container_name = container.name
+    end
+    callback(container)
+  end
+
+  M._list_containers_async(filters, function(containers)
+    if #containers > 0 then
+      -- Prefer a running container when several carry the label
+      table.sort(containers, function(a, b)
+        return (a.status:match('^Up') and 1 or 0) > (b.status:match('^Up') and 1 or 0)
+      end)
+      log.info('Found container by workspace label: %s', containers[1].id)
+      found(containers[1])
+      return
+    end
+
+    local expected_container_name = docker.resolve_container_name(normalized_config)
+    log.info('Looking for existing container: %s', expected_container_name)
+    M._list_containers_with_fallback(expected_container_name, function(named)
+      found(named[1])
+    end)
+  end)
+end
+
+-- Restore plugin state for a running container found after a restart
+function M._restore_attached_container(container, normalized_config, config_path)
+  local workspace_root = state.workspace_root
+  state.current_container = container.id
+  clear_status_cache()
+  state.current_config = normalized_config
+
+  notify.success('Reconnected to existing container: ' .. container.id:sub(1, 12))
+  notify.container('Status: ' .. container.status)
+  notify.info('Use :ContainerStatus for details')
+
+  -- LSP path resolution is now handled by the LSP strategy system
+  log.info('LSP path resolution will be handled by strategy system')
+
+  -- Trigger ContainerDetected event for LSP auto-initialization
+  emit_event('ContainerDetected', {
+    container_id = container.id,
+    container_name = normalized_config.name,
+    status = container.status,
+  }, 'running')
+  emit_event('ContainerAttached', {
+    container_id = container.id,
+    container_name = normalized_config.name,
+    reconnected = true,
+  })
+
+  -- Trigger ContainerOpened event for reconnection
+  emit_event('ContainerOpened', {
+    container_name = normalized_config.name,
+    config_path = config_path,
+    reconnected = true,
+  })
+
+  -- The shell environment (userEnvProbe) is probed again, LSP servers start after a delay below
+  require('container.environment').probe_user_env(container.id, normalized_config, function() end)
+
+  -- Forwarding sidecars outlive Neovim, pick up the ones still running
+  require('container.docker.forward').list(container.id, function(forwards)
+    vim.schedule(in_workspace(workspace_root, function()
+      if state.current_container ~= container.id then
+        return
+      end
+      if #forwards > 0 then
+        state.port_forwards = forwards
+        log.info('Restored %d port forward(s)', #forwards)
+      end
+      -- Saved forwards whose sidecars are gone are set up again
+      M._restore_port_forwards(container.id)
+    end))
+  end)
+
+  -- Auto-setup LSP (if configured)
+  if config and should_setup_lsp() then
+    vim.defer_fn(in_workspace(workspace_root, function()
+      -- Check if LSP is already configured for this container
+      if lsp then
+        local current_state = lsp.get_state()
+        if current_state.container_id == container.id then
+          log.debug('LSP already configured for container %s', container.id)
+          return
+        end
+      end
+
+      if not M.lsp_setup() then
+        log.warn('LSP setup failed after reconnecting')
+      end
+    end), 2000)
+  end
+end
+
 -- Attempt to reconnect to existing container
-function M._try_reconnect_existing_container()
+-- @param opts table|nil: { manual = boolean } reports when nothing is found and re-attaches an attached workspace
+function M._try_reconnect_existing_container(opts)
   log = log or require('container.utils.log')
+  opts = opts or {}
 
-  if state.current_container then
-    -- Skip if container is already configured
+  if (state.current_container or state.detached) and not opts.manual then
+    -- Skip if container is already configured, or the workspace was detached from it on purpose
     return
   end
 
   docker = docker or require('container.docker.init')
 
-  -- Search for devcontainer.json in current directory
-  local cwd = vim.fn.getcwd()
+  -- Search for devcontainer.json from the current workspace
+  local cwd = current_workspace_root() or vim.fn.getcwd()
   parser = parser or require('container.parser')
+  local workspace_root = find_workspace_root(cwd) or cwd
 
-  local devcontainer_config = parser.find_and_parse(cwd)
-  if not devcontainer_config then
-    -- Do nothing if devcontainer.json is not found
-    return
+  -- Configurations to look for: the one chosen for the workspace, or all of them
+  local chosen = workspaces[workspace_root] and workspaces[workspace_root].config_path
+  local config_paths = chosen and { chosen } or parser.find_devcontainer_configs(workspace_root)
+  if #config_paths == 0 then
+    config_paths = { parser.find_devcontainer_json(cwd) }
   end
+  local multiple = #config_paths > 1
 
-  -- Get normalized configuration
-  local normalized_config = parser.normalize_for_plugin(devcontainer_config)
-  normalized_config.base_path = cwd -- Add base path for container name generation
-
-  -- Generate expected container name using same logic as creation
-  local expected_container_name = docker.generate_container_name(normalized_config)
-
-  log.info('Looking for existing container: %s', expected_container_name)
-
-  -- Search for existing containers
-  M._list_containers_with_fallback(expected_container_name, function(containers)
-    vim.schedule(function()
-      if #containers > 0 then
-        local container = containers[1]
-        log.info('Found existing container: %s (%s)', container.id, container.status)
+  local function load_config(config_path)
+    local devcontainer_config = parser.parse(config_path)
+    if not devcontainer_config then
+      return nil
+    end
+    local normalized_config = parser.normalize_for_plugin(devcontainer_config)
+    normalized_config.base_path = cwd -- Add base path for container name generation
+    normalized_config.workspace_root = workspace_root
+    return normalized_config
+  end
 
-        -- Restore state
-        state.current_container = container.id
-        clear_status_cache()
-        state.current_config = normalized_config
+  -- Search for existing containers, trying each configuration in turn
+  local function find_container(index, callback)
+    local config_path = config_paths[index]
+    if not config_path then
+      callback(nil)
+      return
+    end
+    local normalized_config = load_config(config_path)
+    if not normalized_config then
+      find_container(index + 1, callback)
+      return
+    end
+    M._find_workspace_container(normalized_config, function(container)
+      if container then
+        callback(container, normalized_config)
+      else
+        find_container(index + 1, callback)
+      end
+    end, { match_config = multiple })
+  end
 
-        notify.success('Reconnected to existing container: ' .. container.id:sub(1, 12))
-        notify.container('Status: ' .. container.status)
-        notify.info('Use :ContainerStatus for details')
+  if not config_paths[1] then
+    -- Do nothing if devcontainer.json is not found
+    if opts.manual then
+      notify.error('No devcontainer.json found for this workspace')
+    end
+    return
+  end
 
-        -- LSP path resolution is now handled by the LSP strategy system
-        log.info('LSP path resolution will be handled by strategy system')
+  find_container(1, function(container, normalized_config)
+    vim.schedule(in_workspace(workspace_root, function()
+      if not container then
+        log.debug('No existing containers found for this project')
+        if opts.manual then
+          notify.container('No container found for this workspace. Use :ContainerStart to create one')
+        end
+        return
+      end
 
-        -- Trigger ContainerDetected event for LSP auto-initialization
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerDetected',
-          data = {
-            container_id = container.id,
-            container_name = normalized_config.name,
-            status = container.status,
-          },
-        })
+      log.info('Found existing container: %s (%s)', container.id, container.status)
 
-        -- Trigger ContainerOpened event for reconnection
-        vim.api.nvim_exec_autocmds('User', {
-          pattern = 'ContainerOpened',
-          data = {
-            container_name = normalized_config.name,
-            config_path = cwd,
-            reconnected = true,
-          },
-        })
+      -- Restore into the state of the workspace the container belongs to
+      if state.current_container and not opts.manual then
+        return
+      end
+      if multiple then
+        state.config_path = normalized_config.config_file
+      end
 
-        -- Auto-setup LSP (if configured)
-        if config and config.get_value('lsp.auto_setup') and container.status == 'running' then
-          print('  Setting up LSP...')
-          vim.defer_fn(function()
-            -- Check if LSP is already configured for this container
-            if lsp then
-              local current_state = lsp.get_state()
-              if current_state.container_id == container.id then
-                print('  ✓ LSP already configured for this container')
-                return
-              end
-            end
+      local is_running = container.status == 'running' or container.status:match('^Up') ~= nil
+      if is_running then
+        M._restore_attached_container(container, normalized_config, cwd)
+        return
+      end
 
-            local success = M.lsp_setup()
-            if success then
-              print('  ✓ LSP setup complete')
-            else
-              print('  ⚠ LSP setup failed')
-            end
-          end, 2000)
-        end
+      -- The labeled container exists but is stopped: offer to start it instead of rebuilding
+      state.current_container = container.id
+      clear_status_cache()
+      state.current_config = normalized_config
+      emit_event('ContainerDetected', {
+        container_id = container.id,
+        container_name = normalized_config.name,
+        status = container.status,
+      }, 'stopped')
+
+      local choice = vim.fn.confirm(
+        string.format('Container %s for this workspace is stopped. Start it?', container.name),
+        '&Yes\n&No',
+        1
+      )
+      if choice == 1 then
+        notify.container('Starting existing container...')
+        set_container_state('building')
+        M._start_stopped_container(container.id)
       else
-        log.debug('No existing containers found for this project')
+        notify.container('Container is stopped. Use :ContainerStart to start it')
       end
-    end)
+    end))
   end)
 end
 
@@ -2132,108 +4600,164 @@ function M.reconnect()
   M._try_reconnect_existing_container()
 end
 
--- Execute postCreateCommand
-function M._run_post_create_command(container_id, callback)
-  log = log or require('container.utils.log')
+-- Let the host's own tooling take over the loaded buffers again
+-- FileType is fired for them so language servers configured on the host (e.g. by nvim-lspconfig) attach; the
+-- clients started that way are remembered and stopped again by :ContainerReopen.
+local function resume_host_tooling(detached)
+  local before = {}
+  for _, client in ipairs(vim.lsp.get_clients and vim.lsp.get_clients() or {}) do
+    before[client.id] = true
+  end
+  for _, buf in ipairs(vim.api.nvim_list_bufs()) do
+    if vim.api.nvim_buf_is_loaded(buf) and vim.bo[buf].filetype ~= '' then
+      pcall(vim.api.nvim_exec_autocmds, 'FileType', { buffer = buf, modeline = false })
+    end
+  end
+  -- Servers start asynchronously after FileType
+  vim.defer_fn(function()
+    for _, client in ipairs(vim.lsp.get_clients and vim.lsp.get_clients() or {}) do
+      if not before[client.id] then
+        table.insert(detached.host_clients, client.id)
+      end
+    end
+    log.info('Detached: %d host LSP client(s) started', #detached.host_clients)
+  end, 1000)
+end
 
-  log.debug('Checking for postCreateCommand...')
-  log.debug('Current config exists: %s', tostring(state.current_config ~= nil))
+-- Work on the host without the container (:ContainerDetach, like "Reopen Folder Locally")
+-- LSP clients of the container stop and the host's own servers attach instead; tests, exec and new terminals no
+-- longer go to the container. The container keeps running unless detach.stop_container is set.
+-- @return boolean: true when detached
+function M.detach()
+  log = log or require('container.utils.log')
+  config = config or require('container.config')
+  notify = notify or require('container.utils.notify')
 
-  if state.current_config then
-    log.debug('Config keys: %s', vim.inspect(vim.tbl_keys(state.current_config)))
-    log.debug('postCreateCommand value: %s', tostring(state.current_config.postCreateCommand))
-    log.debug('post_create_command value: %s', tostring(state.current_config.post_create_command))
+  if not state.current_container then
+    notify.error('No active container')
+    return false
   end
 
-  if not state.current_config or not state.current_config.post_create_command then
-    notify.debug('No postCreateCommand found, skipping...')
-    log.debug('No postCreateCommand found, skipping')
-    callback(true)
-    return
+  local detached = {
+    container_id = state.current_container,
+    config = state.current_config,
+    stopped = (config.get_value('detach') or {}).stop_container == true,
+    host_clients = {},
+  }
+  local workspace_root = state.workspace_root
+  local name = detached.config and detached.config.name
+  log.info('Detaching from container %s (stop: %s)', detached.container_id, tostring(detached.stopped))
+
+  if detached.stopped then
+    -- stop() stops the LSP clients and clears the state once the container has stopped
+    local unsubscribe
+    unsubscribe = M.on('stopped', in_workspace(workspace_root, function(data)
+      if data.container_id ~= detached.container_id then
+        return
+      end
+      unsubscribe()
+      state.detached = detached
+      emit_event('ContainerDetached', { container_id = detached.container_id, container_name = name, stopped = true })
+      resume_host_tooling(detached)
+    end))
+    if M.stop() == false then
+      unsubscribe()
+      return false
+    end
+    notify.container('Detaching: stopping the container, host tools take over once it has stopped')
+    return true
   end
 
-  local command = state.current_config.post_create_command
-
-  -- Convert array-style commands to shell command string
-  if type(command) == 'table' then
-    -- Join commands with && and add error handling to prevent entire script failure
-    command = 'set +e; ' .. table.concat(command, ' && ')
-    log.debug('Converted array postCreateCommand to shell command: %s', command)
+  if lsp then
+    lsp.stop_all()
   end
+  clear_all_state()
+  state.detached = detached
+  emit_event(
+    'ContainerDetached',
+    { container_id = detached.container_id, container_name = name, stopped = false },
+    'none'
+  )
+  resume_host_tooling(detached)
+  notify.container('Detached from the container, working on the host. :ContainerReopen switches back')
+  return true
+end
 
-  notify.progress('container_setup', 5, 5, 'Running postCreateCommand...')
-  log.info('Executing postCreateCommand: %s', command)
-  log.debug('postCreateCommand container_id: %s', container_id)
+-- Switch back to the container left by :ContainerDetach
+-- Host LSP clients started by the detach are stopped; a stopped container is started again.
+-- @return boolean: true when reopening started
+function M.reopen()
+  log = log or require('container.utils.log')
+  docker = docker or require('container.docker.init')
+  notify = notify or require('container.utils.notify')
 
-  local docker = require('container.docker.init')
-  local environment = require('container.environment')
+  local detached = state.detached
+  if not detached then
+    notify.error('Not detached from a container. Use :ContainerAttach or :ContainerStart')
+    return false
+  end
 
-  -- Build exec args with environment-specific settings
-  local exec_args = {
-    'exec',
-    '-i',
-  }
+  for _, client_id in ipairs(detached.host_clients) do
+    local client = vim.lsp.get_client_by_id(client_id)
+    if client then
+      client.stop()
+    end
+  end
 
-  -- Add environment-specific args (includes user and env vars)
-  log.debug('postCreateCommand state.current_config.containerEnv: %s', vim.inspect(state.current_config.containerEnv))
-  local env_args = environment.build_postcreate_args(state.current_config)
-  log.debug('postCreateCommand env_args: %s', vim.inspect(env_args))
-  for _, arg in ipairs(env_args) do
-    table.insert(exec_args, arg)
+  local status = docker.get_container_status(detached.container_id)
+  if not status then
+    state.detached = nil
+    notify.error('The detached container no longer exists. Use :ContainerStart to create one')
+    return false
   end
 
-  -- Set working directory to workspace (handle both normalized and raw config)
-  local workspace_folder = state.current_config.workspace_folder or state.current_config.workspaceFolder or '/workspace'
-  log.debug('postCreateCommand workspace_folder: %s', workspace_folder)
-  table.insert(exec_args, '-w')
-  table.insert(exec_args, workspace_folder)
+  state.detached = nil
+  log.info('Reopening container %s (%s)', detached.container_id, status)
+  if status == 'running' then
+    M._restore_attached_container(
+      { id = detached.container_id, status = status },
+      detached.config,
+      detached.config and detached.config.config_file
+    )
+    return true
+  end
 
-  -- Add container and command
-  table.insert(exec_args, container_id)
-  -- Use bash for better command execution compatibility
-  local shell = 'bash'
-  table.insert(exec_args, shell)
-  table.insert(exec_args, '-c')
-  table.insert(exec_args, command)
+  state.current_container = detached.container_id
+  clear_status_cache()
+  state.current_config = detached.config
+  notify.container('Starting the container again...')
+  set_container_state('building')
+  M._start_stopped_container(detached.container_id)
+  return true
+end
 
-  log.debug('postCreateCommand final exec_args: %s', vim.inspect(exec_args))
+-- Execute postCreateCommand
+function M._run_post_create_command(container_id, callback)
+  log = log or require('container.utils.log')
 
-  docker.run_docker_command_async(exec_args, { timeout = 60, verbose = true }, function(result)
-    vim.schedule(function()
-      -- Improved success detection: consider success if exit code is 0, even with stderr
-      local is_success = result.success and result.code == 0
-
-      if is_success then
-        notify.success('postCreateCommand completed successfully')
-        log.info('postCreateCommand output: %s', result.stdout)
-        if result.stderr and result.stderr ~= '' then
-          log.debug('postCreateCommand stderr (non-fatal): %s', result.stderr)
-        end
-        callback(true)
-      else
-        -- More detailed error analysis
-        if result.code == 127 then
-          notify.critical('postCreateCommand failed: command not found')
-        elseif result.code == 126 then
-          notify.critical('postCreateCommand failed: permission denied')
-        else
-          notify.critical('postCreateCommand failed')
-        end
+  if not state.current_config or not state.current_config.post_create_command then
+    log.debug('No postCreateCommand found, skipping')
+    callback(true)
+    return
+  end
 
-        log.error('postCreateCommand failed with code %d', result.code)
-        log.error('Full exec args: %s', table.concat(exec_args, ' '))
-        log.error('Converted command: %s', command)
-        log.error('Error output: %s', result.stderr or '')
-        log.error('Stdout: %s', result.stdout or '')
-        callback(false)
-      end
-    end)
+  local lifecycle = require('container.lifecycle')
+  local hook = lifecycle.HOOKS[3]
+  lifecycle.run_hook(container_id, state.current_config, hook, function(success, failure)
+    if success then
+      notify.success('postCreateCommand completed successfully')
+    else
+      notify.critical(
+        string.format('postCreateCommand failed with exit code %d: %s', failure.exit_code, failure.command)
+      )
+    end
+    callback(success)
   end)
 end
 
 -- StatusLine integration API
 function M.statusline()
-  if not state.initialized then
+  if not initialized then
     return ''
   end
 
@@ -2242,7 +4766,7 @@ function M.statusline()
 end
 
 function M.statusline_component()
-  if not state.initialized then
+  if not initialized then
     return function()
       return ''
     end
@@ -2324,6 +4848,21 @@ function M.recover_lsp()
   return true
 end
 
+-- Restart the container LSP clients of the current workspace
+-- @param servers table|nil: server names (default: every running server)
+function M.restart_lsp(servers)
+  lsp = lsp or require('container.lsp.init')
+
+  lsp.restart(servers, function(restarted)
+    if #restarted == 0 then
+      notify.status('No container LSP clients to restart')
+    else
+      notify.status('Restarted LSP: ' .. table.concat(restarted, ', '))
+    end
+  end)
+  return true
+end
+
 -- Retry specific LSP server setup
 function M.retry_lsp_server(server_name)
   if not lsp then
@@ -2343,11 +4882,11 @@ end
 
 -- Graceful degradation for container feature setup
 function M._setup_container_features_gracefully(container_id)
+  local workspace_root = state.workspace_root
+  -- Lifecycle commands are handled by container.lifecycle before this runs
   local features_status = {
-    post_create_command = 'pending',
     lsp_setup = 'pending',
     test_integration = 'pending',
-    post_start_command = 'pending',
   }
 
   local function update_status(feature, status, message)
@@ -2396,25 +4935,10 @@ function M._setup_container_features_gracefully(container_id)
     end
   end
 
-  -- 1. Execute postCreateCommand
   print('Setting up container features...')
-  if state.current_config.post_create_command then
-    print('Step 4.5: Running postCreateCommand...')
-    M._run_post_create_command(container_id, function(success)
-      if success then
-        update_status('post_create_command', 'success', 'postCreateCommand completed')
-      else
-        update_status('post_create_command', 'warning', 'postCreateCommand failed but continuing')
-      end
-      check_completion()
-    end)
-  else
-    update_status('post_create_command', 'success', 'no postCreateCommand defined')
-    check_completion()
-  end
 
-  -- 2. Setup LSP integration with error handling
-  if config.get_value('lsp.auto_setup') then
+  -- 1. Setup LSP integration with error handling
+  if should_setup_lsp() then
     print('Step 5: Setting up LSP...')
     local lsp_success = pcall(function()
       lsp = lsp or require('container.lsp.init')
@@ -2422,19 +4946,10 @@ function M._setup_container_features_gracefully(container_id)
       lsp.set_container_id(container_id)
 
       -- Configure path mapping with error handling
-      local path_ok, lsp_path = pcall(require, 'container.lsp.path')
-      if path_ok then
-        lsp_path.setup(
-          vim.fn.getcwd(),
-          state.current_config.workspace_mount or '/workspace',
-          state.current_config.mounts or {}
-        )
-      else
-        log.warn('Failed to load LSP path module: %s', lsp_path)
-      end
+      M._setup_lsp_path_mappings()
 
       -- Setup LSP servers with error handling
-      vim.defer_fn(function()
+      vim.defer_fn(in_workspace(workspace_root, function()
         local setup_ok, setup_err = pcall(function()
           lsp.setup_lsp_in_container()
         end)
@@ -2446,7 +4961,7 @@ function M._setup_container_features_gracefully(container_id)
           update_status('lsp_setup', 'warning', 'LSP setup failed: ' .. tostring(setup_err))
         end
         check_completion()
-      end, 500)
+      end), 500)
     end)
 
     if not lsp_success then
@@ -2458,7 +4973,7 @@ function M._setup_container_features_gracefully(container_id)
     check_completion()
   end
 
-  -- 3. Setup test integration with error handling
+  -- 2. Setup test integration with error handling
   local test_config = config.get()
   if
     test_config.test_integration
@@ -2484,40 +4999,13 @@ function M._setup_container_features_gracefully(container_id)
     update_status('test_integration', 'success', 'test integration disabled')
     check_completion()
   end
-
-  -- 4. Execute post-start command with error handling
-  if state.current_config.post_start_command then
-    print('Step 6: Running post-start command...')
-    vim.defer_fn(function()
-      local exec_ok, exec_err = pcall(function()
-        M.exec(state.current_config.post_start_command, {
-          on_complete = function(result)
-            if result.success then
-              update_status('post_start_command', 'success', 'post-start command completed')
-            else
-              update_status('post_start_command', 'warning', 'post-start command failed')
-            end
-            check_completion()
-          end,
-        })
-      end)
-
-      if not exec_ok then
-        update_status('post_start_command', 'failed', 'post-start command error: ' .. tostring(exec_err))
-        check_completion()
-      end
-    end, 1000)
-  else
-    update_status('post_start_command', 'success', 'no post-start command defined')
-    check_completion()
-  end
 end
 
 -- DAP integration API
 
 -- Start debugging in container
 function M.dap_start(opts)
-  if not state.initialized then
+  if not initialized then
     log.error('Plugin not initialized. Call setup() first.')
     return false
   end
@@ -2531,6 +5019,29 @@ function M.dap_start(opts)
   return dap.start_debugging(opts)
 end
 
+-- Debug the Go test function under the cursor
+function M.dap_debug_nearest()
+  if not initialized then
+    log.error('Plugin not initialized. Call setup() first.')
+    return false
+  end
+
+  local dap = require('container.dap')
+  return dap.debug_nearest()
+end
+
+-- Attach the debugger to a process running in the container
+-- @param pid number|nil: process ID in the container; nil lets the user pick a process
+function M.attach_debugger(pid)
+  if not initialized then
+    log.error('Plugin not initialized. Call setup() first.')
+    return false
+  end
+
+  local dap = require('container.dap')
+  return dap.attach_process(pid)
+end
+
 -- Stop debugging
 function M.dap_stop()
   local dap = require('container.dap')
@@ -2549,4 +5060,57 @@ function M.dap_list_sessions()
   return dap.list_debug_sessions()
 end
 
+-- Commands resolve the workspace of the current buffer first: a background start or stop finishing in another
+-- project does not redirect them
+for _, name in ipairs({
+  'open',
+  'build',
+  'start',
+  'start_image',
+  'cancel',
+  'stop',
+  'kill',
+  'terminate',
+  'remove',
+  'stop_and_remove',
+  'terminal',
+  'shell',
+  'exec_interactive',
+  'terminal_new',
+  'attach',
+  'restart',
+  'rebuild',
+  'rebuild_container',
+  'show_status',
+  'logs',
+  'show_resolved_config',
+  'clear_go_cache',
+  'execute',
+  'execute_stream',
+  'exec',
+  'exec_selection',
+  'run_file',
+  'task',
+  'lint',
+  'copy',
+  'run_test',
+  'reset',
+  'forward_port',
+  'show_ports',
+  'show_port_stats',
+  'lsp_status',
+  'lsp_setup',
+  'sync_remote_workspace',
+  'reconnect',
+  'detach',
+  'reopen',
+  'debug_info',
+  'restart_lsp',
+  'dap_start',
+  'dap_debug_nearest',
+  'attach_debugger',
+}) do
+  M[name] = command(M[name])
+end
+
 return M
@@ -0,0 +1,352 @@
+-- lua/container/lifecycle.lua
+-- devcontainer.json lifecycle command execution
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Lifecycle hooks in execution order
+-- The "create" family only runs once per container, the others run on every start/attach
+M.HOOKS = {
+  { key = 'on_create_command', name = 'onCreateCommand', family = 'create' },
+  { key = 'update_content_command', name = 'updateContentCommand', family = 'create' },
+  { key = 'post_create_command', name = 'postCreateCommand', family = 'create' },
+  { key = 'post_start_command', name = 'postStartCommand', family = 'start' },
+  { key = 'post_attach_command', name = 'postAttachCommand', family = 'attach' },
+}
+
+-- Hook waited for before the container is ready when waitFor is not set (as in the spec)
+M.DEFAULT_WAIT_FOR = 'updateContentCommand'
+
+-- Hook a configuration waits for (waitFor, else M.DEFAULT_WAIT_FOR)
+function M.wait_for_name(config)
+  return config and config.wait_for or M.DEFAULT_WAIT_FOR
+end
+
+-- Position of a hook in M.HOOKS by its devcontainer.json name
+function M.hook_position(name)
+  for i, hook in ipairs(M.HOOKS) do
+    if hook.name == name then
+      return i
+    end
+  end
+  return nil
+end
+
+-- Marker written inside the container once the create family has completed
+M.CREATE_MARKER = '/var/tmp/.container-nvim-create-commands-done'
+
+-- Name of the output buffer used for lifecycle command output
+M.OUTPUT_NAME = 'lifecycle'
+
+-- Normalize a lifecycle command into a list of { label, args, display } entries
+-- - string: executed through /bin/sh -c
+-- - array: executed directly as argv, without a shell
+-- - object: each labelled value is normalized; the entries run in parallel (sorted by label)
+function M.normalize_command(command, label)
+  local entries = {}
+
+  if type(command) == 'string' then
+    if command ~= '' then
+      table.insert(entries, { label = label, args = { '/bin/sh', '-c', command }, display = command })
+    end
+  elseif type(command) == 'table' and command[1] ~= nil then
+    table.insert(entries, { label = label, args = vim.deepcopy(command), display = table.concat(command, ' ') })
+  elseif type(command) == 'table' then
+    local labels = vim.tbl_keys(command)
+    table.sort(labels)
+    for _, name in ipairs(labels) do
+      vim.list_extend(entries, M.normalize_command(command[name], name))
+    end
+  end
+
+  return entries
+end
+
+-- Build docker exec arguments for a lifecycle command
+function M.build_exec_args(container_id, config, entry)
+  local environment = require('container.environment')
+  local args = { 'exec', '-i' }
+
+  vim.list_extend(args, environment.build_postcreate_args(config))
+
+  table.insert(args, '-w')
+  table.insert(args, config.workspace_folder or config.workspaceFolder or '/workspace')
+  table.insert(args, container_id)
+  vim.list_extend(args, entry.args)
+
+  return args
+end
+
+-- Append lines to the lifecycle output buffer
+local function output(lines)
+  local ok, out = pcall(require, 'container.ui.output')
+  if ok then
+    pcall(out.append, M.OUTPUT_NAME, lines)
+  end
+end
+
+-- Run a single lifecycle command entry, streaming output into the lifecycle buffer
+function M.run_entry(container_id, config, hook_name, entry, callback)
+  local title = entry.label and string.format('%s (%s)', hook_name, entry.label) or hook_name
+  output({ '', '==> ' .. title .. ': ' .. entry.display })
+  log.info('Running %s: %s', title, entry.display)
+
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, M.build_exec_args(container_id, config, entry))
+
+  local function on_data(_, data)
+    local lines = {}
+    for _, line in ipairs(data or {}) do
+      if line ~= '' then
+        table.insert(lines, line)
+      end
+    end
+    if #lines > 0 then
+      vim.schedule(function()
+        output(lines)
+      end)
+    end
+  end
+
+  local job_id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        output({ string.format('<== %s exited with code %d', title, exit_code) })
+        callback(exit_code == 0, exit_code)
+      end)
+    end,
+  })
+
+  if job_id <= 0 then
+    callback(false, -1)
+  else
+    require('container.pipeline').track(config.workspace_root, job_id)
+  end
+end
+
+-- Run entries in parallel, calling back once all of them have exited
+-- @param run function(entry, done(success, exit_code))
+-- @param callback function(success, failure) where failure = { hook, command, exit_code } of the first failing entry
+--   in label order
+local function run_parallel(hook_name, entries, run, callback)
+  if #entries == 0 then
+    callback(true)
+    return
+  end
+  local results = {}
+  local remaining = #entries
+  for i, entry in ipairs(entries) do
+    run(entry, function(success, exit_code)
+      results[i] = { success = success, exit_code = exit_code }
+      remaining = remaining - 1
+      if remaining > 0 then
+        return
+      end
+      for j, result in ipairs(results) do
+        if not result.success then
+          callback(false, { hook = hook_name, command = entries[j].display, exit_code = result.exit_code })
+          return
+        end
+      end
+      callback(true)
+    end)
+  end
+end
+
+-- Run every entry of a hook (the labelled commands of an object in parallel), failing when any of them fails
+function M.run_hook(container_id, config, hook, callback)
+  run_parallel(hook.name, M.normalize_command(config[hook.key]), function(entry, done)
+    M.run_entry(container_id, config, hook.name, entry, done)
+  end, callback)
+end
+
+-- Cleanup hook run before :ContainerStop (customizations.container.nvim.preStopCommand)
+M.PRE_STOP_HOOK = { key = 'pre_stop_command', name = 'preStopCommand' }
+
+-- Run the preStopCommand while the container is still running
+-- A failing command is reported but does not keep the container from stopping.
+-- @param callback function(success, failure)
+function M.run_pre_stop_command(container_id, config, callback)
+  if not config or #M.normalize_command(config.pre_stop_command) == 0 then
+    callback(true)
+    return
+  end
+  M.run_hook(container_id, config, M.PRE_STOP_HOOK, callback)
+end
+
+-- Run initializeCommand on the host (in the workspace folder) before the container is built or started
+-- The command accepts the same forms as the other lifecycle commands; labelled commands run in parallel.
+-- @param on_output function(line): receives every output line
+-- @param callback function(success, failure) where failure = { hook, command, exit_code }
+function M.run_initialize_command(config, cwd, on_output, callback)
+  run_parallel('initializeCommand', M.normalize_command(config.initialize_command), function(entry, done)
+    local title = entry.label and string.format('initializeCommand (%s)', entry.label) or 'initializeCommand'
+    log.info('Running %s on the host: %s', title, entry.display)
+    on_output('==> ' .. title .. ': ' .. entry.display)
+
+    local function on_data(_, data)
+      for _, line in ipairs(data or {}) do
+        if line ~= '' then
+          vim.schedule(function()
+            on_output(line)
+          end)
+        end
+      end
+    end
+
+    local job_id = vim.fn.jobstart(entry.args, {
+      cwd = cwd,
+      on_stdout = on_data,
+      on_stderr = on_data,
+      on_exit = function(_, exit_code)
+        vim.schedule(function()
+          on_output(string.format('<== %s exited with code %d', title, exit_code))
+          done(exit_code == 0, exit_code)
+        end)
+      end,
+    })
+
+    if job_id <= 0 then
+      done(false, -1)
+    else
+      require('container.pipeline').track(config.workspace_root, job_id)
+    end
+  end, callback)
+end
+
+-- Check whether the create family has already run in the container
+function M.has_run_create_commands(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async(
+    { 'exec', '-u', 'root', container_id, 'test', '-f', M.CREATE_MARKER },
+    {},
+    function(result)
+      callback(result.success)
+    end
+  )
+end
+
+-- Record that the create family has completed
+function M.mark_create_commands_done(container_id, callback)
+  local docker = require('container.docker')
+  docker.run_docker_command_async({ 'exec', '-u', 'root', container_id, 'touch', M.CREATE_MARKER }, {}, function()
+    if callback then
+      callback()
+    end
+  end)
+end
+
+-- Check whether any lifecycle command is configured
+function M.has_commands(config)
+  for _, hook in ipairs(M.HOOKS) do
+    if #M.normalize_command(config[hook.key]) > 0 then
+      return true
+    end
+  end
+  return false
+end
+
+-- Run lifecycle commands in order
+-- opts.families: list of families to run (default: all)
+-- opts.wait_for: hook name (waitFor) after which opts.on_ready is called while later hooks keep running
+-- opts.on_ready: called once, when the wait_for hook has finished (or all hooks when none is reached)
+-- opts.on_hook: called with the hook name and whether the container is still waited for before each hook runs
+-- callback(success, failure) where failure = { hook, command, exit_code }
+function M.run(container_id, config, opts, callback)
+  opts = opts or {}
+  local families = opts.families or { 'create', 'start', 'attach' }
+  local wait_position = M.hook_position(opts.wait_for or M.DEFAULT_WAIT_FOR) or #M.HOOKS
+
+  local ready = false
+  local function fire_ready()
+    if not ready then
+      ready = true
+      if opts.on_ready then
+        opts.on_ready()
+      end
+    end
+  end
+  local done = callback
+  callback = function(success, failure)
+    if success then
+      fire_ready()
+    end
+    done(success, failure)
+  end
+
+  local hooks = {}
+  for position, hook in ipairs(M.HOOKS) do
+    if vim.tbl_contains(families, hook.family) and #M.normalize_command(config[hook.key]) > 0 then
+      table.insert(hooks, vim.tbl_extend('force', hook, { position = position }))
+    end
+  end
+
+  if #hooks == 0 then
+    callback(true)
+    return
+  end
+
+  local function run_hooks(skip_create)
+    local index = 0
+    local create_ran = false
+
+    local function next_hook()
+      index = index + 1
+      local hook = hooks[index]
+      if not hook then
+        if create_ran then
+          M.mark_create_commands_done(container_id)
+        end
+        callback(true)
+        return
+      end
+
+      -- Hooks after waitFor run once the container is already reported ready
+      if hook.position > wait_position then
+        fire_ready()
+      end
+
+      if hook.family == 'create' and skip_create then
+        log.debug('Skipping %s: container already created', hook.name)
+        next_hook()
+        return
+      end
+
+      if opts.on_hook then
+        opts.on_hook(hook.name, not ready)
+      end
+      M.run_hook(container_id, config, hook, function(success, failure)
+        if not success then
+          callback(false, failure)
+          return
+        end
+        if hook.family == 'create' then
+          create_ran = true
+        end
+        next_hook()
+      end)
+    end
+
+    next_hook()
+  end
+
+  if opts.open_output ~= false then
+    pcall(function()
+      require('container.ui.output').open(M.OUTPUT_NAME)
+    end)
+  end
+
+  if vim.tbl_contains(families, 'create') then
+    M.has_run_create_commands(container_id, function(done)
+      run_hooks(done)
+    end)
+  else
+    run_hooks(true)
+  end
+end
+
+return M
@@ -0,0 +1,314 @@
+-- lua/container/lint.lua
+-- golangci-lint in the container (:ContainerLint)
+-- `<lint.cmd> <lint.args> [arguments]` runs in the Go module of the current file with JSON output on stdout. The
+-- issues are mapped to host paths and loaded into the quickfix list, and into vim.diagnostic when lint.diagnostics
+-- is set. The log lines on stderr stream into the output buffer and the progress message while the linter runs.
+-- When the linter is missing from the container, installing it with `go install lint.install_package` is offered.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for linter logs
+M.OUTPUT_NAME = 'lint'
+
+-- Diagnostic source, also the quickfix title
+M.SOURCE = 'ContainerLint'
+
+-- Exit code of golangci-lint when issues were found
+M.ISSUES_EXIT_CODE = 1
+
+-- Quickfix type by issue severity; issues without one are warnings
+local SEVERITY_TYPES = { error = 'E', warning = 'W', [''] = 'W' }
+
+-- Linter run in progress: { job_id, cancelled }
+local running = nil
+
+-- Namespace of the lint diagnostics
+local namespace = nil
+
+local function get_namespace()
+  namespace = namespace or vim.api.nvim_create_namespace('container_lint')
+  return namespace
+end
+
+-- Command line of a run: the configured command and arguments, then the :ContainerLint arguments
+-- @param lint_config table: lint settings (cmd, args)
+-- @param args table|nil
+-- @return table: argv
+function M.build_command(lint_config, args)
+  local cmd = { lint_config.cmd or 'golangci-lint' }
+  vim.list_extend(cmd, lint_config.args or {})
+  vim.list_extend(cmd, args or {})
+  return cmd
+end
+
+-- Parse the JSON report of golangci-lint into quickfix items with host paths
+-- File names are relative to the working directory of the linter, or container paths.
+-- @param stdout string
+-- @param ctx table: { host_root, container_root, host_dir }
+-- @return table|nil: quickfix items
+-- @return string|nil: error message when no report was found
+function M.parse_report(stdout, ctx)
+  -- The report is a single JSON object; text output configured besides it is skipped
+  local report = nil
+  for line in (stdout or ''):gmatch('[^\n]+') do
+    if line:match('^%s*{') then
+      local ok, decoded = pcall(vim.json.decode, line)
+      if ok and type(decoded) == 'table' then
+        report = decoded
+        break
+      end
+    end
+  end
+  if not report then
+    return nil, 'no JSON report in the linter output (is --out-format=json among lint.args?)'
+  end
+
+  local test = require('container.test')
+  local fs = require('container.utils.fs')
+  local items = {}
+  for _, issue in ipairs(type(report.Issues) == 'table' and report.Issues or {}) do
+    local pos = issue.Pos or {}
+    local filename = nil
+    if type(pos.Filename) == 'string' and pos.Filename ~= '' then
+      if pos.Filename:match('^/') then
+        filename = test.map_path(pos.Filename, ctx.container_root, ctx.host_root)
+      else
+        filename = fs.resolve_path(pos.Filename, ctx.host_dir)
+      end
+    end
+    if filename then
+      local severity = type(issue.Severity) == 'string' and issue.Severity:lower() or ''
+      table.insert(items, {
+        filename = filename,
+        lnum = tonumber(pos.Line) or 1,
+        col = tonumber(pos.Column) or 0,
+        text = issue.FromLinter and string.format('%s (%s)', issue.Text or '', issue.FromLinter) or issue.Text,
+        type = SEVERITY_TYPES[severity] or 'I',
+      })
+    else
+      log.debug('Lint issue outside the workspace skipped: %s', tostring(pos.Filename))
+    end
+  end
+  return items
+end
+
+-- Diagnostics of quickfix items grouped by host file
+-- @param items table
+-- @return table: filename -> list of vim.diagnostic items
+function M.to_diagnostics(items)
+  local severities = { E = vim.diagnostic.severity.ERROR, W = vim.diagnostic.severity.WARN }
+  local by_file = {}
+  for _, item in ipairs(items) do
+    by_file[item.filename] = by_file[item.filename] or {}
+    table.insert(by_file[item.filename], {
+      lnum = math.max(item.lnum - 1, 0),
+      col = math.max(item.col - 1, 0),
+      message = item.text,
+      severity = severities[item.type] or vim.diagnostic.severity.INFO,
+      source = M.SOURCE,
+    })
+  end
+  return by_file
+end
+
+-- Replace the lint diagnostics
+-- @param items table: quickfix items
+function M.set_diagnostics(items)
+  local ns = get_namespace()
+  vim.diagnostic.reset(ns)
+  for filename, diagnostics in pairs(M.to_diagnostics(items)) do
+    vim.diagnostic.set(ns, vim.fn.bufadd(filename), diagnostics)
+  end
+end
+
+-- Clear the lint diagnostics
+function M.clear_diagnostics()
+  if namespace then
+    vim.diagnostic.reset(namespace)
+  end
+end
+
+-- Check whether a run is in progress
+function M.is_running()
+  return running ~= nil
+end
+
+-- Stop the running linter
+function M.stop()
+  if running then
+    running.cancelled = true
+    pcall(vim.fn.jobstop, running.job_id)
+    running = nil
+  end
+end
+
+-- Make sure the linter is installed in the container, offering to go install it
+-- @param lint_config table
+-- @param callback function(ok, err)
+function M._ensure_installed(lint_config, callback)
+  local container = require('container')
+  local cmd = lint_config.cmd or 'golangci-lint'
+  local result = container.exec({ 'sh', '-c', 'command -v "$0"', cmd })
+  if result and result.code == 0 then
+    callback(true)
+    return
+  end
+  if not lint_config.install_package or lint_config.install_package == '' then
+    callback(false, cmd .. ' is not installed in the container')
+    return
+  end
+
+  local choice = vim.fn.confirm(
+    string.format('%s is not installed in the container. Install it with go install?', cmd),
+    '&Yes\n&No',
+    1
+  )
+  if choice ~= 1 then
+    callback(false, cmd .. ' is not installed in the container')
+    return
+  end
+
+  local progress = require('container.ui.progress')
+  local token = progress.begin('Installing ' .. cmd, 'go install ' .. lint_config.install_package)
+  container.exec({ 'go', 'install', lint_config.install_package }, {
+    callback = function(install_result)
+      vim.schedule(function()
+        if install_result.code ~= 0 then
+          local output = install_result.stderr ~= '' and install_result.stderr or install_result.stdout
+          progress.finish(token, false, 'go install failed')
+          callback(false, 'go install failed: ' .. vim.trim(output))
+          return
+        end
+        progress.finish(token, true, cmd .. ' installed in container')
+        callback(true)
+      end)
+    end,
+  })
+end
+
+-- Lint in the container and load the issues into the quickfix list
+-- The linter runs in the Go module of the current file, or in the workspace folder.
+-- @param args table|nil: arguments appended to lint.args, e.g. packages
+-- @return boolean: true when the run was started
+function M.run(args)
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+  if running then
+    notify.warn('The linter is already running')
+    return false
+  end
+
+  local lint_config = require('container.config').get_value('lint') or {}
+  local container_config = container.get_state().current_config or {}
+  local fs = require('container.utils.fs')
+  local test = require('container.test')
+  local file = vim.fn.expand('%:p')
+  local folder = require('container.workspace_folders').for_path(container_config, file ~= '' and file or nil)
+  local host_dir = file ~= '' and test.find_go_module(fs.dirname(file)) or folder.host
+  local container_dir = test.map_path(host_dir, folder.host, folder.container)
+  if not container_dir then
+    host_dir, container_dir = folder.host, folder.container
+  end
+  local ctx = { host_root = folder.host, container_root = folder.container, host_dir = host_dir }
+
+  local run = { cancelled = false }
+  running = run
+  M._ensure_installed(lint_config, function(ok, err)
+    if run.cancelled then
+      return
+    end
+    if not ok then
+      running = nil
+      notify.error(err)
+      return
+    end
+    M._start(container, container_id, lint_config, args, container_dir, ctx, run)
+  end)
+  return true
+end
+
+-- Start the linter job of a run
+function M._start(container, container_id, lint_config, args, container_dir, ctx, run)
+  local lint_cmd = M.build_command(lint_config, args)
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, container._build_exec_args(container_id, lint_cmd, { cwd = container_dir }))
+  log.info('Linting in container: %s (cwd: %s)', table.concat(lint_cmd, ' '), container_dir)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.append(M.OUTPUT_NAME, { '$ ' .. table.concat(lint_cmd, ' ') .. '  (in ' .. container_dir .. ')', '' })
+  local progress = require('container.ui.progress')
+  local token = progress.begin('Linting', container_dir)
+
+  local stdout = {}
+  -- Log lines stream while the linter runs
+  local on_stderr, remaining = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      output.append(M.OUTPUT_NAME, lines)
+      local last = vim.trim(lines[#lines])
+      if last ~= '' and not run.cancelled then
+        progress.report(token, last:sub(1, 80))
+      end
+    end)
+  end)
+  local job_id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    stdout_buffered = true,
+    on_stdout = function(_, data)
+      stdout = data or {}
+    end,
+    on_stderr = on_stderr,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        if run.cancelled then
+          progress.cancel(token)
+          return
+        end
+        running = nil
+        local rest = remaining()
+        if #rest > 0 then
+          output.append(M.OUTPUT_NAME, rest)
+        end
+
+        local items, parse_err = M.parse_report(table.concat(stdout, '\n'), ctx)
+        if not items then
+          output.append(M.OUTPUT_NAME, stdout)
+          progress.finish(token, false, string.format('Linter exited with code %d: %s', exit_code, parse_err))
+          output.open(M.OUTPUT_NAME)
+          return
+        end
+        if exit_code ~= 0 and exit_code ~= M.ISSUES_EXIT_CODE then
+          log.warn('Linter exited with code %d', exit_code)
+        end
+
+        vim.fn.setqflist({}, ' ', { title = M.SOURCE, items = items })
+        if lint_config.diagnostics then
+          M.set_diagnostics(items)
+        end
+        output.append(M.OUTPUT_NAME, { '', string.format('<== %d issue(s)', #items) })
+        if #items == 0 then
+          progress.finish(token, true, 'No lint issues')
+        else
+          progress.finish(token, true, string.format('%d lint issue(s)', #items))
+          vim.cmd('copen')
+        end
+      end)
+    end,
+  })
+
+  if job_id <= 0 then
+    running = nil
+    progress.finish(token, false, 'Failed to start docker exec')
+    return
+  end
+  run.job_id = job_id
+end
+
+return M
@@ -0,0 +1,265 @@
+-- lua/container/log.lua
+-- Plugin log with levels (trace, debug, info, warn, error)
+-- Entries at or above log_level are kept in a ring buffer of log_buffer_size entries, shown by :ContainerLog, and
+-- optionally appended to a file. Values of sensitive variables (tokens, passwords, keys) are redacted before an
+-- entry is stored. container.utils.log is the same module.
+
+local M = {}
+
+local log_levels = {
+  TRACE = 0,
+  DEBUG = 1,
+  INFO = 2,
+  WARN = 3,
+  ERROR = 4,
+}
+
+local log_level_names = {
+  [0] = 'TRACE',
+  [1] = 'DEBUG',
+  [2] = 'INFO',
+  [3] = 'WARN',
+  [4] = 'ERROR',
+}
+
+M.levels = log_levels
+
+-- Name of the output buffer of :ContainerLog
+M.OUTPUT_NAME = 'log'
+
+-- Default configuration
+M.config = {
+  level = log_levels.INFO,
+  file = nil, -- Disable file logging if nil
+  console = true,
+  max_entries = 2000,
+}
+
+-- Variable names whose values are redacted (matched against the upper-cased name)
+M.SENSITIVE_NAMES = { 'TOKEN', 'SECRET', 'PASSWORD', 'PASSWD', 'PASSPHRASE', 'API_?KEY', 'PRIVATE_?KEY', 'CREDENTIAL' }
+
+M.REDACTED = '***'
+
+-- Values masked wherever they appear (loaded secrets, see container.secrets)
+local masked_values = {}
+
+-- Ring buffer: entries[1..count] starting at first
+local entries = {}
+local first = 1
+local count = 0
+
+-- Whether the :ContainerLog buffer follows new entries
+local following = false
+
+-- Set log level
+function M.set_level(level)
+  if type(level) == 'string' then
+    M.config.level = log_levels[level:upper()] or log_levels.INFO
+  else
+    M.config.level = level
+  end
+end
+
+-- Set log file
+function M.set_file(filepath)
+  M.config.file = filepath
+end
+
+-- Set the number of entries kept; the oldest entries are dropped
+function M.set_max_entries(max_entries)
+  local kept = M.entries()
+  M.config.max_entries = math.max(max_entries or 0, 1)
+  entries, first, count = {}, 1, 0
+  for i = math.max(#kept - M.config.max_entries + 1, 1), #kept do
+    entries[#entries + 1] = kept[i]
+    count = count + 1
+  end
+end
+
+-- Set the values masked in every entry, replacing the previous ones
+-- @param list table: strings
+function M.set_masked_values(list)
+  masked_values = {}
+  for _, value in ipairs(list or {}) do
+    if type(value) == 'string' and value ~= '' then
+      table.insert(masked_values, value)
+    end
+  end
+end
+
+-- Check whether a variable name holds a secret
+function M.is_sensitive(name)
+  local upper = name:upper()
+  for _, pattern in ipairs(M.SENSITIVE_NAMES) do
+    if upper:find(pattern) then
+      return true
+    end
+  end
+  return false
+end
+
+-- Redact values of sensitive variables in a message
+-- Covers NAME=value (bare or quoted, as in -e and --build-arg flags and env files), "NAME": "value" (JSON),
+-- the value after --password and the values set with set_masked_values().
+-- @param text string
+-- @return string
+function M.redact(text)
+  for _, value in ipairs(masked_values) do
+    local parts = {}
+    local position = 1
+    while true do
+      local start, finish = text:find(value, position, true)
+      if not start then
+        break
+      end
+      table.insert(parts, text:sub(position, start - 1) .. M.REDACTED)
+      position = finish + 1
+    end
+    table.insert(parts, text:sub(position))
+    text = table.concat(parts)
+  end
+  local function assignment(name, separator, value)
+    if M.is_sensitive(name) then
+      return name .. separator .. M.REDACTED
+    end
+  end
+  text = text:gsub('([%a_][%w_]*)(=)(%b\'\')', assignment)
+  text = text:gsub('([%a_][%w_]*)(=)(%b"")', assignment)
+  text = text:gsub('([%a_][%w_]*)(=)([^%s\'",}]+)', assignment)
+  text = text:gsub('"([%a_][%w_]*)"(%s*:%s*)(%b"")', function(name, separator)
+    if M.is_sensitive(name) then
+      return '"' .. name .. '"' .. separator .. '"' .. M.REDACTED .. '"'
+    end
+  end)
+  text = text:gsub('(%-%-password[=%s]+)(%S+)', '%1' .. M.REDACTED)
+  return text
+end
+
+-- Entries kept, oldest first
+-- @return table: { time, level, message }
+function M.entries()
+  local result = {}
+  local size = M.config.max_entries
+  for i = 0, count - 1 do
+    result[#result + 1] = entries[(first - 1 + i) % size + 1]
+  end
+  return result
+end
+
+-- Remove all entries
+function M.clear()
+  entries, first, count = {}, 1, 0
+end
+
+-- Line of an entry in the buffer and the file
+function M.format_entry(entry)
+  return string.format('[%s] [%s] %s', entry.time, log_level_names[entry.level] or 'UNKNOWN', entry.message)
+end
+
+local function store(entry)
+  local size = M.config.max_entries
+  if count < size then
+    entries[(first - 1 + count) % size + 1] = entry
+    count = count + 1
+  else
+    entries[first] = entry
+    first = first % size + 1
+  end
+end
+
+-- Internal log function
+local function log(level, msg, ...)
+  if level < M.config.level then
+    return
+  end
+
+  local ok, formatted_msg = pcall(string.format, msg, ...)
+  if not ok then
+    formatted_msg = tostring(msg)
+  end
+  local entry = { time = os.date('%Y-%m-%d %H:%M:%S'), level = level, message = M.redact(formatted_msg) }
+  store(entry)
+  local log_line = M.format_entry(entry)
+
+  -- Console output - only for DEBUG level or when explicitly enabled
+  -- This removes automatic notification routing to prevent duplicate messages
+  if M.config.console and level == log_levels.DEBUG then
+    print(log_line)
+  end
+
+  -- File output
+  if M.config.file then
+    local file = io.open(M.config.file, 'a')
+    if file then
+      file:write(log_line .. '\n')
+      file:close()
+    end
+  end
+
+  if following then
+    vim.schedule(function()
+      local output = require('container.ui.output')
+      if following and output.exists(M.OUTPUT_NAME) then
+        output.append(M.OUTPUT_NAME, vim.split(log_line, '\n', { plain = true }))
+      end
+    end)
+  end
+end
+
+-- Public functions
+function M.trace(msg, ...)
+  log(log_levels.TRACE, msg, ...)
+end
+
+function M.debug(msg, ...)
+  log(log_levels.DEBUG, msg, ...)
+end
+
+function M.info(msg, ...)
+  log(log_levels.INFO, msg, ...)
+end
+
+function M.warn(msg, ...)
+  log(log_levels.WARN, msg, ...)
+end
+
+function M.error(msg, ...)
+  log(log_levels.ERROR, msg, ...)
+end
+
+-- Show the kept entries in the container://log buffer, which then follows new entries
+function M.open()
+  local output = require('container.ui.output')
+  local lines = {}
+  for _, entry in ipairs(M.entries()) do
+    vim.list_extend(lines, vim.split(M.format_entry(entry), '\n', { plain = true }))
+  end
+  if #lines == 0 then
+    lines = { string.format('No log entries at level %s or above', log_level_names[M.config.level] or '?') }
+  end
+  output.set_lines(M.OUTPUT_NAME, lines)
+  local buf, win = output.open(M.OUTPUT_NAME, { focus = true })
+  vim.api.nvim_win_set_cursor(win, { vim.api.nvim_buf_line_count(buf), 0 })
+  following = true
+end
+
+-- Helper function to format log messages for explicit notification use
+-- This allows other modules to get formatted log messages for user notifications
+function M.format_message(level, msg, ...)
+  return string.format(msg, ...)
+end
+
+-- Convenience functions to get formatted messages without logging
+function M.format_info(msg, ...)
+  return M.format_message(log_levels.INFO, msg, ...)
+end
+
+function M.format_warn(msg, ...)
+  return M.format_message(log_levels.WARN, msg, ...)
+end
+
+function M.format_error(msg, ...)
+  return M.format_message(log_levels.ERROR, msg, ...)
+end
+
+return M
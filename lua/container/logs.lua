@@ -0,0 +1,176 @@
+-- lua/container/logs.lua
+-- Stream container (or compose service) logs into a reusable output buffer
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for logs
+M.OUTPUT_NAME = 'logs'
+
+-- Lines shown when neither --tail nor --since is given
+M.DEFAULT_TAIL = 100
+
+-- Job streaming into the buffer
+local job_id = nil
+
+-- Parse :ContainerLogs arguments
+-- [service] [--since=<time>] [--tail=<n>|all] [--no-follow]
+-- @param fargs table: command arguments
+-- @return table|nil, string|nil: { service, since, tail, follow } or an error
+function M.parse_args(fargs)
+  local opts = { follow = true }
+  local i = 1
+  while i <= #fargs do
+    local arg = fargs[i]
+    local flag, value = arg:match('^%-%-([%w-]+)=(.*)$')
+    if not flag and (arg == '--since' or arg == '--tail') then
+      flag, value = arg:sub(3), fargs[i + 1]
+      i = i + 1
+      if not value then
+        return nil, string.format('%s needs a value', arg)
+      end
+    end
+
+    if flag == 'since' then
+      opts.since = value
+    elseif flag == 'tail' then
+      if value ~= 'all' and not value:match('^%d+$') then
+        return nil, 'Invalid --tail value: ' .. value
+      end
+      opts.tail = value
+    elseif arg == '--no-follow' then
+      opts.follow = false
+    elseif arg == 'follow' or arg == 'f' then
+      -- Accepted for compatibility; logs follow by default
+      opts.follow = true
+    elseif arg:match('^%-') then
+      return nil, 'Unknown option: ' .. arg
+    elseif opts.service then
+      return nil, 'Only one service can be given'
+    else
+      opts.service = arg
+    end
+    i = i + 1
+  end
+  return opts
+end
+
+-- Build the logs command
+-- Compose devcontainers read the logs of a service (the attached one unless opts.service is given)
+-- @param config table: normalized devcontainer configuration
+-- @param container_id string: attached container
+-- @param opts table: { service, since, tail, follow }
+-- @return table: command for jobstart
+function M.build_command(config, container_id, opts)
+  local compose = require('container.docker.compose')
+  local cmd = { require('container.docker.runtime').get() }
+
+  local is_compose = compose.is_compose_config(config)
+  if is_compose then
+    vim.list_extend(cmd, compose.build_base_args(config, true))
+    vim.list_extend(cmd, { 'logs', '--no-log-prefix' })
+  else
+    table.insert(cmd, 'logs')
+  end
+
+  if opts.follow then
+    table.insert(cmd, '-f')
+  end
+  if opts.since then
+    vim.list_extend(cmd, { '--since', opts.since })
+  end
+  local tail = opts.tail or (not opts.since and tostring(M.DEFAULT_TAIL) or nil)
+  if tail then
+    vim.list_extend(cmd, { '--tail', tail })
+  end
+
+  table.insert(cmd, is_compose and (opts.service or config.service) or container_id)
+  return cmd
+end
+
+-- Services of a compose devcontainer (for completion)
+function M.list_services()
+  local config = require('container').get_state().current_config
+  local compose = require('container.docker.compose')
+  if not compose.is_compose_config(config) then
+    return {}
+  end
+  local compose_config = compose.get_compose_config(config)
+  local services = vim.tbl_keys(compose_config and compose_config.services or {})
+  table.sort(services)
+  return services
+end
+
+-- Stop streaming logs
+function M.stop()
+  if job_id then
+    pcall(vim.fn.jobstop, job_id)
+    job_id = nil
+  end
+end
+
+-- Show logs in the output buffer, replacing the previous stream
+-- @param opts table: { service, since, tail, follow }
+function M.show(opts)
+  opts = opts or {}
+  local state = require('container').get_state()
+  if not state.current_container then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local config = state.current_config or {}
+  if opts.service and not require('container.docker.compose').is_compose_config(config) then
+    notify.error('Services can only be selected for Docker Compose devcontainers')
+    return false
+  end
+
+  M.stop()
+  local cmd = M.build_command(config, state.current_container, opts)
+  log.info('Showing logs: %s', table.concat(cmd, ' '))
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.append(M.OUTPUT_NAME, { '$ ' .. table.concat(cmd, ' ') })
+  output.open(M.OUTPUT_NAME, { focus = true })
+
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      output.append(M.OUTPUT_NAME, lines)
+    end)
+  end)
+
+  local id
+  id = vim.fn.jobstart(cmd, {
+    cwd = config.compose_project_dir,
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        -- A replaced stream is not reported
+        if job_id ~= id then
+          return
+        end
+        job_id = nil
+        local rest = remaining()
+        if #rest > 0 then
+          output.append(M.OUTPUT_NAME, rest)
+        end
+        if exit_code ~= 0 or opts.follow then
+          output.append(M.OUTPUT_NAME, { '', string.format('<== logs exited with code %d', exit_code) })
+        end
+      end)
+    end,
+  })
+
+  if id <= 0 then
+    notify.error('Failed to start container logs')
+    return false
+  end
+  job_id = id
+  return true
+end
+
+return M
@@ -110,7 +110,7 @@ function M.create_stdio_bridge(container_id, cmd, server_name)
 
   -- Build docker exec command
   local docker_cmd = {
-    'docker',
+    require('container.docker.runtime').get(),
     'exec',
     '-i',
     container_id,
@@ -271,7 +271,7 @@ function M.get_client_cmd(server_name, server_config, container_id)
   -- This bypasses the complexity of stdio bridges and should work reliably
 
   local cmd = {
-    'docker',
+    require('container.docker.runtime').get(),
     'exec',
     '-i',
   }
@@ -0,0 +1,148 @@
+-- lua/container/lsp/gomod.lua
+-- Keep gopls in step with go.mod, go.sum and go.work
+-- gopls does not always pick up module changes made behind its back (a go.mod edit, `go get` in the container).
+-- Writes of these files in Neovim and changes in the gopls root directory (seen through the bind mount) are
+-- collected for lsp.go_mod_debounce milliseconds; then lsp.go_mod_change decides what happens: 'restart' restarts
+-- the gopls client, 'notify' sends workspace/didChangeWatchedFiles and 'off' does nothing.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Files of a Go module or workspace
+M.FILES = { ['go.mod'] = true, ['go.sum'] = true, ['go.work'] = true, ['go.work.sum'] = true }
+
+-- FileChangeType.Changed of the LSP specification
+local CHANGED = 2
+
+-- Changed paths waiting for the debounce timer
+local pending = {}
+local timer = nil
+-- fs_event handles by watched directory
+local watchers = {}
+
+local function setting(key, default)
+  local ok, plugin_config = pcall(require, 'container.config')
+  local value = ok and plugin_config.get_value('lsp.' .. key)
+  if value == nil then
+    return default
+  end
+  return value
+end
+
+-- Check whether a path is a Go module or workspace file
+-- @param path string
+-- @return boolean
+function M.is_module_file(path)
+  return M.FILES[vim.fn.fnamemodify(path, ':t')] == true
+end
+
+-- workspace/didChangeWatchedFiles parameters for changed host paths
+-- @param paths table
+-- @return table
+function M.watched_files_params(paths)
+  local changes = {}
+  for _, path in ipairs(paths) do
+    table.insert(changes, { uri = vim.uri_from_fname(path), type = CHANGED })
+  end
+  return { changes = changes }
+end
+
+-- Apply lsp.go_mod_change for changed files
+-- @param paths table: host paths
+function M.apply(paths)
+  local action = setting('go_mod_change', 'restart')
+  local clients = vim.lsp.get_clients and vim.lsp.get_clients({ name = 'container_gopls' })
+    or vim.lsp.get_active_clients({ name = 'container_gopls' })
+  if action == 'off' or #clients == 0 then
+    return
+  end
+
+  log.info('LSP: %s changed (%s)', table.concat(paths, ', '), action)
+  if action == 'notify' then
+    for _, client in ipairs(clients) do
+      client.notify('workspace/didChangeWatchedFiles', M.watched_files_params(paths))
+    end
+    return
+  end
+  require('container.lsp.init').restart({ 'gopls' }, function(restarted)
+    if #restarted > 0 then
+      local file = vim.fn.fnamemodify(paths[1], ':t')
+      require('container.utils.notify').status('Restarted gopls after a change of ' .. file)
+    end
+  end)
+end
+
+-- Record a changed file; changes within lsp.go_mod_debounce milliseconds are applied together
+-- @param path string: host path
+function M.changed(path)
+  if not M.is_module_file(path) then
+    return
+  end
+  if not vim.tbl_contains(pending, path) then
+    table.insert(pending, path)
+  end
+  timer = timer or (vim.uv or vim.loop).new_timer()
+  timer:stop()
+  timer:start(
+    setting('go_mod_debounce', 1000),
+    0,
+    vim.schedule_wrap(function()
+      local paths = pending
+      pending = {}
+      M.apply(paths)
+    end)
+  )
+end
+
+-- Watch the module files of a directory (the gopls root) and of the buffers written in Neovim
+-- @param dir string|nil: host directory
+function M.watch(dir)
+  local group = vim.api.nvim_create_augroup('ContainerGoMod', { clear = true })
+  vim.api.nvim_create_autocmd('BufWritePost', {
+    group = group,
+    pattern = vim.tbl_keys(M.FILES),
+    callback = function(args)
+      M.changed(vim.fn.fnamemodify(args.file, ':p'))
+    end,
+  })
+
+  if not dir or watchers[dir] then
+    return
+  end
+  -- Files written outside Neovim are only noticed where the loop provides file system events
+  local uv = vim.uv or vim.loop
+  local handle = uv and uv.new_fs_event and uv.new_fs_event()
+  if not handle then
+    return
+  end
+  local ok = handle:start(dir, {}, function(err, filename)
+    if not err and filename and M.FILES[filename] then
+      vim.schedule(function()
+        M.changed(dir .. '/' .. filename)
+      end)
+    end
+  end)
+  if ok then
+    watchers[dir] = handle
+    log.debug('LSP: Watching Go module files in %s', dir)
+  else
+    handle:close()
+  end
+end
+
+-- Stop watching
+function M.unwatch()
+  pcall(vim.api.nvim_del_augroup_by_name, 'ContainerGoMod')
+  for dir, handle in pairs(watchers) do
+    handle:stop()
+    handle:close()
+    watchers[dir] = nil
+  end
+  if timer then
+    timer:stop()
+  end
+  pending = {}
+end
+
+return M
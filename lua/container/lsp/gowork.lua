@@ -0,0 +1,195 @@
+-- lua/container/lsp/gowork.lua
+-- Go workspace layout for gopls: go.work files and repositories holding several modules
+-- gopls has to be rooted where go.work lives (or see every module) for cross-module navigation.
+
+local M = {}
+
+local log = require('container.utils.log')
+local fs = require('container.utils.fs')
+
+-- Directories not searched for nested modules
+local SKIP_DIRS = { vendor = true, testdata = true, node_modules = true, ['.git'] = true }
+
+-- How deep below the workspace root nested go.mod files are looked for
+M.MAX_MODULE_DEPTH = 4
+
+-- Directory to start searching upward from (the file's directory, or the path itself)
+local function start_dir(path)
+  if fs.is_directory(path) then
+    return fs.normalize_path(path)
+  end
+  return vim.fn.fnamemodify(path, ':h')
+end
+
+-- Find the go.work file that applies to a path (the nearest one above it, as the go command does)
+-- @param path string: file or directory
+-- @return string|nil: absolute path of go.work
+function M.find_go_work(path)
+  if not path or path == '' then
+    return nil
+  end
+  return fs.find_file_upward(start_dir(path), 'go.work')
+end
+
+-- Read the module directories listed by the use directives of a go.work file
+-- @param go_work string: path of go.work
+-- @return table: absolute module directories
+function M.parse_use(go_work)
+  local content = fs.read_file(go_work)
+  if not content then
+    return {}
+  end
+
+  local base = vim.fn.fnamemodify(go_work, ':h')
+  local modules = {}
+  local in_block = false
+
+  local function add(dir)
+    dir = dir:gsub('^"(.*)"$', '%1')
+    if dir ~= '' then
+      -- simplify() resolves ../ so modules outside the workspace are recognized as such
+      table.insert(modules, vim.fn.simplify(fs.resolve_path(dir, base)))
+    end
+  end
+
+  for line in content:gmatch('[^\r\n]+') do
+    line = vim.trim((line:gsub('//.*$', '')))
+    if in_block then
+      if line == ')' then
+        in_block = false
+      elseif line ~= '' then
+        add(line)
+      end
+    elseif line:match('^use%s*%($') then
+      in_block = true
+    else
+      local dir = line:match('^use%s+(%S+)$')
+      if dir then
+        add(dir)
+      end
+    end
+  end
+
+  return modules
+end
+
+-- Find the directories below root that contain a go.mod
+-- @param root string: directory to search
+-- @return table: absolute module directories, root first when it is a module itself
+function M.find_modules(root)
+  local modules = {}
+
+  local function search(dir, depth)
+    if fs.is_file(fs.join_path(dir, 'go.mod')) then
+      table.insert(modules, dir)
+    end
+    if depth >= M.MAX_MODULE_DEPTH then
+      return
+    end
+    local entries = fs.list_directory(dir)
+    table.sort(entries, function(a, b)
+      return a.name < b.name
+    end)
+    for _, entry in ipairs(entries) do
+      if entry.type == 'directory' and not SKIP_DIRS[entry.name] then
+        search(entry.path, depth + 1)
+      end
+    end
+  end
+
+  if root and fs.is_directory(root) then
+    search(fs.normalize_path(root), 0)
+  end
+  return modules
+end
+
+-- Work out the gopls workspace for a file
+-- 1. go.work above the file: root at the go.work directory, modules from its use directives
+-- 2. several go.mod below the workspace root: root at the workspace root, one workspace folder per module
+-- 3. otherwise the nearest go.mod
+-- @param fname string|nil: file being edited (the workspace root is used when empty)
+-- @param workspace_root string|nil: project root (defaults to the current directory)
+-- @return table|nil: { root = string, go_work = string|nil, modules = table }, nil outside Go code
+function M.detect(fname, workspace_root)
+  workspace_root = workspace_root or vim.fn.getcwd()
+  local from = (fname and fname ~= '') and fname or workspace_root
+
+  local go_work = M.find_go_work(from)
+  if go_work then
+    local layout = { root = vim.fn.fnamemodify(go_work, ':h'), go_work = go_work, modules = M.parse_use(go_work) }
+    log.debug('LSP: Using go.work %s (%d module(s))', go_work, #layout.modules)
+    return layout
+  end
+
+  local modules = M.find_modules(workspace_root)
+  if #modules > 1 then
+    log.debug('LSP: Found %d Go modules below %s without go.work', #modules, workspace_root)
+    return { root = fs.normalize_path(workspace_root), modules = modules }
+  end
+
+  local go_mod = fs.find_file_upward(start_dir(from), 'go.mod')
+  if go_mod then
+    local root = vim.fn.fnamemodify(go_mod, ':h')
+    return { root = root, modules = { root } }
+  end
+
+  return nil
+end
+
+-- gopls root directory for a file
+-- @return string|nil
+function M.find_root(fname, workspace_root)
+  local layout = M.detect(fname, workspace_root)
+  return layout and layout.root
+end
+
+-- Workspace folders to hand to gopls
+-- A go.work root is a single folder; modules without go.work are listed one by one.
+-- @param layout table: result of detect()
+-- @return table: list of { uri, name }
+function M.workspace_folders(layout)
+  local dirs = layout.go_work and { layout.root } or layout.modules
+  if #dirs == 0 then
+    dirs = { layout.root }
+  end
+  local folders = {}
+  for _, dir in ipairs(dirs) do
+    table.insert(folders, { uri = 'file://' .. dir, name = vim.fn.fnamemodify(dir, ':t') })
+  end
+  return folders
+end
+
+-- Remove -mod=mod from GOFLAGS: the go command rejects it in workspace mode
+-- @param goflags string|nil
+-- @return string|nil: cleaned flags, nil when nothing had to change
+function M.workspace_goflags(goflags)
+  if not goflags or not goflags:match('%-mod=mod') then
+    return nil
+  end
+  local flags = {}
+  for flag in goflags:gmatch('%S+') do
+    if flag ~= '-mod=mod' then
+      table.insert(flags, flag)
+    end
+  end
+  return table.concat(flags, ' ')
+end
+
+-- docker exec arguments that start gopls in the workspace
+-- @param layout table: result of detect()
+-- @param to_container function: host path -> container path
+-- @param goflags string|nil: GOFLAGS of the container environment
+-- @return table: arguments placed before the container id
+function M.exec_args(layout, to_container, goflags)
+  local args = { '-w', to_container(layout.root) }
+  if layout.go_work then
+    vim.list_extend(args, { '-e', 'GOWORK=' .. to_container(layout.go_work) })
+    local cleaned = M.workspace_goflags(goflags)
+    if cleaned then
+      vim.list_extend(args, { '-e', 'GOFLAGS=' .. cleaned })
+    end
+  end
+  return args
+end
+
+return M
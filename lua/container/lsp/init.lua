@@ -32,12 +32,18 @@ local state = {
   container_id = nil,
 }
 
+-- LSP state of containers belonging to other workspaces, keyed by container id
+local inactive_states = {}
+
 -- Track if path mappings have been initialized
 local path_mappings_initialized = false
 
 -- Track auto-initialization status per container to prevent duplicates
 local container_init_status = {} -- { [container_id] = "in_progress" | "completed" }
 
+-- Automatic restarts of dead servers by server name (lsp.auto_restart)
+local restart_counts = {}
+
 -- Initialize LSP module
 function M.setup(config)
   log.debug('LSP: Initializing LSP module')
@@ -142,6 +148,14 @@ function M._setup_auto_initialization()
       return
     end
 
+    -- A start still running the lifecycle commands up to waitFor sets up LSP once they have finished
+    local container = require('container')
+    local waiting_for = container.waiting_for and container.waiting_for()
+    if waiting_for then
+      log.debug('LSP: Waiting for %s before setting up container %s', waiting_for, container_id)
+      return
+    end
+
     -- Import language registry for language detection
     local language_registry = require('container.lsp.language_registry')
 
@@ -317,18 +331,26 @@ function M._setup_auto_initialization()
   })
 
   -- Fallback: Still handle FileType events for cases where container is already detected
+  -- Opening many files at once is debounced into a single check
+  local check_generation = 0
   vim.api.nvim_create_autocmd({ 'BufEnter', 'FileType' }, {
-    pattern = { '*.go', 'go' },
+    pattern = '*',
     group = auto_group,
     callback = function(args)
-      -- Only proceed for Go files
+      -- Only proceed for filetypes a known server handles
       local filetype = vim.bo[args.buf].filetype
-      if filetype ~= 'go' then
+      if not M.is_server_filetype(filetype) then
         return
       end
 
+      check_generation = check_generation + 1
+      local generation = check_generation
+
       -- Small delay to allow container detection to complete if in progress
       vim.defer_fn(function()
+        if generation ~= check_generation then
+          return
+        end
         local container = require('container')
         local state = container.get_state()
 
@@ -355,6 +377,111 @@ function M.set_container_id(container_id)
   end
 end
 
+-- Switch to the LSP state of another container, keeping the clients of the previous one
+-- Used when the active workspace changes; stop_all() only affects the active container.
+function M.switch_container(container_id)
+  if state.container_id == container_id then
+    return
+  end
+
+  if state.container_id then
+    inactive_states[state.container_id] = state
+  end
+
+  if container_id and inactive_states[container_id] then
+    state = inactive_states[container_id]
+    inactive_states[container_id] = nil
+  else
+    state = { servers = {}, clients = {}, port_mappings = {}, container_id = container_id }
+  end
+  log.debug('LSP: Switched to container %s', container_id or 'none')
+end
+
+-- LSP server executables probed on the container PATH
+-- When several servers cover a language, the first one found is started; companion servers (linters)
+-- run next to the main server of their languages.
+M.SERVERS = {
+  -- Lua
+  { name = 'lua_ls', cmd = 'lua-language-server', languages = { 'lua' } },
+  -- Python
+  { name = 'pylsp', cmd = 'pylsp', languages = { 'python' } },
+  { name = 'pyright', cmd = 'pyright-langserver', languages = { 'python' } },
+  -- JavaScript/TypeScript
+  { name = 'tsserver', cmd = 'typescript-language-server', languages = { 'javascript', 'typescript' } },
+  {
+    name = 'eslint',
+    cmd = 'vscode-eslint-language-server',
+    languages = { 'javascript', 'typescript' },
+    companion = true,
+  },
+  -- Go
+  { name = 'gopls', cmd = 'gopls', languages = { 'go' } },
+  -- Rust
+  { name = 'rust_analyzer', cmd = 'rust-analyzer', languages = { 'rust' } },
+  -- C/C++
+  { name = 'clangd', cmd = 'clangd', languages = { 'c', 'cpp' } },
+  -- Java
+  { name = 'jdtls', cmd = 'jdtls', languages = { 'java' } },
+  -- Ruby
+  { name = 'solargraph', cmd = 'solargraph', languages = { 'ruby' } },
+  -- PHP
+  { name = 'intelephense', cmd = 'intelephense', languages = { 'php' } },
+}
+
+-- Check whether a filetype is served by one of the known servers
+function M.is_server_filetype(filetype)
+  for _, server in ipairs(M.SERVERS) do
+    if vim.tbl_contains(server.languages, filetype) then
+      return true
+    end
+  end
+  return false
+end
+
+-- Choose the servers to start from the detected ones, one per language
+-- A language served by several detected servers gets a single process (requested servers win over
+-- the order of M.SERVERS), so buffers of that language attach to one client. Companion servers are
+-- always kept.
+-- @param servers table: detected servers keyed by name
+-- @param requested table|nil: servers requested by the devcontainer customizations
+-- @return table: list of server names in start order
+function M.select_servers(servers, requested)
+  requested = requested or {}
+  local order = {}
+  local others = {}
+  for _, server in ipairs(M.SERVERS) do
+    table.insert(requested[server.name] and order or others, server)
+  end
+  vim.list_extend(order, others)
+
+  local selected = {}
+  local covered = {}
+  for _, server in ipairs(order) do
+    local detected = servers[server.name]
+    if detected and requested[server.name] == false then
+      log.info('LSP: Skipping %s (disabled in devcontainer customizations)', server.name)
+    elseif detected and detected.available then
+      local duplicate = nil
+      if not server.companion then
+        for _, language in ipairs(server.languages) do
+          duplicate = duplicate or covered[language]
+        end
+      end
+      if duplicate then
+        log.info('LSP: Skipping %s, %s already serves its languages', server.name, duplicate)
+      else
+        table.insert(selected, server.name)
+        if not server.companion then
+          for _, language in ipairs(server.languages) do
+            covered[language] = server.name
+          end
+        end
+      end
+    end
+  end
+  return selected
+end
+
 -- Detect available LSP servers in the container
 function M.detect_language_servers()
   if not state.container_id then
@@ -364,33 +491,9 @@ function M.detect_language_servers()
 
   log.info('LSP: Detecting language servers in container')
 
-  -- Common LSP server executables to check
-  local common_servers = {
-    -- Lua
-    { name = 'lua_ls', cmd = 'lua-language-server', languages = { 'lua' } },
-    -- Python
-    { name = 'pylsp', cmd = 'pylsp', languages = { 'python' } },
-    { name = 'pyright', cmd = 'pyright-langserver', languages = { 'python' } },
-    -- JavaScript/TypeScript
-    { name = 'tsserver', cmd = 'typescript-language-server', languages = { 'javascript', 'typescript' } },
-    { name = 'eslint', cmd = 'vscode-eslint-language-server', languages = { 'javascript', 'typescript' } },
-    -- Go
-    { name = 'gopls', cmd = 'gopls', languages = { 'go' } },
-    -- Rust
-    { name = 'rust_analyzer', cmd = 'rust-analyzer', languages = { 'rust' } },
-    -- C/C++
-    { name = 'clangd', cmd = 'clangd', languages = { 'c', 'cpp' } },
-    -- Java
-    { name = 'jdtls', cmd = 'jdtls', languages = { 'java' } },
-    -- Ruby
-    { name = 'solargraph', cmd = 'solargraph', languages = { 'ruby' } },
-    -- PHP
-    { name = 'intelephense', cmd = 'intelephense', languages = { 'php' } },
-  }
-
   local detected_servers = {}
 
-  for _, server in ipairs(common_servers) do
+  for _, server in ipairs(M.SERVERS) do
     -- Lazy load docker module to avoid circular dependencies
     local docker = require('container.docker.init')
 
@@ -462,8 +565,17 @@ end
 
 -- Setup LSP servers in the container
 function M.setup_lsp_in_container()
-  if not M.config or not M.config.auto_setup then
-    log.debug('LSP: Auto-setup disabled or module not initialized')
+  if not M.config then
+    log.debug('LSP: Module not initialized')
+    return
+  end
+
+  -- Servers requested by the devcontainer customizations are set up even without auto_setup
+  local customizations = require('container.customizations')
+  local devcontainer_config = require('container').get_state().current_config
+  local requested = customizations.lsp_servers(devcontainer_config, M.config)
+  if not M.config.auto_setup and not customizations.requests_lsp(devcontainer_config, M.config) then
+    log.debug('LSP: Auto-setup disabled')
     return
   end
 
@@ -471,8 +583,19 @@ function M.setup_lsp_in_container()
   local setup_count = 0
   local skipped_count = 0
 
-  for name, server in pairs(servers) do
-    if server.available then
+  for name, request in pairs(requested) do
+    if request and not servers[name] then
+      log.warn('LSP: %s requested by %s is not installed in the container', name, request.source)
+    end
+  end
+
+  for _, name in ipairs(M.select_servers(servers, requested)) do
+    local server = servers[name]
+    local request = requested[name]
+    if not M.config.auto_setup and not request then
+      log.debug('LSP: Skipping %s (not requested by devcontainer customizations)', name)
+    else
+      server.options = request and request.options or nil
       -- Check if client already exists and clean up duplicates
       local container_client_name = 'container_' .. name
       local existing_clients = get_lsp_clients({ name = container_client_name })
@@ -499,6 +622,9 @@ function M.setup_lsp_in_container()
 
         -- Ensure client is attached to current Go buffers
         M._attach_to_existing_buffers(name, server, client.id)
+        if name == 'gopls' then
+          require('container.lsp.gomod').watch(client.config.root_dir)
+        end
       else
         log.info('LSP: Setting up %s', name)
         M.create_lsp_client(name, server)
@@ -521,6 +647,14 @@ function M.create_lsp_client(name, server_config)
     return
   end
 
+  -- Reuse the running client of the server instead of starting a second process
+  local exists, existing_id = M.client_exists(name)
+  if exists then
+    log.debug('LSP: Reusing running %s client (ID: %s)', name, existing_id)
+    M._attach_to_existing_buffers(name, server_config, existing_id)
+    return
+  end
+
   log.debug('Creating LSP client for %s', name)
 
   -- Initialize strategy selector if not already done
@@ -536,6 +670,9 @@ function M.create_lsp_client(name, server_config)
 
   log.info('LSP: Selected %s strategy for %s', chosen_strategy, name)
 
+  -- settings, init_options and root_dir overrides; read again on every start so they survive restarts
+  local client_server_config = M._apply_server_overrides(name, server_config)
+
   -- Prepare base LSP configuration
   local base_lsp_config = M._prepare_lsp_config(name, server_config)
 
@@ -544,7 +681,7 @@ function M.create_lsp_client(name, server_config)
     chosen_strategy,
     name,
     state.container_id,
-    server_config, -- Pass server_config directly
+    client_server_config,
     strategy_config
   )
 
@@ -579,6 +716,15 @@ function M.create_lsp_client(name, server_config)
     end
   end
 
+  -- A process exiting without being stopped is reported by the watchdog
+  local strategy_on_exit = lsp_config.on_exit
+  lsp_config.on_exit = function(code, signal, exited_client_id)
+    require('container.lsp.watchdog').exited(exited_client_id, code, signal)
+    if strategy_on_exit then
+      return strategy_on_exit(code, signal, exited_client_id)
+    end
+  end
+
   -- Start client directly using compatibility helper
   log.info('LSP: About to start LSP client for %s with command: %s', name, table.concat(lsp_config.cmd or {}, ' '))
   local client_id = start_lsp_client(lsp_config)
@@ -626,6 +772,11 @@ function M.create_lsp_client(name, server_config)
     server_config = server_config,
   }
 
+  -- Requests time out and a dead or hanging docker exec process is detected (lsp.request_timeout)
+  require('container.lsp.watchdog').watch(name, client, function(reason)
+    M._on_client_dead(name, client_id, reason)
+  end)
+
   -- Delay buffer attachment to ensure path transformation is fully set up
   vim.defer_fn(function()
     -- Verify client and transformation are ready
@@ -659,12 +810,36 @@ function M.create_lsp_client(name, server_config)
     -- Setup LSP commands keybindings for Go files if this is gopls
     if name == 'gopls' then
       M._setup_gopls_commands(client_id)
+      -- Changes of go.mod and go.sum restart gopls (lsp.go_mod_change)
+      require('container.lsp.gomod').watch(client.config.root_dir)
     end
   end, 100) -- 100ms delay ensures transformation setup completes
 
   log.info('LSP: Successfully started %s client directly', name)
 end
 
+-- Server config with the client overrides of a server applied
+-- lsp.servers[name] from the plugin config is merged first, then the options requested by the devcontainer
+-- customizations; their settings and init_options are merged into the defaults of the language and
+-- root_dir (a function(fname) returning the host root) replaces the detected root, and cwd the working directory.
+-- @param name string: server name
+-- @param server_config table: detected server
+-- @return table: copy of server_config with settings, init_options, root_dir and cwd
+function M._apply_server_overrides(name, server_config)
+  local servers = M.config and M.config.servers or {}
+  local overrides = vim.tbl_deep_extend('force', {}, servers[name] or {}, server_config.options or {})
+  local result = {}
+  for key, value in pairs(server_config) do
+    result[key] = value
+  end
+  for _, key in ipairs({ 'settings', 'init_options', 'root_dir', 'cwd' }) do
+    if overrides[key] ~= nil then
+      result[key] = overrides[key]
+    end
+  end
+  return result
+end
+
 -- Prepare LSP configuration for a server
 function M._prepare_lsp_config(name, server_config)
   local config = vim.tbl_deep_extend('force', {
@@ -678,10 +853,10 @@ function M._prepare_lsp_config(name, server_config)
     -- Root directory pattern - Strategy A: use host paths (unified via symlinks)
     root_dir = function(fname)
       local util = require('lspconfig.util')
-      -- Strategy A: For Go, find go.mod first, then fall back to git root
+      -- Strategy A: For Go, use the go.work / module root, then fall back to git root
       if name == 'gopls' then
-        -- Look for go.mod in current and parent directories
-        local go_root = util.root_pattern('go.mod', 'go.work')(fname)
+        -- go.work above the file wins over the go.mod of its module
+        local go_root = require('container.lsp.gowork').find_root(fname)
         if go_root then
           log.debug('LSP: Found Go root at %s for %s', go_root, fname)
           return go_root
@@ -717,10 +892,9 @@ function M._prepare_lsp_config(name, server_config)
       local current_file = vim.fn.expand('%:p')
       local workspace_root = vim.fn.getcwd()
 
-      -- For gopls, try to find the go.mod root if available
+      -- For gopls, try to find the go.work or go.mod root if available
       if name == 'gopls' and current_file ~= '' then
-        local util = require('lspconfig.util')
-        local go_root = util.root_pattern('go.mod', 'go.work')(current_file)
+        local go_root = require('container.lsp.gowork').find_root(current_file)
         if go_root then
           workspace_root = go_root
           log.debug('LSP: Using Go project root: %s', workspace_root)
@@ -752,8 +926,7 @@ function M._prepare_lsp_config(name, server_config)
 
       -- For gopls, use Go project root if available
       if name == 'gopls' and current_file ~= '' then
-        local util = require('lspconfig.util')
-        local go_root = util.root_pattern('go.mod', 'go.work')(current_file)
+        local go_root = require('container.lsp.gowork').find_root(current_file)
         if go_root then
           workspace_root = go_root
         end
@@ -866,7 +1039,7 @@ function M._prepare_lsp_config(name, server_config)
         M.config.on_attach(client, bufnr)
       end
     end,
-  }, M.config.servers[name] or {})
+  }, M.config.servers[name] or {}, server_config.options or {})
 
   return config
 end
@@ -883,6 +1056,10 @@ function M.stop_all()
   state.clients = {}
   state.port_mappings = {}
   state.container_id = nil
+  require('container.lsp.root').clear_cache()
+  require('container.lsp.gomod').unwatch()
+
+  restart_counts = {}
 
   -- Clear container initialization status
   container_init_status = {}
@@ -896,17 +1073,138 @@ function M.stop_client(name)
     return
   end
 
-  -- Stop any active LSP clients using container client name
+  -- Stop the client started for this container; other projects may run a client with the same name
   local container_client_name = 'container_' .. name
-  local clients = get_lsp_clients({ name = container_client_name })
-  for _, client in ipairs(clients) do
-    client.stop()
+  require('container.lsp.watchdog').unwatch(client_info.client_id)
+  local tracked = client_info.client_id and vim.lsp.get_client_by_id(client_info.client_id)
+  if tracked then
+    tracked.stop()
+  else
+    local clients = get_lsp_clients({ name = container_client_name })
+    for _, client in ipairs(clients) do
+      client.stop()
+    end
   end
 
   state.clients[name] = nil
   log.info('LSP: Stopped ' .. container_client_name)
 end
 
+-- Milliseconds restart() waits for clients to exit before they are killed
+M.RESTART_TIMEOUT = 5000
+
+-- Restart the container LSP clients of the current workspace
+-- The clients are stopped first and started again only once every one of them has exited, so a restart never
+-- leaves two clients of a server running; clients still running after RESTART_TIMEOUT are killed.
+-- @param names table|nil: servers to restart (default: every running server)
+-- @param callback function|nil: called with the names of the restarted servers
+function M.restart(names, callback)
+  local restarting = {}
+  for name, client_info in pairs(state.clients) do
+    if not names or vim.tbl_contains(names, name) then
+      table.insert(restarting, { name = name, client_id = client_info.client_id, server = client_info.server_config })
+    end
+  end
+  table.sort(restarting, function(a, b)
+    return a.name < b.name
+  end)
+  if #restarting == 0 then
+    log.info('LSP: No container LSP clients to restart')
+    if callback then
+      callback({})
+    end
+    return
+  end
+
+  -- Roots may move with the change that made the restart necessary (a new go.mod, a package.json)
+  require('container.lsp.root').clear_cache()
+  for _, entry in ipairs(restarting) do
+    M.stop_client(entry.name)
+  end
+
+  local function running_clients()
+    local running = {}
+    for _, entry in ipairs(restarting) do
+      local client = entry.client_id and vim.lsp.get_client_by_id(entry.client_id)
+      if client and not client.is_stopped() then
+        table.insert(running, client)
+      end
+    end
+    for _, entry in ipairs(restarting) do
+      for _, client in ipairs(get_lsp_clients({ name = 'container_' .. entry.name })) do
+        if not vim.tbl_contains(running, client) then
+          table.insert(running, client)
+        end
+      end
+    end
+    return running
+  end
+
+  local started_at = (vim.uv or vim.loop).now()
+  local function start_when_stopped()
+    local running = running_clients()
+    if #running > 0 and (vim.uv or vim.loop).now() - started_at < M.RESTART_TIMEOUT then
+      vim.defer_fn(start_when_stopped, 100)
+      return
+    end
+    for _, client in ipairs(running) do
+      log.warn('LSP: %s did not exit, killing it', client.name)
+      client.stop(true)
+    end
+
+    local restarted = {}
+    for _, entry in ipairs(restarting) do
+      if entry.server and state.container_id then
+        M.create_lsp_client(entry.name, entry.server)
+        table.insert(restarted, entry.name)
+      end
+    end
+    log.info('LSP: Restarted %s', table.concat(restarted, ', '))
+    if callback then
+      callback(restarted)
+    end
+  end
+  start_when_stopped()
+end
+
+-- Times lsp.auto_restart may start a dead server again
+M.MAX_AUTO_RESTARTS = 3
+
+-- A container LSP client reported dead by the watchdog
+-- The client is killed and forgotten, so Neovim no longer waits on it. With lsp.auto_restart it is started again
+-- (up to MAX_AUTO_RESTARTS times) as long as its container is running.
+-- @param name string: server name
+-- @param client_id number
+-- @param reason string
+function M._on_client_dead(name, client_id, reason)
+  local client_info = state.clients[name]
+  if not client_info or client_info.client_id ~= client_id then
+    return
+  end
+  state.clients[name] = nil
+  local client = vim.lsp.get_client_by_id(client_id)
+  if client and not client.is_stopped() then
+    client.stop(true)
+  end
+  log.error('LSP: %s stopped unexpectedly: %s', name, reason)
+
+  local notify = require('container.utils.notify')
+  local count = restart_counts[name] or 0
+  local container_id = state.container_id
+  local restart = M.config
+    and M.config.auto_restart
+    and count < M.MAX_AUTO_RESTARTS
+    and container_id
+    and require('container.docker').get_container_status(container_id) == 'running'
+  if not restart then
+    notify.warn(string.format('LSP %s stopped unexpectedly: %s', name, reason))
+    return
+  end
+  restart_counts[name] = count + 1
+  notify.warn(string.format('LSP %s stopped unexpectedly: %s. Restarting it', name, reason))
+  M.create_lsp_client(name, client_info.server_config)
+end
+
 -- Clear initialization status for a specific container
 function M.clear_container_init_status(container_id)
   if container_init_status[container_id] then
@@ -962,6 +1260,7 @@ function M._setup_auto_attach(server_name, server_config, client_id)
             end
 
             if not already_attached then
+              M._add_root_workspace_folder(client, buf)
               vim.lsp.buf_attach_client(buf, client_id)
               log.info('Auto-attached %s LSP to buffer %s (filetype: %s)', server_name, buf, ft)
             end
@@ -995,6 +1294,10 @@ function M._attach_to_existing_buffers(server_name, server_config, client_id)
         end
 
         if not already_attached then
+          local client = vim.lsp.get_client_by_id(client_id)
+          if client then
+            M._add_root_workspace_folder(client, buf)
+          end
           vim.lsp.buf_attach_client(buf, client_id)
           log.info('Attached %s LSP to existing buffer %s (filetype: %s)', server_name, buf, ft)
         end
@@ -1003,6 +1306,40 @@ function M._attach_to_existing_buffers(server_name, server_config, client_id)
   end
 end
 
+-- Add the root of a buffer to the workspace folders of a client
+-- In a monorepo buffers of another module than the one the client started in get their module root as a workspace
+-- folder, so the server analyzes them in the right module.
+-- @param client table: LSP client
+-- @param bufnr number
+function M._add_root_workspace_folder(client, bufnr)
+  if not state.container_id then
+    return
+  end
+  local root = require('container.lsp.root').for_buffer(state.container_id, bufnr)
+  if not root then
+    return
+  end
+
+  local uri = 'file://' .. root
+  for _, folder in ipairs(client.workspace_folders or {}) do
+    if folder.uri == uri then
+      return
+    end
+  end
+
+  local capabilities = client.server_capabilities and client.server_capabilities.workspace
+  if not (capabilities and capabilities.workspaceFolders and capabilities.workspaceFolders.supported) then
+    log.debug('LSP: %s does not support workspace folders, not adding %s', client.name, root)
+    return
+  end
+
+  local folder = { uri = uri, name = vim.fn.fnamemodify(root, ':t') }
+  client.workspace_folders = client.workspace_folders or {}
+  table.insert(client.workspace_folders, folder)
+  client.notify('workspace/didChangeWorkspaceFolders', { event = { added = { folder }, removed = {} } })
+  log.info('LSP: Added workspace folder %s to %s', root, client.name)
+end
+
 -- Setup gopls-specific commands and keybindings
 function M._setup_gopls_commands(client_id)
   local commands_ok, commands = pcall(require, 'container.lsp.commands')
@@ -1108,9 +1445,14 @@ function M._register_existing_files(client, language_config)
   local registered_count = 0
   local workspace_root = vim.fn.getcwd()
 
-  -- Find project root if available
-  local util = require('lspconfig.util')
-  local project_root = util.root_pattern(table.unpack(language_config.root_patterns))(vim.fn.expand('%:p'))
+  -- Find project root if available (for Go the go.work root, so every module of the workspace is registered)
+  local project_root
+  if language_config.server_name == 'gopls' then
+    project_root = require('container.lsp.gowork').find_root(vim.fn.expand('%:p'))
+  else
+    local util = require('lspconfig.util')
+    project_root = util.root_pattern(table.unpack(language_config.root_patterns))(vim.fn.expand('%:p'))
+  end
   if project_root then
     workspace_root = project_root
   end
@@ -4,13 +4,19 @@
 
 local M = {}
 local log = require('container.utils.log')
+local path_util = require('container.lsp.path')
 
 -- Path transformation configuration
 local path_config = {
   host_workspace = nil, -- Will be auto-detected
   container_workspace = '/workspace',
+  mappings = nil, -- List of { host, container } pairs
+  volume_workspace = false, -- Workspace in a volume: its files are opened as container:// buffers
 }
 
+-- Scheme of buffers read from the container (see container.explore)
+M.CONTAINER_SCHEME = 'container://'
+
 -- LSP methods that require path transformation
 local TRANSFORM_RULES = {
   -- Initialization messages
@@ -43,7 +49,7 @@ local TRANSFORM_RULES = {
   -- Language features
   ['textDocument/definition'] = {
     request_paths = { 'textDocument.uri' },
-    response_paths = { 'uri', 'targetUri', '[].uri' },
+    response_paths = { 'uri', 'targetUri', '[].uri', '[].targetUri' },
   },
 
   ['textDocument/references'] = {
@@ -53,17 +59,17 @@ local TRANSFORM_RULES = {
 
   ['textDocument/implementation'] = {
     request_paths = { 'textDocument.uri' },
-    response_paths = { 'uri', 'targetUri', '[].uri' },
+    response_paths = { 'uri', 'targetUri', '[].uri', '[].targetUri' },
   },
 
   ['textDocument/typeDefinition'] = {
     request_paths = { 'textDocument.uri' },
-    response_paths = { 'uri', 'targetUri', '[].uri' },
+    response_paths = { 'uri', 'targetUri', '[].uri', '[].targetUri' },
   },
 
   ['textDocument/declaration'] = {
     request_paths = { 'textDocument.uri' },
-    response_paths = { 'uri', 'targetUri', '[].uri' },
+    response_paths = { 'uri', 'targetUri', '[].uri', '[].targetUri' },
   },
 
   ['textDocument/hover'] = {
@@ -84,7 +90,7 @@ local TRANSFORM_RULES = {
   -- Diagnostics
   ['textDocument/publishDiagnostics'] = {
     request_paths = {},
-    notification_paths = { 'uri' },
+    notification_paths = { 'uri', 'diagnostics[].relatedInformation[].location.uri' },
   },
 
   -- Workspace operations
@@ -93,6 +99,11 @@ local TRANSFORM_RULES = {
     response_paths = {},
   },
 
+  ['workspace/didChangeWorkspaceFolders'] = {
+    request_paths = { 'event.added[].uri', 'event.removed[].uri' },
+    response_paths = {},
+  },
+
   ['workspace/symbol'] = {
     request_paths = {},
     response_paths = { '[].location.uri' },
@@ -100,19 +111,137 @@ local TRANSFORM_RULES = {
 }
 
 -- Setup path configuration for interception
+-- The container workspaceFolder maps to the host workspace root. Bind mounts from
+-- devcontainer.json, lsp.path_mappings and workspace_folders from the plugin config add further mappings.
 -- @param container_id string: target container ID
 -- @param host_workspace string|nil: host workspace path (auto-detected if nil)
-function M.setup_path_config(container_id, host_workspace)
-  path_config.host_workspace = host_workspace or vim.fn.getcwd()
+-- @param opts table|nil: { container_workspace = string, mounts = table, extra_mappings = table,
+--   volume_workspace = boolean }
+function M.setup_path_config(container_id, host_workspace, opts)
+  opts = opts or {}
+
+  local devcontainer_config = {}
+  if not opts.container_workspace or not opts.mounts then
+    local ok, container = pcall(require, 'container')
+    if ok and container.get_state then
+      devcontainer_config = container.get_state().current_config or {}
+    end
+  end
+
+  local extra_mappings = opts.extra_mappings
+  if not extra_mappings then
+    local config_ok, plugin_config = pcall(require, 'container.config')
+    extra_mappings = vim.deepcopy(config_ok and plugin_config.get_value('lsp.path_mappings') or {})
+    -- Each folder of workspace_folders maps with its own pair
+    local folders = config_ok and plugin_config.get_value('workspace_folders') or {}
+    for _, folder in ipairs(require('container.workspace_folders').configured(folders)) do
+      extra_mappings[folder.host] = folder.container
+    end
+  end
+
+  -- The workspace mount decides which host folder backs the container workspace
+  local mount_host, mount_container
+  if devcontainer_config.workspace_mount and not opts.container_workspace then
+    mount_host, mount_container = require('container.parser').workspace_roots(devcontainer_config)
+  end
+
+  path_config.host_workspace = mount_host or host_workspace or vim.fn.getcwd()
+  local parser = devcontainer_config.workspace_mount and require('container.parser')
+  path_config.volume_workspace = opts.volume_workspace or (parser and parser.is_volume_workspace(devcontainer_config))
+    or false
+  path_config.container_workspace = opts.container_workspace
+    or mount_container
+    or devcontainer_config.workspace_folder
+    or '/workspace'
   path_config.container_id = container_id
+  path_config.mappings = M.build_mappings(
+    path_config.host_workspace,
+    path_config.container_workspace,
+    opts.mounts or devcontainer_config.mounts,
+    extra_mappings
+  )
 
   log.info(
-    'Interceptor: Setup path config - Host: %s, Container: %s',
+    'Interceptor: Setup path config - Host: %s, Container: %s (%d mappings)',
     path_config.host_workspace,
-    path_config.container_workspace
+    path_config.container_workspace,
+    #path_config.mappings
   )
 end
 
+-- Build the list of host <-> container path mappings
+-- @param host_workspace string: host workspace root
+-- @param container_workspace string: container workspaceFolder
+-- @param mounts table|nil: normalized devcontainer mounts ({ type, source, target })
+-- @param extra table|nil: additional mappings, host path -> container path
+-- @return table: list of { host = string, container = string }
+function M.build_mappings(host_workspace, container_workspace, mounts, extra)
+  local mappings = {}
+  local seen = {}
+
+  local function add(host, container)
+    if type(host) ~= 'string' or type(container) ~= 'string' then
+      return
+    end
+    if not host:match('^/') or not container:match('^/') then
+      return
+    end
+    host = path_util.strip_trailing_slash(host)
+    container = path_util.strip_trailing_slash(container)
+    local key = host .. '\0' .. container
+    if not seen[key] then
+      seen[key] = true
+      table.insert(mappings, { host = host, container = container })
+    end
+  end
+
+  for host, container in pairs(extra or {}) do
+    add(vim.fn.expand(host), container)
+  end
+
+  for _, mount in ipairs(mounts or {}) do
+    if mount.type == 'bind' then
+      add(mount.source, mount.target)
+    end
+  end
+
+  add(host_workspace, container_workspace)
+
+  return mappings
+end
+
+-- Mapping with the longest prefix containing a path
+local function find_mapping(path, from)
+  local best
+  for _, mapping in ipairs(path_config.mappings or {}) do
+    if path_util.has_path_prefix(path, mapping[from]) and (not best or #mapping[from] > #best[from]) then
+      best = mapping
+    end
+  end
+  return best
+end
+
+-- Rewrite path from one side of the mappings to the other using the longest matching prefix
+-- @param path string: path to rewrite
+-- @param from string: "host" or "container"
+-- @param to string: "host" or "container"
+-- @return string|nil: rewritten path, or nil when no mapping matches
+local function map_path(path, from, to)
+  local best = find_mapping(path, from)
+  if not best then
+    return nil
+  end
+
+  return path_util.replace_prefix(path, best[from], best[to])
+end
+
+-- Check whether a host path is covered by a path mapping (and so visible in the container)
+-- @param path string: host path
+-- @return boolean
+function M.is_mapped_host_path(path)
+  return type(path) == 'string' and map_path(path, 'host', 'container') ~= nil
+end
+
 -- Transform path from host to container format
 -- @param path string: host path
 -- @return string: container path
@@ -121,21 +250,12 @@ local function host_to_container_path(path)
     return path
   end
 
-  local host_workspace = path_config.host_workspace
-  if not host_workspace then
-    log.warn('Interceptor: Host workspace not configured, cannot transform path: %s', path)
+  if not path_config.mappings then
+    log.warn('Interceptor: Path mappings not configured, cannot transform path: %s', path)
     return path
   end
 
-  -- Check if path is already in container format
-  if path:match('^' .. vim.pesc(path_config.container_workspace)) then
-    log.debug('Interceptor: Path already in container format, skipping transformation: %s', path)
-    return path
-  end
-
-  -- Handle both absolute paths and URIs
-  local transformed = path:gsub('^' .. vim.pesc(host_workspace), path_config.container_workspace)
-
+  local transformed = map_path(path, 'host', 'container') or path
   if transformed ~= path then
     log.debug('Interceptor: Transformed host->container: %s -> %s', path, transformed)
   end
@@ -151,20 +271,12 @@ local function container_to_host_path(path)
     return path
   end
 
-  local host_workspace = path_config.host_workspace
-  if not host_workspace then
-    log.warn('Interceptor: Host workspace not configured, cannot transform path: %s', path)
+  if not path_config.mappings then
+    log.warn('Interceptor: Path mappings not configured, cannot transform path: %s', path)
     return path
   end
 
-  -- Check if path is already in host format
-  if path:match('^' .. vim.pesc(host_workspace)) then
-    log.debug('Interceptor: Path already in host format, skipping transformation: %s', path)
-    return path
-  end
-
-  local transformed = path:gsub('^' .. vim.pesc(path_config.container_workspace), host_workspace)
-
+  local transformed = map_path(path, 'container', 'host') or path
   if transformed ~= path then
     log.debug('Interceptor: Transformed container->host: %s -> %s', path, transformed)
   end
@@ -172,7 +284,20 @@ local function container_to_host_path(path)
   return transformed
 end
 
+-- Check whether a container path lies in a workspace that only exists in a volume (no host file backs it)
+-- @param path string: container path
+-- @return boolean
+function M.is_volume_path(path)
+  if not path_config.volume_workspace or type(path) ~= 'string' then
+    return false
+  end
+  local mapping = find_mapping(path, 'container')
+  return mapping ~= nil and mapping.container == path_util.strip_trailing_slash(path_config.container_workspace)
+end
+
 -- Transform URIs in the given direction
+-- container:// URIs (buffers read from the container) carry the container path already; files of a volume
+-- workspace are returned to Neovim as container:// URIs so they open through container.explore.
 -- @param uri string: URI to transform
 -- @param direction string: "to_container" or "to_host"
 -- @return string: transformed URI
@@ -182,6 +307,10 @@ local function transform_uri(uri, direction)
     return uri
   end
 
+  if uri:sub(1, #M.CONTAINER_SCHEME) == M.CONTAINER_SCHEME then
+    return direction == 'to_container' and 'file://' .. uri:sub(#M.CONTAINER_SCHEME + 1) or uri
+  end
+
   -- Handle URIs without file:// scheme
   if not uri:match('^file://') then
     log.warn('Interceptor: URI missing file:// scheme: %s', uri)
@@ -196,26 +325,15 @@ local function transform_uri(uri, direction)
   end
 
   local path = uri:gsub('^file://', '')
-  local transformed_path
+  if direction == 'to_host' and M.is_volume_path(path) then
+    return M.CONTAINER_SCHEME .. path
+  end
 
-  -- Prevent unnecessary transformations by checking the current format
+  local transformed_path
   if direction == 'to_container' then
-    -- Only transform if it's not already in container format
-    if not path:match('^' .. vim.pesc(path_config.container_workspace)) then
-      transformed_path = host_to_container_path(path)
-    else
-      log.debug('Interceptor: URI already in container format: %s', uri)
-      return uri
-    end
-  else -- to_host
-    -- Only transform if it's not already in host format
-    local host_workspace = path_config.host_workspace
-    if host_workspace and not path:match('^' .. vim.pesc(host_workspace)) then
-      transformed_path = container_to_host_path(path)
-    else
-      log.debug('Interceptor: URI already in host format: %s', uri)
-      return uri
-    end
+    transformed_path = host_to_container_path(path)
+  else
+    transformed_path = container_to_host_path(path)
   end
 
   local result = 'file://' .. transformed_path
@@ -223,13 +341,89 @@ local function transform_uri(uri, direction)
     log.debug('Interceptor: URI transformation (%s): %s -> %s', direction, uri, result)
   end
 
-  -- Validate the result has proper scheme
-  if not result:match('^file://') then
-    log.error('Interceptor: Transformation resulted in invalid URI: %s', result)
-    result = 'file://' .. result
+  return result
+end
+
+-- Transform a single value found at a pattern location
+-- Plain absolute paths (e.g. rootPath) stay plain paths, everything else is treated as a URI
+local function transform_value(value, direction)
+  if type(value) ~= 'string' then
+    return value
   end
+  if value:match('^/') then
+    if direction == 'to_container' then
+      return host_to_container_path(value)
+    end
+    return container_to_host_path(value)
+  end
+  return transform_uri(value, direction)
+end
 
-  return result
+-- Transform a URI or absolute path using the configured mappings
+-- @param value string: URI or absolute path
+-- @param direction string: "to_container" or "to_host"
+-- @return string: transformed value
+function M.transform_path(value, direction)
+  return transform_value(value, direction)
+end
+
+-- Translate the host paths and file URIs found in server settings to container paths
+-- Only strings under a path mapping are rewritten (a leading ~ is expanded first); keys and
+-- other values are kept, so flags and names that merely look like paths are left alone.
+-- @param value any: settings or init_options table
+-- @return any: translated copy
+function M.transform_settings(value)
+  if type(value) == 'table' then
+    local result = {}
+    for key, item in pairs(value) do
+      result[key] = M.transform_settings(item)
+    end
+    return result
+  end
+  if type(value) ~= 'string' then
+    return value
+  end
+
+  local path = value:match('^file://(/.*)$') or value
+  if path:match('^~/') then
+    path = vim.fn.expand(path)
+  end
+  if not M.is_mapped_host_path(path) then
+    return value
+  end
+  local container_path = map_path(path, 'host', 'container')
+  return value:match('^file://') and 'file://' .. container_path or container_path
+end
+
+-- Apply a tokenized pattern to an object in place
+-- Tokens are dot separated keys; a "[]" suffix iterates over an array ("[]" alone is the object itself)
+local function apply_pattern(obj, tokens, index, direction)
+  if type(obj) ~= 'table' then
+    return
+  end
+
+  local token = tokens[index]
+  local key = token:match('^(.-)%[%]$')
+
+  if key then
+    local list = key == '' and obj or obj[key]
+    if type(list) ~= 'table' then
+      return
+    end
+    for i, item in ipairs(list) do
+      if index == #tokens then
+        list[i] = transform_value(item, direction)
+      else
+        apply_pattern(item, tokens, index + 1, direction)
+      end
+    end
+  elseif index == #tokens then
+    if obj[token] ~= nil then
+      obj[token] = transform_value(obj[token], direction)
+    end
+  else
+    apply_pattern(obj[token], tokens, index + 1, direction)
+  end
 end
 
 -- Recursively transform paths in a nested table structure
@@ -242,89 +436,15 @@ local function transform_paths_recursive(obj, path_patterns, direction)
     return obj
   end
 
-  -- Deep copy to avoid modifying original
-  local function deep_copy(original)
-    if type(original) ~= 'table' then
-      return original
-    end
-    local copy = {}
-    for key, value in pairs(original) do
-      copy[key] = deep_copy(value)
-    end
-    return copy
+  if type(obj) ~= 'table' then
+    return obj
   end
 
-  local result = deep_copy(obj)
+  -- Deep copy to avoid modifying original
+  local result = vim.deepcopy(obj)
 
   for _, pattern in ipairs(path_patterns) do
-    -- Handle array patterns like "[].uri" or "changes[].uri"
-    if pattern:match('%[%]') then
-      local base_path, field = pattern:match('^(.*)%[%]%.(.+)$')
-
-      local function transform_array(current_obj, path_parts, field_name)
-        if #path_parts == 0 then
-          -- We're at the array level
-          if type(current_obj) == 'table' and #current_obj > 0 then
-            for i, item in ipairs(current_obj) do
-              if type(item) == 'table' and item[field_name] then
-                current_obj[i][field_name] = transform_uri(item[field_name], direction)
-              end
-            end
-          end
-          return
-        end
-
-        local next_part = table.remove(path_parts, 1)
-        if current_obj and current_obj[next_part] then
-          transform_array(current_obj[next_part], path_parts, field_name)
-        end
-      end
-
-      if base_path and base_path ~= '' then
-        local path_parts = vim.split(base_path, '.', { plain = true })
-        transform_array(result, path_parts, field)
-      else
-        -- Direct array pattern like "[].uri"
-        transform_array(result, {}, field)
-      end
-    else
-      -- Handle simple dot notation patterns like "textDocument.uri" or "rootUri"
-      local path_parts = vim.split(pattern, '.', { plain = true })
-
-      local function set_nested_value(current_obj, parts, value)
-        if #parts == 1 then
-          if current_obj and current_obj[parts[1]] then
-            current_obj[parts[1]] = value
-          end
-          return
-        end
-
-        local next_part = table.remove(parts, 1)
-        if current_obj and current_obj[next_part] then
-          set_nested_value(current_obj[next_part], parts, value)
-        end
-      end
-
-      local function get_nested_value(current_obj, parts)
-        if #parts == 1 then
-          return current_obj and current_obj[parts[1]]
-        end
-
-        local next_part = table.remove(parts, 1)
-        if current_obj and current_obj[next_part] then
-          return get_nested_value(current_obj[next_part], parts)
-        end
-        return nil
-      end
-
-      local current_value = get_nested_value(result, path_parts)
-      if current_value then
-        log.debug('Interceptor: Found value for pattern %s: %s', pattern, current_value)
-        local transformed_value = transform_uri(current_value, direction)
-        log.debug('Interceptor: Transformed %s: %s -> %s', pattern, current_value, transformed_value)
-        set_nested_value(result, vim.split(pattern, '.', { plain = true }), transformed_value)
-      end
-    end
+    apply_pattern(result, vim.split(pattern, '.', { plain = true }), 1, direction)
   end
 
   return result
@@ -430,14 +550,15 @@ end
 -- Setup LSP client message interception
 -- @param client table: LSP client object
 -- @param container_id string: target container ID
-function M.setup_client_interception(client, container_id)
+-- @param host_workspace string|nil: host workspace path (auto-detected if nil)
+function M.setup_client_interception(client, container_id, host_workspace)
   if not client then
     log.error('Interceptor: Invalid client provided for interception setup')
     return false
   end
 
   -- Setup path configuration
-  M.setup_path_config(container_id)
+  M.setup_path_config(container_id, host_workspace)
 
   log.info(
     'Interceptor: Setting up message interception for client %s (container: %s)',
@@ -557,7 +678,7 @@ function M.setup_client_interception(client, container_id)
               log.error('Interceptor: URI became empty after transformation!')
               result.uri = original_uri
               log.info('Interceptor: Restored original URI: %s', result.uri)
-            elseif not result.uri:match('^file://') then
+            elseif not result.uri:match('^file://') and not result.uri:match('^container://') then
               log.error('Interceptor: URI lost file:// scheme after transformation: %s', result.uri)
               if result.uri:match('^/') then
                 result.uri = 'file://' .. result.uri
@@ -22,6 +22,77 @@ function M.setup(workspace_folder, container_workspace, mounts)
   )
 end
 
+-- Remove trailing slashes (except for the root directory)
+function M.strip_trailing_slash(path)
+  if #path > 1 then
+    path = path:gsub('/+$', '')
+  end
+  return path
+end
+
+-- Check whether path is prefix or lies below prefix (segment boundary aware)
+function M.has_path_prefix(path, prefix)
+  if prefix == '' or path:sub(1, #prefix) ~= prefix then
+    return false
+  end
+  local next_char = path:sub(#prefix + 1, #prefix + 1)
+  return next_char == '' or next_char == '/' or prefix == '/'
+end
+
+-- Move path from below from_root to the same place below to_root
+-- @param path string
+-- @param from_root string
+-- @param to_root string
+-- @return string|nil: rewritten path, nil when path is not from_root or below it
+function M.replace_prefix(path, from_root, to_root)
+  from_root = M.strip_trailing_slash(from_root)
+  if not M.has_path_prefix(path, from_root) then
+    return nil
+  end
+  to_root = M.strip_trailing_slash(to_root)
+  local rest = path:sub(#from_root + 1)
+  if from_root == '/' and rest ~= '' then
+    rest = '/' .. rest
+  end
+  if to_root == '/' then
+    return rest ~= '' and rest or '/'
+  end
+  return to_root .. rest
+end
+
+-- Rewrite path with the workspace or mount containing it, the longest one when several do (mounts below the
+-- workspace, and workspace folders of a monorepo mounted at different places)
+-- @param path string
+-- @param from string: 'local' or 'container'
+-- @return string|nil: rewritten path, nil when no mapping contains the path
+local function map_path(path, from)
+  local candidates = {}
+  if path_mappings.workspace_folder and path_mappings.container_workspace then
+    table.insert(candidates, { path_mappings.workspace_folder, path_mappings.container_workspace })
+  end
+  for local_mount, container_mount in pairs(path_mappings.mounts) do
+    table.insert(candidates, { local_mount, container_mount })
+  end
+
+  local best_prefix, best_target
+  for _, candidate in ipairs(candidates) do
+    local prefix, target = candidate[1], candidate[2]
+    if from == 'container' then
+      prefix, target = target, prefix
+    end
+    prefix = M.strip_trailing_slash(prefix)
+    if M.has_path_prefix(path, prefix) and (not best_prefix or #prefix > #best_prefix) then
+      best_prefix, best_target = prefix, target
+    end
+  end
+  if not best_prefix then
+    return nil
+  end
+
+  local mapped = M.replace_prefix(path, best_prefix, best_target):gsub('/+', '/')
+  return M.strip_trailing_slash(mapped)
+end
+
 -- Convert local path to container path
 function M.to_container_path(local_path)
   if not local_path then
@@ -29,33 +100,12 @@ function M.to_container_path(local_path)
   end
 
   local abs_path = vim.fn.fnamemodify(local_path, ':p')
-
-  -- Check if path is within workspace
-  if path_mappings.workspace_folder and vim.startswith(abs_path, path_mappings.workspace_folder) then
-    local relative = string.sub(abs_path, #path_mappings.workspace_folder + 1)
-    -- Remove leading slash if present
-    if vim.startswith(relative, '/') then
-      relative = string.sub(relative, 2)
-    end
-    local container_path = path_mappings.container_workspace .. '/' .. relative
-    container_path = container_path:gsub('/+', '/')
-    -- Remove trailing slash
-    container_path = container_path:gsub('/$', '')
+  local container_path = map_path(abs_path, 'local')
+  if container_path then
     log.debug('Path: Local to container - ' .. abs_path .. ' -> ' .. container_path)
     return container_path
   end
 
-  -- Check custom mount points
-  for local_mount, container_mount in pairs(path_mappings.mounts) do
-    if vim.startswith(abs_path, local_mount) then
-      local relative = string.sub(abs_path, #local_mount + 1)
-      local container_path = container_mount .. '/' .. relative
-      container_path = container_path:gsub('/+', '/')
-      log.debug('Path: Local to container (mount) - ' .. abs_path .. ' -> ' .. container_path)
-      return container_path
-    end
-  end
-
   -- Path is outside workspace, return as-is
   log.debug('Path: Local path outside workspace - ' .. abs_path)
   return abs_path
@@ -77,32 +127,12 @@ function M.to_local_path(container_path)
     return nil
   end
 
-  -- Check if path is within container workspace
-  if path_mappings.container_workspace and vim.startswith(container_path, path_mappings.container_workspace) then
-    local relative = string.sub(container_path, #path_mappings.container_workspace + 1)
-    -- Remove leading slash if present
-    if vim.startswith(relative, '/') then
-      relative = string.sub(relative, 2)
-    end
-    local local_path = path_mappings.workspace_folder .. '/' .. relative
-    local_path = local_path:gsub('/+', '/')
-    -- Remove trailing slash
-    local_path = local_path:gsub('/$', '')
+  local local_path = map_path(container_path, 'container')
+  if local_path then
     log.debug('Path: Container to local - ' .. container_path .. ' -> ' .. local_path)
     return local_path
   end
 
-  -- Check custom mount points
-  for local_mount, container_mount in pairs(path_mappings.mounts) do
-    if vim.startswith(container_path, container_mount) then
-      local relative = string.sub(container_path, #container_mount + 1)
-      local local_path = local_mount .. '/' .. relative
-      local_path = local_path:gsub('/+', '/')
-      log.debug('Path: Container to local (mount) - ' .. container_path .. ' -> ' .. local_path)
-      return local_path
-    end
-  end
-
   -- Path is outside mapped directories
   log.debug('Path: Container path outside mappings - ' .. container_path)
   return container_path
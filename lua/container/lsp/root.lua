@@ -0,0 +1,122 @@
+-- lua/container/lsp/root.lua
+-- Project roots of buffers, looked up in the container filesystem
+-- The root of a file is the nearest directory above it holding one of the root patterns of its filetype
+-- (lsp.root_patterns, else the language registry). The check runs with docker exec, so files that only exist in the
+-- container count as well. In a monorepo every module gets a root of its own instead of the workspace root.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Looked up roots: container id, container directory and patterns -> host root, or false when none matched
+local cache = {}
+
+-- Root patterns of a filetype
+-- @param filetype string
+-- @return table: file names or globs, nearest directory first wins
+function M.patterns(filetype)
+  local ok, plugin_config = pcall(require, 'container.config')
+  local configured = ok and plugin_config.get_value('lsp.root_patterns') or {}
+  if configured[filetype] then
+    return configured[filetype]
+  end
+  local language = require('container.lsp.language_registry').get_by_filetype(filetype)
+  return language and language.root_patterns or {}
+end
+
+-- Directories from dir up to stop (or /), nearest first
+-- @param dir string: absolute container directory
+-- @param stop string: directory the search ends at when dir lies below it
+-- @return table
+function M.ancestors(dir, stop)
+  if not (dir == stop or dir:sub(1, #stop + 1) == stop .. '/') then
+    stop = '/'
+  end
+  local dirs = { dir }
+  while dir ~= stop and dir ~= '/' do
+    dir = dir:match('^(.*)/[^/]*$')
+    if dir == '' then
+      dir = '/'
+    end
+    table.insert(dirs, dir)
+  end
+  return dirs
+end
+
+-- Shell script printing the first of dirs that holds one of patterns (exits 1 when none does)
+-- Patterns containing glob characters are expanded by the shell, e.g. '*.csproj'.
+-- @param dirs table: container directories, nearest first
+-- @param patterns table
+-- @return string
+function M.build_script(dirs, patterns)
+  local quote = require('container.dry_run').shell_quote
+  local checks = {}
+  for _, dir in ipairs(dirs) do
+    local prefix = dir == '/' and '' or quote(dir)
+    for _, pattern in ipairs(patterns) do
+      local candidate = prefix .. '/' .. (pattern:find('[*?%[]') and pattern or quote(pattern))
+      table.insert(checks, string.format('set -- %s; [ -e "$1" ] && echo %s && exit 0', candidate, quote(dir)))
+    end
+  end
+  table.insert(checks, 'exit 1')
+  return table.concat(checks, '; ')
+end
+
+-- Find the root of a host file in the container
+-- Results are cached per directory until clear_cache().
+-- @param container_id string
+-- @param file string: host path of the file
+-- @param patterns table: root patterns
+-- @return string|nil: host path of the root; nil outside the path mappings or when no pattern matches
+function M.find(container_id, file, patterns)
+  if not container_id or not file or file == '' or not patterns or #patterns == 0 then
+    return nil
+  end
+
+  local interceptor = require('container.lsp.interceptor')
+  local host_dir = vim.fn.fnamemodify(file, ':h')
+  if not interceptor.is_mapped_host_path(host_dir) then
+    return nil
+  end
+  local dir = interceptor.transform_path(host_dir, 'to_container')
+
+  local key = table.concat({ container_id, dir, table.concat(patterns, '\n') }, '\0')
+  if cache[key] == nil then
+    local dirs = M.ancestors(dir, interceptor.get_path_config().container_workspace or '/')
+    local result = require('container.docker.init').run_docker_command({
+      'exec',
+      container_id,
+      'sh',
+      '-c',
+      M.build_script(dirs, patterns),
+    })
+    local root = result.success and vim.trim(result.stdout) or ''
+    local host_root = root ~= '' and interceptor.transform_path(root, 'to_host') or nil
+    -- A root above every mapping has no host folder to give the server
+    if host_root and not interceptor.is_mapped_host_path(host_root) then
+      host_root = nil
+    end
+    cache[key] = host_root or false
+    log.debug('LSP: Root of %s in the container: %s', dir, host_root or 'none')
+  end
+  return cache[key] or nil
+end
+
+-- Find the root of a buffer, using the root patterns of its filetype
+-- @param container_id string
+-- @param bufnr number
+-- @return string|nil: host path of the root
+function M.for_buffer(container_id, bufnr)
+  if not vim.api.nvim_buf_is_valid(bufnr) then
+    return nil
+  end
+  local file = vim.api.nvim_buf_get_name(bufnr)
+  return M.find(container_id, file, M.patterns(vim.bo[bufnr].filetype))
+end
+
+-- Forget the looked up roots (after the container changed, or files were added)
+function M.clear_cache()
+  cache = {}
+end
+
+return M
@@ -43,24 +43,70 @@ function M.create_client(server_name, container_id, server_config, strategy_conf
 
   log.info('Intercept Strategy: Using host workspace: %s', host_workspace)
 
+  -- Configure path mappings now: initialize is sent before on_init runs
+  -- The mappings always start from the project workspace, not the server root below
+  interceptor.setup_path_config(container_id, host_workspace)
+
+  local cmd = { require('container.docker.runtime').get(), 'exec', '-i' }
+  local root_dir = host_workspace
+  local workspace_folders = {
+    {
+      uri = 'file://' .. host_workspace,
+      name = 'workspace',
+    },
+  }
+
+  -- gopls: root at go.work (or every module of a multi-module workspace) for cross-module navigation,
+  -- unless lsp.servers.gopls.root_dir chooses the root
+  local layout
+  if server_name == 'gopls' and not server_config.root_dir then
+    layout = require('container.lsp.gowork').detect(vim.fn.expand('%:p'), host_workspace)
+    if layout then
+      root_dir = layout.root
+      workspace_folders = require('container.lsp.gowork').workspace_folders(layout)
+      vim.list_extend(cmd, M._go_exec_args(layout))
+      log.info('Intercept Strategy: gopls root %s (%d workspace folder(s))', root_dir, #workspace_folders)
+    end
+  end
+
+  -- Other servers start at the root of the current buffer, found in the container with the root patterns of its
+  -- filetype; buffers of other roots add workspace folders when they attach
+  if not layout and not server_config.root_dir then
+    local buffer_root = require('container.lsp.root').for_buffer(container_id, vim.api.nvim_get_current_buf())
+    if buffer_root then
+      root_dir = buffer_root
+      workspace_folders = { { uri = 'file://' .. buffer_root, name = vim.fn.fnamemodify(buffer_root, ':t') } }
+      log.info('Intercept Strategy: %s root %s', server_name, root_dir)
+    end
+  end
+
+  -- lsp.servers.<name>.cwd starts the server in another directory of the container (variables are expanded)
+  if server_config.cwd then
+    local container_config = require('container').get_state().current_config
+    local cwd = require('container.environment').expand_path(server_config.cwd, container_config)
+    vim.list_extend(cmd, { '-w', cwd })
+    log.info('Intercept Strategy: %s starts in %s', server_name, cwd)
+  end
+
+  vim.list_extend(cmd, { container_id, server_cmd })
+
   -- Create base LSP client configuration
   local client_config = {
     name = 'container_' .. server_name,
-    cmd = { 'docker', 'exec', '-i', container_id, server_cmd },
-    root_dir = host_workspace,
+    cmd = cmd,
+    root_dir = root_dir,
     capabilities = vim.lsp.protocol.make_client_capabilities(),
 
     -- Workspace configuration (will be transformed during interception)
-    workspace_folders = {
-      {
-        uri = 'file://' .. host_workspace,
-        name = 'workspace',
-      },
-    },
+    workspace_folders = workspace_folders,
 
-    -- Language-specific configuration
-    settings = vim.tbl_deep_extend('force', lang_config.settings or {}, server_config.settings or {}),
-    init_options = vim.tbl_deep_extend('force', lang_config.init_options or {}, server_config.init_options or {}),
+    -- Language-specific configuration, with host paths translated since the server only sees the container
+    settings = interceptor.transform_settings(
+      vim.tbl_deep_extend('force', lang_config.settings or {}, server_config.settings or {})
+    ),
+    init_options = interceptor.transform_settings(
+      vim.tbl_deep_extend('force', lang_config.init_options or {}, server_config.init_options or {})
+    ),
 
     -- Custom initialization
     before_init = function(initialize_params, config)
@@ -73,38 +119,27 @@ function M.create_client(server_name, container_id, server_config, strategy_conf
       log.debug('Intercept Strategy: Host workspace: %s', host_workspace)
 
       -- Transform rootUri and rootPath to container paths
-      if initialize_params.rootUri and initialize_params.rootUri:match('^file://') and host_workspace then
+      if initialize_params.rootUri and initialize_params.rootUri:match('^file://') then
         local original_uri = initialize_params.rootUri
-        local host_path = initialize_params.rootUri:gsub('^file://', '')
-        local container_path = host_path:gsub('^' .. vim.pesc(host_workspace), '/workspace')
-        initialize_params.rootUri = 'file://' .. container_path
+        initialize_params.rootUri = interceptor.transform_path(original_uri, 'to_container')
         log.info('Intercept Strategy: Transformed rootUri: %s -> %s', original_uri, initialize_params.rootUri)
       else
-        log.warn('Intercept Strategy: Cannot transform rootUri - missing host_workspace or invalid URI')
+        log.warn('Intercept Strategy: Cannot transform rootUri - invalid URI')
       end
 
-      if initialize_params.rootPath and host_workspace then
+      if initialize_params.rootPath then
         local original_path = initialize_params.rootPath
-        local container_path = initialize_params.rootPath:gsub('^' .. vim.pesc(host_workspace), '/workspace')
-        initialize_params.rootPath = container_path
+        initialize_params.rootPath = interceptor.transform_path(original_path, 'to_container')
         log.info('Intercept Strategy: Transformed rootPath: %s -> %s', original_path, initialize_params.rootPath)
-      else
-        log.warn('Intercept Strategy: Cannot transform rootPath - missing host_workspace')
       end
 
       -- Transform workspace folders
-      if initialize_params.workspaceFolders and host_workspace then
-        for i, folder in ipairs(initialize_params.workspaceFolders) do
-          if folder.uri and folder.uri:match('^file://') then
-            local original_uri = folder.uri
-            local host_path = folder.uri:gsub('^file://', '')
-            local container_path = host_path:gsub('^' .. vim.pesc(host_workspace), '/workspace')
-            folder.uri = 'file://' .. container_path
-            log.info('Intercept Strategy: Transformed workspace folder %d: %s -> %s', i, original_uri, folder.uri)
-          end
+      for i, folder in ipairs(initialize_params.workspaceFolders or {}) do
+        if folder.uri and folder.uri:match('^file://') then
+          local original_uri = folder.uri
+          folder.uri = interceptor.transform_path(original_uri, 'to_container')
+          log.info('Intercept Strategy: Transformed workspace folder %d: %s -> %s', i, original_uri, folder.uri)
         end
-      else
-        log.warn('Intercept Strategy: Cannot transform workspace folders - missing host_workspace')
       end
 
       -- Call original before_init if provided
@@ -118,7 +153,7 @@ function M.create_client(server_name, container_id, server_config, strategy_conf
       log.info('Intercept Strategy: on_init called for %s (client ID: %s)', server_name, client.id)
 
       -- Setup message interception
-      local success = interceptor.setup_client_interception(client, container_id)
+      local success = interceptor.setup_client_interception(client, container_id, host_workspace)
       if not success then
         log.error('Intercept Strategy: Failed to setup interception for %s', server_name)
       else
@@ -190,6 +225,34 @@ function M.create_client(server_name, container_id, server_config, strategy_conf
   return client_config, nil
 end
 
+-- docker exec arguments for gopls in a Go workspace
+-- Modules outside every path mapping cannot be resolved in the container and are reported.
+-- @param layout table: Go workspace layout from container.lsp.gowork
+-- @return table: arguments placed before the container id
+function M._go_exec_args(layout)
+  local gowork = require('container.lsp.gowork')
+
+  for _, module in ipairs(layout.modules) do
+    if not interceptor.is_mapped_host_path(module) then
+      log.warn(
+        'Intercept Strategy: Go module %s is outside the container workspace; mount it or add it to lsp.path_mappings',
+        module
+      )
+    end
+  end
+
+  local goflags
+  local ok, container = pcall(require, 'container')
+  if ok and container.get_state then
+    local env = require('container.environment').get_lsp_environment(container.get_state().current_config)
+    goflags = env and env.GOFLAGS
+  end
+
+  return gowork.exec_args(layout, function(path)
+    return interceptor.transform_path(path, 'to_container')
+  end, goflags)
+end
+
 -- Check if interception strategy is available
 -- @param server_name string: LSP server name
 -- @param container_id string: target container ID
@@ -206,7 +269,7 @@ function M.is_available(server_name, container_id)
   end
 
   -- Check if server exists in container
-  local cmd_check = { 'docker', 'exec', container_id, 'which', server_name }
+  local cmd_check = { require('container.docker.runtime').get(), 'exec', container_id, 'which', server_name }
   vim.fn.system(cmd_check)
   local exit_code = vim.v.shell_error
 
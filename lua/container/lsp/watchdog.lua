@@ -0,0 +1,210 @@
+-- lua/container/lsp/watchdog.lua
+-- Request timeouts and a watchdog for the `docker exec` processes of the container LSP clients
+-- When the container stops responding, the exec process (and the server behind it) hangs: requests never get an
+-- answer and Neovim keeps waiting for them. Requests left unanswered for lsp.request_timeout milliseconds are
+-- cancelled and answered with an error. A client is reported dead when lsp.max_timeouts requests in a row timed
+-- out, when its process exits without being stopped, or when the check run every lsp.watchdog_interval
+-- milliseconds finds its container no longer running; the LSP module then stops it (see lsp.auto_restart).
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Error code of the answer to a timed out request (RequestCancelled of the LSP specification)
+M.REQUEST_CANCELLED = -32800
+
+-- Requests that are never timed out
+M.EXEMPT_METHODS = { initialize = true, shutdown = true }
+
+-- Watched clients by client id: { name, container_id, timeouts, on_dead }
+local watched = {}
+local timer = nil
+-- True while a container check waits for docker
+local checking = false
+
+local function setting(key, default)
+  local ok, plugin_config = pcall(require, 'container.config')
+  local value = ok and plugin_config.get_value('lsp.' .. key)
+  if type(value) ~= type(default) then
+    return default
+  end
+  return value
+end
+
+-- Report a client dead; it is forgotten, so this happens once
+-- @param client_id number
+-- @param reason string
+function M.dead(client_id, reason)
+  local entry = watched[client_id]
+  if not entry then
+    return
+  end
+  M.unwatch(client_id)
+  log.warn('LSP: %s (client %d) is dead: %s', entry.name, client_id, reason)
+  entry.on_dead(reason)
+end
+
+-- Record an answered request
+-- @param client_id number
+function M.answered(client_id)
+  local entry = watched[client_id]
+  if entry then
+    entry.timeouts = 0
+  end
+end
+
+-- Record a timed out request; lsp.max_timeouts in a row make the client dead
+-- @param client_id number
+function M.timed_out(client_id)
+  local entry = watched[client_id]
+  if not entry then
+    return
+  end
+  entry.timeouts = entry.timeouts + 1
+  if entry.timeouts >= setting('max_timeouts', 3) then
+    M.dead(client_id, string.format('%d requests in a row timed out', entry.timeouts))
+  end
+end
+
+-- Wrap client.request so requests unanswered after lsp.request_timeout are cancelled
+-- The handler receives a RequestCancelled error instead of waiting forever; a late answer is dropped.
+-- @param client table: LSP client
+function M.wrap_request(client)
+  local original_request = client.request
+  client.request = function(method, params, handler, bufnr)
+    local timeout = setting('request_timeout', 30000)
+    handler = handler or (client.handlers and client.handlers[method]) or vim.lsp.handlers[method]
+    if timeout <= 0 or M.EXEMPT_METHODS[method] or not handler then
+      return original_request(method, params, handler, bufnr)
+    end
+
+    local done = false
+    local ok, request_id = original_request(method, params, function(err, result, ctx, config)
+      if done then
+        return
+      end
+      done = true
+      M.answered(client.id)
+      return handler(err, result, ctx, config)
+    end, bufnr)
+
+    if ok and request_id then
+      vim.defer_fn(function()
+        if done then
+          return
+        end
+        done = true
+        pcall(client.cancel_request, request_id)
+        log.warn('LSP: %s request %s timed out after %d ms', client.name or 'unknown', method, timeout)
+        local err = { code = M.REQUEST_CANCELLED, message = string.format('%s timed out after %d ms', method, timeout) }
+        pcall(handler, err, nil, { method = method, client_id = client.id, bufnr = bufnr, params = params })
+        M.timed_out(client.id)
+      end, timeout)
+    end
+    return ok, request_id
+  end
+end
+
+-- Check the watched clients: their process must run and their container must be running
+-- A check still waiting for docker when the next one is due counts as a timed out request of every client.
+function M.check()
+  if checking then
+    for client_id in pairs(watched) do
+      M.timed_out(client_id)
+    end
+    return
+  end
+
+  local containers = {}
+  for client_id, entry in pairs(watched) do
+    local client = vim.lsp.get_client_by_id(client_id)
+    if not client or client.is_stopped() then
+      M.dead(client_id, 'the server process exited')
+    elseif entry.container_id then
+      containers[entry.container_id] = true
+    end
+  end
+
+  local pending = vim.tbl_count(containers)
+  if pending == 0 then
+    return
+  end
+  checking = true
+  local docker = require('container.docker')
+  for container_id in pairs(containers) do
+    local args = { 'inspect', '--format', '{{.State.Status}}', container_id }
+    docker.run_docker_command_async(args, { retry = false }, function(result)
+      pending = pending - 1
+      checking = pending > 0
+      local status = result.success and vim.trim(result.stdout) or nil
+      if status == 'running' then
+        return
+      end
+      local reason = status and ('the container is ' .. status) or 'the container is gone'
+      for client_id, entry in pairs(watched) do
+        if entry.container_id == container_id then
+          M.dead(client_id, reason)
+        end
+      end
+    end)
+  end
+end
+
+-- Watch a container LSP client
+-- @param name string: server name
+-- @param client table: LSP client
+-- @param on_dead function: called with the reason once the client is dead
+function M.watch(name, client, on_dead)
+  watched[client.id] = {
+    name = name,
+    container_id = client.config and client.config.container_id,
+    timeouts = 0,
+    on_dead = on_dead,
+  }
+  M.wrap_request(client)
+
+  local interval = setting('watchdog_interval', 10000)
+  local uv = vim.uv or vim.loop
+  if interval > 0 and not timer and uv then
+    timer = uv.new_timer()
+    timer:start(interval, interval, vim.schedule_wrap(M.check))
+  end
+end
+
+-- The process of a client exited (on_exit); a client still watched was not stopped on purpose
+-- @param client_id number
+-- @param code number: exit code
+-- @param signal number
+function M.exited(client_id, code, signal)
+  if watched[client_id] then
+    vim.schedule(function()
+      M.dead(client_id, string.format('the server process exited (code %s, signal %s)', code, signal))
+    end)
+  end
+end
+
+-- Stop watching a client, before it is stopped on purpose
+-- @param client_id number|nil
+function M.unwatch(client_id)
+  if client_id then
+    watched[client_id] = nil
+  end
+  if timer and next(watched) == nil then
+    timer:stop()
+    timer:close()
+    timer = nil
+    checking = false
+  end
+end
+
+-- Names of the watched clients by client id
+-- @return table
+function M.get_watched()
+  local result = {}
+  for client_id, entry in pairs(watched) do
+    result[client_id] = entry.name
+  end
+  return result
+end
+
+return M
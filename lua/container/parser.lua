@@ -6,69 +6,210 @@ local fs = require('container.utils.fs')
 local log = require('container.utils.log')
 local migrate = require('container.migrate')
 
--- Remove comments from JSON string
-local function remove_json_comments(content)
-  -- Remove line comments //
-  content = content:gsub('//[^\n]*', '')
-  -- Remove block comments /* */
-  content = content:gsub('/%*.-*/', '')
-  return content
+-- Turn JSONC (JSON with comments and trailing commas) into plain JSON
+-- Comments and trailing commas are replaced with spaces (newlines are kept), so offsets in the result
+-- point at the same line and column of the original text. String contents, including "//", are kept.
+function M.strip_jsonc(content)
+  local out = {}
+  local len = #content
+  local i = 1
+  local in_string = false
+  -- Index in out of the last comma seen outside strings, until something other than whitespace follows
+  local pending_comma = nil
+
+  while i <= len do
+    local char = content:sub(i, i)
+    local next_char = content:sub(i + 1, i + 1)
+
+    if in_string then
+      table.insert(out, char)
+      if char == '\\' then
+        table.insert(out, next_char)
+        i = i + 1
+      elseif char == '"' then
+        in_string = false
+      end
+    elseif char == '/' and next_char == '/' then
+      -- Line comment
+      while i <= len and content:sub(i, i) ~= '\n' do
+        table.insert(out, ' ')
+        i = i + 1
+      end
+      i = i - 1
+    elseif char == '/' and next_char == '*' then
+      -- Block comment
+      local close = content:find('*/', i + 2, true) or len - 1
+      for j = i, close + 1 do
+        table.insert(out, content:sub(j, j) == '\n' and '\n' or ' ')
+      end
+      i = close + 1
+    else
+      if char == '}' or char == ']' then
+        if pending_comma then
+          out[pending_comma] = ' '
+        end
+        pending_comma = nil
+      elseif char == ',' then
+        pending_comma = #out + 1
+      elseif not char:match('%s') then
+        pending_comma = nil
+      end
+      if char == '"' then
+        in_string = true
+      end
+      table.insert(out, char)
+    end
+    i = i + 1
+  end
+
+  return table.concat(out)
+end
+
+-- Line and column of a character offset
+local function position_of(content, offset)
+  local line = 1
+  local line_start = 1
+  for newline in content:sub(1, offset - 1):gmatch('()\n') do
+    line = line + 1
+    line_start = newline + 1
+  end
+  return line, offset - line_start + 1
 end
 
 -- JSON parsing
+-- Errors report the line and column in the original text when the decoder gives an offset
 local function parse_json(content)
-  content = remove_json_comments(content)
+  local stripped = M.strip_jsonc(content)
 
-  local success, result = pcall(vim.json.decode, content)
+  local success, result = pcall(vim.json.decode, stripped)
   if not success then
-    return nil, 'Invalid JSON: ' .. result
+    local message = tostring(result)
+    local offset = tonumber(message:match('at character (%d+)') or message:match('at position (%d+)'))
+    if offset then
+      local line, column = position_of(content, offset)
+      return nil, string.format('Invalid JSON at line %d, column %d: %s', line, column, message)
+    end
+    return nil, 'Invalid JSON: ' .. message
   end
 
   return result
 end
 
+-- Fallback values for ${containerEnv:...} outside remoteEnv (the container does not exist yet)
+-- PATH includes common development tools locations
+local container_env_fallbacks = {
+  PATH = '/usr/local/go/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin',
+  HOME = '/root',
+  USER = 'root',
+  SHELL = '/bin/sh',
+  TERM = 'xterm',
+}
+
+-- Substitute the ${...} references of a string in one scan from left to right
+-- Substituted text is never scanned again, so a value that looks like a reference stays as it is. A reference ends
+-- at the "}" matching its "${", so a default value may contain references; resolve expands them when it uses it.
+-- @param resolve function(name): value of a reference (the text between "${" and "}"), nil keeps it as is
+-- @return string
+function M.substitute(str, resolve)
+  return (str:gsub('%$(%b{})', function(braced)
+    return resolve(braced:sub(2, -2))
+  end))
+end
+
+local expand_variables
+
+-- Value of a variable reference (the text between "${" and "}")
+-- References in a default value are expanded when the default is used.
+-- @return string|nil: nil keeps the reference as is
+local function resolve_variable(name, context)
+  local local_folder = context.workspace_folder or vim.fn.getcwd()
+  local container_folder = context.container_workspace or '/workspace'
+
+  if name == 'localWorkspaceFolder' then
+    return local_folder
+  elseif name == 'localWorkspaceFolderBasename' then
+    return fs.basename(local_folder)
+  elseif name == 'containerWorkspaceFolder' then
+    return container_folder
+  elseif name == 'containerWorkspaceFolderBasename' then
+    return fs.basename(container_folder)
+  elseif name == 'devcontainerId' then
+    return context.devcontainer_id
+  end
+
+  -- ${localEnv:NAME} and ${containerEnv:NAME}, optionally with a default (${localEnv:NAME:default})
+  local scope, var_name, default = name:match('^(%a+):([^:${}]+):?(.*)$')
+  if scope == 'localEnv' or scope == 'env' then
+    local value = os.getenv(var_name)
+    if value and value ~= '' then
+      return value
+    end
+    return expand_variables(default, context)
+  elseif scope == 'containerEnv' then
+    -- remoteEnv references are resolved against the running container (see environment.lua)
+    if context.defer_container_env then
+      return nil
+    end
+    local fallback = container_env_fallbacks[var_name] or (default ~= '' and expand_variables(default, context) or nil)
+    if fallback then
+      log.debug('Expanding ${containerEnv:%s} to fallback value: %s', var_name, fallback)
+    else
+      log.warn('Unknown containerEnv variable: %s, keeping as placeholder', var_name)
+    end
+    return fallback
+  end
+
+  return nil
+end
+
 -- Variable expansion
-local function expand_variables(str, context)
+-- All variables are substituted in one scan (see substitute). A default value may itself refer to a variable
+-- (${localEnv:CACHE:${localWorkspaceFolder}/.cache}); it is expanded only when the default is used.
+expand_variables = function(str, context)
   if type(str) ~= 'string' then
     return str
   end
 
   context = context or {}
-
-  -- Expand ${localWorkspaceFolder}
-  str = str:gsub('${localWorkspaceFolder}', context.workspace_folder or vim.fn.getcwd())
-
-  -- Expand ${containerWorkspaceFolder}
-  str = str:gsub('${containerWorkspaceFolder}', context.container_workspace or '/workspace')
-
-  -- Expand ${localEnv:variable_name}
-  str = str:gsub('${localEnv:([^}]+)}', function(var_name)
-    return os.getenv(var_name) or ''
+  return M.substitute(str, function(name)
+    return resolve_variable(name, context)
   end)
+end
 
-  -- Expand ${containerEnv:variable_name} with basic fallback
-  str = str:gsub('${containerEnv:([^}]+)}', function(var_name)
-    -- Basic fallback values for common container environment variables
-    -- PATH includes common development tools locations
-    local fallback_values = {
-      PATH = '/usr/local/go/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin',
-      HOME = '/root',
-      USER = 'root',
-      SHELL = '/bin/sh',
-      TERM = 'xterm',
-    }
+M.expand_variables = expand_variables
 
-    local fallback = fallback_values[var_name]
-    if fallback then
-      log.debug('Expanding ${containerEnv:%s} to fallback value: %s', var_name, fallback)
-      return fallback
-    else
-      log.warn('Unknown containerEnv variable: %s, keeping as placeholder', var_name)
-      return '${containerEnv:' .. var_name .. '}'
+-- Identifier of a devcontainer, stable across rebuilds of the same configuration
+-- Computed like the reference implementation: the SHA-256 of the local folder and config file labels
+-- written as 52 base-32 digits.
+function M.get_devcontainer_id(workspace_folder, config_file)
+  local function quote(value)
+    return '"' .. tostring(value):gsub('\\', '\\\\'):gsub('"', '\\"') .. '"'
+  end
+  local labels = string.format(
+    '{"devcontainer.config_file":%s,"devcontainer.local_folder":%s}',
+    quote(config_file),
+    quote(workspace_folder)
+  )
+  local hash = vim.fn.sha256(labels)
+
+  local bits = {}
+  for i = 1, #hash do
+    local nibble = tonumber(hash:sub(i, i), 16)
+    for shift = 3, 0, -1 do
+      table.insert(bits, math.floor(nibble / 2 ^ shift) % 2)
     end
-  end)
+  end
+  while #bits % 5 ~= 0 do
+    table.insert(bits, 1, 0)
+  end
 
-  return str
+  local digits = '0123456789abcdefghijklmnopqrstuv'
+  local id = {}
+  for i = 1, #bits, 5 do
+    local value = bits[i] * 16 + bits[i + 1] * 8 + bits[i + 2] * 4 + bits[i + 3] * 2 + bits[i + 4]
+    table.insert(id, digits:sub(value + 1, value + 1))
+  end
+  return table.concat(id)
 end
 
 -- Generate project ID based on project path
@@ -103,11 +244,15 @@ function M.resolve_dynamic_ports(config, plugin_config)
   end
 
   local port_specs = {}
+  -- Ports of other compose services ("db:5432") are forwarded as they are
+  local service_ports = {}
 
   -- Process normalized ports (from forwardPorts)
   if config.normalized_ports then
     for _, port_entry in ipairs(config.normalized_ports) do
-      if port_entry.type == 'auto' then
+      if port_entry.service then
+        table.insert(service_ports, port_entry)
+      elseif port_entry.type == 'auto' then
         table.insert(port_specs, string.format('auto:%d', port_entry.container_port))
       elseif port_entry.type == 'range' then
         table.insert(
@@ -148,14 +293,17 @@ function M.resolve_dynamic_ports(config, plugin_config)
     end
   end
 
-  -- Replace normalized_ports with resolved ports
-  config.normalized_ports = resolved_ports or {}
+  -- Replace normalized_ports with resolved ports (attributes are looked up again by container port)
+  config.normalized_ports = M.apply_port_attributes(resolved_ports or {}, config)
   config.port_resolution_errors = errors or {}
 
   -- Initialize normalized_ports if it was empty but we had custom dynamic ports
   if not config.normalized_ports then
     config.normalized_ports = {}
   end
+  for _, port_entry in ipairs(service_ports) do
+    table.insert(config.normalized_ports, port_entry)
+  end
 
   log.info('Resolved %d ports for project %s', #config.normalized_ports, project_id)
 
@@ -183,6 +331,19 @@ local function expand_config_variables(config, context)
   return result
 end
 
+-- Configurations in subfolders of .devcontainer/ (.devcontainer/<name>/devcontainer.json)
+local function find_subfolder_configs(devcontainer_dir)
+  local configs = {}
+  for _, entry in ipairs(vim.fn.readdir(devcontainer_dir) or {}) do
+    local config_path = fs.join_path(devcontainer_dir, entry, 'devcontainer.json')
+    if fs.is_file(config_path) then
+      table.insert(configs, config_path)
+    end
+  end
+  table.sort(configs)
+  return configs
+end
+
 -- Search for devcontainer.json file
 function M.find_devcontainer_json(start_path)
   start_path = start_path or vim.fn.getcwd()
@@ -193,6 +354,21 @@ function M.find_devcontainer_json(start_path)
     return devcontainer_path
   end
 
+  -- Search for .devcontainer/<name>/devcontainer.json
+  local devcontainer_dir = fs.find_file_upward(start_path, '.devcontainer')
+  if devcontainer_dir and fs.is_directory(devcontainer_dir) then
+    devcontainer_path = find_subfolder_configs(devcontainer_dir)[1]
+    if devcontainer_path then
+      return devcontainer_path
+    end
+  end
+
+  -- Search for .devcontainer.json
+  devcontainer_path = fs.find_file_upward(start_path, '.devcontainer.json')
+  if devcontainer_path then
+    return devcontainer_path
+  end
+
   -- Search for devcontainer.json
   devcontainer_path = fs.find_file_upward(start_path, 'devcontainer.json')
   if devcontainer_path then
@@ -202,6 +378,53 @@ function M.find_devcontainer_json(start_path)
   return nil
 end
 
+-- Workspace folder of a devcontainer.json
+-- .devcontainer/devcontainer.json and .devcontainer/<name>/devcontainer.json belong to the directory
+-- holding .devcontainer/; a .devcontainer.json belongs to its own directory.
+function M.get_workspace_folder(devcontainer_path)
+  local config_dir = fs.dirname(devcontainer_path)
+  if fs.basename(config_dir) == '.devcontainer' then
+    return fs.dirname(config_dir)
+  end
+  local parent = fs.dirname(config_dir)
+  if fs.basename(parent) == '.devcontainer' then
+    return fs.dirname(parent)
+  end
+  return config_dir
+end
+
+-- Find the workspace root for a path
+-- The root is the directory holding .devcontainer/ (or devcontainer.json itself)
+function M.find_workspace_root(start_path)
+  local devcontainer_path = M.find_devcontainer_json(start_path)
+  if not devcontainer_path then
+    return nil
+  end
+  return M.get_workspace_folder(devcontainer_path)
+end
+
+-- List every devcontainer configuration of a workspace
+-- @return table: paths of .devcontainer/devcontainer.json, .devcontainer/<name>/devcontainer.json
+--   and .devcontainer.json that exist under the workspace root
+function M.find_devcontainer_configs(workspace_root)
+  local configs = {}
+  local devcontainer_dir = fs.join_path(workspace_root, '.devcontainer')
+
+  local primary = fs.join_path(devcontainer_dir, 'devcontainer.json')
+  if fs.is_file(primary) then
+    table.insert(configs, primary)
+  end
+  if fs.is_directory(devcontainer_dir) then
+    vim.list_extend(configs, find_subfolder_configs(devcontainer_dir))
+  end
+  local root_config = fs.join_path(workspace_root, '.devcontainer.json')
+  if fs.is_file(root_config) then
+    table.insert(configs, root_config)
+  end
+
+  return configs
+end
+
 -- Resolve Dockerfile path
 local function resolve_dockerfile_path(config, base_path)
   if not config.dockerFile then
@@ -216,18 +439,27 @@ local function resolve_dockerfile_path(config, base_path)
   return fs.resolve_path(dockerfile_path)
 end
 
--- Resolve docker-compose.yml path
+-- Resolve docker-compose.yml path(s)
+-- dockerComposeFile may be a string or an array of files
 local function resolve_compose_file_path(config, base_path)
   if not config.dockerComposeFile then
     return nil
   end
 
-  local compose_path = config.dockerComposeFile
-  if not fs.is_absolute_path(compose_path) then
-    compose_path = fs.join_path(base_path, compose_path)
+  local compose_files = config.dockerComposeFile
+  if type(compose_files) ~= 'table' then
+    compose_files = { compose_files }
+  end
+
+  local resolved = {}
+  for _, compose_path in ipairs(compose_files) do
+    if not fs.is_absolute_path(compose_path) then
+      compose_path = fs.join_path(base_path, compose_path)
+    end
+    table.insert(resolved, fs.resolve_path(compose_path))
   end
 
-  return fs.resolve_path(compose_path)
+  return resolved[1], resolved
 end
 
 -- Normalize port settings with dynamic port support
@@ -271,7 +503,17 @@ local function normalize_ports(ports, config)
         else
           -- Check for host:container mapping: "8080:3000"
           local host_port, container_port_2 = port:match('(%d+):(%d+)')
-          if host_port and container_port_2 then
+          -- Port of another Docker Compose service: "db:5432"
+          local service, service_port = port:match('^([%a][%w_.-]*):(%d+)$')
+          if service and config and config.dockerComposeFile then
+            port_entry.type = 'fixed'
+            port_entry.host_port = tonumber(service_port)
+            port_entry.container_port = tonumber(service_port)
+            -- The attached service publishes its ports itself
+            if service ~= config.service then
+              port_entry.service = service
+            end
+          elseif host_port and container_port_2 then
             port_entry.type = 'fixed'
             port_entry.host_port = tonumber(host_port)
             port_entry.container_port = tonumber(container_port_2)
@@ -306,8 +548,85 @@ local function normalize_ports(ports, config)
   return normalized, deprecated_ports
 end
 
+-- Normalize appPort (number, string or array) into port entries
+-- appPort is published when the container is created, even when forwardPorts are forwarded after start. Ports
+-- already listed in forwardPorts are skipped and those entries are published at creation instead.
+local function normalize_app_ports(app_port, existing)
+  if not app_port then
+    return {}
+  end
+
+  local specs = type(app_port) == 'table' and app_port or { app_port }
+  local normalized = normalize_ports(specs)
+  local result = {}
+
+  for _, entry in ipairs(normalized) do
+    local duplicate = false
+    for _, port in ipairs(existing or {}) do
+      if port.container_port == entry.container_port and port.host_port == entry.host_port then
+        duplicate = true
+        port.source = 'appPort'
+        break
+      end
+    end
+    if not duplicate then
+      entry.source = 'appPort'
+      table.insert(result, entry)
+    end
+  end
+
+  return result
+end
+
+-- Find portsAttributes for a container port
+-- Keys may be a single port ("3000") or a range ("40000-55000"); otherPortsAttributes is the fallback
+local function find_port_attributes(config, container_port)
+  local attributes = config.portsAttributes or {}
+  if attributes[tostring(container_port)] then
+    return attributes[tostring(container_port)]
+  end
+
+  for key, value in pairs(attributes) do
+    local range_start, range_end = tostring(key):match('^(%d+)%-(%d+)$')
+    if range_start and container_port >= tonumber(range_start) and container_port <= tonumber(range_end) then
+      return value
+    end
+  end
+
+  return config.otherPortsAttributes
+end
+
+-- Attach portsAttributes (label, onAutoForward, ...) to normalized ports
+local function apply_port_attributes(ports, config)
+  for _, port in ipairs(ports or {}) do
+    local attributes = port.container_port and find_port_attributes(config, port.container_port)
+    if type(attributes) == 'table' then
+      port.attributes = attributes
+      port.label = attributes.label
+    end
+  end
+  return ports
+end
+
+-- Aliases accepted by `docker run --mount` for the keys used by the plugin
+local mount_key_aliases = {
+  src = 'source',
+  destination = 'target',
+  dst = 'target',
+  ro = 'readonly',
+}
+
+-- Expand ~ in host paths
+local function expand_home(path)
+  if type(path) == 'string' and path:match('^~') then
+    return (os.getenv('HOME') or '~') .. path:sub(2)
+  end
+  return path
+end
+
 -- Normalize mount settings
-local function normalize_mounts(mounts, context)
+-- Accepts the string form ("source=...,target=...,type=bind") and the object form
+function M.normalize_mounts(mounts, context)
   if not mounts then
     return {}
   end
@@ -315,39 +634,363 @@ local function normalize_mounts(mounts, context)
   local normalized = {}
 
   for _, mount in ipairs(mounts) do
+    local mount_config
     if type(mount) == 'string' then
-      -- Parse "source=...,target=...,type=..." format string
-      local mount_config = {}
+      -- Parse "source=...,target=...,type=..." format string; bare flags such as "readonly" are allowed
+      mount_config = {}
       for pair in mount:gmatch('[^,]+') do
-        local key, value = pair:match('([^=]+)=(.+)')
-        if key and value then
-          mount_config[key:match('^%s*(.-)%s*$')] = expand_variables(value:match('^%s*(.-)%s*$'), context)
+        local key, value = pair:match('^%s*([^=]-)%s*=%s*(.-)%s*$')
+        if not key then
+          key, value = pair:match('^%s*(.-)%s*$'), 'true'
         end
+        key = mount_key_aliases[key] or key
+        mount_config[key] = expand_variables(value, context)
       end
+      mount_config.readonly = mount_config.readonly == 'true' or mount_config.readonly == '1'
+    elseif type(mount) == 'table' then
+      mount_config = {}
+      for key, value in pairs(mount) do
+        mount_config[mount_key_aliases[key] or key] = expand_variables(value, context)
+      end
+      mount_config.readonly = mount_config.readonly == true
+    end
 
-      if mount_config.source and mount_config.target then
+    if mount_config and mount_config.target then
+      local mount_type = mount_config.type or 'bind'
+      local source = mount_config.source
+      if mount_type == 'bind' then
+        source = expand_home(source)
+        -- Relative bind sources resolve against the folder of devcontainer.json
+        if source and context and context.devcontainer_folder and not fs.is_absolute_path(source) then
+          source = fs.resolve_path(source, context.devcontainer_folder)
+        end
+      end
+      if source or mount_type == 'tmpfs' then
         table.insert(normalized, {
-          type = mount_config.type or 'bind',
-          source = mount_config.source,
+          type = mount_type,
+          source = source,
           target = mount_config.target,
-          readonly = mount_config.readonly == 'true',
+          readonly = mount_config.readonly,
           consistency = mount_config.consistency,
         })
       end
-    elseif type(mount) == 'table' then
-      table.insert(normalized, {
-        type = mount.type or 'bind',
-        source = expand_variables(mount.source, context),
-        target = expand_variables(mount.target, context),
-        readonly = mount.readonly or false,
-        consistency = mount.consistency,
-      })
+    elseif mount_config then
+      log.warn('Ignoring mount without target: %s', type(mount) == 'string' and mount or vim.inspect(mount))
     end
   end
 
   return normalized
 end
 
+-- Expand ~ and environment variables in a host path: ${localEnv:NAME}, ${NAME} and $NAME
+-- @param path string
+-- @return string
+function M.expand_host_path(path)
+  path = expand_variables(path, {})
+  path = path:gsub('%${([%w_]+)}', function(name)
+    return os.getenv(name)
+  end)
+  path = path:gsub('%$([%a_][%w_]*)', function(name)
+    return os.getenv(name)
+  end)
+  return expand_home(path)
+end
+
+-- Merge the additional_mounts plugin setting into the mounts of a parsed configuration
+-- Sources are host paths where ~ and environment variables are expanded. Sources that do not exist and
+-- targets that devcontainer.json already mounts are skipped with a warning.
+-- @param config table: parsed configuration (normalized_mounts is extended)
+-- @param additional_mounts table|nil: list of { source, target, readonly }
+-- @return table, table: config and warnings about skipped mounts
+function M.add_additional_mounts(config, additional_mounts)
+  local warnings = {}
+  if not additional_mounts or #additional_mounts == 0 then
+    return config, warnings
+  end
+
+  config.normalized_mounts = config.normalized_mounts or {}
+  local targets = {}
+  for _, mount in ipairs(config.normalized_mounts) do
+    targets[mount.target] = true
+  end
+
+  for _, mount in ipairs(additional_mounts) do
+    local source = type(mount.source) == 'string' and M.expand_host_path(mount.source) or nil
+    local warning
+    if not source or not mount.target then
+      warning = 'Ignoring additional mount without source or target: ' .. vim.inspect(mount)
+    elseif not fs.is_absolute_path(source) or not fs.exists(source) then
+      warning = string.format('Skipping additional mount %s: host path does not exist', source)
+    elseif targets[mount.target] then
+      warning = string.format('Skipping additional mount %s: %s is already mounted', source, mount.target)
+    end
+
+    if warning then
+      log.warn(warning)
+      table.insert(warnings, warning)
+    else
+      targets[mount.target] = true
+      table.insert(config.normalized_mounts, {
+        type = 'bind',
+        source = source,
+        target = mount.target,
+        readonly = mount.readonly == true,
+      })
+    end
+  end
+
+  return config, warnings
+end
+
+-- Put the default_build_args and default_run_args plugin settings before the flags of a normalized configuration
+-- Docker uses the last value of a repeated flag, so runArgs and the build settings of devcontainer.json override
+-- them. The defaults are kept in default_run_args and default_build_args for the dry run.
+-- @param config table: normalized configuration (run_args is extended)
+-- @param plugin_config table|nil
+-- @return table: config
+function M.add_default_args(config, plugin_config)
+  plugin_config = plugin_config or {}
+  config.default_run_args = vim.list_extend({}, plugin_config.default_run_args or {})
+  config.default_build_args = vim.list_extend({}, plugin_config.default_build_args or {})
+  config.run_args = vim.list_extend(vim.list_extend({}, config.default_run_args), config.run_args or {})
+  return config
+end
+
+-- Default container folder of the workspace when devcontainer.json sets neither workspaceFolder nor workspaceMount
+M.DEFAULT_WORKSPACE_FOLDER = '/workspace'
+
+-- Check whether a container path is the given folder or inside it
+local function is_within(path, folder)
+  path = path:gsub('/+$', '')
+  folder = folder:gsub('/+$', '')
+  return path == folder or folder == '' or path:sub(1, #folder + 1) == folder .. '/'
+end
+
+-- Resolve the mount that brings the workspace into the container
+-- An explicit workspaceMount is used as given; otherwise the project folder is bind mounted at workspaceFolder.
+-- Compose configurations mount the workspace in their service definition and get no mount here.
+-- @param config table: expanded devcontainer.json (workspaceFolder not defaulted yet)
+-- @param context table: parse context (workspace_folder is the host project folder)
+-- @return table|nil, table: workspace mount and warnings about inconsistent settings
+function M.resolve_workspace_mount(config, context)
+  local warnings = {}
+  if config.dockerComposeFile then
+    return nil, warnings
+  end
+
+  local workspace_folder = config.workspaceFolder
+  if type(config.workspaceMount) == 'string' and config.workspaceMount ~= '' then
+    local mount = M.normalize_mounts({ config.workspaceMount }, context)[1]
+    if mount then
+      if not workspace_folder then
+        table.insert(
+          warnings,
+          string.format('workspaceMount is set without workspaceFolder, using its target %s', mount.target)
+        )
+      elseif not is_within(workspace_folder, mount.target) then
+        table.insert(
+          warnings,
+          string.format('workspaceFolder %s is not inside the workspaceMount target %s', workspace_folder, mount.target)
+        )
+      end
+      return mount, warnings
+    end
+    table.insert(warnings, 'Ignoring workspaceMount without source or target: ' .. config.workspaceMount)
+  end
+
+  return {
+    type = 'bind',
+    source = context.workspace_folder or vim.fn.getcwd(),
+    target = workspace_folder or M.DEFAULT_WORKSPACE_FOLDER,
+  }, warnings
+end
+
+-- Host and container folders that workspace paths translate between
+-- The workspace bind mount is the base; without one the project folder maps to workspaceFolder.
+-- @param config table: normalized configuration
+-- @return string, string: host root and container root
+function M.workspace_roots(config)
+  config = config or {}
+  local mount = config.workspace_mount
+  if mount and mount.type == 'bind' and mount.source then
+    return mount.source, mount.target
+  end
+  return config.base_path or vim.fn.getcwd(), config.workspace_folder or M.DEFAULT_WORKSPACE_FOLDER
+end
+
+-- Check whether the workspace lives in a volume (e.g. cloned into a named volume), so its files exist only in the
+-- container
+function M.is_volume_workspace(config)
+  local mount = config and config.workspace_mount
+  return mount ~= nil and mount.type ~= 'bind'
+end
+
+-- Default name of the volume a repository is cloned into: the repository name and a hash of the URL and branch
+function M.workspace_clone_volume(repository, branch)
+  local name = repository:gsub('/+$', ''):gsub('%.git$', ''):match('([^/:]+)$') or 'workspace'
+  local hash = vim.fn.sha256(repository .. '#' .. (branch or '')):sub(1, 8)
+  return string.format('%s-%s-workspace', name:lower():gsub('[^%w_.-]', '-'), hash)
+end
+
+-- Clone the workspace into a named volume instead of bind mounting the project folder (workspace_clone)
+-- The volume is mounted at workspaceFolder and the repository is cloned into it once the container runs (see
+-- docker.clone_workspace_async); later starts find the clone there and reuse it.
+-- @param config table: normalized configuration
+-- @param plugin_config table|nil
+-- @return table, string|nil: the configuration and a warning when the clone cannot be used
+function M.add_workspace_clone(config, plugin_config)
+  local clone = plugin_config and plugin_config.workspace_clone or {}
+  if type(clone.repository) ~= 'string' or clone.repository == '' then
+    return config
+  end
+  if config.compose_files then
+    return config, 'workspace_clone is ignored for Docker Compose configurations, mount a volume in the service instead'
+  end
+  local branch = clone.branch ~= '' and clone.branch or nil
+  config.workspace_clone = {
+    repository = clone.repository,
+    branch = branch,
+    volume = clone.volume or M.workspace_clone_volume(clone.repository, branch),
+  }
+  config.workspace_mount = {
+    type = 'volume',
+    source = config.workspace_clone.volume,
+    target = config.workspace_folder or M.DEFAULT_WORKSPACE_FOLDER,
+  }
+  return config
+end
+
+-- Check whether a table is an array (an empty table counts as one)
+local function is_array(value)
+  return type(value) == 'table' and (next(value) == nil or value[1] ~= nil)
+end
+
+local function copy_value(value)
+  if type(value) ~= 'table' then
+    return value
+  end
+  local copy = {}
+  for key, item in pairs(value) do
+    copy[key] = copy_value(item)
+  end
+  return copy
+end
+
+-- Deep merge a configuration over the configuration it extends
+-- Objects (containerEnv, features, customizations, ...) merge key-wise, arrays (mounts, forwardPorts,
+-- runArgs, ...) are concatenated with the base entries first and duplicates dropped, and any other
+-- value in the child overrides the base.
+function M.merge_configs(base, child)
+  if type(base) ~= 'table' or type(child) ~= 'table' then
+    return copy_value(child == nil and base or child)
+  end
+  if next(child) == nil then
+    return copy_value(base)
+  end
+  if next(base) == nil then
+    return copy_value(child)
+  end
+
+  if is_array(base) and is_array(child) then
+    local merged = copy_value(base)
+    local seen = {}
+    for _, item in ipairs(merged) do
+      if type(item) ~= 'table' then
+        seen[item] = true
+      end
+    end
+    for _, item in ipairs(child) do
+      if type(item) == 'table' or not seen[item] then
+        table.insert(merged, copy_value(item))
+      end
+    end
+    return merged
+  end
+  if is_array(base) or is_array(child) then
+    return copy_value(child)
+  end
+
+  local merged = copy_value(base)
+  for key, value in pairs(child) do
+    merged[key] = M.merge_configs(merged[key], value)
+  end
+  return merged
+end
+
+local function resolve_config_path(path, base_path)
+  local resolved = fs.resolve_path(path, base_path)
+  return vim.fn.simplify(resolved)
+end
+
+-- Make the paths of a base configuration absolute, since they are relative to its own folder
+-- rather than to the folder of the configuration extending it
+local function anchor_base_paths(config, base_path)
+  if type(config.dockerFile) == 'string' then
+    config.dockerFile = resolve_config_path(config.dockerFile, base_path)
+  end
+  if type(config.build) == 'table' and type(config.build.context) == 'string' then
+    config.build.context = resolve_config_path(config.build.context, base_path)
+  end
+  if type(config.dockerComposeFile) == 'string' then
+    config.dockerComposeFile = resolve_config_path(config.dockerComposeFile, base_path)
+  elseif type(config.dockerComposeFile) == 'table' then
+    for i, compose_file in ipairs(config.dockerComposeFile) do
+      config.dockerComposeFile[i] = resolve_config_path(compose_file, base_path)
+    end
+  end
+end
+
+-- Merge the chain of configurations named by "extends" (a path relative to the extending file)
+-- sources records the files each top-level key comes from, the base configurations first.
+local function resolve_extends(config, file_path, seen, sources)
+  local extends = config.extends
+  config.extends = nil
+  if extends == nil then
+    for key in pairs(config) do
+      sources[key] = { file_path }
+    end
+    return config
+  end
+  if type(extends) ~= 'string' or extends == '' then
+    return nil, string.format('Invalid extends in %s: must be a path to a devcontainer.json', file_path)
+  end
+
+  local base_file = resolve_config_path(extends, fs.dirname(file_path))
+  if seen[base_file] then
+    return nil, 'Circular extends: ' .. base_file
+  end
+  seen[base_file] = true
+
+  local content, err = fs.read_file(base_file)
+  if not content then
+    return nil, string.format('Failed to read base configuration %s (extended by %s): %s', base_file, file_path, err)
+  end
+  local base, parse_err = parse_json(content)
+  if not base then
+    return nil, string.format('%s (in %s)', parse_err, base_file)
+  end
+
+  base, err = resolve_extends(base, base_file, seen, sources)
+  if not base then
+    return nil, err
+  end
+  anchor_base_paths(base, fs.dirname(base_file))
+
+  -- Objects and arrays merge with the base value, anything else replaces it
+  for key, value in pairs(config) do
+    local base_value = base[key]
+    local merges = type(value) == 'table' and type(base_value) == 'table' and is_array(value) == is_array(base_value)
+    if sources[key] and merges then
+      table.insert(sources[key], file_path)
+    else
+      sources[key] = { file_path }
+    end
+  end
+
+  log.debug('Merging %s over base configuration %s', file_path, base_file)
+  return M.merge_configs(base, config)
+end
+
 -- Parse devcontainer.json
 function M.parse(file_path, context)
   context = context or {}
@@ -370,38 +1013,77 @@ function M.parse(file_path, context)
     return nil, parse_err
   end
 
+  -- Merge base configurations
+  local extends_err
+  local key_sources = {}
+  config, extends_err = resolve_extends(config, file_path, { [file_path] = true }, key_sources)
+  if not config then
+    return nil, extends_err
+  end
+
   -- Debug: postCreateCommand after parsing
   log.debug('Raw config postCreateCommand: %s', tostring(config.postCreateCommand))
 
-  -- Set base path
+  -- Set base path (relative paths such as the Dockerfile resolve against the config's own folder)
   local base_path = fs.dirname(file_path)
-  -- Set workspace_folder to parent directory of .devcontainer (project root)
-  context.workspace_folder = context.workspace_folder or fs.dirname(base_path)
+  -- Set workspace_folder to the project root
+  context.workspace_folder = context.workspace_folder or M.get_workspace_folder(file_path)
   context.devcontainer_folder = base_path
+  context.devcontainer_id = M.get_devcontainer_id(context.workspace_folder, file_path)
 
-  -- Set context for variable expansion
-  context.container_workspace = config.workspaceFolder or '/workspace'
+  -- Set context for variable expansion (workspaceFolder may itself refer to local variables)
+  context.container_workspace = expand_variables(config.workspaceFolder or '/workspace', context)
 
   -- Expand configuration
+  -- ${localEnv:...} and workspace variables are expanded now; ${containerEnv:...} in remoteEnv
+  -- is kept until the container environment is known
+  local remote_env = config.remoteEnv
+  config.remoteEnv = nil
   config = expand_config_variables(config, context)
+  if remote_env then
+    local remote_context = vim.tbl_extend('force', context, { defer_container_env = true })
+    config.remoteEnv = expand_config_variables(remote_env, remote_context)
+  end
 
   -- Resolve paths
+  config.devcontainer_folder = base_path
   config.resolved_dockerfile = resolve_dockerfile_path(config, base_path)
-  config.resolved_compose_file = resolve_compose_file_path(config, base_path)
+  config.resolved_compose_file, config.resolved_compose_files = resolve_compose_file_path(config, base_path)
+  config.config_file = file_path
+  config.devcontainer_id = context.devcontainer_id
+  config.key_sources = key_sources
 
   -- Normalize port settings
   config.normalized_ports, config.deprecated_ports = normalize_ports(config.forwardPorts, config)
+  for _, port in ipairs(config.normalized_ports) do
+    port.source = 'forwardPorts'
+  end
+  vim.list_extend(config.normalized_ports, normalize_app_ports(config.appPort, config.normalized_ports))
+  apply_port_attributes(config.normalized_ports, config)
 
   -- Generate project ID for port allocation
   config.project_id = M.generate_project_id(context.workspace_folder or vim.fn.getcwd())
 
   -- Normalize mount settings
-  config.normalized_mounts = normalize_mounts(config.mounts, context)
+  config.normalized_mounts = M.normalize_mounts(config.mounts, context)
+
+  -- Workspace mount (workspaceMount, or the project folder at workspaceFolder)
+  local workspace_mount, workspace_warnings = M.resolve_workspace_mount(config, context)
+  config.workspace_mount = workspace_mount
+  for _, warning in ipairs(workspace_warnings) do
+    log.warn(warning)
+    vim.notify('[container.nvim] Warning: ' .. warning, vim.log.levels.WARN)
+  end
+  if workspace_mount and not config.workspaceFolder then
+    config.workspaceFolder = workspace_mount.target
+  end
 
   -- Set default values
+  config.workspace_folder_specified = config.workspaceFolder ~= nil
   config.name = config.name or 'devcontainer'
   config.workspaceFolder = config.workspaceFolder or '/workspace'
-  config.remoteUser = config.remoteUser or 'root'
+  -- remoteUser defaults to containerUser; without either, the image's default user is kept
+  config.remoteUser = config.remoteUser or config.containerUser
 
   -- Handle deprecated dynamic port syntax migration
   if config.deprecated_ports and #config.deprecated_ports > 0 then
@@ -487,6 +1169,69 @@ function M.find_and_parse(start_path, context)
   return M.parse(devcontainer_path, context)
 end
 
+-- Values of shutdownAction
+M.SHUTDOWN_ACTIONS = { none = true, stopContainer = true, stopCompose = true }
+
+-- Values of userEnvProbe
+M.USER_ENV_PROBES = { none = true, loginShell = true, interactiveShell = true, loginInteractiveShell = true }
+
+-- Linux capabilities accepted in capAdd (without the CAP_ prefix; docker also accepts ALL)
+M.CAPABILITIES = {}
+for name in (
+  'ALL AUDIT_CONTROL AUDIT_READ AUDIT_WRITE BLOCK_SUSPEND BPF CHECKPOINT_RESTORE CHOWN DAC_OVERRIDE '
+  .. 'DAC_READ_SEARCH FOWNER FSETID IPC_LOCK IPC_OWNER KILL LEASE LINUX_IMMUTABLE MAC_ADMIN MAC_OVERRIDE MKNOD '
+  .. 'NET_ADMIN NET_BIND_SERVICE NET_BROADCAST NET_RAW PERFMON SETFCAP SETGID SETPCAP SETUID SYS_ADMIN SYS_BOOT '
+  .. 'SYS_CHROOT SYS_MODULE SYS_NICE SYS_PACCT SYS_PTRACE SYS_RAWIO SYS_RESOURCE SYS_TIME SYS_TTY_CONFIG SYSLOG '
+  .. 'WAKE_ALARM'
+):gmatch('%S+') do
+  M.CAPABILITIES[name] = true
+end
+
+-- Options accepted in securityOpt (name=value, name:value, or no-new-privileges alone)
+M.SECURITY_OPTIONS = {
+  apparmor = true,
+  label = true,
+  ['no-new-privileges'] = true,
+  seccomp = true,
+  systempaths = true,
+  ['writable-cgroups'] = true,
+}
+
+-- Check privileged, capAdd and securityOpt
+-- @return table: error messages
+function M.validate_security(config)
+  local errors = {}
+  if config.privileged ~= nil and type(config.privileged) ~= 'boolean' then
+    table.insert(errors, 'privileged must be a boolean')
+  end
+
+  for _, key in ipairs({ 'capAdd', 'securityOpt' }) do
+    local value = config[key]
+    local valid = value == nil or type(value) == 'table'
+    for _, item in ipairs(valid and value or {}) do
+      valid = valid and type(item) == 'string'
+    end
+    if not valid then
+      table.insert(errors, key .. ' must be an array of strings')
+    end
+  end
+
+  for _, capability in ipairs(type(config.capAdd) == 'table' and config.capAdd or {}) do
+    local name = type(capability) == 'string' and capability:upper():gsub('^CAP_', '') or nil
+    if name and not M.CAPABILITIES[name] then
+      table.insert(errors, 'Unknown capability in capAdd: ' .. capability)
+    end
+  end
+
+  for _, option in ipairs(type(config.securityOpt) == 'table' and config.securityOpt or {}) do
+    local name = type(option) == 'string' and (option:match('^([%w%-]+)[=:]') or option) or nil
+    if name and not M.SECURITY_OPTIONS[name] then
+      table.insert(errors, 'Unknown option in securityOpt: ' .. option)
+    end
+  end
+  return errors
+end
+
 -- Validate configuration
 function M.validate(config)
   local errors = {}
@@ -501,6 +1246,62 @@ function M.validate(config)
     table.insert(errors, 'Must specify one of: dockerFile, image, or dockerComposeFile')
   end
 
+  -- Compose-based configurations must name the service to attach to
+  if config.dockerComposeFile and not config.service then
+    table.insert(errors, 'Missing required field for dockerComposeFile: service')
+  end
+
+  -- waitFor names a lifecycle command
+  if config.waitFor ~= nil and not require('container.lifecycle').hook_position(config.waitFor) then
+    table.insert(errors, 'Invalid waitFor: ' .. tostring(config.waitFor))
+  end
+
+  if config.overrideCommand ~= nil and type(config.overrideCommand) ~= 'boolean' then
+    table.insert(errors, 'overrideCommand must be a boolean')
+  end
+
+  if config.shutdownAction ~= nil and not M.SHUTDOWN_ACTIONS[config.shutdownAction] then
+    table.insert(errors, 'Invalid shutdownAction: ' .. tostring(config.shutdownAction))
+  end
+
+  if config.userEnvProbe ~= nil and not M.USER_ENV_PROBES[config.userEnvProbe] then
+    table.insert(errors, 'Invalid userEnvProbe: ' .. tostring(config.userEnvProbe))
+  end
+
+  -- runArgs are passed to docker create as they are
+  if config.runArgs ~= nil then
+    local valid = type(config.runArgs) == 'table'
+    for _, arg in ipairs(valid and config.runArgs or {}) do
+      valid = valid and type(arg) == 'string'
+    end
+    if not valid then
+      table.insert(errors, 'runArgs must be an array of strings')
+    end
+  end
+
+  -- privileged, capAdd and securityOpt become docker create flags
+  for _, message in ipairs(M.validate_security(config)) do
+    table.insert(errors, message)
+  end
+
+  -- hostRequirements.gpu: true, false, "optional" or { cores, memory }
+  local gpu = type(config.hostRequirements) == 'table' and config.hostRequirements.gpu or nil
+  if gpu ~= nil and type(gpu) ~= 'boolean' and type(gpu) ~= 'table' and gpu ~= 'optional' then
+    table.insert(errors, 'Invalid hostRequirements.gpu: ' .. tostring(gpu))
+  end
+
+  -- hostRequirements.cpus is a number, memory and storage are sizes such as "8gb"
+  local host_requirements = type(config.hostRequirements) == 'table' and config.hostRequirements or {}
+  if host_requirements.cpus ~= nil and (tonumber(host_requirements.cpus) or 0) <= 0 then
+    table.insert(errors, 'Invalid hostRequirements.cpus: ' .. tostring(host_requirements.cpus))
+  end
+  for _, key in ipairs({ 'memory', 'storage' }) do
+    local value = host_requirements[key]
+    if value ~= nil and not require('container.host_requirements').parse_size(value) then
+      table.insert(errors, string.format('Invalid hostRequirements.%s: %s', key, tostring(value)))
+    end
+  end
+
   -- Validate port settings
   if config.normalized_ports then
     for _, port in ipairs(config.normalized_ports) do
@@ -540,6 +1341,10 @@ function M.validate(config)
 
   -- Validate environment customizations
   if config.customizations then
+    local prebuilt_image = (config.customizations['container.nvim'] or {}).prebuiltImage
+    if prebuilt_image ~= nil and (type(prebuilt_image) ~= 'string' or prebuilt_image == '') then
+      table.insert(errors, 'customizations.container.nvim.prebuiltImage must be an image reference')
+    end
     local environment = require('container.environment')
     local env_errors = environment.validate_environment(config)
     for _, err in ipairs(env_errors) do
@@ -570,6 +1375,33 @@ function M.validate_resolved_ports(config)
   return errors
 end
 
+-- Normalize build.cacheFrom to a list of image references
+-- Accepts a string, an array or an object whose values are used in key order.
+function M.normalize_cache_from(cache_from)
+  if type(cache_from) == 'string' then
+    return cache_from ~= '' and { cache_from } or {}
+  end
+  if type(cache_from) ~= 'table' then
+    return {}
+  end
+  local images = {}
+  if is_array(cache_from) then
+    for _, image in ipairs(cache_from) do
+      table.insert(images, image)
+    end
+    return images
+  end
+  local keys = {}
+  for key in pairs(cache_from) do
+    table.insert(keys, key)
+  end
+  table.sort(keys)
+  for _, key in ipairs(keys) do
+    table.insert(images, cache_from[key])
+  end
+  return images
+end
+
 -- Normalize configuration for plugin use
 function M.normalize_for_plugin(config)
   local normalized = {}
@@ -579,17 +1411,45 @@ function M.normalize_for_plugin(config)
   normalized.image = config.image
   normalized.dockerfile = config.resolved_dockerfile
   normalized.context = config.build and config.build.context or '.'
-  normalized.build_args = config.build and config.build.args or {}
-  normalized.workspace_folder = config.workspaceFolder or '/workspace'
+  -- A build context given in devcontainer.json is relative to its folder
+  if config.build and config.build.context and config.devcontainer_folder then
+    normalized.context = fs.resolve_path(config.build.context, config.devcontainer_folder)
+  end
+  -- build.args values have their ${...} references expanded like the rest of the configuration
+  local build = type(config.build) == 'table' and config.build or {}
+  normalized.build_args = build.args or {}
+  normalized.build_target = build.target
+  normalized.cache_from = M.normalize_cache_from(build.cacheFrom)
+  normalized.build_options = build.options or {}
+  normalized.workspace_folder = config.workspaceFolder or M.DEFAULT_WORKSPACE_FOLDER
+  normalized.workspace_mount = config.workspace_mount
   normalized.remote_user = config.remoteUser
+  normalized.container_user = config.containerUser
+  normalized.update_remote_user_uid = config.updateRemoteUserUID
 
   -- Environment variables (standard support)
-  normalized.environment = {}
-  if config.containerEnv then
-    normalized.environment = vim.tbl_deep_extend('force', normalized.environment, config.containerEnv)
+  -- containerEnv is set when the container is created, remoteEnv is injected into every exec session
+  normalized.environment = vim.deepcopy(config.containerEnv or {})
+  normalized.remote_env = vim.deepcopy(config.remoteEnv or {})
+
+  -- Docker Compose settings
+  if config.resolved_compose_files then
+    normalized.compose_files = config.resolved_compose_files
+    normalized.compose_project_dir = fs.dirname(config.resolved_compose_files[1])
+    normalized.service = config.service
+    normalized.run_services = config.runServices or {}
+    normalized.workspace_folder_specified = config.workspace_folder_specified
   end
-  if config.remoteEnv then
-    normalized.environment = vim.tbl_deep_extend('force', normalized.environment, config.remoteEnv)
+  normalized.config_file = config.config_file
+  normalized.devcontainer_id = config.devcontainer_id
+
+  -- devcontainer.json properties after extends and variable expansion, and the files they come from
+  normalized.key_sources = config.key_sources
+  if config.key_sources then
+    normalized.devcontainer_json = {}
+    for key in pairs(config.key_sources) do
+      normalized.devcontainer_json[key] = copy_value(config[key])
+    end
   end
 
   -- Port settings
@@ -598,16 +1458,27 @@ function M.normalize_for_plugin(config)
   -- Mount settings
   normalized.mounts = config.normalized_mounts or {}
 
-  -- Feature settings
+  -- Feature settings (local feature paths are resolved against the devcontainer folder)
   normalized.features = config.features or {}
+  normalized.devcontainer_folder = config.devcontainer_folder
 
   -- Customizations
   normalized.customizations = config.customizations or {}
 
-  -- Lifecycle commands
+  -- Lifecycle commands (string, array or object keyed by label)
+  -- initializeCommand runs on the host, the others in the container
+  normalized.initialize_command = config.initializeCommand
+  normalized.on_create_command = config.onCreateCommand
+  normalized.update_content_command = config.updateContentCommand
   normalized.post_create_command = config.postCreateCommand
   normalized.post_start_command = config.postStartCommand
   normalized.post_attach_command = config.postAttachCommand
+  normalized.wait_for = config.waitFor
+  -- Cleanup run in the container before it is stopped (customizations.container.nvim.preStopCommand)
+  local plugin_customizations = normalized.customizations['container.nvim'] or {}
+  normalized.pre_stop_command = plugin_customizations.preStopCommand
+  -- Image built by CI, pulled instead of building when it matches (customizations.container.nvim.prebuiltImage)
+  normalized.prebuilt_image = plugin_customizations.prebuiltImage
 
   -- Security settings
   normalized.privileged = config.privileged or false
@@ -617,14 +1488,18 @@ function M.normalize_for_plugin(config)
 
   -- Other Docker settings
   normalized.run_args = config.runArgs or {}
+  normalized.host_requirements = config.hostRequirements or {}
   normalized.override_command = config.overrideCommand
   normalized.shutdown_action = config.shutdownAction
+  -- Shell whose environment exec sessions inherit (see environment.probe_user_env)
+  normalized.user_env_probe = config.userEnvProbe or 'loginInteractiveShell'
 
   -- Force rebuild flag
   normalized.force_rebuild = false
 
   -- Port attributes
   normalized.port_attributes = config.portsAttributes or {}
+  normalized.other_port_attributes = config.otherPortsAttributes
 
   return normalized
 end
@@ -718,5 +1593,8 @@ end
 
 -- Expose normalize_ports for testing
 M.normalize_ports = normalize_ports
+M.normalize_app_ports = normalize_app_ports
+M.apply_port_attributes = apply_port_attributes
+M.find_port_attributes = find_port_attributes
 
 return M
@@ -0,0 +1,86 @@
+-- lua/container/pipeline.lua
+-- Start pipelines: initializeCommand → image build/pull → container create/start → lifecycle commands
+-- One pipeline runs per workspace. Jobs started on its behalf are registered with track() so that
+-- cancel() can stop whatever is in flight; the steps check `cancelled` before moving on.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Active pipelines keyed by workspace root
+local pipelines = {}
+
+-- Begin a pipeline for a workspace, replacing a finished or cancelled one
+-- Without a workspace root the pipeline is not registered (nothing can be tracked or cancelled).
+-- @return table: { key, cancelled, ready, jobs, progress }
+function M.begin(key)
+  local run = {
+    key = key,
+    cancelled = false,
+    -- Set once the container is ready; cancelling afterwards only stops remaining lifecycle commands
+    ready = false,
+    jobs = {},
+    -- Last reported step: { step, total, message }
+    progress = nil,
+  }
+  if key then
+    pipelines[key] = run
+  end
+  return run
+end
+
+-- Pipeline running for a workspace
+-- @return table|nil
+function M.active(key)
+  return key and pipelines[key] or nil
+end
+
+-- Check whether a pipeline was cancelled (nil, i.e. no pipeline, is never cancelled)
+function M.is_cancelled(run)
+  return run ~= nil and run.cancelled
+end
+
+-- Register a job started on behalf of the workspace's pipeline
+-- Does nothing when no pipeline is running, so callers can track unconditionally.
+function M.track(key, job_id)
+  local run = M.active(key)
+  if not run or not job_id or job_id <= 0 then
+    return
+  end
+  table.insert(run.jobs, job_id)
+end
+
+-- Record the current step
+function M.set_progress(run, step, total, message)
+  if not run then
+    return
+  end
+  local previous = run.progress or {}
+  run.progress = { step = step or previous.step, total = total or previous.total, message = message }
+end
+
+-- End a pipeline once it has completed or failed
+function M.finish(run)
+  if run and pipelines[run.key] == run then
+    pipelines[run.key] = nil
+  end
+end
+
+-- Cancel the workspace's pipeline and stop its jobs
+-- @return table|nil: the cancelled pipeline, nil when none was running
+function M.cancel(key)
+  local run = M.active(key)
+  if not run then
+    return nil
+  end
+  run.cancelled = true
+  pipelines[key] = nil
+  for _, job_id in ipairs(run.jobs) do
+    -- Jobs that already exited are ignored by jobstop
+    pcall(vim.fn.jobstop, job_id)
+  end
+  log.info('Cancelled start pipeline of %s (%d job(s) stopped)', key, #run.jobs)
+  return run
+end
+
+return M
@@ -0,0 +1,159 @@
+-- lua/container/port_actions.lua
+-- portsAttributes onAutoForward actions
+-- Once a forwarded port is listened on in the container, "notify" shows its URL and "openBrowser",
+-- "openBrowserOnce" and "openPreview" open it on the host (vim.ui.open or port_forwarding.open_browser).
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Seconds between checks of the listening ports and how long to wait for a port
+local POLL_INTERVAL = 2
+local WAIT_TIMEOUT = 300
+
+-- Actions that open the URL
+local OPEN_ACTIONS = {
+  openBrowser = true,
+  openBrowserOnce = true,
+  openPreview = true,
+}
+
+-- URLs opened by openBrowserOnce during this Neovim session
+local opened_once = {}
+
+-- onAutoForward action of a port, nil when the port has none to perform
+-- @param port table: normalized port with attributes
+-- @return string|nil
+function M.get_action(port)
+  local action = type(port.attributes) == 'table' and port.attributes.onAutoForward or nil
+  if action == 'notify' or OPEN_ACTIONS[action] then
+    return action
+  end
+  return nil
+end
+
+-- URL of a forwarded port on the host
+-- @param port table: { host_port, attributes }
+-- @return string
+function M.get_url(port)
+  local attributes = type(port.attributes) == 'table' and port.attributes or {}
+  local scheme = attributes.protocol == 'https' and 'https' or 'http'
+  local forward_config = require('container.config').get_value('port_forwarding') or {}
+  local address = forward_config.bind_address
+  if not address or address == '0.0.0.0' or address == '127.0.0.1' then
+    address = 'localhost'
+  end
+  return string.format('%s://%s:%d', scheme, address, port.host_port)
+end
+
+-- Open a URL on the host with port_forwarding.open_browser, or vim.ui.open
+-- @param url string
+-- @param port table: the forwarded port
+function M.open(url, port)
+  local notify = require('container.utils.notify')
+  local open_browser = require('container.config').get_value('port_forwarding.open_browser')
+  if open_browser then
+    local ok, err = pcall(open_browser, url, port)
+    if not ok then
+      notify.error('port_forwarding.open_browser failed: ' .. tostring(err))
+    end
+    return
+  end
+
+  if not (vim.ui and vim.ui.open) then
+    local message = 'Cannot open %s: vim.ui.open requires Neovim 0.10 (see port_forwarding.open_browser)'
+    notify.warn(string.format(message, url))
+    return
+  end
+  local _, err = vim.ui.open(url)
+  if err then
+    notify.warn(string.format('Failed to open %s: %s', url, err))
+  end
+end
+
+-- Perform the onAutoForward action of a port that is now reachable
+-- @param port table: { container_port, host_port, label, attributes }
+function M.perform(port)
+  local action = M.get_action(port)
+  if not action then
+    return
+  end
+  local url = M.get_url(port)
+  local name = port.label and string.format('%d (%s)', port.container_port, port.label) or tostring(port.container_port)
+
+  if action == 'notify' then
+    require('container.utils.notify').container(string.format('Port %s is available at %s', name, url))
+    return
+  end
+  if action == 'openBrowserOnce' then
+    if opened_once[url] then
+      return
+    end
+    opened_once[url] = true
+  end
+  log.info('Opening %s for port %s (%s)', url, name, action)
+  M.open(url, port)
+end
+
+-- Wait for the ports with an onAutoForward action to be listened on and perform their actions
+-- Ports are checked every few seconds until they are reachable, the timeout passes or
+-- is_active returns false (the container was stopped or replaced).
+-- @param container_id string
+-- @param ports table: normalized ports with host_port and attributes
+-- @param is_active function|nil: returns false once the container is no longer current
+function M.watch(container_id, ports, is_active)
+  local pending = {}
+  for _, port in ipairs(ports or {}) do
+    -- Ports of other compose services are not listened on in this container
+    if port.host_port and port.container_port and not port.service and M.get_action(port) then
+      table.insert(pending, port)
+    end
+  end
+  if #pending == 0 then
+    return
+  end
+
+  local store = require('container.forward_store')
+  local deadline = os.time() + WAIT_TIMEOUT
+
+  local function check()
+    if is_active and not is_active() then
+      return
+    end
+    store.list_listening_ports(container_id, function(listening)
+      vim.schedule(function()
+        if is_active and not is_active() then
+          return
+        end
+        if not listening then
+          -- The container cannot be inspected, act right away rather than never
+          log.debug('Could not read listening ports of %s, performing port actions unchecked', container_id)
+        end
+        local waiting = {}
+        for _, port in ipairs(pending) do
+          if not listening or listening[port.container_port] then
+            M.perform(port)
+          else
+            table.insert(waiting, port)
+          end
+        end
+        pending = waiting
+        if #pending == 0 then
+          return
+        end
+        if os.time() >= deadline then
+          for _, port in ipairs(pending) do
+            local message = 'Port %d was not listened on within %ds, skipping its onAutoForward action'
+            log.info(message, port.container_port, WAIT_TIMEOUT)
+          end
+          return
+        end
+        vim.defer_fn(check, POLL_INTERVAL * 1000)
+      end)
+    end)
+  end
+
+  check()
+end
+
+return M
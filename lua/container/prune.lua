@@ -0,0 +1,283 @@
+-- lua/container/prune.lua
+-- Cleanup of the containers and images the plugin left behind (:ContainerPrune)
+-- Stopped containers carrying the workspace label and untagged (dangling) images carrying the image label are listed
+-- with their sizes and removed once confirmed. With --all, cached images built more than prune.max_age days ago are
+-- removed as well. The attached container is never removed, and docker keeps images a container still uses.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Entries shown in the confirmation before the rest is summarized
+M.MAX_LISTED = 15
+
+local SIZE_UNITS = { b = 1, kb = 1000, mb = 1000 ^ 2, gb = 1000 ^ 3, tb = 1000 ^ 4 }
+
+-- Parse a size printed by docker ("1.2GB", "512MB", "0B"); for containers the writable layer is the first size
+-- @param text string
+-- @return number: bytes (0 when unknown)
+function M.parse_size(text)
+  local number, unit = (text or ''):match('^%s*([%d%.]+)%s*(%a+)')
+  local factor = unit and SIZE_UNITS[(unit:lower():gsub('i', ''))]
+  return (tonumber(number) and factor) and math.floor(tonumber(number) * factor) or 0
+end
+
+-- Format bytes like docker does
+-- @param bytes number
+-- @return string
+function M.format_size(bytes)
+  for _, unit in ipairs({ 'TB', 'GB', 'MB', 'kB' }) do
+    local factor = SIZE_UNITS[unit:lower()]
+    if bytes >= factor then
+      return string.format('%.1f%s', bytes / factor, unit)
+    end
+  end
+  return string.format('%dB', bytes)
+end
+
+-- Parse the creation time of `docker images` ("2024-05-01 10:20:30 +0200 CEST")
+-- @param text string
+-- @return number|nil: time (the offset is ignored; ages are counted in days)
+function M.parse_created(text)
+  local year, month, day, hour, min, sec = (text or ''):match('^(%d+)%-(%d+)%-(%d+) (%d+):(%d+):(%d+)')
+  if not year then
+    return nil
+  end
+  return os.time({
+    year = tonumber(year),
+    month = tonumber(month),
+    day = tonumber(day),
+    hour = tonumber(hour),
+    min = tonumber(min),
+    sec = tonumber(sec),
+  })
+end
+
+-- Parse `docker ps --size` output in the format of collect()
+-- @param stdout string
+-- @param keep string|nil: id of the attached container, which is left out
+-- @return table: list of { id, name, size }
+function M.parse_containers(stdout, keep)
+  local containers = {}
+  for line in (stdout or ''):gmatch('[^\n]+') do
+    local id, name, size = line:match('^([^\t]+)\t([^\t]*)\t?(.*)$')
+    if id and not (keep and (keep:sub(1, #id) == id or id:sub(1, #keep) == keep)) then
+      table.insert(containers, { id = id, name = name, size = M.parse_size(size) })
+    end
+  end
+  return containers
+end
+
+-- Parse `docker images` output in the format of collect() into the images to remove
+-- Untagged images are always removed; tagged ones only with opts.all when older than opts.max_age days.
+-- @param stdout string
+-- @param opts table: { all = boolean, max_age = days, now = time }
+-- @return table: list of { id, name, size, age (days), dangling }
+function M.parse_images(stdout, opts)
+  local now = opts.now or os.time()
+  local images = {}
+  local seen = {}
+  for line in (stdout or ''):gmatch('[^\n]+') do
+    local id, name, size, created = line:match('^([^\t]+)\t([^\t]*)\t([^\t]*)\t(.*)$')
+    local created_at = M.parse_created(created)
+    local age = created_at and math.floor(os.difftime(now, created_at) / 86400) or 0
+    local dangling = name ~= nil and name:match('^<none>') ~= nil
+    if id and not seen[id] and (dangling or (opts.all and age >= opts.max_age)) then
+      seen[id] = true
+      table.insert(images, {
+        id = id,
+        name = dangling and '<none>' or name,
+        size = M.parse_size(size),
+        age = age,
+        dangling = dangling,
+      })
+    end
+  end
+  return images
+end
+
+-- Days after which --all removes cached images
+local function max_age()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local prune = ok and plugin_config.get_value and plugin_config.get_value('prune') or {}
+  return prune.max_age or 30
+end
+
+-- Containers and images to remove
+-- @param opts table: { all = boolean, max_age = days }
+-- @param callback function({ containers, images }|nil, err)
+function M.collect(opts, callback)
+  local docker = require('container.docker')
+  local state = require('container').get_state()
+  local ps_args = {
+    'ps',
+    '-a',
+    '--size',
+    '--filter',
+    'label=' .. docker.WORKSPACE_LABEL,
+    '--filter',
+    'status=exited',
+    '--filter',
+    'status=created',
+    '--filter',
+    'status=dead',
+    '--format',
+    '{{.ID}}\t{{.Names}}\t{{.Size}}',
+  }
+  docker.run_docker_command_async(ps_args, {}, function(ps_result)
+    if not ps_result.success then
+      callback(nil, vim.trim(ps_result.stderr or ''))
+      return
+    end
+    local image_args = {
+      'images',
+      '--filter',
+      'label=' .. docker.IMAGE_LABEL,
+      '--format',
+      '{{.ID}}\t{{.Repository}}:{{.Tag}}\t{{.Size}}\t{{.CreatedAt}}',
+    }
+    docker.run_docker_command_async(image_args, {}, function(image_result)
+      if not image_result.success then
+        callback(nil, vim.trim(image_result.stderr or ''))
+        return
+      end
+      callback({
+        containers = M.parse_containers(ps_result.stdout, state.current_container),
+        images = M.parse_images(image_result.stdout, { all = opts.all, max_age = opts.max_age or max_age() }),
+      })
+    end)
+  end)
+end
+
+-- Confirmation text listing what is going to be removed
+-- @param candidates table: from collect()
+-- @return string
+function M.describe(candidates)
+  local lines = {}
+  local total = 0
+  for _, container in ipairs(candidates.containers) do
+    table.insert(lines, string.format('  container %s (%s)', container.name, M.format_size(container.size)))
+    total = total + container.size
+  end
+  for _, image in ipairs(candidates.images) do
+    local age = image.dangling and 'untagged' or string.format('%d days old', image.age)
+    local name = image.dangling and image.id or image.name
+    table.insert(lines, string.format('  image %s (%s, %s)', name, M.format_size(image.size), age))
+    total = total + image.size
+  end
+  local listed = {}
+  for i = 1, math.min(#lines, M.MAX_LISTED) do
+    listed[i] = lines[i]
+  end
+  if #lines > M.MAX_LISTED then
+    table.insert(listed, string.format('  ... and %d more', #lines - M.MAX_LISTED))
+  end
+  return string.format(
+    'Remove %d container(s) and %d image(s), up to %s?\n%s',
+    #candidates.containers,
+    #candidates.images,
+    M.format_size(total),
+    table.concat(listed, '\n')
+  )
+end
+
+-- Remove the images one at a time; images in use are kept by docker and reported
+local function remove_images(images, index, removed, callback)
+  local image = images[index]
+  if not image then
+    callback(removed)
+    return
+  end
+  require('container.docker').run_docker_command_async({ 'rmi', image.id }, {}, function(result)
+    if result.success then
+      table.insert(removed, image)
+    else
+      log.info('Kept image %s: %s', image.id, vim.trim(result.stderr or ''))
+    end
+    remove_images(images, index + 1, removed, callback)
+  end)
+end
+
+-- Remove the collected containers, then the images (an image is freed once its containers are gone)
+-- @param candidates table: from collect()
+-- @param callback function({ containers, images, reclaimed }): what was removed and the bytes freed
+function M.remove(candidates, callback)
+  local docker = require('container.docker')
+  local function after_containers(removed_containers)
+    remove_images(candidates.images, 1, {}, function(removed_images)
+      local reclaimed = 0
+      for _, item in ipairs(removed_containers) do
+        reclaimed = reclaimed + item.size
+      end
+      for _, item in ipairs(removed_images) do
+        reclaimed = reclaimed + item.size
+      end
+      callback({ containers = removed_containers, images = removed_images, reclaimed = reclaimed })
+    end)
+  end
+
+  if #candidates.containers == 0 then
+    after_containers({})
+    return
+  end
+  local args = { 'rm' }
+  for _, container in ipairs(candidates.containers) do
+    table.insert(args, container.id)
+  end
+  docker.run_docker_command_async(args, {}, function(result)
+    -- docker rm goes on after a failure and prints the ids it removed
+    local gone = {}
+    for id in (result.stdout or ''):gmatch('%S+') do
+      gone[id] = true
+    end
+    local removed = {}
+    for _, container in ipairs(candidates.containers) do
+      if result.success or gone[container.id] or gone[container.name] then
+        table.insert(removed, container)
+      end
+    end
+    if not result.success then
+      log.warn('Some containers were not removed: %s', vim.trim(result.stderr or ''))
+    end
+    after_containers(removed)
+  end)
+end
+
+-- :ContainerPrune
+-- @param opts table|nil: { all = boolean, max_age = days }
+function M.prune(opts)
+  opts = opts or {}
+  M.collect(opts, function(candidates, err)
+    vim.schedule(function()
+      if not candidates then
+        notify.critical('Failed to list containers and images: ' .. (err or 'unknown error'))
+        return
+      end
+      if #candidates.containers == 0 and #candidates.images == 0 then
+        notify.container('Nothing to prune')
+        return
+      end
+      if vim.fn.confirm(M.describe(candidates), '&Yes\n&No', 2) ~= 1 then
+        return
+      end
+      M.remove(candidates, function(removed)
+        vim.schedule(function()
+          local message = string.format(
+            'Removed %d container(s) and %d image(s), reclaimed %s',
+            #removed.containers,
+            #removed.images,
+            M.format_size(removed.reclaimed)
+          )
+          local kept = #candidates.images - #removed.images
+          if kept > 0 then
+            message = message .. string.format(' (%d image(s) still in use were kept)', kept)
+          end
+          notify.success(message)
+        end)
+      end)
+    end)
+  end)
+end
+
+return M
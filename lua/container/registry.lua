@@ -0,0 +1,222 @@
+-- lua/container/registry.lua
+-- Registry authentication for image pulls
+-- A pull failing with an authentication error names the registry that needs credentials. The registry setting
+-- (server, username and password_env or password_command) logs in before pulling from that server; otherwise the
+-- user is asked for credentials. Passwords are passed to docker login on stdin, never on the command line.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Docker Hub is addressed under several names
+local DOCKER_HUB = 'docker.io'
+local docker_hub_aliases = {
+  ['docker.io'] = true,
+  ['index.docker.io'] = true,
+  ['registry-1.docker.io'] = true,
+}
+
+-- Output of a failed pull that means credentials are missing or rejected
+local auth_patterns = {
+  'unauthorized',
+  'authentication required',
+  'pull access denied',
+  'requested access to the resource is denied',
+  'no basic auth credentials',
+  'denied:',
+  '403 forbidden',
+}
+
+-- Servers logged into during this session
+local logged_in = {}
+
+local function normalize(server)
+  server = (server or DOCKER_HUB):gsub('^https?://', ''):gsub('/.*$', '')
+  return docker_hub_aliases[server] and DOCKER_HUB or server
+end
+
+-- Registry an image reference is pulled from
+-- The first path component is a registry when it contains "." or ":" or is "localhost" (as docker decides).
+-- @param image string
+-- @return string
+function M.get_registry(image)
+  local first, rest = (image or ''):match('^([^/]+)/(.+)$')
+  if first and rest and (first:find('[.:]') or first == 'localhost') then
+    return normalize(first)
+  end
+  return DOCKER_HUB
+end
+
+-- Check whether pull output reports an authentication failure
+-- @param output string|nil: stderr of docker pull
+-- @return boolean
+function M.is_auth_error(output)
+  local text = (output or ''):lower()
+  for _, pattern in ipairs(auth_patterns) do
+    if text:find(pattern, 1, true) then
+      return true
+    end
+  end
+  return false
+end
+
+local function get_settings()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  return ok and plugin_config and plugin_config.registry or {}
+end
+
+-- Credentials of the registry setting for a server
+-- @param server string
+-- @return table|nil: { server, username, password }, string|nil: why the configured credentials are unusable
+function M.get_credentials(server)
+  local settings = get_settings()
+  if not settings.server or normalize(settings.server) ~= normalize(server) or not settings.username then
+    return nil
+  end
+
+  local password
+  if settings.password_env then
+    password = os.getenv(settings.password_env)
+    if not password or password == '' then
+      return nil, string.format('$%s is not set', settings.password_env)
+    end
+  elseif settings.password_command then
+    local output = vim.fn.system(settings.password_command)
+    if vim.v.shell_error ~= 0 then
+      return nil, string.format('password_command failed with exit code %d', vim.v.shell_error)
+    end
+    password = vim.trim(output)
+  else
+    return nil
+  end
+  return { server = normalize(server), username = settings.username, password = password }
+end
+
+-- Run docker login with the password on stdin
+-- @param credentials table: { server, username, password }
+-- @param callback function(success, err)
+function M.login(credentials, callback)
+  local runtime = require('container.docker.runtime')
+  local stderr = {}
+  local cmd = { runtime.get(), 'login', credentials.server, '--username', credentials.username, '--password-stdin' }
+  log.info('Logging in to %s as %s', credentials.server, credentials.username)
+
+  local job_id = vim.fn.jobstart(cmd, {
+    stderr_buffered = true,
+    on_stderr = function(_, data)
+      for _, line in ipairs(data or {}) do
+        if line ~= '' then
+          table.insert(stderr, line)
+        end
+      end
+    end,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        if exit_code == 0 then
+          logged_in[credentials.server] = true
+          callback(true)
+        else
+          log.error('docker login %s failed: %s', credentials.server, table.concat(stderr, ' '))
+          callback(false, table.concat(stderr, '\n'))
+        end
+      end)
+    end,
+  })
+  if job_id <= 0 then
+    callback(false, 'Failed to start docker login')
+    return
+  end
+  vim.fn.chansend(job_id, credentials.password .. '\n')
+  vim.fn.chanclose(job_id, 'stdin')
+end
+
+-- Log in before pulling an image when the registry setting covers its server (once per session)
+-- @param image string
+-- @param callback function(): called when done; a failed login is reported and the pull goes ahead
+function M.ensure_login(image, callback)
+  local server = M.get_registry(image)
+  local credentials, err = M.get_credentials(server)
+  if logged_in[server] or (not credentials and not err) then
+    callback()
+    return
+  end
+
+  local notify = require('container.utils.notify')
+  if not credentials then
+    notify.status(string.format('Skipping login to %s: %s', server, err), 'warn')
+    callback()
+    return
+  end
+  notify.status('Logging in to ' .. server, 'info')
+  M.login(credentials, function(success, login_err)
+    if not success then
+      notify.status(string.format('Login to %s failed: %s', server, login_err or 'unknown error'), 'warn')
+    end
+    callback()
+  end)
+end
+
+-- Ask for credentials and log in
+local function prompt_login(server, callback)
+  vim.ui.select({ 'Log in to ' .. server, 'Cancel' }, {
+    prompt = string.format('Pulling from %s requires credentials', server),
+  }, function(_, index)
+    if index ~= 1 then
+      callback(false)
+      return
+    end
+    vim.ui.input({ prompt = string.format('Username for %s: ', server) }, function(username)
+      if not username or username == '' then
+        callback(false)
+        return
+      end
+      local password = vim.fn.inputsecret('Password: ')
+      if password == '' then
+        callback(false)
+        return
+      end
+      M.login({ server = server, username = username, password = password }, function(success, err)
+        if not success then
+          require('container.utils.notify').critical(
+            string.format('Login to %s failed: %s', server, err or 'unknown error')
+          )
+        end
+        callback(success)
+      end)
+    end)
+  end)
+end
+
+-- Handle a pull that failed for lack of credentials
+-- Logs in with the registry setting when it covers the server and was not used yet, otherwise asks the user.
+-- Without a UI the registry is reported with the docker login command to run.
+-- @param image string
+-- @param callback function(success): true once logged in, so the pull can be retried
+function M.handle_auth_failure(image, callback)
+  local notify = require('container.utils.notify')
+  local server = M.get_registry(image)
+  notify.critical(string.format('Pulling %s requires credentials for %s', image, server))
+
+  local credentials = M.get_credentials(server)
+  if credentials and not logged_in[server] then
+    M.login(credentials, function(success, err)
+      if not success then
+        notify.critical(string.format('Login to %s failed: %s', server, err or 'unknown error'))
+      end
+      callback(success)
+    end)
+    return
+  end
+
+  local has_ui, uis = pcall(vim.api.nvim_list_uis)
+  if not has_ui or #uis == 0 then
+    notify.status(string.format('Run "docker login %s" and start the container again', server), 'warn')
+    callback(false)
+    return
+  end
+  prompt_login(server, callback)
+end
+
+return M
@@ -0,0 +1,138 @@
+-- lua/container/run_file.lua
+-- Run the file of the current buffer in the container (:ContainerRunFile)
+-- run_file.commands maps a filetype to a shell command: Go runs the package with `go run .`, scripts run with their
+-- interpreter. The command runs in the container folder of the file with the output streamed to a buffer.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for results
+M.OUTPUT_NAME = 'run'
+
+-- Job streaming into the buffer
+local job_id = nil
+
+-- Command configured for a filetype
+-- @param filetype string
+-- @return string|nil: shell command with {file}, {dir} and {name} placeholders
+function M.get_command(filetype)
+  local commands = require('container.config').get_value('run_file.commands') or {}
+  local command = commands[filetype]
+  if type(command) ~= 'string' or vim.trim(command) == '' then
+    return nil
+  end
+  return command
+end
+
+-- Shell command running a file
+-- {file} is the container path of the file, {dir} its container folder and {name} its file name; the values are
+-- quoted, as are the extra arguments appended to the command.
+-- @param command string: from get_command()
+-- @param ctx table: { file, dir, name } in the container
+-- @param args table|nil: extra arguments
+-- @return string
+function M.build_command(command, ctx, args)
+  local quote = require('container.dry_run').shell_quote
+  local result = command:gsub('{(%a+)}', function(key)
+    if ctx[key] then
+      return quote(ctx[key])
+    end
+  end)
+  for _, arg in ipairs(args or {}) do
+    result = result .. ' ' .. quote(arg)
+  end
+  return result
+end
+
+-- Stop the running file
+function M.stop()
+  if job_id then
+    pcall(vim.fn.jobstop, job_id)
+    job_id = nil
+  end
+end
+
+-- Run a file in the container and stream the output into the output buffer
+-- The command runs as remoteUser with containerEnv/remoteEnv, like exec(). A run replaces the previous one.
+-- @param opts table|nil: { file, filetype, args } (default: the current buffer)
+-- @return boolean: true when the command was started
+function M.run(opts)
+  opts = opts or {}
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local file = opts.file or vim.fn.expand('%:p')
+  if file == '' then
+    notify.warn('Nothing to run: the buffer has no file')
+    return false
+  end
+  local filetype = opts.filetype or vim.bo.filetype
+  local command = M.get_command(filetype)
+  if not command then
+    notify.error(string.format('No command to run %s files; set run_file.commands.%s', filetype, filetype))
+    return false
+  end
+
+  local fs = require('container.utils.fs')
+  local test = require('container.test')
+  local host_root, container_root = require('container.parser').workspace_roots(container.get_config())
+  local container_file = test.map_path(file, host_root, container_root)
+  if not container_file then
+    notify.error(string.format('%s is outside the workspace mounted in the container', file))
+    return false
+  end
+  local ctx = { file = container_file, dir = fs.dirname(container_file), name = fs.basename(container_file) }
+  local script = M.build_command(command, ctx, opts.args)
+
+  M.stop()
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, container._build_exec_args(container_id, script, { cwd = ctx.dir }))
+  log.info('Running %s in container: %s (cwd: %s)', file, script, ctx.dir)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.append(M.OUTPUT_NAME, { '$ ' .. script .. '  (in ' .. ctx.dir .. ')', '' })
+  output.open(M.OUTPUT_NAME)
+
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      output.append(M.OUTPUT_NAME, lines)
+    end)
+  end)
+
+  local id
+  id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        -- A replaced run is not reported
+        if job_id ~= id then
+          return
+        end
+        job_id = nil
+        local rest = remaining()
+        if #rest > 0 then
+          output.append(M.OUTPUT_NAME, rest)
+        end
+        output.append(M.OUTPUT_NAME, { '', string.format('<== exited with code %d', exit_code) })
+      end)
+    end,
+  })
+
+  if id <= 0 then
+    notify.error('Failed to start docker exec')
+    return false
+  end
+  job_id = id
+  return true
+end
+
+return M
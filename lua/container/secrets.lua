@@ -0,0 +1,174 @@
+-- lua/container/secrets.lua
+-- Secrets passed to the container without writing them to disk (the secrets plugin setting)
+-- Each secret is read from a host environment variable or returned by a function the first time it is needed and
+-- kept in memory of this module: exec sessions (terminals, lifecycle commands, tests) get `-e NAME` and image builds
+-- `--secret id=NAME,env=NAME`, and only the docker job running such a command gets the values, in its environment
+-- (job_env()). The environment of Neovim and of its other child processes is left alone. Values never appear on
+-- command lines, in the dry-run output, in :ContainerConfig or in the plugin log (which masks them as well).
+-- :ContainerStop clears them.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Values of the loaded secrets by name, masked in the plugin log
+local values = {}
+-- Secrets whose missing value was reported
+local warned = {}
+
+-- Secrets of the plugin configuration in name order
+-- A secret is given as the name of a host environment variable, a function returning the value, or a table
+-- { env = 'HOST_VAR', value = function, build = boolean }.
+-- @return table: list of { name, env, value, build }
+function M.specs()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local secrets = ok and plugin_config.get_value and plugin_config.get_value('secrets') or {}
+  local specs = {}
+  for name, spec in pairs(secrets) do
+    if type(spec) == 'string' then
+      spec = { env = spec }
+    elseif type(spec) == 'function' then
+      spec = { value = spec }
+    end
+    table.insert(specs, { name = name, env = spec.env, value = spec.value, build = spec.build == true })
+  end
+  table.sort(specs, function(a, b)
+    return a.name < b.name
+  end)
+  return specs
+end
+
+-- Read the value of a secret
+-- @param spec table: from specs()
+-- @return string|nil, string|nil: value, or nil and the reason
+function M.resolve(spec)
+  local value
+  if spec.value then
+    local ok, result = pcall(spec.value, spec.name)
+    if not ok then
+      return nil, 'function failed: ' .. tostring(result)
+    end
+    value = result
+  else
+    value = os.getenv(spec.env or spec.name)
+    if value == nil then
+      return nil, string.format('$%s is not set', spec.env or spec.name)
+    end
+  end
+  if type(value) ~= 'string' or value == '' then
+    return nil, 'no value'
+  end
+  return value
+end
+
+-- Values to mask in the plugin log
+local function masked_values()
+  local list = {}
+  for _, value in pairs(values) do
+    table.insert(list, value)
+  end
+  return list
+end
+
+-- Load the secrets not loaded yet
+-- Missing secrets are reported once and skipped.
+-- @return table: names of the loaded secrets
+function M.load()
+  local names = {}
+  local masked = false
+  for _, spec in ipairs(M.specs()) do
+    if not values[spec.name] then
+      local value, err = M.resolve(spec)
+      if value then
+        values[spec.name] = value
+        masked = true
+        log.debug('Loaded secret %s', spec.name)
+      elseif not warned[spec.name] then
+        warned[spec.name] = true
+        log.warn('Secret %s is not passed to the container: %s', spec.name, err)
+        require('container.utils.notify').warn(string.format('Secret %s is not available: %s', spec.name, err))
+      end
+    end
+    if values[spec.name] then
+      table.insert(names, spec.name)
+    end
+  end
+  if masked and log.set_masked_values then
+    log.set_masked_values(masked_values())
+  end
+  return names
+end
+
+-- docker exec arguments passing the secrets (names only; docker takes the values from the job_env() of its job)
+-- @return table
+function M.exec_args()
+  local args = {}
+  for _, name in ipairs(M.load()) do
+    vim.list_extend(args, { '-e', name })
+  end
+  return args
+end
+
+-- docker build arguments passing the secrets marked with build = true
+-- A Dockerfile reads them with RUN --mount=type=secret,id=NAME (BuildKit).
+-- @return table
+function M.build_args()
+  local build = {}
+  for _, spec in ipairs(M.specs()) do
+    build[spec.name] = spec.build
+  end
+  local args = {}
+  for _, name in ipairs(M.load()) do
+    if build[name] then
+      vim.list_extend(args, { '--secret', string.format('id=%s,env=%s', name, name) })
+    end
+  end
+  return args
+end
+
+-- Descriptions of the configured secrets without their values, e.g. "GOPRIVATE_TOKEN (from $GITHUB_TOKEN, build)"
+-- @return table
+function M.describe()
+  local lines = {}
+  for _, spec in ipairs(M.specs()) do
+    local source = spec.value and 'from a function' or string.format('from $%s', spec.env or spec.name)
+    table.insert(lines, string.format('%s (%s%s)', spec.name, source, spec.build and ', build' or ''))
+  end
+  return lines
+end
+
+-- Environment for the job running a docker command with secret arguments
+-- Only the secrets the command names with `-e NAME` or `--secret id=NAME,env=NAME` are included.
+-- @param cmd table: command line (shell-escaped names, as in terminal commands, are recognized too)
+-- @return table|nil: { NAME = value }, nil when the command passes no loaded secret
+function M.job_env(cmd)
+  local env = nil
+  for i, arg in ipairs(cmd or {}) do
+    local name = nil
+    if arg == '-e' or arg == '--env' then
+      name = cmd[i + 1]
+    elseif arg == '--secret' then
+      name = (cmd[i + 1] or ''):match('env=([^,]+)')
+    end
+    name = name and (name:match("^'(.*)'$") or name)
+    if name and values[name] then
+      env = env or {}
+      env[name] = values[name]
+    end
+  end
+  return env
+end
+
+-- Forget the loaded values
+function M.clear()
+  if next(values) then
+    log.debug('Cleared secrets from memory')
+  end
+  values = {}
+  warned = {}
+  if log.set_masked_values then
+    log.set_masked_values({})
+  end
+end
+
+return M
@@ -0,0 +1,306 @@
+-- lua/container/stats.lua
+-- Resource usage of the container in a floating window (:ContainerStats)
+-- `docker stats --no-stream` is polled every ui.stats_interval milliseconds for the attached container, or every
+-- container of the Compose project, and shown next to the memory and CPU limits the containers were created with.
+-- Containers using most of their memory limit are marked, as they are close to being OOM-killed.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+local BUFFER_NAME = 'stats'
+
+-- Share of the memory limit above which a container is marked
+M.MEMORY_WARNING = 0.9
+
+-- Open window: { win, timer, ids, limits, rows, pending, error }
+local view = nil
+
+local inspect_format = table.concat({
+  '{{.Id}}',
+  '{{.Name}}',
+  '{{index .Config.Labels "com.docker.compose.service"}}',
+  '{{.HostConfig.Memory}}',
+  '{{.HostConfig.NanoCpus}}',
+  '{{.HostConfig.CpuQuota}}',
+  '{{.HostConfig.CpuPeriod}}',
+  '{{.State.OOMKilled}}',
+}, '|')
+
+-- Parse `docker inspect` output in inspect_format
+-- @param stdout string
+-- @return table: limits by short container id { name, memory (bytes), cpus, oom_killed }; nil memory/cpus: no limit
+function M.parse_limits(stdout)
+  local limits = {}
+  for line in (stdout or ''):gmatch('[^\r\n]+') do
+    local id, name, service, memory, nano_cpus, quota, period, oom_killed =
+      line:match('^([^|]*)|([^|]*)|([^|]*)|([^|]*)|([^|]*)|([^|]*)|([^|]*)|([^|]*)$')
+    if id then
+      memory, nano_cpus, quota, period = tonumber(memory), tonumber(nano_cpus), tonumber(quota), tonumber(period)
+      local cpus = nil
+      if nano_cpus and nano_cpus > 0 then
+        cpus = nano_cpus / 1e9
+      elseif quota and quota > 0 and period and period > 0 then
+        cpus = quota / period
+      end
+      -- Containers outside Compose have no service label ("<no value>")
+      if service == '' or service == '<no value>' then
+        service = name:gsub('^/', '')
+      end
+      limits[id:sub(1, 12)] = {
+        name = service,
+        memory = memory and memory > 0 and memory or nil,
+        cpus = cpus,
+        oom_killed = oom_killed == 'true',
+      }
+    end
+  end
+  return limits
+end
+
+-- Parse `docker stats --no-stream --format '{{json .}}'` output
+-- @param stdout string
+-- @return table: rows { id, name, cpu, memory, memory_percent, net, block, pids }
+function M.parse_stats(stdout)
+  local rows = {}
+  for line in (stdout or ''):gmatch('[^\r\n]+') do
+    local ok, entry = pcall(vim.json.decode, line)
+    if ok and type(entry) == 'table' then
+      table.insert(rows, {
+        id = (entry.ID or entry.Container or ''):sub(1, 12),
+        name = entry.Name,
+        cpu = entry.CPUPerc,
+        -- "12.5MiB / 7.6GiB": the right side is the host memory unless a limit is set
+        memory = (entry.MemUsage or ''):match('^%s*(.-)%s*/') or entry.MemUsage,
+        memory_percent = tonumber((entry.MemPerc or ''):match('([%d%.]+)')),
+        net = entry.NetIO,
+        block = entry.BlockIO,
+        pids = entry.PIDs,
+      })
+    end
+  end
+  return rows
+end
+
+local columns = { 'CONTAINER', 'CPU %', 'CPU LIMIT', 'MEMORY', 'MEM LIMIT', 'MEM %', 'NET I/O', 'BLOCK I/O', 'PIDS' }
+
+-- Lines of the window: a table of the rows with their limits
+-- @param rows table: from parse_stats()
+-- @param limits table: from parse_limits()
+-- @return table: lines
+function M.render_lines(rows, limits)
+  local format_size = require('container.build_context').format_size
+  local cells = { columns }
+  local notes = {}
+  for _, row in ipairs(rows) do
+    local limit = limits[row.id] or {}
+    local marker = ''
+    -- Without a memory limit docker stats reports the share of the host memory
+    if limit.memory and row.memory_percent and row.memory_percent >= M.MEMORY_WARNING * 100 then
+      marker = ' !'
+      table.insert(notes, string.format('! %s is close to its memory limit', limit.name or row.name))
+    end
+    if limit.oom_killed then
+      table.insert(notes, string.format('! %s was OOM-killed', limit.name or row.name))
+    end
+    table.insert(cells, {
+      limit.name or row.name or row.id,
+      row.cpu or '-',
+      limit.cpus and string.format('%g', limit.cpus) or 'none',
+      row.memory or '-',
+      limit.memory and format_size(limit.memory) or 'none',
+      (row.memory_percent and string.format('%.1f%%', row.memory_percent) or '-') .. marker,
+      row.net or '-',
+      row.block or '-',
+      row.pids or '-',
+    })
+  end
+
+  local widths = {}
+  for _, line in ipairs(cells) do
+    for i, cell in ipairs(line) do
+      widths[i] = math.max(widths[i] or 0, vim.fn.strdisplaywidth(cell))
+    end
+  end
+  local lines = {}
+  for _, line in ipairs(cells) do
+    local padded = {}
+    for i, cell in ipairs(line) do
+      table.insert(padded, cell .. string.rep(' ', widths[i] - vim.fn.strdisplaywidth(cell)))
+    end
+    table.insert(lines, (table.concat(padded, '  '):gsub('%s+$', '')))
+  end
+  if #notes > 0 then
+    table.insert(lines, '')
+    vim.list_extend(lines, notes)
+  end
+  return lines
+end
+
+local function window_valid()
+  return view ~= nil and view.win ~= nil and vim.api.nvim_win_is_valid(view.win)
+end
+
+local function interval()
+  local ok, plugin_config = pcall(require, 'container.config')
+  return ok and plugin_config.get_value('ui.stats_interval') or 2000
+end
+
+local function render()
+  if not view then
+    return
+  end
+  local lines
+  if view.error then
+    lines = { 'docker stats failed: ' .. view.error }
+  elseif not view.rows then
+    lines = { 'Collecting stats...' }
+  else
+    lines = M.render_lines(view.rows, view.limits or {})
+  end
+  require('container.ui.output').set_lines(BUFFER_NAME, lines)
+  if window_valid() then
+    local width = 0
+    for _, line in ipairs(lines) do
+      width = math.max(width, vim.fn.strdisplaywidth(line))
+    end
+    vim.api.nvim_win_set_config(view.win, {
+      relative = 'editor',
+      width = math.min(math.max(width, 40), vim.o.columns - 4),
+      height = math.min(#lines, vim.o.lines - 4),
+      row = 1,
+      col = math.max(vim.o.columns - width - 4, 0),
+    })
+  end
+end
+
+-- Poll docker stats once; a poll still running is not overlapped
+local function refresh()
+  if not view or view.pending then
+    return
+  end
+  view.pending = true
+  local polled = view
+  local docker = require('container.docker')
+  local args = { 'stats', '--no-stream', '--format', '{{json .}}' }
+  vim.list_extend(args, view.ids)
+  docker.run_docker_command_async(args, {}, function(result)
+    if view ~= polled then
+      return
+    end
+    view.pending = false
+    if result.success then
+      view.rows = M.parse_stats(result.stdout)
+      view.error = nil
+    else
+      view.error = vim.trim(result.stderr or '')
+    end
+    render()
+  end)
+end
+
+-- Ids of the containers to watch: every container of the Compose project, else the attached container
+-- @param callback function(ids)
+function M.get_container_ids(callback)
+  local container = require('container')
+  local state = container.get_state()
+  local config = state.current_config
+  local compose = require('container.docker.compose')
+  if not compose.is_compose_config(config) then
+    callback({ state.current_container })
+    return
+  end
+  local args = compose.build_base_args(config, false)
+  vim.list_extend(args, { 'ps', '-q' })
+  require('container.docker').run_docker_command_async(args, { cwd = config.compose_project_dir }, function(result)
+    local ids = {}
+    for id in (result.success and result.stdout or ''):gmatch('%S+') do
+      table.insert(ids, id)
+    end
+    callback(#ids > 0 and ids or { state.current_container })
+  end)
+end
+
+-- Close the window and stop polling
+function M.close()
+  if not view then
+    return
+  end
+  if view.timer then
+    view.timer:stop()
+    view.timer:close()
+  end
+  if window_valid() then
+    pcall(vim.api.nvim_win_close, view.win, true)
+  end
+  view = nil
+end
+
+-- Show the stats window, polling until it is closed
+-- @return boolean: true when the window was opened
+function M.open()
+  local state = require('container').get_state()
+  if not state.current_container then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+  if window_valid() then
+    vim.api.nvim_set_current_win(view.win)
+    return true
+  end
+  M.close()
+
+  local buf = require('container.ui.output').get_buffer(BUFFER_NAME)
+  view = {}
+  view.win = vim.api.nvim_open_win(buf, true, {
+    relative = 'editor',
+    width = 40,
+    height = 1,
+    row = 1,
+    col = math.max(vim.o.columns - 44, 0),
+    style = 'minimal',
+    border = 'rounded',
+  })
+  vim.wo[view.win].wrap = false
+  if vim.fn.has('nvim-0.9') == 1 then
+    vim.api.nvim_win_set_config(view.win, { title = ' Container stats ', title_pos = 'center' })
+  end
+  for _, key in ipairs({ 'q', '<Esc>' }) do
+    vim.keymap.set('n', key, M.close, { buffer = buf, nowait = true, desc = 'Close stats window' })
+  end
+  -- Polling stops however the window is closed
+  local opened = view
+  vim.api.nvim_create_autocmd('WinClosed', {
+    pattern = tostring(view.win),
+    once = true,
+    callback = function()
+      if view == opened then
+        M.close()
+      end
+    end,
+  })
+  render()
+
+  M.get_container_ids(function(ids)
+    if view ~= opened then
+      return
+    end
+    view.ids = ids
+    log.debug('Watching stats of %s', table.concat(ids, ', '))
+    local inspect_args = { 'inspect', '--format', inspect_format }
+    vim.list_extend(inspect_args, ids)
+    require('container.docker').run_docker_command_async(inspect_args, {}, function(result)
+      if view ~= opened then
+        return
+      end
+      view.limits = M.parse_limits(result.success and result.stdout or '')
+      refresh()
+      view.timer = (vim.uv or vim.loop).new_timer()
+      view.timer:start(interval(), interval(), vim.schedule_wrap(refresh))
+    end)
+  end)
+  return true
+end
+
+return M
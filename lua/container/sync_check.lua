@@ -0,0 +1,193 @@
+-- lua/container/sync_check.lua
+-- Workspace mount health (:ContainerSyncCheck)
+-- A sentinel file is written in the host workspace and the container waits for it to appear, which measures how long
+-- file changes take to cross the bind mount. Slow file sharing (Docker Desktop's gRPC FUSE or osxfs, for instance)
+-- lets tests run against stale files; the check reports the latency together with the type of the mount.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Prefix of the sentinel files written in the workspace
+M.SENTINEL_PREFIX = '.container-nvim-sync-'
+
+-- Interval of the wait loop in the container (milliseconds)
+local POLL_INTERVAL = 20
+
+-- File systems of file sharing implementations, named for reports
+local MOUNT_TYPES = {
+  virtiofs = 'VirtioFS',
+  fakeowner = 'VirtioFS (Docker Desktop)',
+  ['fuse.grpcfuse'] = 'gRPC FUSE (Docker Desktop)',
+  ['fuse.osxfs'] = 'osxfs (Docker Desktop)',
+  ['9p'] = '9p',
+  ['fuse.sshfs'] = 'SSHFS',
+}
+
+-- Settings of the check
+local function settings()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local value = ok and plugin_config.get_value and plugin_config.get_value('sync_check') or {}
+  return { timeout = value.timeout or 5000, warn_latency = value.warn_latency or 500 }
+end
+
+-- Decode the octal escapes of /proc/mounts (spaces are written as \040)
+local function unescape(path)
+  return (path:gsub('\\(%d%d%d)', function(octal)
+    return string.char(tonumber(octal, 8))
+  end))
+end
+
+-- Find the mount holding a path in /proc/mounts
+-- @param text string: contents of /proc/mounts in the container
+-- @param path string: container path
+-- @return table|nil: { source, target, type } of the deepest mount point containing the path
+function M.parse_mounts(text, path)
+  local best
+  for line in (text or ''):gmatch('[^\n]+') do
+    local source, target, fstype = line:match('^(%S+)%s+(%S+)%s+(%S+)')
+    if target then
+      target = unescape(target)
+      local prefix = target == '/' and '/' or target .. '/'
+      local contains = path == target or path:sub(1, #prefix) == prefix
+      if contains and (not best or #target >= #best.target) then
+        best = { source = unescape(source), target = target, type = fstype }
+      end
+    end
+  end
+  return best
+end
+
+-- Readable name of a mount type
+-- @param mount table|nil: from parse_mounts()
+-- @return string
+function M.describe_mount(mount)
+  if not mount then
+    return 'unknown'
+  end
+  local name = MOUNT_TYPES[mount.type]
+  return name and string.format('%s, %s', mount.type, name) or mount.type
+end
+
+-- Suggestion for a slow mount
+-- @param mount table|nil: from parse_mounts()
+-- @return string
+function M.hint(mount)
+  local fstype = mount and mount.type or ''
+  if fstype == 'fuse.grpcfuse' or fstype == 'fuse.osxfs' then
+    return 'Switch Docker Desktop to VirtioFS file sharing (Settings > General), '
+      .. 'or keep heavy directories in a named volume'
+  end
+  return 'Keep heavy directories (dependencies, build output) in a named volume, '
+    .. 'e.g. "mounts": ["source=node_modules,target=${containerWorkspaceFolder}/node_modules,type=volume"]'
+end
+
+-- Command waiting in the container until a file exists
+-- @param path string: container path
+-- @param timeout number: milliseconds
+-- @return table: docker exec arguments after the container ID
+function M.wait_command(path, timeout)
+  local attempts = math.max(1, math.ceil(timeout / POLL_INTERVAL))
+  local script = string.format(
+    'i=0; while [ ! -e "$1" ]; do i=$((i+1)); [ "$i" -gt %d ] && exit 1; sleep %.2f; done',
+    attempts,
+    POLL_INTERVAL / 1000
+  )
+  return { 'sh', '-c', script, 'sh', path }
+end
+
+-- Mount of the workspace in the running container
+-- @param container_id string
+-- @param container_path string: workspace folder in the container
+-- @param callback function(mount|nil, elapsed): mount from parse_mounts() and the milliseconds the exec took
+function M.detect_mount(container_id, container_path, callback)
+  local uv = vim.uv or vim.loop
+  local started = uv.hrtime()
+  local args = { 'exec', container_id, 'cat', '/proc/mounts' }
+  require('container.docker').run_docker_command_async(args, {}, function(result)
+    local elapsed = (uv.hrtime() - started) / 1e6
+    if not result.success then
+      log.warn('Sync check: cannot read /proc/mounts: %s', vim.trim(result.stderr or ''))
+      callback(nil, elapsed)
+      return
+    end
+    callback(M.parse_mounts(result.stdout, container_path), elapsed)
+  end)
+end
+
+-- Measure how long a file written in the host workspace takes to appear in the container
+-- The time of an exec (measured while reading the mounts) is subtracted from the wait.
+-- @param callback function(result|nil, err): { latency (ms, nil on timeout), timeout, mount, host_path }
+function M.check(callback)
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    callback(nil, 'No active container')
+    return
+  end
+  local host_root, container_root = require('container.parser').workspace_roots(container.get_state().current_config)
+  local timeout = settings().timeout
+  local name = string.format('%s%d-%d', M.SENTINEL_PREFIX, os.time(), math.random(100000, 999999))
+  local host_path = host_root:gsub('/+$', '') .. '/' .. name
+  local container_path = container_root:gsub('/+$', '') .. '/' .. name
+
+  M.detect_mount(container_id, container_root, function(mount, exec_time)
+    vim.schedule(function()
+      local file = io.open(host_path, 'w')
+      if not file then
+        callback(nil, 'Cannot write ' .. host_path)
+        return
+      end
+      file:write('container.nvim sync check\n')
+      file:close()
+
+      local uv = vim.uv or vim.loop
+      local started = uv.hrtime()
+      local args = { 'exec', container_id }
+      vim.list_extend(args, M.wait_command(container_path, timeout))
+      require('container.docker').run_docker_command_async(args, {}, function(result)
+        local elapsed = (uv.hrtime() - started) / 1e6
+        os.remove(host_path)
+        callback({
+          latency = result.success and math.max(0, math.floor(elapsed - exec_time)) or nil,
+          timeout = timeout,
+          mount = mount,
+          host_path = host_path,
+        })
+      end)
+    end)
+  end)
+end
+
+-- :ContainerSyncCheck
+function M.run()
+  notify.status('Checking workspace mount...')
+  M.check(function(result, err)
+    vim.schedule(function()
+      if not result then
+        notify.critical('Sync check failed: ' .. (err or 'unknown error'))
+        return
+      end
+      local mount = M.describe_mount(result.mount)
+      if not result.latency then
+        notify.critical(
+          string.format('Host changes did not reach the container within %d ms (mount: %s)', result.timeout, mount)
+            .. '\n'
+            .. M.hint(result.mount)
+        )
+      elseif result.latency > settings().warn_latency then
+        notify.warn(
+          string.format('Workspace mount is slow: changes appeared after ~%d ms (mount: %s)', result.latency, mount)
+            .. '\n'
+            .. M.hint(result.mount)
+        )
+      else
+        local message = 'Workspace mount in sync: changes appeared after ~%d ms (mount: %s)'
+        notify.success(string.format(message, result.latency, mount))
+      end
+    end)
+  end)
+end
+
+return M
@@ -0,0 +1,248 @@
+-- lua/container/task.lua
+-- Named tasks run in the container (:ContainerTask)
+-- `tasks` in the plugin config maps a name to a shell command, or to a table:
+--
+--   {
+--     cmd = 'golangci-lint run',
+--     cwd = 'services/api',   -- container directory, relative to the workspace folder; variables are expanded
+--     env = { GOFLAGS = '-mod=mod' },
+--     parser = 'go',          -- name of a parser in M.parsers, or { locations = { ... } } (see test_runners)
+--   }
+--
+-- A task runs with `sh -c` as the remoteUser with containerEnv and remoteEnv, its output streams into a buffer and
+-- the locations found by its parser are loaded into the quickfix list. A new run stops and replaces the previous one.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for task output
+M.OUTPUT_NAME = 'task'
+
+-- Output parsers by name: patterns for container.test_runners.pattern_parser
+M.parsers = {
+  -- go build, go vet, golangci-lint, staticcheck
+  go = {
+    locations = { '^%s*([^%s:]+%.go):(%d+):(%d+):%s*(.*)$', '^%s*([^%s:]+%.go):(%d+):%s*(.*)$' },
+  },
+  -- gcc, clang, make, and other tools printing file:line[:col]: message
+  gcc = {
+    locations = { '^([^%s:]+):(%d+):(%d+):%s*(.*)$', '^([^%s:]+):(%d+):%s*(.*)$' },
+  },
+  -- tsc --pretty false
+  tsc = {
+    locations = { '^([^%s(]+)%((%d+),(%d+)%):%s*(.*)$' },
+  },
+  -- Python tracebacks, flake8, ruff, mypy
+  python = {
+    locations = {
+      '^%s*File "([^"]+)", line (%d+)',
+      '^([^%s:]+%.py):(%d+):(%d+):%s*(.*)$',
+      '^([^%s:]+%.py):(%d+):%s*(.*)$',
+    },
+  },
+}
+
+-- Job streaming into the buffer
+local job_id = nil
+
+-- Configured tasks
+-- @return table: name -> spec
+local function configured()
+  local tasks = require('container.config').get_value('tasks')
+  return type(tasks) == 'table' and tasks or {}
+end
+
+-- Names of the configured tasks, sorted
+-- @return table
+function M.names()
+  local names = vim.tbl_keys(configured())
+  table.sort(names)
+  return names
+end
+
+-- Task spec in table form
+-- @param name string
+-- @return table|nil: { cmd, cwd, env, parser }
+-- @return string|nil: error message
+function M.get(name)
+  local spec = configured()[name]
+  if type(spec) == 'string' then
+    spec = { cmd = spec }
+  end
+  if type(spec) ~= 'table' then
+    return nil, 'Unknown task: ' .. tostring(name)
+  end
+  if type(spec.cmd) ~= 'string' or vim.trim(spec.cmd) == '' then
+    return nil, string.format('Task %s has no command (tasks.%s.cmd)', name, name)
+  end
+  return spec
+end
+
+-- Shell command of a task with extra arguments appended, quoted
+-- @param spec table
+-- @param args table|nil
+-- @return string
+function M.build_command(spec, args)
+  local quote = require('container.dry_run').shell_quote
+  local command = spec.cmd
+  for _, arg in ipairs(args or {}) do
+    command = command .. ' ' .. quote(arg)
+  end
+  return command
+end
+
+-- Patterns of a task's parser
+-- @param parser string|table|nil: parser name or patterns
+-- @return table|nil: patterns, nil without a parser
+-- @return string|nil: error message for an unknown parser name
+function M.resolve_parser(parser)
+  if parser == nil or type(parser) == 'table' then
+    return parser
+  end
+  if not M.parsers[parser] then
+    return nil, 'Unknown task parser: ' .. tostring(parser)
+  end
+  return M.parsers[parser]
+end
+
+-- Container working directory of a task
+-- @param spec table
+-- @param container_config table: normalized configuration
+-- @return string
+function M.resolve_cwd(spec, container_config)
+  local _, container_root = require('container.parser').workspace_roots(container_config)
+  local cwd = require('container.environment').expand_path(spec.cwd, container_config)
+  if not cwd or cwd == '' then
+    return container_config.workspace_folder or container_root
+  end
+  if cwd:sub(1, 1) ~= '/' then
+    cwd = container_root:gsub('/+$', '') .. '/' .. cwd:gsub('^%./', '')
+  end
+  return cwd
+end
+
+-- Stop the running task
+function M.stop()
+  if job_id then
+    pcall(vim.fn.jobstop, job_id)
+    job_id = nil
+  end
+end
+
+-- Run a task in the container
+-- Without a name the task is picked from a list.
+-- @param name string|nil
+-- @param args table|nil: extra arguments appended to the command
+-- @return boolean: true when the task was started (or the picker opened)
+function M.run(name, args)
+  local container = require('container')
+  local container_id = container.get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  if not name or name == '' then
+    local names = M.names()
+    if #names == 0 then
+      notify.warn('No tasks configured; add them to the tasks setting')
+      return false
+    end
+    vim.ui.select(names, { prompt = 'Run task:' }, function(choice)
+      if choice then
+        M.run(choice, args)
+      end
+    end)
+    return true
+  end
+
+  local spec, err = M.get(name)
+  if not spec then
+    notify.error(err)
+    return false
+  end
+  local patterns, parser_err = M.resolve_parser(spec.parser)
+  if parser_err then
+    notify.error(parser_err)
+    return false
+  end
+
+  local container_config = container.get_state().current_config or {}
+  local cwd = M.resolve_cwd(spec, container_config)
+  local host_root, container_root = require('container.parser').workspace_roots(container_config)
+  local ctx = {
+    host_root = host_root,
+    container_root = container_root,
+    host_dir = require('container.test').map_path(cwd, container_root, host_root) or host_root,
+  }
+  local parser = patterns and require('container.test_runners').pattern_parser(ctx, patterns)
+  local command = M.build_command(spec, args)
+
+  M.stop()
+  local cmd = { require('container.docker.runtime').get() }
+  vim.list_extend(cmd, container._build_exec_args(container_id, command, { cwd = cwd, env = spec.env }))
+  log.info('Running task %s in container: %s (cwd: %s)', name, command, cwd)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.append(M.OUTPUT_NAME, { '$ ' .. command .. '  (in ' .. cwd .. ')', '' })
+  output.open(M.OUTPUT_NAME)
+
+  local function add_lines(lines)
+    if parser then
+      for _, line in ipairs(lines) do
+        parser.feed(line)
+      end
+    end
+    output.append(M.OUTPUT_NAME, lines)
+  end
+
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    vim.schedule(function()
+      add_lines(lines)
+    end)
+  end)
+
+  local id
+  id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        -- A replaced run is not reported
+        if job_id ~= id then
+          return
+        end
+        job_id = nil
+        local rest = remaining()
+        if #rest > 0 then
+          add_lines(rest)
+        end
+        output.append(M.OUTPUT_NAME, { '', string.format('<== task %s exited with code %d', name, exit_code) })
+        if parser then
+          vim.fn.setqflist({}, ' ', { title = 'ContainerTask ' .. name, items = parser.items })
+          if #parser.items > 0 then
+            vim.cmd('copen')
+          end
+        end
+        if exit_code == 0 then
+          notify.status(string.format('Task %s finished', name))
+        else
+          notify.error(string.format('Task %s failed with exit code %d', name, exit_code))
+        end
+      end)
+    end,
+  })
+
+  if id <= 0 then
+    notify.error('Failed to start docker exec')
+    return false
+  end
+  job_id = id
+  return true
+end
+
+return M
@@ -14,14 +14,10 @@ function M.create_terminal_buffer(session, position, opts)
   -- Determine positioning
   position = position or config.default_position or 'split'
 
-  if position == 'split' then
-    buf_id, win_id = M._create_split_terminal(session, opts)
-  elseif position == 'tab' then
-    buf_id, win_id = M._create_tab_terminal(session, opts)
-  elseif position == 'float' then
-    buf_id, win_id = M._create_float_terminal(session, opts)
-  else
-    return nil, nil, string.format('Unknown position: %s', position)
+  local err
+  buf_id, win_id, err = M._open_positioned(session, position, opts)
+  if err then
+    return nil, nil, err
   end
 
   if not buf_id then
@@ -38,15 +34,34 @@ function M.create_terminal_buffer(session, position, opts)
   return buf_id, win_id, nil
 end
 
+-- Open a window at the given position, showing opts.buf_id or a new scratch buffer
+-- @return buf_id, win_id, err
+function M._open_positioned(session, position, opts)
+  if position == 'split' then
+    return M._create_split_terminal(session, opts)
+  elseif position == 'vsplit' then
+    return M._create_split_terminal(session, opts, true)
+  elseif position == 'tab' then
+    return M._create_tab_terminal(session, opts)
+  elseif position == 'float' then
+    return M._create_float_terminal(session, opts)
+  end
+  return nil, nil, string.format('Unknown position: %s', position)
+end
+
 -- Create split terminal (handles both horizontal and vertical)
-function M._create_split_terminal(session, opts)
+function M._create_split_terminal(session, opts, vertical)
   local config = session.config
+  opts = opts or {}
 
   -- Create buffer first
-  local buf_id = vim.api.nvim_create_buf(false, true)
+  local buf_id = opts.buf_id or vim.api.nvim_create_buf(false, true)
 
   -- Use split_command directly or fallback to default
   local cmd = config.split_command or 'belowright 15'
+  if vertical and not cmd:match('vertical') then
+    cmd = 'vertical ' .. cmd
+  end
 
   -- If split_command doesn't include 'new', add it
   if not cmd:match('new$') then
@@ -70,15 +85,16 @@ function M._create_tab_terminal(session, opts)
   local win_id = vim.api.nvim_get_current_win()
 
   -- Create a new buffer for the terminal (unlisted, scratch buffer)
-  local buf_id = vim.api.nvim_create_buf(false, true)
+  local buf_id = opts and opts.buf_id or vim.api.nvim_create_buf(false, true)
   vim.api.nvim_win_set_buf(win_id, buf_id)
 
   return buf_id, win_id
 end
 
--- Create floating terminal
-function M._create_float_terminal(session, opts)
+-- Window configuration of a floating terminal, sized relative to the current editor size
+function M._float_win_config(session, opts)
   local config = session.config.float or {}
+  opts = opts or {}
 
   -- Get editor dimensions
   local editor_width = vim.o.columns
@@ -99,11 +115,7 @@ function M._create_float_terminal(session, opts)
   local col = math.floor((editor_width - width) / 2)
   local row = math.floor((editor_height - height) / 2)
 
-  -- Create buffer
-  local buf_id = vim.api.nvim_create_buf(false, true)
-
-  -- Window configuration
-  local win_config = {
+  return {
     relative = 'editor',
     width = width,
     height = height,
@@ -114,9 +126,17 @@ function M._create_float_terminal(session, opts)
     title_pos = config.title_pos or 'center',
     style = 'minimal',
   }
+end
+
+-- Create floating terminal
+function M._create_float_terminal(session, opts)
+  opts = opts or {}
+
+  -- Create buffer
+  local buf_id = opts.buf_id or vim.api.nvim_create_buf(false, true)
 
   -- Create floating window
-  local win_id = vim.api.nvim_open_win(buf_id, true, win_config)
+  local win_id = vim.api.nvim_open_win(buf_id, true, M._float_win_config(session, opts))
 
   return buf_id, win_id
 end
@@ -217,7 +237,8 @@ function M._setup_terminal_keymaps(buf_id, session)
 end
 
 -- Switch to an existing session
-function M.switch_to_session(session)
+-- @param position string|nil: where to show the buffer when no window displays it yet
+function M.switch_to_session(session, position)
   if not session or not session:is_valid() then
     return false, 'Invalid session'
   end
@@ -236,6 +257,11 @@ function M.switch_to_session(session)
   if win_id then
     -- Focus existing window
     vim.api.nvim_set_current_win(win_id)
+  elseif position then
+    local _, _, err = M._open_positioned(session, position, { buf_id = buf_id })
+    if err then
+      return false, err
+    end
   else
     -- Create new window for existing buffer with positioning
     local cmd = session.config.split_command or 'belowright 15'
@@ -258,19 +284,57 @@ function M.switch_to_session(session)
   return true, nil
 end
 
+local function sh_quote(text)
+  return "'" .. text:gsub("'", "'\\''") .. "'"
+end
+
+-- Shell script starting the user's login shell (as a login shell, so rc files and the prompt load)
+-- The shell is the configured one, or the login shell of the exec user read from the passwd database. A shell that
+-- does not exist in the container falls back to fallback.
+-- @param shell string|nil: configured shell (name or path)
+-- @param fallback string|nil: shell used when none is found (default: /bin/sh)
+-- @return string
+function M.login_shell_script(shell, fallback)
+  return table.concat({
+    's=' .. sh_quote(shell or ''),
+    'if [ -z "$s" ]; then u=$(id -un)',
+    's=$(getent passwd "$u" 2>/dev/null | cut -d: -f7)',
+    [=[[ -n "$s" ] || s=$(awk -F: -v u="$u" '$1 == u { print $7 }' /etc/passwd 2>/dev/null); fi]=],
+    'case "$s" in /*) [ -x "$s" ] || s= ;; *) s=$(command -v "$s" 2>/dev/null) ;; esac',
+    '[ -n "$s" ] || s=' .. sh_quote(fallback or '/bin/sh'),
+    'exec "$s" -l',
+  }, '; ')
+end
+
 -- Create terminal command for container
-function M.build_terminal_command(container_id, shell, environment)
+-- @param opts table|nil: { user = remoteUser, workdir = workspaceFolder, env_file = generated env file }
+function M.build_terminal_command(container_id, shell, environment, opts)
   shell = shell or '/bin/sh'
   environment = environment or {}
+  opts = opts or {}
 
-  local cmd = { 'docker', 'exec', '-it' }
+  local cmd = { require('container.docker.runtime').get(), 'exec', '-it' }
 
   -- Add environment variables
+  if opts.env_file then
+    table.insert(cmd, '--env-file')
+    table.insert(cmd, vim.fn.shellescape(opts.env_file))
+  end
   for _, env in ipairs(environment) do
     table.insert(cmd, '-e')
     table.insert(cmd, env)
   end
 
+  if opts.user then
+    table.insert(cmd, '-u')
+    table.insert(cmd, vim.fn.shellescape(opts.user))
+  end
+
+  if opts.workdir then
+    table.insert(cmd, '-w')
+    table.insert(cmd, vim.fn.shellescape(opts.workdir))
+  end
+
   -- Add container and shell
   table.insert(cmd, container_id)
   table.insert(cmd, shell)
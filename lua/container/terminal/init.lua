@@ -22,6 +22,50 @@ function M.setup(config)
   log.debug('Terminal system initialized')
 end
 
+-- Environment and docker exec options of terminal sessions
+-- The terminal environment (with a TERM), then the devcontainer remoteEnv and secrets so terminals match exec and
+-- LSP sessions; entries are quoted for the shell.
+-- @param cwd string|nil: working directory (terminal.cwd, then workspaceFolder by default); variables such as
+--   ${containerEnv:PROJECT_DIR} are expanded
+-- @return table, table: environment entries and { user, workdir, env_file }
+local function exec_environment(terminal_config, cwd)
+  local environment = vim.deepcopy(terminal_config.environment or {})
+
+  -- Interactive programs need a terminal type even if the configured environment omits it
+  local has_term = false
+  for _, entry in ipairs(environment) do
+    if entry:match('^TERM=') then
+      has_term = true
+    end
+  end
+  if not has_term then
+    table.insert(environment, 'TERM=xterm-256color')
+  end
+
+  local container_config = require('container').get_state().current_config
+  local exec_opts = {}
+  local workdir = cwd or terminal_config.cwd or (container_config and container_config.workspace_folder)
+  local expand = require('container.environment').expand_path
+  exec_opts.workdir = expand and expand(workdir, container_config) or workdir
+  if container_config then
+    exec_opts.user = container_config.remote_user
+    exec_opts.env_file = require('container.env_file').args(container_config)[2]
+    local remote_env = require('container.environment').get_remote_environment(container_config)
+    -- The probed shell environment (userEnvProbe) sits below remoteEnv
+    remote_env = vim.tbl_extend('force', container_config.user_env or {}, remote_env)
+    local keys = vim.tbl_keys(remote_env)
+    table.sort(keys)
+    for _, key in ipairs(keys) do
+      table.insert(environment, vim.fn.shellescape(key .. '=' .. tostring(remote_env[key])))
+    end
+    -- Secrets by name only: docker reads the values from the environment of the terminal job
+    for _, name in ipairs(require('container.secrets').load()) do
+      table.insert(environment, vim.fn.shellescape(name))
+    end
+  end
+  return environment, exec_opts
+end
+
 -- Create or switch to a terminal session
 function M.terminal(opts)
   opts = opts or {}
@@ -35,18 +79,44 @@ function M.terminal(opts)
     return false
   end
 
+  -- A workspace folder (workspace_folders) by name or path starts the terminal there, in a session of its name
+  local folder
+  if opts.folder then
+    folder = require('container.workspace_folders').find(container.get_state().current_config, opts.folder)
+    if not folder then
+      notify.critical('Unknown workspace folder: ' .. opts.folder)
+      return false
+    end
+  end
+
   -- Determine session name
-  local session_name = opts.name or 'main'
+  local named = opts.name or (folder and folder.name)
+  local session_name = named or 'main'
   if session_name == '' then
     session_name = session_manager.generate_unique_name('terminal')
   end
 
-  -- Try to get existing session
-  local session = session_manager.get_session(session_name)
+  -- Try to get existing session, reusing any terminal already open for this container when no name is given
+  local session
+  if named then
+    session = session_manager.get_session(session_name)
+  else
+    session = session_manager.find_session_for_container(container_id)
+  end
+
+  if session and session.container_id ~= container_id then
+    -- The named session belongs to another (or a recreated) container
+    session_manager.close_session(session_name, true)
+    session = nil
+  elseif not session and not named and session_manager.get_session(session_name) then
+    -- 'main' is taken by a terminal of another workspace's container
+    session_name = session_manager.generate_unique_name(session_name)
+  end
 
   if session then
+    session_name = session.name
     -- Switch to existing session
-    local success, err = display.switch_to_session(session)
+    local success, err = display.switch_to_session(session, opts.position)
     if not success then
       log.error('Failed to switch to session %s: %s', session_name, err)
       return false
@@ -92,10 +162,15 @@ function M.terminal(opts)
     history.setup_auto_save(session, project_path)
   end
 
-  -- Build terminal command
+  -- Build terminal command; login sessions start the user's login shell (terminal.shell when configured)
   local shell = opts.shell or config.terminal.default_shell
-  local environment = config.terminal.environment or {}
-  local cmd = display.build_terminal_command(container_id, shell, environment)
+  if opts.login then
+    local script = display.login_shell_script(opts.shell or config.terminal.shell, config.terminal.default_shell)
+    shell = 'sh -c ' .. vim.fn.shellescape(script)
+  end
+  local environment, exec_opts = exec_environment(config.terminal, opts.cwd or (folder and folder.container))
+
+  local cmd = display.build_terminal_command(container_id, shell, environment, exec_opts)
 
   -- Switch to the terminal buffer before calling termopen
   vim.api.nvim_set_current_buf(buf_id)
@@ -105,6 +180,7 @@ function M.terminal(opts)
 
   -- Start terminal job
   local job_id = vim.fn.termopen(table.concat(cmd, ' '), {
+    env = require('container.secrets').job_env(cmd),
     on_exit = function(job_id, exit_code, event)
       log.debug('Terminal session %s exited with code %d', session_name, exit_code)
 
@@ -149,6 +225,98 @@ function M.terminal(opts)
   return true
 end
 
+-- Open the login shell of the container user in its own session
+-- @param opts table|nil: { position, shell } (shell overrides terminal.shell)
+function M.shell(opts)
+  return M.terminal(vim.tbl_extend('force', { name = 'shell' }, opts or {}, { login = true }))
+end
+
+-- Run a full-screen program (htop, lazygit) in the container in a terminal buffer of its own
+-- docker exec -it gives the program a TTY in the container. The terminal buffer is the TTY of the docker client:
+-- Neovim sizes it to the window and docker passes every size change on to the program (SIGWINCH), so it redraws
+-- at the right size. A floating window follows changes of the editor size. Unlike container.exec() nothing is
+-- captured; the buffer is closed when the program exits with code 0 and kept otherwise to show its last output.
+-- @param cmd string|table: shell command line or argv
+-- @param opts table|nil: { position ('float' by default, 'split', 'vsplit', 'tab'), user, cwd, env }
+-- @return boolean
+function M.exec_interactive(cmd, opts)
+  opts = opts or {}
+  display = display or require('container.terminal.display')
+
+  local container_id = require('container').get_container_id()
+  if not container_id then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local program
+  if type(cmd) == 'table' then
+    program = table.concat(vim.tbl_map(vim.fn.shellescape, cmd), ' ')
+  else
+    program = 'sh -c ' .. vim.fn.shellescape(cmd)
+  end
+  local title = type(cmd) == 'table' and table.concat(cmd, ' ') or cmd
+
+  local terminal_config = (require('container.config').get() or {}).terminal or {}
+  local environment, exec_opts = exec_environment(terminal_config, opts.cwd)
+  for key, value in pairs(opts.env or {}) do
+    table.insert(environment, vim.fn.shellescape(key .. '=' .. tostring(value)))
+  end
+  exec_opts.user = opts.user or exec_opts.user
+  local docker_cmd = display.build_terminal_command(container_id, program, environment, exec_opts)
+
+  -- The window exists before termopen so the TTY starts at its size
+  local position = opts.position or 'float'
+  local window_owner = { name = title, config = terminal_config }
+  local buf_id, win_id, err = display._open_positioned(window_owner, position, {})
+  if not buf_id then
+    notify.critical(string.format('Failed to open terminal: %s', err))
+    return false
+  end
+  vim.api.nvim_set_current_buf(buf_id)
+  vim.api.nvim_buf_set_option(buf_id, 'modified', false)
+
+  local group = vim.api.nvim_create_augroup('ContainerExecInteractive_' .. buf_id, { clear = true })
+  if position == 'float' then
+    vim.api.nvim_create_autocmd('VimResized', {
+      group = group,
+      callback = function()
+        if vim.api.nvim_win_is_valid(win_id) then
+          vim.api.nvim_win_set_config(win_id, display._float_win_config(window_owner, {}))
+        end
+      end,
+    })
+  end
+  vim.api.nvim_create_autocmd('BufWipeout', {
+    group = group,
+    buffer = buf_id,
+    callback = function()
+      pcall(vim.api.nvim_del_augroup_by_id, group)
+    end,
+  })
+
+  log.info('Running interactive program in container: %s', title)
+  local job_id = vim.fn.termopen(table.concat(docker_cmd, ' '), {
+    env = require('container.secrets').job_env(docker_cmd),
+    on_exit = function(_, exit_code)
+      log.debug('Interactive program %s exited with code %d', title, exit_code)
+      vim.schedule(function()
+        if exit_code == 0 and vim.api.nvim_buf_is_valid(buf_id) then
+          vim.api.nvim_buf_delete(buf_id, { force = true })
+        elseif exit_code ~= 0 then
+          notify.warn(string.format('%s exited with code %d', title, exit_code))
+        end
+      end)
+    end,
+  })
+  if job_id <= 0 then
+    notify.critical('Failed to start ' .. title)
+    return false
+  end
+  vim.cmd('startinsert')
+  return true
+end
+
 -- Create new terminal session
 function M.new_session(name)
   name = name or session_manager.generate_unique_name('terminal')
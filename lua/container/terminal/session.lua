@@ -151,6 +151,16 @@ function M.list_sessions()
   return valid_sessions
 end
 
+-- Most recently used session running in the given container
+function M.find_session_for_container(container_id)
+  for _, session in ipairs(M.list_sessions()) do
+    if session.container_id == container_id then
+      return session
+    end
+  end
+  return nil
+end
+
 function M.get_active_session()
   if active_session and active_session:is_valid() then
     return active_session
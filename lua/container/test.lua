@@ -0,0 +1,754 @@
+-- lua/container/test.lua
+-- Run tests inside the container and load failures into the quickfix list
+-- The runner is picked by the filetype of the current buffer from container.test_runners; the go test -json
+-- parser of the built-in Go runner lives here. Go benchmarks (go test -bench -benchmem) run from here as well.
+
+local M = {}
+
+local log = require('container.utils.log')
+local notify = require('container.utils.notify')
+
+-- Name of the output buffer used for test output
+M.OUTPUT_NAME = 'test'
+
+-- Pattern matching the start of a Go test function
+M.TEST_FUNC_PATTERN = '^func%s+(Test[%w_]*)%s*%('
+
+-- Running go test job and the options it was started with
+local running = nil
+
+-- Result of the running or last test run: { state, counts }
+local last_result = nil
+
+-- Summary of the running or last test run, nil before the first run
+-- state is 'running', 'passed', 'failed' (failing tests) or 'build_failed' (the code did not compile)
+-- @return table|nil: { state, passed, failed, skipped }
+function M.summary()
+  if not last_result then
+    return nil
+  end
+  return {
+    state = last_result.state,
+    passed = last_result.counts.passed,
+    failed = last_result.counts.failed,
+    skipped = last_result.counts.skipped,
+  }
+end
+
+-- Find the test function enclosing the given line
+-- @param lines table: buffer lines
+-- @param lnum number: 1-based cursor line
+-- @return string|nil: test function name
+function M.find_enclosing_test(lines, lnum)
+  for i = math.min(lnum, #lines), 1, -1 do
+    local name = lines[i]:match(M.TEST_FUNC_PATTERN)
+    if name then
+      return name
+    end
+  end
+  return nil
+end
+
+-- Find the Go module containing dir by searching upward for go.mod
+-- @return string|nil, string|nil: module root directory and module path
+function M.find_go_module(dir)
+  local fs = require('container.utils.fs')
+  local current = dir
+  while current and current ~= '' do
+    local go_mod = fs.join_path(current, 'go.mod')
+    if fs.is_file(go_mod) then
+      local content = fs.read_file(go_mod) or ''
+      return current, content:match('module%s+([^%s]+)')
+    end
+    local parent = fs.dirname(current)
+    if not parent or parent == current then
+      break
+    end
+    current = parent
+  end
+  return nil, nil
+end
+
+-- Map a path between the host workspace and the container workspace
+-- @return string|nil: mapped path, or nil when path is outside from_root
+function M.map_path(path, from_root, to_root)
+  return require('container.lsp.path').replace_prefix(path, from_root, to_root)
+end
+
+-- Build the go test argv
+-- @param opts table: { run = string|nil, packages = table|nil, args = table|nil, coverprofile = string|nil }
+function M.build_command(opts)
+  opts = opts or {}
+  local cmd = { 'go', 'test', '-json' }
+  if opts.run then
+    vim.list_extend(cmd, { '-run', '^' .. opts.run .. '$' })
+  end
+  if opts.coverprofile then
+    table.insert(cmd, '-coverprofile=' .. opts.coverprofile)
+  end
+  vim.list_extend(cmd, opts.args or {})
+  vim.list_extend(cmd, opts.packages or { './...' })
+  return cmd
+end
+
+-- Parse a "file.go:line[:col]: message" location from an output line
+-- @return string|nil, number, number, string: file, line, column, message
+function M.parse_location(text)
+  local file, lnum, col, msg = text:match('^%s*([^%s:]+%.go):(%d+):(%d+):%s*(.*)$')
+  if file then
+    return file, tonumber(lnum), tonumber(col), msg
+  end
+  file, lnum, msg = text:match('^%s*([^%s:]+%.go):(%d+):?%s*(.*)$')
+  if file then
+    return file, tonumber(lnum), 0, msg
+  end
+  return nil
+end
+
+-- Resolve a file reported by go test to a host path
+-- Bare file names (t.Errorf output) are relative to the reporting package, relative
+-- paths to the working directory and absolute paths are container paths
+function M.resolve_file(file, package, ctx)
+  local fs = require('container.utils.fs')
+
+  if file:match('^/') then
+    return M.map_path(file, ctx.container_root, ctx.host_root)
+  end
+
+  if not file:find('/', 1, true) and package and ctx.module_root and ctx.module_path then
+    if package == ctx.module_path then
+      return fs.join_path(ctx.module_root, file)
+    end
+    local prefix = ctx.module_path .. '/'
+    if package:sub(1, #prefix) == prefix then
+      return fs.join_path(ctx.module_root, package:sub(#prefix + 1), file)
+    end
+  end
+
+  return fs.resolve_path(file, ctx.host_dir)
+end
+
+-- Parent tests of a subtest, innermost first ("TestA/b/c" -> "TestA/b", "TestA")
+local function parent_tests(name)
+  local parents = {}
+  local parent = name:match('^(.*)/[^/]*$')
+  while parent do
+    table.insert(parents, parent)
+    parent = parent:match('^(.*)/[^/]*$')
+  end
+  return parents
+end
+
+-- Check whether a test output line is printed by the testing framework itself
+local function is_framework_line(text)
+  return text:match('^%s*=== ') ~= nil or text:match('^%s*%-%-%- ') ~= nil
+end
+
+-- Create a parser for go test -json output
+-- Failures become quickfix entries named after the (sub)test, with the lines continuing a t.Errorf
+-- message as context entries. Compiler errors are reported as build errors (parser.build_failed),
+-- separately from failing tests.
+-- @param ctx table: { host_root, container_root, host_dir, module_root, module_path }
+-- @return table: parser with feed(line) returning display text, items list and counts
+function M.new_parser(ctx)
+  local parser = {
+    items = {},
+    failed = false,
+    build_failed = false,
+    counts = { passed = 0, failed = 0, skipped = 0 },
+  }
+  -- Entries of running tests, added once the test fails
+  local pending = {}
+  -- Indentation of the last location of each test, to recognize continuation lines
+  local indents = {}
+  -- Tests with a failing subtest, which report no entry of their own
+  local failed_children = {}
+
+  local function location_item(text, package, test)
+    local file, lnum, col, msg = M.parse_location(text)
+    if not file then
+      return nil
+    end
+    local host_file = M.resolve_file(file, package, ctx)
+    if not host_file then
+      return nil
+    end
+    return {
+      filename = host_file,
+      lnum = lnum,
+      col = col,
+      text = test and string.format('%s: %s', test, msg) or msg,
+      type = 'E',
+    }
+  end
+
+  local function add_build_error(text, package)
+    local item = location_item(text, package)
+    if item then
+      parser.build_failed = true
+      parser.failed = true
+      table.insert(parser.items, item)
+    end
+  end
+
+  local function add_test_output(text, event, key)
+    if is_framework_line(text) then
+      indents[key] = nil
+      return
+    end
+    pending[key] = pending[key] or {}
+    local item = location_item(text, event.Package, event.Test)
+    local indent = #text:match('^%s*')
+    if item then
+      table.insert(pending[key], item)
+      indents[key] = indent
+    elseif indents[key] and indent > indents[key] and vim.trim(text) ~= '' then
+      -- Continuation of a multi-line failure message
+      table.insert(pending[key], { text = text })
+    else
+      indents[key] = nil
+    end
+  end
+
+  function parser.feed(line)
+    local ok, event = pcall(vim.json.decode, line)
+    if not ok or type(event) ~= 'table' or not event.Action then
+      -- Plain output: compiler errors of Go versions before build events in the JSON stream
+      add_build_error(line, nil)
+      return line
+    end
+
+    local text = (event.Output or ''):gsub('\n$', '')
+
+    -- Compiler output (Go 1.24+) belongs to ImportPath instead of Package
+    if event.Action == 'build-output' then
+      add_build_error(text, nil)
+      return text
+    end
+    if event.Action == 'build-fail' then
+      parser.build_failed = true
+      parser.failed = true
+      return nil
+    end
+
+    local key = (event.Package or '') .. '\0' .. (event.Test or '')
+
+    if event.Action == 'output' then
+      if event.Test then
+        add_test_output(text, event, key)
+      elseif text:find('[build failed]', 1, true) or text:find('[setup failed]', 1, true) then
+        parser.build_failed = true
+        parser.failed = true
+      else
+        -- Package level output, e.g. a panic outside of a test
+        local item = location_item(text, event.Package)
+        if item then
+          parser.failed = true
+          table.insert(parser.items, item)
+        end
+      end
+      return text
+    end
+
+    if event.Action == 'fail' then
+      parser.failed = true
+      if event.FailedBuild then
+        parser.build_failed = true
+      end
+    end
+
+    if event.Test and (event.Action == 'pass' or event.Action == 'fail' or event.Action == 'skip') then
+      if event.Action == 'pass' then
+        parser.counts.passed = parser.counts.passed + 1
+      elseif event.Action == 'skip' then
+        parser.counts.skipped = parser.counts.skipped + 1
+      else
+        parser.counts.failed = parser.counts.failed + 1
+        local items = pending[key] or {}
+        if #items > 0 then
+          vim.list_extend(parser.items, items)
+        elseif not failed_children[key] then
+          -- A failure without location (t.Fail, timeout) still gets an entry
+          table.insert(parser.items, {
+            text = string.format('FAIL: %s (%s)', event.Test, event.Package or ''),
+            type = 'E',
+          })
+        end
+        for _, parent in ipairs(parent_tests(event.Test)) do
+          failed_children[(event.Package or '') .. '\0' .. parent] = true
+        end
+      end
+      pending[key] = nil
+      indents[key] = nil
+    end
+    return nil
+  end
+
+  return parser
+end
+
+-- Runner of a filetype, falling back to test_integration.default_runner
+-- @return table|nil, string: runner spec and the filetype it was found for
+function M.get_runner(filetype)
+  local runners = require('container.test_runners')
+  local runner = filetype and filetype ~= '' and runners.get_by_filetype(filetype)
+  if runner then
+    return runner, filetype
+  end
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  local test_integration = ok and plugin_config and plugin_config.test_integration or {}
+  local default = test_integration.default_runner or 'go'
+  return runners.get_by_filetype(default), default
+end
+
+-- Workspace folder tests run in
+-- @param container_config table: normalized configuration
+-- @param name string|nil: folder name or path; the folder of file when nil
+-- @param file string|nil: host path of the current file
+-- @return table|nil, string|nil: folder { name, host, container, primary } or nil and an error message
+function M.resolve_folder(container_config, name, file)
+  local workspace_folders = require('container.workspace_folders')
+  if name and name ~= '' then
+    local folder = workspace_folders.find(container_config, name)
+    if not folder then
+      return nil, 'Unknown workspace folder: ' .. name
+    end
+    return folder
+  end
+  return workspace_folders.for_path(container_config, file ~= '' and file or nil)
+end
+
+-- Run tests in the container, streaming output and filling the quickfix list
+-- Tests run in the workspace folder of the current file (workspace_folders), or in opts.folder given by name or path.
+-- @param opts table: { run = string|nil, args = table|nil, file = string|nil, filetype = string|nil,
+--   folder = string|nil }
+function M.run(opts)
+  opts = opts or {}
+  local fs = require('container.utils.fs')
+  local state = require('container').get_state()
+
+  if not state.current_container then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local runner, filetype = M.get_runner(opts.filetype or vim.bo.filetype)
+  if not runner then
+    notify.error('No test runner for filetype ' .. tostring(filetype))
+    return false
+  end
+  local runner_name = runner.name or filetype
+
+  local container_config = state.current_config or {}
+  local file = opts.file or vim.fn.expand('%:p')
+  local folder, folder_err = M.resolve_folder(container_config, opts.folder, file)
+  if not folder then
+    notify.error(folder_err)
+    return false
+  end
+  local host_root, container_root = folder.host, folder.container
+  local workspace_dir = folder.primary and container_config.workspace_folder or container_root
+
+  -- Go runs from the package directory of the current file, other runners (and runs in a named folder) from the
+  -- workspace folder
+  local host_dir = runner.cwd == 'file' and not opts.folder and file ~= '' and fs.dirname(file)
+    or M.map_path(workspace_dir, container_root, host_root)
+    or host_root
+  local container_dir = M.map_path(host_dir, host_root, container_root)
+  if not container_dir then
+    container_dir = workspace_dir
+    host_dir = M.map_path(container_dir, container_root, host_root) or host_root
+  end
+
+  -- Coverage is collected when test_integration.coverage is enabled (or requested for this run)
+  local coverage = opts.coverage
+  if coverage == nil then
+    local ok, plugin_config = pcall(function()
+      return require('container.config').get()
+    end)
+    coverage = ok and plugin_config and plugin_config.test_integration and plugin_config.test_integration.coverage
+  end
+  coverage = coverage and runner.coverage or false
+
+  local ctx = {
+    host_root = host_root,
+    container_root = container_root,
+    host_dir = host_dir,
+    container_dir = container_dir,
+    file = file ~= '' and M.map_path(file, host_dir, '.') or nil,
+    coverage = coverage,
+  }
+  if ctx.file then
+    ctx.file = ctx.file:gsub('^%./', '')
+  end
+  if runner.context then
+    runner.context(ctx)
+  end
+  local parser = runner.parser(ctx)
+
+  local test_cmd = require('container.test_runners').build_command(runner, opts, ctx)
+
+  local environment = require('container.environment')
+  local cmd = { require('container.docker.runtime').get(), 'exec', '-i' }
+  vim.list_extend(cmd, environment.build_exec_args(container_config))
+  local container_id = state.current_container
+  vim.list_extend(cmd, { '-w', container_dir, container_id })
+  vim.list_extend(cmd, test_cmd)
+
+  local output = require('container.ui.output')
+  output.clear(M.OUTPUT_NAME)
+  output.append(M.OUTPUT_NAME, { '$ ' .. table.concat(test_cmd, ' ') .. '  (in ' .. container_dir .. ')' })
+  output.open(M.OUTPUT_NAME)
+  log.info('Running tests in container: %s (cwd: %s)', table.concat(test_cmd, ' '), container_dir)
+
+  -- The running counts are reported to the progress sink (ui.progress), which also shows the result
+  local progress = require('container.ui.progress')
+  local progress_token = progress.begin('Testing (' .. runner_name .. ')')
+  local function report_counts()
+    local counts = parser.counts
+    progress.report(progress_token, string.format('%d passed, %d failed', counts.passed, counts.failed))
+  end
+
+  -- Lines are fed to the parser as they arrive; only the lines it returns are shown
+  local function feed(lines)
+    local texts = {}
+    for _, line in ipairs(lines) do
+      local text = parser.feed(line)
+      if text then
+        table.insert(texts, text)
+      end
+    end
+    return texts
+  end
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    local texts = feed(lines)
+    if #texts > 0 then
+      vim.schedule(function()
+        output.append(M.OUTPUT_NAME, texts)
+        report_counts()
+      end)
+    end
+  end)
+
+  local job_id
+  job_id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        -- A stopped run is not reported
+        if not running or running.job_id ~= job_id then
+          progress.cancel(progress_token)
+          return
+        end
+        running = nil
+        local texts = feed(remaining())
+        if #texts > 0 then
+          output.append(M.OUTPUT_NAME, texts)
+        end
+
+        local title = (parser.build_failed and 'ContainerTest (build failed): ' or 'ContainerTest: ')
+          .. table.concat(test_cmd, ' ')
+        vim.fn.setqflist({}, ' ', { title = title, items = parser.items })
+        output.append(M.OUTPUT_NAME, { '', string.format('<== %s exited with code %d', runner_name, exit_code) })
+
+        if coverage and not parser.build_failed then
+          require('container.coverage').load(container_id, ctx)
+        end
+
+        local counts = parser.counts
+        if parser.build_failed then
+          last_result.state = 'build_failed'
+          progress.finish(progress_token, false, string.format('Build failed (%d quickfix entries)', #parser.items))
+        elseif exit_code == 0 then
+          last_result.state = 'passed'
+          progress.finish(
+            progress_token,
+            true,
+            string.format('Tests passed (%d passed, %d skipped)', counts.passed, counts.skipped)
+          )
+        else
+          last_result.state = 'failed'
+          progress.finish(
+            progress_token,
+            false,
+            string.format('Tests failed (%d failed, %d passed)', counts.failed, counts.passed)
+          )
+        end
+        if exit_code ~= 0 and #parser.items > 0 then
+          vim.cmd('copen')
+        end
+      end)
+    end,
+  })
+
+  if job_id <= 0 then
+    progress.cancel(progress_token)
+    notify.error(string.format('Failed to start %s in container', runner_name))
+    return false
+  end
+  running = { job_id = job_id, opts = vim.tbl_extend('force', opts, { file = file, filetype = filetype }) }
+  last_result = { state = 'running', counts = parser.counts }
+  return true
+end
+
+-- Stop the running tests
+-- @return table|nil: options of the stopped run, to start it again with run()
+function M.stop()
+  if not running then
+    return nil
+  end
+  local stopped = running
+  running = nil
+  last_result = nil
+  pcall(vim.fn.jobstop, stopped.job_id)
+  log.info('Stopped running tests')
+  return stopped.opts
+end
+
+-- Run the test enclosing the cursor
+function M.run_nearest(opts)
+  opts = opts or {}
+  local runner = M.get_runner(opts.filetype or vim.bo.filetype)
+  if not runner or not runner.nearest then
+    notify.error('No test runner finding the nearest test for filetype ' .. tostring(vim.bo.filetype))
+    return false
+  end
+  local lines = vim.api.nvim_buf_get_lines(0, 0, -1, false)
+  local test_name = runner.nearest(lines, vim.fn.line('.'))
+  if not test_name then
+    notify.error(string.format('No test found at cursor (%s)', runner.name or vim.bo.filetype))
+    return false
+  end
+  return M.run(vim.tbl_extend('force', opts, { run = test_name }))
+end
+
+-- Name of the output buffer used for benchmark output
+M.BENCH_OUTPUT_NAME = 'bench'
+
+-- Pattern matching the start of a Go benchmark function
+M.BENCH_FUNC_PATTERN = '^func%s+(Benchmark[%w_]*)%s*%('
+
+-- Running go test -bench job
+local running_bench = nil
+
+-- Results of the running or last benchmark run
+local bench_results = {}
+
+-- Find the benchmark function enclosing the given line
+-- @param lines table: buffer lines
+-- @param lnum number: 1-based cursor line
+-- @return string|nil: benchmark function name
+function M.find_enclosing_benchmark(lines, lnum)
+  for i = math.min(lnum, #lines), 1, -1 do
+    local name = lines[i]:match(M.BENCH_FUNC_PATTERN)
+    if name then
+      return name
+    end
+  end
+  return nil
+end
+
+-- Build the go test -bench argv
+-- A single benchmark runs without the tests of the package (-run=^$).
+-- @param opts table: { bench = string|nil, args = table|nil }
+function M.build_bench_command(opts)
+  opts = opts or {}
+  local cmd = { 'go', 'test' }
+  if opts.bench then
+    vim.list_extend(cmd, { '-bench=^' .. opts.bench .. '$', '-run=^$' })
+  else
+    table.insert(cmd, '-bench=.')
+  end
+  table.insert(cmd, '-benchmem')
+  vim.list_extend(cmd, opts.args or {})
+  table.insert(cmd, '.')
+  return cmd
+end
+
+-- Parse a result line of go test -bench
+-- "BenchmarkSum/small-8  1000000  1052 ns/op  128 B/op  2 allocs/op"
+-- @return table|nil: { name, procs, iterations, ns_per_op, bytes_per_op, allocs_per_op, metrics }, metrics holding
+--   every value by unit (custom b.ReportMetric units included); nil for other lines
+function M.parse_bench_line(line)
+  local full_name, iterations, rest = line:match('^(Benchmark%S+)%s+(%d+)%s+(.+)$')
+  if not full_name then
+    return nil
+  end
+  local metrics = {}
+  for value, unit in rest:gmatch('([%d%.eE+-]+)%s+([^%s]+)') do
+    metrics[unit] = tonumber(value)
+  end
+  if not metrics['ns/op'] then
+    return nil
+  end
+  local name, procs = full_name:match('^(.-)%-(%d+)$')
+  return {
+    name = name or full_name,
+    procs = tonumber(procs),
+    iterations = tonumber(iterations),
+    ns_per_op = metrics['ns/op'],
+    bytes_per_op = metrics['B/op'],
+    allocs_per_op = metrics['allocs/op'],
+    metrics = metrics,
+  }
+end
+
+-- Results of the running or last benchmark run, in output order
+-- @return table: list of parse_bench_line() results
+function M.bench_results()
+  return vim.list_extend({}, bench_results)
+end
+
+-- Format benchmark results as an aligned table
+-- @param results table: list of parse_bench_line() results
+-- @return table: lines
+function M.format_bench_results(results)
+  local rows = { { 'Benchmark', 'Iterations', 'ns/op', 'B/op', 'allocs/op' } }
+  for _, result in ipairs(results) do
+    table.insert(rows, {
+      result.name,
+      tostring(result.iterations),
+      string.format(result.ns_per_op >= 100 and '%.0f' or '%.4g', result.ns_per_op),
+      result.bytes_per_op and string.format('%.0f', result.bytes_per_op) or '-',
+      result.allocs_per_op and string.format('%.0f', result.allocs_per_op) or '-',
+    })
+  end
+  local widths = {}
+  for _, row in ipairs(rows) do
+    for i, cell in ipairs(row) do
+      widths[i] = math.max(widths[i] or 0, #cell)
+    end
+  end
+  local lines = {}
+  for _, row in ipairs(rows) do
+    -- Name left-aligned, numbers right-aligned
+    local cells = { row[1] .. string.rep(' ', widths[1] - #row[1]) }
+    for i = 2, #row do
+      table.insert(cells, string.rep(' ', widths[i] - #row[i]) .. row[i])
+    end
+    table.insert(lines, (table.concat(cells, '  '):gsub('%s+$', '')))
+  end
+  return lines
+end
+
+-- Run benchmarks of the package of the current file in the container, streaming output
+-- @param opts table: { bench = string|nil, args = table|nil, file = string|nil }
+function M.run_bench(opts)
+  opts = opts or {}
+  local fs = require('container.utils.fs')
+  local state = require('container').get_state()
+
+  if not state.current_container then
+    notify.critical('No active container. Start container first with :ContainerStart')
+    return false
+  end
+
+  local container_config = state.current_config or {}
+  local file = opts.file or vim.fn.expand('%:p')
+  local folder = M.resolve_folder(container_config, nil, file)
+  local host_root, container_root = folder.host, folder.container
+  local host_dir = file ~= '' and fs.dirname(file) or host_root
+  local container_dir = M.map_path(host_dir, host_root, container_root)
+  if not container_dir then
+    notify.error('Current file is outside of the workspace: ' .. host_dir)
+    return false
+  end
+
+  if running_bench then
+    pcall(vim.fn.jobstop, running_bench.job_id)
+    running_bench = nil
+  end
+
+  local bench_cmd = M.build_bench_command(opts)
+  local environment = require('container.environment')
+  local cmd = { require('container.docker.runtime').get(), 'exec', '-i' }
+  vim.list_extend(cmd, environment.build_exec_args(container_config))
+  vim.list_extend(cmd, { '-w', container_dir, state.current_container })
+  vim.list_extend(cmd, bench_cmd)
+
+  local output = require('container.ui.output')
+  output.clear(M.BENCH_OUTPUT_NAME)
+  output.append(M.BENCH_OUTPUT_NAME, { '$ ' .. table.concat(bench_cmd, ' ') .. '  (in ' .. container_dir .. ')' })
+  output.open(M.BENCH_OUTPUT_NAME)
+  log.info('Running benchmarks in container: %s (cwd: %s)', table.concat(bench_cmd, ' '), container_dir)
+
+  local progress = require('container.ui.progress')
+  local progress_token = progress.begin('Benchmarking ' .. (opts.bench or 'package'))
+  local results = {}
+  bench_results = results
+
+  local function collect(lines)
+    for _, line in ipairs(lines) do
+      local result = M.parse_bench_line(line)
+      if result then
+        table.insert(results, result)
+      end
+    end
+  end
+  local on_data, remaining = require('container.utils.lines').handler(function(lines)
+    collect(lines)
+    vim.schedule(function()
+      output.append(M.BENCH_OUTPUT_NAME, lines)
+      if #results > 0 then
+        progress.report(progress_token, results[#results].name)
+      end
+    end)
+  end)
+
+  local job_id
+  job_id = vim.fn.jobstart(cmd, {
+    env = require('container.secrets').job_env(cmd),
+    on_stdout = on_data,
+    on_stderr = on_data,
+    on_exit = function(_, exit_code)
+      vim.schedule(function()
+        if not running_bench or running_bench.job_id ~= job_id then
+          progress.cancel(progress_token)
+          return
+        end
+        running_bench = nil
+        local rest = remaining()
+        collect(rest)
+        if #rest > 0 then
+          output.append(M.BENCH_OUTPUT_NAME, rest)
+        end
+        output.append(M.BENCH_OUTPUT_NAME, { '', string.format('<== go test exited with code %d', exit_code) })
+        if #results > 0 then
+          output.append(M.BENCH_OUTPUT_NAME, { '' })
+          output.append(M.BENCH_OUTPUT_NAME, M.format_bench_results(results))
+        end
+        if exit_code == 0 then
+          progress.finish(progress_token, true, string.format('%d benchmarks finished', #results))
+        else
+          progress.finish(progress_token, false, string.format('Benchmarks failed (exit code %d)', exit_code))
+        end
+      end)
+    end,
+  })
+
+  if job_id <= 0 then
+    progress.cancel(progress_token)
+    notify.error('Failed to start go test -bench in container')
+    return false
+  end
+  running_bench = { job_id = job_id }
+  return true
+end
+
+-- Run the benchmark enclosing the cursor
+function M.run_nearest_bench(opts)
+  opts = opts or {}
+  local lines = vim.api.nvim_buf_get_lines(0, 0, -1, false)
+  local bench_name = M.find_enclosing_benchmark(lines, vim.fn.line('.'))
+  if not bench_name then
+    notify.error('No benchmark found at cursor')
+    return false
+  end
+  return M.run_bench(vim.tbl_extend('force', opts, { bench = bench_name }))
+end
+
+return M
@@ -430,7 +430,7 @@ function M.setup_neotest()
     -- Modify the command to run in container
     local original_command = spec.command
     local docker_command = {
-      'docker',
+      require('container.docker.runtime').get(),
       'exec',
       '-i',
     }
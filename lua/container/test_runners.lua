@@ -0,0 +1,292 @@
+-- lua/container/test_runners.lua
+-- Test runner registry used by :ContainerTest
+-- A filetype maps to a runner spec describing how to run its tests in the container and how to read the output:
+--
+--   {
+--     name = 'pytest',
+--     command = { 'python', '-m', 'pytest', '{file}::{test}' } or function(opts, ctx) returning argv,
+--     cwd = 'root' (workspace folder, default) or 'file' (directory of the current file),
+--     parser = function(ctx) returning { feed(line), items, counts, failed, build_failed },
+--     nearest = function(lines, lnum) returning the name of the test enclosing the cursor,
+--   }
+--
+-- List commands are templates: {test} is the nearest test and {file} the current file relative to the working
+-- directory, both only set when running the nearest test. Arguments (or nested lists of arguments) referring to a
+-- placeholder without a value are left out, and :ContainerTest arguments are appended.
+-- parser.feed returns the text shown in the output buffer (nil hides the line) and collects quickfix items.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Name of the nearest test function in a buffer: the closest line above the cursor matching one of the patterns
+-- @param patterns table: Lua patterns capturing the test name
+-- @return function(lines, lnum): string|nil
+function M.nearest_by_patterns(patterns)
+  return function(lines, lnum)
+    for i = math.min(lnum, #lines), 1, -1 do
+      for _, pattern in ipairs(patterns) do
+        local name = lines[i]:match(pattern)
+        if name then
+          return name
+        end
+      end
+    end
+    return nil
+  end
+end
+
+-- Create a parser matching output lines against Lua patterns
+-- @param ctx table: { host_root, container_root, host_dir, container_dir }
+-- @param patterns table:
+--   locations: patterns capturing file, line and optionally column and message of a failure
+--   title: pattern capturing the name of the failing test the next locations belong to
+--   summary: pattern of the line reporting the counts, with passed/failed/skipped patterns capturing them
+--   skip: pattern of locations to ignore (e.g. dependencies in stack traces)
+-- @return table: parser
+function M.pattern_parser(ctx, patterns)
+  local fs = require('container.utils.fs')
+  local parser = {
+    items = {},
+    failed = false,
+    counts = { passed = 0, failed = 0, skipped = 0 },
+  }
+  local title = nil
+  local title_located = false
+  local seen = {}
+
+  local function host_path(file)
+    if file:match('^/') then
+      return require('container.test').map_path(file, ctx.container_root, ctx.host_root)
+    end
+    return fs.resolve_path(file, ctx.host_dir)
+  end
+
+  local function add_location(line)
+    if patterns.skip and line:find(patterns.skip) then
+      return
+    end
+    for _, pattern in ipairs(patterns.locations or {}) do
+      local file, lnum, col, msg = line:match(pattern)
+      if file then
+        -- The column capture is optional
+        if msg == nil and col and not tonumber(col) then
+          col, msg = nil, col
+        end
+        local filename = host_path(file)
+        local key = string.format('%s:%s:%s', tostring(filename), lnum, tostring(title))
+        if filename and not seen[key] and not (title and title_located) then
+          seen[key] = true
+          title_located = title ~= nil
+          local text = vim.trim(msg or '')
+          if title then
+            text = text ~= '' and string.format('%s: %s', title, text) or title
+          end
+          table.insert(parser.items, {
+            filename = filename,
+            lnum = tonumber(lnum),
+            col = tonumber(col) or 0,
+            text = text,
+            type = 'E',
+          })
+        end
+        return
+      end
+    end
+  end
+
+  function parser.feed(line)
+    if patterns.summary and line:find(patterns.summary) then
+      for _, key in ipairs({ 'passed', 'failed', 'skipped' }) do
+        local count = patterns[key] and line:match(patterns[key])
+        if count then
+          parser.counts[key] = tonumber(count)
+        end
+      end
+      parser.failed = parser.counts.failed > 0
+      return line
+    end
+    local name = patterns.title and line:match(patterns.title)
+    if name then
+      title = vim.trim(name)
+      title_located = false
+      parser.failed = true
+      return line
+    end
+    add_location(line)
+    return line
+  end
+
+  return parser
+end
+
+-- Escape a test name for a regular expression argument (jest -t)
+function M.escape_regex(name)
+  return (name:gsub('[%^%$%(%)%.%[%]%*%+%?{}|\\]', '\\%0'))
+end
+
+-- Built-in runners by filetype
+M.runners = {
+  go = {
+    name = 'go test',
+    cwd = 'file',
+    coverage = true,
+    command = function(opts, ctx)
+      local go_test = require('container.test')
+      return go_test.build_command({
+        run = opts.run,
+        args = opts.args,
+        packages = opts.run and { '.' } or nil,
+        coverprofile = ctx.coverage and require('container.coverage').PROFILE_PATH or nil,
+      })
+    end,
+    context = function(ctx)
+      ctx.module_root, ctx.module_path = require('container.test').find_go_module(ctx.host_dir)
+    end,
+    parser = function(ctx)
+      return require('container.test').new_parser(ctx)
+    end,
+    nearest = function(lines, lnum)
+      return require('container.test').find_enclosing_test(lines, lnum)
+    end,
+  },
+
+  python = {
+    name = 'pytest',
+    -- --tb=line prints one "file:line: message" line per failure
+    command = { 'python', '-m', 'pytest', '--tb=line', '-rfE', '{file}::{test}' },
+    parser = function(ctx)
+      return M.pattern_parser(ctx, {
+        locations = { '^([^%s:]+%.py):(%d+): (.*)$' },
+        summary = '^=+ .* in [%d%.]+s',
+        passed = '(%d+) passed',
+        failed = '(%d+) failed',
+        skipped = '(%d+) skipped',
+      })
+    end,
+    -- Methods of test classes are addressed as Class::method
+    nearest = function(lines, lnum)
+      for i = math.min(lnum, #lines), 1, -1 do
+        local indent, name = lines[i]:match('^(%s*)def%s+(test[%w_]*)%s*%(')
+        if not name then
+          indent, name = lines[i]:match('^(%s*)async%s+def%s+(test[%w_]*)%s*%(')
+        end
+        if name then
+          if indent == '' then
+            return name
+          end
+          for j = i - 1, 1, -1 do
+            local class = lines[j]:match('^class%s+([%w_]+)')
+            if class then
+              return class .. '::' .. name
+            end
+          end
+          return name
+        end
+      end
+      return nil
+    end,
+  },
+
+  javascript = {
+    name = 'jest',
+    command = function(opts, ctx)
+      local cmd = { 'npx', 'jest', '--ci' }
+      if opts.run then
+        vim.list_extend(cmd, { ctx.file, '-t', M.escape_regex(opts.run) })
+      end
+      return vim.list_extend(cmd, opts.args or {})
+    end,
+    parser = function(ctx)
+      return M.pattern_parser(ctx, {
+        title = '^%s*● (.+)$',
+        locations = { '%(([^%s()]+):(%d+):(%d+)%)$', '^%s*at ([^%s()]+):(%d+):(%d+)$' },
+        skip = 'node_modules',
+        summary = '^Tests:',
+        passed = '(%d+) passed',
+        failed = '(%d+) failed',
+        skipped = '(%d+) skipped',
+      })
+    end,
+    nearest = M.nearest_by_patterns({
+      '^%s*it%s*%(%s*[\'"`](.-)[\'"`]',
+      '^%s*test%s*%(%s*[\'"`](.-)[\'"`]',
+    }),
+  },
+}
+
+M.runners.typescript = M.runners.javascript
+M.runners.javascriptreact = M.runners.javascript
+M.runners.typescriptreact = M.runners.javascript
+
+-- Runner for a filetype (test_integration.runners override the built-ins)
+-- @param filetype string
+-- @return table|nil
+function M.get_by_filetype(filetype)
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  local configured = ok and plugin_config and plugin_config.test_integration and plugin_config.test_integration.runners
+  if configured and configured[filetype] then
+    return configured[filetype]
+  end
+  return M.runners[filetype]
+end
+
+-- Register a runner for a filetype
+-- @param filetype string
+-- @param spec table: runner spec (see the top of this file)
+function M.register_runner(filetype, spec)
+  if type(spec) ~= 'table' or not spec.command or not spec.parser then
+    error('test runner for ' .. tostring(filetype) .. ' needs command and parser')
+  end
+  log.debug('Registered test runner for %s: %s', filetype, spec.name or filetype)
+  M.runners[filetype] = spec
+end
+
+-- Build the command of a runner
+-- @param spec table
+-- @param opts table: { run = string|nil, args = table|nil }
+-- @param ctx table: { file = string|nil } (file relative to the working directory)
+-- @return table: argv
+function M.build_command(spec, opts, ctx)
+  local cmd
+  if type(spec.command) == 'function' then
+    cmd = spec.command(opts, ctx)
+  else
+    local values = { test = opts.run, file = opts.run and ctx.file or nil }
+    local function expand(arg)
+      local missing = false
+      local text = arg:gsub('{(%w+)}', function(name)
+        if values[name] == nil then
+          missing = true
+          return ''
+        end
+        return values[name]
+      end)
+      return not missing and text or nil
+    end
+
+    cmd = {}
+    for _, arg in ipairs(spec.command) do
+      if type(arg) == 'table' then
+        local group = {}
+        for _, item in ipairs(arg) do
+          table.insert(group, expand(item) or false)
+        end
+        if not vim.tbl_contains(group, false) then
+          vim.list_extend(cmd, group)
+        end
+      else
+        local value = expand(arg)
+        if value then
+          table.insert(cmd, value)
+        end
+      end
+    end
+    vim.list_extend(cmd, opts.args or {})
+  end
+  return cmd
+end
+
+return M
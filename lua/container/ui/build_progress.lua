@@ -0,0 +1,365 @@
+-- lua/container/ui/build_progress.lua
+-- Floating window following an image build
+-- Build output lines are parsed into steps (BuildKit `--progress=plain`, the classic builder's
+-- "Step n/m" and Podman's "STEP n/m") and rendered as a step list with elapsed times above the
+-- full output. The window is a control surface: q hides it while the build keeps running, <C-c> cancels the start
+-- (see cancel() in init.lua), after which the window shows that the build was cancelled.
+
+local M = {}
+
+local BUFFER_NAME = 'build'
+
+-- Lines of output kept for the window
+local MAX_OUTPUT_LINES = 2000
+
+local icons = {
+  running = '●',
+  done = '✓',
+  cached = '⊘',
+  error = '✗',
+  cancelled = '■',
+}
+
+-- Milliseconds a finished (not failed) build stays shown before the window closes
+local CLOSE_DELAY = 1500
+
+local build = nil
+local win = nil
+local render_pending = false
+
+local function now()
+  return (vim.uv or vim.loop).hrtime() / 1e9
+end
+
+-- Parse a line of build output
+-- @return table: { kind = 'step'|'done'|'cached'|'error'|'output', id, name, duration, text }
+function M.parse_line(line)
+  -- BuildKit plain progress: "#5 [2/4] RUN make", "#5 DONE 1.2s", "#5 CACHED", "#5 ERROR: ..."
+  local id, rest = line:match('^#(%d+) (.*)$')
+  -- #0 only reports the builder instance
+  if id and id ~= '0' then
+    local duration = rest:match('^DONE ([%d%.]+)s')
+    if duration then
+      return { kind = 'done', id = id, duration = tonumber(duration) }
+    end
+    if rest == 'CACHED' then
+      return { kind = 'cached', id = id }
+    end
+    local message = rest:match('^ERROR:? ?(.*)$') or (rest == 'CANCELED' and rest or nil)
+    if message then
+      return { kind = 'error', id = id, text = message }
+    end
+    -- Output lines start with a timestamp; anything else opens a step
+    if rest:match('^%d+%.%d+ ') then
+      return { kind = 'output', id = id, text = rest:gsub('^%d+%.%d+ ', '') }
+    end
+    return { kind = 'step', id = id, name = rest }
+  end
+
+  -- Classic builder and Podman: "Step 2/4 : RUN make", "STEP 2/4: RUN make"
+  local number, name = line:match('^[Ss][Tt][Ee][Pp] (%d+/%d+) ?: (.*)$')
+  if number then
+    return { kind = 'step', id = 'step-' .. number, name = string.format('[%s] %s', number, name) }
+  end
+
+  return { kind = 'output', text = line }
+end
+
+local function get_float_config()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  local float = ok and plugin_config and plugin_config.terminal and plugin_config.terminal.float or {}
+  local width = math.floor(vim.o.columns * (float.width or 0.8))
+  local height = math.floor(vim.o.lines * (float.height or 0.6))
+  return {
+    relative = 'editor',
+    width = width,
+    height = height,
+    row = math.floor((vim.o.lines - height) / 2),
+    col = math.floor((vim.o.columns - width) / 2),
+    style = 'minimal',
+    border = float.border or 'rounded',
+  }
+end
+
+local function format_duration(seconds)
+  if seconds >= 60 then
+    return string.format('%dm%02ds', math.floor(seconds / 60), math.floor(seconds % 60))
+  end
+  return string.format('%.1fs', seconds)
+end
+
+-- Title shown in the window border
+local function title()
+  local elapsed = format_duration((build.finished_at or now()) - build.started_at)
+  if not build.finished_at then
+    return string.format(' %s (%s) ', build.title, elapsed)
+  end
+  if build.cancelled then
+    return string.format(' %s %s cancelled (%s) ', icons.cancelled, build.title, elapsed)
+  end
+  return string.format(' %s %s (%s) ', build.success and icons.done or icons.error, build.title, elapsed)
+end
+
+-- Keys shown at the bottom of the window (Neovim 0.10+)
+local function footer()
+  if build.finished_at then
+    return ' q close '
+  end
+  return ' q hide · <C-c> cancel '
+end
+
+-- Lines rendered into the buffer (step list, separator, output)
+-- @return table, number|nil: lines and the line of the first error
+function M.render_lines()
+  local lines = {}
+  for _, step in ipairs(build.steps) do
+    local elapsed = step.duration or ((step.finished_at or now()) - step.started_at)
+    local suffix = step.status == 'cached' and 'cached' or format_duration(elapsed)
+    table.insert(lines, string.format('%s %s  %s', icons[step.status], step.name, suffix))
+  end
+  if #lines > 0 then
+    table.insert(lines, string.rep('─', 40))
+  end
+
+  local error_line = nil
+  for _, line in ipairs(build.output) do
+    table.insert(lines, line)
+    if not error_line and line:match('ERROR') then
+      error_line = #lines
+    end
+  end
+  return lines, error_line
+end
+
+local function window_valid()
+  return win ~= nil and vim.api.nvim_win_is_valid(win)
+end
+
+local function render()
+  render_pending = false
+  if not build then
+    return
+  end
+
+  local output = require('container.ui.output')
+  local lines, error_line = M.render_lines()
+  -- Keep following the output unless the cursor was moved away from the last line
+  local follow = not window_valid() or vim.api.nvim_win_get_cursor(win)[1] >= (build.rendered_lines or 0)
+  output.set_lines(BUFFER_NAME, lines)
+  build.rendered_lines = #lines
+
+  if not window_valid() then
+    return
+  end
+  if vim.fn.has('nvim-0.10') == 1 then
+    vim.api.nvim_win_set_config(win, {
+      title = title(),
+      title_pos = 'center',
+      footer = footer(),
+      footer_pos = 'center',
+    })
+  elseif vim.fn.has('nvim-0.9') == 1 then
+    vim.api.nvim_win_set_config(win, { title = title(), title_pos = 'center' })
+  end
+  -- Show the error once the build failed
+  if build.finished_at and not build.success and not build.cancelled and error_line then
+    vim.api.nvim_win_set_cursor(win, { error_line, 0 })
+  elseif follow and #lines > 0 then
+    vim.api.nvim_win_set_cursor(win, { #lines, 0 })
+  end
+end
+
+local function schedule_render()
+  if render_pending then
+    return
+  end
+  render_pending = true
+  vim.defer_fn(render, 100)
+end
+
+-- Check whether builds are shown in the window (ui.build_window)
+function M.enabled()
+  local ok, plugin_config = pcall(function()
+    return require('container.config').get()
+  end)
+  local ui = ok and plugin_config and plugin_config.ui or {}
+  -- No window in headless sessions
+  local has_ui, uis = pcall(vim.api.nvim_list_uis)
+  return ui.build_window ~= false and has_ui and #uis > 0
+end
+
+-- Open the window
+-- @param opts table|nil: { focus = boolean } moves the cursor into the window (default: true)
+function M.open(opts)
+  opts = opts or {}
+  local focus = opts.focus ~= false
+  if not build then
+    return
+  end
+  if window_valid() then
+    if focus then
+      vim.api.nvim_set_current_win(win)
+    end
+    return
+  end
+
+  local output = require('container.ui.output')
+  local buf = output.get_buffer(BUFFER_NAME)
+  local float_config = get_float_config()
+  if vim.fn.has('nvim-0.9') == 1 then
+    float_config.title = title()
+    float_config.title_pos = 'center'
+  end
+  if vim.fn.has('nvim-0.10') == 1 then
+    float_config.footer = footer()
+    float_config.footer_pos = 'center'
+  end
+  win = vim.api.nvim_open_win(buf, focus, float_config)
+  vim.wo[win].wrap = false
+
+  for _, key in ipairs({ 'q', '<Esc>' }) do
+    vim.keymap.set('n', key, M.close, { buffer = buf, nowait = true, desc = 'Hide build window' })
+  end
+  vim.keymap.set('n', '<C-c>', M.cancel, { buffer = buf, nowait = true, desc = 'Cancel the build' })
+  render()
+end
+
+-- Cancel the start or build followed by the window (<C-c> in the window)
+function M.cancel()
+  if not build or build.finished_at then
+    require('container.utils.notify').status('The build has already finished', 'warn')
+    return false
+  end
+  return require('container').cancel()
+end
+
+-- Close the window; the build keeps running
+function M.close()
+  if window_valid() then
+    pcall(vim.api.nvim_win_close, win, true)
+  end
+  win = nil
+end
+
+-- Begin following a build
+function M.start(build_title)
+  build = {
+    title = build_title or 'Building image',
+    started_at = now(),
+    steps = {},
+    steps_by_id = {},
+    output = {},
+  }
+  require('container.ui.output').clear(BUFFER_NAME)
+end
+
+local function finish_running_steps(at)
+  for _, step in ipairs(build.steps) do
+    if step.status == 'running' and step.id:match('^step%-') then
+      step.status = 'done'
+      step.finished_at = at
+    end
+  end
+end
+
+-- Feed a line of build output; the window opens with the first line
+-- Lines of a killed job arriving after the build was cancelled are dropped.
+function M.handle_line(line)
+  if not build or build.cancelled then
+    return
+  end
+
+  local parsed = M.parse_line(line)
+  local step = parsed.id and build.steps_by_id[parsed.id]
+
+  if parsed.kind == 'step' and not step then
+    -- Classic builder steps end when the next one begins
+    if parsed.id:match('^step%-') then
+      finish_running_steps(now())
+    end
+    step = { id = parsed.id, name = parsed.name, status = 'running', started_at = now() }
+    build.steps_by_id[parsed.id] = step
+    table.insert(build.steps, step)
+  elseif step and parsed.kind == 'done' then
+    step.status = 'done'
+    step.duration = parsed.duration
+  elseif step and parsed.kind == 'cached' then
+    step.status = 'cached'
+  elseif step and parsed.kind == 'error' then
+    step.status = 'error'
+    step.finished_at = now()
+  end
+
+  table.insert(build.output, line)
+  if #build.output > MAX_OUTPUT_LINES then
+    table.remove(build.output, 1)
+  end
+
+  if not build.opened then
+    build.opened = true
+    M.open({ focus = false })
+  end
+  schedule_render()
+end
+
+-- Finish the build
+-- A successful build closes the window; a failed one keeps it open (reopening it if it was
+-- closed) with the cursor on the first error.
+function M.finish(success)
+  if not build or build.cancelled then
+    return
+  end
+  build.success = success
+  build.finished_at = now()
+  for _, step in ipairs(build.steps) do
+    if step.status == 'running' then
+      step.status = success and 'done' or 'error'
+      step.finished_at = build.finished_at
+    end
+  end
+
+  if success then
+    render()
+    local finished = build
+    vim.defer_fn(function()
+      if build == finished then
+        M.close()
+      end
+    end, CLOSE_DELAY)
+  else
+    build.opened = true
+    M.open()
+    render()
+  end
+end
+
+-- Mark the build as cancelled once cancel() in init.lua has stopped its jobs and cleaned up
+-- Running steps are shown as cancelled and the message ends the output; the window closes shortly after.
+-- @param message string|nil: e.g. 'Image build cancelled'
+function M.mark_cancelled(message)
+  if not build or build.finished_at then
+    return
+  end
+  build.cancelled = true
+  build.success = false
+  build.finished_at = now()
+  for _, step in ipairs(build.steps) do
+    if step.status == 'running' then
+      step.status = 'cancelled'
+      step.finished_at = build.finished_at
+    end
+  end
+  vim.list_extend(build.output, { '', string.format('%s %s', icons.cancelled, message or 'Build cancelled') })
+  render()
+
+  local cancelled = build
+  vim.defer_fn(function()
+    if build == cancelled then
+      M.close()
+    end
+  end, CLOSE_DELAY * 2)
+end
+
+return M
@@ -0,0 +1,118 @@
+-- lua/container/ui/output.lua
+-- Named scratch buffers for streaming command output
+
+local M = {}
+
+-- name -> buffer number
+local buffers = {}
+
+-- Get (or create) the output buffer for the given name
+function M.get_buffer(name)
+  local buf = buffers[name]
+  if buf and vim.api.nvim_buf_is_valid(buf) then
+    return buf
+  end
+
+  buf = vim.api.nvim_create_buf(false, true)
+  vim.api.nvim_buf_set_name(buf, 'container://' .. name)
+  vim.bo[buf].buftype = 'nofile'
+  vim.bo[buf].bufhidden = 'hide'
+  vim.bo[buf].swapfile = false
+  vim.bo[buf].filetype = 'container-output'
+  vim.bo[buf].modifiable = false
+
+  buffers[name] = buf
+  return buf
+end
+
+-- Check whether an output buffer exists for the given name
+function M.exists(name)
+  return buffers[name] ~= nil and vim.api.nvim_buf_is_valid(buffers[name])
+end
+
+-- Replace buffer contents
+function M.set_lines(name, lines)
+  local buf = M.get_buffer(name)
+  vim.bo[buf].modifiable = true
+  vim.api.nvim_buf_set_lines(buf, 0, -1, false, lines)
+  vim.bo[buf].modifiable = false
+end
+
+-- Clear buffer contents
+function M.clear(name)
+  M.set_lines(name, {})
+end
+
+-- Append lines and keep windows showing the buffer scrolled to the bottom
+-- Windows whose cursor was moved off the last line stay where they are until it is moved back.
+function M.append(name, lines)
+  if type(lines) == 'string' then
+    lines = vim.split(lines, '\n', { plain = true })
+  end
+
+  local buf = M.get_buffer(name)
+  local line_count = vim.api.nvim_buf_line_count(buf)
+  local following = {}
+  for _, win in ipairs(vim.fn.win_findbuf(buf)) do
+    if vim.api.nvim_win_get_cursor(win)[1] >= line_count then
+      table.insert(following, win)
+    end
+  end
+
+  vim.bo[buf].modifiable = true
+  local first_line = vim.api.nvim_buf_get_lines(buf, 0, 1, false)[1]
+  if line_count == 1 and first_line == '' then
+    vim.api.nvim_buf_set_lines(buf, 0, -1, false, lines)
+  else
+    vim.api.nvim_buf_set_lines(buf, -1, -1, false, lines)
+  end
+  vim.bo[buf].modifiable = false
+
+  local last = vim.api.nvim_buf_line_count(buf)
+  for _, win in ipairs(following) do
+    vim.api.nvim_win_set_cursor(win, { last, 0 })
+  end
+end
+
+-- Show the buffer in a window (reuses an existing window showing it)
+-- opts.split: command used to open the window (default: 'botright 12split')
+-- opts.focus: move the cursor into the window (default: false)
+function M.open(name, opts)
+  opts = opts or {}
+  local buf = M.get_buffer(name)
+
+  local wins = vim.fn.win_findbuf(buf)
+  if #wins > 0 then
+    if opts.focus then
+      vim.api.nvim_set_current_win(wins[1])
+    end
+    return buf, wins[1]
+  end
+
+  local current_win = vim.api.nvim_get_current_win()
+  vim.cmd(opts.split or 'botright 12split')
+  local win = vim.api.nvim_get_current_win()
+  vim.api.nvim_win_set_buf(win, buf)
+  vim.wo[win].number = false
+  vim.wo[win].relativenumber = false
+  vim.wo[win].wrap = false
+
+  if not opts.focus and vim.api.nvim_win_is_valid(current_win) then
+    vim.api.nvim_set_current_win(current_win)
+  end
+
+  return buf, win
+end
+
+-- Close windows showing the buffer
+function M.close(name)
+  local buf = buffers[name]
+  if not buf or not vim.api.nvim_buf_is_valid(buf) then
+    return
+  end
+  for _, win in ipairs(vim.fn.win_findbuf(buf)) do
+    pcall(vim.api.nvim_win_close, win, true)
+  end
+end
+
+return M
@@ -80,6 +80,39 @@ function M.containers(opts)
   end
 end
 
+-- Image picker
+-- Inserts the selected image reference at the cursor
+function M.images(opts)
+  opts = opts or {}
+  local picker = get_available_picker()
+
+  log.debug('Using picker: %s for images', picker)
+
+  if picker == 'telescope' then
+    local pickers = require('container.ui.telescope.pickers')
+    return pickers.images(opts)
+  else
+    -- vim.ui.select fallback (fzf-lua has no image picker)
+    local images = require('container.docker').list_images()
+
+    if #images == 0 then
+      notify.ui('No images found')
+      return
+    end
+
+    vim.ui.select(images, {
+      prompt = 'Select Image:',
+      format_item = function(image)
+        return string.format('%s (%s, %s)', image.name, image.size, image.created)
+      end,
+    }, function(choice)
+      if choice then
+        vim.api.nvim_put({ choice.name }, 'c', true, true)
+      end
+    end)
+  end
+end
+
 -- Terminal session picker
 function M.sessions(opts)
   opts = opts or {}
@@ -0,0 +1,230 @@
+-- lua/container/ui/progress.lua
+-- Progress of long operations (image builds, pulls, test runs) reported to a pluggable sink
+-- An operation begins, reports stages and ends; every event carries the token of its operation. ui.progress chooses
+-- the sink: 'notify' keeps one vim.notify message per operation (updated in place by nvim-notify and snacks.nvim),
+-- 'fidget' shows it with fidget.nvim's progress API, 'none' only notifies the results and a function receives the
+-- events themselves.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Minimum milliseconds between two reports shown by vim.notify
+M.NOTIFY_INTERVAL = 1000
+
+-- Running operations by token
+local operations = {}
+local last_token = 0
+
+local function now_ms()
+  return (vim.uv or vim.loop).now()
+end
+
+-- Text of an event: "Title: message (40%)"
+-- @param event table
+-- @return string
+function M.format(event)
+  local text = event.title
+  if event.message and event.message ~= '' then
+    text = text .. ': ' .. event.message
+  end
+  if event.percentage then
+    text = string.format('%s (%d%%)', text, event.percentage)
+  end
+  return text
+end
+
+local sinks = {}
+
+-- One message per operation; nvim-notify replaces the record, snacks.nvim the id
+function sinks.notify(event, operation)
+  local notify = require('container.utils.notify')
+  if not notify.enabled(event.level == vim.log.levels.ERROR and 'critical' or 'progress') then
+    return
+  end
+
+  local now = now_ms()
+  if event.kind == 'report' and operation.notified_at and now - operation.notified_at < M.NOTIFY_INTERVAL then
+    return
+  end
+  operation.notified_at = now
+
+  local ok, record = pcall(vim.notify, M.format(event), event.level, {
+    title = 'Container',
+    id = 'container-progress-' .. event.token,
+    replace = operation.record,
+    -- Running operations stay on screen; the final message is dismissed after the usual timeout
+    timeout = event.kind ~= 'end' and false or nil,
+    hide_from_history = event.kind ~= 'end',
+  })
+  operation.record = ok and type(record) == 'table' and record or nil
+end
+
+-- fidget.nvim progress handles; without fidget the events go to vim.notify
+function sinks.fidget(event, operation)
+  local ok, fidget_progress = pcall(require, 'fidget.progress')
+  if not ok then
+    sinks.notify(event, operation)
+    return
+  end
+
+  if event.kind == 'begin' then
+    operation.handle = fidget_progress.handle.create({
+      title = event.title,
+      message = event.message,
+      percentage = event.percentage,
+      lsp_client = { name = 'container.nvim' },
+    })
+  elseif operation.handle then
+    operation.handle:report({ message = event.message, percentage = event.percentage })
+    if event.kind == 'end' then
+      if event.success then
+        operation.handle:finish()
+      else
+        operation.handle:cancel()
+      end
+    end
+  end
+
+  -- fidget only shows progress, failures are reported as errors as well
+  if event.kind == 'end' and event.level == vim.log.levels.ERROR then
+    vim.notify(M.format(event), event.level, { title = 'Container' })
+  end
+end
+
+-- The sink chosen by ui.progress
+local function get_sink()
+  local ok, plugin_config = pcall(require, 'container.config')
+  local choice = ok and plugin_config.get_value('ui.progress')
+  if type(choice) == 'function' then
+    return choice
+  end
+  if choice == 'none' then
+    return nil
+  end
+  return sinks[choice] or sinks.notify
+end
+
+-- Hand an event to the sink; errors of the sink never reach the operation
+local function emit(operation, kind, extra)
+  local sink = get_sink()
+  if not sink then
+    if kind ~= 'end' then
+      return
+    end
+    sink = sinks.notify
+  end
+  local event = vim.tbl_extend('force', {
+    token = operation.token,
+    kind = kind,
+    title = operation.title,
+    message = operation.message,
+    percentage = operation.percentage,
+    level = vim.log.levels.INFO,
+  }, extra or {})
+  local ok, err = pcall(sink, event, operation)
+  if not ok then
+    log.warn('Progress sink failed: %s', err)
+  end
+end
+
+-- Begin an operation
+-- @param title string: e.g. 'Building devcontainer'
+-- @param message string|nil: first stage
+-- @return number: token of the operation
+function M.begin(title, message)
+  last_token = last_token + 1
+  local operation = { token = last_token, title = title, message = message }
+  operations[operation.token] = operation
+  emit(operation, 'begin')
+  return operation.token
+end
+
+-- Report a stage of an operation
+-- @param token number
+-- @param message string|nil: stage; nil keeps the previous one
+-- @param percentage number|nil: 0-100; nil keeps the previous one
+function M.report(token, message, percentage)
+  local operation = operations[token]
+  if not operation then
+    return
+  end
+  operation.message = message or operation.message
+  operation.percentage = percentage or operation.percentage
+  emit(operation, 'report')
+end
+
+-- End an operation
+-- A failed operation is reported with vim.log.levels.ERROR.
+-- @param token number
+-- @param success boolean
+-- @param message string|nil: result, e.g. the error
+function M.finish(token, success, message)
+  local operation = operations[token]
+  if not operation then
+    return
+  end
+  operations[token] = nil
+  operation.message = message or operation.message
+  if success then
+    operation.percentage = 100
+  end
+  emit(operation, 'end', { success = success, level = success and vim.log.levels.INFO or vim.log.levels.ERROR })
+end
+
+-- End an operation stopped by the user (reported as a warning)
+-- @param token number
+function M.cancel(token)
+  local operation = operations[token]
+  if not operation then
+    return
+  end
+  operations[token] = nil
+  operation.message = 'Cancelled'
+  emit(operation, 'end', { success = false, level = vim.log.levels.WARN })
+end
+
+-- Stage of an image build output line
+-- BuildKit ("#5 [builder 2/4] RUN make"), the classic builder and Podman ("Step 2/4 : RUN make") number their steps.
+-- @param line string
+-- @return string|nil, number|nil: step and percentage of steps done; nil for other lines
+function M.build_stage(line)
+  local parsed = require('container.ui.build_progress').parse_line(line)
+  if parsed.kind ~= 'step' then
+    return nil
+  end
+  local number, total = parsed.name:match('^%[[^%]]-(%d+)/(%d+)%]')
+  number, total = tonumber(number), tonumber(total)
+  if not number or total == 0 then
+    return parsed.name
+  end
+  return parsed.name, math.floor((number - 1) * 100 / total)
+end
+
+-- Stage of an image pull output line
+-- Layers are counted as they appear ("Pulling fs layer", "Waiting") and finish ("Pull complete", "Already exists").
+-- @param line string
+-- @param layers table: layer state kept by the caller across the lines of one pull
+-- @return string|nil, number|nil: stage and percentage of layers done; nil for other lines
+function M.pull_stage(line, layers)
+  line = vim.trim((line:gsub('^%s*%[std%a+%] ', '')))
+  local id, status = line:match('^(%x+): (.+)$')
+  -- "<tag>: Pulling from <repository>" names the image, not a layer
+  if not id or status:match('^Pulling from ') then
+    return nil
+  end
+  layers.ids = layers.ids or {}
+  layers.total = layers.total or 0
+  layers.done = layers.done or 0
+  if layers.ids[id] == nil then
+    layers.ids[id] = false
+    layers.total = layers.total + 1
+  end
+  if not layers.ids[id] and (status == 'Pull complete' or status == 'Already exists') then
+    layers.ids[id] = true
+    layers.done = layers.done + 1
+  end
+  return string.format('%d/%d layers', layers.done, layers.total), math.floor(layers.done * 100 / layers.total)
+end
+
+return M
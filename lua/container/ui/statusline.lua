@@ -51,6 +51,47 @@ local function format_status(template, icon, name, status, labels)
   return formatted
 end
 
+-- Render a status table from require('container').status() as statusline text
+-- @param status table: { state, name, service, ... }
+-- @return string: empty when there is no container
+function M.render(status)
+  if not status or status.state == 'none' then
+    return ''
+  end
+
+  local cfg = config.get() or {}
+  local ui = cfg.ui or {}
+  local icons = ui.icons or {}
+  local statusline_config = ui.statusline or {}
+  local formats = statusline_config.format or {}
+  local labels = statusline_config.labels or {}
+  local default_format = statusline_config.default_format or '{icon} {name}'
+
+  local icon
+  if status.state == 'running' then
+    icon = icons.running or '🚀'
+  elseif status.state == 'stopped' then
+    icon = icons.stopped or '📦'
+  elseif status.state == 'building' then
+    icon = icons.building or '🔨'
+  else
+    icon = icons.container or '🐳'
+  end
+
+  local name
+  if statusline_config.show_container_name ~= false and status.name then
+    name = status.name
+  else
+    name = labels.container_name or 'DevContainer'
+  end
+  -- Compose devcontainers show the attached service
+  if status.service then
+    name = name .. '/' .. status.service
+  end
+
+  return format_status(formats[status.state] or default_format, icon, name, status.state, labels)
+end
+
 -- Get container status for statusline display
 function M.get_status()
   local cfg = config.get()
@@ -99,41 +140,23 @@ function M.get_status()
   local statusline_config = cfg.ui.statusline or {}
   local formats = statusline_config.format or {}
   local labels = statusline_config.labels or {}
-  local show_container_name = statusline_config.show_container_name ~= false
   local default_format = statusline_config.default_format or '{icon} {name}'
 
   local status_text = ''
-
-  if state.current_container then
-    -- Get container status
-    local status = state.container_status
-    local icon, format_key
-
-    if status == 'running' then
-      icon = icons.running or '🚀'
-      format_key = 'running'
-    elseif status == 'exited' or status == 'stopped' then
-      icon = icons.stopped or '📦'
-      format_key = 'stopped'
-    elseif status == 'created' then
-      icon = icons.building or '🔨'
-      format_key = 'building'
-    else
-      icon = icons.container or '🐳'
-      format_key = 'error'
-    end
-
-    -- Determine container name
-    local container_name
-    if show_container_name and state.current_config and state.current_config.name then
-      container_name = state.current_config.name
-    else
-      container_name = labels.container_name or 'DevContainer'
-    end
-
-    -- Use format template
-    local format_template = formats[format_key] or default_format
-    status_text = format_status(format_template, icon, container_name, status, labels)
+  local lifecycle = devcontainer.status and devcontainer.status() or nil
+
+  if lifecycle and lifecycle.state == 'building' then
+    -- Builds and startup happen before Docker reports a container status
+    status_text = M.render(lifecycle)
+  elseif state.current_container then
+    -- Map the Docker status onto the states used by status()
+    local docker_states = { running = 'running', exited = 'stopped', stopped = 'stopped', created = 'building' }
+    local current_config = state.current_config or {}
+    status_text = M.render({
+      state = docker_states[state.container_status] or 'error',
+      name = current_config.name,
+      service = current_config.service,
+    })
   else
     -- No container - check if devcontainer.json exists
     local devcontainer_available
@@ -290,13 +313,20 @@ function M.setup()
     group = group,
     pattern = {
       'ContainerStarted',
+      'ContainerRestarted',
       'ContainerStopped',
       'ContainerBuilt',
       'ContainerOpened',
       'ContainerClosed',
+      'ContainerStateChanged',
     },
     callback = function()
       M.clear_cache()
+      -- lualine redraws on its own timer, refresh it right away on transitions
+      local ok, lualine = pcall(require, 'lualine')
+      if ok and lualine.refresh then
+        lualine.refresh()
+      end
     end,
   })
 
@@ -45,6 +45,7 @@ function M.register_extension()
     end,
     exports = {
       containers = require('container.ui.telescope.pickers').containers,
+      images = require('container.ui.telescope.pickers').images,
       sessions = require('container.ui.telescope.pickers').sessions,
       ports = require('container.ui.telescope.pickers').ports,
       history = require('container.ui.telescope.pickers').history,
@@ -57,6 +58,10 @@ function M.containers(opts)
   require('telescope').extensions.container.containers(opts)
 end
 
+function M.images(opts)
+  require('telescope').extensions.container.images(opts)
+end
+
 function M.sessions(opts)
   require('telescope').extensions.container.sessions(opts)
 end
@@ -23,6 +23,67 @@ local function create_container_display(container)
   )
 end
 
+-- Number of log lines shown in the container preview
+M.PREVIEW_LOG_LINES = 50
+
+-- Show the devcontainer.json of the container's workspace and its recent logs
+-- Logs are read asynchronously and appended while the entry is still previewed
+local function preview_container(self, container)
+  local lines = {
+    '# Container Information',
+    '',
+    'Name: ' .. container.name,
+    'Status: ' .. container.status,
+    'Image: ' .. container.image,
+    'ID: ' .. (container.id or 'N/A'),
+    'Workspace: ' .. (container.project_path or 'unknown'),
+    '',
+  }
+
+  if container.ports and #container.ports > 0 then
+    table.insert(lines, '## Forwarded Ports')
+    for _, port in ipairs(container.ports) do
+      table.insert(lines, '- ' .. port)
+    end
+    table.insert(lines, '')
+  end
+
+  local config_path = container.project_path
+    and require('container.parser').find_devcontainer_json(container.project_path)
+  local content = config_path and require('container.utils.fs').read_file(config_path)
+  if content then
+    table.insert(lines, '## ' .. config_path)
+    table.insert(lines, '```jsonc')
+    vim.list_extend(lines, vim.split(content, '\n', { trimempty = true }))
+    table.insert(lines, '```')
+    table.insert(lines, '')
+  end
+
+  table.insert(lines, '## Recent Logs')
+  local bufnr = self.state.bufnr
+  vim.api.nvim_buf_set_lines(bufnr, 0, -1, false, lines)
+  vim.bo[bufnr].filetype = 'markdown'
+
+  local preview_id = container.id or container.name
+  vim.b[bufnr].container_preview = preview_id
+  require('container.docker').run_docker_command_async(
+    { 'logs', '--tail', tostring(M.PREVIEW_LOG_LINES), preview_id },
+    {},
+    function(result)
+      vim.schedule(function()
+        if not vim.api.nvim_buf_is_valid(bufnr) or vim.b[bufnr].container_preview ~= preview_id then
+          return
+        end
+        local output = (result.stdout or '') .. (result.stderr or '')
+        local log_lines = { '```' }
+        vim.list_extend(log_lines, vim.split(output, '\n', { trimempty = true }))
+        table.insert(log_lines, '```')
+        vim.api.nvim_buf_set_lines(bufnr, -1, -1, false, log_lines)
+      end)
+    end
+  )
+end
+
 -- Devcontainer picker
 function M.containers(opts)
   opts = opts or {}
@@ -103,21 +164,8 @@ function M.containers(opts)
           local lines = {}
 
           if entry.type == 'container' then
-            local container = entry.value
-            table.insert(lines, '# Container Information')
-            table.insert(lines, '')
-            table.insert(lines, 'Name: ' .. container.name)
-            table.insert(lines, 'Status: ' .. container.status)
-            table.insert(lines, 'Image: ' .. container.image)
-            table.insert(lines, 'ID: ' .. (container.id or 'N/A'))
-            table.insert(lines, '')
-
-            if container.ports and #container.ports > 0 then
-              table.insert(lines, '## Forwarded Ports')
-              for _, port in ipairs(container.ports) do
-                table.insert(lines, '- ' .. port)
-              end
-            end
+            preview_container(self, entry.value)
+            return
           else
             local project = entry.value
             table.insert(lines, '# Project Information')
@@ -186,6 +234,124 @@ function M.containers(opts)
           end
         end)
 
+        -- Attach to a running container
+        map('i', '<C-a>', function()
+          local selection = action_state.get_selected_entry()
+          if not selection or selection.type ~= 'container' then
+            return
+          end
+          actions.close(prompt_bufnr)
+          if selection.value.running then
+            require('container').attach(selection.value.name)
+          else
+            require('container.utils.notify').ui('Container is not running, start it with <CR> first')
+          end
+        end)
+
+        -- Remove a stopped container
+        map('i', '<C-x>', function()
+          local selection = action_state.get_selected_entry()
+          if not selection or selection.type ~= 'container' then
+            return
+          end
+          if selection.value.running then
+            require('container.utils.notify').ui('Stop the container with <C-s> before removing it')
+            return
+          end
+          actions.close(prompt_bufnr)
+          if vim.fn.confirm('Remove container ' .. selection.value.name .. '?', '&Yes\n&No', 2) == 1 then
+            require('container').remove_container(selection.value.name)
+          end
+        end)
+
+        return true
+      end,
+    })
+    :find()
+end
+
+-- Image picker
+-- <CR> inserts the image reference at the cursor (e.g. as "image" of a devcontainer.json), <C-y> copies it
+function M.images(opts)
+  opts = opts or {}
+  local docker = require('container.docker')
+  local images = docker.list_images()
+
+  if #images == 0 then
+    require('container.utils.notify').ui('No images found')
+    return
+  end
+
+  pickers
+    .new(opts, {
+      prompt_title = 'Images',
+      finder = finders.new_table({
+        results = images,
+        entry_maker = function(image)
+          return {
+            value = image,
+            display = string.format('%-50s %-14s %-10s %s', image.name, image.id, image.size, image.created),
+            ordinal = image.name,
+          }
+        end,
+      }),
+      sorter = conf.generic_sorter(opts),
+      selection_strategy = 'reset',
+      initial_mode = 'insert',
+      previewer = previewers.new_buffer_previewer({
+        title = 'Image Info',
+        define_preview = function(self, entry)
+          local image = entry.value
+          local bufnr = self.state.bufnr
+          vim.api.nvim_buf_set_lines(bufnr, 0, -1, false, {
+            '# ' .. image.name,
+            '',
+            'ID: ' .. image.id,
+            'Size: ' .. image.size,
+            'Created: ' .. image.created,
+            '',
+          })
+          vim.bo[bufnr].filetype = 'markdown'
+
+          vim.b[bufnr].container_preview = image.id
+          docker.run_docker_command_async({
+            'image',
+            'inspect',
+            '--format',
+            'Platform: {{.Os}}/{{.Architecture}}\nUser: {{.Config.User}}\nWorkdir: {{.Config.WorkingDir}}'
+              .. '\nCmd: {{json .Config.Cmd}}\nEntrypoint: {{json .Config.Entrypoint}}\nEnv: {{json .Config.Env}}',
+            image.id,
+          }, {}, function(result)
+            vim.schedule(function()
+              if not vim.api.nvim_buf_is_valid(bufnr) or vim.b[bufnr].container_preview ~= image.id then
+                return
+              end
+              if result.success then
+                vim.api.nvim_buf_set_lines(bufnr, -1, -1, false, vim.split(result.stdout, '\n', { trimempty = true }))
+              end
+            end)
+          end)
+        end,
+      }),
+      attach_mappings = function(prompt_bufnr, map)
+        actions.select_default:replace(function()
+          local selection = action_state.get_selected_entry()
+          if not selection then
+            return
+          end
+          actions.close(prompt_bufnr)
+          vim.api.nvim_put({ selection.value.name }, 'c', true, true)
+        end)
+
+        map('i', '<C-y>', function()
+          local selection = action_state.get_selected_entry()
+          if not selection then
+            return
+          end
+          vim.fn.setreg('+', selection.value.name)
+          require('container.utils.notify').status('Copied: ' .. selection.value.name)
+        end)
+
         return true
       end,
     })
@@ -0,0 +1,40 @@
+-- lua/container/utils/lines.lua
+-- Complete lines of streamed job output
+-- Job output arrives in chunks split on newlines whose last element is the start of a line continued by the next
+-- chunk. The handler joins those pieces per stream, so stdout and stderr lines are not glued together.
+
+local M = {}
+
+-- Create an on_stdout/on_stderr handler passing complete lines on
+-- @param on_lines function(lines, stream): called from the job callback with the complete lines of each chunk
+-- @return function, function: the job callback, and remaining() returning the incomplete last lines (on exit)
+function M.handler(on_lines)
+  local partial = {}
+
+  local function on_data(_, data, stream)
+    if not data then
+      return
+    end
+    stream = stream or 'stdout'
+    data[1] = (partial[stream] or '') .. data[1]
+    partial[stream] = table.remove(data)
+    if #data > 0 then
+      on_lines(data, stream)
+    end
+  end
+
+  local function remaining()
+    local lines = {}
+    for _, stream in ipairs({ 'stdout', 'stderr' }) do
+      if partial[stream] and partial[stream] ~= '' then
+        table.insert(lines, partial[stream])
+      end
+      partial[stream] = nil
+    end
+    return lines
+  end
+
+  return on_data, remaining
+end
+
+return M
@@ -66,23 +66,25 @@ local function should_deduplicate(message, category)
   return false
 end
 
+-- Check whether messages of a category are shown (ui.show_notifications and ui.notification_level)
+-- @param category string: 'critical', 'container', 'status', 'progress' or 'debug'
+-- @return boolean
+function M.enabled(category)
+  local category_config = CATEGORIES[category] or CATEGORIES.status
+  return notifications_enabled() and category_config.level <= get_notification_level()
+end
+
 -- Core notification function
 local function notify(message, category, opts)
   opts = opts or {}
 
-  if not notifications_enabled() then
-    return
-  end
-
   local category_config = CATEGORIES[category]
   if not category_config then
     category_config = CATEGORIES.status
   end
 
-  local notification_level = get_notification_level()
-
   -- Check if this category should be shown at current level
-  if category_config.level > notification_level then
+  if not M.enabled(category) then
     return
   end
 
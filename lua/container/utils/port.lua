@@ -25,6 +25,32 @@ local function is_port_available(port)
   return success
 end
 
+-- Check if a port can be bound on the host
+function M.is_port_available(port)
+  return is_port_available(port)
+end
+
+-- Find the first free host port starting at port (auto-increment)
+-- @param port number: preferred host port
+-- @param exclude_ports table|nil: ports that must not be used
+-- @param max_attempts number|nil: number of ports to try (default: 100)
+-- @return number|nil: available port
+function M.find_next_available_port(port, exclude_ports, max_attempts)
+  local exclude_set = {}
+  for _, excluded in ipairs(exclude_ports or {}) do
+    exclude_set[excluded] = true
+  end
+
+  local last = math.min(port + (max_attempts or 100) - 1, 65535)
+  for candidate = port, last do
+    if not exclude_set[candidate] and M.is_port_available(candidate) then
+      return candidate
+    end
+  end
+
+  return nil
+end
+
 -- Find an available port within a specified range
 function M.find_available_port(start_port, end_port, exclude_ports)
   start_port = start_port or DEFAULT_DYNAMIC_PORT_START
@@ -0,0 +1,183 @@
+-- lua/container/watch.lua
+-- Watch the files a container is built from (watch_config)
+-- While a configuration is open, its devcontainer.json (and the files it extends), Dockerfile, compose files, feature
+-- lockfile and local features are watched. Saving one of them in Neovim reports that the container needs a rebuild;
+-- watch_config.action = 'prompt' asks whether to run :ContainerRebuild and 'rebuild' runs it right away. Saves within
+-- watch_config.debounce milliseconds are reported together.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Watched paths by devcontainer.json: { files = { [path] = true }, dirs = { path, ... } }
+local watched = {}
+
+-- Changed paths waiting for the debounce timer, by devcontainer.json
+local pending = {}
+local timer = nil
+
+local function setting(key, default)
+  local ok, plugin_config = pcall(require, 'container.config')
+  local value = ok and plugin_config.get_value('watch_config.' .. key)
+  if value == nil then
+    return default
+  end
+  return value
+end
+
+-- Files and folders a configuration is built from
+-- Local features ("./my-feature") are folders; a save of any file in them counts.
+-- @param config table: normalized configuration
+-- @return table: { files = list of paths, dirs = list of paths }
+function M.watched_paths(config)
+  local files, dirs, seen = {}, {}, {}
+  local function add(list, path)
+    if type(path) == 'string' and path ~= '' and not seen[path] then
+      seen[path] = true
+      table.insert(list, path)
+    end
+  end
+
+  add(files, config.config_file)
+  -- Base configurations named by "extends"
+  for _, sources in pairs(config.key_sources or {}) do
+    for _, file in ipairs(sources) do
+      add(files, file)
+    end
+  end
+  add(files, config.dockerfile)
+  for _, compose_file in ipairs(config.compose_files or {}) do
+    add(files, compose_file)
+  end
+  local features = require('container.features').normalize(config.features, config.devcontainer_folder)
+  for _, feature in ipairs(features) do
+    if feature.kind == 'local' then
+      add(dirs, feature.path)
+    end
+  end
+  if #features > 0 and config.config_file then
+    add(files, require('container.features_lock').path(config.config_file))
+  end
+  table.sort(files)
+  return { files = files, dirs = dirs }
+end
+
+-- Configurations a saved file belongs to
+-- @param path string: absolute host path
+-- @return table: devcontainer.json paths
+function M.match(path)
+  local configs = {}
+  for config_file, paths in pairs(watched) do
+    local found = paths.files[path] == true
+    for _, dir in ipairs(paths.dirs) do
+      if path:sub(1, #dir + 1) == dir .. '/' then
+        found = true
+      end
+    end
+    if found then
+      table.insert(configs, config_file)
+    end
+  end
+  table.sort(configs)
+  return configs
+end
+
+-- Act on the changed files of a configuration (watch_config.action)
+-- Only the configuration of the active workspace with a container is acted on: a container started later is
+-- built from the changed files anyway.
+-- @param config_file string
+-- @param paths table: changed host paths
+function M.apply(config_file, paths)
+  local container = require('container')
+  local state = container.get_state()
+  local current = state.current_config
+  if not state.current_container or not current or current.config_file ~= config_file then
+    log.debug('Watch: %s changed, no container of %s to rebuild', table.concat(paths, ', '), config_file)
+    return
+  end
+
+  local names = {}
+  for _, path in ipairs(paths) do
+    table.insert(names, vim.fn.fnamemodify(path, ':t'))
+  end
+  local message = table.concat(names, ', ') .. ' changed: the container needs a rebuild'
+  local action = setting('action', 'notify')
+  log.info('Watch: %s (%s)', message, action)
+
+  local notify = require('container.utils.notify')
+  if action == 'rebuild' then
+    notify.status(message .. ', rebuilding')
+    container.rebuild_container()
+  elseif action == 'prompt' then
+    vim.ui.select({ 'Rebuild now', 'Later' }, { prompt = message }, function(choice)
+      if choice == 'Rebuild now' then
+        container.rebuild_container()
+      end
+    end)
+  else
+    notify.warn(message .. ' (run :ContainerRebuild)')
+  end
+end
+
+-- Record a saved file; saves within watch_config.debounce milliseconds are applied together
+-- @param path string: absolute host path
+function M.changed(path)
+  local configs = M.match(path)
+  if #configs == 0 then
+    return
+  end
+  for _, config_file in ipairs(configs) do
+    pending[config_file] = pending[config_file] or {}
+    if not vim.tbl_contains(pending[config_file], path) then
+      table.insert(pending[config_file], path)
+    end
+  end
+  timer = timer or (vim.uv or vim.loop).new_timer()
+  timer:stop()
+  timer:start(
+    setting('debounce', 500),
+    0,
+    vim.schedule_wrap(function()
+      local changes = pending
+      pending = {}
+      for config_file, paths in pairs(changes) do
+        M.apply(config_file, paths)
+      end
+    end)
+  )
+end
+
+-- Watch the files of a configuration, replacing the files watched for it before
+-- @param config table: normalized configuration
+function M.watch(config)
+  if not config or not config.config_file then
+    return
+  end
+  local paths = M.watched_paths(config)
+  local files = {}
+  for _, file in ipairs(paths.files) do
+    files[file] = true
+  end
+  watched[config.config_file] = { files = files, dirs = paths.dirs }
+  log.debug('Watch: %s', table.concat(vim.list_extend(vim.list_extend({}, paths.files), paths.dirs), ', '))
+
+  local group = vim.api.nvim_create_augroup('ContainerWatchConfig', { clear = true })
+  vim.api.nvim_create_autocmd('BufWritePost', {
+    group = group,
+    callback = function(args)
+      M.changed(vim.fn.fnamemodify(args.file, ':p'))
+    end,
+  })
+end
+
+-- Stop watching
+function M.unwatch()
+  pcall(vim.api.nvim_del_augroup_by_name, 'ContainerWatchConfig')
+  watched = {}
+  pending = {}
+  if timer then
+    timer:stop()
+  end
+end
+
+return M
@@ -0,0 +1,125 @@
+-- lua/container/workspace_folders.lua
+-- Host folders mounted into the container besides the workspace (the workspace_folders plugin setting)
+-- A monorepo split across host directories lists its other folders as { host, container } pairs. They are bind
+-- mounted like additional_mounts, and paths are translated with the pair of the folder they are in: the LSP path
+-- mappings, :ContainerTest and terminals (which also take a folder by name) work across all of them.
+
+local M = {}
+
+local log = require('container.utils.log')
+
+-- Remove trailing slashes (except for the root directory)
+local function strip_trailing_slash(path)
+  if #path > 1 then
+    path = path:gsub('/+$', '')
+  end
+  return path
+end
+
+-- Check whether path is prefix or lies below prefix (segment boundary aware)
+local function has_path_prefix(path, prefix)
+  if path:sub(1, #prefix) ~= prefix then
+    return false
+  end
+  local next_char = path:sub(#prefix + 1, #prefix + 1)
+  return next_char == '' or next_char == '/' or prefix == '/'
+end
+
+-- Folders of the plugin configuration with expanded host paths
+-- The name defaults to the last component of the host folder.
+-- @param folders table|nil: list of { host, container, name, readonly } (the workspace_folders setting by default)
+-- @return table: list of { name, host, container, readonly }
+function M.configured(folders)
+  if folders == nil then
+    local ok, plugin_config = pcall(require, 'container.config')
+    folders = ok and plugin_config.get_value and plugin_config.get_value('workspace_folders') or {}
+  end
+  local parser = require('container.parser')
+  local resolved = {}
+  for _, folder in ipairs(folders) do
+    if type(folder.host) == 'string' and type(folder.container) == 'string' then
+      local host = strip_trailing_slash(parser.expand_host_path(folder.host))
+      table.insert(resolved, {
+        name = folder.name or host:match('([^/]+)$') or host,
+        host = host,
+        container = strip_trailing_slash(folder.container),
+        readonly = folder.readonly == true,
+      })
+    end
+  end
+  return resolved
+end
+
+-- Mounts of the configured folders, in the form of additional_mounts
+-- @param additional_mounts table|nil: additional_mounts setting the folders are appended to
+-- @param folders table|nil: the workspace_folders setting
+-- @return table: list of { source, target, readonly }
+function M.mounts(additional_mounts, folders)
+  local mounts = {}
+  for _, mount in ipairs(additional_mounts or {}) do
+    table.insert(mounts, mount)
+  end
+  for _, folder in ipairs(M.configured(folders)) do
+    table.insert(mounts, { source = folder.host, target = folder.container, readonly = folder.readonly })
+  end
+  return mounts
+end
+
+-- All workspace folders of a configuration: the workspace itself (primary = true), then the configured folders
+-- @param config table|nil: normalized configuration
+-- @return table: list of { name, host, container, primary }
+function M.list(config)
+  local host_root, container_root = require('container.parser').workspace_roots(config)
+  host_root = strip_trailing_slash(host_root)
+  local folders = {
+    { name = host_root:match('([^/]+)$') or host_root, host = host_root, container = container_root, primary = true },
+  }
+  for _, folder in ipairs(M.configured()) do
+    table.insert(folders, folder)
+  end
+  return folders
+end
+
+-- Workspace folder containing a host path, the deepest one when folders are nested
+-- @param config table|nil: normalized configuration
+-- @param path string|nil: absolute host path
+-- @return table: folder from list(); the workspace when no folder contains the path
+function M.for_path(config, path)
+  local folders = M.list(config)
+  local best
+  for _, folder in ipairs(folders) do
+    if path and has_path_prefix(path, folder.host) and (not best or #folder.host > #best.host) then
+      best = folder
+    end
+  end
+  return best or folders[1]
+end
+
+-- Workspace folder by name, host path or container path
+-- @param config table|nil: normalized configuration
+-- @param name string
+-- @return table|nil: folder from list()
+function M.find(config, name)
+  local path = strip_trailing_slash(name)
+  for _, folder in ipairs(M.list(config)) do
+    if folder.name == name or folder.host == path or folder.container == path then
+      return folder
+    end
+  end
+  log.debug('Workspace folders: no folder %s', name)
+  return nil
+end
+
+-- Names of the workspace folders, for command completion
+-- @return table
+function M.names()
+  local ok, container = pcall(require, 'container')
+  local config = ok and container.get_state and container.get_state().current_config or nil
+  local names = {}
+  for _, folder in ipairs(M.list(config)) do
+    table.insert(names, folder.name)
+  end
+  return names
+end
+
+return M
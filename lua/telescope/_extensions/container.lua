@@ -15,6 +15,7 @@ return telescope.register_extension({
   exports = {
     container = pickers.containers,
     containers = pickers.containers,
+    images = pickers.images,
     sessions = pickers.sessions,
     ports = pickers.ports,
     history = pickers.history,
@@ -14,8 +14,8 @@ local function create_commands()
     require('container').open(args.args ~= '' and args.args or nil)
   end, {
     nargs = '?',
-    desc = 'Open container from specified path or current directory',
-    complete = 'dir',
+    desc = 'Open container from specified path, devcontainer.json or current directory',
+    complete = 'file',
   })
 
   vim.api.nvim_create_user_command('ContainerBuild', function()
@@ -24,10 +24,58 @@ local function create_commands()
     desc = 'Build container image',
   })
 
-  vim.api.nvim_create_user_command('ContainerStart', function()
-    require('container').start()
+  vim.api.nvim_create_user_command('ContainerStart', function(args)
+    if args.args ~= '' and args.args ~= '--dry-run' then
+      require('container.utils.notify').error('Unknown option: ' .. args.args)
+      return
+    end
+    require('container').start({ force_rebuild = args.bang, dry_run = args.args == '--dry-run' })
+  end, {
+    bang = true,
+    nargs = '?',
+    desc = 'Start container (! to rebuild the image ignoring the cache, --dry-run to only show the commands)',
+    complete = function()
+      return { '--dry-run' }
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerStartImage', function(args)
+    require('container').start_image(args.fargs[1], { keep = args.bang })
+  end, {
+    bang = true,
+    nargs = 1,
+    desc = 'Start an ad hoc container from an image with the workspace mounted (! to keep it after stop)',
+    complete = function(arg_lead)
+      return require('container.completion').start_image(arg_lead)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerRebuild', function(args)
+    require('container').rebuild_container({ prune = args.bang })
   end, {
-    desc = 'Start container',
+    bang = true,
+    desc = 'Rebuild the image without cache and recreate the container (! to remove the old image)',
+  })
+
+  vim.api.nvim_create_user_command('ContainerCopy', function(args)
+    require('container').copy(args.fargs[1], args.fargs[2])
+  end, {
+    nargs = '+',
+    complete = 'file',
+    desc = 'Copy between host and container (prefix the container side with container:)',
+  })
+
+  vim.api.nvim_create_user_command('ContainerExplore', function(args)
+    require('container.explore').open(args.args)
+  end, {
+    nargs = '?',
+    desc = 'Browse and edit the files of the container (workspace folder by default)',
+  })
+
+  vim.api.nvim_create_user_command('ContainerSyncWorkspace', function()
+    require('container').sync_remote_workspace()
+  end, {
+    desc = 'Copy the workspace into the container (remote Docker hosts)',
   })
 
   vim.api.nvim_create_user_command('ContainerStop', function()
@@ -36,6 +84,12 @@ local function create_commands()
     desc = 'Stop container',
   })
 
+  vim.api.nvim_create_user_command('ContainerCancel', function()
+    require('container').cancel()
+  end, {
+    desc = 'Cancel the container start or image build in progress',
+  })
+
   vim.api.nvim_create_user_command('ContainerKill', function(args)
     if args.bang then
       -- Skip confirmation with :ContainerKill!
@@ -121,9 +175,22 @@ local function create_commands()
   -- Execution and access commands
   vim.api.nvim_create_user_command('ContainerExec', function(args)
     local command_args = vim.split(args.args, ' ', { plain = false, trimempty = true })
+    -- --service=<name> (or --service <name>) runs the command in another compose service
+    local service = command_args[1] and command_args[1]:match('^%-%-service=(.+)$')
+    if service then
+      table.remove(command_args, 1)
+    elseif command_args[1] == '--service' then
+      service = command_args[2]
+      table.remove(command_args, 1)
+      table.remove(command_args, 1)
+    end
     local command = table.concat(command_args, ' ')
+    if command == '' then
+      print('Error: Usage: :ContainerExec [--service=<name>] {command}')
+      return
+    end
 
-    local output, err = require('container').execute(command)
+    local output, err = require('container').execute(command, { service = service })
     if output then
       print(output)
     else
@@ -131,7 +198,52 @@ local function create_commands()
     end
   end, {
     nargs = '+',
-    desc = 'Execute command in container (sync)',
+    desc = 'Execute command in container (sync), or in another compose service with --service=<name>',
+    complete = function(arg_lead, cmd_line)
+      return require('container.completion').exec(arg_lead, cmd_line)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerExecInteractive', function(args)
+    require('container').exec_interactive(args.args)
+  end, {
+    nargs = '+',
+    complete = 'shellcmd',
+    desc = 'Run a full-screen program (htop, lazygit) in container in a terminal',
+  })
+
+  vim.api.nvim_create_user_command('ContainerExecSelection', function(args)
+    require('container').exec_selection({ line1 = args.line1, line2 = args.line2 })
+  end, {
+    range = true,
+    desc = 'Run the selected lines as one shell script in container',
+  })
+
+  vim.api.nvim_create_user_command('ContainerRunFile', function(args)
+    require('container').run_file({ args = args.fargs })
+  end, {
+    nargs = '*',
+    desc = 'Run the current file in container with the command of its filetype',
+  })
+
+  vim.api.nvim_create_user_command('ContainerTask', function(args)
+    require('container').task(args.fargs[1], vim.list_slice(args.fargs, 2))
+  end, {
+    nargs = '*',
+    desc = 'Run a task of the tasks setting in container',
+    complete = function(arg_lead, cmd_line)
+      -- Only the task name is completed
+      if #vim.split(vim.trim(cmd_line), '%s+') > (arg_lead == '' and 1 or 2) then
+        return {}
+      end
+      return require('container.completion').filter(require('container.task').names(), arg_lead)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerFormat', function()
+    require('container.format').format(0)
+  end, {
+    desc = 'Format the current buffer with the formatter in container',
   })
 
   vim.api.nvim_create_user_command('ContainerRun', function(args)
@@ -270,6 +382,10 @@ local function create_commands()
         opts.name = arg:gsub('^%-%-name=', '')
       elseif arg:match('^%-%-shell=') then
         opts.shell = arg:gsub('^%-%-shell=', '')
+      elseif arg:match('^%-%-folder=') then
+        opts.folder = arg:gsub('^%-%-folder=', '')
+      elseif arg:match('^%-%-cwd=') then
+        opts.cwd = arg:gsub('^%-%-cwd=', '')
       elseif arg:match('^%-%-size=') then
         local size = tonumber(arg:gsub('^%-%-size=', ''))
         if size then
@@ -284,6 +400,8 @@ local function create_commands()
         opts.position = 'tab'
       elseif arg == '--float' then
         opts.position = 'float'
+      elseif arg == 'split' or arg == 'vsplit' or arg == 'tab' or arg == 'float' then
+        opts.position = arg
       elseif arg ~= '' then
         table.insert(remaining_args, arg)
       end
@@ -300,6 +418,10 @@ local function create_commands()
     desc = 'Open enhanced terminal in container',
     complete = function(arg_lead, cmd_line, cursor_pos)
       local completions = {
+        'split',
+        'vsplit',
+        'tab',
+        'float',
         '--position=split',
         '--position=vsplit',
         '--position=tab',
@@ -310,14 +432,38 @@ local function create_commands()
         '--float',
         '--name=',
         '--shell=',
+        '--cwd=',
         '--size=',
       }
+      for _, name in ipairs(require('container.workspace_folders').names()) do
+        table.insert(completions, '--folder=' .. name)
+      end
       return vim.tbl_filter(function(item)
         return item:match('^' .. vim.pesc(arg_lead))
       end, completions)
     end,
   })
 
+  vim.api.nvim_create_user_command('ContainerShell', function(args)
+    local opts = {}
+    for _, arg in ipairs(args.fargs) do
+      if arg:match('^%-%-shell=') then
+        opts.shell = arg:gsub('^%-%-shell=', '')
+      elseif arg == 'split' or arg == 'vsplit' or arg == 'tab' or arg == 'float' then
+        opts.position = arg
+      end
+    end
+    require('container').shell(opts)
+  end, {
+    nargs = '*',
+    desc = "Open the container user's login shell",
+    complete = function(arg_lead)
+      return vim.tbl_filter(function(item)
+        return item:match('^' .. vim.pesc(arg_lead))
+      end, { 'split', 'vsplit', 'tab', 'float', '--shell=' })
+    end,
+  })
+
   vim.api.nvim_create_user_command('ContainerTerminalNew', function(args)
     local name = args.args ~= '' and args.args or nil
     require('container').terminal_new(name)
@@ -384,20 +530,96 @@ local function create_commands()
 
   -- Information display commands
   vim.api.nvim_create_user_command('ContainerStatus', function()
-    require('container').status()
+    require('container').show_status()
   end, {
     desc = 'Show container status',
   })
 
+  vim.api.nvim_create_user_command('ContainerStats', function()
+    require('container.stats').open()
+  end, {
+    desc = 'Show live resource usage of the container',
+  })
+
+  vim.api.nvim_create_user_command('ContainerHealth', function(args)
+    require('container.health').open(args.args ~= '' and args.args or nil)
+  end, {
+    nargs = '?',
+    desc = 'Show health checks of the container and follow their transitions',
+  })
+
   vim.api.nvim_create_user_command('ContainerLogs', function(args)
-    local opts = {}
-    if args.args:find('follow') or args.args:find('f') then
-      opts.follow = true
+    local opts, err = require('container.logs').parse_args(args.fargs)
+    if not opts then
+      require('container.utils.notify').error(err)
+      return
     end
     require('container').logs(opts)
   end, {
     nargs = '*',
     desc = 'Show container logs',
+    complete = function(arg_lead)
+      return require('container.completion').logs(arg_lead)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerLog', function()
+    require('container.log').open()
+  end, {
+    desc = 'Show the plugin log',
+  })
+
+  vim.api.nvim_create_user_command('ContainerGoCacheClear', function()
+    require('container').clear_go_cache()
+  end, {
+    desc = 'Remove the Go module and build cache volumes of the workspace',
+  })
+
+  vim.api.nvim_create_user_command('ContainerGoBuild', function(args)
+    require('container.go_build').run(args.fargs)
+  end, {
+    desc = 'Build Go binaries for GOOS/GOARCH targets in container and copy them to the host',
+    nargs = '*',
+    complete = function(arg_lead)
+      local targets = require('container.config').get_value('go_build.targets') or {}
+      return require('container.completion').filter(targets, arg_lead)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerLint', function(args)
+    require('container.lint').run(args.fargs)
+  end, {
+    desc = 'Run golangci-lint in container and load the issues into the quickfix list',
+    nargs = '*',
+  })
+
+  vim.api.nvim_create_user_command('ContainerSyncCheck', function()
+    require('container.sync_check').run()
+  end, {
+    desc = 'Measure how long host file changes take to appear in the container',
+  })
+
+  vim.api.nvim_create_user_command('ContainerPrune', function(args)
+    local opts = {}
+    for _, arg in ipairs(args.fargs) do
+      local days = arg:match('^%-%-all=(%d+)$')
+      if arg == '--all' or days then
+        opts.all = true
+        opts.max_age = tonumber(days)
+      else
+        require('container.utils.notify').error('Unknown argument: ' .. arg .. ' (expected --all or --all=DAYS)')
+        return
+      end
+    end
+    require('container.prune').prune(opts)
+  end, {
+    nargs = '?',
+    desc = 'Remove stopped containers and untagged images of the plugin (--all[=DAYS] also old cached images)',
+    complete = function(arg_lead)
+      return vim.tbl_filter(function(candidate)
+        return candidate:find(arg_lead, 1, true) == 1
+      end, { '--all', '--all=' })
+    end,
   })
 
   -- Configuration and management commands
@@ -405,6 +627,13 @@ local function create_commands()
     local config = require('container.config')
 
     if args.args == '' then
+      -- The resolved devcontainer configuration, or the plugin settings before one is loaded
+      if require('container').get_config() then
+        require('container').show_resolved_config()
+      else
+        config.show_config()
+      end
+    elseif args.args == 'plugin' then
       config.show_config()
     elseif args.args == 'reload' then
       local success = config.reload()
@@ -476,6 +705,7 @@ local function create_commands()
       -- First argument completions
       if not cmd_line:match('ContainerConfig%s+%S+%s') then
         local completions = {
+          'plugin',
           'reload',
           'reset',
           'env',
@@ -585,6 +815,12 @@ local function create_commands()
     desc = 'Reset container plugin state',
   })
 
+  vim.api.nvim_create_user_command('ContainerDoctor', function()
+    require('container').doctor()
+  end, {
+    desc = 'Check the container runtime, daemon and configuration',
+  })
+
   vim.api.nvim_create_user_command('ContainerDebug', function()
     require('container').debug_info()
   end, {
@@ -649,6 +885,16 @@ local function create_commands()
     desc = 'Recover failed LSP servers',
   })
 
+  vim.api.nvim_create_user_command('ContainerLspRestart', function(args)
+    require('container').restart_lsp(#args.fargs > 0 and args.fargs or nil)
+  end, {
+    desc = 'Restart the container LSP clients (all, or the given servers)',
+    nargs = '*',
+    complete = function()
+      return require('container.lsp.init').get_state().clients
+    end,
+  })
+
   vim.api.nvim_create_user_command('ContainerLspRetry', function(args)
     if args.args == '' then
       print('Usage: ContainerLspRetry <server_name>')
@@ -682,6 +928,19 @@ local function create_commands()
     desc = 'Show detailed port forwarding information',
   })
 
+  vim.api.nvim_create_user_command('ContainerForward', function(args)
+    local ok, err = require('container').forward_port(args.fargs[1], args.fargs[2])
+    if not ok then
+      vim.notify('ContainerForward: ' .. err, vim.log.levels.ERROR)
+    end
+  end, {
+    nargs = '+',
+    desc = 'Forward a container port to the host: {container_port} [host_port]',
+    complete = function(arg_lead, cmd_line)
+      return require('container.completion').forward(arg_lead, cmd_line)
+    end,
+  })
+
   vim.api.nvim_create_user_command('ContainerPortStats', function()
     require('container').show_port_stats()
   end, {
@@ -689,12 +948,34 @@ local function create_commands()
   })
 
   -- Utility commands
+  vim.api.nvim_create_user_command('ContainerAttach', function(args)
+    require('container').attach(args.args)
+  end, {
+    nargs = '?',
+    desc = "Attach to the current workspace's container, or to a container by name",
+    complete = function(arg_lead)
+      return require('container.completion').attach(arg_lead)
+    end,
+  })
+
   vim.api.nvim_create_user_command('ContainerReconnect', function()
     require('container').reconnect()
   end, {
     desc = 'Reconnect to existing container',
   })
 
+  vim.api.nvim_create_user_command('ContainerDetach', function()
+    require('container').detach()
+  end, {
+    desc = 'Work on the host: stop container LSP and let host tools take over',
+  })
+
+  vim.api.nvim_create_user_command('ContainerReopen', function()
+    require('container').reopen()
+  end, {
+    desc = 'Switch back to the container left by :ContainerDetach',
+  })
+
   -- Picker integration commands (supports telescope, fzf-lua, vim.ui.select)
   vim.api.nvim_create_user_command('ContainerPicker', function()
     local picker = require('container.ui.picker')
@@ -703,6 +984,13 @@ local function create_commands()
     desc = 'Open container picker',
   })
 
+  vim.api.nvim_create_user_command('ContainerImagePicker', function()
+    local picker = require('container.ui.picker')
+    picker.images()
+  end, {
+    desc = 'Open image picker',
+  })
+
   vim.api.nvim_create_user_command('ContainerSessionPicker', function()
     local picker = require('container.ui.picker')
     picker.sessions()
@@ -725,11 +1013,46 @@ local function create_commands()
   })
 
   -- Test runner commands
+  vim.api.nvim_create_user_command('ContainerTest', function(args)
+    -- --folder=NAME runs the tests of a workspace folder (workspace_folders); other arguments go to the runner
+    local opts = { args = {} }
+    for _, arg in ipairs(args.fargs) do
+      if arg:match('^%-%-folder=') then
+        opts.folder = arg:gsub('^%-%-folder=', '')
+      else
+        table.insert(opts.args, arg)
+      end
+    end
+    require('container.test').run(opts)
+  end, {
+    desc = 'Run the tests of the current filetype in container and load failures into quickfix',
+    nargs = '*',
+    complete = function(arg_lead)
+      local completions = vim.tbl_map(function(name)
+        return '--folder=' .. name
+      end, require('container.workspace_folders').names())
+      return vim.tbl_filter(function(item)
+        return item:match('^' .. vim.pesc(arg_lead))
+      end, completions)
+    end,
+  })
+
+  vim.api.nvim_create_user_command('ContainerCoverage', function()
+    require('container.coverage').toggle()
+  end, {
+    desc = 'Toggle go test coverage signs of the last container test run',
+  })
+
   vim.api.nvim_create_user_command('ContainerTestNearest', function(args)
     local opts = {}
     if args.args and args.args ~= '' then
       opts.output_mode = args.args
     end
+    -- Filetypes with a registered test runner stream into the test output buffer and populate quickfix
+    if require('container.test_runners').get_by_filetype(vim.bo.filetype) and opts.output_mode ~= 'terminal' then
+      require('container.test').run_nearest()
+      return
+    end
     require('container.test_runner').run_nearest_test(opts)
   end, {
     desc = 'Run nearest test in container',
@@ -739,6 +1062,19 @@ local function create_commands()
     end,
   })
 
+  vim.api.nvim_create_user_command('ContainerBench', function(args)
+    require('container.test').run_bench({ args = args.fargs })
+  end, {
+    desc = 'Run the Go benchmarks of the current package in container',
+    nargs = '*',
+  })
+
+  vim.api.nvim_create_user_command('ContainerBenchNearest', function()
+    require('container.test').run_nearest_bench()
+  end, {
+    desc = 'Run the Go benchmark under the cursor in container',
+  })
+
   vim.api.nvim_create_user_command('ContainerTestFile', function(args)
     local opts = {}
     if args.args and args.args ~= '' then
@@ -788,6 +1124,19 @@ local function create_commands()
     end,
   })
 
+  vim.api.nvim_create_user_command('ContainerDebugNearest', function()
+    require('container').dap_debug_nearest()
+  end, {
+    desc = 'Debug the Go test under the cursor in container',
+  })
+
+  vim.api.nvim_create_user_command('ContainerDebugAttach', function(args)
+    require('container').attach_debugger(args.args ~= '' and args.args or nil)
+  end, {
+    desc = 'Attach the debugger to a process running in container',
+    nargs = '?',
+  })
+
   vim.api.nvim_create_user_command('ContainerDapStop', function()
     require('container').dap_stop()
   end, {
@@ -863,6 +1212,24 @@ vim.api.nvim_create_autocmd({ 'BufWritePost' }, {
   end,
 })
 
+-- Files of the container opened as container:///<path> (:ContainerExplore, LSP locations in a volume workspace)
+vim.api.nvim_create_autocmd('BufReadCmd', {
+  group = augroup,
+  pattern = 'container:///*',
+  callback = function(args)
+    local explore = require('container.explore')
+    explore.read(args.buf, explore.path_of(args.match))
+  end,
+})
+vim.api.nvim_create_autocmd('BufWriteCmd', {
+  group = augroup,
+  pattern = 'container:///*',
+  callback = function(args)
+    local explore = require('container.explore')
+    explore.write(args.buf, explore.path_of(args.match))
+  end,
+})
+
 -- Auto-detection when opening project directory (optional)
 vim.api.nvim_create_autocmd({ 'VimEnter', 'DirChanged' }, {
   group = augroup,
@@ -877,7 +1244,7 @@ vim.api.nvim_create_autocmd({ 'VimEnter', 'DirChanged' }, {
           -- Check if container is already running first
           local container = require('container')
           local state = container.get_state()
-          if not state.current_container then
+          if not state.current_container and not state.detached then
             require('container.utils.notify').status('Auto-opening container...')
             container.open()
           end
@@ -0,0 +1,99 @@
+#!/usr/bin/env lua
+
+-- Test script for container.adhoc module
+-- Run with: lua test/unit/test_adhoc.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {}
+
+-- The parser keeps the fields the ad hoc configuration relies on
+package.loaded['container.parser'] = {
+  DEFAULT_WORKSPACE_FOLDER = '/workspace',
+  normalize_for_plugin = function(config)
+    return {
+      name = config.name,
+      image = config.image,
+      workspace_folder = config.workspaceFolder,
+      remote_user = config.remoteUser,
+      environment = config.containerEnv or {},
+      remote_env = config.remoteEnv or {},
+      run_args = config.runArgs or {},
+    }
+  end,
+}
+
+local adhoc = require('container.adhoc')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running ad hoc container tests...')
+print()
+
+test('the workspace is mounted at /workspace by default', function()
+  local config = adhoc.config('golang:1.22', '/projects/app')
+  assert_equals(config.image, 'golang:1.22', 'image')
+  assert_equals(config.workspace_folder, '/workspace', 'workspace folder')
+  assert_equals(config.workspace_mount.source, '/projects/app', 'mount source')
+  assert_equals(config.workspace_mount.target, '/workspace', 'mount target')
+  assert_equals(config.base_path, '/projects/app', 'base path')
+  assert_equals(config.workspace_root, '/projects/app', 'workspace root')
+end)
+
+test('exec sessions keep the PATH of the image', function()
+  local config = adhoc.config('golang:1.22', '/projects/app')
+  assert_equals(config.remote_env.PATH, '${containerEnv:PATH}', 'PATH')
+end)
+
+test('the container is removed when it stops unless kept', function()
+  local config = adhoc.config('golang:1.22', '/projects/app')
+  assert_equals(config.ephemeral, true, 'ephemeral')
+  assert_equals(config.run_args[1], '--rm', 'removed on stop')
+
+  config = adhoc.config('golang:1.22', '/projects/app', { keep = true })
+  assert_equals(config.ephemeral, false, 'kept')
+  assert_equals(#config.run_args, 0, 'no --rm')
+end)
+
+test('options set the folder, user, environment and name', function()
+  local config = adhoc.config('node:20', '/projects/web', {
+    workspace_folder = '/src',
+    user = 'node',
+    env = { NODE_ENV = 'development' },
+    name = 'scratch',
+  })
+  assert_equals(config.workspace_folder, '/src', 'workspace folder')
+  assert_equals(config.workspace_mount.target, '/src', 'mount target')
+  assert_equals(config.remote_user, 'node', 'user')
+  assert_equals(config.environment.NODE_ENV, 'development', 'environment')
+  assert_equals(config.name, 'scratch', 'name')
+end)
+
+test('the default name is derived from the image', function()
+  assert_equals(adhoc.name('ghcr.io/org/tools:1.0'), 'adhoc-ghcr.io-org-tools-1.0', 'name')
+end)
+
+print()
+print(string.format('=== Ad Hoc Container Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,91 @@
+#!/usr/bin/env lua
+
+-- Test script for container.build_context module
+-- Run with: lua test/unit/test_build_context.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local build_context = require('container.build_context')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running build context tests...')
+print()
+
+test('comments and blank lines are skipped', function()
+  local rules = build_context.parse('# build output\n\nbin\n  ./dist/  \n')
+  assert_equals(#rules, 2, 'rule count')
+  assert_equals(build_context.is_ignored('dist/app.js', rules), true, 'leading ./ and trailing / dropped')
+end)
+
+test('a rule excludes a path and everything below it', function()
+  local rules = build_context.parse('node_modules\n.git\n')
+  assert_equals(build_context.is_ignored('node_modules', rules), true, 'directory')
+  assert_equals(build_context.is_ignored('node_modules/react/index.js', rules), true, 'below the directory')
+  assert_equals(build_context.is_ignored('web/node_modules/x', rules), false, 'only at the root')
+  assert_equals(build_context.is_ignored('src/main.go', rules), false, 'other paths')
+end)
+
+test('wildcards stay within a segment unless written as **', function()
+  local rules = build_context.parse('*.md\n**/*.log\ntmp?\n')
+  assert_equals(build_context.is_ignored('README.md', rules), true, 'root file')
+  assert_equals(build_context.is_ignored('docs/guide.md', rules), false, '* does not cross directories')
+  assert_equals(build_context.is_ignored('a/b/c/debug.log', rules), true, '** crosses directories')
+  assert_equals(build_context.is_ignored('debug.log', rules), true, '** matches no directory')
+  assert_equals(build_context.is_ignored('tmp1/x', rules), true, '? matches one character')
+  assert_equals(build_context.is_ignored('tmp12/x', rules), false, '? matches only one character')
+end)
+
+test('! re-includes and the last matching rule wins', function()
+  local rules = build_context.parse('*.md\n!README.md\n')
+  assert_equals(build_context.is_ignored('CHANGELOG.md', rules), true, 'excluded')
+  assert_equals(build_context.is_ignored('README.md', rules), false, 're-included')
+
+  rules = build_context.parse('!README.md\n*.md\n')
+  assert_equals(build_context.is_ignored('README.md', rules), true, 'later rule wins')
+end)
+
+test('sizes are human-readable', function()
+  assert_equals(build_context.format_size(512), '512 B', 'bytes')
+  assert_equals(build_context.format_size(1536), '1.5 KB', 'kilobytes')
+  assert_equals(build_context.format_size(734003200), '700.0 MB', 'megabytes')
+  assert_equals(build_context.format_size(3 * 1024 * 1024 * 1024), '3.0 GB', 'gigabytes')
+end)
+
+print()
+print(string.format('=== Build Context Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,106 @@
+#!/usr/bin/env lua
+
+-- Test script for container.ui.build_progress module
+-- Run with: lua test/unit/test_build_progress.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  loop = {
+    hrtime = function()
+      return 0
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  defer_fn = function(fn, timeout) end,
+}
+
+package.loaded['container.ui.output'] = {
+  clear = function(name) end,
+  set_lines = function(name, lines) end,
+}
+
+local build_progress = require('container.ui.build_progress')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running build progress tests...')
+print()
+
+test('BuildKit steps, durations and cache hits are parsed', function()
+  local step = build_progress.parse_line('#5 [2/4] RUN apt-get update')
+  assert_equals(step.kind, 'step', 'step kind')
+  assert_equals(step.id, '5', 'step id')
+  assert_equals(step.name, '[2/4] RUN apt-get update', 'step name')
+
+  local done = build_progress.parse_line('#5 DONE 12.3s')
+  assert_equals(done.kind, 'done', 'done kind')
+  assert_equals(done.duration, 12.3, 'duration')
+
+  assert_equals(build_progress.parse_line('#6 CACHED').kind, 'cached', 'cached')
+end)
+
+test('BuildKit output and errors are parsed', function()
+  local output = build_progress.parse_line('#5 0.512 Get:1 http://archive.ubuntu.com')
+  assert_equals(output.kind, 'output', 'output kind')
+  assert_equals(output.text, 'Get:1 http://archive.ubuntu.com', 'timestamp stripped')
+
+  local err = build_progress.parse_line('#7 ERROR: process "/bin/sh -c make" did not complete successfully')
+  assert_equals(err.kind, 'error', 'error kind')
+  assert_equals(err.id, '7', 'error id')
+
+  assert_equals(build_progress.parse_line('#0 building with "default" instance').kind, 'output', 'builder line')
+end)
+
+test('classic builder and Podman steps are parsed', function()
+  local classic = build_progress.parse_line('Step 2/4 : RUN make')
+  assert_equals(classic.kind, 'step', 'classic kind')
+  assert_equals(classic.name, '[2/4] RUN make', 'classic name')
+
+  local podman = build_progress.parse_line('STEP 3/4: COPY . .')
+  assert_equals(podman.id, 'step-3/4', 'podman id')
+
+  assert_equals(build_progress.parse_line(' ---> Running in 1234').kind, 'output', 'plain output')
+end)
+
+test('a cancelled build ends with the message and ignores later output', function()
+  build_progress.start('Building app')
+  build_progress.mark_cancelled('Image build cancelled')
+  build_progress.handle_line('#5 [2/4] RUN apt-get update')
+  build_progress.finish(false)
+
+  local lines = build_progress.render_lines()
+  assert_equals(#lines, 2, 'only the message is rendered')
+  assert_equals(lines[2], '■ Image build cancelled', 'cancel message')
+end)
+
+print()
+print(string.format('=== Build Progress Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
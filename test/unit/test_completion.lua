@@ -0,0 +1,128 @@
+#!/usr/bin/env lua
+
+-- Test script for container.completion module
+-- Run with: lua test/unit/test_completion.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s, sep)
+    local parts = {}
+    for part in s:gmatch('[^' .. (sep == '%s+' and '%s' or sep) .. ']+') do
+      table.insert(parts, part)
+    end
+    return parts
+  end,
+  tbl_map = function(fn, t)
+    local result = {}
+    for i, v in ipairs(t) do
+      result[i] = fn(v)
+    end
+    return result
+  end,
+  tbl_contains = function(t, value)
+    for _, v in ipairs(t) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+}
+
+local services = { 'app', 'db', 'redis' }
+package.loaded['container.logs'] = {
+  list_services = function()
+    return services
+  end,
+}
+
+local state = {
+  current_container = 'abc123',
+  current_config = { ports = { { container_port = 8080 }, { container_port = 5432, service = 'db' } } },
+  port_forwards = { { container_port = 3000, host_port = 3000 } },
+}
+package.loaded['container'] = {
+  get_state = function()
+    return state
+  end,
+}
+package.loaded['container.docker'] = {
+  get_container_info = function()
+    return { Config = { ExposedPorts = { ['3000/tcp'] = {}, ['9229/tcp'] = {}, ['53/udp'] = {} } } }
+  end,
+  list_containers = function()
+    return {
+      { name = 'web-dev', status = 'Up 2 hours' },
+      { name = 'old-dev', status = 'Exited (0) 3 days ago' },
+      { name = 'api-dev', status = 'Up 5 minutes' },
+    }
+  end,
+  list_images = function()
+    return { { name = 'golang:1.22' }, { name = 'node:20' } }
+  end,
+}
+
+local completion = require('container.completion')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running completion tests...')
+print()
+
+test('candidates are filtered by prefix', function()
+  assert_equals(table.concat(completion.filter({ 'db', 'app', 'db' }, 'd'), ' '), 'db', 'prefix and duplicates')
+  assert_equals(table.concat(completion.logs(''), ' '), 'app db redis', 'services')
+  assert_equals(table.concat(completion.logs('--t'), ' '), '--tail=', 'options')
+end)
+
+test('exec completes the service option only before the command', function()
+  assert_equals(table.concat(completion.exec('-', 'ContainerExec -'), ' '), '--service=', 'option')
+  local values = completion.exec('--service=r', 'ContainerExec --service=r')
+  assert_equals(table.concat(values, ' '), '--service=redis', 'value')
+  assert_equals(table.concat(completion.exec('d', 'ContainerExec --service d'), ' '), 'db', 'separate value')
+  assert_equals(#completion.exec('-', 'ContainerExec ls -'), 0, 'command arguments')
+
+  services = {}
+  assert_equals(#completion.exec('-', 'ContainerExec -'), 0, 'not a compose devcontainer')
+  services = { 'app', 'db', 'redis' }
+end)
+
+test('forward completes container ports that are not forwarded yet', function()
+  assert_equals(table.concat(completion.forward('', 'ContainerForward '), ' '), '8080 9229', 'ports')
+  assert_equals(table.concat(completion.forward('9', 'ContainerForward 9'), ' '), '9229', 'prefix')
+  assert_equals(#completion.forward('', 'ContainerForward 8080 '), 0, 'host port')
+end)
+
+test('attach completes running containers and start_image local images', function()
+  assert_equals(table.concat(completion.attach(''), ' '), 'api-dev web-dev', 'running containers')
+  assert_equals(table.concat(completion.start_image('go'), ' '), 'golang:1.22', 'images')
+end)
+
+print()
+print(string.format('=== Completion Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
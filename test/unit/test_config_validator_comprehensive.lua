@@ -598,6 +598,35 @@ run_test('Special validator edge cases', function()
   print('  Special validator edge cases tested')
 end)
 
+-- TEST 16: Every problem is reported at once
+run_test('All invalid and unknown options reported', function()
+  local validator = require('container.config.validator')
+
+  local valid, errors = validator.validate({
+    container_runtime = 'nerdctl',
+    docker = { stop_timeout = 0 },
+    terminal = 'float',
+  })
+  assert(not valid, 'Invalid configuration should fail')
+  local error_string = table.concat(errors, '; ')
+  assert(error_string:find('container_runtime: Must be one of: docker, podman, auto', 1, true), error_string)
+  assert(error_string:find('docker.stop_timeout: Must be >= 1', 1, true), error_string)
+  assert(error_string:find('terminal: Expected table, got string', 1, true), error_string)
+
+  local unknown = validator.unknown_options({
+    runtime = 'docker',
+    terminal = { shel = 'zsh', shell = 'zsh', float = { widht = 0.5 } },
+    lsp = { servers = { gopls = { settings = {} } } },
+    labels = { team = 'core' },
+  }, { terminal = { float = {} }, lsp = { servers = {} }, labels = {} })
+  assert(#unknown == 3, 'Three unknown options expected, got ' .. table.concat(unknown, ', '))
+  assert(unknown[1] == 'runtime', 'Top-level typo reported')
+  assert(unknown[2] == 'terminal.float.widht', 'Nested typo reported')
+  assert(unknown[3] == 'terminal.shel', 'Section typo reported')
+
+  print('  All invalid and unknown options reported')
+end)
+
 -- Print results
 print('')
 print('=== Config Validator Module Test Results ===')
@@ -0,0 +1,122 @@
+#!/usr/bin/env lua
+
+-- Test script for container.config_view module
+-- Run with: lua test/unit/test_config_view.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  split = function(text, sep)
+    local parts = {}
+    for part in (text .. sep):gmatch('(.-)' .. sep) do
+      table.insert(parts, part)
+    end
+    return parts
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.docker'] = {
+  resolve_container_name = function(config)
+    return config.name .. '-1a2b3c4d-devcontainer'
+  end,
+  format_mount = function(mount)
+    return string.format('type=%s,source=%s,target=%s', mount.type, mount.source, mount.target)
+  end,
+}
+package.loaded['container.environment'] = {
+  get_remote_environment = function(config)
+    return config.remote_env or {}
+  end,
+}
+
+local config_view = require('container.config_view')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function contains(lines, expected)
+  for _, line in ipairs(lines) do
+    if line == expected then
+      return true
+    end
+  end
+  return false
+end
+
+print('Running config view tests...')
+print()
+
+test('values are encoded as indented JSON with sorted keys', function()
+  local json = config_view.encode({ b = { 1, 2 }, a = 'x/"y"', c = {}, d = true })
+  assert_equals(json, '{\n  "a": "x/\\"y\\"",\n  "b": [\n    1,\n    2\n  ],\n  "c": {},\n  "d": true\n}', 'json')
+end)
+
+test('computed values and devcontainer.json are shown', function()
+  local lines = config_view.render({
+    name = 'app',
+    image = 'golang:1.22',
+    config_file = '/work/.devcontainer/devcontainer.json',
+    key_sources = { image = { '/work/.devcontainer/devcontainer.json' } },
+    devcontainer_json = { image = 'golang:1.22' },
+    workspace_folder = '/workspace',
+    mounts = { { type = 'volume', source = 'cache', target = '/cache' } },
+    environment = { TZ = 'UTC' },
+    env_files = { '/work/.env' },
+    env_file_keys = { 'API_TOKEN' },
+    ports = { { container_port = 3000, host_port = 3001, label = 'Web' } },
+  }, 'abc123')
+  assert_equals(lines[1], '{', 'object')
+  assert_equals(lines[2], '  "configFile": "/work/.devcontainer/devcontainer.json",', 'config file')
+  assert_equals(contains(lines, '    "containerName": "app-1a2b3c4d-devcontainer",'), true, 'container name')
+  assert_equals(contains(lines, '    "containerId": "abc123",'), true, 'container id')
+  assert_equals(contains(lines, '      "type=volume,source=cache,target=/cache"'), true, 'mounts')
+  assert_equals(contains(lines, '      "API_TOKEN"'), true, 'env file variable names')
+  assert_equals(contains(lines, '      "3001 -> 3000 (Web)"'), true, 'ports')
+  assert_equals(contains(lines, '    "image": "golang:1.22"'), true, 'devcontainer.json')
+  assert_equals(contains(lines, '  "sources": {'), false, 'no sources without extends')
+end)
+
+test('sources are shown when extends is used', function()
+  local lines = config_view.render({
+    name = 'app',
+    config_file = '/work/.devcontainer/devcontainer.json',
+    key_sources = {
+      image = { '/work/base.json' },
+      containerEnv = { '/work/base.json', '/work/.devcontainer/devcontainer.json' },
+    },
+    devcontainer_json = {},
+  })
+  assert_equals(contains(lines, '  "sources": {'), true, 'sources')
+  assert_equals(contains(lines, '    "image": "/work/base.json"'), true, 'single source')
+  assert_equals(contains(lines, '      "/work/.devcontainer/devcontainer.json"'), true, 'merged sources')
+end)
+
+print()
+print(string.format('=== Config View Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -5,6 +5,10 @@
 
 package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
 
+-- Current git branch and the number of git calls
+local branch = 'feature/login'
+local git_calls = 0
+
 -- Mock vim functions for testing
 _G.vim = {
   fn = {
@@ -19,7 +23,33 @@ _G.vim = {
       end
       return string.format('%08x', hash % 0x100000000)
     end,
+    systemlist = function()
+      git_calls = git_calls + 1
+      return { branch }
+    end,
   },
+  v = { shell_error = 0 },
+  tbl_keys = function(t)
+    local keys = {}
+    for key in pairs(t) do
+      table.insert(keys, key)
+    end
+    return keys
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Mock plugin config
+local plugin_config = {}
+package.loaded['container.config'] = {
+  get = function()
+    return plugin_config
+  end,
 }
 
 -- Mock log module
@@ -132,5 +162,70 @@ print('Generated container name: ' .. container_name5)
 print('✓ Default path handling works')
 print()
 
+-- Test 6: Name template
+print('=== Test 6: Container Name Template ===')
+local config6 = {
+  name = 'API Server',
+  base_path = '/home/user/projects/My Service',
+}
+local default_name = docker.generate_container_name(config6)
+
+plugin_config.container_name_template = '{project}-{branch}-{hash}'
+local templated = docker.generate_container_name(config6)
+print('Template: ' .. plugin_config.container_name_template)
+print('Generated container name: ' .. templated)
+assert(templated:match('^my%-service%-feature%-login%-%x+$'), 'template expanded and sanitized')
+
+plugin_config.container_name_template = '{project}'
+local without_hash = docker.generate_container_name(config6)
+local other_workspace = docker.generate_container_name({ name = 'API Server', base_path = '/other/My Service' })
+print('Template without {hash}: ' .. without_hash)
+assert(without_hash:match('^my%-service%-%x+$'), 'hash appended')
+assert(without_hash ~= other_workspace, 'unique per workspace')
+
+plugin_config.container_name_template = '__{name}'
+assert(docker.generate_container_name(config6):match('^api%-server'), 'leading invalid characters removed')
+
+plugin_config.container_name_template = nil
+assert(docker.generate_container_name(config6) == default_name, 'default template unchanged')
+print('✓ Container name template works')
+print()
+
+-- Test 7: User labels
+print('=== Test 7: User Labels ===')
+plugin_config.labels = { team = 'backend', ['com.example.owner'] = 'me' }
+local label_args = table.concat(docker.user_label_args(), ' ')
+print('Label arguments: ' .. label_args)
+assert(label_args == '--label com.example.owner=me --label team=backend', 'labels sorted by key')
+plugin_config.labels = nil
+print('✓ User labels are passed as --label')
+print()
+
+-- Test 8: Names resolved once and stable ids
+print('=== Test 8: Resolved Names and Configuration Ids ===')
+plugin_config.container_name_template = '{project}-{branch}'
+local config8 = { name = 'API Server', base_path = '/home/user/projects/My Service' }
+git_calls = 0
+local resolved = docker.resolve_container_name(config8)
+branch = 'main'
+assert(docker.resolve_container_name(config8) == resolved, 'name kept after a branch switch')
+assert(git_calls == 1, 'git runs once per configuration')
+assert(resolved:match('^my%-service%-feature%-login%-'), 'name of the branch at creation')
+
+local config_id = docker.get_config_id(config8)
+assert(config_id == docker.get_config_id({ name = 'API Server', base_path = '/home/user/projects/My Service' }))
+assert(docker.remote_workspace_volume(config8) == config_id .. '-workspace', 'volume named after the id')
+plugin_config.container_name_template = nil
+assert(config_id == docker.generate_container_name(config8), 'id is the default container name')
+assert(
+  table.concat(docker.workspace_label_args(config8), ' ')
+    == '--label container.nvim.workspace=/home/user/projects/My Service --label container.nvim.config=' .. config_id,
+  'workspace and configuration labels'
+)
+assert(docker.config_label_filter(config8) == 'label=container.nvim.config=' .. config_id, 'label filter')
+branch = 'feature/login'
+print('✓ Names are resolved once and volumes use the configuration id')
+print()
+
 print('=== Container Naming Tests Complete ===')
 print('All tests passed! ✓')
@@ -0,0 +1,240 @@
+#!/usr/bin/env lua
+
+-- Test script for container.copy (docker cp between the host and the container)
+-- Run with: lua test/unit/test_copy.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+local host_dirs = { ['/projects/a'] = true, ['/projects/c'] = true, ['/home/me/Downloads/'] = true }
+local host_files = { ['/projects/a/config.yaml'] = true }
+-- Status docker inspect answers with
+local container_status = 'running'
+local status_checks = {}
+-- docker cp calls: { source, target }
+local copies = {}
+local errors = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' or mods == ':h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      if mods == ':p' and path:sub(1, 2) == './' then
+        return '/projects/a/' .. path:sub(3)
+      end
+      return path
+    end,
+    isdirectory = function(path)
+      return host_dirs[path] and 1 or 0
+    end,
+    filereadable = function(path)
+      return host_files[path] and 1 or 0
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  cmd = function() end,
+  startswith = function(s, prefix)
+    return s:sub(1, #prefix) == prefix
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = function(message)
+    table.insert(errors, message)
+  end,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function() end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = { setup = noop, stop_all = noop, switch_container = noop }
+package.loaded['container.events'] = { emit = noop }
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+  workspace_roots = function(config)
+    return '/projects/' .. config.name, config.workspace_folder
+  end,
+}
+local docker = {
+  run_docker_command_async = function(args, _, callback)
+    if args[4] == '{{.State.Status}}' then
+      table.insert(status_checks, args[2])
+      callback({ success = true, stdout = container_status .. '\n', stderr = '' })
+    end
+  end,
+  get_container_status = function()
+    error('synchronous status check')
+  end,
+  copy_async = function(source, target, callback)
+    table.insert(copies, { source = source, target = target })
+    callback(true)
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  container_status, status_checks, copies, errors = 'running', {}, {}, {}
+  buffer_name = '/projects/a/main.go'
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Copy and return what the callback received
+local function copy(src, dest)
+  local received = {}
+  local started, err = container.copy(src, dest, function(success, error_msg)
+    received = { success = success, error_msg = error_msg }
+  end)
+  return started, err, received
+end
+
+container.setup({})
+container._sync_workspace()
+container._restore_attached_container({ id = 'ctr-a', status = 'Up' }, {
+  name = 'a',
+  workspace_folder = '/workspaces/a',
+}, nil)
+
+print('Running copy tests...')
+print()
+
+test('container paths are copied to the host', function()
+  local started, _, received = copy('container:dist/app.tar.gz', '/home/me/Downloads/')
+  assert_equals(started, true, 'started')
+  assert_equals(status_checks[1], 'ctr-a', 'status checked with docker inspect')
+  assert_equals(copies[1].source, 'ctr-a:/workspaces/a/dist/app.tar.gz', 'relative to the workspace folder')
+  assert_equals(copies[1].target, '/home/me/Downloads/', 'host directory')
+  assert_equals(received.success, true, 'callback')
+end)
+
+test('host paths are copied into the container', function()
+  copy('./config.yaml', 'container:/etc/app/config.yaml')
+  assert_equals(copies[1].source, '/projects/a/config.yaml', 'relative to the current directory')
+  assert_equals(copies[1].target, 'ctr-a:/etc/app/config.yaml', 'absolute container path')
+
+  copy('./config.yaml', 'container:')
+  assert_equals(copies[2].target, 'ctr-a:/workspaces/a', 'workspace folder')
+end)
+
+test('a container that is not running is reported without copying', function()
+  container_status = 'exited'
+  local started, _, received = copy('container:dist/app.tar.gz', '/home/me/Downloads/')
+  assert_equals(started, true, 'status check started')
+  assert_equals(#copies, 0, 'nothing copied')
+  assert_equals(received.success, false, 'callback')
+  assert_equals(received.error_msg, 'Container is not running, start it with :ContainerStart first', 'error')
+  assert_equals(errors[1], received.error_msg, 'notified')
+end)
+
+test('invalid arguments are rejected before docker is called', function()
+  local started, err = copy('container:/a', 'container:/b')
+  assert_equals(started, false, 'both sides in the container')
+  assert_equals(err:match('^Copy needs exactly one side') ~= nil, true, 'direction error')
+  started, err = copy('./missing.txt', 'container:/tmp')
+  assert_equals(started, false, 'missing host path')
+  assert_equals(err, 'Host path does not exist: ./missing.txt', 'host path error')
+  started, err = copy('container:/tmp/out.log', '/nowhere/out.log')
+  assert_equals(err, 'Host directory does not exist: /nowhere', 'host directory error')
+  assert_equals(#status_checks + #copies, 0, 'no docker calls')
+end)
+
+test('projects without a container are rejected', function()
+  buffer_name = '/projects/c/main.go'
+  local started, err = copy('container:/tmp/out.log', '/home/me/Downloads/')
+  assert_equals(started, false, 'not started')
+  assert_equals(err, 'No active container', 'error')
+  assert_equals(#status_checks, 0, 'no docker calls')
+end)
+
+print()
+print(string.format('=== Copy Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -208,6 +208,9 @@ local function setup_core_mocks()
     validate_resolved_ports = function()
       return {}
     end,
+    add_additional_mounts = function(config)
+      return config, {}
+    end,
     merge_with_plugin_config = function(config)
       return config
     end,
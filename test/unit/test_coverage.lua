@@ -0,0 +1,114 @@
+#!/usr/bin/env lua
+
+-- Test script for container.coverage module
+-- Run with: lua test/unit/test_coverage.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {}
+
+-- Mock log, notify, fs and LSP path modules
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  error = function(...) end,
+  status = function(...) end,
+}
+package.loaded['container.utils.fs'] = {
+  join_path = function(...)
+    return table.concat({ ... }, '/')
+  end,
+}
+local lsp_mappings = {}
+package.loaded['container.lsp.path'] = {
+  get_mappings = function()
+    return lsp_mappings
+  end,
+  to_local_path = function(path)
+    local relative = path:match('^/workspace/(.*)$')
+    return relative and ('/lsp/app/' .. relative) or path
+  end,
+  replace_prefix = function(path, from_root, to_root)
+    if path == from_root or path:sub(1, #from_root + 1) == from_root .. '/' then
+      return to_root .. path:sub(#from_root + 1)
+    end
+  end,
+}
+
+local coverage = require('container.coverage')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local ctx = {
+  host_root = '/host/app',
+  container_root = '/workspaces/app',
+  module_root = '/host/app',
+  module_path = 'example.com/app',
+}
+
+print('Running coverage tests...')
+print()
+
+test('profile blocks mark covered and uncovered lines', function()
+  local parsed = coverage.parse_profile(table.concat({
+    'mode: set',
+    'example.com/app/calc.go:3.24,5.2 2 1',
+    'example.com/app/calc.go:5.2,7.3 1 0',
+    'example.com/app/calc.go:9.10,10.2 1 0',
+  }, '\n'))
+  local lines = parsed.files['example.com/app/calc.go']
+  assert_equals(lines[3], 1, 'covered line')
+  assert_equals(lines[5], 1, 'line shared with a covered block')
+  assert_equals(lines[7], 0, 'uncovered line')
+  assert_equals(lines[8], nil, 'line without statements')
+  assert_equals(coverage.percentage(parsed), 50, 'statement coverage')
+end)
+
+test('blocks repeated by several packages are counted once', function()
+  local parsed = coverage.parse_profile(table.concat({
+    'mode: count',
+    'example.com/app/calc.go:3.24,5.2 2 0',
+    'example.com/app/calc.go:3.24,5.2 2 4',
+  }, '\n'))
+  assert_equals(parsed.statements, 2, 'statements')
+  assert_equals(parsed.covered, 2, 'covered statements')
+  assert_equals(parsed.files['example.com/app/calc.go'][3], 4, 'highest count')
+end)
+
+test('profile files resolve to host paths', function()
+  assert_equals(coverage.resolve_file('example.com/app/pkg/calc.go', ctx), '/host/app/pkg/calc.go', 'import path')
+  assert_equals(coverage.resolve_file('github.com/other/lib.go', ctx), nil, 'other module')
+  assert_equals(coverage.resolve_file('/workspaces/app/main.go', ctx), '/host/app/main.go', 'container path')
+  lsp_mappings = { container_workspace = '/workspace' }
+  assert_equals(coverage.resolve_file('/workspace/main.go', ctx), '/lsp/app/main.go', 'LSP path mapping')
+end)
+
+print()
+print(string.format('=== Coverage Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
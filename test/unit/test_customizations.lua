@@ -0,0 +1,108 @@
+#!/usr/bin/env lua
+
+-- Test script for container.customizations module
+-- Run with: lua test/unit/test_customizations.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  deepcopy = function(value)
+    local copy = {}
+    for k, v in pairs(value) do
+      copy[k] = v
+    end
+    return copy
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local customizations = require('container.customizations')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local config = {
+  customizations = {
+    vscode = {
+      extensions = { 'golang.go', 'Rust-Lang.rust-analyzer', '-dbaeumer.vscode-eslint', 'unknown.extension' },
+    },
+    ['container.nvim'] = {
+      lsp = {
+        servers = {
+          gopls = { settings = { gopls = { staticcheck = true } } },
+          rust_analyzer = false,
+          pyright = true,
+        },
+      },
+    },
+  },
+}
+
+print('Running customizations tests...')
+print()
+
+test('customizations tree and tool blocks are exposed', function()
+  assert_equals(customizations.get(config), config.customizations, 'whole tree')
+  assert_equals(customizations.get(config, 'vscode'), config.customizations.vscode, 'vscode block')
+  assert_equals(next(customizations.get(config, 'jetbrains')), nil, 'missing tool')
+  assert_equals(next(customizations.get(nil)), nil, 'no config')
+end)
+
+test('excluded extensions are skipped and IDs are case-insensitive', function()
+  local ids = customizations.vscode_extensions(config)
+  assert_equals(#ids, 3, 'extension count')
+  assert_equals(ids[2], 'rust-lang.rust-analyzer', 'lowercased id')
+end)
+
+test('servers come from extensions and the container.nvim block', function()
+  local servers = customizations.lsp_servers(config, {})
+  assert_equals(servers.gopls.source, 'container.nvim', 'container.nvim block takes precedence')
+  assert_equals(servers.gopls.options.settings.gopls.staticcheck, true, 'server options')
+  assert_equals(servers.rust_analyzer, false, 'disabled server')
+  assert_equals(servers.pyright.options, nil, 'enabled without options')
+  assert_equals(servers.eslint, nil, 'excluded extension')
+  assert_equals(customizations.requests_lsp(config, {}), true, 'requests lsp')
+end)
+
+test('extension mappings are user-extensible', function()
+  local lsp_config = { vscode_extensions = { ['unknown.extension'] = 'unknown_ls', ['golang.go'] = false } }
+  local servers = customizations.lsp_servers(config, lsp_config)
+  assert_equals(servers.unknown_ls.source, 'unknown.extension', 'user mapping')
+
+  local vscode_only = { customizations = { vscode = { extensions = { 'golang.go' } } } }
+  assert_equals(customizations.lsp_servers(vscode_only, lsp_config).gopls, nil, 'default mapping removed')
+  assert_equals(customizations.lsp_servers(vscode_only, {}).gopls.source, 'golang.go', 'default mapping')
+  assert_equals(customizations.requests_lsp({}, {}), false, 'no customizations')
+end)
+
+print()
+print(string.format('=== Customizations Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
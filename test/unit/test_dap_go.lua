@@ -0,0 +1,152 @@
+#!/usr/bin/env lua
+
+-- Test script for Go debugging helpers in container.dap
+-- Run with: lua test/unit/test_dap_go.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+-- Mock modules loaded by container.dap
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {}
+package.loaded['container.config'] = {}
+
+local published_ports = {}
+package.loaded['container.docker'] = {
+  get_container_info = function()
+    return { NetworkSettings = { Ports = published_ports } }
+  end,
+}
+
+local port_forwards = {}
+package.loaded['container'] = {
+  get_port_forwards = function()
+    return port_forwards
+  end,
+}
+
+local dap = require('container.dap')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running DAP Go tests...')
+print()
+
+test('substitutePath maps host paths to container paths', function()
+  local substitute_path = dap.build_substitute_path({
+    { host = '/home/me/lib', container = '/opt/lib' },
+    { host = '/home/me/app', container = '/workspace' },
+  })
+  assert_equals(#substitute_path, 2, 'entries')
+  assert_equals(substitute_path[1].from, '/home/me/lib', 'from')
+  assert_equals(substitute_path[1].to, '/opt/lib', 'to')
+  assert_equals(substitute_path[2].to, '/workspace', 'workspace')
+end)
+
+test('test configuration runs only the nearest test', function()
+  local configuration = dap.build_go_test_configuration({
+    test_name = 'TestAdd',
+    container_dir = '/workspace/calc',
+    substitute_path = {},
+  })
+  assert_equals(configuration.type, 'container_go', 'type')
+  assert_equals(configuration.mode, 'test', 'mode')
+  assert_equals(configuration.program, '/workspace/calc', 'program')
+  assert_equals(configuration.args[1], '-test.run', 'flag')
+  assert_equals(configuration.args[2], '^TestAdd$', 'pattern')
+end)
+
+test('dlv dap is started detached in the package directory', function()
+  local args = dap.build_dlv_dap_args('abc', 2345, '/workspace/calc', { '-e', 'GOFLAGS=-mod=mod' })
+  local joined = table.concat(args, ' ')
+  assert_equals(joined, 'exec -d -e GOFLAGS=-mod=mod -w /workspace/calc abc dlv dap --listen=0.0.0.0:2345', 'args')
+end)
+
+test('host port prefers dynamic forwards, then published ports', function()
+  published_ports['2345/tcp'] = { { HostIp = '127.0.0.1', HostPort = '12345' } }
+  assert_equals(dap._find_host_port('abc', 2345), 12345, 'published')
+
+  port_forwards = { { container_port = 2345, host_port = 2346 } }
+  assert_equals(dap._find_host_port('abc', 2345), 2346, 'forwarded')
+
+  port_forwards = {}
+  published_ports = {}
+  assert_equals(dap._find_host_port('abc', 2345), nil, 'unreachable')
+end)
+
+test('attaching uses ptrace of the container, exec --privileged or a sidecar', function()
+  assert_equals(dap.has_ptrace({ HostConfig = { CapAdd = { 'SYS_PTRACE' } } }), true, 'capAdd')
+  assert_equals(dap.has_ptrace({ HostConfig = { Privileged = true } }), true, 'privileged')
+  assert_equals(dap.has_ptrace({ HostConfig = { CapAdd = { 'NET_ADMIN' } } }), false, 'other capability')
+  assert_equals(dap.has_ptrace(nil), false, 'unknown container')
+
+  local opts = { ptrace = true, container_dir = '/workspace' }
+  local joined = table.concat(dap.build_attach_args('abc', 2345, opts), ' ')
+  assert_equals(joined, 'exec -d -w /workspace abc dlv dap --listen=0.0.0.0:2345', 'in the container')
+  opts.ptrace = false
+  joined = table.concat(dap.build_attach_args('abc', 2345, opts), ' ')
+  assert_equals(joined, 'exec -d --privileged -w /workspace abc dlv dap --listen=0.0.0.0:2345', 'privileged exec')
+
+  opts.image = 'tools:dlv'
+  joined = table.concat(dap.build_attach_args('abcdef0123456789', 2345, opts), ' ')
+  assert(joined:find('--pid=container:abcdef0123456789 --network=container:abcdef0123456789', 1, true), joined)
+  assert(joined:find('--cap-add=SYS_PTRACE', 1, true), joined)
+  assert(joined:find('--name container-nvim-dlv-abcdef012345 ', 1, true), joined)
+  assert_equals(joined:match('tools:dlv .*$'), 'tools:dlv dlv dap --listen=0.0.0.0:2345', 'image runs dlv')
+end)
+
+test('processes are listed without ps and dlv, and attached by PID', function()
+  local stdout = table.concat({
+    '    1 /usr/local/bin/server -port 8080',
+    '   42 dlv dap --listen=0.0.0.0:2345',
+    '   57 ps -eo pid=,args=',
+  }, '\n')
+  local processes = dap.parse_processes(stdout)
+  assert_equals(#processes, 1, 'count')
+  assert_equals(processes[1].pid, 1, 'pid')
+  assert_equals(processes[1].command, '/usr/local/bin/server -port 8080', 'command')
+
+  local configuration = dap.build_attach_configuration(1, {})
+  assert_equals(configuration.request, 'attach', 'request')
+  assert_equals(configuration.mode, 'local', 'mode')
+  assert_equals(configuration.processId, 1, 'process')
+end)
+
+print()
+print(string.format('=== DAP Go Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
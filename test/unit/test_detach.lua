@@ -0,0 +1,329 @@
+#!/usr/bin/env lua
+
+-- Test script for container.detach and container.reopen (working on the host and switching back)
+-- Run with: lua test/unit/test_detach.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+local detach_settings = {}
+-- User autocmds fired: { pattern, data }
+local autocmds = {}
+-- Buffers FileType was fired for
+local filetype_buffers = {}
+local deferred = {}
+local lsp_clients = {}
+local stopped_clients = {}
+local lsp_stops = 0
+local container_status = {}
+local stop_callbacks = {}
+local restarted = {}
+local errors = {}
+
+-- LSP client of the host; FileType starts the one for Go
+local function client(id)
+  return {
+    id = id,
+    stop = function()
+      table.insert(stopped_clients, id)
+    end,
+  }
+end
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+    nvim_list_bufs = function()
+      return { 1, 2 }
+    end,
+    nvim_buf_is_loaded = function()
+      return true
+    end,
+    nvim_exec_autocmds = function(event, opts)
+      if event == 'User' then
+        table.insert(autocmds, { pattern = opts.pattern, data = opts.data })
+      elseif event == 'FileType' then
+        table.insert(filetype_buffers, opts.buffer)
+        table.insert(lsp_clients, client(7))
+      end
+    end,
+  },
+  bo = { [1] = { filetype = 'go' }, [2] = { filetype = '' } },
+  lsp = {
+    get_clients = function()
+      return lsp_clients
+    end,
+    get_client_by_id = function(id)
+      for _, existing in ipairs(lsp_clients) do
+        if existing.id == id then
+          return existing
+        end
+      end
+    end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function(fn)
+    table.insert(deferred, fn)
+  end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = function(message)
+    table.insert(errors, message)
+  end,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function(key)
+    if key == 'detach' then
+      return detach_settings
+    end
+  end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.test'] = { summary = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = {
+  setup = noop,
+  stop_all = function()
+    lsp_stops = lsp_stops + 1
+  end,
+  switch_container = noop,
+}
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function()
+    return false
+  end,
+}
+package.loaded['container.lifecycle'] = {
+  run_pre_stop_command = function(_, _, callback)
+    callback(true)
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  get_stop_timeout = function()
+    return 10
+  end,
+  run_docker_command_async = noop,
+  get_container_status = function(container_id)
+    return container_status[container_id]
+  end,
+  stop_container_async = function(container_id, callback)
+    table.insert(stop_callbacks, { container_id = container_id, callback = callback })
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+container._start_stopped_container = function(container_id)
+  table.insert(restarted, container_id)
+end
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  detach_settings, container_status = {}, { ['ctr-a'] = 'running' }
+  autocmds, filetype_buffers, deferred, stopped_clients, stop_callbacks, restarted, errors = {}, {}, {}, {}, {}, {}, {}
+  lsp_clients, lsp_stops = { client(5) }, 0
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Last User autocmd fired with a pattern
+local function fired(pattern)
+  for i = #autocmds, 1, -1 do
+    if autocmds[i].pattern == pattern then
+      return autocmds[i]
+    end
+  end
+  return nil
+end
+
+local function run_deferred()
+  local pending = deferred
+  deferred = {}
+  for _, fn in ipairs(pending) do
+    fn()
+  end
+end
+
+local function attach()
+  buffer_name = '/projects/a/main.go'
+  container._sync_workspace()
+  container._restore_attached_container({ id = 'ctr-a', status = 'Up' }, { name = 'a' }, nil)
+  autocmds = {}
+end
+
+container.setup({})
+
+print('Running detach tests...')
+print()
+
+test('detaching hands the buffers to the host and keeps the container running', function()
+  attach()
+  local received
+  local unsubscribe = container.on('detached', function(data)
+    received = data.container_id
+  end)
+  assert_equals(container.detach(), true, 'detached')
+  unsubscribe()
+  assert_equals(received, 'ctr-a', 'subscribers of the detached event')
+  assert_equals(lsp_stops, 1, 'container LSP clients stopped')
+  assert_equals(#stop_callbacks, 0, 'container not stopped')
+  assert_equals(container.get_container_id(), nil, 'commands no longer use the container')
+  assert_equals(container.status().state, 'none', 'state')
+  local detached = fired('ContainerDetached')
+  assert_equals(detached.data.container_id, 'ctr-a', 'detached container')
+  assert_equals(detached.data.container_name, 'a', 'container name')
+  assert_equals(detached.data.stopped, false, 'still running')
+  assert_equals(table.concat(filetype_buffers, ','), '1', 'FileType fired for buffers with a filetype')
+end)
+
+test('reopening a running container stops the host clients and attaches again', function()
+  attach()
+  container.detach()
+  run_deferred()
+  assert_equals(container.reopen(), true, 'reopened')
+  assert_equals(table.concat(stopped_clients, ','), '7', 'only the client started on the host stopped')
+  assert_equals(container.get_container_id(), 'ctr-a', 'container back')
+  assert_equals(container.status().state, 'running', 'state')
+  assert_equals(fired('ContainerAttached').data.container_id, 'ctr-a', 'attached')
+  assert_equals(container.reopen(), false, 'not detached anymore')
+end)
+
+test('detach.stop_container stops the container before the host takes over', function()
+  detach_settings = { stop_container = true }
+  attach()
+  assert_equals(container.detach(), true, 'detaching')
+  assert_equals(stop_callbacks[1].container_id, 'ctr-a', 'docker stop')
+  assert_equals(fired('ContainerDetached'), nil, 'not before the stop finished')
+  assert_equals(#filetype_buffers, 0, 'host tooling waits for the stop')
+
+  stop_callbacks[1].callback(true)
+  assert_equals(fired('ContainerDetached').data.stopped, true, 'detached with the container stopped')
+  assert_equals(#filetype_buffers, 1, 'host tooling resumed')
+end)
+
+test('reopening a stopped container starts it again', function()
+  detach_settings = { stop_container = true }
+  attach()
+  container.detach()
+  stop_callbacks[1].callback(true)
+  container_status['ctr-a'] = 'exited'
+  assert_equals(container.reopen(), true, 'reopening')
+  assert_equals(restarted[1], 'ctr-a', 'container started')
+  assert_equals(container.get_container_id(), 'ctr-a', 'container set')
+  assert_equals(container.status().state, 'building', 'state while starting')
+end)
+
+test('reopening fails when the detached container is gone', function()
+  attach()
+  container.detach()
+  container_status['ctr-a'] = nil
+  assert_equals(container.reopen(), false, 'not reopened')
+  assert_equals(errors[1], 'The detached container no longer exists. Use :ContainerStart to create one', 'error')
+  assert_equals(container.reopen(), false, 'detached state cleared')
+end)
+
+test('detaching without a container is an error', function()
+  buffer_name = '/projects/c/main.go'
+  assert_equals(container.detach(), false, 'not detached')
+  assert_equals(errors[1], 'No active container', 'error')
+end)
+
+print()
+print(string.format('=== Detach Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -840,6 +840,9 @@ local function setup_dependency_mocks()
       -- Return empty errors array (all valid)
       return {}
     end,
+    add_additional_mounts = function(config)
+      return config, {}
+    end,
     merge_with_plugin_config = function(devcontainer_config, plugin_config)
       -- Return merged configuration
       return devcontainer_config
@@ -0,0 +1,176 @@
+#!/usr/bin/env lua
+
+-- Test script for container.docker.compose module
+-- Run with: lua test/unit/test_docker_compose.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/test/project'
+    end,
+    stdpath = function()
+      return '/tmp/cache'
+    end,
+    sha256 = function(str)
+      local hash = 0
+      for i = 1, #str do
+        hash = (hash * 31 + string.byte(str, i)) % 0x100000000
+      end
+      return string.format('%08x', hash)
+    end,
+  },
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
+  tbl_contains = function(tbl, value)
+    for _, v in ipairs(tbl) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  tbl_isempty = function(t)
+    return next(t) == nil
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local compose = require('container.docker.compose')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local base_config = {
+  name = 'My App',
+  base_path = '/test/project',
+  compose_files = { '/test/project/.devcontainer/docker-compose.yml', '/test/project/.devcontainer/extra.yml' },
+  service = 'app',
+  run_services = { 'db' },
+  ports = { { host_port = 3000, container_port = 3000 } },
+}
+
+print('Running docker compose tests...')
+print()
+
+test('compose config detection', function()
+  assert_equals(compose.is_compose_config(base_config), true, 'compose config')
+  assert_equals(compose.is_compose_config({ image = 'ubuntu' }), false, 'image config')
+end)
+
+test('base args include project name and all compose files', function()
+  local args = compose.build_base_args(base_config, false)
+  assert_equals(args[1], 'compose', 'subcommand')
+  assert_equals(args[2], '-p', 'project flag')
+  assert(args[3]:match('^my%-app%-%x+%-devcontainer$'), 'project name: ' .. args[3])
+  assert_equals(args[5], base_config.compose_files[1], 'first file')
+  assert_equals(args[7], base_config.compose_files[2], 'second file')
+  assert_equals(#args, 7, 'no override')
+end)
+
+test('override file is appended when requested', function()
+  local args = compose.build_base_args(base_config, true)
+  assert_equals(#args, 9, 'with override')
+  assert(args[9]:match('%.json$'), 'override path')
+end)
+
+test('services to start include attached service', function()
+  local services = compose.get_services_to_start(base_config)
+  assert_equals(#services, 2, 'service count')
+  assert_equals(services[1], 'db', 'run service')
+  assert_equals(services[2], 'app', 'attached service')
+end)
+
+test('empty runServices starts all services', function()
+  local services = compose.get_services_to_start({ service = 'app', run_services = {} })
+  assert_equals(#services, 0, 'no explicit services')
+end)
+
+test('override publishes ports when service has no network config', function()
+  local override = compose.build_override(base_config, { services = { app = { image = 'node' } } })
+  local service = override.services.app
+  assert_equals(service.ports[1], '3000:3000', 'published port')
+  assert_equals(service.entrypoint, nil, 'service command kept by default')
+  assert(service.labels['container.nvim.workspace'], 'workspace label')
+end)
+
+test('ports of other services are not published on the attached service', function()
+  local config = vim.deepcopy(base_config)
+  table.insert(config.ports, { host_port = 5432, container_port = 5432, service = 'db' })
+  local override = compose.build_override(config, { services = { app = { image = 'node' } } })
+  assert_equals(#override.services.app.ports, 1, 'attached service ports only')
+  assert_equals(override.services.db, nil, 'service left untouched')
+end)
+
+test('only appPort is published when forwardPorts are forwarded after start', function()
+  local config = vim.deepcopy(base_config)
+  config.ports[1].source = 'forwardPorts'
+  table.insert(config.ports, { host_port = 8000, container_port = 80, source = 'appPort' })
+  local plugin_config = require('container.config').get()
+  plugin_config.port_forwarding = { forward_ports = 'start' }
+  local override = compose.build_override(config, { services = { app = { image = 'node' } } })
+  plugin_config.port_forwarding = nil
+  assert_equals(#override.services.app.ports, 1, 'appPort only')
+  assert_equals(override.services.app.ports[1], '8000:80', 'published appPort')
+end)
+
+test('override keeps service network untouched', function()
+  local override = compose.build_override(base_config, { services = { app = { network_mode = 'service:db' } } })
+  assert_equals(override.services.app.ports, nil, 'no ports with network_mode')
+  assert_equals(override.services.app.networks, nil, 'no extra networks')
+end)
+
+test('overrideCommand false keeps service command', function()
+  local config = vim.deepcopy(base_config)
+  config.override_command = false
+  local override = compose.build_override(config, nil)
+  assert_equals(override.services.app.entrypoint, nil, 'no entrypoint override')
+end)
+
+test('overrideCommand true keeps the service alive', function()
+  local config = vim.deepcopy(base_config)
+  config.override_command = true
+  local override = compose.build_override(config, nil)
+  assert_equals(override.services.app.entrypoint[1], '/bin/sh', 'keep-alive entrypoint')
+end)
+
+print()
+print(string.format('=== Docker Compose Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
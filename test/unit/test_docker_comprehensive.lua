@@ -117,6 +117,20 @@ _G.vim = {
     end
     return result
   end,
+  tbl_contains = function(tbl, value)
+    for _, v in ipairs(tbl) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
   system = function(cmd)
     return vim.fn.system(cmd)
   end,
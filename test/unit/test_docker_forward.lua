@@ -0,0 +1,148 @@
+#!/usr/bin/env lua
+
+-- Test script for container.docker.forward module
+-- Run with: lua test/unit/test_docker_forward.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  tbl_keys = function(t)
+    local keys = {}
+    for k, _ in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  split = function(str, sep)
+    local parts = {}
+    for part in (str .. sep):gmatch('(.-)' .. sep) do
+      table.insert(parts, part)
+    end
+    return parts
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local forward = require('container.docker.forward')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running docker forward tests...')
+print()
+
+test('sidecar name is derived from container name and port', function()
+  assert_equals(forward.get_sidecar_name('/my-app-devcontainer', 3000), 'my-app-devcontainer-forward-3000', 'name')
+end)
+
+test('bridge network target uses container IP', function()
+  local target = forward.get_network_target({
+    HostConfig = { NetworkMode = 'bridge' },
+    NetworkSettings = { Networks = { bridge = { IPAddress = '172.17.0.2' } } },
+  })
+  assert_equals(target.network, 'bridge', 'network')
+  assert_equals(target.ip, '172.17.0.2', 'ip')
+end)
+
+test('user defined network is found when mode is default', function()
+  local target = forward.get_network_target({
+    HostConfig = { NetworkMode = 'default' },
+    NetworkSettings = { Networks = { app_net = { IPAddress = '10.0.0.5' } } },
+  })
+  assert_equals(target.network, 'app_net', 'network')
+end)
+
+test('host network needs no sidecar', function()
+  local target = forward.get_network_target({ HostConfig = { NetworkMode = 'host' } })
+  assert_equals(target.mode, 'host', 'host mode')
+end)
+
+test('network modes without publish capability suggest a rebuild', function()
+  local target, err = forward.get_network_target({ HostConfig = { NetworkMode = 'none' } })
+  assert_equals(target, nil, 'no target')
+  assert(err:find('rebuild', 1, true), 'rebuild suggestion')
+  target = forward.get_network_target({ HostConfig = { NetworkMode = 'container:abc' } })
+  assert_equals(target, nil, 'shared network namespace')
+end)
+
+test('sidecar run arguments publish and relay the port', function()
+  local args = forward.build_run_args({
+    name = 'app-forward-3000',
+    container_id = 'abc123',
+    network = 'bridge',
+    ip = '172.17.0.2',
+    container_port = 3000,
+    host_port = 3001,
+    bind_address = '127.0.0.1',
+  })
+  local joined = table.concat(args, ' ')
+  assert(joined:find('--label container.nvim.forward=abc123', 1, true), 'label')
+  assert(joined:find('-p 127.0.0.1:3001:3000', 1, true), 'publish')
+  assert_equals(args[#args], 'TCP-CONNECT:172.17.0.2:3000', 'relay target')
+  assert_equals(args[#args - 2], forward.DEFAULT_IMAGE, 'default image')
+end)
+
+test('running sidecars are parsed back into forwards', function()
+  local forward_entry = forward.parse_sidecar_line('app-forward-3000\t127.0.0.1:3001->3000/tcp\tbridge')
+  assert_equals(forward_entry.container_port, 3000, 'container port')
+  assert_equals(forward_entry.host_port, 3001, 'host port')
+  assert_equals(forward_entry.sidecar, 'app-forward-3000', 'sidecar')
+  assert_equals(forward_entry.network, 'bridge', 'network')
+  assert_equals(forward.parse_sidecar_line('app-forward-3000\t\tbridge'), nil, 'no published port')
+  forward_entry = forward.parse_sidecar_line('db-1-forward-5432\t0.0.0.0:5432->5432/tcp\tproject_default\tdb')
+  assert_equals(forward_entry.service, 'db', 'service of the forward')
+end)
+
+test('forwards of other compose services are owned by the attached container', function()
+  local args = forward.build_run_args({
+    name = 'db-1-forward-5432',
+    container_id = 'app123',
+    service = 'db',
+    network = 'project_default',
+    ip = '172.18.0.3',
+    container_port = 5432,
+    host_port = 5432,
+  })
+  local joined = table.concat(args, ' ')
+  assert(joined:find('--label container.nvim.forward=app123', 1, true), 'listed and stopped with the attached container')
+  assert(joined:find('--label container.nvim.forward.service=db', 1, true), 'service label')
+  assert_equals(args[#args], 'TCP-CONNECT:172.18.0.3:5432', 'relay to the service container')
+end)
+
+print()
+print(string.format('=== Docker Forward Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,128 @@
+#!/usr/bin/env lua
+
+-- Test script for container.docker.health module
+-- Run with: lua test/unit/test_docker_health.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local clock = 0
+local health_outputs = {}
+
+-- Mock vim global for testing
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  json = {
+    -- Only the shapes used by the tests
+    decode = function(text)
+      local status = text:match('"Status":"(%w+)"')
+      local output = text:match('"Output":"([^"]*)"')
+      return { Status = status, Log = output and { { Output = output, ExitCode = 1 } } or {} }
+    end,
+  },
+  loop = {
+    now = function()
+      return clock
+    end,
+  },
+  defer_fn = function(fn, delay)
+    clock = clock + delay
+    fn()
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+-- Mock docker module returning the queued inspect outputs
+package.loaded['container.docker'] = {
+  run_docker_command_async = function(_, _, callback)
+    local stdout = table.remove(health_outputs, 1) or health_outputs.last
+    callback({ success = true, stdout = stdout })
+  end,
+}
+
+local health = require('container.docker.health')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running docker health tests...')
+print()
+
+test('containers without a health check have no health state', function()
+  assert_equals(health.parse_health('null\n'), nil, 'null')
+  assert_equals(health.parse_health(''), nil, 'empty')
+  local parsed = health.parse_health('{"Status":"starting","Log":[{"Output":"connection refused"}]}')
+  assert_equals(parsed.status, 'starting', 'status')
+  assert_equals(parsed.output, 'connection refused', 'last probe output')
+end)
+
+test('waiting ends when the container becomes healthy', function()
+  clock = 0
+  health_outputs = { '{"Status":"starting"}', '{"Status":"starting"}', '{"Status":"healthy"}' }
+  local statuses = {}
+  local result
+  health.wait('abc', {
+    timeout = 30,
+    on_status = function(h)
+      table.insert(statuses, h.status)
+    end,
+  }, function(ready)
+    result = ready
+  end)
+  assert_equals(result, true, 'ready')
+  assert_equals(#statuses, 1, 'status reported once per change')
+end)
+
+test('waiting times out with the last probe output', function()
+  clock = 0
+  health_outputs = { last = '{"Status":"unhealthy","Log":[{"Output":"curl: (7) Failed to connect"}]}' }
+  local result, last
+  health.wait('abc', { timeout = 5 }, function(ready, h)
+    result, last = ready, h
+  end)
+  assert_equals(result, false, 'not ready')
+  assert_equals(last.output, 'curl: (7) Failed to connect', 'probe output')
+  assert_equals(clock, 5000, 'polled until the timeout')
+end)
+
+test('images without a health check are ready immediately', function()
+  health_outputs = { 'null' }
+  local result
+  health.wait('abc', { timeout = 5 }, function(ready)
+    result = ready
+  end)
+  assert_equals(result, true, 'ready')
+  assert_equals(health_outputs[1], nil, 'single inspect')
+end)
+
+print()
+print(string.format('=== Docker Health Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
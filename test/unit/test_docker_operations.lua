@@ -16,6 +16,7 @@ _G.vim.list_extend = function(list, items)
   end
   return list
 end
+_G.vim.env = {}
 
 local tests = {}
 
@@ -180,6 +181,112 @@ function tests.test_docker_command_building()
   return true
 end
 
+-- Test runArgs and hostRequirements.gpu in the create command
+function tests.test_run_args_and_gpu()
+  print('\n=== runArgs and GPU Test ===')
+
+  local docker = require('container.docker')
+
+  local function index_of(args, value)
+    for i, arg in ipairs(args) do
+      if arg == value then
+        return i
+      end
+    end
+    return nil
+  end
+
+  local args = docker._build_create_args({
+    name = 'ml',
+    image = 'pytorch:latest',
+    remote_user = 'vscode',
+    host_requirements = { gpu = true },
+    run_args = { '--shm-size=1g', '--cap-add', 'SYS_PTRACE', '--user', 'root' },
+  })
+
+  -- runArgs keep their order and come after the generated flags, right before the image
+  local image_index = index_of(args, 'pytorch:latest')
+  local shm_index = index_of(args, '--shm-size=1g')
+  if not shm_index or args[shm_index + 1] ~= '--cap-add' or args[shm_index + 2] ~= 'SYS_PTRACE' then
+    print('✗ runArgs order not preserved:', table.concat(args, ' '))
+    return false
+  end
+  if shm_index + 5 ~= image_index or index_of(args, '--user') > shm_index then
+    print('✗ runArgs should follow the generated flags so they take precedence')
+    return false
+  end
+  print('✓ runArgs appended in order before the image')
+
+  local gpus_index = index_of(args, '--gpus')
+  if not gpus_index or args[gpus_index + 1] ~= 'all' then
+    print('✗ hostRequirements.gpu should add --gpus all')
+    return false
+  end
+  print('✓ hostRequirements.gpu adds --gpus all')
+
+  -- GPUs requested in runArgs are not requested twice, and a missing GPU setup drops the flag
+  args = docker._build_create_args({
+    image = 'pytorch:latest',
+    host_requirements = { gpu = true },
+    run_args = { '--gpus=device=0' },
+  })
+  if index_of(args, '--gpus') then
+    print('✗ --gpus added although runArgs request GPUs')
+    return false
+  end
+  args = docker._build_create_args({
+    image = 'pytorch:latest',
+    host_requirements = { gpu = true },
+    gpu_available = false,
+  })
+  if index_of(args, '--gpus') then
+    print('✗ --gpus added although no GPU support was found')
+    return false
+  end
+  print('✓ GPU flag skipped when runArgs request GPUs or none are available')
+
+  -- Ports are not published on the host network
+  args = docker._build_create_args({
+    image = 'alpine:latest',
+    ports = { { host_port = 3000, container_port = 3000 } },
+    run_args = { '--network', 'host' },
+  })
+  if index_of(args, '-p') then
+    print('✗ -p should be omitted with --network host')
+    return false
+  end
+  print('✓ Ports not published with --network host')
+
+  if docker.find_run_arg({ '--init', '--net=host' }, { '--network', '--net' }) ~= 'host' then
+    print('✗ find_run_arg should read --flag=value')
+    return false
+  end
+  if docker.find_run_arg({ '--init', '--privileged' }, { '--init' }) ~= true then
+    print('✗ find_run_arg should report flags without value')
+    return false
+  end
+  if docker.find_run_arg({ '--network=host', '--network', 'bridge' }, { '--network' }) ~= 'bridge' then
+    print('✗ find_run_arg should return the last value of a repeated flag')
+    return false
+  end
+  print('✓ runArgs flags looked up')
+
+  -- overrideCommand: the keep-alive command replaces the image's command unless it is false
+  args = docker._build_create_args({ image = 'app:latest' })
+  if index_of(args, '--entrypoint') == nil or args[#args] ~= docker.KEEP_ALIVE_COMMAND then
+    print('✗ keep-alive command missing by default:', table.concat(args, ' '))
+    return false
+  end
+  args = docker._build_create_args({ image = 'app:latest', override_command = false })
+  if index_of(args, '--entrypoint') or args[#args] ~= 'app:latest' then
+    print('✗ overrideCommand false should run the image command:', table.concat(args, ' '))
+    return false
+  end
+  print('✓ overrideCommand decides the container command')
+
+  return true
+end
+
 -- Test shell detection logic
 function tests.test_shell_detection()
   print('\n=== Shell Detection Test ===')
@@ -299,6 +406,136 @@ function tests.test_container_operations()
   return true
 end
 
+-- Test privileged, capAdd and securityOpt flags
+function tests.test_security_args()
+  print('\n=== Security Flags Test ===')
+
+  local docker = require('container.docker')
+  local args = docker._build_create_args({
+    name = 'go',
+    image = 'golang:1.22',
+    cap_add = { 'SYS_PTRACE' },
+    security_opt = { 'seccomp=unconfined' },
+  })
+  local joined = table.concat(args, ' ')
+  if not joined:find('--cap-add SYS_PTRACE --security-opt seccomp=unconfined', 1, true) then
+    print('✗ capAdd and securityOpt should become docker create flags:', joined)
+    return false
+  end
+  if joined:find('--privileged', 1, true) then
+    print('✗ --privileged added without privileged')
+    return false
+  end
+  print('✓ capAdd and securityOpt become --cap-add and --security-opt')
+
+  args = docker.security_args({ privileged = true, cap_add = {}, security_opt = {} })
+  if table.concat(args, ' ') ~= '--privileged' then
+    print('✗ privileged should add --privileged:', table.concat(args, ' '))
+    return false
+  end
+  print('✓ privileged adds --privileged')
+
+  return true
+end
+
+-- Test that a restart keeps the container and uses the stop timeout
+function tests.test_restart_container_async()
+  print('\n=== Restart Container Test ===')
+
+  local docker = require('container.docker')
+  local original = docker.run_docker_command_async
+  local calls = {}
+  docker.run_docker_command_async = function(args, _, callback)
+    table.insert(calls, args)
+    callback({ success = true, stdout = '', stderr = '' })
+  end
+
+  local result
+  docker.restart_container_async('abc123', function(success, err)
+    result = { success = success, err = err }
+  end, 5)
+  docker.run_docker_command_async = original
+
+  if table.concat(calls[1] or {}, ' ') ~= 'restart -t 5 abc123' then
+    print('✗ Unexpected restart arguments:', table.concat(calls[1] or {}, ' '))
+    return false
+  end
+  if not result or result.success ~= true or result.err ~= nil then
+    print('✗ Restart callback should report success')
+    return false
+  end
+  print('✓ docker restart runs on the same container with the stop timeout')
+
+  return true
+end
+
+-- Test that idempotent commands are retried with backoff after transient failures
+function tests.test_retry_transient_failures()
+  print('\n=== Retry Transient Failures Test ===')
+
+  local docker = require('container.docker')
+  local original_jobstart, original_defer, original_schedule = vim.fn.jobstart, vim.defer_fn, vim.schedule
+  local runs, delays = {}, {}
+  local failures = {}
+  vim.schedule = function(fn)
+    fn()
+  end
+  vim.defer_fn = function(fn, delay)
+    table.insert(delays, delay)
+    fn()
+  end
+  vim.fn.jobstart = function(cmd, opts)
+    table.insert(runs, table.concat(cmd, ' '))
+    local failure = table.remove(failures, 1)
+    if failure then
+      opts.on_stderr(1, { failure, '' })
+    end
+    opts.on_exit(1, failure and 1 or 0)
+    return #runs
+  end
+
+  local function run(args, opts)
+    runs, delays = {}, {}
+    local result
+    docker.run_docker_command_async(args, opts or {}, function(r)
+      result = r
+    end)
+    return result
+  end
+
+  failures = { 'Error response from daemon: Get "https://registry-1.docker.io/v2/": net/http: TLS handshake timeout' }
+  local pulled = run({ 'pull', 'alpine' })
+  failures = { 'Bind for 0.0.0.0:8080 failed: port is already allocated' }
+  local created = run({ 'create', '--name', 'app', 'alpine' })
+  local create_runs = #runs
+  failures = { 'manifest unknown', 'manifest unknown' }
+  local unknown = run({ 'pull', 'alpine:nope' })
+  local unknown_runs = #runs
+  failures = { 'i/o timeout', 'i/o timeout', 'i/o timeout', 'i/o timeout' }
+  local exhausted = run({ 'network', 'create', 'dev' })
+  vim.fn.jobstart, vim.defer_fn, vim.schedule = original_jobstart, original_defer, original_schedule
+
+  if not pulled or not pulled.success then
+    print('✗ A pull failing with a timeout should be retried')
+    return false
+  end
+  if create_runs ~= 1 or created.success then
+    print('✗ docker create must not be retried:', create_runs)
+    return false
+  end
+  if unknown_runs ~= 1 or unknown.success then
+    print('✗ Permanent failures should not be retried:', unknown_runs)
+    return false
+  end
+  if #runs ~= 4 or exhausted.success or table.concat(delays, ' ') ~= '1000 2000 4000' then
+    print('✗ Retries should back off exponentially up to docker.retries:', #runs, table.concat(delays, ' '))
+    return false
+  end
+  print('✓ Transient failures of idempotent commands are retried with backoff')
+
+  return true
+end
+
 -- Test async command execution with errors
 function tests.test_async_command_errors()
   print('\n=== Async Command Errors Test ===')
@@ -572,10 +809,14 @@ local function run_docker_unit_tests()
     tests.test_docker_module_init,
     tests.test_container_name_generation,
     tests.test_docker_command_building,
+    tests.test_run_args_and_gpu,
     tests.test_shell_detection,
     tests.test_docker_command_execution_dry,
     tests.test_image_operations,
     tests.test_container_operations,
+    tests.test_security_args,
+    tests.test_restart_container_async,
+    tests.test_retry_transient_failures,
     tests.test_async_command_errors,
     tests.test_pull_image_operations,
     tests.test_logs_and_ports,
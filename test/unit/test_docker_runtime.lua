@@ -0,0 +1,122 @@
+#!/usr/bin/env lua
+
+-- Test script for container.docker.runtime module
+-- Run with: lua test/unit/test_docker_runtime.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local host = { uid = 1000, executables = { docker = true, podman = true } }
+local plugin_config = {}
+
+-- Mock vim global for testing
+_G.vim = {
+  env = {},
+  loop = {
+    getuid = function()
+      return host.uid
+    end,
+  },
+  fn = {
+    executable = function(name)
+      return host.executables[name] and 1 or 0
+    end,
+  },
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+-- Mock config module
+package.loaded['container.config'] = {
+  get = function()
+    return plugin_config
+  end,
+}
+
+local runtime = require('container.docker.runtime')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running docker runtime tests...')
+print()
+
+test('configured runtime is used as the binary', function()
+  plugin_config.container_runtime = nil
+  assert_equals(runtime.get(), 'docker', 'default')
+  plugin_config.container_runtime = 'podman'
+  assert_equals(runtime.get(), 'podman', 'podman')
+  assert_equals(runtime.is_podman(), true, 'is_podman')
+end)
+
+test('auto detection prefers docker and falls back to podman', function()
+  assert_equals(runtime.detect(), 'docker', 'both installed')
+  host.executables.docker = false
+  assert_equals(runtime.detect(), 'podman', 'only podman')
+  host.executables.docker = true
+end)
+
+test('rootless podman keeps the host user namespace', function()
+  plugin_config.container_runtime = 'podman'
+  assert_equals(table.concat(runtime.create_args(), ' '), '--userns=keep-id', 'rootless')
+  assert_equals(#runtime.create_args({ '--userns=auto' }), 0, 'runArgs take precedence')
+
+  host.uid = 0
+  assert_equals(#runtime.create_args(), 0, 'rootful podman')
+  host.uid = 1000
+
+  plugin_config.container_runtime = 'docker'
+  assert_equals(#runtime.create_args(), 0, 'docker')
+end)
+
+test('remote daemons are detected from DOCKER_HOST', function()
+  assert_equals(runtime.is_remote_host(nil), false, 'unset')
+  assert_equals(runtime.is_remote_host('unix:///var/run/docker.sock'), false, 'unix socket')
+  assert_equals(runtime.is_remote_host('npipe:////./pipe/docker_engine'), false, 'named pipe')
+  assert_equals(runtime.is_remote_host('tcp://localhost:2375'), false, 'localhost')
+  assert_equals(runtime.is_remote_host('tcp://127.0.0.1:2375'), false, 'loopback')
+  assert_equals(runtime.is_remote_host('tcp://[::1]:2375'), false, 'ipv6 loopback')
+  assert_equals(runtime.is_remote_host('ssh://me@host'), true, 'ssh')
+  assert_equals(runtime.is_remote_host('tcp://build-server:2376'), true, 'tcp')
+
+  plugin_config.container_runtime = 'docker'
+  vim.env.DOCKER_HOST = 'ssh://me@host'
+  assert_equals(runtime.is_remote(), true, 'DOCKER_HOST')
+  plugin_config.container_runtime = 'podman'
+  assert_equals(runtime.is_remote(), false, 'podman reads CONTAINER_HOST')
+  vim.env.CONTAINER_HOST = 'ssh://me@host'
+  assert_equals(runtime.is_remote(), true, 'CONTAINER_HOST')
+  assert_equals(#runtime.create_args(), 0, 'no user namespace option on a remote daemon')
+  vim.env.DOCKER_HOST = nil
+  vim.env.CONTAINER_HOST = nil
+  plugin_config.container_runtime = 'docker'
+end)
+
+print()
+print(string.format('=== Docker Runtime Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,111 @@
+#!/usr/bin/env lua
+
+-- Test script for container.docker.uid module
+-- Run with: lua test/unit/test_docker_uid.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local host = { sysname = 'Linux', uid = 1000, gid = 1000 }
+
+-- Mock vim global for testing
+_G.vim = {
+  loop = {
+    os_uname = function()
+      return { sysname = host.sysname }
+    end,
+    getuid = function()
+      return host.uid
+    end,
+    getgid = function()
+      return host.gid
+    end,
+  },
+  fn = {
+    sha256 = function(str)
+      local hash = 0
+      for i = 1, #str do
+        hash = (hash * 31 + string.byte(str, i)) % 0x100000000
+      end
+      return string.format('%08x%08x', hash, hash)
+    end,
+  },
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local uid = require('container.docker.uid')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running docker uid tests...')
+print()
+
+test('remoteUser is remapped on Linux hosts', function()
+  assert_equals(uid.should_update({ remote_user = 'vscode' }), true, 'remote user')
+  assert_equals(uid.should_update({ container_user = 'node' }), true, 'container user fallback')
+end)
+
+test('remap is skipped when disabled or not applicable', function()
+  assert_equals(uid.should_update({ remote_user = 'vscode', update_remote_user_uid = false }), false, 'disabled')
+  assert_equals(uid.should_update({ remote_user = 'root' }), false, 'root user')
+  assert_equals(uid.should_update({ remote_user = '1001' }), false, 'numeric user')
+  assert_equals(uid.should_update({}), false, 'no user')
+
+  host.sysname = 'Darwin'
+  assert_equals(uid.should_update({ remote_user = 'vscode' }), false, 'non-Linux host')
+  host.sysname = 'Linux'
+
+  host.uid = 0
+  assert_equals(uid.should_update({ remote_user = 'vscode' }), false, 'root host user')
+  host.uid = 1000
+end)
+
+test('dockerfile remaps as root and restores the image user', function()
+  local dockerfile = uid.generate_dockerfile('node:20', 'node')
+  assert(dockerfile:find('FROM node:20\nUSER root\n', 1, true), 'starts as root')
+  assert(dockerfile:find('ARG NEW_UID', 1, true), 'uid build arg')
+  assert(dockerfile:find('USER node\n$'), 'restores image user')
+  assert(not uid.generate_dockerfile('node:20', ''):find('USER node', 1, true), 'no user to restore')
+end)
+
+test('build args pass user and host ids', function()
+  local args = table.concat(uid.build_args('vscode', 1000, 1001), ' ')
+  assert_equals(args, '--build-arg REMOTE_USER=vscode --build-arg NEW_UID=1000 --build-arg NEW_GID=1001', 'args')
+end)
+
+test('image tag depends on base image and ids', function()
+  local tag = uid.image_tag('node:20', 'node', 1000, 1000)
+  assert(tag:match('^container%-nvim%-uid:%x+$'), 'tag format')
+  assert(tag ~= uid.image_tag('node:20', 'node', 1001, 1000), 'different uid')
+end)
+
+print()
+print(string.format('=== Docker UID Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
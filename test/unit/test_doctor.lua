@@ -0,0 +1,226 @@
+#!/usr/bin/env lua
+
+-- Test script for container.doctor module
+-- Run with: lua test/unit/test_doctor.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Command responses keyed by the joined command line: { output, exit code }
+local responses = {}
+local executables = { docker = 1, podman = 0 }
+
+_G.vim = {
+  v = { shell_error = 0 },
+  fn = {
+    system = function(args)
+      local response = responses[table.concat(args, ' ')] or { '', 0 }
+      vim.v.shell_error = response[2]
+      return response[1]
+    end,
+    executable = function(name)
+      return executables[name] or 0
+    end,
+    filereadable = function()
+      return 1
+    end,
+    getcwd = function()
+      return '/project'
+    end,
+  },
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+local remote = false
+local devcontainer = nil
+local container_id = nil
+
+package.loaded['container.docker.runtime'] = {
+  RUNTIMES = { 'docker', 'podman' },
+  get = function()
+    return 'docker'
+  end,
+  docker_host = function()
+    return remote and 'ssh://user@build-host' or nil
+  end,
+  is_remote = function()
+    return remote
+  end,
+}
+package.loaded['container.docker'] = {
+  ping_args = function()
+    return { 'docker', 'info', '--format', '{{.ServerVersion}}' }
+  end,
+}
+package.loaded['container.config'] = {
+  get = function()
+    return {}
+  end,
+}
+package.loaded['container.config.validator'] = {
+  validate = function()
+    return true, {}
+  end,
+}
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {}
+package.loaded['container'] = {
+  get_container_id = function()
+    return container_id
+  end,
+  get_state = function()
+    return { current_config = {} }
+  end,
+}
+package.loaded['container.parser'] = {
+  workspace_roots = function()
+    return '/project', '/workspaces/project'
+  end,
+  find_devcontainer_json = function()
+    return devcontainer and '/project/.devcontainer/devcontainer.json' or nil
+  end,
+  parse = function()
+    return devcontainer
+  end,
+  validate = function()
+    return {}
+  end,
+  normalize_for_plugin = function(config)
+    return config
+  end,
+}
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function(config)
+    return config.compose_files ~= nil
+  end,
+}
+
+local doctor = require('container.doctor')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function reset()
+  remote = false
+  devcontainer = nil
+  container_id = nil
+  executables = { docker = 1, podman = 0 }
+  responses = {
+    ['docker --version'] = { 'Docker version 27.0.3\n', 0 },
+    ['docker info --format {{.ServerVersion}}'] = { '27.0.3\n', 0 },
+    ['docker compose version --short'] = { '2.28.1\n', 0 },
+  }
+end
+
+print('Running doctor tests...')
+print()
+
+test('missing runtime suggests an installed alternative', function()
+  reset()
+  executables = { docker = 0, podman = 1 }
+  local results = doctor.collect()
+  assert_equals(#results, 1, 'stops after the runtime check')
+  assert_equals(results[1].level, 'error', 'level')
+  assert_equals(results[1].message, 'docker not found in PATH', 'message')
+  assert(results[1].hint:match('podman'), 'hint names podman')
+end)
+
+test('unreachable daemon reports the error with a hint', function()
+  reset()
+  responses['docker info --format {{.ServerVersion}}'] = {
+    'Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?',
+    1,
+  }
+  local results = doctor.collect()
+  local last = results[#results]
+  assert_equals(last.level, 'error', 'level')
+  assert(last.message:match('^Docker daemon not reachable'), 'message')
+  assert(last.hint:match('systemctl start docker'), 'hint')
+end)
+
+test('healthy environment without devcontainer.json', function()
+  reset()
+  local results = doctor.collect()
+  for i = 1, #results - 1 do
+    assert_equals(results[i].level, 'ok', results[i].message)
+  end
+  assert_equals(results[#results].level, 'warn', 'missing devcontainer.json')
+end)
+
+test('compose configuration without compose is an error', function()
+  reset()
+  responses['docker compose version --short'] = { 'unknown command', 1 }
+  devcontainer = { name = 'app', compose_files = { 'compose.yml' } }
+  local results = doctor.collect()
+  assert_equals(results[#results].level, 'error', 'level')
+  assert(results[#results].message:match('dockerComposeFile'), 'message')
+end)
+
+test('bind mounts are flagged on a remote daemon', function()
+  reset()
+  remote = true
+  devcontainer = { name = 'app', mounts = { { type = 'bind', source = '/data' } } }
+  local found = false
+  for _, result in ipairs(doctor.collect()) do
+    if result.message == 'Bind mount ignored on a remote daemon: /data' then
+      found = result.level == 'warn'
+    end
+  end
+  assert(found, 'bind mount warning')
+end)
+
+test('mount type of the attached container workspace', function()
+  reset()
+  container_id = 'abc123'
+  responses['docker exec abc123 cat /proc/mounts'] = {
+    'overlay / overlay rw 0 0\n/dev/vda1 /workspaces/project virtiofs rw 0 0\n',
+    0,
+  }
+  local found = false
+  for _, result in ipairs(doctor.collect()) do
+    if result.message == 'Workspace mount: /workspaces/project (virtiofs, VirtioFS)' then
+      found = result.level == 'ok'
+    end
+  end
+  assert(found, 'mount type reported')
+end)
+
+test('format renders icons and hints', function()
+  local lines = doctor.format({
+    { level = 'ok', message = 'fine' },
+    { level = 'error', message = 'broken', hint = 'fix it' },
+  })
+  assert_equals(lines[3], '✓ fine', 'ok line')
+  assert_equals(lines[4], '✗ broken', 'error line')
+  assert_equals(lines[5], '    fix it', 'hint line')
+end)
+
+print()
+print(string.format('=== Doctor Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,299 @@
+#!/usr/bin/env lua
+
+-- Test script for container.dry_run module
+-- Run with: lua test/unit/test_dry_run.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local function deepcopy(value)
+  if type(value) ~= 'table' then
+    return value
+  end
+  local copy = {}
+  for k, v in pairs(value) do
+    copy[k] = deepcopy(v)
+  end
+  return copy
+end
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/projects/app'
+    end,
+    stdpath = function(what)
+      return '/home/user/.' .. what
+    end,
+    sha256 = function()
+      return 'abcdef0123456789'
+    end,
+  },
+  json = {
+    encode = function()
+      return '{"services":{}}'
+    end,
+  },
+  deepcopy = deepcopy,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  tbl_isempty = function(t)
+    return next(t) == nil
+  end,
+  tbl_contains = function(t, value)
+    for _, v in ipairs(t) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.fs'] = {
+  join_path = function(...)
+    return table.concat({ ... }, '/')
+  end,
+}
+package.loaded['container.config'] = {
+  get = function()
+    return {}
+  end,
+}
+package.loaded['container.docker.runtime'] = {
+  get = function()
+    return 'docker'
+  end,
+}
+package.loaded['container.environment'] = {
+  build_postcreate_args = function(config)
+    return config.remote_user and { '-u', config.remote_user } or {}
+  end,
+}
+
+local remap_uid = false
+package.loaded['container.docker.uid'] = {
+  should_update = function()
+    return remap_uid
+  end,
+  get_target_user = function(config)
+    return config.remote_user
+  end,
+  get_host_ids = function()
+    return 1000, 1000
+  end,
+  image_tag = function()
+    return 'container-nvim-uid:123'
+  end,
+}
+package.loaded['container.features'] = {
+  normalize = function(features)
+    local list = {}
+    for ref in pairs(features or {}) do
+      table.insert(list, { ref = ref })
+    end
+    return list
+  end,
+  image_tag = function()
+    return 'container-nvim-app-features:456'
+  end,
+}
+package.loaded['container.docker'] = {
+  WORKSPACE_LABEL = 'container.nvim.workspace',
+  get_workspace_path = function()
+    return '/projects/app'
+  end,
+  compute_image_cache_key = function()
+    return 'key'
+  end,
+  get_image_cache_tag = function(_, key)
+    return 'container-nvim-app:' .. key
+  end,
+  build_image_args = function(_, tag)
+    return { 'build', '-t', tag, '--progress=plain', '-f', '/projects/app/.devcontainer/Dockerfile', '.' }, true
+  end,
+  prebuilt_candidates = function(config)
+    return config.prebuilt_image and { config.prebuilt_image } or {}
+  end,
+  format_mount = function(mount)
+    return 'type=' .. mount.type .. ',target=' .. mount.target
+  end,
+  is_published_at_create = function()
+    return true
+  end,
+  overrides_command = function(config)
+    -- Compose services run their own command unless overrideCommand is true
+    return config.override_command == true
+  end,
+  resolve_port_conflicts = function(ports)
+    for _, port in ipairs(ports or {}) do
+      if port.host_port == 3000 then
+        port.requested_host_port = 3000
+        port.host_port = 3001
+      end
+    end
+    return ports
+  end,
+  resolve_container_name = function()
+    return 'app-abcdef01-devcontainer'
+  end,
+  _build_create_args = function(config)
+    package.loaded['container.docker'].resolve_port_conflicts(config.ports)
+    return {
+      'create',
+      '--name',
+      'app-abcdef01-devcontainer',
+      '-e',
+      'GREETING=hello world',
+      config.uid_image or config.features_image or config.built_image or config.image,
+    }
+  end,
+}
+
+local dry_run = require('container.dry_run')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function contains(lines, expected)
+  for _, line in ipairs(lines) do
+    if line == expected then
+      return true
+    end
+  end
+  return false
+end
+
+print('Running dry run tests...')
+print()
+
+test('words are quoted for the shell only when needed', function()
+  assert_equals(dry_run.shell_quote('--name'), '--name', 'plain word')
+  assert_equals(dry_run.shell_quote('type=bind,source=/a,target=/b'), 'type=bind,source=/a,target=/b', 'mount')
+  assert_equals(dry_run.shell_quote('hello world'), "'hello world'", 'space')
+  assert_equals(dry_run.shell_quote("it's"), [['it'\''s']], 'single quote')
+  assert_equals(dry_run.shell_quote(''), "''", 'empty')
+end)
+
+test('a Dockerfile config plans the build, create, start and lifecycle commands', function()
+  local config = {
+    name = 'app',
+    dockerfile = '/projects/app/.devcontainer/Dockerfile',
+    base_path = '/projects/app',
+    remote_user = 'vscode',
+    workspace_folder = '/workspace',
+    post_create_command = 'npm install',
+    ports = { { container_port = 3000, host_port = 3000 } },
+    environment = { GREETING = 'hello world' },
+    prebuilt_image = 'ghcr.io/org/app:main',
+    default_run_args = { '--network=host' },
+  }
+  local lines = dry_run.plan(config)
+  assert(contains(lines, 'docker pull ghcr.io/org/app:main'), 'prebuilt image is pulled first')
+  assert(
+    contains(
+      lines,
+      'DOCKER_BUILDKIT=1 docker build -t container-nvim-app:key --progress=plain'
+        .. ' -f /projects/app/.devcontainer/Dockerfile .'
+    ),
+    'build command'
+  )
+  assert(
+    contains(lines, "docker create --name app-abcdef01-devcontainer -e 'GREETING=hello world' container-nvim-app:key"),
+    'create runs the built image'
+  )
+  assert(contains(lines, 'docker start app-abcdef01-devcontainer'), 'start command')
+  assert(contains(lines, 'CONTAINER=app-abcdef01-devcontainer'), 'container variable')
+  assert(
+    contains(lines, [[docker exec -i -u vscode -w /workspace "$CONTAINER" /bin/sh -c 'npm install']]),
+    'lifecycle command'
+  )
+  assert(contains(lines, '#   container 3000 -> host 3001 (3000 is in use)'), 'resolved port')
+  assert(contains(lines, '#   GREETING=hello world'), 'containerEnv')
+  assert(contains(lines, '# default_run_args (before runArgs):'), 'plugin run defaults listed')
+  assert(contains(lines, '#   --network=host'), 'plugin run defaults')
+  assert(not contains(lines, '# default_build_args (before the build settings):'), 'no build defaults')
+  assert_equals(config.ports[1].host_port, 3000, 'config is not modified')
+  assert_equals(config.built_image, nil, 'no image recorded')
+end)
+
+test('an image config with features and a UID remap runs the derived image', function()
+  remap_uid = true
+  local lines = dry_run.plan({
+    name = 'app',
+    image = 'node:20',
+    remote_user = 'node',
+    features = { ['ghcr.io/devcontainers/features/go:1'] = {} },
+  })
+  remap_uid = false
+  assert(contains(lines, 'docker pull node:20'), 'pull command')
+  assert(contains(lines, '#   ghcr.io/devcontainers/features/go:1'), 'feature listed')
+  assert(contains(lines, '#   -> container-nvim-app-features:456'), 'features image')
+  assert(
+    contains(lines, "docker create --name app-abcdef01-devcontainer -e 'GREETING=hello world' container-nvim-uid:123"),
+    'create runs the remapped image'
+  )
+end)
+
+test('a compose config plans the override file and docker compose up', function()
+  local lines = dry_run.plan({
+    name = 'app',
+    base_path = '/projects/app',
+    compose_files = { '/projects/app/docker-compose.yml' },
+    compose_project_dir = '/projects/app',
+    service = 'web',
+    run_services = { 'db' },
+    workspace_folder = '/workspace',
+    post_start_command = { 'make', 'serve' },
+  })
+  local base = 'docker compose -p app-abcdef01-devcontainer -f /projects/app/docker-compose.yml'
+    .. ' -f /home/user/.cache/container.nvim/compose/app-abcdef01-devcontainer.json'
+  assert(
+    contains(lines, "cat > /home/user/.cache/container.nvim/compose/app-abcdef01-devcontainer.json <<'EOF'"),
+    'override'
+  )
+  assert(contains(lines, base .. ' up -d --build db web'), 'up command')
+  assert(contains(lines, 'CONTAINER=$(' .. base .. ' ps -q web)'), 'service container')
+  assert(contains(lines, 'docker exec -i -w /workspace "$CONTAINER" make serve'), 'lifecycle argv')
+  assert(not contains(lines, 'docker start app-abcdef01-devcontainer'), 'no docker start')
+end)
+
+print()
+print(string.format('=== Dry Run Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,199 @@
+#!/usr/bin/env lua
+
+-- Test script for container.env_file module
+-- Run with: lua test/unit/test_env_file.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  startswith = function(s, prefix)
+    return s:sub(1, #prefix) == prefix
+  end,
+  fn = {},
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+-- File system calls of vim.uv; files maps paths to { content, mode }
+local files = {}
+vim.uv = {
+  fs_unlink = function(path)
+    files[path] = nil
+  end,
+  fs_open = function(path, flags, mode)
+    if flags == 'wx' and files[path] then
+      return nil, 'EEXIST'
+    end
+    files[path] = { content = '', mode = mode }
+    return path
+  end,
+  fs_write = function(fd, content)
+    files[fd].content = content
+    return #content
+  end,
+  fs_close = function() end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.fs'] = {
+  read_file = function(path)
+    return path == '/project/.env' and 'API_TOKEN=secret\n' or nil
+  end,
+  is_absolute_path = function(path)
+    return path:sub(1, 1) == '/'
+  end,
+  resolve_path = function(path, base)
+    return base .. '/' .. path
+  end,
+  ensure_directory = function()
+    return true
+  end,
+  dirname = function(path)
+    return path:match('(.*)/')
+  end,
+  join_path = function(...)
+    return table.concat({ ... }, '/')
+  end,
+}
+package.loaded['container.parser'] = {
+  expand_host_path = function(path)
+    return path
+  end,
+}
+
+local original_getenv = os.getenv
+os.getenv = function(name)
+  if name == 'HOST_TOKEN' then
+    return 'from-host'
+  end
+  return original_getenv(name)
+end
+
+local env_file = require('container.env_file')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running env file tests...')
+print()
+
+test('KEY=VALUE lines, comments and export are parsed', function()
+  local env, keys = env_file.parse(table.concat({
+    '# database',
+    'DB_HOST=localhost',
+    '',
+    'export DB_USER = app',
+    'DB_PORT=5432 # default port',
+    'URL=http://example.com/#anchor',
+  }, '\n'))
+  assert_equals(env.DB_HOST, 'localhost', 'plain value')
+  assert_equals(env.DB_USER, 'app', 'export prefix and spaces')
+  assert_equals(env.DB_PORT, '5432', 'inline comment')
+  assert_equals(env.URL, 'http://example.com/#anchor', '# inside a value')
+  assert_equals(table.concat(keys, ','), 'DB_HOST,DB_USER,DB_PORT,URL', 'file order')
+end)
+
+test('quoted values are unquoted', function()
+  local env = env_file.parse(table.concat({
+    [[SINGLE='it is $literal \n']],
+    [[DOUBLE="line1\nline2 \"quoted\"" # comment]],
+    [[EMPTY=""]],
+  }, '\r\n'))
+  assert_equals(env.SINGLE, [[it is $literal \n]], 'single quotes are literal')
+  assert_equals(env.DOUBLE, 'line1\nline2 "quoted"', 'double quote escapes')
+  assert_equals(env.EMPTY, '', 'empty value')
+end)
+
+test('a bare KEY takes the host value and warnings carry no values', function()
+  local env, keys, warnings = env_file.parse('HOST_TOKEN\nUNSET_VARIABLE_X\nnot a variable\nBROKEN="secret')
+  assert_equals(env.HOST_TOKEN, 'from-host', 'host value')
+  assert_equals(env.UNSET_VARIABLE_X, nil, 'unset host variable is skipped')
+  assert_equals(#keys, 2, 'keys')
+  assert_equals(#warnings, 2, 'warnings')
+  assert_equals(warnings[1], 'line 3: expected KEY=VALUE', 'invalid line')
+  assert_equals(warnings[2], 'line 4: unterminated double quote', 'no value in the warning')
+end)
+
+test('rendering skips multi-line values', function()
+  local content, skipped = env_file.render({ A = '1', B = 'x\ny', C = 'a b' }, { 'A', 'B', 'C' })
+  assert_equals(content, 'A=1\nC=a b\n', 'docker format')
+  assert_equals(skipped[1], 'B', 'skipped')
+end)
+
+test('--env-file is taken out of runArgs', function()
+  local run_args, paths =
+    env_file.extract_run_args({ '--env-file', '.env', '--cap-add=SYS_PTRACE', '--env-file=a.env' })
+  assert_equals(#run_args, 1, 'remaining')
+  assert_equals(run_args[1], '--cap-add=SYS_PTRACE', 'other flags kept')
+  assert_equals(table.concat(paths, ','), '.env,a.env', 'paths')
+end)
+
+test('no env file arguments without a generated file', function()
+  assert_equals(#env_file.args({}), 0, 'none')
+  assert_equals(#env_file.args(nil), 0, 'no config')
+end)
+
+test('the generated file is created readable by the owner only', function()
+  vim.fn.stdpath = function()
+    return '/cache'
+  end
+  vim.fn.sha256 = function()
+    return '0123456789abcdef'
+  end
+  local path = '/cache/container.nvim/env/app-01234567.env'
+  -- A file left by an earlier session with other permissions is replaced, not rewritten in place
+  files[path] = { content = 'OLD=1\n', mode = 420 }
+  local config = { name = 'app', base_path = '/project', run_args = { '--env-file', '.env' } }
+  local warnings = env_file.prepare(config, {})
+  assert_equals(#warnings, 0, 'warnings')
+  assert_equals(config.env_file, path, 'generated path')
+  assert_equals(files[path].mode, 384, 'created with mode 0600')
+  assert_equals(files[path].content, 'API_TOKEN=secret\n', 'content')
+  assert_equals(#config.run_args, 0, '--env-file taken out of runArgs')
+end)
+
+test('remove() deletes the generated file', function()
+  files['/cache/app.env'] = { content = 'A=1\n', mode = 384 }
+  local config = { env_file = '/cache/app.env' }
+  env_file.remove(config)
+  assert_equals(files['/cache/app.env'], nil, 'deleted')
+  assert_equals(config.env_file, nil, 'no longer passed to exec sessions')
+  env_file.remove(config)
+  env_file.remove(nil)
+end)
+
+print()
+print(string.format('=== Env File Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -595,6 +595,37 @@ run_test('Edge cases and error handling', function()
   print('  Edge cases and error handling tested')
 end)
 
+-- TEST 13: remoteEnv resolved against the running container environment
+run_test('remoteEnv containerEnv references', function()
+  local environment = require('container.environment')
+
+  local runtime_env = { PATH = '/usr/local/bin:/usr/bin', HOME = '/home/dev' }
+  local resolved = environment.resolve_container_env_refs('/go/bin:${containerEnv:PATH}', runtime_env)
+  assert(resolved == '/go/bin:/usr/local/bin:/usr/bin', 'Should resolve reference from container environment')
+  assert(
+    environment.resolve_container_env_refs('${containerEnv:MISSING:fallback}', runtime_env) == 'fallback',
+    'Should use default for missing variable'
+  )
+  assert(
+    environment.resolve_container_env_refs('${containerEnv:MISSING}', runtime_env) == '${containerEnv:MISSING}',
+    'Should keep unresolved reference'
+  )
+
+  local config = {
+    environment = { CONTAINER_ONLY = 'c', SHARED = 'container' },
+    remote_env = { SHARED = 'remote', GOPATH_BIN = '${containerEnv:HOME}/go/bin' },
+    container_runtime_env = runtime_env,
+  }
+  assert(environment.needs_container_env(config), 'Should detect containerEnv references')
+
+  local args_str = table.concat(environment.build_exec_args(config), ' ')
+  assert(args_str:match('SHARED=remote'), 'remoteEnv should override containerEnv')
+  assert(args_str:match('GOPATH_BIN=/home/dev/go/bin'), 'Should resolve remoteEnv against container environment')
+  assert(args_str:match('CONTAINER_ONLY=c'), 'Should keep containerEnv')
+
+  print('  remoteEnv containerEnv references tested')
+end)
+
 -- Print results
 print('')
 print('=== Environment Module Test Results ===')
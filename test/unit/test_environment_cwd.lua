@@ -0,0 +1,135 @@
+#!/usr/bin/env lua
+
+-- Test script for the working directory expansion of container.environment
+-- Run with: lua test/unit/test_environment_cwd.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local inspected = 0
+local warnings = {}
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  json = {
+    decode = function()
+      return { 'PATH=/usr/bin', 'APP_HOME=/srv/app' }
+    end,
+  },
+  tbl_extend = function(_, ...)
+    local result = {}
+    for _, tbl in ipairs({ ... }) do
+      for k, v in pairs(tbl) do
+        result[k] = v
+      end
+    end
+    return result
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(message, ...)
+    table.insert(warnings, string.format(message, ...))
+  end,
+  error = function(...) end,
+}
+package.loaded['container.parser'] = {
+  workspace_roots = function(config)
+    return config.base_path, config.workspace_folder
+  end,
+  expand_variables = function(str, context)
+    return (str:gsub('%${containerWorkspaceFolder}', context.container_workspace))
+  end,
+  substitute = function(str, resolve)
+    return (str:gsub('%$(%b{})', function(braced)
+      return resolve(braced:sub(2, -2))
+    end))
+  end,
+}
+package.loaded['container.docker'] = {
+  run_docker_command = function()
+    inspected = inspected + 1
+    return { success = true, stdout = '[]', stderr = '' }
+  end,
+}
+package.loaded['container'] = {
+  get_container_id = function()
+    return 'abc123'
+  end,
+}
+
+local environment = require('container.environment')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  inspected, warnings = 0, {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function new_config()
+  return {
+    base_path = '/home/user/project',
+    workspace_folder = '/workspaces/project',
+    remote_env = { PROJECT_DIR = '/workspaces/project/api' },
+  }
+end
+
+print('Running working directory expansion tests...')
+print()
+
+test('paths without variables are returned as is', function()
+  assert_equals(environment.expand_path('/tmp', new_config()), '/tmp', 'plain path')
+  assert_equals(environment.expand_path(nil, new_config()), nil, 'no path')
+  assert_equals(inspected, 0, 'container not inspected')
+end)
+
+test('containerEnv sees remoteEnv on top of the container environment', function()
+  local config = new_config()
+  local path = environment.expand_path('${containerEnv:PROJECT_DIR}/cmd', config)
+  assert_equals(path, '/workspaces/project/api/cmd', 'remoteEnv')
+  assert_equals(environment.expand_path('${containerEnv:APP_HOME}', config), '/srv/app', 'container environment')
+  assert_equals(inspected, 1, 'container environment read once')
+end)
+
+test('defaults may refer to other variables', function()
+  local path = environment.expand_path('${containerEnv:MISSING:${containerWorkspaceFolder}/app}', new_config())
+  assert_equals(path, '/workspaces/project/app', 'nested default')
+  path = environment.expand_path('${containerWorkspaceFolder}/web', new_config())
+  assert_equals(path, '/workspaces/project/web', 'workspace folder')
+end)
+
+test('values are not expanded again', function()
+  local config = new_config()
+  config.remote_env = { LITERAL = '${containerWorkspaceFolder}' }
+  assert_equals(environment.expand_path('${containerEnv:LITERAL}', config), '${containerWorkspaceFolder}', 'kept')
+end)
+
+test('unresolved references are kept and logged', function()
+  assert_equals(environment.expand_path('${containerEnv:MISSING}/x', new_config()), '${containerEnv:MISSING}/x', 'kept')
+  assert_equals(#warnings, 1, 'logged')
+end)
+
+print()
+print(string.format('=== Environment Cwd Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,270 @@
+#!/usr/bin/env lua
+
+-- Test script for the lifecycle events of container.init: payloads, and the state handlers see when they run
+-- Run with: lua test/unit/test_event_state.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+-- User autocmds fired: { pattern, data }
+local autocmds = {}
+local stop_callbacks = {}
+local image_callbacks = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+    nvim_exec_autocmds = function(event, opts)
+      if event == 'User' then
+        table.insert(autocmds, { pattern = opts.pattern, data = opts.data })
+      end
+    end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = noop,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function() end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.test'] = { summary = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = { setup = noop, stop_all = noop, switch_container = noop }
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.ui.build_progress'] = {
+  enabled = function()
+    return false
+  end,
+}
+package.loaded['container.ui.progress'] = {
+  begin = function()
+    return 1
+  end,
+  build_stage = noop,
+  report = noop,
+  finish = noop,
+  cancel = noop,
+}
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function()
+    return false
+  end,
+}
+package.loaded['container.lifecycle'] = {
+  run_pre_stop_command = function(_, _, callback)
+    callback(true)
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  get_stop_timeout = function()
+    return 10
+  end,
+  run_docker_command_async = noop,
+  stop_container_async = function(_, callback)
+    table.insert(stop_callbacks, callback)
+  end,
+  prepare_image = function(_, _, callback)
+    table.insert(image_callbacks, callback)
+    return true
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+-- What a subscriber saw of the plugin while handling each event: "event:state:container_id"
+local seen = {}
+container.on('*', function(_, event)
+  table.insert(seen, string.format('%s:%s:%s', event, container.status().state, tostring(container.get_container_id())))
+end)
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  autocmds, seen, stop_callbacks, image_callbacks = {}, {}, {}, {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- First User autocmd fired with a pattern
+local function fired(pattern)
+  for _, autocmd in ipairs(autocmds) do
+    if autocmd.pattern == pattern then
+      return autocmd
+    end
+  end
+  error('not fired: ' .. pattern)
+end
+
+-- Events seen by the subscriber, without the state changes
+local function seen_events()
+  local events = {}
+  for _, entry in ipairs(seen) do
+    if not entry:match('^state_changed:') then
+      table.insert(events, entry)
+    end
+  end
+  return table.concat(events, ', ')
+end
+
+local function attach()
+  container._sync_workspace()
+  container._restore_attached_container({ id = 'ctr-a', status = 'Up' }, { name = 'a', image = 'golang:1.22' }, nil)
+end
+
+container.setup({})
+
+print('Running event state tests...')
+print()
+
+test('attaching reports the container once it is the running one', function()
+  attach()
+  assert_equals(seen_events(), 'attached:running:ctr-a, opened:running:ctr-a', 'events and state')
+  local attached = fired('ContainerAttached')
+  assert_equals(attached.data.container_id, 'ctr-a', 'container id')
+  assert_equals(attached.data.container_name, 'a', 'container name')
+  assert_equals(attached.data.reconnected, true, 'reconnected')
+  assert_equals(fired('ContainerDetected').data.status, 'Up', 'docker status')
+end)
+
+test('a build is reported as building and its failure restores the state', function()
+  attach()
+  seen = {}
+  container.build()
+  assert_equals(seen_events(), 'build_started:building:ctr-a', 'build started')
+  assert_equals(fired('ContainerBuildStarted').data.image, 'golang:1.22', 'image')
+
+  image_callbacks[1](false, { stderr = 'pull access denied' })
+  assert_equals(seen_events(), 'build_started:building:ctr-a, build_failed:running:ctr-a', 'build failed')
+  assert_equals(fired('ContainerBuildFailed').data.error, 'pull access denied', 'error')
+end)
+
+test('a successful build is reported with the image', function()
+  attach()
+  seen = {}
+  container.build()
+  image_callbacks[1](true, {})
+  assert_equals(seen_events(), 'build_started:building:ctr-a, built:running:ctr-a', 'built')
+  assert_equals(fired('ContainerBuilt').data.container_name, 'a', 'container name')
+end)
+
+test('stopping is reported once the container is cleared', function()
+  attach()
+  seen = {}
+  container.stop()
+  assert_equals(seen_events(), '', 'nothing before docker stop returns')
+  stop_callbacks[1](true)
+  assert_equals(seen_events(), 'stopped:stopped:nil', 'stopped')
+  local stopped = fired('ContainerStopped')
+  assert_equals(stopped.data.container_id, 'ctr-a', 'stopped container id')
+  assert_equals(stopped.data.container_name, 'a', 'stopped container name')
+end)
+
+test('state changes carry the previous state', function()
+  attach()
+  autocmds = {}
+  container.build()
+  local changed = fired('ContainerStateChanged')
+  assert_equals(changed.data.state, 'building', 'new state')
+  assert_equals(changed.data.previous, 'running', 'previous state')
+end)
+
+print()
+print(string.format('=== Event State Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
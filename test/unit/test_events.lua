@@ -0,0 +1,128 @@
+#!/usr/bin/env lua
+
+-- Test script for container.events module
+-- Run with: lua test/unit/test_events.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local autocmds = {}
+local warnings = {}
+
+_G.vim = {
+  api = {
+    nvim_exec_autocmds = function(event, opts)
+      table.insert(autocmds, { event = event, pattern = opts.pattern, data = opts.data })
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {
+  warn = function(message)
+    table.insert(warnings, message)
+  end,
+}
+
+local events = require('container.events')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  autocmds = {}
+  warnings = {}
+  events.clear()
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running events tests...')
+print()
+
+test('subscribers receive the event before the autocmd fires', function()
+  local received = {}
+  events.on('started', function(data, name)
+    table.insert(received, name .. ':' .. data.container_id)
+    assert_equals(#autocmds, 0, 'autocmd not fired yet')
+  end)
+  events.on('*', function(_, name)
+    table.insert(received, '*:' .. name)
+  end)
+  events.emit('ContainerStarted', { container_id = 'abc' })
+  events.emit('ContainerStopped', { container_id = 'abc' })
+
+  assert_equals(table.concat(received, ','), 'started:abc,*:started,*:stopped', 'received')
+  assert_equals(#autocmds, 2, 'autocmds')
+  assert_equals(autocmds[1].pattern, 'ContainerStarted', 'pattern')
+  assert_equals(autocmds[1].data.container_id, 'abc', 'same data')
+end)
+
+test('the returned function unsubscribes', function()
+  local count = 0
+  local unsubscribe
+  unsubscribe = events.on('build_progress', function()
+    count = count + 1
+    unsubscribe()
+  end)
+  events.on('build_progress', function()
+    count = count + 10
+  end)
+  events.emit('ContainerBuildProgress', { stage = '[1/2] FROM alpine', percentage = 0 })
+  events.emit('ContainerBuildProgress', { stage = '[2/2] RUN make', percentage = 50 })
+  assert_equals(count, 21, 'first subscriber only called once, second twice')
+  unsubscribe()
+end)
+
+test('a failing subscriber does not stop the others', function()
+  local reached = 0
+  events.on('state_changed', function()
+    error('broken handler')
+  end)
+  events.on('state_changed', function()
+    reached = reached + 1
+  end)
+  events.emit('ContainerStateChanged', { state = 'running', previous = 'stopped' })
+  events.emit('ContainerStateChanged', { state = 'stopped', previous = 'running' })
+  assert_equals(reached, 2, 'other subscriber called')
+  assert_equals(#autocmds, 2, 'autocmds still fired')
+  assert_equals(#warnings, 1, 'failure notified once')
+  assert(warnings[1]:find('state_changed', 1, true), 'warning names the event')
+end)
+
+test('unknown events are rejected', function()
+  local ok, err = pcall(events.on, 'launched', function() end)
+  assert_equals(ok, false, 'error')
+  assert(tostring(err):find("Unknown container event 'launched'", 1, true), 'message: ' .. tostring(err))
+  assert_equals(pcall(events.on, 'started', 'not a function'), false, 'callback must be a function')
+end)
+
+print()
+print(string.format('=== Events Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,282 @@
+#!/usr/bin/env lua
+
+-- Test script for container.exec (docker exec arguments, sync and async modes)
+-- Run with: lua test/unit/test_exec.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+-- docker exec calls: { args, opts, callback }
+local exec_calls = {}
+-- Result the mocked docker returns right away (nil leaves the command running)
+local docker_result = nil
+local stopped_jobs = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+    jobstop = function(job_id)
+      table.insert(stopped_jobs, job_id)
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  wait = function(_, condition)
+    return condition()
+  end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  tbl_extend = function(_, ...)
+    local result = {}
+    for _, tbl in ipairs({ ... }) do
+      for k, v in pairs(tbl) do
+        result[k] = v
+      end
+    end
+    return result
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = noop,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function() end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = {
+  setup = noop,
+  stop_all = noop,
+  switch_container = noop,
+}
+package.loaded['container.events'] = { emit = noop }
+package.loaded['container.environment'] = {
+  probe_user_env = noop,
+  get_exec_environment = function()
+    return { EDITOR = 'vi' }
+  end,
+  expand_path = function(path)
+    return path
+  end,
+}
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = {
+  clear = noop,
+  exec_args = function()
+    return { '-e', 'GITHUB_TOKEN' }
+  end,
+}
+package.loaded['container.env_file'] = {
+  remove = noop,
+  args = function()
+    return { '--env-file', '/cache/a.env' }
+  end,
+}
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function()
+    return false
+  end,
+}
+package.loaded['container.lifecycle'] = {
+  run_pre_stop_command = function(_, _, callback)
+    callback(true)
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  run_docker_command_async = function(args, opts, callback)
+    table.insert(exec_calls, { args = args, opts = opts, callback = callback })
+    if docker_result then
+      callback(docker_result)
+    end
+    return 42
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  exec_calls, stopped_jobs = {}, {}
+  docker_result = { success = true, code = 0, stdout = '', stderr = '' }
+  buffer_name = '/projects/a/main.go'
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+container.setup({})
+container._sync_workspace()
+container._restore_attached_container(
+  { id = 'ctr-a', status = 'Up' },
+  { name = 'a', remote_user = 'vscode', workspace_folder = '/workspaces/a' },
+  nil
+)
+
+print('Running exec tests...')
+print()
+
+test('arguments default to the remoteUser, workspaceFolder and exec environment', function()
+  container.exec('go test ./... | tee out.txt')
+  assert_equals(
+    table.concat(exec_calls[1].args, ' '),
+    'exec -i -u vscode --env-file /cache/a.env -e GITHUB_TOKEN -e EDITOR=vi -w /workspaces/a ctr-a'
+      .. ' /bin/sh -c go test ./... | tee out.txt',
+    'string commands run in a shell'
+  )
+  container.exec({ 'go', 'version' })
+  assert_equals(table.concat(exec_calls[2].args, ' ', 13), 'ctr-a go version', 'argv commands run as is')
+end)
+
+test('user, cwd and env options override the defaults', function()
+  container.exec({ 'env' }, { user = 'root', cwd = '/tmp', env = { EDITOR = 'nano', DEBUG = '1' } })
+  assert_equals(
+    table.concat(exec_calls[1].args, ' '),
+    'exec -i -u root --env-file /cache/a.env -e GITHUB_TOKEN -e DEBUG=1 -e EDITOR=nano -w /tmp ctr-a env',
+    'arguments'
+  )
+end)
+
+test('sync mode returns the output with its blank lines', function()
+  docker_result = { success = true, code = 0, stdout = 'a\n\nb\n', stderr = 'warning\n\n' }
+  local result, err = container.exec('printf "a\\n\\nb\\n"')
+  assert_equals(err, nil, 'no error')
+  assert_equals(exec_calls[1].opts.keep_empty, true, 'blank lines kept')
+  assert_equals(result.code, 0, 'exit code')
+  assert_equals(result.stdout, 'a\n\nb\n', 'stdout as printed')
+  assert_equals(result.stderr, 'warning\n\n', 'stderr as printed')
+end)
+
+test('sync mode stops the command when it times out', function()
+  docker_result = nil
+  local result, err = container.exec('sleep 60', { timeout = 100 })
+  assert_equals(result, nil, 'no result')
+  assert_equals(err, 'Command timed out after 100ms', 'error')
+  assert_equals(stopped_jobs[1], 42, 'job stopped')
+end)
+
+test('async mode returns the job id and passes the result to the callback', function()
+  docker_result = nil
+  local received
+  local job_id = container.exec('make', {
+    callback = function(result)
+      received = result
+    end,
+  })
+  assert_equals(job_id, 42, 'job id')
+  assert_equals(received, nil, 'still running')
+  assert_equals(exec_calls[1].opts.keep_empty, true, 'blank lines kept')
+  exec_calls[1].callback({ success = false, code = 2, stdout = 'x\n\n', stderr = 'failed' })
+  assert_equals(received.code, 2, 'exit code')
+  assert_equals(received.stdout, 'x\n\n', 'stdout as printed')
+  assert_equals(received.stderr, 'failed', 'stderr')
+end)
+
+test('invalid commands are rejected', function()
+  local result, err = container.exec('  ')
+  assert_equals(result, nil, 'empty string')
+  assert_equals(err, 'exec: command must not be empty', 'error')
+  result, err = container.exec({})
+  assert_equals(err, 'exec: command must be a string or a non-empty argv table', 'empty argv')
+  assert_equals(#exec_calls, 0, 'nothing run')
+end)
+
+test('no container', function()
+  buffer_name = '/projects/b/main.go'
+  local result, err = container.exec('ls')
+  assert_equals(result, nil, 'no result')
+  assert_equals(err, 'No running container. Start one with :ContainerStart or attach with :ContainerAttach', 'error')
+  assert_equals(#exec_calls, 0, 'nothing run')
+end)
+
+print()
+print(string.format('=== Exec Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
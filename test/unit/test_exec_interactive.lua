@@ -0,0 +1,205 @@
+#!/usr/bin/env lua
+
+-- Test script for container.terminal.exec_interactive
+-- Run with: lua test/unit/test_exec_interactive.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local termopen_cmd = nil
+local termopen_opts = nil
+local autocmds = {}
+local float_configs = {}
+local deleted = {}
+local notifications = {}
+local container_id = 'abc123'
+
+_G.vim = {
+  o = { columns = 100, lines = 40 },
+  api = {
+    nvim_create_buf = function()
+      return 7
+    end,
+    nvim_open_win = function(_, _, config)
+      table.insert(float_configs, config)
+      return 3
+    end,
+    nvim_win_set_config = function(_, config)
+      table.insert(float_configs, config)
+    end,
+    nvim_win_is_valid = function()
+      return true
+    end,
+    nvim_set_current_buf = function() end,
+    nvim_buf_set_option = function() end,
+    nvim_buf_is_valid = function()
+      return true
+    end,
+    nvim_buf_delete = function(buf)
+      table.insert(deleted, buf)
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function(event, opts)
+      autocmds[event] = opts.callback
+    end,
+  },
+  fn = {
+    shellescape = function(s)
+      return "'" .. s:gsub("'", "'\\''") .. "'"
+    end,
+    termopen = function(cmd, opts)
+      termopen_cmd = cmd
+      termopen_opts = opts
+      return 12
+    end,
+  },
+  cmd = function() end,
+  schedule = function(fn)
+    fn()
+  end,
+  deepcopy = function(t)
+    local copy = {}
+    for k, v in pairs(t) do
+      copy[k] = v
+    end
+    return copy
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  tbl_map = function(fn, t)
+    local result = {}
+    for i, v in ipairs(t) do
+      result[i] = fn(v)
+    end
+    return result
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  critical = function(message)
+    table.insert(notifications, message)
+  end,
+  warn = function(message)
+    table.insert(notifications, message)
+  end,
+}
+package.loaded['container.docker.runtime'] = {
+  get = function()
+    return 'docker'
+  end,
+}
+package.loaded['container.config'] = {
+  get = function()
+    return { terminal = { environment = { 'TERM=xterm-256color' }, float = { width = 0.5, height = 0.5 } } }
+  end,
+}
+package.loaded['container'] = {
+  get_container_id = function()
+    return container_id
+  end,
+  get_state = function()
+    return { current_config = { remote_user = 'vscode', workspace_folder = '/workspace' } }
+  end,
+}
+package.loaded['container.env_file'] = {
+  args = function()
+    return {}
+  end,
+}
+package.loaded['container.environment'] = {
+  get_remote_environment = function()
+    return { EDITOR = 'vi' }
+  end,
+}
+package.loaded['container.secrets'] = {
+  load = function()
+    return { 'GITHUB_TOKEN' }
+  end,
+}
+
+local terminal = require('container.terminal')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  termopen_cmd, termopen_opts = nil, nil
+  autocmds, float_configs, deleted, notifications = {}, {}, {}, {}
+  container_id = 'abc123'
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running interactive exec tests...')
+print()
+
+test('programs run with a TTY and the exec environment', function()
+  assert_equals(terminal.exec_interactive('lazygit -p /workspace/app'), true, 'started')
+  assert_equals(
+    termopen_cmd,
+    "docker exec -it -e TERM=xterm-256color -e 'EDITOR=vi' -e 'GITHUB_TOKEN' -u 'vscode' -w '/workspace' abc123"
+      .. " sh -c 'lazygit -p /workspace/app'",
+    'command'
+  )
+
+  terminal.exec_interactive({ 'htop', '-d', '10' }, { user = 'root', cwd = '/tmp', position = 'float' })
+  assert(termopen_cmd:find("-u 'root' -w '/tmp' abc123 'htop' '-d' '10'$"), termopen_cmd)
+end)
+
+test('the float window follows the editor size', function()
+  terminal.exec_interactive('htop')
+  assert_equals(float_configs[1].width, 50, 'initial width')
+  assert_equals(float_configs[1].title, 'htop', 'title')
+  vim.o.columns = 200
+  autocmds.VimResized()
+  assert_equals(float_configs[2].width, 100, 'resized width')
+  assert_equals(float_configs[2].col, 50, 'centered')
+  vim.o.columns = 100
+end)
+
+test('the buffer closes on success and stays on failure', function()
+  terminal.exec_interactive('htop')
+  termopen_opts.on_exit(12, 0)
+  assert_equals(deleted[1], 7, 'closed')
+  terminal.exec_interactive('lazygit')
+  termopen_opts.on_exit(12, 1)
+  assert_equals(#deleted, 1, 'kept')
+  assert_equals(notifications[1], 'lazygit exited with code 1', 'reported')
+end)
+
+test('no container', function()
+  container_id = nil
+  assert_equals(terminal.exec_interactive('htop'), false, 'not started')
+  assert_equals(termopen_cmd, nil, 'no terminal')
+end)
+
+print()
+print(string.format('=== Interactive Exec Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,162 @@
+#!/usr/bin/env lua
+
+-- Test script for container.exec_selection module
+-- Run with: lua test/unit/test_exec_selection.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_lines = { 'echo one', 'cd /tmp', 'X=1', 'pwd; echo $X' }
+local marks = { ["'<"] = 2, ["'>"] = 4 }
+local jobs = {}
+local output_lines = {}
+local warnings = {}
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    line = function(mark)
+      return marks[mark]
+    end,
+    jobstart = function(cmd, opts)
+      table.insert(jobs, { cmd = cmd, opts = opts })
+      return #jobs
+    end,
+    jobstop = function() end,
+  },
+  api = {
+    nvim_buf_get_lines = function(_, first, last)
+      local lines = {}
+      for i = first + 1, last do
+        table.insert(lines, buffer_lines[i])
+      end
+      return lines
+    end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Mock log and notify modules
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  error = function(...) end,
+  critical = function(...) end,
+  warn = function(message)
+    table.insert(warnings, message)
+  end,
+}
+
+package.loaded['container.docker.runtime'] = {
+  get = function()
+    return 'docker'
+  end,
+}
+package.loaded['container.ui.output'] = {
+  clear = function()
+    output_lines = {}
+  end,
+  append = function(_, lines)
+    for _, line in ipairs(lines) do
+      table.insert(output_lines, line)
+    end
+  end,
+  open = function() end,
+}
+
+local container_id = 'abc123'
+package.loaded['container'] = {
+  get_container_id = function()
+    return container_id
+  end,
+  _build_exec_args = function(id, cmd)
+    return { 'exec', '-i', '-w', '/workspace', id, '/bin/sh', '-c', cmd }
+  end,
+}
+
+local exec_selection = require('container.exec_selection')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running exec selection tests...')
+print()
+
+test('the visual selection is used when no range is given', function()
+  local lines = exec_selection.get_lines()
+  assert_equals(#lines, 3, 'line count')
+  assert_equals(lines[1], 'cd /tmp', 'first line')
+  lines = exec_selection.get_lines({ line1 = 1, line2 = 1 })
+  assert_equals(lines[1], 'echo one', 'explicit range')
+end)
+
+test('multi-line selections run as one sh -c script', function()
+  jobs = {}
+  assert_equals(exec_selection.run(exec_selection.get_lines()), true, 'started')
+  local cmd = jobs[1].cmd
+  assert_equals(cmd[1], 'docker', 'runtime')
+  assert_equals(cmd[#cmd - 1], '-c', 'shell')
+  assert_equals(cmd[#cmd], 'cd /tmp\nX=1\npwd; echo $X', 'script')
+  assert_equals(output_lines[1], '$ cd /tmp', 'header echoes the script')
+end)
+
+test('output is streamed and ends with the exit code', function()
+  jobs = {}
+  exec_selection.run({ 'pwd' })
+  local opts = jobs[1].opts
+  opts.on_stdout(1, { '/tmp', 'par' })
+  opts.on_stdout(1, { 'tial' })
+  opts.on_exit(1, 3)
+  assert_equals(output_lines[3], '/tmp', 'streamed line')
+  assert_equals(output_lines[4], 'partial', 'incomplete line flushed on exit')
+  assert_equals(output_lines[#output_lines], '<== exited with code 3', 'exit code')
+end)
+
+test('blank selections and missing containers start nothing', function()
+  jobs = {}
+  warnings = {}
+  assert_equals(exec_selection.run({ '', '  ' }), false, 'blank')
+  assert_equals(#warnings, 1, 'warned')
+  container_id = nil
+  assert_equals(exec_selection.run({ 'ls' }), false, 'no container')
+  container_id = 'abc123'
+  assert_equals(#jobs, 0, 'no job started')
+end)
+
+print()
+print(string.format('=== Exec Selection Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
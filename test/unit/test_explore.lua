@@ -0,0 +1,90 @@
+#!/usr/bin/env lua
+
+-- Test script for container.explore module
+-- Run with: lua test/unit/test_explore.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  startswith = function(s, prefix)
+    return s:sub(1, #prefix) == prefix
+  end,
+  endswith = function(s, suffix)
+    return suffix == '' or s:sub(-#suffix) == suffix
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {}
+
+local explore = require('container.explore')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running explore tests...')
+print()
+
+test('buffer names map to container paths', function()
+  assert_equals(explore.buffer_name('/workspaces/app/'), 'container:///workspaces/app/', 'name')
+  assert_equals(explore.path_of('container:///workspaces/app/main.go'), '/workspaces/app/main.go', 'path')
+  assert_equals(explore.path_of('container://run'), nil, 'output buffer')
+  assert_equals(explore.path_of('/home/me/main.go'), nil, 'host file')
+end)
+
+test('paths are joined and walked up', function()
+  assert_equals(explore.join('/workspaces/app/', 'cmd/'), '/workspaces/app/cmd/', 'directory')
+  assert_equals(explore.join('/', 'etc/'), '/etc/', 'root')
+  assert_equals(explore.parent('/workspaces/app/cmd/'), '/workspaces/app/', 'parent')
+  assert_equals(explore.parent('/workspaces/app/main.go'), '/workspaces/app/', 'parent of a file')
+  assert_equals(explore.parent('/workspaces/'), '/', 'top level')
+  assert_equals(explore.parent('/'), '/', 'root')
+end)
+
+test('directories are listed first', function()
+  local entries = explore.parse_listing('main.go\n.git/\ncmd/\n.env\ngo.mod\n')
+  assert_equals(table.concat(entries, ' '), '.git/ cmd/ .env go.mod main.go', 'order')
+  assert_equals(#explore.parse_listing(''), 0, 'empty directory')
+end)
+
+test('files are written through exec -i as the remoteUser', function()
+  local args = explore.exec_args('abc', { remote_user = 'vscode' }, { 'sh', '-c', 'cat > "$1"', 'sh', '/x' }, true)
+  assert_equals(table.concat(args, ' ', 1, 5), 'exec -i -u vscode abc', 'exec')
+  assert_equals(args[#args], '/x', 'path as argument')
+  assert_equals(table.concat(explore.exec_args('abc', {}, { 'cat', '/x' }), ' '), 'exec abc cat /x', 'read')
+end)
+
+print()
+print(string.format('=== Explore Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,186 @@
+#!/usr/bin/env lua
+
+-- Test script for container.features module
+-- Run with: lua test/unit/test_features.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/test/project'
+    end,
+    stdpath = function()
+      return '/tmp/cache'
+    end,
+    fnamemodify = function(path, modifier)
+      if modifier == ':t' then
+        return path:match('[^/]*$')
+      elseif modifier == ':h' then
+        return path:gsub('/[^/]*$', '')
+      end
+      return path
+    end,
+    sha256 = function(str)
+      local hash = 0
+      for i = 1, #str do
+        hash = (hash * 31 + string.byte(str, i)) % 0x100000000
+      end
+      return string.format('%08x', hash):rep(8)
+    end,
+  },
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k, _ in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local features = require('container.features')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running features tests...')
+print()
+
+test('parse OCI reference with tag', function()
+  local oci = features.parse_oci_reference('ghcr.io/devcontainers/features/go:1')
+  assert_equals(oci.registry, 'ghcr.io', 'registry')
+  assert_equals(oci.repository, 'devcontainers/features/go', 'repository')
+  assert_equals(oci.tag, '1', 'tag')
+  assert_equals(oci.id, 'go', 'id')
+end)
+
+test('parse OCI reference without tag defaults to latest', function()
+  local oci = features.parse_oci_reference('ghcr.io/devcontainers/features/node')
+  assert_equals(oci.tag, 'latest', 'tag')
+  assert_equals(oci.resource, 'ghcr.io/devcontainers/features/node', 'resource')
+end)
+
+test('parse OCI reference with registry port and digest', function()
+  local oci = features.parse_oci_reference('localhost:5000/features/tool@sha256:abcdef')
+  assert_equals(oci.registry, 'localhost:5000', 'registry')
+  assert_equals(oci.digest, 'sha256:abcdef', 'digest')
+  assert_equals(oci.tag, nil, 'tag')
+end)
+
+test('option names are converted to env var names', function()
+  assert_equals(features.option_env_name('version'), 'VERSION', 'simple')
+  assert_equals(features.option_env_name('install-tools'), 'INSTALL_TOOLS', 'dash')
+  assert_equals(features.option_env_name('1abc'), '_ABC', 'leading digit')
+end)
+
+test('normalize resolves local features against devcontainer folder', function()
+  local list = features.normalize({
+    ['./my-feature'] = { flag = true },
+    ['ghcr.io/devcontainers/features/go:1'] = '1.22',
+  }, '/test/project/.devcontainer')
+  assert_equals(#list, 2, 'feature count')
+  assert_equals(list[1].kind, 'local', 'local kind')
+  assert_equals(list[1].path, '/test/project/.devcontainer/my-feature', 'local path')
+  assert_equals(list[2].kind, 'oci', 'oci kind')
+  assert_equals(list[2].options.version, '1.22', 'string shorthand version')
+end)
+
+test('sort honors installsAfter', function()
+  local ordered = features.sort_by_installs_after({
+    { ref = 'ghcr.io/x/a:1', resource = 'ghcr.io/x/a', id = 'a', installs_after = { 'ghcr.io/x/b' } },
+    { ref = 'ghcr.io/x/b:1', resource = 'ghcr.io/x/b', id = 'b', installs_after = {} },
+    { ref = 'ghcr.io/x/c:1', resource = 'ghcr.io/x/c', id = 'c', installs_after = {} },
+  })
+  assert_equals(ordered[1].id, 'b', 'first')
+  assert_equals(ordered[2].id, 'a', 'second')
+  assert_equals(ordered[3].id, 'c', 'third')
+end)
+
+test('sort falls back to declaration order on cycles', function()
+  local ordered = features.sort_by_installs_after({
+    { ref = 'a', resource = 'r/a', id = 'a', installs_after = { 'r/b' } },
+    { ref = 'b', resource = 'r/b', id = 'b', installs_after = { 'r/a' } },
+  })
+  assert_equals(#ordered, 2, 'count')
+  assert_equals(ordered[1].id, 'a', 'first')
+end)
+
+test('option env merges metadata defaults with user options', function()
+  local env = features.build_option_env({
+    metadata = { options = { version = { default = 'latest' }, golangciLintVersion = { default = '1.0' } } },
+    options = { version = '1.22' },
+  }, { remote_user = 'vscode' })
+  assert_equals(env.VERSION, '1.22', 'user option')
+  assert_equals(env.GOLANGCILINTVERSION, '1.0', 'default option')
+  assert_equals(env._REMOTE_USER, 'vscode', 'remote user')
+  assert_equals(env._REMOTE_USER_HOME, '/home/vscode', 'remote user home')
+end)
+
+test('env file escapes shell characters', function()
+  local content = features.render_env_file({ B = 'x"$y', A = 'plain' })
+  assert_equals(content, 'A="plain"\nB="x\\"\\$y"\n', 'env file')
+end)
+
+test('dockerfile installs features in order and restores user', function()
+  local dockerfile = features.generate_dockerfile('ubuntu:22.04', {
+    { ref = './one', id = 'one' },
+    { ref = './two', id = 'two', metadata = { containerEnv = { GOPATH = '/go' } } },
+  }, { image_user = 'vscode' })
+  assert(dockerfile:find('FROM ubuntu:22.04', 1, true), 'FROM line')
+  local one = dockerfile:find('COPY 1-one', 1, true)
+  local two = dockerfile:find('COPY 2-two', 1, true)
+  assert(one and two and one < two, 'features copied in order')
+  assert(dockerfile:find('ENV GOPATH="/go"', 1, true), 'feature containerEnv')
+  assert(dockerfile:find('USER vscode\n$'), 'user restored')
+end)
+
+test('cache key changes with options', function()
+  local a = features.compute_cache_key('img', { { ref = 'r', kind = 'oci', options = { version = '1' } } })
+  local b = features.compute_cache_key('img', { { ref = 'r', kind = 'oci', options = { version = '2' } } })
+  local c = features.compute_cache_key('img', { { ref = 'r', kind = 'oci', options = { version = '1' } } })
+  assert(a ~= b, 'different options produce different keys')
+  assert_equals(a, c, 'same options produce same key')
+end)
+
+print()
+print(string.format('=== Features Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
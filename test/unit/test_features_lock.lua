@@ -0,0 +1,130 @@
+#!/usr/bin/env lua
+
+-- Test script for container.features_lock module
+-- Run with: lua test/unit/test_features_lock.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.fs'] = {
+  basename = function(path)
+    return path:match('[^/]*$')
+  end,
+  dirname = function(path)
+    return (path:gsub('/[^/]*$', ''))
+  end,
+  join_path = function(...)
+    return table.concat({ ... }, '/')
+  end,
+}
+
+local features_lock = require('container.features_lock')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local GO = 'ghcr.io/devcontainers/features/go:1'
+local NODE = 'ghcr.io/devcontainers/features/node:1'
+
+local function oci_feature(ref, digest)
+  local resource = ref:gsub(':[^:/]*$', '')
+  return { ref = ref, kind = 'oci', resource = resource, oci = { resource = resource, digest = digest } }
+end
+
+local function lock_of(entries)
+  return { features = entries }
+end
+
+print('Running features lock tests...')
+print()
+
+test('the lockfile sits next to devcontainer.json', function()
+  assert_equals(
+    features_lock.path('/p/.devcontainer/devcontainer.json'),
+    '/p/.devcontainer/devcontainer-lock.json',
+    'devcontainer.json'
+  )
+  assert_equals(features_lock.path('/p/.devcontainer.json'), '/p/.devcontainer-lock.json', '.devcontainer.json')
+end)
+
+test('locked OCI features are pinned to their digest', function()
+  local go = oci_feature(GO)
+  local local_feature = { ref = './mine', kind = 'local' }
+  local lock = lock_of({
+    [GO] = {
+      version = '1.3.1',
+      resolved = 'ghcr.io/devcontainers/features/go@sha256:abc123',
+      integrity = 'sha256:def',
+    },
+  })
+  local pinned = features_lock.apply({ go, local_feature }, lock)
+  assert_equals(#pinned, 1, 'pinned features')
+  assert_equals(go.oci.digest, 'sha256:abc123', 'digest')
+  assert_equals(go.locked.integrity, 'sha256:def', 'lock entry kept for the integrity check')
+  assert_equals(local_feature.locked, nil, 'local features are not locked')
+end)
+
+test('a digest in devcontainer.json wins over the lockfile', function()
+  local go = oci_feature(GO, 'sha256:111')
+  features_lock.apply({ go }, lock_of({ [GO] = { resolved = 'ghcr.io/devcontainers/features/go@sha256:222' } }))
+  assert_equals(go.oci.digest, 'sha256:111', 'digest')
+  assert_equals(go.locked, nil, 'not locked')
+end)
+
+test('added and removed features make the lockfile stale', function()
+  local lock = lock_of({ [GO] = { resolved = 'x@sha256:1' }, ['ghcr.io/old/feature:2'] = { resolved = 'y@sha256:2' } })
+  local diff = features_lock.diff({ oci_feature(GO), oci_feature(NODE), { ref = './mine', kind = 'local' } }, lock)
+  assert(features_lock.is_stale(diff), 'stale')
+  assert_equals(table.concat(diff.added, ','), NODE, 'added')
+  assert_equals(table.concat(diff.removed, ','), 'ghcr.io/old/feature:2', 'removed')
+  assert(features_lock.describe(diff):find('not locked: ' .. NODE, 1, true), 'description')
+
+  assert(not features_lock.is_stale(features_lock.diff({ oci_feature(GO) }, lock_of({ [GO] = {} }))), 'up to date')
+end)
+
+test('resolved features are written with version, digest and integrity', function()
+  local go = oci_feature(GO)
+  go.version = '1.3.1'
+  go.resolved = 'sha256:abc'
+  go.integrity = 'sha256:def'
+  local unresolved = oci_feature(NODE)
+  local lock = features_lock.build({ go, unresolved, { ref = './mine', kind = 'local', resolved = 'x' } })
+  assert_equals(lock.features[GO].resolved, 'ghcr.io/devcontainers/features/go@sha256:abc', 'resolved')
+  assert_equals(lock.features[GO].version, '1.3.1', 'version')
+  assert_equals(lock.features[GO].integrity, 'sha256:def', 'integrity')
+  assert_equals(lock.features[NODE], nil, 'features that were not fetched are left out')
+  assert_equals(lock.features['./mine'], nil, 'local features are left out')
+end)
+
+print()
+print(string.format('=== Features Lock Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,141 @@
+#!/usr/bin/env lua
+
+-- Test script for container.format module
+-- Run with: lua test/unit/test_format.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer = {}
+local diff_hunks = {}
+local plugin_config = {
+  format = {
+    formatters = { go = { 'goimports', 'gofmt -s' }, python = 'black --quiet -', text = {} },
+  },
+}
+
+_G.vim = {
+  api = {
+    nvim_buf_get_lines = function()
+      local lines = {}
+      for i, line in ipairs(buffer) do
+        lines[i] = line
+      end
+      return lines
+    end,
+    nvim_buf_set_lines = function(_, first, last, _, replacement)
+      local lines = {}
+      for i = 1, first do
+        table.insert(lines, buffer[i])
+      end
+      for _, line in ipairs(replacement) do
+        table.insert(lines, line)
+      end
+      for i = last + 1, #buffer do
+        table.insert(lines, buffer[i])
+      end
+      buffer = lines
+    end,
+  },
+  diff = function()
+    return diff_hunks
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  warn = function(...) end,
+  critical = function(...) end,
+}
+package.loaded['container.config'] = {
+  get_value = function(path)
+    return plugin_config[path]
+  end,
+}
+
+local format = require('container.format')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running format tests...')
+print()
+
+test('formatters are looked up per filetype', function()
+  assert_equals(#format.get_formatters('go'), 2, 'go candidates')
+  assert_equals(format.get_formatters('python')[1], 'black --quiet -', 'a single command is accepted')
+  assert_equals(format.get_formatters('text'), nil, 'empty list')
+  assert_equals(format.get_formatters('lua'), nil, 'not configured')
+end)
+
+test('the script runs the first formatter installed in the container', function()
+  local script = format.build_script({ 'goimports', 'gofmt -s' })
+  assert_equals(
+    script,
+    'if command -v goimports >/dev/null 2>&1; then exec goimports; '
+      .. 'elif command -v gofmt >/dev/null 2>&1; then exec gofmt -s; else exit 127; fi',
+    'script'
+  )
+end)
+
+test('only the changed lines are replaced', function()
+  buffer = { 'package main', 'import "fmt"', 'func main() {', 'fmt.Println("hi")', '}' }
+  -- line 2 replaced by a block, line 4 indented
+  diff_hunks = { { 2, 1, 2, 3 }, { 4, 1, 6, 1 } }
+  local changed = format.apply(0, {
+    'package main',
+    'import (',
+    '\t"fmt"',
+    ')',
+    'func main() {',
+    '\tfmt.Println("hi")',
+    '}',
+  })
+  assert_equals(changed, true, 'changed')
+  assert_equals(#buffer, 7, 'line count')
+  assert_equals(buffer[3], '\t"fmt"', 'import block')
+  assert_equals(buffer[6], '\tfmt.Println("hi")', 'indented')
+  assert_equals(buffer[7], '}', 'last line kept')
+end)
+
+test('insertions and deletions are applied at the right lines', function()
+  buffer = { 'a', 'b', 'c' }
+  -- insert "x" after line 1, delete line 3
+  diff_hunks = { { 1, 0, 2, 1 }, { 3, 1, 4, 0 } }
+  format.apply(0, { 'a', 'x', 'b' })
+  assert_equals(table.concat(buffer, ','), 'a,x,b', 'buffer')
+end)
+
+test('an already formatted buffer is left alone', function()
+  buffer = { 'package main' }
+  diff_hunks = { { 1, 1, 1, 1 } }
+  assert_equals(format.apply(0, { 'package main' }), false, 'unchanged')
+end)
+
+print()
+print(string.format('=== Format Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,165 @@
+#!/usr/bin/env lua
+
+-- Test script for container.forward_store module
+-- Run with: lua test/unit/test_forward_store.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- In-memory files written through container.utils.fs
+local files = {}
+
+-- JSON documents are kept as tables; encode returns a handle that decode resolves
+local documents = {}
+
+_G.vim = {
+  fn = {
+    stdpath = function(what)
+      return '/home/user/.local/' .. what .. '/nvim'
+    end,
+    sha256 = function(str)
+      local sum = 0
+      for i = 1, #str do
+        sum = (sum * 31 + str:byte(i)) % 0xFFFFFFFF
+      end
+      return string.format('%016x', sum)
+    end,
+  },
+  json = {
+    encode = function(value)
+      table.insert(documents, value)
+      return 'json:' .. #documents
+    end,
+    decode = function(str)
+      local document = documents[tonumber(str:match('^json:(%d+)$') or '')]
+      if not document then
+        error('invalid json')
+      end
+      return document
+    end,
+  },
+  tbl_filter = function(func, t)
+    local result = {}
+    for _, v in ipairs(t) do
+      if func(v) then
+        table.insert(result, v)
+      end
+    end
+    return result
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.fs'] = {
+  join_path = function(...)
+    return table.concat({ ... }, '/')
+  end,
+  is_file = function(path)
+    return files[path] ~= nil
+  end,
+  read_file = function(path)
+    return files[path]
+  end,
+  write_file = function(path, content)
+    files[path] = content
+    return true
+  end,
+}
+
+local original_remove = os.remove
+os.remove = function(path)
+  files[path] = nil
+  return true
+end
+
+local store = require('container.forward_store')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running forward store tests...')
+print()
+
+test('forwards are saved per workspace under stdpath state', function()
+  local path = store.get_path('/projects/app')
+  assert(path:match('^/home/user/%.local/state/nvim/container%.nvim/forwards/%x+%.json$'), path)
+  assert(path ~= store.get_path('/projects/other'), 'workspaces use different files')
+end)
+
+test('saved forwards are loaded back', function()
+  files = {}
+  store.add('/projects/app', { container_port = 3000, host_port = 3001 })
+  store.add('/projects/app', { container_port = 5432, host_port = 5432 })
+  local forwards = store.load('/projects/app')
+  assert_equals(#forwards, 2, 'forwards')
+  assert_equals(forwards[1].container_port, 3000, 'container port')
+  assert_equals(forwards[1].host_port, 3001, 'host port')
+  assert_equals(#store.load('/projects/other'), 0, 'other workspace')
+end)
+
+test('a forward of the same port replaces the saved one', function()
+  files = {}
+  store.add('/projects/app', { container_port = 3000, host_port = 3001 })
+  store.add('/projects/app', { container_port = 3000, host_port = 4000 })
+  local forwards = store.load('/projects/app')
+  assert_equals(#forwards, 1, 'forwards')
+  assert_equals(forwards[1].host_port, 4000, 'host port')
+end)
+
+test('removing the last forward deletes the file', function()
+  files = {}
+  store.add('/projects/app', { container_port = 3000, host_port = 3000 })
+  store.remove('/projects/app', 3000)
+  assert_equals(files[store.get_path('/projects/app')], nil, 'file removed')
+  assert_equals(#store.load('/projects/app'), 0, 'nothing saved')
+end)
+
+test('unreadable files and missing workspaces load nothing', function()
+  files = { [store.get_path('/projects/app')] = 'not json' }
+  assert_equals(#store.load('/projects/app'), 0, 'corrupt file')
+  assert_equals(#store.load(nil), 0, 'no workspace')
+  assert_equals(store.add(nil, { container_port = 1 }), false, 'not saved without workspace')
+end)
+
+test('listening ports are read from /proc/net/tcp and tcp6', function()
+  local ports = store.parse_listening_ports(table.concat({
+    '  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode',
+    '   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1234 1',
+    '   1: 0100007F:1538 0100007F:9C40 01 00000000:00000000 00:00000000 00000000  1000        0 1235 1',
+    '  sl  local_address                         remote_address                        st',
+    '   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000',
+  }, '\n'))
+  assert_equals(ports[3000], true, 'IPv4 listener')
+  assert_equals(ports[8080], true, 'IPv6 listener')
+  assert_equals(ports[5432], nil, 'established connection is not a listener')
+end)
+
+os.remove = original_remove
+
+print()
+print(string.format('=== Forward Store Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,124 @@
+#!/usr/bin/env lua
+
+-- Test script for container.go_build module
+-- Run with: lua test/unit/test_go_build.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local targets = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'go_build.targets' then
+      return targets
+    end
+  end,
+}
+
+local go_build = require('container.go_build')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  targets = {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running Go build tests...')
+print()
+
+test('targets come from the configuration unless given', function()
+  targets = { 'linux/amd64', 'darwin/arm64' }
+  local resolved = go_build.resolve_targets({})
+  assert_equals(#resolved, 2, 'configured targets')
+  assert_equals(resolved[2].goos, 'darwin', 'GOOS')
+  assert_equals(resolved[2].goarch, 'arm64', 'GOARCH')
+
+  resolved = go_build.resolve_targets({ 'windows/amd64' })
+  assert_equals(#resolved, 1, 'arguments win')
+  assert_equals(resolved[1].target, 'windows/amd64', 'target')
+end)
+
+test('invalid or missing targets are reported', function()
+  local resolved, err = go_build.resolve_targets({ 'linux-amd64' })
+  assert_equals(resolved, nil, 'invalid')
+  assert(err:find('linux-amd64', 1, true), 'error names the target: ' .. err)
+
+  resolved, err = go_build.resolve_targets(nil)
+  assert_equals(resolved, nil, 'none configured')
+  assert(err:find('go_build.targets', 1, true), 'error names the setting: ' .. err)
+end)
+
+test('the build writes into an emptied artifact directory', function()
+  local cmd = go_build.build_command('/tmp/out/linux_amd64', { args = { '-trimpath' }, packages = { './cmd/...' } })
+  local expected = {
+    'sh',
+    '-c',
+    'rm -rf "$0" && mkdir -p "$0" && exec "$@"',
+    '/tmp/out/linux_amd64',
+    'go',
+    'build',
+    '-o',
+    '/tmp/out/linux_amd64/',
+    '-trimpath',
+    './cmd/...',
+  }
+  assert_equals(#cmd, #expected, 'length')
+  for i, arg in ipairs(expected) do
+    assert_equals(cmd[i], arg, 'argument ' .. i)
+  end
+  assert_equals(go_build.build_command('/o', {})[9], '.', 'default package')
+end)
+
+test('compiler errors are mapped to host paths', function()
+  local ctx = { host_root = '/home/me/app', container_root = '/workspace', host_dir = '/home/me/app/svc' }
+  local item = go_build.parse_error('./main.go:12:5: undefined: foo', ctx)
+  assert_equals(item.filename, '/home/me/app/svc/main.go', 'relative path')
+  assert_equals(item.lnum, 12, 'line')
+  assert_equals(item.col, 5, 'column')
+  assert_equals(item.text, 'undefined: foo', 'message')
+
+  item = go_build.parse_error('/workspace/pkg/util.go:3:1: syntax error', ctx)
+  assert_equals(item.filename, '/home/me/app/pkg/util.go', 'container path')
+
+  assert_equals(go_build.parse_error('# example.com/app/svc', ctx), nil, 'package header')
+  assert_equals(go_build.parse_error('/go/pkg/mod/x.go:1:1: oops', ctx), nil, 'outside the workspace')
+end)
+
+print()
+print(string.format('=== Go Build Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
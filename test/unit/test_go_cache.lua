@@ -0,0 +1,156 @@
+#!/usr/bin/env lua
+
+-- Test script for container.go_cache module
+-- Run with: lua test/unit/test_go_cache.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- docker commands run by the module and the results they get
+local commands = {}
+local existing_volumes = {}
+local probe_output = '/go/pkg/mod\n/home/vscode/.cache/go-build\ngo1.22.3\n1000\n1000\n'
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+  tbl_filter = function(fn, t)
+    local result = {}
+    for _, item in ipairs(t) do
+      if fn(item) then
+        table.insert(result, item)
+      end
+    end
+    return result
+  end,
+  fn = {
+    sha256 = function()
+      return '1a2b3c4d5e6f'
+    end,
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.docker'] = {
+  run_docker_command_async = function(args, _, callback)
+    table.insert(commands, table.concat(args, ' '))
+    if args[1] == 'run' and args[#args] == package.loaded['container.go_cache'].PROBE_SCRIPT then
+      callback({ success = probe_output ~= nil, stdout = probe_output or '', stderr = 'go: not found' })
+    elseif args[1] == 'volume' and args[2] == 'inspect' then
+      callback({ success = existing_volumes[args[3]] == true })
+    else
+      callback({ success = true, stdout = '' })
+    end
+  end,
+}
+
+local go_cache = require('container.go_cache')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function ran(prefix)
+  for _, command in ipairs(commands) do
+    if command:sub(1, #prefix) == prefix then
+      return true
+    end
+  end
+  return false
+end
+
+print('Running Go cache tests...')
+print()
+
+test('go env output is parsed', function()
+  local probe = go_cache.parse_probe(probe_output)
+  assert_equals(probe.modcache, '/go/pkg/mod', 'GOMODCACHE')
+  assert_equals(probe.gocache, '/home/vscode/.cache/go-build', 'GOCACHE')
+  assert_equals(probe.uid, '1000', 'uid')
+  assert_equals(go_cache.parse_probe('sh: go: not found'), nil, 'no go')
+  assert_equals(go_cache.parse_probe('/go/pkg/mod\noff\ngo1.22.3\n'), nil, 'GOCACHE=off')
+end)
+
+test('volumes are named per workspace and Go minor version', function()
+  local volumes = go_cache.volumes({ base_path = '/work' }, go_cache.parse_probe(probe_output))
+  assert_equals(volumes[1].name, 'container-nvim-go-1a2b3c4d-go1-22-mod', 'module cache')
+  assert_equals(volumes[2].name, 'container-nvim-go-1a2b3c4d-go1-22-build', 'build cache')
+  assert_equals(volumes[2].target, '/home/vscode/.cache/go-build', 'target')
+  assert_equals(go_cache.version_key('devel go1.23-abc'), 'devel', 'development version')
+end)
+
+test('new volumes are labeled, handed to the user and mounted', function()
+  commands = {}
+  local config = { base_path = '/work', remote_user = 'vscode', mounts = {} }
+  local added
+  go_cache.prepare_async(config, 'app:latest', function(count)
+    added = count
+  end)
+  assert_equals(added, 2, 'mounts added')
+  assert_equals(commands[1], 'run --rm -u vscode --entrypoint sh app:latest -c ' .. go_cache.PROBE_SCRIPT, 'probe')
+  assert_equals(
+    ran('volume create --label container.nvim.go-cache=1a2b3c4d container-nvim-go-1a2b3c4d-go1-22-mod'),
+    true,
+    'labeled volume'
+  )
+  assert_equals(ran('run --rm -u root -v container-nvim-go-1a2b3c4d-go1-22-build:/cache'), true, 'chown')
+  assert_equals(config.mounts[1].source, 'container-nvim-go-1a2b3c4d-go1-22-mod', 'mount source')
+  assert_equals(config.mounts[1].type, 'volume', 'mount type')
+end)
+
+test('existing volumes and mounted targets are reused', function()
+  commands = {}
+  existing_volumes['container-nvim-go-1a2b3c4d-go1-22-mod'] = true
+  local config = {
+    base_path = '/work',
+    mounts = { { type = 'bind', source = '/cache', target = '/home/vscode/.cache/go-build' } },
+  }
+  go_cache.prepare_async(config, 'app:latest', function() end)
+  assert_equals(#config.mounts, 2, 'only the module cache is added')
+  assert_equals(ran('volume create'), false, 'no volume created')
+end)
+
+test('images without go get no volumes', function()
+  probe_output = nil
+  local config = { base_path = '/work' }
+  local added
+  go_cache.prepare_async(config, 'alpine', function(count)
+    added = count
+  end)
+  assert_equals(added, 0, 'none added')
+  assert_equals(config.mounts, nil, 'mounts unchanged')
+end)
+
+print()
+print(string.format('=== Go Cache Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
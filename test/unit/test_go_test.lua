@@ -0,0 +1,293 @@
+#!/usr/bin/env lua
+
+-- Test script for container.test module
+-- Run with: lua test/unit/test_go_test.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- go test -json events used by the tests (vim.json.decode is mocked with this table)
+local events = {
+  ['E1'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestAdd',
+    Output = '    calc_test.go:12: want 3\n',
+  },
+  ['E2'] = { Action = 'fail', Package = 'example.com/app/pkg/calc', Test = 'TestAdd' },
+  ['E3'] = { Action = 'output', Package = 'example.com/app', Test = 'TestLog', Output = '    main_test.go:5: debug\n' },
+  ['E4'] = { Action = 'pass', Package = 'example.com/app', Test = 'TestLog' },
+  ['E5'] = { Action = 'fail', Package = 'example.com/app', Test = 'TestNoLocation' },
+  -- Subtest with a multi-line failure message
+  ['S1'] = { Action = 'run', Package = 'example.com/app/pkg/calc', Test = 'TestDiv' },
+  ['S2'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestDiv/by_zero',
+    Output = '=== RUN   TestDiv/by_zero\n',
+  },
+  ['S3'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestDiv/by_zero',
+    Output = '    calc_test.go:30: unexpected result:\n',
+  },
+  ['S4'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestDiv/by_zero',
+    Output = '        got: 1\n',
+  },
+  ['S5'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestDiv/by_zero',
+    Output = '        want: error\n',
+  },
+  ['S6'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Test = 'TestDiv/by_zero',
+    Output = '    --- FAIL: TestDiv/by_zero (0.00s)\n',
+  },
+  ['S7'] = { Action = 'fail', Package = 'example.com/app/pkg/calc', Test = 'TestDiv/by_zero' },
+  ['S8'] = { Action = 'pass', Package = 'example.com/app/pkg/calc', Test = 'TestDiv/ok' },
+  ['S9'] = { Action = 'fail', Package = 'example.com/app/pkg/calc', Test = 'TestDiv' },
+  ['S10'] = { Action = 'skip', Package = 'example.com/app/pkg/calc', Test = 'TestSlow' },
+  -- Build failure reported in the JSON stream (Go 1.24+)
+  ['B1'] = {
+    Action = 'build-output',
+    ImportPath = 'example.com/app/pkg/calc [example.com/app/pkg/calc.test]',
+    Output = '# example.com/app/pkg/calc\n',
+  },
+  ['B2'] = {
+    Action = 'build-output',
+    ImportPath = 'example.com/app/pkg/calc [example.com/app/pkg/calc.test]',
+    Output = './calc.go:3:1: syntax error: unexpected }\n',
+  },
+  ['B3'] = { Action = 'build-fail', ImportPath = 'example.com/app/pkg/calc [example.com/app/pkg/calc.test]' },
+  ['B4'] = {
+    Action = 'output',
+    Package = 'example.com/app/pkg/calc',
+    Output = 'FAIL\texample.com/app/pkg/calc [build failed]\n',
+  },
+  ['B5'] = {
+    Action = 'fail',
+    Package = 'example.com/app/pkg/calc',
+    FailedBuild = 'example.com/app/pkg/calc [example.com/app/pkg/calc.test]',
+  },
+}
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/host/app'
+    end,
+  },
+  json = {
+    decode = function(str)
+      if not events[str] then
+        error('invalid json')
+      end
+      return events[str]
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+-- Mock log and notify modules
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  critical = function(...) end,
+  error = function(...) end,
+  success = function(...) end,
+}
+
+local go_test = require('container.test')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local ctx = {
+  host_root = '/host/app',
+  container_root = '/workspaces/app',
+  host_dir = '/host/app/pkg/calc',
+  module_root = '/host/app',
+  module_path = 'example.com/app',
+}
+
+print('Running go test integration tests...')
+print()
+
+test('enclosing test function is found above the cursor', function()
+  local lines = { 'package calc', '', 'func TestAdd(t *testing.T) {', '  if x != 3 {', '  }', '}' }
+  assert_equals(go_test.find_enclosing_test(lines, 4), 'TestAdd', 'inside test')
+  assert_equals(go_test.find_enclosing_test(lines, 1), nil, 'before test')
+end)
+
+test('command runs all packages or a single test', function()
+  local all = go_test.build_command({})
+  assert_equals(table.concat(all, ' '), 'go test -json ./...', 'suite command')
+  local nearest = go_test.build_command({ run = 'TestAdd', packages = { '.' } })
+  assert_equals(table.concat(nearest, ' '), 'go test -json -run ^TestAdd$ .', 'nearest command')
+end)
+
+test('paths map between host and container roots', function()
+  local mapped = go_test.map_path('/host/app/pkg', '/host/app', '/workspaces/app')
+  assert_equals(mapped, '/workspaces/app/pkg', 'to container')
+  assert_equals(go_test.map_path('/host/application', '/host/app', '/workspaces/app'), nil, 'sibling directory')
+end)
+
+test('locations are parsed with and without columns', function()
+  local file, lnum, col, msg = go_test.parse_location('./calc.go:10:2: undefined: x')
+  assert_equals(file, './calc.go', 'file')
+  assert_equals(lnum, 10, 'line')
+  assert_equals(col, 2, 'column')
+  assert_equals(msg, 'undefined: x', 'message')
+  file, lnum, col = go_test.parse_location('    calc_test.go:12: want 3')
+  assert_equals(file, 'calc_test.go', 'test file')
+  assert_equals(col, 0, 'no column')
+end)
+
+test('files resolve to host paths', function()
+  assert_equals(
+    go_test.resolve_file('calc_test.go', 'example.com/app/pkg/calc', ctx),
+    '/host/app/pkg/calc/calc_test.go',
+    'package relative'
+  )
+  assert_equals(go_test.resolve_file('/workspaces/app/main.go', nil, ctx), '/host/app/main.go', 'container path')
+  assert_equals(go_test.resolve_file('/usr/local/go/src/testing.go', nil, ctx), nil, 'outside workspace')
+  assert_equals(go_test.resolve_file('./calc.go', nil, ctx), '/host/app/pkg/calc/calc.go', 'working directory')
+end)
+
+test('only failing tests produce quickfix entries', function()
+  local parser = go_test.new_parser(ctx)
+  for _, line in ipairs({ 'E1', 'E2', 'E3', 'E4', 'E5' }) do
+    parser.feed(line)
+  end
+  assert_equals(#parser.items, 2, 'item count')
+  assert_equals(parser.items[1].filename, '/host/app/pkg/calc/calc_test.go', 'failure file')
+  assert_equals(parser.items[1].lnum, 12, 'failure line')
+  assert_equals(parser.items[1].text, 'TestAdd: want 3', 'entry names the test')
+  assert_equals(parser.items[2].filename, nil, 'failure without location')
+  assert_equals(parser.failed, true, 'failed flag')
+end)
+
+test('plain build errors are collected', function()
+  local parser = go_test.new_parser(ctx)
+  assert_equals(parser.feed('./calc.go:3:1: syntax error'), './calc.go:3:1: syntax error', 'displayed text')
+  assert_equals(#parser.items, 1, 'build error item')
+  assert_equals(parser.build_failed, true, 'reported as a build failure')
+end)
+
+test('subtest failures point at the assertion and keep multi-line messages', function()
+  local parser = go_test.new_parser(ctx)
+  for _, line in ipairs({ 'S1', 'S2', 'S3', 'S4', 'S5', 'S6', 'S7', 'S8', 'S9', 'S10' }) do
+    parser.feed(line)
+  end
+  assert_equals(#parser.items, 3, 'assertion and two context lines, nothing for the parent')
+  assert_equals(parser.items[1].filename, '/host/app/pkg/calc/calc_test.go', 'failure file')
+  assert_equals(parser.items[1].lnum, 30, 'failure line')
+  assert_equals(parser.items[1].text, 'TestDiv/by_zero: unexpected result:', 'subtest name')
+  assert_equals(parser.items[2].text, '        got: 1', 'continuation line')
+  assert_equals(parser.items[2].filename, nil, 'context line has no location')
+  assert_equals(parser.items[3].text, '        want: error', 'second continuation line')
+  assert_equals(parser.build_failed, false, 'not a build failure')
+end)
+
+test('passed, failed and skipped tests are counted', function()
+  local parser = go_test.new_parser(ctx)
+  for _, line in ipairs({ 'S1', 'S7', 'S8', 'S9', 'S10', 'E3', 'E4' }) do
+    parser.feed(line)
+  end
+  assert_equals(parser.counts.passed, 2, 'passed')
+  assert_equals(parser.counts.failed, 2, 'failed')
+  assert_equals(parser.counts.skipped, 1, 'skipped')
+  assert_equals(go_test.summary(), nil, 'no summary before a run')
+end)
+
+test('build failures in the JSON stream are told apart from test failures', function()
+  local parser = go_test.new_parser(ctx)
+  for _, line in ipairs({ 'B1', 'B2', 'B3', 'B4', 'B5' }) do
+    parser.feed(line)
+  end
+  assert_equals(parser.build_failed, true, 'build failed')
+  assert_equals(#parser.items, 1, 'compiler error')
+  assert_equals(parser.items[1].filename, '/host/app/pkg/calc/calc.go', 'error file')
+  assert_equals(parser.items[1].text, 'syntax error: unexpected }', 'compiler message')
+  assert_equals(parser.counts.failed, 0, 'no failing tests')
+end)
+
+test('enclosing benchmark and bench commands', function()
+  local lines = { 'func TestAdd(t *testing.T) {', '}', 'func BenchmarkSum(b *testing.B) {', '  for b.Loop() {', '}' }
+  assert_equals(go_test.find_enclosing_benchmark(lines, 4), 'BenchmarkSum', 'inside benchmark')
+  assert_equals(go_test.find_enclosing_benchmark(lines, 2), nil, 'inside test')
+  assert_equals(table.concat(go_test.build_bench_command({}), ' '), 'go test -bench=. -benchmem .', 'package')
+  assert_equals(
+    table.concat(go_test.build_bench_command({ bench = 'BenchmarkSum', args = { '-count=3' } }), ' '),
+    'go test -bench=^BenchmarkSum$ -run=^$ -benchmem -count=3 .',
+    'nearest'
+  )
+end)
+
+test('benchmark result lines are parsed', function()
+  local line = 'BenchmarkSum/small-8   \t 1000000\t      1052 ns/op\t     128 B/op\t       2 allocs/op'
+  local result = go_test.parse_bench_line(line)
+  assert_equals(result.name, 'BenchmarkSum/small', 'name')
+  assert_equals(result.procs, 8, 'procs')
+  assert_equals(result.iterations, 1000000, 'iterations')
+  assert_equals(result.ns_per_op, 1052, 'ns/op')
+  assert_equals(result.bytes_per_op, 128, 'B/op')
+  assert_equals(result.allocs_per_op, 2, 'allocs/op')
+
+  local custom = go_test.parse_bench_line('BenchmarkParse 500 0.5123 ns/op 12.00 MB/s')
+  assert_equals(custom.procs, nil, 'no procs suffix')
+  assert_equals(custom.ns_per_op, 0.5123, 'fractional ns/op')
+  assert_equals(custom.metrics['MB/s'], 12, 'custom metric')
+  assert_equals(custom.allocs_per_op, nil, 'no -benchmem columns')
+
+  assert_equals(go_test.parse_bench_line('BenchmarkSum-8'), nil, 'name only')
+  assert_equals(go_test.parse_bench_line('ok  \texample.com/app\t1.2s'), nil, 'summary line')
+
+  local lines = go_test.format_bench_results({ result, custom })
+  assert_equals(lines[1], 'Benchmark           Iterations   ns/op  B/op  allocs/op', 'header')
+  assert_equals(lines[2], 'BenchmarkSum/small     1000000    1052   128          2', 'row')
+  assert_equals(lines[3], 'BenchmarkParse             500  0.5123     -          -', 'row without memory columns')
+end)
+
+print()
+print(string.format('=== Go Test Integration Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,151 @@
+#!/usr/bin/env lua
+
+-- Test script for container.health module
+-- Run with: lua test/unit/test_health.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- JSON texts decoded by the vim.json mock
+local decoded = {
+  ['{"Status":"unhealthy"}'] = {
+    Status = 'unhealthy',
+    FailingStreak = 3,
+    Log = {
+      { ExitCode = 0, Output = 'ok' },
+      { ExitCode = 1, Output = 'curl: (7) Failed to connect\nline 2\nline 3\nline 4\nline 5\nline 6\n' },
+    },
+  },
+  ['{"Status":"healthy"}'] = { Status = 'healthy', FailingStreak = 0, Log = { { ExitCode = 0, Output = '' } } },
+  ['health-event'] = {
+    Type = 'container',
+    Action = 'health_status: healthy',
+    Actor = { ID = '0123456789abcdef', Attributes = { ['com.docker.compose.service'] = 'db', name = 'mono-db-1' } },
+    time = 1700000000,
+  },
+  ['start-event'] = { Type = 'container', Action = 'start', Actor = { ID = '0123456789abcdef' } },
+}
+
+_G.vim = {
+  json = {
+    decode = function(str)
+      if not decoded[str] then
+        error('invalid json')
+      end
+      return decoded[str]
+    end,
+  },
+  fn = {
+    strdisplaywidth = function(s)
+      return #s
+    end,
+  },
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s, sep)
+    local parts = {}
+    for part in (s .. sep):gmatch('(.-)' .. sep) do
+      table.insert(parts, part)
+    end
+    return parts
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local health = require('container.health')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function contains(lines, text)
+  for _, line in ipairs(lines) do
+    if line == text then
+      return true
+    end
+  end
+  return false
+end
+
+local inspect_output = table.concat({
+  '0123456789abcdef|/mono-db-1|db|running|{"Status":"healthy"}',
+  'fedcba9876543210|/mono-api-1|api|running|{"Status":"unhealthy"}',
+  'aaaaaaaaaaaaaaaa|/app-devcontainer|<no value>|running|null',
+}, '\n')
+
+print('Running health tests...')
+print()
+
+test('the state and last check of each container are parsed', function()
+  local services = health.parse_states(inspect_output)
+  assert_equals(#services, 3, 'services')
+  assert_equals(services[1].id, '0123456789ab', 'short id')
+  assert_equals(services[1].health.status, 'healthy', 'status')
+  assert_equals(services[2].name, 'api', 'service name')
+  assert_equals(services[2].health.failing_streak, 3, 'failing streak')
+  assert_equals(services[2].health.exit_code, 1, 'exit code of the last check')
+  assert_equals(services[3].name, 'app-devcontainer', 'container name without a service label')
+  assert_equals(services[3].health, nil, 'no health check')
+end)
+
+test('only health_status events are parsed', function()
+  local event = health.parse_event('health-event')
+  assert_equals(event.id, '0123456789ab', 'id')
+  assert_equals(event.name, 'db', 'service')
+  assert_equals(event.status, 'healthy', 'status')
+  assert_equals(event.time, 1700000000, 'time')
+  assert_equals(health.parse_event('start-event'), nil, 'other events')
+  assert_equals(health.parse_event('not json'), nil, 'invalid lines')
+end)
+
+test('the window lists services, the last check output and the transitions', function()
+  local lines = health.render_lines(health.parse_states(inspect_output), {})
+  assert_equals(lines[1], 'SERVICE           STATE    HEALTH           FAILING  LAST CHECK', 'header')
+  assert_equals(lines[3], 'api               running  unhealthy        3        exit 1', 'unhealthy row')
+  assert_equals(lines[4], 'app-devcontainer  running  no health check  -        -', 'row without health check')
+  assert(contains(lines, 'api, last check output:'), 'output heading')
+  assert(contains(lines, '  curl: (7) Failed to connect'), 'output')
+  assert(contains(lines, '  (1 more lines)'), 'long output is cut')
+  assert(not contains(lines, 'db, last check output:'), 'empty output is left out')
+  assert(contains(lines, '  (waiting for health status changes)'), 'no transitions yet')
+
+  lines = health.render_lines({}, { { id = '0123456789ab', name = 'db', status = 'healthy', time = 1700000000 } })
+  assert(lines[#lines]:match('^  %d%d:%d%d:%d%d  db  healthy$'), 'transition: ' .. lines[#lines])
+end)
+
+print()
+print(string.format('=== Health Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
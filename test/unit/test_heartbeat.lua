@@ -0,0 +1,186 @@
+#!/usr/bin/env lua
+
+-- Test script for container.heartbeat module
+-- Run with: lua test/unit/test_heartbeat.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Exit codes of the next docker exec pings, in order (nil: the ping never returns)
+local ping_results = {}
+local pings = 0
+local reconnected = {}
+local lost = {}
+local sessions = {}
+local status = 'running'
+
+_G.vim = {
+  deepcopy = function(t)
+    local copy = {}
+    for k, v in pairs(t) do
+      copy[k] = v
+    end
+    return copy
+  end,
+  tbl_extend = function(_, a, b)
+    local result = {}
+    for k, v in pairs(a) do
+      result[k] = v
+    end
+    for k, v in pairs(b) do
+      result[k] = v
+    end
+    return result
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function(fn)
+    fn()
+  end,
+  fn = {
+    jobstart = function(_, opts)
+      pings = pings + 1
+      local code = table.remove(ping_results, 1)
+      if code then
+        opts.on_exit(1, code)
+      end
+      return pings
+    end,
+    jobstop = function() end,
+  },
+  lsp = {
+    get_clients = function()
+      return {}
+    end,
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.docker.runtime'] = {
+  get = function()
+    return 'docker'
+  end,
+}
+
+package.loaded['container.docker'] = {
+  run_docker_command_async = function(_, _, callback)
+    callback({ success = status ~= nil, stdout = (status or '') .. '\n' })
+  end,
+}
+
+package.loaded['container.terminal.session'] = {
+  list_sessions = function()
+    return sessions
+  end,
+}
+
+package.loaded['container.lsp.init'] = {
+  get_state = function()
+    return { container_id = nil }
+  end,
+}
+
+package.loaded['container'] = {
+  get_container_id = function()
+    return 'abc'
+  end,
+  get_state = function()
+    return {}
+  end,
+  _reconnect_session = function(container_id, reason)
+    table.insert(reconnected, container_id .. ':' .. reason)
+  end,
+  _lost_container = function(container_id, container_status)
+    table.insert(lost, container_id .. ':' .. tostring(container_status))
+  end,
+}
+
+local heartbeat = require('container.heartbeat')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  ping_results = {}
+  pings = 0
+  reconnected = {}
+  lost = {}
+  sessions = {}
+  status = 'running'
+  heartbeat.stop()
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running heartbeat tests...')
+print()
+
+test('a tick much later than due is a resume', function()
+  assert_equals(heartbeat.is_resumed(nil, 100, 30000), false, 'first tick')
+  assert_equals(heartbeat.is_resumed(100, 130, 30000), false, 'on time')
+  assert_equals(heartbeat.is_resumed(100, 161, 30000), false, 'within two intervals')
+  assert_equals(heartbeat.is_resumed(100, 1000, 30000), true, 'after a suspend')
+end)
+
+test('an answered ping changes nothing', function()
+  ping_results = { 0 }
+  heartbeat.tick()
+  assert_equals(pings, 1, 'one ping')
+  assert_equals(#reconnected, 0, 'no reconnection')
+end)
+
+test('a failed ping reconnects once the container answers', function()
+  ping_results = { 1, 0 }
+  heartbeat.tick()
+  assert_equals(pings, 2, 'pinged again')
+  assert_equals(reconnected[1], 'abc:the container did not answer', 'reconnected')
+end)
+
+test('a container that no longer runs is reported', function()
+  ping_results = { 1, 1 }
+  status = 'exited'
+  heartbeat.tick()
+  assert_equals(#reconnected, 0, 'not reconnected')
+  assert_equals(lost[1], 'abc:exited', 'lost')
+end)
+
+test('dead terminals make the session dead', function()
+  sessions = {
+    {
+      container_id = 'abc',
+      is_valid = function()
+        return false
+      end,
+    },
+  }
+  assert_equals(heartbeat.session_alive('abc'), false, 'dead terminal')
+  assert_equals(heartbeat.session_alive('other'), true, 'terminal of another container')
+end)
+
+print()
+print(string.format('=== Heartbeat Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,110 @@
+#!/usr/bin/env lua
+
+-- Test script for container.host_requirements module
+-- Run with: lua test/unit/test_host_requirements.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local settings = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    return settings[key]
+  end,
+}
+
+-- find_run_arg is all the module uses from container.docker
+package.loaded['container.docker'] = {
+  find_run_arg = function(run_args, names)
+    for _, arg in ipairs(run_args or {}) do
+      for _, name in ipairs(names) do
+        if arg == name or arg:sub(1, #name + 1) == name .. '=' then
+          return true
+        end
+      end
+    end
+    return nil
+  end,
+}
+
+local host_requirements = require('container.host_requirements')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  settings = {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running host requirements tests...')
+print()
+
+test('sizes are parsed with their unit', function()
+  assert_equals(host_requirements.parse_size('4gb'), 4 * 1024 ^ 3, 'gb')
+  assert_equals(host_requirements.parse_size('512MB'), 512 * 1024 ^ 2, 'upper case')
+  assert_equals(host_requirements.parse_size('1.5 tb'), math.floor(1.5 * 1024 ^ 4), 'fraction and space')
+  assert_equals(host_requirements.parse_size('8Gi'), 8 * 1024 ^ 3, 'binary suffix')
+  assert_equals(host_requirements.parse_size(1024), 1024, 'bytes')
+  assert_equals(host_requirements.parse_size('lots'), nil, 'no number')
+  assert_equals(host_requirements.parse_size('4pb'), nil, 'unknown unit')
+  assert_equals(host_requirements.parse_size('0gb'), nil, 'zero')
+end)
+
+test('only resources the host lacks are reported', function()
+  local required = host_requirements.requirements({
+    host_requirements = { cpus = 8, memory = '16gb', storage = '32gb', gpu = true },
+  })
+  local shortfalls = host_requirements.check(required, { cpus = 4, memory = 8 * 1024 ^ 3, storage = 64 * 1024 ^ 3 })
+  assert_equals(#shortfalls, 2, 'shortfalls')
+  assert_equals(shortfalls[1], 'cpus: 8 required, 4 available', 'cpus')
+  assert_equals(shortfalls[2], 'memory: 16.0 GB required, 8.0 GB available', 'memory')
+  assert_equals(#host_requirements.check(required, {}), 0, 'unknown resources are not reported')
+  assert_equals(host_requirements.has_requirements({ host_requirements = { gpu = true } }), false, 'gpu only')
+end)
+
+test('required cpus and memory become limits unless runArgs set them', function()
+  local config = { host_requirements = { cpus = 2, memory = '4gb' } }
+  assert_equals(table.concat(host_requirements.limit_args(config), ' '), '--cpus 2 --memory 4294967296', 'limits')
+
+  config.run_args = { '--memory=2g' }
+  assert_equals(table.concat(host_requirements.limit_args(config), ' '), '--cpus 2', 'runArgs memory wins')
+
+  settings['host_requirements.limits'] = false
+  assert_equals(#host_requirements.limit_args(config), 0, 'limits disabled')
+end)
+
+print()
+print(string.format('=== Host Requirements Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,316 @@
+#!/usr/bin/env lua
+
+-- Test script for image cache keys in container.docker
+-- Run with: lua test/unit/test_image_cache.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local files = {}
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    filereadable = function(path)
+      return files[path] and 1 or 0
+    end,
+    isdirectory = function()
+      return 0
+    end,
+    getcwd = function()
+      return '/project'
+    end,
+    glob = function()
+      return {}
+    end,
+    globpath = function()
+      return {}
+    end,
+    -- Deterministic stand-in for sha256
+    sha256 = function(str)
+      local h1, h2 = 5381, 52711
+      for i = 1, #str do
+        local c = str:byte(i)
+        h1 = (h1 * 33 + c) % 4294967296
+        h2 = (h2 * 31 + c) % 4294967296
+      end
+      return string.format('%08x%08x', h1, h2)
+    end,
+  },
+  tbl_keys = function(t)
+    local keys = {}
+    for k, _ in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Files are served from the in-memory table
+local real_open = io.open
+io.open = function(path, mode)
+  if files[path] then
+    local content = files[path]
+    return {
+      read = function()
+        return content
+      end,
+      close = function() end,
+    }
+  end
+  return real_open(path, mode)
+end
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local plugin_config = { docker = {} }
+package.loaded['container.config'] = {
+  get = function()
+    return plugin_config
+  end,
+}
+
+package.loaded['container.pipeline'] = {
+  active = function() end,
+  is_cancelled = function()
+    return false
+  end,
+  track = function() end,
+}
+
+local docker = require('container.docker')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function base_config()
+  files['/project/.devcontainer/Dockerfile'] =
+    'FROM golang:1.22\nCOPY go.mod go.sum /src/\nCOPY --from=builder /bin/x /x\n'
+  files['/project/go.mod'] = 'module example.com/app\n'
+  files['/project/go.sum'] = ''
+  return {
+    name = 'App',
+    dockerfile = '/project/.devcontainer/Dockerfile',
+    context = '.',
+    base_path = '/project',
+    build_args = { VERSION = '1' },
+    features = {},
+  }
+end
+
+print('Running image cache tests...')
+print()
+
+test('COPY sources are collected from the build context', function()
+  local config = base_config()
+  local context_files = docker.get_build_context_files(files[config.dockerfile], '/project')
+  assert_equals(#context_files, 2, 'file count')
+  assert_equals(context_files[1], '/project/go.mod', 'first file')
+  assert_equals(context_files[2], '/project/go.sum', 'second file')
+end)
+
+test('cache key is stable for unchanged inputs', function()
+  assert_equals(docker.compute_image_cache_key(base_config()), docker.compute_image_cache_key(base_config()), 'key')
+end)
+
+test('cache key changes with build args, features and image', function()
+  local key = docker.compute_image_cache_key(base_config())
+
+  local config = base_config()
+  config.build_args.VERSION = '2'
+  assert(docker.compute_image_cache_key(config) ~= key, 'build args should invalidate')
+
+  config = base_config()
+  config.features = { ['ghcr.io/devcontainers/features/node:1'] = {} }
+  assert(docker.compute_image_cache_key(config) ~= key, 'features should invalidate')
+
+  config = base_config()
+  config.image = 'golang:1.23'
+  assert(docker.compute_image_cache_key(config) ~= key, 'image should invalidate')
+end)
+
+test('cache key changes with build target and options only when set', function()
+  local key = docker.compute_image_cache_key(base_config())
+
+  local config = base_config()
+  config.cache_from = { 'app:cache' }
+  assert_equals(docker.compute_image_cache_key(config), key, 'cacheFrom does not change the image')
+
+  config.build_target = 'dev'
+  assert(docker.compute_image_cache_key(config) ~= key, 'target should invalidate')
+
+  config = base_config()
+  config.build_options = { '--network=host' }
+  assert(docker.compute_image_cache_key(config) ~= key, 'options should invalidate')
+
+  config = base_config()
+  config.default_build_args = { '--build-arg', 'HTTP_PROXY=http://proxy:3128' }
+  assert(docker.compute_image_cache_key(config) ~= key, 'default build args should invalidate')
+  config.default_build_args = {}
+  assert_equals(docker.compute_image_cache_key(config), key, 'no default build args keep the key')
+end)
+
+test('build flags follow build.args, target, cacheFrom and options', function()
+  local config = base_config()
+  config.build_args = { VERSION = '1', GO = '1.22' }
+  config.build_target = 'dev'
+  config.cache_from = { 'app:main', 'app:dev' }
+  config.build_options = { '--network=host' }
+  assert_equals(
+    table.concat(docker.build_option_args(config), ' '),
+    '--build-arg GO=1.22 --build-arg VERSION=1 --target dev --cache-from app:main --cache-from app:dev --network=host',
+    'flags'
+  )
+  assert_equals(#docker.build_option_args({ name = 'App' }), 0, 'no flags without build settings')
+
+  config.default_build_args = { '--pull', '--network=none' }
+  local args = docker.build_option_args(config)
+  assert_equals(args[1] .. ' ' .. args[2], '--pull --network=none', 'plugin defaults come first')
+  assert_equals(args[#args], '--network=host', 'build.options override the defaults')
+end)
+
+test('cache key changes when a copied file changes', function()
+  local key = docker.compute_image_cache_key(base_config())
+  local config = base_config()
+  files['/project/go.mod'] = 'module example.com/app\n\ngo 1.22\n'
+  assert(docker.compute_image_cache_key(config) ~= key, 'context file should invalidate')
+end)
+
+test('files excluded by .dockerignore are left out of the key', function()
+  local config = base_config()
+  files['/project/.dockerignore'] = '# lock files are regenerated\ngo.sum\n'
+  local context_files = docker.get_build_context_files(
+    files[config.dockerfile],
+    '/project',
+    require('container.build_context').rules('/project', config.dockerfile)
+  )
+  assert_equals(#context_files, 1, 'file count')
+  assert_equals(context_files[1], '/project/go.mod', 'remaining file')
+
+  local key = docker.compute_image_cache_key(config)
+  files['/project/go.sum'] = 'example.com/dep v1.0.0 h1:abc=\n'
+  assert_equals(docker.compute_image_cache_key(config), key, 'ignored file does not invalidate')
+  files['/project/.dockerignore'] = nil
+end)
+
+test('cache tag includes the sanitized name and key', function()
+  assert_equals(docker.get_image_cache_tag({ name = 'My App' }, 'abc123'), 'container-nvim-my-app:abc123', 'tag')
+end)
+
+test('prebuilt candidates are prebuiltImage and registry cacheFrom images', function()
+  local config = base_config()
+  config.prebuilt_image = 'ghcr.io/org/app:main'
+  config.cache_from = {
+    'ghcr.io/org/app:main',
+    'ghcr.io/org/app:cache',
+    'type=registry,ref=ghcr.io/org/app:buildcache',
+    'type=local,src=/tmp/cache',
+  }
+  local candidates = docker.prebuilt_candidates(config)
+  assert_equals(#candidates, 3, 'candidate count')
+  assert_equals(candidates[1], 'ghcr.io/org/app:main', 'prebuiltImage first')
+  assert_equals(candidates[2], 'ghcr.io/org/app:cache', 'cacheFrom image')
+  assert_equals(candidates[3], 'ghcr.io/org/app:buildcache', 'registry cache ref')
+
+  plugin_config.docker.pull_cache_from = false
+  assert_equals(#docker.prebuilt_candidates(config), 1, 'only prebuiltImage without pull_cache_from')
+  plugin_config.docker.pull_cache_from = nil
+end)
+
+-- Pull, inspect and tag are answered from the given tables
+local function with_registry(pullable, labels, func)
+  local original_pull, original_command = docker.pull_image_async, docker.run_docker_command_async
+  local commands = {}
+  docker.pull_image_async = function(image, _, on_complete)
+    on_complete(pullable[image] == true, {})
+    return 1
+  end
+  docker.run_docker_command_async = function(args, _, callback)
+    table.insert(commands, table.concat(args, ' '))
+    if args[1] == 'image' then
+      callback({ success = true, stdout = (labels[args[#args]] or '<no value>') .. '\n' })
+    else
+      callback({ success = true, stdout = '' })
+    end
+  end
+  local ok, err = pcall(func, commands)
+  docker.pull_image_async, docker.run_docker_command_async = original_pull, original_command
+  if not ok then
+    error(err, 0)
+  end
+end
+
+test('a pulled image with the local cache key is tagged and used', function()
+  local config = base_config()
+  config.prebuilt_image = 'ghcr.io/org/app:main'
+  config.cache_from = { 'ghcr.io/org/app:cache' }
+  with_registry({ ['ghcr.io/org/app:cache'] = true }, { ['ghcr.io/org/app:cache'] = 'abc123' }, function(commands)
+    local used, reasons
+    docker.use_prebuilt_image(config, 'abc123', nil, function(image, skipped)
+      used, reasons = image, skipped
+    end)
+    assert_equals(used, 'ghcr.io/org/app:cache', 'image used')
+    assert_equals(reasons[1], 'pull of ghcr.io/org/app:main failed', 'failed pull is reported')
+    assert_equals(commands[#commands], 'tag ghcr.io/org/app:cache container-nvim-app:abc123', 'tagged as cache tag')
+  end)
+end)
+
+test('a prebuilt image from other inputs falls back to a local build', function()
+  local config = base_config()
+  config.prebuilt_image = 'ghcr.io/org/app:main'
+  with_registry({ ['ghcr.io/org/app:main'] = true }, { ['ghcr.io/org/app:main'] = 'old456' }, function(commands)
+    local used, reasons = 'unset', nil
+    docker.use_prebuilt_image(config, 'abc123', nil, function(image, skipped)
+      used, reasons = image, skipped
+    end)
+    assert_equals(used, nil, 'no image used')
+    assert_equals(reasons[1], 'ghcr.io/org/app:main was built from a different Dockerfile or context', 'reason')
+    assert_equals(#commands, 1, 'nothing tagged')
+  end)
+end)
+
+test('built images are labeled with their cache key', function()
+  local config = base_config()
+  config.image_cache_key = 'abc123'
+  local args = table.concat(docker.build_image_args(config, 'container-nvim-app:abc123'), ' ')
+  assert(args:find('--label dev.container-nvim.cache-key=abc123', 1, true), 'label: ' .. args)
+  assert(args:find('--label dev.container-nvim.image=build', 1, true), 'image label for :ContainerPrune: ' .. args)
+end)
+
+print()
+print(string.format('=== Image Cache Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
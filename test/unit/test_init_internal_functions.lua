@@ -53,6 +53,9 @@ local docker_mock = {
       callback(true, nil)
     end)
   end,
+  get_stop_timeout = function()
+    return 10
+  end,
   stop_container_async = function(container_id, callback)
     table.insert(mock_state.async_callbacks, { type = 'stop', container_id = container_id, callback = callback })
     vim.schedule(function()
@@ -161,6 +164,9 @@ local parser_mock = {
   validate_resolved_ports = function(config)
     return {} -- No validation errors
   end,
+  add_additional_mounts = function(config)
+    return config, {}
+  end,
   normalize_for_plugin = function(config)
     local normalized = vim.deepcopy(config)
     normalized.post_create_command = config.postCreateCommand
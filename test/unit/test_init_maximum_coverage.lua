@@ -190,6 +190,9 @@ local function setup_maximum_coverage_mocks()
         end
       end, 10)
     end,
+    get_stop_timeout = function()
+      return 10
+    end,
     stop_container_async = function(container_id, callback)
       vim.defer_fn(function()
         callback(
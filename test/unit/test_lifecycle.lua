@@ -0,0 +1,260 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lifecycle module
+-- Run with: lua test/unit/test_lifecycle.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k, _ in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+  tbl_extend = function(_, ...)
+    local result = {}
+    for _, source in ipairs({ ... }) do
+      for k, v in pairs(source) do
+        result[k] = v
+      end
+    end
+    return result
+  end,
+  tbl_contains = function(tbl, value)
+    for _, v in ipairs(tbl) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+-- Mock environment module
+package.loaded['container.environment'] = {
+  build_postcreate_args = function(config)
+    return { '-u', config.remote_user or 'root' }
+  end,
+}
+
+local lifecycle = require('container.lifecycle')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running lifecycle tests...')
+print()
+
+test('hooks are ordered as in the specification', function()
+  local names = {}
+  for _, hook in ipairs(lifecycle.HOOKS) do
+    table.insert(names, hook.name)
+  end
+  assert_equals(
+    table.concat(names, ','),
+    'onCreateCommand,updateContentCommand,postCreateCommand,postStartCommand,postAttachCommand',
+    'order'
+  )
+end)
+
+test('string command runs through shell', function()
+  local entries = lifecycle.normalize_command('npm install')
+  assert_equals(#entries, 1, 'entry count')
+  assert_equals(entries[1].args[1], '/bin/sh', 'shell')
+  assert_equals(entries[1].args[3], 'npm install', 'command')
+end)
+
+test('array command runs as argv', function()
+  local entries = lifecycle.normalize_command({ 'go', 'mod', 'download' })
+  assert_equals(#entries, 1, 'entry count')
+  assert_equals(entries[1].args[1], 'go', 'argv[0]')
+  assert_equals(#entries[1].args, 3, 'argv length')
+end)
+
+test('array elements with spaces are still argv', function()
+  local entries = lifecycle.normalize_command({ 'echo', 'hello world' })
+  assert_equals(#entries, 1, 'entry count')
+  assert_equals(entries[1].args[1], 'echo', 'argv[0]')
+  assert_equals(entries[1].args[2], 'hello world', 'argument is not split or joined')
+end)
+
+test('object command is expanded per label', function()
+  local entries = lifecycle.normalize_command({ server = 'npm install', db = { 'make', 'migrate' } })
+  assert_equals(#entries, 2, 'entry count')
+  assert_equals(entries[1].label, 'db', 'first label')
+  assert_equals(entries[2].label, 'server', 'second label')
+end)
+
+test('empty commands are ignored', function()
+  assert_equals(#lifecycle.normalize_command(nil), 0, 'nil')
+  assert_equals(#lifecycle.normalize_command(''), 0, 'empty string')
+  assert_equals(#lifecycle.normalize_command({}), 0, 'empty table')
+end)
+
+test('exec args include user, workspace and container', function()
+  local entry = lifecycle.normalize_command('make')[1]
+  local args = lifecycle.build_exec_args('abc123', { remote_user = 'vscode', workspace_folder = '/src' }, entry)
+  assert_equals(args[1], 'exec', 'exec')
+  assert_equals(args[4], 'vscode', 'user')
+  assert_equals(args[6], '/src', 'workdir')
+  assert_equals(args[7], 'abc123', 'container')
+  assert_equals(args[10], 'make', 'command')
+end)
+
+test('has_commands detects configured hooks', function()
+  assert_equals(lifecycle.has_commands({}), false, 'no hooks')
+  assert_equals(lifecycle.has_commands({ post_attach_command = 'echo hi' }), true, 'attach hook')
+end)
+
+test('the container is ready after the waitFor hook', function()
+  local events = {}
+  local original_run_hook = lifecycle.run_hook
+  local original_has_run = lifecycle.has_run_create_commands
+  lifecycle.run_hook = function(_, _, hook, callback)
+    table.insert(events, hook.name)
+    callback(true)
+  end
+  lifecycle.has_run_create_commands = function(_, callback)
+    callback(true)
+  end
+
+  local config = { post_create_command = 'make', post_start_command = 'serve', post_attach_command = 'echo hi' }
+  local function run(wait_for)
+    events = {}
+    lifecycle.run('abc', config, {
+      families = { 'start', 'attach' },
+      open_output = false,
+      wait_for = wait_for,
+      on_ready = function()
+        table.insert(events, 'ready')
+      end,
+    }, function(success)
+      table.insert(events, success and 'done' or 'failed')
+    end)
+    return table.concat(events, ',')
+  end
+
+  assert_equals(run(nil), 'ready,postStartCommand,postAttachCommand,done', 'default waitFor')
+  assert_equals(run('postStartCommand'), 'postStartCommand,ready,postAttachCommand,done', 'postStartCommand')
+  assert_equals(run('postAttachCommand'), 'postStartCommand,postAttachCommand,ready,done', 'postAttachCommand')
+
+  -- Hooks are reported with whether the container is still waited for
+  local reported = {}
+  lifecycle.run('abc', config, {
+    families = { 'start', 'attach' },
+    open_output = false,
+    wait_for = 'postStartCommand',
+    on_hook = function(name, waiting)
+      table.insert(reported, name .. '=' .. tostring(waiting))
+    end,
+  }, function() end)
+  assert_equals(table.concat(reported, ','), 'postStartCommand=true,postAttachCommand=false', 'on_hook')
+  assert_equals(lifecycle.wait_for_name({}), 'updateContentCommand', 'default waitFor')
+
+  lifecycle.run_hook = original_run_hook
+  lifecycle.has_run_create_commands = original_has_run
+end)
+
+test('labelled initializeCommands run in parallel on the host and a failure is reported', function()
+  local jobs = {}
+  vim.schedule = function(fn)
+    fn()
+  end
+  vim.fn = {
+    jobstart = function(args, opts)
+      table.insert(jobs, { args = args, cwd = opts.cwd })
+      opts.on_stdout(nil, { 'output of ' .. args[#args], '' })
+      opts.on_exit(nil, args[#args] == 'fail' and 1 or 0)
+      return #jobs
+    end,
+  }
+
+  local lines = {}
+  local result, failure
+  local config = { initialize_command = { a = 'prepare', b = 'fail', c = 'never' } }
+  lifecycle.run_initialize_command(config, '/workspace', function(line)
+    table.insert(lines, line)
+  end, function(success, info)
+    result, failure = success, info
+  end)
+
+  assert_equals(#jobs, 3, 'every labelled command runs')
+  assert_equals(jobs[1].cwd, '/workspace', 'runs in the workspace folder')
+  assert_equals(lines[2], 'output of prepare', 'output is streamed')
+  assert_equals(result, false, 'failure reported')
+  assert_equals(failure.command, 'fail', 'failed command')
+  assert_equals(failure.exit_code, 1, 'exit code')
+end)
+
+test('preStopCommand runs only when configured', function()
+  local hooks = {}
+  local original_run_hook = lifecycle.run_hook
+  lifecycle.run_hook = function(container_id, _, hook, callback)
+    table.insert(hooks, container_id .. ':' .. hook.name)
+    callback(true)
+  end
+
+  local done = 0
+  local function on_done(success)
+    assert_equals(success, true, 'success')
+    done = done + 1
+  end
+  lifecycle.run_pre_stop_command('abc', {}, on_done)
+  lifecycle.run_pre_stop_command('abc', { pre_stop_command = 'pg_ctl stop' }, on_done)
+
+  assert_equals(done, 2, 'callback always called')
+  assert_equals(table.concat(hooks, ','), 'abc:preStopCommand', 'hook run once')
+
+  lifecycle.run_hook = original_run_hook
+end)
+
+print()
+print(string.format('=== Lifecycle Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
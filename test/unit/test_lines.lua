@@ -0,0 +1,77 @@
+#!/usr/bin/env lua
+
+-- Test script for container.utils.lines
+-- Run with: lua test/unit/test_lines.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local lines_util = require('container.utils.lines')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Handler recording the lines passed on as "stream:line"
+local function recorder()
+  local received = {}
+  local on_data, remaining = lines_util.handler(function(lines, stream)
+    for _, line in ipairs(lines) do
+      table.insert(received, stream .. ':' .. line)
+    end
+  end)
+  return on_data, remaining, received
+end
+
+print('Running line buffering tests...')
+print()
+
+test('lines split across chunks are joined', function()
+  local on_data, remaining, received = recorder()
+  on_data(1, { 'first', 'sec' }, 'stdout')
+  assert_equals(#received, 1, 'only complete lines')
+  on_data(1, { 'ond', '', 'thi' }, 'stdout')
+  assert_equals(table.concat(received, ','), 'stdout:first,stdout:second,stdout:', 'joined line and blank line')
+  assert_equals(table.concat(remaining(), ','), 'thi', 'incomplete last line')
+  assert_equals(#remaining(), 0, 'returned once')
+end)
+
+test('stdout and stderr are buffered separately', function()
+  local on_data, remaining, received = recorder()
+  on_data(1, { 'out' }, 'stdout')
+  on_data(1, { 'err', '' }, 'stderr')
+  on_data(1, { 'put', '' }, 'stdout')
+  assert_equals(table.concat(received, ','), 'stderr:err,stdout:output', 'not glued together')
+  assert_equals(#remaining(), 0, 'nothing left')
+end)
+
+test('end of output and missing data', function()
+  local on_data, remaining, received = recorder()
+  on_data(1, nil, 'stdout')
+  on_data(1, { '' }, 'stdout')
+  on_data(1, { 'tail' })
+  assert_equals(#received, 0, 'nothing complete')
+  assert_equals(table.concat(remaining(), ','), 'tail', 'stream defaults to stdout')
+end)
+
+print()
+print(string.format('=== Line Buffering Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
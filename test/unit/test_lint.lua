@@ -0,0 +1,130 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lint module
+-- Run with: lua test/unit/test_lint.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Decoded values of the JSON texts used by the tests
+local reports = {}
+
+_G.vim = {
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  json = {
+    decode = function(text)
+      if not reports[text] then
+        error('invalid JSON')
+      end
+      return reports[text]
+    end,
+  },
+  diagnostic = {
+    severity = { ERROR = 1, WARN = 2, INFO = 3, HINT = 4 },
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local lint = require('container.lint')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  reports = {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local ctx = { host_root = '/home/me/app', container_root = '/workspace', host_dir = '/home/me/app/api' }
+
+print('Running lint tests...')
+print()
+
+test('arguments are appended to the configured command', function()
+  local cmd = lint.build_command({ cmd = 'golangci-lint', args = { 'run', '--out-format=json' } }, { './...' })
+  assert_equals(table.concat(cmd, ' '), 'golangci-lint run --out-format=json ./...', 'command')
+  assert_equals(lint.build_command({})[1], 'golangci-lint', 'default command')
+end)
+
+test('issues of the report are mapped to host paths', function()
+  reports['{"Issues":[...]}'] = {
+    Issues = {
+      {
+        FromLinter = 'errcheck',
+        Text = 'Error return value is not checked',
+        Pos = { Filename = 'handler.go', Line = 12, Column = 3 },
+      },
+      {
+        FromLinter = 'staticcheck',
+        Text = 'unused',
+        Severity = 'error',
+        Pos = { Filename = '/workspace/pkg/db.go', Line = 4 },
+      },
+      { FromLinter = 'gosec', Text = 'outside', Pos = { Filename = '/go/pkg/mod/x.go', Line = 1 } },
+    },
+  }
+  local items = lint.parse_report('level=warning msg="skipped"\n{"Issues":[...]}\n', ctx)
+  assert_equals(#items, 2, 'issues outside the workspace are skipped')
+  assert_equals(items[1].filename, '/home/me/app/api/handler.go', 'relative path')
+  assert_equals(items[1].col, 3, 'column')
+  assert_equals(items[1].text, 'Error return value is not checked (errcheck)', 'text names the linter')
+  assert_equals(items[1].type, 'W', 'issues without severity are warnings')
+  assert_equals(items[2].filename, '/home/me/app/pkg/db.go', 'container path')
+  assert_equals(items[2].type, 'E', 'severity')
+end)
+
+test('a report without issues is empty, a missing report an error', function()
+  reports['{"Issues":null}'] = { Report = {} }
+  assert_equals(#lint.parse_report('{"Issues":null}', ctx), 0, 'no issues')
+
+  local items, err = lint.parse_report('api/handler.go:12:3: oops (errcheck)', ctx)
+  assert_equals(items, nil, 'text output')
+  assert(err:find('--out-format=json', 1, true), 'error names the flag: ' .. err)
+end)
+
+test('diagnostics are grouped by file and zero-based', function()
+  local by_file = lint.to_diagnostics({
+    { filename = '/a.go', lnum = 12, col = 3, text = 'x', type = 'E' },
+    { filename = '/a.go', lnum = 1, col = 0, text = 'y', type = 'W' },
+    { filename = '/b.go', lnum = 2, col = 1, text = 'z', type = 'I' },
+  })
+  assert_equals(#by_file['/a.go'], 2, 'grouped')
+  assert_equals(by_file['/a.go'][1].lnum, 11, 'line')
+  assert_equals(by_file['/a.go'][1].col, 2, 'column')
+  assert_equals(by_file['/a.go'][2].col, 0, 'missing column')
+  assert_equals(by_file['/a.go'][2].severity, vim.diagnostic.severity.WARN, 'warning')
+  assert_equals(by_file['/b.go'][1].severity, vim.diagnostic.severity.INFO, 'info')
+  assert_equals(by_file['/b.go'][1].source, 'ContainerLint', 'source')
+end)
+
+print()
+print(string.format('=== Lint Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
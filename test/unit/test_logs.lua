@@ -0,0 +1,110 @@
+#!/usr/bin/env lua
+
+-- Test script for container.logs module
+-- Run with: lua test/unit/test_logs.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    sha256 = function(str)
+      return string.format('%08x', #str)
+    end,
+    getcwd = function()
+      return '/project'
+    end,
+    stdpath = function()
+      return '/cache'
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Mock log and notify modules
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  error = function(...) end,
+  critical = function(...) end,
+}
+
+-- Mock runtime module
+package.loaded['container.docker.runtime'] = {
+  get = function()
+    return 'docker'
+  end,
+}
+
+local logs = require('container.logs')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running logs tests...')
+print()
+
+test('arguments select the service, history and follow mode', function()
+  local opts = logs.parse_args({ 'db', '--since=10m', '--tail', '50', '--no-follow' })
+  assert_equals(opts.service, 'db', 'service')
+  assert_equals(opts.since, '10m', 'since')
+  assert_equals(opts.tail, '50', 'tail')
+  assert_equals(opts.follow, false, 'follow')
+  assert_equals(logs.parse_args({}).follow, true, 'follow by default')
+  assert_equals(logs.parse_args({ 'follow' }).service, nil, 'legacy follow argument')
+end)
+
+test('invalid arguments are reported', function()
+  assert_equals(select(2, logs.parse_args({ '--tail=abc' })), 'Invalid --tail value: abc', 'tail')
+  assert_equals(select(2, logs.parse_args({ '--since' })), '--since needs a value', 'missing value')
+  assert_equals(select(2, logs.parse_args({ '--bogus' })), 'Unknown option: --bogus', 'unknown option')
+  assert_equals(select(2, logs.parse_args({ 'web', 'db' })), 'Only one service can be given', 'two services')
+end)
+
+test('container logs follow the last lines by default', function()
+  local cmd = logs.build_command({}, 'abc123', { follow = true })
+  assert_equals(table.concat(cmd, ' '), 'docker logs -f --tail 100 abc123', 'command')
+  cmd = logs.build_command({}, 'abc123', { since = '1h' })
+  assert_equals(table.concat(cmd, ' '), 'docker logs --since 1h abc123', 'one-shot since')
+end)
+
+test('compose logs target the attached or selected service', function()
+  local config = { name = 'app', compose_files = { '/project/compose.yml' }, service = 'web' }
+  local cmd = table.concat(logs.build_command(config, 'abc123', { follow = true, tail = 'all' }), ' ')
+  assert_equals(cmd:match('logs .*$'), 'logs --no-log-prefix -f --tail all web', 'attached service')
+  cmd = table.concat(logs.build_command(config, 'abc123', { service = 'db' }), ' ')
+  assert_equals(cmd:match('%S+$'), 'db', 'selected service')
+end)
+
+print()
+print(string.format('=== Logs Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
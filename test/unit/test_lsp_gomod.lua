@@ -0,0 +1,146 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lsp.gomod module
+-- Run with: lua test/unit/test_lsp_gomod.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local notifications = {}
+local gopls_clients = {}
+local restarts = {}
+
+_G.vim = {
+  fn = {
+    fnamemodify = function(path, mods)
+      if mods == ':t' then
+        return path:match('([^/]*)$')
+      end
+      return path
+    end,
+  },
+  uri_from_fname = function(path)
+    return 'file://' .. path
+  end,
+  lsp = {
+    get_clients = function(opts)
+      if opts.name == 'container_gopls' then
+        return gopls_clients
+      end
+      return {}
+    end,
+  },
+  tbl_contains = function(t, value)
+    for _, v in ipairs(t) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {
+  status = function(message)
+    table.insert(notifications, message)
+  end,
+}
+
+local action = 'restart'
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'lsp.go_mod_change' then
+      return action
+    end
+  end,
+}
+
+package.loaded['container.lsp.init'] = {
+  restart = function(names, callback)
+    table.insert(restarts, names)
+    callback(names)
+  end,
+}
+
+local gomod = require('container.lsp.gomod')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  notifications = {}
+  restarts = {}
+  gopls_clients = {}
+  action = 'restart'
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running LSP go.mod tests...')
+print()
+
+test('only module and workspace files count', function()
+  assert_equals(gomod.is_module_file('/work/go.mod'), true, 'go.mod')
+  assert_equals(gomod.is_module_file('/work/go.work.sum'), true, 'go.work.sum')
+  assert_equals(gomod.is_module_file('/work/main.go'), false, 'source file')
+  assert_equals(gomod.is_module_file('/work/go.mod.bak'), false, 'backup')
+end)
+
+test('changes restart gopls by default', function()
+  gopls_clients = { {} }
+  gomod.apply({ '/work/go.mod', '/work/go.sum' })
+  assert_equals(#restarts, 1, 'one restart for both files')
+  assert_equals(restarts[1][1], 'gopls', 'only gopls')
+  assert_equals(notifications[1], 'Restarted gopls after a change of go.mod', 'reported')
+end)
+
+test("'notify' sends the changed files to gopls", function()
+  action = 'notify'
+  local sent = {}
+  gopls_clients = {
+    {
+      notify = function(method, params)
+        table.insert(sent, { method = method, params = params })
+      end,
+    },
+  }
+  gomod.apply({ '/work/go.mod' })
+  assert_equals(#restarts, 0, 'no restart')
+  assert_equals(sent[1].method, 'workspace/didChangeWatchedFiles', 'method')
+  assert_equals(sent[1].params.changes[1].uri, 'file:///work/go.mod', 'host uri (translated by the interceptor)')
+  assert_equals(sent[1].params.changes[1].type, 2, 'changed')
+end)
+
+test("nothing happens with 'off' or without a gopls client", function()
+  gomod.apply({ '/work/go.mod' })
+  assert_equals(#restarts, 0, 'no client')
+  action = 'off'
+  gopls_clients = { {} }
+  gomod.apply({ '/work/go.mod' })
+  assert_equals(#restarts, 0, 'off')
+end)
+
+print()
+print(string.format('=== LSP go.mod Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
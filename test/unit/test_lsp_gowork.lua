@@ -0,0 +1,232 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lsp.gowork module
+-- Run with: lua test/unit/test_lsp_gowork.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Fake file system: directories map to true, files to their content
+local tree = {}
+
+local function set_tree(entries)
+  tree = {}
+  for path, content in pairs(entries) do
+    tree[path] = content
+    -- Register parent directories
+    local dir = path:match('^(.*)/[^/]*$')
+    while dir and dir ~= '' do
+      tree[dir] = true
+      dir = dir:match('^(.*)/[^/]*$')
+    end
+  end
+end
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/repo'
+    end,
+    isdirectory = function(path)
+      return tree[path] == true and 1 or 0
+    end,
+    filereadable = function(path)
+      return type(tree[path]) == 'string' and 1 or 0
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':h' then
+        local head = path:match('^(.*)/[^/]*$')
+        return head == '' and '/' or head or '.'
+      elseif mods == ':t' then
+        return path:match('[^/]+$')
+      end
+      return path
+    end,
+    simplify = function(path)
+      local parts = {}
+      for part in path:gmatch('[^/]+') do
+        if part == '..' then
+          table.remove(parts)
+        elseif part ~= '.' then
+          table.insert(parts, part)
+        end
+      end
+      return '/' .. table.concat(parts, '/')
+    end,
+  },
+  loop = {
+    fs_scandir = function(path)
+      local names = {}
+      for entry, content in pairs(tree) do
+        local name = entry:match('^' .. path:gsub('%p', '%%%0') .. '/([^/]+)$')
+        if name then
+          table.insert(names, { name, content == true and 'directory' or 'file' })
+        end
+      end
+      return names
+    end,
+    fs_scandir_next = function(handle)
+      local entry = table.remove(handle)
+      if entry then
+        return entry[1], entry[2]
+      end
+    end,
+  },
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+-- Serve file contents from the fake tree
+local original_io_open = io.open
+io.open = function(path, mode)
+  if type(tree[path]) == 'string' then
+    local content = tree[path]
+    return {
+      read = function()
+        return content
+      end,
+      close = function() end,
+    }
+  end
+  return original_io_open(path, mode)
+end
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local gowork = require('container.lsp.gowork')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Two-module workspace: app imports lib through go.work
+local TWO_MODULES = {
+  ['/repo/go.work'] = 'go 1.22\n\nuse (\n\t./app\n\t./lib // shared code\n)\n',
+  ['/repo/app/go.mod'] = 'module example.com/app\n',
+  ['/repo/app/main.go'] = 'package main\n',
+  ['/repo/lib/go.mod'] = 'module example.com/lib\n',
+  ['/repo/lib/greet.go'] = 'package lib\n',
+}
+
+print('Running gowork tests...')
+print()
+
+test('go.work above the module decides the root', function()
+  set_tree(TWO_MODULES)
+  local layout = gowork.detect('/repo/app/main.go', '/repo')
+  assert_equals(layout.root, '/repo', 'root')
+  assert_equals(layout.go_work, '/repo/go.work', 'go.work')
+  assert_equals(#layout.modules, 2, 'modules')
+  assert_equals(layout.modules[1], '/repo/app', 'first module')
+  assert_equals(layout.modules[2], '/repo/lib', 'second module')
+end)
+
+test('go.work is found from a file of the sibling module and from a subdirectory workspace', function()
+  set_tree(TWO_MODULES)
+  assert_equals(gowork.find_root('/repo/lib/greet.go', '/repo'), '/repo', 'sibling module')
+  assert_equals(gowork.find_root('', '/repo/app'), '/repo', 'workspace opened at a module')
+end)
+
+test('single-line use directives, quotes and parent directories', function()
+  set_tree({
+    ['/repo/go.work'] = 'go 1.22\nuse ./app\nuse "../shared"\n',
+    ['/repo/app/go.mod'] = 'module example.com/app\n',
+  })
+  local modules = gowork.parse_use('/repo/go.work')
+  assert_equals(#modules, 2, 'modules')
+  assert_equals(modules[1], '/repo/app', 'relative module')
+  assert_equals(modules[2], '/shared', 'module outside the workspace')
+end)
+
+test('a go.work root is one workspace folder', function()
+  set_tree(TWO_MODULES)
+  local folders = gowork.workspace_folders(gowork.detect('/repo/app/main.go', '/repo'))
+  assert_equals(#folders, 1, 'folders')
+  assert_equals(folders[1].uri, 'file:///repo', 'folder uri')
+end)
+
+test('several modules without go.work become one workspace folder each', function()
+  set_tree({
+    ['/mono/svc-a/go.mod'] = 'module example.com/a\n',
+    ['/mono/svc-b/go.mod'] = 'module example.com/b\n',
+    ['/mono/svc-b/vendor/example.com/x/go.mod'] = 'module example.com/x\n',
+  })
+  local layout = gowork.detect('/mono/svc-a/main.go', '/mono')
+  assert_equals(layout.root, '/mono', 'root')
+  assert_equals(layout.go_work, nil, 'no go.work')
+  assert_equals(#layout.modules, 2, 'vendored modules skipped')
+  local folders = gowork.workspace_folders(layout)
+  assert_equals(folders[2].uri, 'file:///mono/svc-b', 'second folder')
+  assert_equals(folders[2].name, 'svc-b', 'folder name')
+end)
+
+test('a single module is rooted at its go.mod', function()
+  set_tree({
+    ['/single/go.mod'] = 'module example.com/single\n',
+    ['/single/cmd/main.go'] = 'package main\n',
+  })
+  assert_equals(gowork.find_root('/single/cmd/main.go', '/single'), '/single', 'root')
+  set_tree({ ['/docs/README.md'] = 'docs\n' })
+  assert_equals(gowork.detect('/docs/README.md', '/docs'), nil, 'no Go code')
+end)
+
+test('exec args point GOWORK at the container go.work and drop -mod=mod', function()
+  set_tree(TWO_MODULES)
+  local layout = gowork.detect('/repo/app/main.go', '/repo')
+  local args = gowork.exec_args(layout, function(path)
+    return (path:gsub('^/repo', '/workspaces/repo'))
+  end, '-mod=mod -tags=integration')
+  assert_equals(
+    table.concat(args, ' '),
+    '-w /workspaces/repo -e GOWORK=/workspaces/repo/go.work -e GOFLAGS=-tags=integration',
+    'args'
+  )
+
+  args = gowork.exec_args(layout, function(path)
+    return path
+  end, '-tags=integration')
+  assert_equals(#args, 4, 'GOFLAGS left alone')
+end)
+
+test('exec args without go.work only set the working directory', function()
+  local args = gowork.exec_args({ root = '/mono', modules = { '/mono/a', '/mono/b' } }, function(path)
+    return (path:gsub('^/mono', '/workspace'))
+  end, '-mod=mod')
+  assert_equals(table.concat(args, ' '), '-w /workspace', 'args')
+end)
+
+io.open = original_io_open
+
+print()
+print(string.format('=== Gowork Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
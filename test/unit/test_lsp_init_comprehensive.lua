@@ -356,6 +356,12 @@ local mock_lspconfig_util = {
   },
 }
 
+local mock_gowork = {
+  find_root = function(fname)
+    return '/test/workspace'
+  end,
+}
+
 -- Register mocks
 package.loaded['container.utils.log'] = mock_log
 package.loaded['container.docker.init'] = mock_docker
@@ -364,6 +370,7 @@ package.loaded['container'] = mock_container
 package.loaded['container.lsp.commands'] = mock_commands
 package.loaded['container.lsp.strategy'] = mock_strategy
 package.loaded['lspconfig.util'] = mock_lspconfig_util
+package.loaded['container.lsp.gowork'] = mock_gowork
 
 -- Helper functions
 local function reset_test_state()
@@ -386,6 +386,15 @@ local mock_lspconfig_util = {
   },
 }
 
+local mock_gowork = {
+  find_root = function(fname)
+    if edge_test_state.root_pattern_fails then
+      error('root pattern failed')
+    end
+    return '/test/workspace'
+  end,
+}
+
 -- Register mocks
 package.loaded['container.utils.log'] = mock_log
 package.loaded['container.docker.init'] = mock_docker
@@ -394,6 +403,7 @@ package.loaded['container'] = mock_container
 package.loaded['container.lsp.commands'] = mock_commands
 package.loaded['container.lsp.strategy'] = mock_strategy
 package.loaded['lspconfig.util'] = mock_lspconfig_util
+package.loaded['container.lsp.gowork'] = mock_gowork
 
 -- Helper functions
 local function reset_edge_test_state()
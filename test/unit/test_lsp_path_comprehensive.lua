@@ -332,14 +332,19 @@ path_module.add_mount('/host/data', '/container/data')
 path_module.add_mount('/host/data/subdir', '/container/special')
 local priority_local = '/host/data/subdir/file.txt'
 local priority_container = path_module.to_container_path(priority_local)
--- Due to iteration order, the first matching mount may win (implementation dependent)
-print('Priority container path: ' .. priority_container)
--- Both outcomes are valid depending on iteration order
-local is_valid = priority_container == '/container/special/file.txt'
-  or priority_container == '/container/data/subdir/file.txt'
-assert(is_valid, 'Mount priority should return a valid transformation')
+-- The deepest mount containing the path wins
+assert_equals(priority_container, '/container/special/file.txt', 'Nested mount should win')
+assert_equals(path_module.to_local_path('/container/special/a.txt'), '/host/data/subdir/a.txt', 'Nested mount back')
 print('✓ Mount priority works correctly')
 
+-- Workspace folders mounted at different places each use their own pair
+path_module.setup('/src/mono/web', '/workspaces/web', { ['/src/mono/api'] = '/workspaces/api' })
+assert_equals(path_module.to_container_path('/src/mono/api/main.go'), '/workspaces/api/main.go', 'Second folder')
+assert_equals(path_module.to_local_path('/workspaces/web/app.ts'), '/src/mono/web/app.ts', 'First folder back')
+assert_equals(path_module.to_container_path('/src/mono/apix/a.go'), '/src/mono/apix/a.go', 'Sibling folder')
+path_module.setup('/test/workspace', '/workspace', {})
+print('✓ Workspace folders are translated with their own pair')
+
 -- Test 6: URI Transformation
 print('\n=== Test 6: URI Transformation ===')
 
@@ -702,9 +707,8 @@ print('✓ Exact workspace root transformation works')
 path_module.setup('/test/work', '/workspace', {})
 local similar_path = '/test/workspace/file.go' -- This starts with '/test/work' but is not within it
 local similar_result = path_module.to_container_path(similar_path)
--- In this case, '/test/workspace' does start with '/test/work', so it gets transformed
--- The test logic needs to reflect actual behavior
-assert_equals(similar_result, '/workspace/space/file.go', 'Path with workspace prefix gets transformed')
+-- Prefixes only match at path segment boundaries
+assert_equals(similar_result, similar_path, 'Path with workspace prefix is not within the workspace')
 print('✓ Similar path edge case handled correctly')
 
 -- Reset again
@@ -0,0 +1,233 @@
+#!/usr/bin/env lua
+
+-- Test script for LSP path translation in container.lsp.interceptor
+-- Run with: lua test/unit/test_lsp_path_mappings.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/home/user/project'
+    end,
+    expand = function(path)
+      return (path:gsub('^~', '/home/user'))
+    end,
+  },
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
+  tbl_deep_extend = function(behavior, ...)
+    local result = {}
+    for _, tbl in ipairs({ ... }) do
+      for k, v in pairs(tbl) do
+        if type(v) == 'table' and type(result[k]) == 'table' then
+          result[k] = vim.tbl_deep_extend(behavior, result[k], v)
+        else
+          result[k] = vim.deepcopy(v)
+        end
+      end
+    end
+    return result
+  end,
+  split = function(str, sep)
+    local parts = {}
+    for part in (str .. sep):gmatch('(.-)' .. sep:gsub('%p', '%%%0')) do
+      table.insert(parts, part)
+    end
+    return parts
+  end,
+  inspect = function(obj)
+    return tostring(obj)
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local interceptor = require('container.lsp.interceptor')
+local lsp_path = require('container.lsp.path')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+interceptor.setup_path_config('test-container', '/home/user/project', {
+  container_workspace = '/workspaces/project',
+  mounts = {
+    { type = 'bind', source = '/home/user/.cache/go', target = '/go/pkg/mod' },
+    { type = 'volume', source = 'node_modules', target = '/workspaces/project/node_modules' },
+  },
+  extra_mappings = { ['~/lib'] = '/opt/lib' },
+})
+
+print('Running LSP path mapping tests...')
+print()
+
+test('mappings include workspace, bind mounts and extra mappings', function()
+  local config = interceptor.get_path_config()
+  assert_equals(#config.mappings, 3, 'mapping count')
+  assert_equals(config.container_workspace, '/workspaces/project', 'container workspace')
+end)
+
+test('workspace paths are translated in both directions', function()
+  local uri = 'file:///home/user/project/main.go'
+  local container_uri = interceptor.transform_path(uri, 'to_container')
+  assert_equals(container_uri, 'file:///workspaces/project/main.go', 'to container')
+  assert_equals(interceptor.transform_path(container_uri, 'to_host'), uri, 'round trip')
+end)
+
+test('mount and extra mappings are translated', function()
+  assert_equals(
+    interceptor.transform_path('file:///go/pkg/mod/github.com/x/y.go', 'to_host'),
+    'file:///home/user/.cache/go/github.com/x/y.go',
+    'bind mount'
+  )
+  assert_equals(interceptor.transform_path('/home/user/lib/a.c', 'to_container'), '/opt/lib/a.c', 'extra mapping')
+end)
+
+test('prefixes only match at path segment boundaries', function()
+  local uri = 'file:///home/user/project-other/main.go'
+  assert_equals(interceptor.transform_path(uri, 'to_container'), uri, 'sibling directory untouched')
+  assert_equals(interceptor.transform_path('file:///usr/lib/go/a.go', 'to_host'), 'file:///usr/lib/go/a.go', 'outside')
+end)
+
+test('sibling modules of a go.work are mapped through the workspace', function()
+  assert_equals(
+    interceptor.transform_path('file:///home/user/project/lib/util.go', 'to_container'),
+    'file:///workspaces/project/lib/util.go',
+    'sibling module'
+  )
+  assert_equals(interceptor.is_mapped_host_path('/home/user/project/lib'), true, 'inside workspace')
+  assert_equals(interceptor.is_mapped_host_path('/home/user/shared'), false, 'outside every mapping')
+end)
+
+test('definition responses translate Location and LocationLink arrays', function()
+  local result = interceptor.transform_response('textDocument/definition', {
+    { uri = 'file:///workspaces/project/a.go' },
+    { targetUri = 'file:///workspaces/project/b.go' },
+  }, 'to_host')
+  assert_equals(result[1].uri, 'file:///home/user/project/a.go', 'location')
+  assert_equals(result[2].targetUri, 'file:///home/user/project/b.go', 'location link')
+end)
+
+test('workspace/symbol responses translate nested locations', function()
+  local result = interceptor.transform_response('workspace/symbol', {
+    { name = 'Foo', location = { uri = 'file:///workspaces/project/foo.go' } },
+  }, 'to_host')
+  assert_equals(result[1].location.uri, 'file:///home/user/project/foo.go', 'symbol location')
+end)
+
+test('publishDiagnostics translates related information', function()
+  local result = interceptor.transform_notification_params('textDocument/publishDiagnostics', {
+    uri = 'file:///workspaces/project/main.go',
+    diagnostics = {
+      { message = 'x', relatedInformation = { { location = { uri = 'file:///workspaces/project/other.go' } } } },
+    },
+  }, 'to_host')
+  assert_equals(result.uri, 'file:///home/user/project/main.go', 'diagnostic uri')
+  assert_equals(
+    result.diagnostics[1].relatedInformation[1].location.uri,
+    'file:///home/user/project/other.go',
+    'related information uri'
+  )
+end)
+
+test('request params keep rootPath as a plain path', function()
+  local params = interceptor.transform_request_params('initialize', {
+    rootUri = 'file:///home/user/project',
+    rootPath = '/home/user/project',
+  }, 'to_container')
+  assert_equals(params.rootUri, 'file:///workspaces/project', 'rootUri')
+  assert_equals(params.rootPath, '/workspaces/project', 'rootPath')
+end)
+
+test('host paths in server settings are translated to container paths', function()
+  local settings = interceptor.transform_settings({
+    gopls = {
+      staticcheck = true,
+      ['local'] = 'example.com/app',
+      env = { GOFLAGS = '-tags=integration' },
+      directoryFilters = { '-node_modules', '/home/user/project/vendor' },
+    },
+    python = { analysis = { extraPaths = { '~/lib/py', 'file:///home/user/project/src' } } },
+    other = '/usr/local/bin/tool',
+  })
+  assert_equals(settings.gopls.staticcheck, true, 'non-string kept')
+  assert_equals(settings.gopls['local'], 'example.com/app', 'module path kept')
+  assert_equals(settings.gopls.env.GOFLAGS, '-tags=integration', 'flag kept')
+  assert_equals(settings.gopls.directoryFilters[2], '/workspaces/project/vendor', 'workspace path')
+  assert_equals(settings.python.analysis.extraPaths[1], '/opt/lib/py', 'home relative path')
+  assert_equals(settings.python.analysis.extraPaths[2], 'file:///workspaces/project/src', 'file URI')
+  assert_equals(settings.other, '/usr/local/bin/tool', 'unmapped path kept')
+end)
+
+test('files of a volume workspace are returned as container:// URIs', function()
+  local uri = 'container:///workspaces/project/main.go'
+  assert_equals(interceptor.transform_path(uri, 'to_container'), 'file:///workspaces/project/main.go', 'request')
+  assert_equals(interceptor.transform_path(uri, 'to_host'), uri, 'kept')
+
+  interceptor.setup_path_config('test-container', '/home/user/project', {
+    container_workspace = '/workspaces/project',
+    mounts = { { type = 'bind', source = '/home/user/.cache/go', target = '/go/pkg/mod' } },
+    extra_mappings = {},
+    volume_workspace = true,
+  })
+  assert_equals(interceptor.transform_path('file:///workspaces/project/main.go', 'to_host'), uri, 'volume')
+  assert_equals(
+    interceptor.transform_path('file:///go/pkg/mod/x.go', 'to_host'),
+    'file:///home/user/.cache/go/x.go',
+    'bind mount stays on the host'
+  )
+  assert_equals(
+    interceptor.transform_path('file:///home/user/project', 'to_container'),
+    'file:///workspaces/project',
+    'root still maps'
+  )
+end)
+
+test('prefixes are replaced at segment boundaries', function()
+  local mapped = lsp_path.replace_prefix('/host/app/pkg', '/host/app/', '/workspaces/app')
+  assert_equals(mapped, '/workspaces/app/pkg', 'below')
+  assert_equals(lsp_path.replace_prefix('/host/app', '/host/app', '/workspaces/app/'), '/workspaces/app', 'root itself')
+  assert_equals(lsp_path.replace_prefix('/host/application', '/host/app', '/workspaces/app'), nil, 'sibling')
+  assert_equals(lsp_path.replace_prefix('/etc/hosts', '/', '/host/root'), '/host/root/etc/hosts', 'from /')
+  assert_equals(lsp_path.replace_prefix('/srv/app/main.go', '/srv/app', '/'), '/main.go', 'to /')
+  assert_equals(lsp_path.replace_prefix('/srv/app', '/srv/app', '/'), '/', 'root to /')
+end)
+
+print()
+print(string.format('=== LSP Path Mapping Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
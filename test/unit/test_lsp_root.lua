@@ -0,0 +1,150 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lsp.root module
+-- Run with: lua test/unit/test_lsp_root.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  fn = {
+    fnamemodify = function(path, mods)
+      if mods == ':h' then
+        local head = path:match('^(.*)/[^/]*$')
+        return head == '' and '/' or head or '.'
+      end
+      return path
+    end,
+  },
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+-- Workspace /host/mono is mounted at /workspace
+package.loaded['container.lsp.interceptor'] = {
+  is_mapped_host_path = function(path)
+    return path:match('^/host/mono') ~= nil or path:match('^/workspace') ~= nil
+  end,
+  transform_path = function(path, direction)
+    if direction == 'to_container' then
+      return (path:gsub('^/host/mono', '/workspace'))
+    end
+    return (path:gsub('^/workspace', '/host/mono'))
+  end,
+  get_path_config = function()
+    return { container_workspace = '/workspace' }
+  end,
+}
+
+local root_patterns = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'lsp.root_patterns' then
+      return root_patterns
+    end
+  end,
+}
+
+-- docker exec answers with the directory the container "has" a root in
+local exec_calls = {}
+local container_root
+package.loaded['container.docker.init'] = {
+  run_docker_command = function(args)
+    table.insert(exec_calls, args)
+    if container_root then
+      return { success = true, code = 0, stdout = container_root .. '\n' }
+    end
+    return { success = false, code = 1, stdout = '' }
+  end,
+}
+
+local root = require('container.lsp.root')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  exec_calls = {}
+  container_root = nil
+  root.clear_cache()
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running LSP root tests...')
+print()
+
+test('ancestors stop at the workspace, or at / outside of it', function()
+  local dirs = root.ancestors('/workspace/svc/a', '/workspace')
+  assert_equals(table.concat(dirs, ' '), '/workspace/svc/a /workspace/svc /workspace', 'inside')
+  dirs = root.ancestors('/go/pkg', '/workspace')
+  assert_equals(table.concat(dirs, ' '), '/go/pkg /go /', 'outside')
+end)
+
+test('the script checks the nearest directory first and expands globs', function()
+  local script = root.build_script({ '/workspace/svc', '/workspace' }, { 'go.mod', '*.csproj' })
+  local first = script:find('/workspace/svc/go.mod', 1, true)
+  local glob = script:find('/workspace/svc/*.csproj', 1, true)
+  local outer = script:find('set -- /workspace/go.mod', 1, true)
+  assert_equals(first ~= nil and glob ~= nil and outer ~= nil, true, 'checks present')
+  assert_equals(first < glob and glob < outer, true, 'order')
+  assert_equals(script:sub(-6), 'exit 1', 'fails when nothing matches')
+  assert_equals(root.build_script({ "/it's" }, { 'a b' }):find([['/it'\''s'/'a b']], 1, true) ~= nil, true, 'quoting')
+end)
+
+test('the container root is translated to the host and cached per directory', function()
+  container_root = '/workspace/svc-a'
+  local found = root.find('c1', '/host/mono/svc-a/pkg/x.go', { 'go.mod', '.git' })
+  assert_equals(found, '/host/mono/svc-a', 'host root')
+  assert_equals(exec_calls[1][1], 'exec', 'docker exec')
+  assert_equals(exec_calls[1][2], 'c1', 'container')
+
+  root.find('c1', '/host/mono/svc-a/pkg/y.go', { 'go.mod', '.git' })
+  assert_equals(#exec_calls, 1, 'same directory cached')
+  root.find('c1', '/host/mono/svc-a/cmd/main.go', { 'go.mod', '.git' })
+  assert_equals(#exec_calls, 2, 'other directory looked up')
+end)
+
+test('no root outside the mappings or without a match', function()
+  assert_equals(root.find('c1', '/tmp/scratch.go', { 'go.mod' }), nil, 'unmapped file')
+  assert_equals(#exec_calls, 0, 'no exec for unmapped files')
+  assert_equals(root.find('c1', '/host/mono/README.md', {}), nil, 'no patterns')
+  assert_equals(root.find('c1', '/host/mono/docs/a.go', { 'go.mod' }), nil, 'no match')
+  root.find('c1', '/host/mono/docs/b.go', { 'go.mod' })
+  assert_equals(#exec_calls, 1, 'misses are cached too')
+end)
+
+test('lsp.root_patterns replaces the patterns of the language', function()
+  assert_equals(root.patterns('go')[1], 'go.mod', 'registry default')
+  root_patterns = { go = { 'go.work' } }
+  assert_equals(#root.patterns('go'), 1, 'configured')
+  assert_equals(root.patterns('go')[1], 'go.work', 'configured pattern')
+  assert_equals(#root.patterns('unknown'), 0, 'unknown filetype')
+  root_patterns = {}
+end)
+
+print()
+print(string.format('=== LSP Root Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,100 @@
+#!/usr/bin/env lua
+
+-- Test script for choosing the container LSP servers to start
+-- Run with: lua test/unit/test_lsp_server_selection.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Mock vim global for testing
+_G.vim = {
+  tbl_contains = function(list, value)
+    for _, item in ipairs(list) do
+      if item == value then
+        return true
+      end
+    end
+    return false
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local lsp = require('container.lsp.init')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function detected(...)
+  local servers = {}
+  for _, name in ipairs({ ... }) do
+    servers[name] = { available = true }
+  end
+  return servers
+end
+
+print('Running LSP server selection tests...')
+print()
+
+test('one server is started per language', function()
+  local selected = lsp.select_servers(detected('pyright', 'pylsp', 'gopls'))
+  assert_equals(table.concat(selected, ','), 'pylsp,gopls', 'selected servers')
+end)
+
+test('companion servers run next to the main server', function()
+  local selected = lsp.select_servers(detected('tsserver', 'eslint'))
+  assert_equals(table.concat(selected, ','), 'tsserver,eslint', 'selected servers')
+end)
+
+test('requested servers win over the default order', function()
+  local selected = lsp.select_servers(detected('pyright', 'pylsp'), { pyright = { source = 'container.nvim' } })
+  assert_equals(table.concat(selected, ','), 'pyright', 'requested server')
+end)
+
+test('disabled and unavailable servers are not started', function()
+  local servers = detected('gopls', 'pylsp')
+  servers.clangd = { available = false }
+  local selected = lsp.select_servers(servers, { pylsp = false })
+  assert_equals(table.concat(selected, ','), 'gopls', 'selected servers')
+end)
+
+test('filetypes of known servers trigger setup', function()
+  assert_equals(lsp.is_server_filetype('go'), true, 'go')
+  assert_equals(lsp.is_server_filetype('typescript'), true, 'typescript')
+  assert_equals(lsp.is_server_filetype('markdown'), false, 'markdown')
+end)
+
+print()
+print(string.format('=== LSP Server Selection Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,226 @@
+#!/usr/bin/env lua
+
+-- Test script for container.lsp.watchdog module
+-- Run with: lua test/unit/test_lsp_watchdog.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local deferred = {}
+local clients = {}
+local inspect_results = {}
+local settings = {}
+
+_G.vim = {
+  defer_fn = function(fn, timeout)
+    table.insert(deferred, { fn = fn, timeout = timeout })
+  end,
+  schedule = function(fn)
+    fn()
+  end,
+  schedule_wrap = function(fn)
+    return fn
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  tbl_count = function(t)
+    local count = 0
+    for _ in pairs(t) do
+      count = count + 1
+    end
+    return count
+  end,
+  uv = {
+    new_timer = function()
+      return {
+        start = function() end,
+        stop = function() end,
+        close = function() end,
+      }
+    end,
+  },
+  lsp = {
+    handlers = {},
+    get_client_by_id = function(id)
+      return clients[id]
+    end,
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.config'] = {
+  get_value = function(key)
+    return settings[key]
+  end,
+}
+
+package.loaded['container.docker'] = {
+  run_docker_command_async = function(args, _, callback)
+    callback(inspect_results[args[#args]] or { success = false, stdout = '' })
+  end,
+}
+
+local watchdog = require('container.lsp.watchdog')
+
+-- Client whose requests are answered only when `respond` is called
+local function new_client(id)
+  local client = {
+    id = id,
+    name = 'container_gopls',
+    config = { container_id = 'abc' },
+    stopped = false,
+    cancelled = {},
+    pending = {},
+  }
+  client.is_stopped = function()
+    return client.stopped
+  end
+  client.request = function(_, _, handler)
+    table.insert(client.pending, handler)
+    return true, #client.pending
+  end
+  client.cancel_request = function(request_id)
+    table.insert(client.cancelled, request_id)
+  end
+  clients[id] = client
+  return client
+end
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  deferred = {}
+  clients = {}
+  inspect_results = {}
+  settings = {}
+  for client_id in pairs(watchdog.get_watched()) do
+    watchdog.unwatch(client_id)
+  end
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running LSP watchdog tests...')
+print()
+
+test('unanswered requests are cancelled with an error', function()
+  settings['lsp.request_timeout'] = 500
+  local client = new_client(1)
+  watchdog.watch('gopls', client, function() end)
+
+  local answers = {}
+  client.request('textDocument/hover', {}, function(err, result)
+    table.insert(answers, { err = err, result = result })
+  end, 0)
+  assert_equals(#deferred, 1, 'timer armed')
+  assert_equals(deferred[1].timeout, 500, 'request_timeout')
+
+  deferred[1].fn()
+  assert_equals(#answers, 1, 'handler called')
+  assert_equals(answers[1].err.code, watchdog.REQUEST_CANCELLED, 'error code')
+  assert_equals(client.cancelled[1], 1, 'request cancelled')
+
+  -- A late answer is dropped
+  client.pending[1](nil, 'late')
+  assert_equals(#answers, 1, 'late answer dropped')
+end)
+
+test('answered requests are passed through', function()
+  local client = new_client(1)
+  watchdog.watch('gopls', client, function() end)
+  local result
+  client.request('textDocument/definition', {}, function(_, r)
+    result = r
+  end, 0)
+  client.pending[1](nil, 'location')
+  deferred[1].fn()
+  assert_equals(result, 'location', 'result')
+  assert_equals(#client.cancelled, 0, 'not cancelled')
+end)
+
+test('initialize is never timed out', function()
+  local client = new_client(1)
+  watchdog.watch('gopls', client, function() end)
+  client.request('initialize', {}, function() end)
+  assert_equals(#deferred, 0, 'no timer')
+end)
+
+test('timeouts in a row make the client dead', function()
+  settings['lsp.max_timeouts'] = 2
+  local client = new_client(1)
+  local reasons = {}
+  watchdog.watch('gopls', client, function(reason)
+    table.insert(reasons, reason)
+  end)
+
+  client.request('textDocument/hover', {}, function() end)
+  deferred[1].fn()
+  -- An answer in between resets the count
+  client.request('textDocument/hover', {}, function() end)
+  client.pending[2](nil, {})
+  client.request('textDocument/hover', {}, function() end)
+  deferred[3].fn()
+  assert_equals(#reasons, 0, 'not dead yet')
+
+  client.request('textDocument/hover', {}, function() end)
+  deferred[4].fn()
+  assert_equals(#reasons, 1, 'dead')
+  assert_equals(reasons[1], '2 requests in a row timed out', 'reason')
+  assert_equals(watchdog.get_watched()[1], nil, 'no longer watched')
+end)
+
+test('an unexpected exit is reported, a stop on purpose is not', function()
+  local reasons = {}
+  watchdog.watch('gopls', new_client(1), function(reason)
+    table.insert(reasons, reason)
+  end)
+  watchdog.watch('pylsp', new_client(2), function(reason)
+    table.insert(reasons, reason)
+  end)
+
+  watchdog.unwatch(2)
+  watchdog.exited(2, 0, 0)
+  watchdog.exited(1, 1, 0)
+  assert_equals(#reasons, 1, 'one report')
+  assert(reasons[1]:find('code 1', 1, true), 'reason names the exit code: ' .. reasons[1])
+end)
+
+test('clients of a container that is no longer running are dead', function()
+  local reasons = {}
+  watchdog.watch('gopls', new_client(1), function(reason)
+    table.insert(reasons, reason)
+  end)
+  inspect_results.abc = { success = true, stdout = 'running\n' }
+  watchdog.check()
+  assert_equals(#reasons, 0, 'running')
+
+  inspect_results.abc = { success = true, stdout = 'paused\n' }
+  watchdog.check()
+  assert_equals(reasons[1], 'the container is paused', 'reason')
+end)
+
+print()
+print(string.format('=== LSP Watchdog Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -137,6 +137,22 @@ _G.vim = {
     end
     return result
   end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
 }
 
 -- Mock os.getenv for variable expansion tests
@@ -306,6 +322,76 @@ assert_equals(normalized[1].protocol, 'tcp', 'Object protocol should be parsed')
 assert_equals(normalized[2].host_port, 9000, 'Missing host port should default to container port')
 print('✓ Object port format handled correctly')
 
+-- Test ports of other compose services ("service:port")
+local compose_ports = parser.normalize_ports({ 'db:5432', 'app:3000', '8080:80' }, {
+  dockerComposeFile = 'docker-compose.yml',
+  service = 'app',
+})
+assert_table_length(compose_ports, 3, 'Service ports should be normalized')
+assert_equals(compose_ports[1].service, 'db', 'Service should be recorded')
+assert_equals(compose_ports[1].container_port, 5432, 'Service port should be parsed')
+assert_equals(compose_ports[1].host_port, 5432, 'Service port is forwarded to the same host port')
+assert_nil(compose_ports[2].service, 'Ports of the attached service are published as usual')
+assert_nil(compose_ports[3].service, 'Host mappings are not service ports')
+assert_table_length(parser.normalize_ports({ 'db:5432' }, { image = 'ubuntu' }), 0, 'Service ports need compose')
+print('✓ Compose service ports normalized correctly')
+
+-- Test appPort normalization (number, string and array forms)
+local forwarded = parser.normalize_ports({ 3000 })
+local app_ports = parser.normalize_app_ports({ 3000, '8000:80' }, forwarded)
+assert_table_length(app_ports, 1, 'appPort already in forwardPorts should be skipped')
+assert_equals(app_ports[1].host_port, 8000, 'appPort host port should be parsed')
+assert_equals(app_ports[1].container_port, 80, 'appPort container port should be parsed')
+assert_equals(app_ports[1].source, 'appPort', 'appPort source should be recorded')
+assert_equals(forwarded[1].source, 'appPort', 'forwardPorts entry also in appPort should be published at creation')
+assert_table_length(parser.normalize_app_ports('3000', {}), 1, 'String appPort should be normalized')
+assert_table_length(parser.normalize_app_ports(5000, {}), 1, 'Single appPort should be normalized')
+print('✓ appPort normalized correctly')
+
+-- Test default_run_args and default_build_args of the plugin come before the devcontainer.json flags
+local with_defaults = parser.add_default_args({ run_args = { '--network=bridge' } }, {
+  default_run_args = { '--network=host', '--add-host=db:10.0.0.5' },
+  default_build_args = { '--pull' },
+})
+local run_args = table.concat(with_defaults.run_args, ' ')
+assert_equals(run_args, '--network=host --add-host=db:10.0.0.5 --network=bridge', 'Run defaults should come first')
+assert_equals(with_defaults.default_run_args[2], '--add-host=db:10.0.0.5', 'Run defaults should be kept')
+assert_equals(with_defaults.default_build_args[1], '--pull', 'Build defaults should be kept')
+assert_table_length(parser.add_default_args({}, nil).run_args, 0, 'No defaults should add nothing')
+print('✓ Default docker flags added correctly')
+
+-- Test workspace_clone mounts a named volume at workspaceFolder instead of the project folder
+local cloned = parser.add_workspace_clone({ workspace_folder = '/workspaces/app' }, {
+  workspace_clone = { repository = 'git@github.com:me/App.git', branch = 'main' },
+})
+assert_equals(cloned.workspace_mount.type, 'volume', 'Workspace should be a volume')
+assert_equals(cloned.workspace_mount.target, '/workspaces/app', 'Volume should be mounted at workspaceFolder')
+assert_equals(cloned.workspace_clone.branch, 'main', 'Branch should be kept')
+assert(cloned.workspace_mount.source:match('^app%-%x+%-workspace$'), 'Volume name: ' .. cloned.workspace_mount.source)
+assert(parser.is_volume_workspace(cloned), 'Cloned workspace should be a volume workspace')
+local named = parser.add_workspace_clone({}, { workspace_clone = { repository = 'https://x/app', volume = 'src' } })
+assert_equals(named.workspace_mount.source, 'src', 'Configured volume should be used')
+assert_equals(named.workspace_mount.target, '/workspace', 'Default workspace folder')
+local _, clone_warning = parser.add_workspace_clone(
+  { compose_files = { '/p/compose.yml' } },
+  { workspace_clone = { repository = 'https://x/app' } }
+)
+assert(clone_warning, 'Compose configurations should be warned about')
+assert_equals(parser.add_workspace_clone({}, {}).workspace_clone, nil, 'No repository should clone nothing')
+print('✓ Workspace clone volume added correctly')
+
+-- Test portsAttributes are attached by container port or range
+local attributed = parser.apply_port_attributes(parser.normalize_ports({ 8080, 45000 }), {
+  portsAttributes = {
+    ['8080'] = { label = 'Web', onAutoForward = 'notify' },
+    ['40000-50000'] = { label = 'Ephemeral' },
+  },
+})
+assert_equals(attributed[1].label, 'Web', 'Label should be attached from portsAttributes')
+assert_equals(attributed[1].attributes.onAutoForward, 'notify', 'Attributes should be kept')
+assert_equals(attributed[2].label, 'Ephemeral', 'Range attributes should match')
+print('✓ portsAttributes attached correctly')
+
 -- Test 3: Mock configuration parsing
 print('\n=== Test 3: Configuration Parsing Mock ===')
 
@@ -410,6 +496,48 @@ assert_table_length(
 )
 print('✓ Mount configuration normalization tested')
 
+-- Test mounts array forms, key aliases and variables
+local mounts = parser.normalize_mounts({
+  'source=${localWorkspaceFolder}/cache,target=/cache,type=bind,readonly',
+  'type=volume,src=${localWorkspaceFolderBasename}-node_modules,dst=/workspace/node_modules',
+  { type = 'tmpfs', target = '/tmp/scratch' },
+  { source = '/host/data', destination = '/data' },
+  'source=/host/no-target,type=bind',
+}, { workspace_folder = '/home/user/app' })
+assert_table_length(mounts, 4, 'Mount without target should be ignored')
+assert_equals(mounts[1].source, '/home/user/app/cache', 'Workspace variable should be expanded')
+assert_equals(mounts[1].readonly, true, 'Bare readonly flag should be read')
+assert_equals(mounts[2].type, 'volume', 'Volume type should be kept')
+assert_equals(mounts[2].source, 'app-node_modules', 'src alias and basename variable should be applied')
+assert_equals(mounts[2].target, '/workspace/node_modules', 'dst alias should be applied')
+assert_equals(mounts[3].type, 'tmpfs', 'tmpfs mount should be kept')
+assert_nil(mounts[3].source, 'tmpfs mount has no source')
+assert_equals(mounts[4].type, 'bind', 'Object mount type should default to bind')
+assert_equals(mounts[4].target, '/data', 'destination alias should be applied')
+print('✓ Mounts array forms and aliases tested')
+
+-- Test additional_mounts merged from the plugin config
+local original_isdirectory = vim.fn.isdirectory
+vim.fn.isdirectory = function(path)
+  return path:match('missing') and 0 or 1
+end
+local mounts_config, mount_warnings = parser.add_additional_mounts({
+  normalized_mounts = { { type = 'bind', source = '/host/cache', target = '/cache' } },
+}, {
+  { source = '~/.ssh', target = '/home/vscode/.ssh', readonly = true },
+  { source = '$HOME/libs', target = '/libs' },
+  { source = '${HOME}/missing', target = '/missing' },
+  { source = '/host/other-cache', target = '/cache' },
+})
+vim.fn.isdirectory = original_isdirectory
+assert_table_length(mounts_config.normalized_mounts, 3, 'Missing sources and mounted targets should be skipped')
+assert_equals(mounts_config.normalized_mounts[2].source, '/home/testuser/.ssh', '~ should be expanded')
+assert_equals(mounts_config.normalized_mounts[2].readonly, true, 'readonly should be kept')
+assert_equals(mounts_config.normalized_mounts[3].source, '/home/testuser/libs', '$HOME should be expanded')
+assert_table_length(mount_warnings, 2, 'Skipped mounts should be reported')
+assert_truthy(mount_warnings[1]:match('/home/testuser/missing'), 'Missing source should be named')
+print('✓ additional_mounts merged with devcontainer.json mounts')
+
 -- Test 7: Additional Error Cases
 print('\n=== Test 7: Additional Error Cases ===')
 
@@ -458,6 +586,12 @@ else
   print('✓ Nonexistent path handling tested')
 end
 
+-- Test find_workspace_root returns a directory, not the config file
+local workspace_root = parser.find_workspace_root('/test/workspace')
+assert_truthy(workspace_root, 'Should find workspace root')
+assert_truthy(not workspace_root:match('devcontainer%.json$'), 'Workspace root should be a directory')
+print('✓ Workspace root discovery tested')
+
 -- Test 9: Project ID Generation Edge Cases
 print('\n=== Test 9: Project ID Generation Edge Cases ===')
 
@@ -474,5 +608,285 @@ assert_truthy(long_id, 'Should handle long paths')
 assert_truthy(#long_id > 0, 'Should generate non-empty ID for long path')
 print('✓ Long path project ID generation tested')
 
+-- Test 10: Variable Substitution
+print('\n=== Test 10: Variable Substitution ===')
+
+local substitution_context = {
+  workspace_folder = '/home/user/app',
+  container_workspace = '/workspaces/app',
+  devcontainer_id = 'abc123',
+}
+
+-- Repeated references within one string
+assert_equals(
+  parser.expand_variables('${localWorkspaceFolderBasename}-${localWorkspaceFolderBasename}', substitution_context),
+  'app-app',
+  'Repeated references should all be expanded'
+)
+assert_equals(
+  parser.expand_variables(
+    'source=${localWorkspaceFolder},target=${containerWorkspaceFolder},name=${containerWorkspaceFolderBasename}',
+    substitution_context
+  ),
+  'source=/home/user/app,target=/workspaces/app,name=app',
+  'Local and container workspace variables should be expanded'
+)
+assert_equals(
+  parser.expand_variables('vol-${devcontainerId}', substitution_context),
+  'vol-abc123',
+  'devcontainerId should be expanded'
+)
+print('✓ Repeated substitutions tested')
+
+-- Nested references: the default of localEnv refers to another variable
+assert_equals(
+  parser.expand_variables('${localEnv:CONTAINER_NVIM_UNSET_VAR:${localWorkspaceFolder}/.cache}', substitution_context),
+  '/home/user/app/.cache',
+  'Default value with a nested variable should be expanded'
+)
+assert_equals(
+  parser.expand_variables('${localEnv:HOME}/${localWorkspaceFolderBasename}', substitution_context),
+  (os.getenv('HOME') or '') .. '/app',
+  'localEnv and workspace variables should be expanded together'
+)
+assert_equals(
+  parser.expand_variables('${unknownVariable}', substitution_context),
+  '${unknownVariable}',
+  'Unknown variables should be kept'
+)
+-- Substituted values are not scanned again, and references are only nested in defaults
+local original_getenv = os.getenv
+os.getenv = function(name)
+  if name == 'CONTAINER_NVIM_LITERAL_VAR' then
+    return '${localWorkspaceFolder}'
+  end
+  return original_getenv(name)
+end
+assert_equals(
+  parser.expand_variables('${localEnv:CONTAINER_NVIM_LITERAL_VAR}', substitution_context),
+  '${localWorkspaceFolder}',
+  'A value that looks like a reference should be kept'
+)
+assert_equals(
+  parser.expand_variables('${localEnv:CONTAINER_NVIM_LITERAL_VAR:${devcontainerId}}', substitution_context),
+  '${localWorkspaceFolder}',
+  'An unused default should not be expanded'
+)
+os.getenv = original_getenv
+assert_equals(
+  parser.expand_variables('${localEnv:${localWorkspaceFolderBasename}}', substitution_context),
+  '${localEnv:${localWorkspaceFolderBasename}}',
+  'A reference in a variable name should be kept'
+)
+assert_equals(
+  parser.expand_variables('${localWorkspaceFolder', substitution_context),
+  '${localWorkspaceFolder',
+  'Unterminated references should be kept'
+)
+print('✓ Nested substitutions tested')
+
+-- devcontainerId is stable for the same configuration
+local devcontainer_id = parser.get_devcontainer_id('/home/user/app', '/home/user/app/.devcontainer/devcontainer.json')
+assert_equals(
+  devcontainer_id,
+  parser.get_devcontainer_id('/home/user/app', '/home/user/app/.devcontainer/devcontainer.json'),
+  'devcontainerId should be stable'
+)
+assert_truthy(devcontainer_id:match('^[0-9a-v]+$'), 'devcontainerId should be base-32')
+print('✓ devcontainerId generation tested')
+
+-- Test 11: JSONC Parsing
+print('\n=== Test 11: JSONC Parsing ===')
+
+local jsonc = table.concat({
+  '{',
+  '  // Line comment',
+  '  "name": "app", /* block',
+  '  comment */',
+  '  "image": "http://registry//image", // URL with slashes',
+  '  "note": "a \\" // quoted",',
+  '  "forwardPorts": [3000, 8080,],',
+  '}',
+}, '\n')
+local stripped = parser.strip_jsonc(jsonc)
+assert_equals(#stripped, #jsonc, 'Stripped JSON should keep offsets')
+assert_truthy(not stripped:find('Line comment', 1, true), 'Line comments should be removed')
+assert_truthy(not stripped:find('block', 1, true), 'Block comments should be removed')
+assert_truthy(stripped:find('"http://registry//image"', 1, true), '// inside strings should be kept')
+assert_truthy(stripped:find('"a \\" // quoted"', 1, true), 'Escaped quotes should not end strings')
+assert_truthy(stripped:find('8080 ]', 1, true), 'Trailing comma in array should be removed')
+assert_truthy(stripped:find('] \n}', 1, true), 'Trailing comma in object should be removed')
+assert_equals(select(2, stripped:gsub('\n', '')), select(2, jsonc:gsub('\n', '')), 'Newlines should be kept')
+print('✓ Comments and trailing commas stripped')
+
+-- Test 12: Configuration Inheritance (extends)
+print('\n=== Test 12: Configuration Inheritance ===')
+
+local base_config = {
+  image = 'mcr.microsoft.com/devcontainers/base:ubuntu',
+  remoteUser = 'vscode',
+  forwardPorts = { 3000, 5432 },
+  mounts = { 'source=cache,target=/cache,type=volume' },
+  containerEnv = { TZ = 'UTC', LOG_LEVEL = 'info' },
+  features = {
+    ['ghcr.io/devcontainers/features/node:1'] = { version = '18' },
+  },
+  customizations = {
+    vscode = { extensions = { 'dbaeumer.vscode-eslint' }, settings = { ['editor.tabSize'] = 2 } },
+  },
+}
+local child_config = {
+  name = 'api',
+  remoteUser = 'node',
+  forwardPorts = { 3000, 8080 },
+  mounts = { { source = 'data', target = '/data', type = 'volume' } },
+  containerEnv = { LOG_LEVEL = 'debug' },
+  features = {
+    ['ghcr.io/devcontainers/features/node:1'] = { version = '20' },
+    ['ghcr.io/devcontainers/features/go:1'] = {},
+  },
+  customizations = {
+    vscode = { extensions = { 'golang.go' } },
+  },
+}
+local merged = parser.merge_configs(base_config, child_config)
+assert_equals(merged.name, 'api', 'Child-only field should be set')
+assert_equals(merged.image, base_config.image, 'Base-only field should be inherited')
+assert_equals(merged.remoteUser, 'node', 'Child scalar should override base')
+assert_table_length(merged.forwardPorts, 3, 'forwardPorts should be concatenated without duplicates')
+assert_equals(merged.forwardPorts[3], 8080, 'Child ports should follow base ports')
+assert_table_length(merged.mounts, 2, 'Mounts should be concatenated')
+assert_equals(merged.mounts[2].target, '/data', 'Child mount should be appended')
+assert_equals(merged.containerEnv.TZ, 'UTC', 'containerEnv should keep base keys')
+assert_equals(merged.containerEnv.LOG_LEVEL, 'debug', 'containerEnv should let child keys win')
+assert_equals(merged.features['ghcr.io/devcontainers/features/node:1'].version, '20', 'Feature options should merge')
+assert_truthy(merged.features['ghcr.io/devcontainers/features/go:1'], 'Child features should be added')
+assert_table_length(merged.customizations.vscode.extensions, 2, 'Extensions should be concatenated')
+assert_equals(merged.customizations.vscode.settings['editor.tabSize'], 2, 'Customization settings should be kept')
+assert_table_length(base_config.forwardPorts, 2, 'Base config should not be modified')
+print('✓ Child config merged over base config')
+
+-- Test 13: Workspace Mount
+print('\n=== Test 13: Workspace Mount ===')
+
+local mount_context = { workspace_folder = '/home/user/project' }
+local default_mount, default_warnings = parser.resolve_workspace_mount({ image = 'alpine' }, mount_context)
+assert_equals(default_mount.source, '/home/user/project', 'Project folder should be mounted by default')
+assert_equals(default_mount.target, '/workspace', 'Default target should be /workspace')
+assert_table_length(default_warnings, 0, 'Default mount should not warn')
+
+local folder_mount = parser.resolve_workspace_mount({ workspaceFolder = '/src/app' }, mount_context)
+assert_equals(folder_mount.target, '/src/app', 'workspaceFolder alone should become the mount target')
+
+local explicit_mount, explicit_warnings = parser.resolve_workspace_mount({
+  workspaceMount = 'source=/home/user,target=/src,type=bind,consistency=cached',
+  workspaceFolder = '/src/project',
+}, mount_context)
+assert_equals(explicit_mount.source, '/home/user', 'workspaceMount source should be used')
+assert_equals(explicit_mount.consistency, 'cached', 'workspaceMount options should be kept')
+assert_table_length(explicit_warnings, 0, 'workspaceFolder inside the mount should not warn')
+
+local _, outside_warnings = parser.resolve_workspace_mount({
+  workspaceMount = 'source=/home/user/project,target=/src,type=bind',
+  workspaceFolder = '/workspaces/project',
+}, mount_context)
+assert_table_length(outside_warnings, 1, 'workspaceFolder outside the mount should warn')
+
+local host_root, container_root =
+  parser.workspace_roots({ workspace_folder = '/src/project', workspace_mount = explicit_mount })
+assert_equals(host_root, '/home/user', 'Host root should follow the workspace mount')
+assert_equals(container_root, '/src', 'Container root should follow the workspace mount')
+local compose_mount = parser.resolve_workspace_mount({ dockerComposeFile = 'compose.yml' }, mount_context)
+assert_equals(compose_mount, nil, 'Compose configurations should not get a workspace mount')
+print('✓ Workspace mount resolved from workspaceMount and workspaceFolder')
+
+-- Test 14: Build options
+print('\n=== Test 14: Build Options ===')
+
+local cache_from_string = parser.normalize_cache_from('ghcr.io/org/app:cache')
+assert_table_length(cache_from_string, 1, 'String cacheFrom should become a single image')
+local cache_from_array = parser.normalize_cache_from({ 'app:main', 'app:dev' })
+assert_equals(cache_from_array[2], 'app:dev', 'Array cacheFrom should keep its order')
+local cache_from_map = parser.normalize_cache_from({ b = 'app:dev', a = 'app:main' })
+assert_equals(cache_from_map[1], 'app:main', 'Map cacheFrom values should be ordered by key')
+assert_table_length(parser.normalize_cache_from(nil), 0, 'Missing cacheFrom should be empty')
+
+local build_config = parser.normalize_for_plugin({
+  devcontainer_folder = '/test/workspace/.devcontainer',
+  build = {
+    dockerfile = 'Dockerfile',
+    context = '..',
+    target = 'dev',
+    args = { GO_VERSION = '1.22' },
+    cacheFrom = 'app:cache',
+    options = { '--network=host' },
+  },
+})
+assert_equals(
+  build_config.context,
+  '/test/workspace/.devcontainer/..',
+  'build.context should resolve against the config folder'
+)
+assert_equals(build_config.build_target, 'dev', 'build.target should be normalized')
+assert_equals(build_config.build_args.GO_VERSION, '1.22', 'build.args should be normalized')
+assert_equals(build_config.cache_from[1], 'app:cache', 'build.cacheFrom should be normalized')
+assert_equals(build_config.build_options[1], '--network=host', 'build.options should be normalized')
+print('✓ build.args, build.target, build.cacheFrom and build.options normalized')
+
+-- Test 15: shutdownAction
+print('\n=== Test 15: Shutdown Action ===')
+
+local shutdown_config = parser.normalize_for_plugin({ image = 'ubuntu:22.04', shutdownAction = 'none' })
+assert_equals(shutdown_config.shutdown_action, 'none', 'shutdownAction should be normalized')
+assert_table_length(
+  parser.validate({ name = 'test', image = 'ubuntu:22.04', shutdownAction = 'stopCompose' }),
+  0,
+  'stopCompose should be a valid shutdownAction'
+)
+assert_table_length(
+  parser.validate({ name = 'test', image = 'ubuntu:22.04', shutdownAction = 'stop' }),
+  1,
+  'Unknown shutdownAction should be rejected'
+)
+print('✓ shutdownAction normalized and validated')
+
+-- Test 16: privileged, capAdd and securityOpt
+print('\n=== Test 16: Security Settings ===')
+
+local security_config = parser.normalize_for_plugin({
+  image = 'golang:1.22',
+  capAdd = { 'SYS_PTRACE' },
+  securityOpt = { 'seccomp=unconfined' },
+})
+assert_equals(security_config.cap_add[1], 'SYS_PTRACE', 'capAdd should be normalized')
+assert_equals(security_config.security_opt[1], 'seccomp=unconfined', 'securityOpt should be normalized')
+assert_equals(security_config.privileged, false, 'privileged defaults to false')
+assert_table_length(
+  parser.validate({
+    name = 'test',
+    image = 'golang:1.22',
+    privileged = true,
+    capAdd = { 'SYS_PTRACE', 'cap_net_admin' },
+    securityOpt = { 'seccomp=unconfined', 'apparmor:unconfined', 'no-new-privileges' },
+  }),
+  0,
+  'Known capabilities and security options should be valid'
+)
+local security_errors = parser.validate({
+  name = 'test',
+  image = 'golang:1.22',
+  privileged = 'yes',
+  capAdd = { 'SYS_PTRAC' },
+  securityOpt = { 'seccomp-unconfined' },
+})
+assert_table_length(security_errors, 3, 'Invalid security settings should be rejected')
+assert_equals(security_errors[2], 'Unknown capability in capAdd: SYS_PTRAC', 'Misspelled capability')
+assert_table_length(
+  parser.validate({ name = 'test', image = 'golang:1.22', capAdd = 'SYS_PTRACE' }),
+  1,
+  'capAdd must be an array'
+)
+print('✓ privileged, capAdd and securityOpt normalized and validated')
+
 print('\n=== Parser Test Results ===')
 print('All parser tests passed! ✓')
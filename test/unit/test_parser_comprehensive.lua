@@ -214,6 +214,20 @@ _G.vim = {
     end
     return result
   end,
+  tbl_contains = function(tbl, value)
+    for _, v in ipairs(tbl) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
 }
 
 -- Mock os.getenv for variable expansion tests
@@ -609,6 +623,15 @@ local errors4 = parser.validate(invalid_port_config)
 assert_truthy(#errors4 > 0, 'Invalid port range should have errors')
 print('✓ Invalid port range validation')
 
+-- Test runArgs and hostRequirements.gpu validation
+local errors5 = parser.validate({ name = 'test', image = 'ubuntu', runArgs = { '--gpus', 1 } })
+assert_truthy(#errors5 > 0, 'runArgs with a non-string entry should have errors')
+local errors6 = parser.validate({ name = 'test', image = 'ubuntu', hostRequirements = { gpu = 'yes' } })
+assert_truthy(#errors6 > 0, 'Invalid hostRequirements.gpu should have errors')
+local errors7 = parser.validate({ name = 'test', image = 'ubuntu', hostRequirements = { gpu = 'optional' } })
+assert_table_length(errors7, 0, 'hostRequirements.gpu "optional" should be valid')
+print('✓ runArgs and hostRequirements validation')
+
 -- Test resolved port validation
 local resolved_port_config = {
   normalized_ports = {
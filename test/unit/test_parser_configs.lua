@@ -0,0 +1,134 @@
+#!/usr/bin/env lua
+
+-- Test script for discovery of multiple devcontainer configurations
+-- Run with: lua test/unit/test_parser_configs.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Virtual file system of a workspace with configurations in subfolders
+local files = {
+  ['/repo/.devcontainer/backend/devcontainer.json'] = true,
+  ['/repo/.devcontainer/frontend/devcontainer.json'] = true,
+  ['/repo/.devcontainer/backend/Dockerfile'] = true,
+}
+local dirs = {
+  ['/repo'] = true,
+  ['/repo/src'] = true,
+  ['/repo/.devcontainer'] = true,
+  ['/repo/.devcontainer/backend'] = true,
+  ['/repo/.devcontainer/frontend'] = true,
+  ['/repo/.devcontainer/shared'] = true,
+}
+
+-- Mock vim global for testing
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/repo'
+    end,
+    filereadable = function(path)
+      return files[path] and 1 or 0
+    end,
+    isdirectory = function(path)
+      return dirs[path] and 1 or 0
+    end,
+    readdir = function(path)
+      local entries = {}
+      for dir in pairs(dirs) do
+        local entry = dir:match('^' .. path:gsub('%p', '%%%0') .. '/([^/]+)$')
+        if entry then
+          table.insert(entries, entry)
+        end
+      end
+      return entries
+    end,
+    fnamemodify = function(path, modifier)
+      if modifier == ':h' then
+        return path:match('^(.+)/[^/]*$') or '/'
+      elseif modifier == ':t' then
+        return path:match('([^/]*)$')
+      end
+      return path
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+}
+
+-- Mock log module
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local parser = require('container.parser')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running devcontainer configuration discovery tests...')
+print()
+
+test('all subfolder configurations are listed', function()
+  local configs = parser.find_devcontainer_configs('/repo')
+  assert_equals(#configs, 2, 'config count')
+  assert_equals(configs[1], '/repo/.devcontainer/backend/devcontainer.json', 'first config')
+  assert_equals(configs[2], '/repo/.devcontainer/frontend/devcontainer.json', 'second config')
+end)
+
+test('a subfolder configuration is found from the workspace', function()
+  assert_equals(
+    parser.find_devcontainer_json('/repo/src'),
+    '/repo/.devcontainer/backend/devcontainer.json',
+    'found config'
+  )
+end)
+
+test('subfolder configurations belong to the project root', function()
+  assert_equals(
+    parser.get_workspace_folder('/repo/.devcontainer/backend/devcontainer.json'),
+    '/repo',
+    'subfolder config'
+  )
+  assert_equals(parser.get_workspace_folder('/repo/.devcontainer/devcontainer.json'), '/repo', 'primary config')
+  assert_equals(parser.get_workspace_folder('/repo/.devcontainer.json'), '/repo', 'root config')
+  assert_equals(parser.find_workspace_root('/repo/src'), '/repo', 'workspace root')
+end)
+
+test('relative bind mount sources resolve against the configuration folder', function()
+  local mounts = parser.normalize_mounts(
+    { 'source=./cache,target=/cache,type=bind' },
+    { devcontainer_folder = '/repo/.devcontainer/backend' }
+  )
+  assert_equals(mounts[1].source, '/repo/.devcontainer/backend/cache', 'resolved source')
+end)
+
+print()
+print(string.format('=== Parser Configuration Discovery Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
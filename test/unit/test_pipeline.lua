@@ -0,0 +1,125 @@
+#!/usr/bin/env lua
+
+-- Test script for container.pipeline module
+-- Run with: lua test/unit/test_pipeline.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local stopped_jobs = {}
+
+_G.vim = {
+  fn = {
+    jobstop = function(job_id)
+      table.insert(stopped_jobs, job_id)
+      return 1
+    end,
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local pipeline = require('container.pipeline')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running pipeline tests...')
+print()
+
+test('cancel stops the tracked jobs of the workspace only', function()
+  stopped_jobs = {}
+  local run = pipeline.begin('/project')
+  pipeline.begin('/other')
+  pipeline.track('/project', 11)
+  pipeline.track('/project', 12)
+  pipeline.track('/other', 21)
+
+  assert_equals(pipeline.cancel('/project'), run, 'cancelled pipeline')
+  assert_equals(run.cancelled, true, 'marked cancelled')
+  assert_equals(#stopped_jobs, 2, 'stopped jobs')
+  assert_equals(stopped_jobs[1], 11, 'first job')
+  assert_equals(stopped_jobs[2], 12, 'second job')
+  assert_equals(pipeline.active('/project'), nil, 'no longer active')
+  assert_equals(pipeline.active('/other') ~= nil, true, 'other workspace keeps running')
+  pipeline.cancel('/other')
+end)
+
+test('cancel without a running pipeline does nothing', function()
+  stopped_jobs = {}
+  assert_equals(pipeline.cancel('/project'), nil, 'nothing cancelled')
+  assert_equals(#stopped_jobs, 0, 'no jobs stopped')
+end)
+
+test('jobs are ignored without a pipeline or when they failed to start', function()
+  pipeline.track('/project', 5)
+  local run = pipeline.begin('/project')
+  pipeline.track('/project', 0)
+  pipeline.track('/project', -1)
+  pipeline.track('/project', nil)
+  assert_equals(#run.jobs, 0, 'tracked jobs')
+  pipeline.finish(run)
+end)
+
+test('finish only ends the pipeline it was given', function()
+  local old = pipeline.begin('/project')
+  local new = pipeline.begin('/project')
+  pipeline.finish(old)
+  assert_equals(pipeline.active('/project'), new, 'newer pipeline kept')
+  pipeline.finish(new)
+  assert_equals(pipeline.active('/project'), nil, 'finished')
+end)
+
+test('is_cancelled treats a missing pipeline as running', function()
+  assert_equals(pipeline.is_cancelled(nil), false, 'nil pipeline')
+  local run = pipeline.begin('/project')
+  assert_equals(pipeline.is_cancelled(run), false, 'running')
+  pipeline.cancel('/project')
+  assert_equals(pipeline.is_cancelled(run), true, 'cancelled')
+end)
+
+test('progress keeps the last step for message-only updates', function()
+  local run = pipeline.begin('/project')
+  pipeline.set_progress(run, 3, 6, 'Step 3: Creating new container...')
+  pipeline.set_progress(run, nil, nil, 'Step 3: ✓ Created container')
+  assert_equals(run.progress.step, 3, 'step')
+  assert_equals(run.progress.total, 6, 'total')
+  assert_equals(run.progress.message, 'Step 3: ✓ Created container', 'message')
+  pipeline.set_progress(nil, 1, 6, 'ignored')
+  pipeline.finish(run)
+end)
+
+test('a pipeline without a workspace root is not registered', function()
+  local run = pipeline.begin(nil)
+  assert_equals(run.cancelled, false, 'usable')
+  assert_equals(pipeline.active(nil), nil, 'not registered')
+  assert_equals(pipeline.cancel(nil), nil, 'cannot be cancelled')
+end)
+
+print()
+print(string.format('=== Pipeline Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
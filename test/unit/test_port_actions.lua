@@ -0,0 +1,163 @@
+#!/usr/bin/env lua
+
+-- Test script for container.port_actions module
+-- Run with: lua test/unit/test_port_actions.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local opened = {}
+local notifications = {}
+local deferred = {}
+local plugin_config = { port_forwarding = { bind_address = '127.0.0.1' } }
+local listening = {}
+
+_G.vim = {
+  ui = {
+    open = function(url)
+      table.insert(opened, url)
+      return {}, nil
+    end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function(fn)
+    table.insert(deferred, fn)
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {
+  container = function(message)
+    table.insert(notifications, message)
+  end,
+  warn = function(message)
+    table.insert(notifications, message)
+  end,
+  error = function(message)
+    table.insert(notifications, message)
+  end,
+}
+package.loaded['container.config'] = {
+  get_value = function(path)
+    if path == 'port_forwarding' then
+      return plugin_config.port_forwarding
+    end
+    return plugin_config.port_forwarding[path:match('^port_forwarding%.(.+)$')]
+  end,
+}
+package.loaded['container.forward_store'] = {
+  list_listening_ports = function(_, callback)
+    callback(listening)
+  end,
+}
+
+local port_actions = require('container.port_actions')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local function reset()
+  opened = {}
+  notifications = {}
+  deferred = {}
+  listening = {}
+end
+
+print('Running port action tests...')
+print()
+
+test('only notify and the open actions are performed', function()
+  assert_equals(port_actions.get_action({ attributes = { onAutoForward = 'openBrowser' } }), 'openBrowser', 'open')
+  assert_equals(port_actions.get_action({ attributes = { onAutoForward = 'notify' } }), 'notify', 'notify')
+  assert_equals(port_actions.get_action({ attributes = { onAutoForward = 'silent' } }), nil, 'silent')
+  assert_equals(port_actions.get_action({ label = 'Web' }), nil, 'no attributes')
+end)
+
+test('URLs use the host port and the https protocol attribute', function()
+  assert_equals(port_actions.get_url({ host_port = 3001 }), 'http://localhost:3001', 'http')
+  local url = port_actions.get_url({ host_port = 8443, attributes = { protocol = 'https' } })
+  assert_equals(url, 'https://localhost:8443', 'https')
+end)
+
+test('ports are acted on once they are listened on in the container', function()
+  reset()
+  local ports = {
+    { container_port = 3000, host_port = 3001, label = 'Web', attributes = { onAutoForward = 'openBrowser' } },
+    { container_port = 5432, host_port = 5432, attributes = { onAutoForward = 'notify' } },
+    { container_port = 9229, host_port = 9229, attributes = { onAutoForward = 'silent' } },
+  }
+  listening = { [5432] = true }
+  port_actions.watch('abc123', ports)
+  assert_equals(#notifications, 1, 'notified')
+  assert_equals(notifications[1], 'Port 5432 is available at http://localhost:5432', 'notification')
+  assert_equals(#opened, 0, 'web port not listened on yet')
+  assert_equals(#deferred, 1, 'checked again later')
+
+  listening = { [3000] = true, [5432] = true }
+  deferred[1]()
+  assert_equals(opened[1], 'http://localhost:3001', 'browser opened')
+  assert_equals(#notifications, 1, 'notify is not repeated')
+  assert_equals(#deferred, 1, 'nothing left to wait for')
+end)
+
+test('watching stops once the container is no longer active', function()
+  reset()
+  local active = true
+  port_actions.watch('abc123', {
+    { container_port = 3000, host_port = 3000, attributes = { onAutoForward = 'openBrowser' } },
+  }, function()
+    return active
+  end)
+  active = false
+  listening = { [3000] = true }
+  deferred[1]()
+  assert_equals(#opened, 0, 'not opened')
+end)
+
+test('openBrowserOnce opens a URL once and open_browser overrides vim.ui.open', function()
+  reset()
+  local port = { container_port = 8080, host_port = 8080, attributes = { onAutoForward = 'openBrowserOnce' } }
+  port_actions.perform(port)
+  port_actions.perform(port)
+  assert_equals(#opened, 1, 'opened once')
+
+  local custom = {}
+  plugin_config.port_forwarding.open_browser = function(url, p)
+    table.insert(custom, { url = url, port = p })
+  end
+  port_actions.perform({ container_port = 3000, host_port = 3000, attributes = { onAutoForward = 'openPreview' } })
+  plugin_config.port_forwarding.open_browser = nil
+  assert_equals(#opened, 1, 'vim.ui.open not used')
+  assert_equals(custom[1].url, 'http://localhost:3000', 'callback url')
+  assert_equals(custom[1].port.container_port, 3000, 'callback port')
+end)
+
+print()
+print(string.format('=== Port Action Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
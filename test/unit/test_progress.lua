@@ -0,0 +1,171 @@
+#!/usr/bin/env lua
+
+-- Test script for container.ui.progress module
+-- Run with: lua test/unit/test_progress.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local clock = 0
+local notifications = {}
+
+_G.vim = {
+  log = { levels = { DEBUG = 1, INFO = 2, WARN = 3, ERROR = 4 } },
+  loop = {
+    now = function()
+      return clock
+    end,
+    hrtime = function()
+      return 0
+    end,
+  },
+  notify = function(message, level, opts)
+    table.insert(notifications, { message = message, level = level, opts = opts })
+    return { id = #notifications }
+  end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  tbl_extend = function(_, ...)
+    local result = {}
+    for _, tbl in ipairs({ ... }) do
+      for k, v in pairs(tbl) do
+        result[k] = v
+      end
+    end
+    return result
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+local enabled = true
+package.loaded['container.utils.notify'] = {
+  enabled = function()
+    return enabled
+  end,
+}
+
+local progress_setting = 'notify'
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'ui.progress' then
+      return progress_setting
+    end
+  end,
+}
+
+local progress = require('container.ui.progress')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  notifications = {}
+  progress_setting = 'notify'
+  enabled = true
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running progress tests...')
+print()
+
+test('notify keeps one message per operation and dismisses it at the end', function()
+  clock = 10000
+  local token = progress.begin('Building app')
+  clock = clock + 2000
+  progress.report(token, '[2/4] RUN make', 25)
+  progress.finish(token, true)
+
+  assert_equals(#notifications, 3, 'notifications')
+  assert_equals(notifications[1].opts.timeout, false, 'running message stays')
+  assert_equals(notifications[2].message, 'Building app: [2/4] RUN make (25%)', 'stage and percentage')
+  assert_equals(notifications[2].opts.replace.id, 1, 'replaces the first message')
+  assert_equals(notifications[2].opts.id, notifications[1].opts.id, 'same id')
+  assert_equals(notifications[3].opts.timeout, nil, 'final message times out')
+  assert_equals(notifications[3].message, 'Building app: [2/4] RUN make (100%)', 'completed')
+end)
+
+test('rapid reports are throttled and failures are errors', function()
+  clock = 50000
+  local token = progress.begin('Pulling alpine')
+  progress.report(token, '1/3 layers', 33)
+  progress.report(token, '2/3 layers', 66)
+  assert_equals(#notifications, 1, 'reports within the interval are dropped')
+  progress.finish(token, false, 'manifest unknown')
+  assert_equals(notifications[2].level, vim.log.levels.ERROR, 'error level')
+  assert_equals(notifications[2].message, 'Pulling alpine: manifest unknown (66%)', 'error message')
+  progress.report(token, 'late', 99)
+  assert_equals(#notifications, 2, 'finished operations ignore reports')
+end)
+
+test('a function sink receives every event and its errors are caught', function()
+  local events = {}
+  progress_setting = function(event)
+    table.insert(events, event)
+    error('broken sink')
+  end
+  local token = progress.begin('Testing (go)')
+  progress.report(token, '3 passed, 0 failed')
+  progress.cancel(token)
+  assert_equals(#events, 3, 'events')
+  assert_equals(events[1].kind, 'begin', 'begin')
+  assert_equals(events[2].token, token, 'token')
+  assert_equals(events[3].kind, 'end', 'end')
+  assert_equals(events[3].level, vim.log.levels.WARN, 'cancel is a warning')
+  assert_equals(#notifications, 0, 'nothing notified')
+end)
+
+test("'none' only notifies the result", function()
+  progress_setting = 'none'
+  local token = progress.begin('Building app')
+  progress.report(token, 'step')
+  progress.finish(token, false, 'failed')
+  assert_equals(#notifications, 1, 'result only')
+  assert_equals(notifications[1].level, vim.log.levels.ERROR, 'error level')
+end)
+
+test('build steps give the percentage of steps done', function()
+  local stage, percentage = progress.build_stage('#5 [builder 3/4] RUN go build ./...')
+  assert_equals(stage, '[builder 3/4] RUN go build ./...', 'BuildKit stage')
+  assert_equals(percentage, 50, 'BuildKit percentage')
+  stage, percentage = progress.build_stage('Step 1/5 : FROM golang:1.22')
+  assert_equals(stage, '[1/5] FROM golang:1.22', 'classic stage')
+  assert_equals(percentage, 0, 'classic percentage')
+  assert_equals(progress.build_stage('#5 0.512 compiling'), nil, 'output lines')
+end)
+
+test('pulled layers are counted', function()
+  local layers = {}
+  assert_equals(progress.pull_stage('[stdout] 3.19: Pulling from library/alpine', layers), nil, 'image line')
+  progress.pull_stage('[stdout] 4abcf2066143: Pulling fs layer', layers)
+  progress.pull_stage('[stdout] 9f1a2b3c4d5e: Already exists', layers)
+  local stage, percentage = progress.pull_stage('[stdout] 4abcf2066143: Pull complete', layers)
+  assert_equals(stage, '2/2 layers', 'stage')
+  assert_equals(percentage, 100, 'percentage')
+  assert_equals(progress.pull_stage('Digest: sha256:abc', layers), nil, 'digest line')
+end)
+
+print()
+print(string.format('=== Progress Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,145 @@
+#!/usr/bin/env lua
+
+-- Test script for container.prune module
+-- Run with: lua test/unit/test_prune.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local commands = {}
+local responses = {}
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'prune' then
+      return { max_age = 30 }
+    end
+  end,
+}
+package.loaded['container'] = {
+  get_state = function()
+    return { current_container = 'aaa111aaa111ffffffff' }
+  end,
+}
+package.loaded['container.docker'] = {
+  WORKSPACE_LABEL = 'container.nvim.workspace',
+  IMAGE_LABEL = 'dev.container-nvim.image',
+  run_docker_command_async = function(args, _, callback)
+    table.insert(commands, table.concat(args, ' '))
+    callback(responses[args[1]] or { success = true, stdout = '', stderr = '' })
+  end,
+}
+
+local prune = require('container.prune')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  commands, responses = {}, {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running prune tests...')
+print()
+
+test('docker sizes are parsed and formatted', function()
+  assert_equals(prune.parse_size('1.5GB'), 1500000000, 'GB')
+  assert_equals(prune.parse_size('12.3kB (virtual 1.2GB)'), 12300, 'writable layer of a container')
+  assert_equals(prune.parse_size('0B'), 0, 'zero')
+  assert_equals(prune.parse_size(''), 0, 'unknown')
+  assert_equals(prune.format_size(1500000000), '1.5GB', 'format')
+  assert_equals(prune.format_size(512), '512B', 'bytes')
+end)
+
+test('the attached container is never pruned', function()
+  local containers = prune.parse_containers(
+    'aaa111aaa111\tapp-devcontainer\t0B (virtual 1GB)\nbbb222bbb222\told-devcontainer\t2MB (virtual 1GB)\n',
+    'aaa111aaa111ffffffff'
+  )
+  assert_equals(#containers, 1, 'count')
+  assert_equals(containers[1].name, 'old-devcontainer', 'stopped container')
+  assert_equals(containers[1].size, 2000000, 'size')
+end)
+
+test('untagged images are pruned, old cached images only with --all', function()
+  local now = os.time({ year = 2024, month = 6, day = 30, hour = 12 })
+  local stdout = table.concat({
+    'c1\t<none>:<none>\t1GB\t2024-06-29 10:00:00 +0000 UTC',
+    'c2\tcontainer-nvim-app:abc\t2GB\t2024-05-01 10:00:00 +0000 UTC',
+    'c3\tcontainer-nvim-uid:def\t1GB\t2024-06-20 10:00:00 +0000 UTC',
+  }, '\n')
+  local images = prune.parse_images(stdout, { max_age = 30, now = now })
+  assert_equals(#images, 1, 'only untagged')
+  assert_equals(images[1].dangling, true, 'dangling')
+
+  images = prune.parse_images(stdout, { all = true, max_age = 30, now = now })
+  assert_equals(#images, 2, 'old cached image added')
+  assert_equals(images[2].name, 'container-nvim-app:abc', 'older than 30 days')
+  assert_equals(images[2].age, 60, 'age in days')
+  assert(prune.describe({ containers = {}, images = images }):find('up to 3.0GB', 1, true), 'total in confirmation')
+end)
+
+test('candidates are found by label and images in use are kept', function()
+  responses.ps = { success = true, stdout = 'bbb222bbb222\told-devcontainer\t1MB (virtual 1GB)\n', stderr = '' }
+  responses.images = { success = true, stdout = 'c1\t<none>:<none>\t1GB\t2024-06-29 10:00:00 +0000 UTC\n' }
+  local candidates
+  prune.collect({}, function(result)
+    candidates = result
+  end)
+  assert(commands[1]:find('--filter label=container.nvim.workspace --filter status=exited', 1, true), commands[1])
+  assert(commands[2]:find('--filter label=dev.container-nvim.image', 1, true), commands[2])
+  table.insert(candidates.images, { id = 'c9', size = 5 })
+
+  local rmi = 0
+  package.loaded['container.docker'].run_docker_command_async = function(args, _, callback)
+    table.insert(commands, table.concat(args, ' '))
+    if args[1] == 'rmi' then
+      rmi = rmi + 1
+      callback({ success = rmi == 1, stdout = '', stderr = 'image is being used by a container' })
+    else
+      callback({ success = true, stdout = 'bbb222bbb222\n', stderr = '' })
+    end
+  end
+  local removed
+  prune.remove(candidates, function(result)
+    removed = result
+  end)
+  assert_equals(commands[3], 'rm bbb222bbb222', 'containers first')
+  assert_equals(commands[4], 'rmi c1', 'then images')
+  assert_equals(#removed.containers, 1, 'container removed')
+  assert_equals(#removed.images, 1, 'image in use kept')
+  assert_equals(removed.reclaimed, 1001000000, 'reclaimed')
+end)
+
+print()
+print(string.format('=== Prune Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
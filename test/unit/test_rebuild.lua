@@ -0,0 +1,314 @@
+#!/usr/bin/env lua
+
+-- Test script for container.rebuild_container (remove, rebuild and start, then restore the session)
+-- Run with: lua test/unit/test_rebuild.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+-- Docker, compose and start calls in order, e.g. "remove ctr-a"
+local calls = {}
+-- Callback of the pending container removal
+local remove_callback = nil
+-- ContainerStarted autocmds created by the rebuild: { id, callback }
+local started_autocmds = {}
+local last_autocmd_id = 100
+local deleted_autocmds = {}
+local critical_messages = {}
+local image_ids = {}
+local is_compose = false
+local sessions = {}
+local closed_sessions = {}
+local reopened_terminals = {}
+local running_test = nil
+local rerun_tests = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function(_, opts)
+      if opts.pattern == 'ContainerStarted' then
+        last_autocmd_id = last_autocmd_id + 1
+        table.insert(started_autocmds, { id = last_autocmd_id, callback = opts.callback })
+        return last_autocmd_id
+      end
+      return 1
+    end,
+    nvim_del_autocmd = function(id)
+      table.insert(deleted_autocmds, id)
+    end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = noop,
+  critical = function(message)
+    table.insert(critical_messages, message)
+  end,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function() end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.terminal.session'] = {
+  list_sessions = function()
+    return sessions
+  end,
+  close_session = function(name)
+    table.insert(closed_sessions, name)
+  end,
+}
+package.loaded['container.test'] = {
+  stop = function()
+    local stopped = running_test
+    running_test = nil
+    return stopped
+  end,
+  run = function(opts)
+    table.insert(rerun_tests, opts)
+  end,
+  summary = noop,
+}
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = { setup = noop, stop_all = noop, switch_container = noop }
+package.loaded['container.events'] = { emit = noop }
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function()
+    return is_compose
+  end,
+  down = function(config, _, callback)
+    table.insert(calls, 'compose down ' .. config.name)
+    remove_callback = callback
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  run_docker_command_async = noop,
+  get_container_image_id = function(container_id, callback)
+    table.insert(calls, 'image ' .. container_id)
+    callback(image_ids[container_id])
+  end,
+  stop_and_remove_container = function(container_id, _, callback)
+    table.insert(calls, 'remove ' .. container_id)
+    remove_callback = callback
+  end,
+  remove_dangling_image = function(image_id, callback)
+    table.insert(calls, 'prune ' .. image_id)
+    callback(true)
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+container.start = function(opts)
+  table.insert(calls, 'start force_rebuild=' .. tostring(opts and opts.force_rebuild))
+  return true
+end
+container.terminal = function(opts)
+  table.insert(reopened_terminals, opts.name)
+end
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  calls, remove_callback, started_autocmds, deleted_autocmds, critical_messages = {}, nil, {}, {}, {}
+  image_ids = { ['ctr-a'] = 'sha256:old', ['ctr-new'] = 'sha256:new' }
+  is_compose = false
+  sessions = {
+    { name = 'dev', container_id = 'ctr-a' },
+    { name = 'elsewhere', container_id = 'ctr-b' },
+  }
+  closed_sessions, reopened_terminals, rerun_tests = {}, {}, {}
+  running_test = { run = 'TestHandler' }
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Enter a buffer of a project and attach a running container to it
+local function attach(project, container_id, config)
+  buffer_name = '/projects/' .. project .. '/main.go'
+  container._sync_workspace()
+  container._restore_attached_container({ id = container_id, status = 'Up' }, config or { name = project }, nil)
+end
+
+container.setup({})
+
+print('Running rebuild tests...')
+print()
+
+test('the container is removed before the rebuilt one is started', function()
+  attach('a', 'ctr-a')
+  assert_equals(container.rebuild_container(), true, 'rebuild started')
+  assert_equals(table.concat(calls, ', '), 'image ctr-a, remove ctr-a', 'nothing started before the removal')
+  assert_equals(container.status().state, 'building', 'state while removing')
+  assert_equals(table.concat(closed_sessions, ','), 'dev', 'terminals of the container closed')
+
+  remove_callback(true)
+  assert_equals(table.concat(calls, ', '), 'image ctr-a, remove ctr-a, start force_rebuild=true', 'order')
+  assert_equals(container.get_container_id(), nil, 'removed container forgotten')
+  assert_equals(#started_autocmds, 1, 'waits for ContainerStarted')
+end)
+
+test('the session is restored once the new container has started', function()
+  attach('a', 'ctr-a')
+  container.rebuild_container({ prune = true })
+  remove_callback(true)
+  assert_equals(#reopened_terminals, 0, 'not before the start')
+
+  started_autocmds[1].callback({ data = { container_id = 'ctr-new' } })
+  assert_equals(table.concat(reopened_terminals, ','), 'dev', 'terminal reopened')
+  assert_equals(rerun_tests[1].run, 'TestHandler', 'go test run again')
+  assert_equals(calls[#calls], 'prune sha256:old', 'previous image pruned')
+end)
+
+test('the previous image is kept when it is still the image of the container', function()
+  attach('a', 'ctr-a')
+  image_ids['ctr-new'] = 'sha256:old'
+  container.rebuild_container({ prune = true })
+  remove_callback(true)
+  started_autocmds[1].callback({ data = { container_id = 'ctr-new' } })
+  assert_equals(calls[#calls], 'image ctr-new', 'nothing pruned')
+end)
+
+test('a failed removal aborts the rebuild', function()
+  attach('a', 'ctr-a')
+  container.rebuild_container()
+  remove_callback(false, 'container is in use')
+  assert_equals(table.concat(calls, ', '), 'image ctr-a, remove ctr-a', 'not started')
+  assert_equals(critical_messages[1], 'Failed to remove container for rebuild: container is in use', 'reported')
+  assert_equals(container.get_container_id(), 'ctr-a', 'container kept')
+  assert_equals(container.status().state, 'stopped', 'state reset')
+  assert_equals(#started_autocmds, 0, 'no restore pending')
+end)
+
+test('compose configurations are taken down with compose', function()
+  is_compose = true
+  attach('a', 'ctr-a')
+  container.rebuild_container()
+  remove_callback(true)
+  assert_equals(table.concat(calls, ', '), 'image ctr-a, compose down a, start force_rebuild=true', 'calls')
+end)
+
+test('a start in another project does not restore the session', function()
+  attach('a', 'ctr-a')
+  container.rebuild_container()
+  remove_callback(true)
+  attach('b', 'ctr-b')
+  started_autocmds[1].callback({ data = { container_id = 'ctr-b' } })
+  assert_equals(#reopened_terminals, 0, 'terminals not reopened')
+  assert_equals(#rerun_tests, 0, 'tests not run')
+end)
+
+test('a second rebuild replaces the pending restore', function()
+  attach('a', 'ctr-a')
+  container.rebuild_container()
+  remove_callback(true)
+  attach('a', 'ctr-a2')
+  container.rebuild_container()
+  assert_equals(deleted_autocmds[#deleted_autocmds], started_autocmds[1].id, 'pending restore deleted')
+end)
+
+test('without a container the start rebuilds right away', function()
+  buffer_name = '/projects/c/main.go'
+  container._sync_workspace()
+  assert_equals(container.rebuild_container(), true, 'started')
+  assert_equals(table.concat(calls, ', '), 'start force_rebuild=true', 'no docker calls')
+  assert_equals(#closed_sessions, 0, 'session untouched')
+end)
+
+print()
+print(string.format('=== Rebuild Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
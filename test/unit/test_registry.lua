@@ -0,0 +1,121 @@
+#!/usr/bin/env lua
+
+-- Test script for container.registry module
+-- Run with: lua test/unit/test_registry.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local plugin_config = { registry = {} }
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  v = { shell_error = 0 },
+  fn = {
+    system = function()
+      return 'token-from-command\n'
+    end,
+  },
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.config'] = {
+  get = function()
+    return plugin_config
+  end,
+}
+
+local original_getenv = os.getenv
+os.getenv = function(name)
+  if name == 'REGISTRY_TOKEN' then
+    return 'token-from-env'
+  end
+  return original_getenv(name)
+end
+
+local registry = require('container.registry')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running registry tests...')
+print()
+
+test('the registry is taken from the image reference', function()
+  assert_equals(registry.get_registry('golang:1.22'), 'docker.io', 'official image')
+  assert_equals(registry.get_registry('octocat/app:latest'), 'docker.io', 'Docker Hub user image')
+  assert_equals(registry.get_registry('ghcr.io/octocat/app:1.0'), 'ghcr.io', 'registry host')
+  assert_equals(registry.get_registry('localhost:5000/app'), 'localhost:5000', 'registry with port')
+  assert_equals(registry.get_registry('index.docker.io/library/node'), 'docker.io', 'Docker Hub alias')
+end)
+
+test('authentication failures are recognized', function()
+  assert_equals(
+    registry.is_auth_error('Error response from daemon: Head "https://ghcr.io/v2/x/manifests/1": unauthorized'),
+    true,
+    'unauthorized'
+  )
+  assert_equals(
+    registry.is_auth_error('pull access denied for private/app, repository does not exist or may require docker login'),
+    true,
+    'pull access denied'
+  )
+  assert_equals(registry.is_auth_error('dial tcp: lookup ghcr.io: no such host'), false, 'network error')
+  assert_equals(registry.is_auth_error(nil), false, 'no output')
+end)
+
+test('credentials come from the registry setting of the same server', function()
+  plugin_config.registry = { server = 'https://ghcr.io', username = 'octocat', password_env = 'REGISTRY_TOKEN' }
+  local credentials = registry.get_credentials('ghcr.io')
+  assert_equals(credentials.server, 'ghcr.io', 'server')
+  assert_equals(credentials.username, 'octocat', 'username')
+  assert_equals(credentials.password, 'token-from-env', 'password from the environment')
+  assert_equals(registry.get_credentials('docker.io'), nil, 'other server')
+
+  plugin_config.registry = { server = 'ghcr.io', username = 'octocat', password_command = 'pass show ghcr' }
+  assert_equals(registry.get_credentials('ghcr.io').password, 'token-from-command', 'password from a command')
+end)
+
+test('unusable credentials are reported', function()
+  plugin_config.registry = { server = 'ghcr.io', username = 'octocat', password_env = 'UNSET_REGISTRY_TOKEN_X' }
+  local credentials, err = registry.get_credentials('ghcr.io')
+  assert_equals(credentials, nil, 'no credentials')
+  assert_equals(err, '$UNSET_REGISTRY_TOKEN_X is not set', 'reason')
+
+  plugin_config.registry = { server = 'ghcr.io', username = 'octocat', password_command = 'false' }
+  vim.v.shell_error = 1
+  credentials, err = registry.get_credentials('ghcr.io')
+  vim.v.shell_error = 0
+  assert_equals(credentials, nil, 'failed command')
+  assert_equals(err, 'password_command failed with exit code 1', 'exit code')
+end)
+
+print()
+print(string.format('=== Registry Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
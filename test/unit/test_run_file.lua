@@ -0,0 +1,84 @@
+#!/usr/bin/env lua
+
+-- Test script for container.run_file module
+-- Run with: lua test/unit/test_run_file.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local commands = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'run_file.commands' then
+      return commands
+    end
+  end,
+}
+
+local run_file = require('container.run_file')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running run file tests...')
+print()
+
+local ctx = { file = '/workspace/scripts/seed data.py', dir = '/workspace/scripts', name = 'seed data.py' }
+
+test('placeholders are replaced with quoted container paths', function()
+  assert_equals(run_file.build_command('python3 {file}', ctx), "python3 '/workspace/scripts/seed data.py'", 'file')
+  local command = run_file.build_command('cd {dir} && ls {name}', ctx)
+  assert_equals(command, "cd /workspace/scripts && ls 'seed data.py'", 'dir and name')
+  assert_equals(run_file.build_command('echo {unknown}', ctx), 'echo {unknown}', 'unknown placeholders are kept')
+end)
+
+test('extra arguments are appended quoted', function()
+  assert_equals(run_file.build_command('go run .', ctx, { '--port', '8080' }), 'go run . --port 8080', 'plain')
+  assert_equals(run_file.build_command('go run .', ctx, { "it's" }), [[go run . 'it'\''s']], 'quoted')
+end)
+
+test('commands are looked up by filetype', function()
+  commands = { go = 'go run .', python = '  ', ruby = { 'ruby' } }
+  assert_equals(run_file.get_command('go'), 'go run .', 'configured')
+  assert_equals(run_file.get_command('python'), nil, 'blank command')
+  assert_equals(run_file.get_command('ruby'), nil, 'not a string')
+  assert_equals(run_file.get_command('rust'), nil, 'missing')
+end)
+
+print()
+print(string.format('=== Run File Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,178 @@
+#!/usr/bin/env lua
+
+-- Test script for container.secrets module
+-- Run with: lua test/unit/test_secrets.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- Process environment shared by os.getenv and vim.env
+local env = {}
+local warnings = {}
+local masked = nil
+
+_G.vim = {
+  env = env,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+os.getenv = function(name)
+  return env[name]
+end
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+  set_masked_values = function(values)
+    masked = values
+  end,
+}
+
+package.loaded['container.utils.notify'] = {
+  warn = function(message)
+    table.insert(warnings, message)
+  end,
+}
+
+local settings = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'secrets' then
+      return settings
+    end
+  end,
+}
+
+local secrets = require('container.secrets')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  secrets.clear()
+  for key in pairs(env) do
+    env[key] = nil
+  end
+  warnings = {}
+  settings = {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running secrets tests...')
+print()
+
+test('exec sessions get the names only and the docker job gets the values', function()
+  env.HOST_GITHUB_TOKEN = 'ghp_secret'
+  settings = {
+    GITHUB_TOKEN = 'HOST_GITHUB_TOKEN',
+    NPM_TOKEN = function()
+      return 'npm_secret'
+    end,
+  }
+  local args = secrets.exec_args()
+  assert_equals(table.concat(args, ' '), '-e GITHUB_TOKEN -e NPM_TOKEN', 'arguments')
+  local job_env = secrets.job_env({ 'docker', 'exec', '-e', 'GITHUB_TOKEN', '-e', 'NPM_TOKEN', 'ctr', 'sh' })
+  assert_equals(job_env.GITHUB_TOKEN, 'ghp_secret', 'value from the host variable')
+  assert_equals(job_env.NPM_TOKEN, 'npm_secret', 'value from the function')
+  assert_equals(env.GITHUB_TOKEN, nil, 'environment of Neovim untouched')
+  assert_equals(env.NPM_TOKEN, nil, 'environment of Neovim untouched')
+  assert_equals(#masked, 2, 'values are masked in the log')
+end)
+
+test('build secrets are passed with --secret', function()
+  env.GITHUB_TOKEN = 'ghp_secret'
+  settings = {
+    GOPRIVATE_TOKEN = { env = 'GITHUB_TOKEN', build = true },
+    NPM_TOKEN = function()
+      return 'npm_secret'
+    end,
+  }
+  assert_equals(
+    table.concat(secrets.build_args(), ' '),
+    '--secret id=GOPRIVATE_TOKEN,env=GOPRIVATE_TOKEN',
+    'only secrets marked for builds'
+  )
+  local job_env = secrets.job_env({ 'docker', 'build', '--secret', 'id=GOPRIVATE_TOKEN,env=GOPRIVATE_TOKEN', '.' })
+  assert_equals(job_env.GOPRIVATE_TOKEN, 'ghp_secret', 'build job')
+  assert_equals(job_env.NPM_TOKEN, nil, 'only the named secrets')
+  assert_equals(secrets.describe()[1], 'GOPRIVATE_TOKEN (from $GITHUB_TOKEN, build)', 'description')
+  assert_equals(secrets.describe()[2], 'NPM_TOKEN (from a function)', 'description without value')
+end)
+
+test('job_env() only covers loaded secrets named by the command', function()
+  settings = {
+    API_KEY = function()
+      return 'key'
+    end,
+  }
+  assert_equals(secrets.job_env({ 'docker', 'exec', '-e', 'API_KEY', 'ctr' }), nil, 'not loaded yet')
+  secrets.load()
+  assert_equals(secrets.job_env({ 'docker', 'exec', 'ctr', 'env' }), nil, 'not named')
+  assert_equals(secrets.job_env({ 'docker', 'exec', '-e', 'HOME', 'ctr' }), nil, 'other variable')
+  assert_equals(secrets.job_env({ 'docker', 'exec', '-e', "'API_KEY'", 'ctr' }).API_KEY, 'key', 'shell-escaped name')
+  assert_equals(secrets.job_env({ 'docker', 'exec', '--env', 'API_KEY', 'ctr' }).API_KEY, 'key', 'long option')
+end)
+
+test('clear() forgets the values', function()
+  env.GITHUB_TOKEN = 'from-shell'
+  env.HOST_TOKEN = 'ghp_secret'
+  local calls = 0
+  settings = {
+    GITHUB_TOKEN = 'HOST_TOKEN',
+    API_KEY = function()
+      calls = calls + 1
+      return 'key'
+    end,
+  }
+  secrets.load()
+  secrets.load()
+  assert_equals(calls, 1, 'loaded once')
+  assert_equals(secrets.job_env({ '-e', 'GITHUB_TOKEN' }).GITHUB_TOKEN, 'ghp_secret', 'loaded')
+  assert_equals(env.GITHUB_TOKEN, 'from-shell', 'environment of Neovim untouched')
+
+  secrets.clear()
+  assert_equals(secrets.job_env({ '-e', 'GITHUB_TOKEN', '-e', 'API_KEY' }), nil, 'forgotten')
+  assert_equals(#masked, 0, 'nothing masked')
+  secrets.load()
+  assert_equals(calls, 2, 'loaded again after clear')
+end)
+
+test('missing secrets are skipped with one warning without values', function()
+  settings = {
+    MISSING = 'NOT_SET',
+    BROKEN = function()
+      error('vault locked')
+    end,
+  }
+  assert_equals(#secrets.exec_args(), 0, 'no arguments')
+  secrets.exec_args()
+  assert_equals(#warnings, 2, 'warned once per secret')
+  assert_equals(warnings[1]:match('^Secret BROKEN is not available: function failed') ~= nil, true, warnings[1])
+  assert_equals(warnings[2], 'Secret MISSING is not available: $NOT_SET is not set', 'missing variable')
+end)
+
+print()
+print(string.format('=== Secrets Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
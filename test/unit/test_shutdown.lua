@@ -0,0 +1,229 @@
+#!/usr/bin/env lua
+
+-- Test script for the shutdownAction of container.init (stopping containers when Neovim exits)
+-- Run with: lua test/unit/test_shutdown.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+local default_action = 'none'
+-- Calls of docker.run_docker_commands_detached: { commands, timeout_ms }
+local detached_calls = {}
+local pending_stops = 0
+local warnings = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = {
+  debug = noop,
+  info = noop,
+  warn = function(fmt, ...)
+    table.insert(warnings, string.format(fmt, ...))
+  end,
+  error = noop,
+}
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = noop,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function(key)
+    if key == 'shutdown_action' then
+      return default_action
+    end
+  end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = { setup = noop, stop_all = noop, switch_container = noop }
+package.loaded['container.events'] = { emit = noop }
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function(config)
+    return config ~= nil and config.compose == true
+  end,
+  build_base_args = function(config)
+    return { 'compose', '-p', config.name }
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  get_stop_timeout = function()
+    return 10
+  end,
+  run_docker_command_async = noop,
+  run_docker_command = function(args)
+    error('synchronous docker ' .. table.concat(args, ' '))
+  end,
+  run_docker_commands_detached = function(commands, timeout_ms)
+    table.insert(detached_calls, { commands = commands, timeout_ms = timeout_ms })
+    return pending_stops
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  detached_calls, warnings, pending_stops = {}, {}, 0
+  default_action = 'none'
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Enter a buffer of a project and attach a running container to it
+local function attach(project, container_id, config)
+  buffer_name = '/projects/' .. project .. '/main.go'
+  container._sync_workspace()
+  config.name = project
+  container._restore_attached_container({ id = container_id, status = 'Up' }, config, nil)
+end
+
+-- Stop commands of the last shutdown as sorted "cwd: args" strings (workspaces are visited in any order)
+local function stopped()
+  local commands = {}
+  for _, command in ipairs(detached_calls[#detached_calls].commands) do
+    table.insert(commands, (command.cwd or '-') .. ': ' .. table.concat(command.args, ' '))
+  end
+  table.sort(commands)
+  return table.concat(commands, ' | ')
+end
+
+container.setup({})
+
+print('Running shutdown tests...')
+print()
+
+test('containers left running by shutdownAction none are not touched', function()
+  attach('b', 'ctr-b', { shutdown_action = 'none' })
+  attach('d', 'ctr-d', {})
+  container._shutdown_containers()
+  assert_equals(#detached_calls, 0, 'no docker calls')
+end)
+
+test('the containers of several workspaces are stopped together', function()
+  attach('a', 'ctr-a', { shutdown_action = 'stopContainer' })
+  attach('c', 'ctr-c', { shutdown_action = 'stopCompose', compose = true, compose_project_dir = '/projects/c/.dc' })
+  attach('e', 'ctr-e', { shutdown_action = 'stopContainer', ephemeral = true })
+  container._shutdown_containers()
+  assert_equals(#detached_calls, 1, 'one batch')
+  assert_equals(stopped(), '-: stop -t 10 ctr-a | /projects/c/.dc: compose -p c stop -t 10', 'stop commands')
+  assert_equals(detached_calls[1].timeout_ms, 12000, 'one stop timeout in total')
+end)
+
+test('the shutdown_action setting applies without shutdownAction', function()
+  default_action = 'stopContainer'
+  container._shutdown_containers()
+  assert_equals(
+    stopped(),
+    '-: stop -t 10 ctr-a | -: stop -t 10 ctr-d | /projects/c/.dc: compose -p c stop -t 10',
+    'workspace without shutdownAction stopped, explicit none kept'
+  )
+end)
+
+test('stops still running when the wait ends are reported', function()
+  pending_stops = 1
+  container._shutdown_containers()
+  assert_equals(warnings[1], '1 container(s) still stopping when Neovim exits', 'warning')
+end)
+
+print()
+print(string.format('=== Shutdown Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
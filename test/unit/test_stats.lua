@@ -0,0 +1,130 @@
+#!/usr/bin/env lua
+
+-- Test script for container.stats module
+-- Run with: lua test/unit/test_stats.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+-- docker stats lines decoded by the vim.json mock
+local stats_lines = {
+  ['app-line'] = {
+    ID = '0123456789abcdef',
+    Name = 'mono-app-1',
+    CPUPerc = '105.30%',
+    MemUsage = '1.8GiB / 2GiB',
+    MemPerc = '92.50%',
+    NetIO = '1.2MB / 3.4kB',
+    BlockIO = '0B / 0B',
+    PIDs = '42',
+  },
+  ['db-line'] = {
+    ID = 'fedcba9876543210',
+    Name = 'mono-db-1',
+    CPUPerc = '0.40%',
+    MemUsage = '80MiB / 15.5GiB',
+    MemPerc = '0.50%',
+    NetIO = '3.4kB / 1.2MB',
+    BlockIO = '4MB / 0B',
+    PIDs = '7',
+  },
+}
+
+_G.vim = {
+  json = {
+    decode = function(str)
+      if not stats_lines[str] then
+        error('invalid json')
+      end
+      return stats_lines[str]
+    end,
+  },
+  fn = {
+    strdisplaywidth = function(s)
+      return #s
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local stats = require('container.stats')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running stats tests...')
+print()
+
+local inspect_output = table.concat({
+  '0123456789abcdef0000|/mono-app-1|app|2147483648|1500000000|0|0|false',
+  'fedcba98765432100000|/mono-db-1|db|0|0|50000|100000|true',
+}, '\n')
+
+test('limits come from the host config of each container', function()
+  local limits = stats.parse_limits(inspect_output)
+  assert_equals(limits['0123456789ab'].name, 'app', 'compose service name')
+  assert_equals(limits['0123456789ab'].memory, 2147483648, 'memory limit')
+  assert_equals(limits['0123456789ab'].cpus, 1.5, '--cpus')
+  assert_equals(limits['fedcba987654'].memory, nil, 'no memory limit')
+  assert_equals(limits['fedcba987654'].cpus, 0.5, 'CPU quota')
+  assert_equals(limits['fedcba987654'].oom_killed, true, 'OOM-killed')
+
+  limits = stats.parse_limits('abcdef123456|/plain|<no value>|0|0|0|0|false')
+  assert_equals(limits['abcdef123456'].name, 'plain', 'container name without service')
+end)
+
+test('stats rows keep the used memory and its share', function()
+  local rows = stats.parse_stats('app-line\nnot json\ndb-line\n')
+  assert_equals(#rows, 2, 'invalid lines are skipped')
+  assert_equals(rows[1].id, '0123456789ab', 'short id')
+  assert_equals(rows[1].memory, '1.8GiB', 'used memory')
+  assert_equals(rows[1].memory_percent, 92.5, 'memory share')
+  assert_equals(rows[2].pids, '7', 'pids')
+end)
+
+test('containers near their memory limit are marked', function()
+  local lines = stats.render_lines(stats.parse_stats('app-line\ndb-line'), stats.parse_limits(inspect_output))
+  assert_equals(lines[1]:match('^CONTAINER') ~= nil, true, 'header')
+  assert_equals(lines[2]:find('2.0 GB', 1, true) ~= nil, true, 'configured memory limit')
+  assert_equals(lines[2]:find('92.5% !', 1, true) ~= nil, true, 'marked row')
+  assert_equals(lines[3]:find('none', 1, true) ~= nil, true, 'no memory limit')
+  assert_equals(lines[5], '! app is close to its memory limit', 'memory note')
+  assert_equals(lines[6], '! db was OOM-killed', 'OOM note')
+end)
+
+print()
+print(string.format('=== Stats Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
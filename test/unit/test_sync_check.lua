@@ -0,0 +1,155 @@
+#!/usr/bin/env lua
+
+-- Test script for container.sync_check module
+-- Run with: lua test/unit/test_sync_check.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local commands = {}
+local clock = 0
+local workspace = os.tmpname()
+os.remove(workspace)
+os.execute('mkdir -p ' .. workspace)
+
+_G.vim = {
+  uv = {
+    hrtime = function()
+      return clock * 1e6
+    end,
+  },
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  schedule = function(fn)
+    fn()
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.notify'] = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'sync_check' then
+      return { timeout = 1000, warn_latency = 200 }
+    end
+  end,
+}
+package.loaded['container.parser'] = {
+  workspace_roots = function()
+    return workspace, '/workspaces/app'
+  end,
+}
+package.loaded['container'] = {
+  get_container_id = function()
+    return 'abc123'
+  end,
+  get_state = function()
+    return { current_config = {} }
+  end,
+}
+
+local MOUNTS = table.concat({
+  'overlay / overlay rw,relatime 0 0',
+  'proc /proc proc rw,nosuid 0 0',
+  'grpcfuse /workspaces/app fuse.grpcfuse rw,nosuid 0 0',
+  '/dev/vda1 /workspaces/app/node\\040modules ext4 rw 0 0',
+}, '\n')
+
+local sentinel_seen = true
+package.loaded['container.docker'] = {
+  run_docker_command_async = function(args, _, callback)
+    table.insert(commands, args)
+    if args[3] == 'cat' then
+      clock = clock + 80
+      callback({ success = true, stdout = MOUNTS, stderr = '' })
+    else
+      clock = clock + 330
+      callback({ success = sentinel_seen, stdout = '', stderr = '' })
+    end
+  end,
+}
+
+local sync_check = require('container.sync_check')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  commands, clock, sentinel_seen = {}, 0, true
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running sync check tests...')
+print()
+
+test('the deepest mount holding the workspace is found', function()
+  local mount = sync_check.parse_mounts(MOUNTS, '/workspaces/app')
+  assert_equals(mount.type, 'fuse.grpcfuse', 'workspace mount')
+  assert_equals(sync_check.describe_mount(mount), 'fuse.grpcfuse, gRPC FUSE (Docker Desktop)', 'described')
+  assert(sync_check.hint(mount):find('VirtioFS', 1, true), 'Docker Desktop hint')
+
+  mount = sync_check.parse_mounts(MOUNTS, '/workspaces/app/node modules/x')
+  assert_equals(mount.target, '/workspaces/app/node modules', 'escaped mount point')
+  assert_equals(sync_check.parse_mounts(MOUNTS, '/workspaces/application').type, 'overlay', 'sibling path')
+  assert(sync_check.hint(mount):find('named volume', 1, true), 'volume hint')
+  assert_equals(sync_check.describe_mount(nil), 'unknown', 'not detected')
+end)
+
+test('the container waits for the sentinel within the timeout', function()
+  local args = sync_check.wait_command('/workspaces/app/.x', 1000)
+  assert_equals(args[1], 'sh', 'shell')
+  assert(args[3]:find('-gt 50 ]', 1, true), args[3])
+  assert_equals(args[5], '/workspaces/app/.x', 'path as argument')
+end)
+
+test('latency excludes the exec time and the sentinel is removed', function()
+  local result
+  sync_check.check(function(value)
+    result = value
+  end)
+  assert_equals(result.latency, 250, 'latency')
+  assert_equals(result.mount.type, 'fuse.grpcfuse', 'mount')
+  assert_equals(commands[2][1], 'exec', 'waits in the container')
+  assert(commands[2][7]:find('^/workspaces/app/%.container%-nvim%-sync%-'), commands[2][7])
+  assert_equals(io.open(result.host_path, 'r'), nil, 'sentinel removed')
+
+  sentinel_seen = false
+  sync_check.check(function(value)
+    result = value
+  end)
+  assert_equals(result.latency, nil, 'timed out')
+  assert_equals(result.timeout, 1000, 'timeout')
+end)
+
+os.execute('rmdir ' .. workspace)
+
+print()
+print(string.format('=== Sync Check Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
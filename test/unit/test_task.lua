@@ -0,0 +1,112 @@
+#!/usr/bin/env lua
+
+-- Test script for container.task module
+-- Run with: lua test/unit/test_task.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {}
+
+local tasks = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'tasks' then
+      return tasks
+    end
+  end,
+}
+
+local task = require('container.task')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  tasks = {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+print('Running task tests...')
+print()
+
+test('tasks are shell commands or tables', function()
+  tasks = { test = 'go test ./...', lint = { cmd = 'golangci-lint run', parser = 'go' }, broken = { cwd = 'x' } }
+  assert_equals(table.concat(task.names(), ','), 'broken,lint,test', 'sorted names')
+  assert_equals(task.get('test').cmd, 'go test ./...', 'string task')
+  assert_equals(task.get('lint').parser, 'go', 'table task')
+
+  local spec, err = task.get('broken')
+  assert_equals(spec, nil, 'no command')
+  assert(err:find('tasks.broken.cmd', 1, true), 'error names the setting: ' .. err)
+  spec, err = task.get('deploy')
+  assert_equals(err, 'Unknown task: deploy', 'unknown task')
+end)
+
+test('arguments are appended quoted', function()
+  assert_equals(task.build_command({ cmd = 'golangci-lint run' }, { '--fix' }), 'golangci-lint run --fix', 'plain')
+  assert_equals(task.build_command({ cmd = 'echo' }, { 'a b' }), "echo 'a b'", 'quoted')
+  assert_equals(task.build_command({ cmd = 'make' }), 'make', 'no arguments')
+end)
+
+test('parsers are looked up by name', function()
+  assert_equals(task.resolve_parser(nil), nil, 'no parser')
+  assert_equals(task.resolve_parser('go'), task.parsers.go, 'built-in')
+  local custom = { locations = { '^(%S+):(%d+)' } }
+  assert_equals(task.resolve_parser(custom), custom, 'patterns')
+  local patterns, err = task.resolve_parser('rust')
+  assert_equals(patterns, nil, 'unknown')
+  assert_equals(err, 'Unknown task parser: rust', 'error')
+end)
+
+test('the go parser maps linter output to host paths', function()
+  local ctx = { host_root = '/home/me/app', container_root = '/workspace', host_dir = '/home/me/app/api' }
+  local parser = require('container.test_runners').pattern_parser(ctx, task.parsers.go)
+  parser.feed('handler.go:12:3: Error return value is not checked (errcheck)')
+  parser.feed('level=warning msg="[runner] skipped"')
+  parser.feed('/workspace/pkg/db.go:40: unreachable code')
+  assert_equals(#parser.items, 2, 'items')
+  assert_equals(parser.items[1].filename, '/home/me/app/api/handler.go', 'relative path')
+  assert_equals(parser.items[1].col, 3, 'column')
+  assert_equals(parser.items[2].filename, '/home/me/app/pkg/db.go', 'container path')
+  assert_equals(parser.items[2].text, 'unreachable code', 'message without column')
+end)
+
+print()
+print(string.format('=== Task Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
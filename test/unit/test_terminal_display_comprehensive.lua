@@ -101,6 +101,11 @@ _G.vim = {
       -- Mock closing window
     end
   end,
+  fn = {
+    shellescape = function(str)
+      return "'" .. str:gsub("'", "'\\''") .. "'"
+    end,
+  },
   o = {
     columns = 120,
     lines = 40,
@@ -561,6 +566,27 @@ local function test_build_terminal_command()
   cmd = display.build_terminal_command('container101', '/bin/fish', {})
   assert_equal(cmd[#cmd], '/bin/fish', 'Custom shell should be used with empty environment')
 
+  -- Test with remoteUser and workspaceFolder
+  local original_shellescape = vim.fn.shellescape
+  vim.fn.shellescape = function(str)
+    return "'" .. str .. "'"
+  end
+  cmd = display.build_terminal_command('container202', '/bin/bash', {}, { user = 'vscode', workdir = '/workspace' })
+  local joined = table.concat(cmd, ' ')
+  assert_true(joined:find("-u 'vscode'", 1, true) ~= nil, 'remoteUser should be passed with -u')
+  assert_true(joined:find("-w '/workspace'", 1, true) ~= nil, 'workspaceFolder should be passed with -w')
+  assert_equal(cmd[#cmd - 1], 'container202', 'Container ID should follow exec options')
+  vim.fn.shellescape = original_shellescape
+
+  -- Login shell script: passwd lookup unless a shell is configured, existence check and fallback
+  local script = display.login_shell_script(nil, '/bin/sh')
+  assert_true(script:find("s=''", 1, true) ~= nil, 'No shell configured')
+  assert_true(script:find('getent passwd', 1, true) ~= nil, 'Login shell read from passwd')
+  assert_true(script:find("s='/bin/sh'", 1, true) ~= nil, 'Fallback shell')
+  assert_true(script:find('exec "$s" -l', 1, true) ~= nil, 'Started as a login shell')
+  script = display.login_shell_script("it's", nil)
+  assert_true(script:find([[s='it'\''s']], 1, true) ~= nil, 'Configured shell is quoted')
+
   print('✓ build_terminal_command tests passed')
 end
 
@@ -102,6 +102,16 @@ _G.vim = {
     end
     return false
   end,
+  deepcopy = function(obj)
+    if type(obj) ~= 'table' then
+      return obj
+    end
+    local copy = {}
+    for k, v in pairs(obj) do
+      copy[k] = vim.deepcopy(v)
+    end
+    return copy
+  end,
 }
 
 -- Mock package.loaded modules
@@ -129,6 +139,9 @@ local mock_session_manager = {
   generate_unique_name = function(base)
     return base .. '_unique'
   end,
+  find_session_for_container = function(container_id)
+    return nil
+  end,
   get_session = function(name)
     if name == 'existing' then
       return {
@@ -342,11 +355,21 @@ local mock_history = {
 
 package.loaded['container.terminal.history'] = mock_history
 
+-- Mock environment module (terminals get the workspace folder as working directory)
+package.loaded['container.environment'] = {
+  expand_path = function(path)
+    return path
+  end,
+}
+
 -- Mock container module
 package.loaded['container'] = {
   get_container_id = function()
     return 'container123'
   end,
+  get_state = function()
+    return {}
+  end,
 }
 
 -- Mock config module
@@ -479,6 +502,9 @@ package.loaded['container'] = {
   get_container_id = function()
     return 'container123'
   end,
+  get_state = function()
+    return {}
+  end,
 }
 
 -- Test session creation failure
@@ -0,0 +1,157 @@
+#!/usr/bin/env lua
+
+-- Test script for container.test_runners module
+-- Run with: lua test/unit/test_test_runners.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local plugin_config = { test_integration = { runners = {} } }
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  list_extend = function(dst, src)
+    for _, item in ipairs(src) do
+      table.insert(dst, item)
+    end
+    return dst
+  end,
+  tbl_contains = function(t, value)
+    for _, item in ipairs(t) do
+      if item == value then
+        return true
+      end
+    end
+    return false
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.utils.fs'] = {
+  resolve_path = function(path, base)
+    return base .. '/' .. path
+  end,
+}
+package.loaded['container.config'] = {
+  get = function()
+    return plugin_config
+  end,
+}
+package.loaded['container.test'] = {
+  map_path = function(path, from_root, to_root)
+    if path:sub(1, #from_root + 1) == from_root .. '/' then
+      return to_root .. path:sub(#from_root + 1)
+    end
+    return nil
+  end,
+}
+
+local runners = require('container.test_runners')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local ctx = { host_root = '/home/me/app', container_root = '/workspace', host_dir = '/home/me/app' }
+
+print('Running test runner registry tests...')
+print()
+
+test('command templates leave out arguments with missing placeholders', function()
+  local spec = { command = { 'rspec', '{file}:{test}', { '-e', '{test}' } } }
+  local all = runners.build_command(spec, { args = { '--fail-fast' } }, { file = 'spec/a_spec.rb' })
+  assert_equals(table.concat(all, ' '), 'rspec --fail-fast', 'suite run')
+  local nearest = runners.build_command(spec, { run = 'adds' }, { file = 'spec/a_spec.rb' })
+  assert_equals(table.concat(nearest, ' '), 'rspec spec/a_spec.rb:adds -e adds', 'nearest run')
+end)
+
+test('pytest runs a file node or the whole suite', function()
+  local python = runners.get_by_filetype('python')
+  local cmd = runners.build_command(python, { run = 'TestCalc::test_add' }, { file = 'tests/test_calc.py' })
+  assert_equals(cmd[#cmd], 'tests/test_calc.py::TestCalc::test_add', 'node id')
+  cmd = runners.build_command(python, {}, { file = 'tests/test_calc.py' })
+  assert_equals(cmd[#cmd], '-rfE', 'no node id')
+end)
+
+test('pytest failures become quickfix entries with host paths', function()
+  local parser = runners.get_by_filetype('python').parser(ctx)
+  parser.feed('/workspace/tests/test_calc.py:12: AssertionError: assert 1 == 2')
+  parser.feed('tests/test_util.py:3: ValueError')
+  parser.feed('=========== 2 failed, 5 passed, 1 skipped in 0.42s ===========')
+  assert_equals(#parser.items, 2, 'items')
+  assert_equals(parser.items[1].filename, '/home/me/app/tests/test_calc.py', 'container path mapped')
+  assert_equals(parser.items[1].lnum, 12, 'line')
+  assert_equals(parser.items[1].text, 'AssertionError: assert 1 == 2', 'message')
+  assert_equals(parser.items[2].filename, '/home/me/app/tests/test_util.py', 'relative path')
+  assert_equals(parser.counts.failed, 2, 'failed')
+  assert_equals(parser.counts.passed, 5, 'passed')
+  assert_equals(parser.counts.skipped, 1, 'skipped')
+  assert_equals(parser.failed, true, 'failed run')
+end)
+
+test('jest failures point at the first test file frame', function()
+  local parser = runners.get_by_filetype('typescript').parser(ctx)
+  parser.feed('  ● Calc › adds numbers')
+  parser.feed('      at Object.toBe (/workspace/node_modules/expect/build/index.js:10:3)')
+  parser.feed('      at Object.<anonymous> (/workspace/src/calc.test.ts:8:19)')
+  parser.feed('      at helper (/workspace/src/helper.ts:2:1)')
+  parser.feed('Tests:       1 failed, 3 passed, 4 total')
+  assert_equals(#parser.items, 1, 'one entry per test')
+  assert_equals(parser.items[1].filename, '/home/me/app/src/calc.test.ts', 'file')
+  assert_equals(parser.items[1].col, 19, 'column')
+  assert_equals(parser.items[1].text, 'Calc › adds numbers', 'title')
+  assert_equals(parser.counts.failed, 1, 'failed')
+  assert_equals(parser.counts.passed, 3, 'passed')
+end)
+
+test('nearest tests are found per language', function()
+  local python = runners.get_by_filetype('python')
+  local lines = { 'class TestCalc:', '    def test_add(self):', '        assert add(1, 2) == 3', 'def test_top():' }
+  assert_equals(python.nearest(lines, 3), 'TestCalc::test_add', 'method')
+  assert_equals(python.nearest(lines, 4), 'test_top', 'function')
+  local js = runners.get_by_filetype('javascript')
+  local spec = { "describe('Calc', () => {", "  it('adds numbers', () => {", '    expect(1)' }
+  assert_equals(js.nearest(spec, 3), 'adds numbers', 'it')
+  assert_equals(runners.escape_regex('adds (1+2)'), 'adds \\(1\\+2\\)', 'jest -t pattern')
+end)
+
+test('configured runners override the built-ins', function()
+  local custom = { name = 'unittest', command = { 'python', '-m', 'unittest' }, parser = function() end }
+  plugin_config.test_integration.runners.python = custom
+  assert_equals(runners.get_by_filetype('python'), custom, 'configured runner')
+  plugin_config.test_integration.runners.python = nil
+  assert_equals(runners.get_by_filetype('python').name, 'pytest', 'built-in')
+
+  runners.register_runner('ruby', { name = 'rspec', command = { 'rspec' }, parser = function() end })
+  assert_equals(runners.get_by_filetype('ruby').name, 'rspec', 'registered runner')
+  assert_equals(pcall(runners.register_runner, 'lua', { name = 'busted' }), false, 'spec without command')
+end)
+
+print()
+print(string.format('=== Test Runner Registry Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
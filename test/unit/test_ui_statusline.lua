@@ -1299,6 +1299,49 @@ local function test_config_missing_ui_section()
   print('✓ Missing ui section handled')
 end
 
+local function test_render_status_table()
+  print('Test: render status table')
+  reset_mocks()
+  _G.require = mock_require
+
+  assert_equals('', statusline.render({ state = 'none' }), 'No container should render empty')
+  assert_equals('✅ MyApp (running)', statusline.render({ state = 'running', name = 'MyApp' }), 'Running format')
+  assert_equals(
+    '✅ MyApp/web (running)',
+    statusline.render({ state = 'running', name = 'MyApp', service = 'web' }),
+    'Compose service should be appended'
+  )
+  assert_equals('🔨 Building MyApp', statusline.render({ state = 'building', name = 'MyApp' }), 'Building format')
+  print('✓ Status table rendering works')
+end
+
+local function test_get_status_while_building()
+  print('Test: get_status prefers building lifecycle state')
+  reset_mocks()
+  _G.require = function(module_name)
+    if module_name == 'container' then
+      return {
+        get_state = function()
+          return mock_container_state
+        end,
+        status = function()
+          return { state = 'building', name = 'MyApp' }
+        end,
+      }
+    end
+    return mock_require(module_name)
+  end
+
+  mock_container_state.initialized = true
+  mock_container_state.current_container = nil
+  statusline.clear_cache()
+
+  assert_equals('🔨 Building MyApp', statusline.get_status(), 'Building state should show before container exists')
+  _G.require = mock_require
+  statusline.clear_cache()
+  print('✓ Building state is shown')
+end
+
 local function test_config_missing_status_line_key()
   print('Test 44: config missing status_line key')
   reset_mocks()
@@ -1380,6 +1423,8 @@ local function run_tests()
     test_config_completely_missing,
     test_config_missing_ui_section,
     test_config_missing_status_line_key,
+    test_render_status_table,
+    test_get_status_while_building,
   }
 
   local passed = 0
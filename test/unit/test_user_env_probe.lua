@@ -0,0 +1,117 @@
+#!/usr/bin/env lua
+
+-- Test script for the userEnvProbe support of container.environment
+-- Run with: lua test/unit/test_user_env_probe.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local probe_stdout = ''
+
+_G.vim = {
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  json = {
+    decode = function()
+      return { 'PATH=/usr/bin', 'HOME=/home/vscode' }
+    end,
+  },
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+  tbl_count = function(t)
+    local count = 0
+    for _ in pairs(t) do
+      count = count + 1
+    end
+    return count
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+package.loaded['container.docker'] = {
+  run_docker_command = function(args)
+    if args[1] == 'inspect' then
+      return { success = true, stdout = '[]', stderr = '' }
+    end
+    return { success = true, stdout = probe_stdout, stderr = '' }
+  end,
+}
+
+local environment = require('container.environment')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local MARKER = 'CONTAINER_NVIM_ENV_PROBE'
+
+print('Running userEnvProbe tests...')
+print()
+
+test('the probe runs the shell of userEnvProbe as the remoteUser', function()
+  local args = environment.build_probe_args('abc', { user_env_probe = 'loginInteractiveShell', remote_user = 'vscode' })
+  assert_equals(table.concat(args, ' ', 1, 4), 'exec -u vscode abc', 'user')
+  assert_equals(args[#args - 1], '-lic', 'flags')
+  assert_equals(args[#args], MARKER, 'marker')
+
+  args = environment.build_probe_args('abc', { user_env_probe = 'loginShell' })
+  assert_equals(args[2], 'abc', 'default user')
+  assert_equals(args[#args - 1], '-lc', 'login shell')
+  assert_equals(environment.build_probe_args('abc', { user_env_probe = 'none' }), nil, 'disabled')
+end)
+
+test('only variables the shell adds or changes are kept', function()
+  local stdout = 'Welcome!\n'
+    .. MARKER
+    .. 'PATH=/home/vscode/.nvm/bin:/usr/bin\1HOME=/home/vscode\1SHLVL=2\1NVM_DIR=/home/vscode/.nvm\1'
+    .. MARKER
+    .. '\nbye\n'
+  local env = environment.parse_probe_output(stdout, { PATH = '/usr/bin', HOME = '/home/vscode' })
+  assert_equals(env.PATH, '/home/vscode/.nvm/bin:/usr/bin', 'changed')
+  assert_equals(env.NVM_DIR, '/home/vscode/.nvm', 'added')
+  assert_equals(env.HOME, nil, 'unchanged')
+  assert_equals(env.SHLVL, nil, 'shell variable')
+  assert_equals(environment.parse_probe_output('sh: not found', {}), nil, 'no output')
+end)
+
+test('the probed environment is cached in the configuration', function()
+  probe_stdout = MARKER .. 'PATH=/opt/tools:/usr/bin\1GOPATH=/home/vscode/go\1' .. MARKER
+  local config = { user_env_probe = 'loginInteractiveShell' }
+  environment.probe_user_env('abc', config)
+  assert_equals(config.user_env.PATH, '/opt/tools:/usr/bin', 'cached')
+
+  config.user_env_probe = 'none'
+  assert_equals(environment.probe_user_env('abc', config), nil, 'disabled probe')
+end)
+
+print()
+print(string.format('=== userEnvProbe Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
@@ -0,0 +1,255 @@
+#!/usr/bin/env lua
+
+-- Test script for container.watch module
+-- Run with: lua test/unit/test_watch.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local notifications = {}
+local rebuilds = 0
+local selection = nil
+local autocmds = {}
+local container_state = {}
+
+-- Timers fire when the test calls flush()
+local timer_callback = nil
+local timer = {
+  stop = function() end,
+  start = function(_, _, _, callback)
+    timer_callback = callback
+  end,
+}
+
+local function flush()
+  local callback = timer_callback
+  timer_callback = nil
+  if callback then
+    callback()
+  end
+end
+
+_G.vim = {
+  fn = {
+    fnamemodify = function(path, mods)
+      if mods == ':t' then
+        return path:match('([^/]*)$')
+      elseif mods == ':h' then
+        return path:match('^(.*)/[^/]*$')
+      end
+      return path
+    end,
+  },
+  api = {
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function(event, opts)
+      table.insert(autocmds, { event = event, callback = opts.callback })
+    end,
+    nvim_del_augroup_by_name = function() end,
+  },
+  uv = {
+    new_timer = function()
+      return timer
+    end,
+  },
+  schedule_wrap = function(fn)
+    return fn
+  end,
+  ui = {
+    select = function(items, opts, on_choice)
+      table.insert(notifications, 'prompt: ' .. opts.prompt)
+      on_choice(selection)
+    end,
+  },
+  tbl_contains = function(t, value)
+    for _, v in ipairs(t) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  list_extend = function(dst, src)
+    for _, v in ipairs(src) do
+      table.insert(dst, v)
+    end
+    return dst
+  end,
+}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.utils.notify'] = {
+  status = function(message)
+    table.insert(notifications, message)
+  end,
+  warn = function(message)
+    table.insert(notifications, 'warn: ' .. message)
+  end,
+}
+
+local settings = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    return settings[key]
+  end,
+}
+
+-- Local features are the ones starting with ./ or ../
+package.loaded['container.features'] = {
+  normalize = function(features, folder)
+    local normalized = {}
+    for ref in pairs(features or {}) do
+      if ref:match('^%./') then
+        table.insert(normalized, { kind = 'local', path = folder .. ref:sub(2) })
+      else
+        table.insert(normalized, { kind = 'oci' })
+      end
+    end
+    return normalized
+  end,
+}
+
+package.loaded['container'] = {
+  get_state = function()
+    return container_state
+  end,
+  rebuild_container = function()
+    rebuilds = rebuilds + 1
+  end,
+}
+
+local watch = require('container.watch')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  notifications = {}
+  rebuilds = 0
+  selection = nil
+  autocmds = {}
+  settings = {}
+  container_state = {}
+  watch.unwatch()
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local config = {
+  config_file = '/app/.devcontainer/devcontainer.json',
+  key_sources = {
+    image = { '/app/.devcontainer/base.json' },
+    features = { '/app/.devcontainer/base.json', '/app/.devcontainer/devcontainer.json' },
+  },
+  dockerfile = '/app/.devcontainer/Dockerfile',
+  compose_files = { '/app/compose.yml' },
+  features = { ['./tools'] = {}, ['ghcr.io/devcontainers/features/go:1'] = {} },
+  devcontainer_folder = '/app/.devcontainer',
+}
+
+-- Simulate a save through the BufWritePost autocmd
+local function save(path)
+  for _, autocmd in ipairs(autocmds) do
+    autocmd.callback({ file = path })
+  end
+end
+
+print('Running watch tests...')
+print()
+
+test('configuration, Dockerfile, compose files, feature lockfile and local features are watched', function()
+  local paths = watch.watched_paths(config)
+  assert_equals(
+    table.concat(paths.files, ' '),
+    table.concat({
+      '/app/.devcontainer/Dockerfile',
+      '/app/.devcontainer/base.json',
+      '/app/.devcontainer/devcontainer-lock.json',
+      '/app/.devcontainer/devcontainer.json',
+      '/app/compose.yml',
+    }, ' '),
+    'files'
+  )
+  assert_equals(table.concat(paths.dirs, ' '), '/app/.devcontainer/tools', 'local feature folder')
+
+  watch.watch(config)
+  assert_equals(watch.match('/app/.devcontainer/tools/install.sh')[1], config.config_file, 'file in feature')
+  assert_equals(watch.match('/app/.devcontainer/devcontainer-lock.json')[1], config.config_file, 'feature lockfile')
+  assert_equals(#watch.match('/app/.devcontainer/toolsx/install.sh'), 0, 'sibling folder')
+  assert_equals(#watch.match('/app/main.go'), 0, 'other file')
+end)
+
+test('saves are debounced into one notification', function()
+  container_state = { current_container = 'abc', current_config = config }
+  watch.watch(config)
+  save('/app/.devcontainer/Dockerfile')
+  save('/app/.devcontainer/Dockerfile')
+  save('/app/compose.yml')
+  save('/app/main.go')
+  assert_equals(#notifications, 0, 'nothing before the timer fires')
+  flush()
+  assert_equals(#notifications, 1, 'one notification')
+  assert_equals(
+    notifications[1],
+    'warn: Dockerfile, compose.yml changed: the container needs a rebuild (run :ContainerRebuild)',
+    'message'
+  )
+  assert_equals(rebuilds, 0, 'no rebuild')
+end)
+
+test('prompt and rebuild actions run :ContainerRebuild', function()
+  container_state = { current_container = 'abc', current_config = config }
+  watch.watch(config)
+
+  settings['watch_config.action'] = 'prompt'
+  selection = 'Later'
+  save('/app/.devcontainer/devcontainer.json')
+  flush()
+  assert_equals(notifications[1], 'prompt: devcontainer.json changed: the container needs a rebuild', 'prompt')
+  assert_equals(rebuilds, 0, 'declined')
+  selection = 'Rebuild now'
+  save('/app/.devcontainer/devcontainer.json')
+  flush()
+  assert_equals(rebuilds, 1, 'accepted')
+
+  settings['watch_config.action'] = 'rebuild'
+  save('/app/.devcontainer/Dockerfile')
+  flush()
+  assert_equals(rebuilds, 2, 'rebuilt right away')
+end)
+
+test('nothing happens without a container of the configuration', function()
+  watch.watch(config)
+  save('/app/.devcontainer/Dockerfile')
+  flush()
+  container_state = { current_container = 'abc', current_config = { config_file = '/other/devcontainer.json' } }
+  save('/app/.devcontainer/Dockerfile')
+  flush()
+  assert_equals(#notifications, 0, 'no notification')
+end)
+
+print()
+print(string.format('=== Watch Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
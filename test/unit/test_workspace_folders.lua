@@ -0,0 +1,107 @@
+#!/usr/bin/env lua
+
+-- Test script for container.workspace_folders module
+-- Run with: lua test/unit/test_workspace_folders.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+_G.vim = {}
+
+package.loaded['container.utils.log'] = {
+  debug = function(...) end,
+  info = function(...) end,
+  warn = function(...) end,
+  error = function(...) end,
+}
+
+package.loaded['container.parser'] = {
+  workspace_roots = function(config)
+    return config.base_path, config.workspace_folder
+  end,
+  expand_host_path = function(path)
+    return (path:gsub('^~', '/home/user'))
+  end,
+}
+
+local settings = {}
+package.loaded['container.config'] = {
+  get_value = function(key)
+    if key == 'workspace_folders' then
+      return settings
+    end
+  end,
+}
+
+local workspace_folders = require('container.workspace_folders')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  settings = {
+    { host = '~/mono/api/', container = '/workspaces/api' },
+    { host = '/srv/web', container = '/workspaces/web', name = 'frontend', readonly = true },
+    { host = '~/mono/api/vendor', container = '/opt/vendor' },
+  }
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+local config = { base_path = '/home/user/mono/core', workspace_folder = '/workspaces/core' }
+
+print('Running workspace folders tests...')
+print()
+
+test('folders are listed after the workspace with expanded host paths', function()
+  local folders = workspace_folders.list(config)
+  assert_equals(#folders, 4, 'count')
+  assert_equals(folders[1].name, 'core', 'workspace name')
+  assert_equals(folders[1].primary, true, 'workspace first')
+  assert_equals(folders[2].host, '/home/user/mono/api', 'expanded without trailing slash')
+  assert_equals(folders[2].name, 'api', 'name from the host folder')
+  assert_equals(folders[3].name, 'frontend', 'configured name')
+end)
+
+test('folders are mounted like additional_mounts', function()
+  local mounts = workspace_folders.mounts({ { source = '~/.ssh', target = '/home/vscode/.ssh' } })
+  assert_equals(#mounts, 4, 'count')
+  assert_equals(mounts[1].source, '~/.ssh', 'additional mounts first')
+  assert_equals(mounts[3].source, '/srv/web', 'folder source')
+  assert_equals(mounts[3].target, '/workspaces/web', 'folder target')
+  assert_equals(mounts[3].readonly, true, 'readonly')
+  assert_equals(#workspace_folders.mounts(nil, {}), 0, 'nothing configured')
+end)
+
+test('files belong to the deepest folder containing them', function()
+  assert_equals(workspace_folders.for_path(config, '/home/user/mono/api/cmd/main.go').name, 'api', 'folder')
+  assert_equals(workspace_folders.for_path(config, '/home/user/mono/api/vendor/x/y.go').name, 'vendor', 'nested')
+  assert_equals(workspace_folders.for_path(config, '/home/user/mono/apix/a.go').name, 'core', 'sibling folder')
+  assert_equals(workspace_folders.for_path(config, nil).name, 'core', 'no file')
+end)
+
+test('folders are found by name, host path or container path', function()
+  assert_equals(workspace_folders.find(config, 'frontend').host, '/srv/web', 'name')
+  assert_equals(workspace_folders.find(config, '/srv/web/').name, 'frontend', 'host path')
+  assert_equals(workspace_folders.find(config, '/workspaces/api').name, 'api', 'container path')
+  assert_equals(workspace_folders.find(config, 'core').primary, true, 'workspace')
+  assert_equals(workspace_folders.find(config, 'docs'), nil, 'unknown')
+end)
+
+print()
+print(string.format('=== Workspace Folders Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end
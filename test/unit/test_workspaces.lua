@@ -0,0 +1,220 @@
+#!/usr/bin/env lua
+
+-- Test script for the per-workspace state of container.init
+-- Run with: lua test/unit/test_workspaces.lua
+
+package.path = './lua/?.lua;./lua/?/init.lua;' .. package.path
+
+local buffer_name = '/projects/a/main.go'
+local stop_calls = {}
+local lsp_containers = {}
+
+_G.vim = {
+  fn = {
+    getcwd = function()
+      return '/'
+    end,
+    fnamemodify = function(path, mods)
+      if mods == ':p:h' then
+        return path:match('(.*)/[^/]*$')
+      end
+      return path
+    end,
+    isdirectory = function()
+      return 1
+    end,
+  },
+  api = {
+    nvim_buf_get_name = function()
+      return buffer_name
+    end,
+    nvim_create_augroup = function()
+      return 1
+    end,
+    nvim_create_autocmd = function() end,
+  },
+  schedule = function(fn)
+    fn()
+  end,
+  defer_fn = function() end,
+  cmd = function() end,
+  trim = function(s)
+    return (s:gsub('^%s+', ''):gsub('%s+$', ''))
+  end,
+  split = function(s)
+    return { s }
+  end,
+  tbl_keys = function(t)
+    local keys = {}
+    for k in pairs(t) do
+      table.insert(keys, k)
+    end
+    return keys
+  end,
+  list_extend = function(list, items)
+    for _, item in ipairs(items) do
+      table.insert(list, item)
+    end
+    return list
+  end,
+}
+
+local noop = function() end
+package.loaded['container.utils.log'] = { debug = noop, info = noop, warn = noop, error = noop }
+package.loaded['container.utils.notify'] = {
+  success = noop,
+  container = noop,
+  info = noop,
+  status = noop,
+  warn = noop,
+  error = noop,
+  critical = noop,
+  progress = noop,
+  clear_progress = noop,
+}
+package.loaded['container.config'] = {
+  setup = function()
+    return true
+  end,
+  get = function()
+    return { ui = {} }
+  end,
+  get_value = function() end,
+}
+package.loaded['container.terminal'] = { setup = noop }
+package.loaded['container.dap'] = { setup = noop }
+package.loaded['container.lsp.ftplugin_manager'] = { setup_autocmds = noop }
+package.loaded['container.format'] = { setup = noop }
+package.loaded['container.heartbeat'] = { setup = noop }
+package.loaded['container.lsp.init'] = {
+  setup = noop,
+  stop_all = noop,
+  switch_container = function(container_id)
+    table.insert(lsp_containers, container_id or 'none')
+  end,
+}
+package.loaded['container.events'] = { emit = noop }
+package.loaded['container.environment'] = { probe_user_env = noop }
+package.loaded['container.customizations'] = {
+  requests_lsp = function()
+    return false
+  end,
+}
+package.loaded['container.secrets'] = { clear = noop }
+package.loaded['container.env_file'] = { remove = noop }
+package.loaded['container.ui.statusline'] = { set_stopping_state = noop }
+package.loaded['container.docker.forward'] = { list = noop, stop_all = noop }
+package.loaded['container.docker.compose'] = {
+  is_compose_config = function()
+    return false
+  end,
+}
+package.loaded['container.lifecycle'] = {
+  run_pre_stop_command = function(_, _, callback)
+    callback(true)
+  end,
+}
+package.loaded['container.parser'] = {
+  find_workspace_root = function(dir)
+    return dir:match('^/projects/[^/]+')
+  end,
+}
+local docker = {
+  get_stop_timeout = function()
+    return 10
+  end,
+  run_docker_command_async = function() end,
+  stop_container_async = function(container_id, callback)
+    table.insert(stop_calls, { container_id = container_id, callback = callback })
+  end,
+}
+package.loaded['container.docker'] = docker
+package.loaded['container.docker.init'] = docker
+
+local container = require('container.init')
+
+local test_count = 0
+local passed_count = 0
+
+local function test(name, func)
+  test_count = test_count + 1
+  stop_calls, lsp_containers = {}, {}
+  local ok, err = pcall(func)
+  if ok then
+    passed_count = passed_count + 1
+    print('✓ ' .. name)
+  else
+    print('✗ ' .. name .. ': ' .. tostring(err))
+  end
+end
+
+local function assert_equals(actual, expected, message)
+  if actual ~= expected then
+    error(string.format('%s: expected %s, got %s', message or 'Assertion failed', tostring(expected), tostring(actual)))
+  end
+end
+
+-- Enter a buffer of a project (BufEnter)
+local function enter(project)
+  buffer_name = '/projects/' .. project .. '/main.go'
+  container._sync_workspace()
+end
+
+-- Attach a running container to the project of the current buffer
+local function attach(project, container_id)
+  enter(project)
+  container._restore_attached_container({ id = container_id, status = 'Up' }, { name = project }, nil)
+end
+
+print('Running workspace tests...')
+print()
+
+test('each project keeps its own container', function()
+  container.setup({})
+  attach('a', 'ctr-a')
+  attach('b', 'ctr-b')
+  assert_equals(container.get_container_id(), 'ctr-b', 'active project')
+  enter('a')
+  assert_equals(container.get_workspace_root(), '/projects/a', 'switched back')
+  assert_equals(container.get_container_id(), 'ctr-a', 'container of the first project')
+  assert_equals(#container.list_workspaces(), 2, 'workspaces')
+end)
+
+test('a stop finishing in the background leaves the active project alone', function()
+  container.setup({})
+  attach('a', 'ctr-a')
+  attach('b', 'ctr-b')
+  assert_equals(container.stop(), true, 'stop started')
+  assert_equals(stop_calls[1].container_id, 'ctr-b', 'stopped container')
+
+  enter('a')
+  lsp_containers = {}
+  stop_calls[1].callback(true)
+  assert_equals(container.get_workspace_root(), '/projects/a', 'active project')
+  assert_equals(container.get_container_id(), 'ctr-a', 'active container')
+  -- LSP followed the stopped project for the callback and came back
+  assert_equals(lsp_containers[1], 'ctr-b', 'LSP switched to the stopped container')
+  assert_equals(lsp_containers[#lsp_containers], 'ctr-a', 'LSP switched back')
+
+  enter('b')
+  assert_equals(container.get_container_id(), nil, 'container of the stopped project cleared')
+end)
+
+test('commands act on the project of the current buffer without BufEnter', function()
+  container.setup({})
+  attach('a', 'ctr-a')
+  attach('b', 'ctr-b')
+  -- The buffer changes without an autocmd switching the workspace (e.g. a window opened by a callback)
+  buffer_name = '/projects/a/main.go'
+  assert_equals(container.get_workspace_root(), '/projects/b', 'still the previous project')
+  container.stop()
+  assert_equals(stop_calls[1].container_id, 'ctr-a', 'stopped the container of the current buffer')
+  assert_equals(container.get_workspace_root(), '/projects/a', 'active project')
+end)
+
+print()
+print(string.format('=== Workspace Tests: %d/%d passed ===', passed_count, test_count))
+
+if passed_count ~= test_count then
+  os.exit(1)
+end